@@ -23,6 +23,7 @@ import (
 	"io"
 	stdlog "log"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,6 +41,7 @@ import (
 	"github.com/mysteriumnetwork/node/services/noop"
 	"github.com/mysteriumnetwork/node/services/openvpn"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn/service"
+	"github.com/mysteriumnetwork/node/services/proxy"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
 	"github.com/mysteriumnetwork/node/session/pingpong"
@@ -62,18 +64,30 @@ const serviceHelp = `service <action> [args]
 
 	example: service start 0x7d5ee3557775aed0b85d691b036769c17349db23 openvpn --openvpn.port=1194 --openvpn.proto=UDP`
 
+var flagToken = cli.StringFlag{
+	Name:    "token",
+	Usage:   "JWT or API token used to authenticate Tequilapi requests",
+	EnvVars: []string{"MYST_TEQUILAPI_TOKEN"},
+}
+
 // NewCommand constructs CLI based Mysterium UI with possibility to control quiting
 func NewCommand() *cli.Command {
 	return &cli.Command{
-		Name:   cliCommandName,
-		Usage:  "Starts a CLI client with a Tequilapi",
-		Before: clicontext.LoadUserConfigQuietly,
+		Name:        cliCommandName,
+		Usage:       "Starts a CLI client with a Tequilapi",
+		Flags:       []cli.Flag{&flagToken},
+		Before:      clicontext.LoadUserConfigQuietly,
+		Subcommands: newSubcommands(),
 		Action: func(ctx *cli.Context) error {
 			config.ParseFlagsNode(ctx)
 			nodeOptions := node.GetOptions()
+			tequilapiClient, err := cmd.NewTequilapiClient(*nodeOptions, ctx.String(flagToken.Name))
+			if err != nil {
+				return err
+			}
 			cmdCLI := &cliApp{
 				historyFile: filepath.Join(nodeOptions.Directories.Data, ".cli_history"),
-				tequilapi:   tequilapi_client.NewClient(nodeOptions.TequilapiAddress, nodeOptions.TequilapiPort),
+				tequilapi:   tequilapiClient,
 			}
 			cmd.RegisterSignalCallback(utils.SoftKiller(cmdCLI.Kill))
 
@@ -179,11 +193,13 @@ func (c *cliApp) handleActions(line string) {
 	}{
 		{"connect", c.connect},
 		{"identities", c.identities},
+		{"auth", c.auth},
 		{"payout", c.payout},
 		{"version", c.version},
 		{"license", c.license},
 		{"proposals", c.proposals},
 		{"service", c.service},
+		{"evidence", c.evidence},
 	}
 
 	for _, cmd := range staticCmds {
@@ -319,7 +335,7 @@ func (c *cliApp) serviceGet(id string) {
 func (c *cliApp) connect(argsString string) {
 	args := strings.Fields(argsString)
 
-	helpMsg := "Please type in the provider identity. connect <consumer-identity> <provider-identity> <service-type> [dns=auto|provider|system|1.1.1.1] [disable-kill-switch]"
+	helpMsg := "Please type in the provider identity. connect <consumer-identity> <provider-identity> <service-type> [dns=auto|provider|system|1.1.1.1|doh:<url>|dot:<host>] [disable-kill-switch] [proxy-port=<port>]"
 	if len(args) < 3 {
 		info(helpMsg)
 		return
@@ -329,11 +345,20 @@ func (c *cliApp) connect(argsString string) {
 
 	var disableKillSwitch bool
 	var dns connection.DNSOption
+	var proxyPort int
 	var err error
 	for _, arg := range args[3:] {
+		if strings.HasPrefix(arg, "proxy-port=") {
+			proxyPort, err = strconv.Atoi(strings.TrimPrefix(arg, "proxy-port="))
+			if err != nil || proxyPort <= 0 || proxyPort > 65535 {
+				warn("Invalid proxy port: ", arg)
+				info(helpMsg)
+				return
+			}
+			continue
+		}
 		if strings.HasPrefix(arg, "dns=") {
-			kv := strings.Split(arg, "=")
-			dns, err = connection.NewDNSOption(kv[1])
+			dns, err = connection.NewDNSOption(strings.TrimPrefix(arg, "dns="))
 			if err != nil {
 				warn("Invalid value: ", err)
 				info(helpMsg)
@@ -354,6 +379,7 @@ func (c *cliApp) connect(argsString string) {
 	connectOptions := contract.ConnectOptions{
 		DNS:               dns,
 		DisableKillSwitch: disableKillSwitch,
+		ProxyPort:         proxyPort,
 	}
 
 	if consumerID == "new" {
@@ -437,6 +463,9 @@ func (c *cliApp) status() {
 	} else {
 		info("Status:", status.Status)
 		info("SID:", status.SessionID)
+		if status.Proxy != nil {
+			info("Proxy:", proxyURL(status.Proxy))
+		}
 	}
 
 	ip, err := c.tequilapi.ConnectionIP()
@@ -507,35 +536,41 @@ func (c *cliApp) proposals(filter string) {
 	info(fmt.Sprintf("Found %v proposals %s", len(proposals), filterMsg))
 
 	for _, proposal := range proposals {
-		country := proposal.ServiceDefinition.LocationOriginate.Country
-		if country == "" {
-			country = "Unknown"
-		}
+		msg := fmt.Sprintf("- provider id: %v\ttype: %v\tcountry: %v\taccess policies: %v", proposal.ProviderID, proposal.ServiceType, proposalCountry(proposal), strings.Join(proposalPolicies(proposal), ","))
 
-		var policies []string
-		if proposal.AccessPolicies != nil {
-			for _, policy := range *proposal.AccessPolicies {
-				policies = append(policies, policy.ID)
-			}
+		if proposalMatches(proposal, filter) {
+			info(msg)
 		}
+	}
+}
 
-		msg := fmt.Sprintf("- provider id: %v\ttype: %v\tcountry: %v\taccess policies: %v", proposal.ProviderID, proposal.ServiceType, country, strings.Join(policies, ","))
-
-		if filter == "" ||
-			strings.Contains(proposal.ProviderID, filter) ||
-			strings.Contains(country, filter) {
+func proposalCountry(proposal contract.ProposalDTO) string {
+	country := proposal.ServiceDefinition.LocationOriginate.Country
+	if country == "" {
+		country = "Unknown"
+	}
+	return country
+}
 
-			info(msg)
+func proposalPolicies(proposal contract.ProposalDTO) []string {
+	var policies []string
+	if proposal.AccessPolicies != nil {
+		for _, policy := range *proposal.AccessPolicies {
+			policies = append(policies, policy.ID)
 		}
 	}
+	return policies
+}
+
+// proposalMatches tells if provider ID or country of the proposal contains the filter.
+func proposalMatches(proposal contract.ProposalDTO, filter string) bool {
+	return filter == "" ||
+		strings.Contains(proposal.ProviderID, filter) ||
+		strings.Contains(proposalCountry(proposal), filter)
 }
 
 func (c *cliApp) fetchProposals() []contract.ProposalDTO {
-	upperTimeBound := config.GetUInt64(config.FlagPaymentsConsumerPricePerMinuteUpperBound)
-	lowerTimeBound := config.GetUInt64(config.FlagPaymentsConsumerPricePerMinuteLowerBound)
-	upperGBBound := config.GetUInt64(config.FlagPaymentsConsumerPricePerGBUpperBound)
-	lowerGBBound := config.GetUInt64(config.FlagPaymentsConsumerPricePerGBLowerBound)
-	proposals, err := c.tequilapi.ProposalsByPrice(lowerTimeBound, upperTimeBound, lowerGBBound, upperGBBound)
+	proposals, err := proposalsByConfiguredPrice(c.tequilapi)
 	if err != nil {
 		warn(err)
 		return []contract.ProposalDTO{}
@@ -543,6 +578,15 @@ func (c *cliApp) fetchProposals() []contract.ProposalDTO {
 	return proposals
 }
 
+// proposalsByConfiguredPrice fetches proposals within the consumer price bounds of the configuration.
+func proposalsByConfiguredPrice(tequilapi *tequilapi_client.Client) ([]contract.ProposalDTO, error) {
+	upperTimeBound := config.GetUInt64(config.FlagPaymentsConsumerPricePerMinuteUpperBound)
+	lowerTimeBound := config.GetUInt64(config.FlagPaymentsConsumerPricePerMinuteLowerBound)
+	upperGBBound := config.GetUInt64(config.FlagPaymentsConsumerPricePerGBUpperBound)
+	lowerGBBound := config.GetUInt64(config.FlagPaymentsConsumerPricePerGBLowerBound)
+	return tequilapi.ProposalsByPrice(lowerTimeBound, upperTimeBound, lowerGBBound, upperGBBound)
+}
+
 func (c *cliApp) location() {
 	location, err := c.tequilapi.OriginLocation()
 	if err != nil {
@@ -618,6 +662,9 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []contract.P
 		readline.PcItem("dns=provider"),
 		readline.PcItem("dns=system"),
 		readline.PcItem("dns=1.1.1.1"),
+		readline.PcItem("dns=doh:https://cloudflare-dns.com/dns-query"),
+		readline.PcItem("dns=dot:dns.quad9.net"),
+		readline.PcItem("proxy-port=1080"),
 	}
 	return readline.NewPrefixCompleter(
 		readline.PcItem(
@@ -629,6 +676,7 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []contract.P
 					readline.PcItem("noop", connectOpts...),
 					readline.PcItem("openvpn", connectOpts...),
 					readline.PcItem("wireguard", connectOpts...),
+					readline.PcItem("proxy", connectOpts...),
 				),
 			),
 		),
@@ -639,6 +687,7 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []contract.P
 				readline.PcItem("noop"),
 				readline.PcItem("openvpn"),
 				readline.PcItem("wireguard"),
+				readline.PcItem("proxy"),
 			)),
 			readline.PcItem("stop"),
 			readline.PcItem("list"),
@@ -654,6 +703,21 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []contract.P
 			readline.PcItem("register", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
 			readline.PcItem("topup", readline.PcItemDynamic(getIdentityOptionList(tequilapi))),
 		),
+		readline.PcItem(
+			"auth",
+			readline.PcItem("users"),
+			readline.PcItem("user-add"),
+			readline.PcItem("user-delete"),
+			readline.PcItem("tokens"),
+			readline.PcItem("token-create"),
+			readline.PcItem("token-revoke"),
+		),
+		readline.PcItem(
+			"evidence",
+			readline.PcItem("list"),
+			readline.PcItem("export"),
+			readline.PcItem("verify"),
+		),
 		readline.PcItem("status"),
 		readline.PcItem("healthcheck"),
 		readline.PcItem("nat"),
@@ -676,13 +740,8 @@ func newAutocompleter(tequilapi *tequilapi_client.Client, proposals []contract.P
 }
 
 func parseStartFlags(serviceType string, args ...string) (service.Options, config.ServicesOptions, contract.PaymentMethodDTO, error) {
-	var flags []cli.Flag
-	config.RegisterFlagsServiceShared(&flags)
-	config.RegisterFlagsServiceOpenvpn(&flags)
-	config.RegisterFlagsServiceWireguard(&flags)
-
 	set := flag.NewFlagSet("", flag.ContinueOnError)
-	for _, f := range flags {
+	for _, f := range serviceStartFlags() {
 		f.Apply(set)
 	}
 
@@ -690,8 +749,20 @@ func parseStartFlags(serviceType string, args ...string) (service.Options, confi
 		return nil, config.ServicesOptions{}, contract.PaymentMethodDTO{}, err
 	}
 
-	ctx := cli.NewContext(nil, set, nil)
+	return parseStartOptions(cli.NewContext(nil, set, nil), serviceType)
+}
+
+// serviceStartFlags returns flags configuring services started from CLI.
+func serviceStartFlags() []cli.Flag {
+	var flags []cli.Flag
+	config.RegisterFlagsServiceShared(&flags)
+	config.RegisterFlagsServiceOpenvpn(&flags)
+	config.RegisterFlagsServiceWireguard(&flags)
+	config.RegisterFlagsServiceProxy(&flags)
+	return flags
+}
 
+func parseStartOptions(ctx *cli.Context, serviceType string) (service.Options, config.ServicesOptions, contract.PaymentMethodDTO, error) {
 	config.ParseFlagsServiceShared(ctx)
 	switch serviceType {
 	case noop.ServiceType:
@@ -714,6 +785,13 @@ func parseStartFlags(serviceType string, args ...string) (service.Options, confi
 			config.GetFloat64(config.FlagOpenVPNPriceMinute),
 		))
 		return openvpn_service.GetOptions(), services.SharedConfiguredOptions(), payment, nil
+	case proxy.ServiceType:
+		config.ParseFlagsServiceProxy(ctx)
+		payment := contract.NewPaymentMethodDTO(pingpong.NewPaymentMethod(
+			config.GetFloat64(config.FlagProxyPriceGB),
+			config.GetFloat64(config.FlagProxyPriceMinute),
+		))
+		return proxy.ParseFlags(ctx), services.SharedConfiguredOptions(), payment, nil
 	}
 
 	return nil, config.ServicesOptions{}, contract.PaymentMethodDTO{}, errors.New("service type not found")
@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (c *cliApp) auth(argsString string) {
+	var usage = strings.Join([]string{
+		"Usage: auth <action> [args]",
+		"Available actions:",
+		"  " + usageListUsers,
+		"  " + usageAddUser,
+		"  " + usageDeleteUser,
+		"  " + usageListTokens,
+		"  " + usageCreateToken,
+		"  " + usageRevokeToken,
+		"Scopes: admin, read, connect, services, payments",
+	}, "\n")
+
+	if len(argsString) == 0 {
+		info(usage)
+		return
+	}
+
+	args := strings.Fields(argsString)
+	action := args[0]
+	actionArgs := args[1:]
+
+	switch action {
+	case "users":
+		c.listUsers(actionArgs)
+	case "user-add":
+		c.addUser(actionArgs)
+	case "user-delete":
+		c.deleteUser(actionArgs)
+	case "tokens":
+		c.listTokens(actionArgs)
+	case "token-create":
+		c.createToken(actionArgs)
+	case "token-revoke":
+		c.revokeToken(actionArgs)
+	default:
+		warnf("Unknown sub-command '%s'\n", argsString)
+		fmt.Println(usage)
+	}
+}
+
+const usageListUsers = "users"
+
+func (c *cliApp) listUsers(args []string) {
+	if len(args) > 0 {
+		info("Usage: " + usageListUsers)
+		return
+	}
+	users, err := c.tequilapi.AuthUsers()
+	if err != nil {
+		warn(err)
+		return
+	}
+
+	for _, user := range users.Users {
+		status(user.Username, "Scopes: "+strings.Join(user.Scopes, ","))
+	}
+}
+
+const usageAddUser = "user-add <username> <password> <scope>[,<scope>...]"
+
+func (c *cliApp) addUser(args []string) {
+	if len(args) != 3 {
+		info("Usage: " + usageAddUser)
+		return
+	}
+	user, err := c.tequilapi.AuthCreateUser(args[0], args[1], strings.Split(args[2], ","))
+	if err != nil {
+		warn(err)
+		return
+	}
+	success("User created:", user.Username)
+}
+
+const usageDeleteUser = "user-delete <username>"
+
+func (c *cliApp) deleteUser(args []string) {
+	if len(args) != 1 {
+		info("Usage: " + usageDeleteUser)
+		return
+	}
+	if err := c.tequilapi.AuthDeleteUser(args[0]); err != nil {
+		warn(err)
+		return
+	}
+	success("User deleted:", args[0])
+}
+
+const usageListTokens = "tokens"
+
+func (c *cliApp) listTokens(args []string) {
+	if len(args) > 0 {
+		info("Usage: " + usageListTokens)
+		return
+	}
+	tokens, err := c.tequilapi.AuthTokens()
+	if err != nil {
+		warn(err)
+		return
+	}
+
+	for _, token := range tokens.Tokens {
+		status(token.ID, "Name: "+token.Name, "Scopes: "+strings.Join(token.Scopes, ","), "Created: "+token.CreatedAt)
+	}
+}
+
+const usageCreateToken = "token-create <name> <scope>[,<scope>...]"
+
+func (c *cliApp) createToken(args []string) {
+	if len(args) != 2 {
+		info("Usage: " + usageCreateToken)
+		return
+	}
+	token, err := c.tequilapi.AuthCreateToken(args[0], strings.Split(args[1], ","))
+	if err != nil {
+		warn(err)
+		return
+	}
+	success("API token created:", token.ID)
+	info("Token (it will not be shown again):", token.Token)
+}
+
+const usageRevokeToken = "token-revoke <id>"
+
+func (c *cliApp) revokeToken(args []string) {
+	if len(args) != 1 {
+		info("Usage: " + usageRevokeToken)
+		return
+	}
+	if err := c.tequilapi.AuthRevokeToken(args[0]); err != nil {
+		warn(err)
+		return
+	}
+	success("API token revoked:", args[0])
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/session/pingpong"
+)
+
+func (c *cliApp) evidence(argsString string) {
+	var usage = strings.Join([]string{
+		"Usage: evidence <action> [args]",
+		"Available actions:",
+		"  " + usageListEvidence,
+		"  " + usageExportEvidence,
+		"  " + usageVerifyEvidence,
+	}, "\n")
+
+	if len(argsString) == 0 {
+		info(usage)
+		return
+	}
+
+	args := strings.Fields(argsString)
+	action := args[0]
+	actionArgs := args[1:]
+
+	switch action {
+	case "list":
+		c.listEvidence(actionArgs)
+	case "export":
+		c.exportEvidence(actionArgs)
+	case "verify":
+		c.verifyEvidence(actionArgs)
+	default:
+		warnf("Unknown sub-command '%s'\n", argsString)
+		fmt.Println(usage)
+	}
+}
+
+const usageListEvidence = "list"
+
+func (c *cliApp) listEvidence(args []string) {
+	if len(args) > 0 {
+		info("Usage: " + usageListEvidence)
+		return
+	}
+	sessions, err := c.tequilapi.PaymentEvidenceSessions()
+	if err != nil {
+		warn(err)
+		return
+	}
+
+	for _, session := range sessions.Sessions {
+		status(session.SessionID, "Role: "+session.Role, "Consumer: "+session.ConsumerID, "Provider: "+session.ProviderID, "Started: "+session.Started, "Error: "+session.Error)
+	}
+}
+
+const usageExportEvidence = "export <session-id> <file>"
+
+func (c *cliApp) exportEvidence(args []string) {
+	if len(args) != 2 {
+		info("Usage: " + usageExportEvidence)
+		return
+	}
+	bundle, err := c.tequilapi.PaymentEvidence(args[0])
+	if err != nil {
+		warn(err)
+		return
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, bundle, "", "  "); err != nil {
+		warn(err)
+		return
+	}
+	if err := ioutil.WriteFile(args[1], out.Bytes(), 0600); err != nil {
+		warn(err)
+		return
+	}
+	success("Payment evidence exported to:", args[1])
+}
+
+const usageVerifyEvidence = "verify <file>"
+
+// verifyEvidence replays the bundle locally, so that evidence exported by any node could be checked without trusting it.
+func (c *cliApp) verifyEvidence(args []string) {
+	if len(args) != 1 {
+		info("Usage: " + usageVerifyEvidence)
+		return
+	}
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		warn(err)
+		return
+	}
+	var bundle pingpong.EvidenceBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		warn("Could not parse evidence bundle:", err)
+		return
+	}
+
+	verdict := bundle.Replay()
+	if verdict.SignatureValid {
+		success("Signed by:", bundle.Signer, "("+string(bundle.Session.Role)+")")
+	} else {
+		warn("Bundle signature is not valid")
+	}
+	for _, finding := range verdict.Findings {
+		status(fmt.Sprintf("Record %v", finding.RecordID), "Party: "+string(finding.Party), finding.Message)
+	}
+	if verdict.Deviator == "" {
+		success("No deviation from the payment method found")
+		return
+	}
+	warn("Deviated from the payment method:", verdict.Deviator)
+}
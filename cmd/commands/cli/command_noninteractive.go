@@ -0,0 +1,532 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mysteriumnetwork/node/cmd"
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/money"
+	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	flagConnectDNS = cli.StringFlag{
+		Name:  "dns",
+		Usage: "DNS to use in the tunnel: auto, provider, system, comma separated IPs, doh:<url> or dot:<host>",
+		Value: string(connection.DNSOptionAuto),
+	}
+	flagConnectDisableKillSwitch = cli.BoolFlag{
+		Name:  "disable-kill-switch",
+		Usage: "Allow traffic outside of the tunnel while connecting",
+	}
+	flagConnectProxyPort = cli.IntFlag{
+		Name:  "proxy-port",
+		Usage: "Expose the tunnel only as a local SOCKS5/HTTP proxy on this port instead of a system-wide tunnel",
+	}
+	flagProposalsFilter = cli.StringFlag{
+		Name:  "filter",
+		Usage: "Show proposals whose provider ID or country contains the value",
+	}
+	flagProposalsServiceType = cli.StringFlag{
+		Name:  "service-type",
+		Usage: "Show proposals of the service type only",
+	}
+	flagPassphraseFile = cli.StringFlag{
+		Name:  "passphrase-file",
+		Usage: "Read the identity passphrase from the file, - reads it from stdin. Defaults to " + envPassphrase + " environment variable",
+	}
+)
+
+// envPassphrase holds the identity passphrase, so it doesn't show up in process list and shell history.
+const envPassphrase = "MYST_IDENTITY_PASSPHRASE"
+
+// clientAction executes a single action against Tequilapi of the running node.
+type clientAction func(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error
+
+func withClient(action clientAction) cli.ActionFunc {
+	return func(ctx *cli.Context) error {
+		out, err := newPrinter(ctx.App.Writer, ctx.String(flagOutput.Name))
+		if err != nil {
+			return err
+		}
+
+		config.ParseFlagsNode(ctx)
+		client, err := cmd.NewTequilapiClient(*node.GetOptions(), ctx.String(flagToken.Name))
+		if err != nil {
+			return failure(err, "could not create Tequilapi client")
+		}
+		return action(ctx, client, out)
+	}
+}
+
+// newSubcommands creates commands executing a single action, so that the node can be scripted.
+func newSubcommands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "connect",
+			Usage:     "Connects to the provider and waits until the connection is established",
+			ArgsUsage: "<consumer-identity> <provider-identity> <service-type>",
+			Flags:     []cli.Flag{&flagOutput, &flagConnectDNS, &flagConnectDisableKillSwitch, &flagConnectProxyPort},
+			Action:    withClient(connectAction),
+		},
+		{
+			Name:   "disconnect",
+			Usage:  "Disconnects from the provider",
+			Flags:  []cli.Flag{&flagOutput},
+			Action: withClient(disconnectAction),
+		},
+		{
+			Name:   "status",
+			Usage:  fmt.Sprintf("Shows connection status, exits with code %d if not connected", exitCodeNotConnected),
+			Flags:  []cli.Flag{&flagOutput},
+			Action: withClient(statusAction),
+		},
+		{
+			Name:   "proposals",
+			Usage:  "Lists service proposals within the configured price bounds",
+			Flags:  []cli.Flag{&flagOutput, &flagProposalsFilter, &flagProposalsServiceType},
+			Action: withClient(proposalsAction),
+		},
+		{
+			Name:  "service",
+			Usage: "Manages services provided by the node",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "start",
+					Usage:     "Starts the service",
+					ArgsUsage: "<provider-identity> <service-type>",
+					Flags:     append([]cli.Flag{&flagOutput}, serviceStartFlags()...),
+					Action:    withClient(serviceStartAction),
+				},
+				{
+					Name:      "stop",
+					Usage:     "Stops the service",
+					ArgsUsage: "<service-id>",
+					Flags:     []cli.Flag{&flagOutput},
+					Action:    withClient(serviceStopAction),
+				},
+				{
+					Name:   "list",
+					Usage:  "Lists running services",
+					Flags:  []cli.Flag{&flagOutput},
+					Action: withClient(serviceListAction),
+				},
+				{
+					Name:      "status",
+					Usage:     "Shows the service",
+					ArgsUsage: "<service-id>",
+					Flags:     []cli.Flag{&flagOutput},
+					Action:    withClient(serviceStatusAction),
+				},
+				{
+					Name:   "sessions",
+					Usage:  "Lists sessions of the running services",
+					Flags:  []cli.Flag{&flagOutput},
+					Action: withClient(serviceSessionsAction),
+				},
+			},
+		},
+		{
+			Name:  "identities",
+			Usage: "Manages identities of the node",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "list",
+					Usage:  "Lists identities",
+					Flags:  []cli.Flag{&flagOutput},
+					Action: withClient(identitiesListAction),
+				},
+				{
+					Name:      "get",
+					Usage:     "Shows the identity",
+					ArgsUsage: "<identity>",
+					Flags:     []cli.Flag{&flagOutput},
+					Action:    withClient(identitiesGetAction),
+				},
+				{
+					Name:   "new",
+					Usage:  "Creates a new identity",
+					Flags:  []cli.Flag{&flagOutput, &flagPassphraseFile},
+					Action: withClient(identitiesNewAction),
+				},
+				{
+					Name:      "unlock",
+					Usage:     "Unlocks the identity",
+					ArgsUsage: "<identity>",
+					Flags:     []cli.Flag{&flagOutput, &flagPassphraseFile},
+					Action:    withClient(identitiesUnlockAction),
+				},
+			},
+		},
+		{
+			Name:   "watch",
+			Usage:  "Streams node state events until interrupted",
+			Flags:  []cli.Flag{&flagOutput},
+			Action: withClient(watchAction),
+		},
+	}
+}
+
+func connectAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	if ctx.NArg() != 3 {
+		return usageError("usage: connect [options] <consumer-identity> <provider-identity> <service-type>")
+	}
+	dns, err := connection.NewDNSOption(ctx.String(flagConnectDNS.Name))
+	if err != nil {
+		return usageError("invalid DNS option: %v", err)
+	}
+
+	args := ctx.Args()
+	status, err := client.ConnectionCreate(args.Get(0), args.Get(1), config.GetString(config.FlagAccountantID), args.Get(2), contract.ConnectOptions{
+		DNS:               dns,
+		DisableKillSwitch: ctx.Bool(flagConnectDisableKillSwitch.Name),
+		ProxyPort:         ctx.Int(flagConnectProxyPort.Name),
+	})
+	if err != nil {
+		return failure(err, "could not connect")
+	}
+	return printConnectionStatus(out, status)
+}
+
+func disconnectAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	if err := client.ConnectionDestroy(); err != nil {
+		return failure(err, "could not disconnect")
+	}
+	status, err := client.ConnectionStatus()
+	if err != nil {
+		return failure(err, "could not get connection status")
+	}
+	return printConnectionStatus(out, status)
+}
+
+func printConnectionStatus(out *printer, status contract.ConnectionStatusDTO) error {
+	fields := [][2]string{
+		{"Status", status.Status},
+		{"Session", status.SessionID},
+		{"Consumer", status.ConsumerID},
+	}
+	if status.Proxy != nil {
+		fields = append(fields, [2]string{"Proxy", proxyURL(status.Proxy)})
+	}
+	return out.printFields(status, fields)
+}
+
+// proxyURL formats the local proxy address with its credentials, as accepted by ALL_PROXY and curl --proxy.
+func proxyURL(proxy *contract.LocalProxyDTO) string {
+	u := url.URL{
+		Scheme: "socks5h",
+		User:   url.UserPassword(proxy.Username, proxy.Password),
+		Host:   net.JoinHostPort("127.0.0.1", strconv.Itoa(proxy.Port)),
+	}
+	return u.String()
+}
+
+type statusOutput struct {
+	contract.ConnectionStatusDTO
+	IP         string                            `json:"ip,omitempty"`
+	Statistics *contract.ConnectionStatisticsDTO `json:"statistics,omitempty"`
+}
+
+func statusAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	status, err := client.ConnectionStatus()
+	if err != nil {
+		return failure(err, "could not get connection status")
+	}
+	result := statusOutput{ConnectionStatusDTO: status}
+	fields := [][2]string{
+		{"Status", status.Status},
+		{"Session", status.SessionID},
+	}
+	if status.Proxy != nil {
+		fields = append(fields, [2]string{"Proxy", proxyURL(status.Proxy)})
+	}
+
+	if status.Status == statusConnected {
+		if ip, err := client.ConnectionIP(); err == nil {
+			result.IP = ip
+		}
+		statistics, err := client.ConnectionStatistics()
+		if err != nil {
+			return failure(err, "could not get connection statistics")
+		}
+		result.Statistics = &statistics
+
+		fields = append(fields,
+			[2]string{"Provider", status.Proposal.ProviderID},
+			[2]string{"Service", status.Proposal.ServiceType},
+			[2]string{"IP", result.IP},
+			[2]string{"Duration", (time.Duration(statistics.Duration) * time.Second).String()},
+			[2]string{"Data", fmt.Sprintf("%s/%s", datasize.FromBytes(statistics.BytesReceived), datasize.FromBytes(statistics.BytesSent))},
+			[2]string{"Spent", money.NewMoney(statistics.TokensSpent, money.CurrencyMyst).String()},
+		)
+	}
+
+	if err := out.printFields(result, fields); err != nil {
+		return err
+	}
+	if status.Status != statusConnected {
+		return cli.Exit("", exitCodeNotConnected)
+	}
+	return nil
+}
+
+func proposalsAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	proposals, err := proposalsByConfiguredPrice(client)
+	if err != nil {
+		return failure(err, "could not get proposals")
+	}
+
+	filter, serviceType := ctx.String(flagProposalsFilter.Name), ctx.String(flagProposalsServiceType.Name)
+	matching := make([]contract.ProposalDTO, 0, len(proposals))
+	rows := make([][]string, 0, len(proposals))
+	for _, proposal := range proposals {
+		if !proposalMatches(proposal, filter) || (serviceType != "" && proposal.ServiceType != serviceType) {
+			continue
+		}
+		matching = append(matching, proposal)
+		rows = append(rows, []string{proposal.ProviderID, proposal.ServiceType, proposalCountry(proposal), strings.Join(proposalPolicies(proposal), ",")})
+	}
+	return out.print(matching, []string{"PROVIDER", "TYPE", "COUNTRY", "ACCESS POLICIES"}, rows)
+}
+
+func serviceStartAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	if ctx.NArg() != 2 {
+		return usageError("usage: service start [options] <provider-identity> <service-type>")
+	}
+	providerID, serviceType := ctx.Args().Get(0), ctx.Args().Get(1)
+
+	options, sharedOptions, paymentMethod, err := parseStartOptions(ctx, serviceType)
+	if err != nil {
+		return usageError("invalid service options: %v", err)
+	}
+	service, err := client.ServiceStart(providerID, serviceType, options, tequilapi_client.AccessPoliciesRequest{IDs: sharedOptions.AccessPolicyList}, paymentMethod)
+	if err != nil {
+		return failure(err, "could not start service")
+	}
+	return printService(out, service)
+}
+
+func serviceStopAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	if ctx.NArg() != 1 {
+		return usageError("usage: service stop <service-id>")
+	}
+	id := ctx.Args().First()
+	if err := client.ServiceStop(id); err != nil {
+		return failure(err, "could not stop service")
+	}
+	return out.printFields(map[string]string{"id": id, "status": "Stopping"}, [][2]string{
+		{"ID", id},
+		{"Status", "Stopping"},
+	})
+}
+
+func serviceListAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	services, err := client.Services()
+	if err != nil {
+		return failure(err, "could not get services")
+	}
+	if services == nil {
+		services = tequilapi_client.ServiceListDTO{}
+	}
+
+	rows := make([][]string, 0, len(services))
+	for _, service := range services {
+		rows = append(rows, []string{service.ID, service.Proposal.ServiceType, service.Status, service.Proposal.ProviderID})
+	}
+	return out.print(services, []string{"ID", "TYPE", "STATUS", "PROVIDER"}, rows)
+}
+
+func serviceStatusAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	if ctx.NArg() != 1 {
+		return usageError("usage: service status <service-id>")
+	}
+	service, err := client.Service(ctx.Args().First())
+	if err != nil {
+		return failure(err, "could not get service")
+	}
+	return printService(out, service)
+}
+
+func printService(out *printer, service tequilapi_client.ServiceInfoDTO) error {
+	return out.printFields(service, [][2]string{
+		{"ID", service.ID},
+		{"Type", service.Proposal.ServiceType},
+		{"Status", service.Status},
+		{"Provider", service.Proposal.ProviderID},
+	})
+}
+
+func serviceSessionsAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	sessions, err := client.ServiceSessions()
+	if err != nil {
+		return failure(err, "could not get service sessions")
+	}
+
+	rows := make([][]string, 0, len(sessions.Sessions))
+	for _, session := range sessions.Sessions {
+		rows = append(rows, []string{session.ID, session.ConsumerID})
+	}
+	return out.print(sessions, []string{"ID", "CONSUMER"}, rows)
+}
+
+func identitiesListAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	ids, err := client.GetIdentities()
+	if err != nil {
+		return failure(err, "could not get identities")
+	}
+
+	rows := make([][]string, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, []string{id.Address})
+	}
+	return out.print(ids, []string{"IDENTITY"}, rows)
+}
+
+func identitiesGetAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	if ctx.NArg() != 1 {
+		return usageError("usage: identities get <identity>")
+	}
+	identity, err := client.Identity(ctx.Args().First())
+	if err != nil {
+		return failure(err, "could not get identity")
+	}
+	return out.printFields(identity, [][2]string{
+		{"Identity", identity.Address},
+		{"Registration status", identity.RegistrationStatus},
+		{"Channel address", identity.ChannelAddress},
+		{"Balance", money.NewMoney(identity.Balance, money.CurrencyMyst).String()},
+		{"Earnings", money.NewMoney(identity.Earnings, money.CurrencyMyst).String()},
+		{"Earnings total", money.NewMoney(identity.EarningsTotal, money.CurrencyMyst).String()},
+	})
+}
+
+func identitiesNewAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	if ctx.NArg() > 0 {
+		return usageError("usage: identities new [--passphrase-file <file>]")
+	}
+	passphrase, err := readPassphrase(ctx, os.Stdin)
+	if err != nil {
+		return failure(err, "could not read passphrase")
+	}
+	id, err := client.NewIdentity(passphrase)
+	if err != nil {
+		return failure(err, "could not create identity")
+	}
+	return out.printFields(id, [][2]string{{"Identity", id.Address}})
+}
+
+func identitiesUnlockAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	if ctx.NArg() != 1 {
+		return usageError("usage: identities unlock [--passphrase-file <file>] <identity>")
+	}
+	passphrase, err := readPassphrase(ctx, os.Stdin)
+	if err != nil {
+		return failure(err, "could not read passphrase")
+	}
+	address := ctx.Args().First()
+	if err := client.Unlock(address, passphrase); err != nil {
+		return failure(err, "could not unlock identity")
+	}
+	return out.printFields(map[string]string{"id": address, "status": "Unlocked"}, [][2]string{
+		{"Identity", address},
+		{"Status", "Unlocked"},
+	})
+}
+
+// readPassphrase reads identity passphrase from the passphrase file or stdin, falling back to the environment.
+func readPassphrase(ctx *cli.Context, stdin io.Reader) (string, error) {
+	path := ctx.String(flagPassphraseFile.Name)
+	switch path {
+	case "":
+		return os.Getenv(envPassphrase), nil
+	case "-":
+		return readLine(stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return readLine(file)
+}
+
+func readLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func watchAction(ctx *cli.Context, client *tequilapi_client.Client, out *printer) error {
+	watchCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-watchCtx.Done():
+		}
+	}()
+
+	err := client.Events(watchCtx, func(event tequilapi_client.EventDTO) error {
+		return printEvent(out, event, time.Now())
+	})
+	if err != nil {
+		return failure(err, "could not watch events")
+	}
+	return nil
+}
+
+// printEvent writes the event as a single line, so that the stream can be processed line by line.
+func printEvent(out *printer, event tequilapi_client.EventDTO, received time.Time) error {
+	if out.format == outputJSON {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out.w, string(line))
+		return err
+	}
+
+	_, err := fmt.Fprintf(out.w, "%s\t%s\t%s\n", received.Format(time.RFC3339), event.Type, event.Payload)
+	return err
+}
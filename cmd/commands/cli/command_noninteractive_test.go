@@ -0,0 +1,183 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestPrinter(t *testing.T) {
+	_, err := newPrinter(&bytes.Buffer{}, "yaml")
+	assert.Equal(t, exitCodeUsage, err.(cli.ExitCoder).ExitCode())
+
+	output := &bytes.Buffer{}
+	out, err := newPrinter(output, outputTable)
+	assert.NoError(t, err)
+	assert.NoError(t, out.print(nil, []string{"ID", "TYPE"}, [][]string{{"1", "openvpn"}, {"22", "wireguard"}}))
+	assert.Equal(t, "ID  TYPE\n1   openvpn\n22  wireguard\n", output.String())
+
+	output.Reset()
+	out, err = newPrinter(output, outputJSON)
+	assert.NoError(t, err)
+	assert.NoError(t, out.printFields(map[string]string{"id": "1"}, [][2]string{{"ID", "1"}}))
+	assert.JSONEq(t, `{"id": "1"}`, output.String())
+}
+
+func TestStatusAction(t *testing.T) {
+	state := `{"status": "NotConnected"}`
+	client := newTestClient(t, map[string]string{
+		"/connection":            state,
+		"/connection/ip":         `{"ip": "1.2.3.4"}`,
+		"/connection/statistics": `{"bytes_sent": 1, "bytes_received": 2, "duration": 60, "tokens_spent": 100}`,
+	})
+
+	output := &bytes.Buffer{}
+	out, _ := newPrinter(output, outputJSON)
+	err := statusAction(newTestContext(t), client, out)
+	assert.Equal(t, exitCodeNotConnected, err.(cli.ExitCoder).ExitCode())
+	assert.JSONEq(t, `{"status": "NotConnected"}`, output.String())
+
+	state = `{"status": "Connected", "session_id": "session1", "proposal": {"provider_id": "0x1", "service_type": "wireguard"}}`
+	client = newTestClient(t, map[string]string{
+		"/connection":            state,
+		"/connection/ip":         `{"ip": "1.2.3.4"}`,
+		"/connection/statistics": `{"bytes_sent": 1, "bytes_received": 2, "duration": 60, "tokens_spent": 100}`,
+	})
+	output.Reset()
+	err = statusAction(newTestContext(t), client, out)
+	assert.NoError(t, err)
+	var result statusOutput
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &result))
+	assert.Equal(t, "Connected", result.Status)
+	assert.Equal(t, "0x1", result.Proposal.ProviderID)
+	assert.Equal(t, "1.2.3.4", result.IP)
+	assert.Equal(t, uint64(100), result.Statistics.TokensSpent)
+}
+
+func TestProposalsAction(t *testing.T) {
+	client := newTestClient(t, map[string]string{
+		"/proposals": `{"proposals": [
+			{"provider_id": "0x1", "service_type": "openvpn", "service_definition": {"location_originate": {"country": "DE"}}},
+			{"provider_id": "0x2", "service_type": "wireguard", "service_definition": {"location_originate": {"country": "DE"}}},
+			{"provider_id": "0x3", "service_type": "wireguard", "service_definition": {"location_originate": {"country": "US"}}}
+		]}`,
+	})
+
+	output := &bytes.Buffer{}
+	out, _ := newPrinter(output, outputTable)
+	ctx := newTestContext(t, &flagProposalsFilter, &flagProposalsServiceType)
+	assert.NoError(t, ctx.Set(flagProposalsFilter.Name, "DE"))
+	assert.NoError(t, ctx.Set(flagProposalsServiceType.Name, "wireguard"))
+
+	assert.NoError(t, proposalsAction(ctx, client, out))
+	assert.Equal(t, "PROVIDER  TYPE       COUNTRY  ACCESS POLICIES\n0x2       wireguard  DE       \n", output.String())
+}
+
+func TestConnectAction_ValidatesArgs(t *testing.T) {
+	err := connectAction(newTestContext(t, &flagConnectDNS), nil, nil)
+	assert.Equal(t, exitCodeUsage, err.(cli.ExitCoder).ExitCode())
+}
+
+func TestPrintEvent(t *testing.T) {
+	output := &bytes.Buffer{}
+	event := tequilapi_client.EventDTO{Type: "state-change", Payload: []byte(`{"sessions":[]}`)}
+	received := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	out, _ := newPrinter(output, outputTable)
+	assert.NoError(t, printEvent(out, event, received))
+	out, _ = newPrinter(output, outputJSON)
+	assert.NoError(t, printEvent(out, event, received))
+
+	assert.Equal(t, "2020-06-01T10:00:00Z\tstate-change\t{\"sessions\":[]}\n"+
+		`{"type":"state-change","payload":{"sessions":[]}}`+"\n", output.String())
+}
+
+func newTestClient(t *testing.T, responses map[string]string) *tequilapi_client.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	assert.NoError(t, err)
+	return tequilapi_client.NewClient(host, portNum)
+}
+
+func newTestContext(t *testing.T, flags ...cli.Flag) *cli.Context {
+	set := flag.NewFlagSet("", flag.ContinueOnError)
+	for _, f := range flags {
+		assert.NoError(t, f.Apply(set))
+	}
+	return cli.NewContext(nil, set, nil)
+}
+
+func TestReadPassphrase(t *testing.T) {
+	os.Setenv(envPassphrase, "from env")
+	defer os.Unsetenv(envPassphrase)
+
+	passphrase, err := readPassphrase(newTestContext(t, &flagPassphraseFile), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "from env", passphrase)
+
+	ctx := newTestContext(t, &flagPassphraseFile)
+	assert.NoError(t, ctx.Set(flagPassphraseFile.Name, "-"))
+	passphrase, err = readPassphrase(ctx, strings.NewReader("from stdin\nignored"))
+	assert.NoError(t, err)
+	assert.Equal(t, "from stdin", passphrase)
+
+	file, err := ioutil.TempFile("", "passphrase")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("from file\r\n")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	ctx = newTestContext(t, &flagPassphraseFile)
+	assert.NoError(t, ctx.Set(flagPassphraseFile.Name, file.Name()))
+	passphrase, err = readPassphrase(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "from file", passphrase)
+}
+
+func TestIdentitiesUnlockAction_RejectsPassphraseArgument(t *testing.T) {
+	set := flag.NewFlagSet("", flag.ContinueOnError)
+	assert.NoError(t, set.Parse([]string{"0x1", "secret"}))
+
+	err := identitiesUnlockAction(cli.NewContext(nil, set, nil), nil, nil)
+	assert.Equal(t, exitCodeUsage, err.(cli.ExitCoder).ExitCode())
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+const (
+	// exitCodeFailure is returned when the node fails to perform the action.
+	exitCodeFailure = 1
+	// exitCodeUsage is returned when the command is called with invalid arguments.
+	exitCodeUsage = 2
+	// exitCodeNotConnected is returned by status when the consumer is not connected.
+	exitCodeNotConnected = 3
+)
+
+var flagOutput = cli.StringFlag{
+	Name:    "output",
+	Aliases: []string{"o"},
+	Usage:   "Output format: table or json",
+	Value:   outputTable,
+}
+
+// printer writes command results either as JSON or as a human readable table.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case outputTable, outputJSON:
+		return &printer{w: w, format: format}, nil
+	}
+	return nil, usageError("unknown output format %q, expected %s or %s", format, outputTable, outputJSON)
+}
+
+// print writes the value as JSON or the rows as a table under the header.
+func (p *printer) print(value interface{}, header []string, rows [][]string) error {
+	if p.format == outputJSON {
+		encoder := json.NewEncoder(p.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	if len(header) > 0 {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// printFields writes the value as JSON or its fields as a two column table.
+func (p *printer) printFields(value interface{}, fields [][2]string) error {
+	rows := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		rows = append(rows, []string{field[0] + ":", field[1]})
+	}
+	return p.print(value, nil, rows)
+}
+
+func usageError(format string, args ...interface{}) error {
+	return cli.Exit(fmt.Sprintf(format, args...), exitCodeUsage)
+}
+
+func failure(err error, action string) error {
+	return cli.Exit(fmt.Sprintf("%s: %v", action, err), exitCodeFailure)
+}
@@ -0,0 +1,226 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package db
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mysteriumnetwork/node/cmd"
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/config/urfavecli/clicontext"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/migrations/history"
+	"github.com/mysteriumnetwork/node/core/storage/sqlite"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var flagToken = cli.StringFlag{
+	Name:    "token",
+	Usage:   "JWT or API token used to back up the database of a running node through Tequilapi",
+	EnvVars: []string{"MYST_TEQUILAPI_TOKEN"},
+}
+
+// maintenance is a set of database operations of a storage backend, which do not need the node running.
+type maintenance struct {
+	file    func(dir string) string
+	backup  func(dir, file string) error
+	restore func(dir, file string) error
+	compact func(dir string) (storage.CompactResult, error)
+	inspect func(dir string) ([]storage.BucketStats, error)
+}
+
+var backends = map[string]maintenance{
+	node.StorageBackendBolt: {
+		file:   boltdb.DatabaseFile,
+		backup: boltdb.BackupFile,
+		restore: func(dir, file string) error {
+			return boltdb.Restore(dir, file, history.Sequence)
+		},
+		compact: boltdb.Compact,
+		inspect: boltdb.Inspect,
+	},
+	node.StorageBackendSQLite: {
+		file:    sqlite.DatabaseFile,
+		backup:  sqlite.BackupFile,
+		restore: sqlite.Restore,
+		compact: sqlite.Compact,
+		inspect: sqlite.Inspect,
+	},
+}
+
+// NewCommand function creates database maintenance command
+func NewCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "db",
+		Usage:  "Maintains node database",
+		Before: clicontext.LoadUserConfigQuietly,
+		Subcommands: []*cli.Command{
+			{
+				Name:      "backup",
+				Usage:     "Writes a consistent snapshot of the database to the file, through Tequilapi if the node is running",
+				ArgsUsage: "<file>",
+				Flags:     []cli.Flag{&flagToken},
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() != 1 {
+						return errors.New("backup file is required")
+					}
+					options := nodeOptions(ctx)
+					online := func(w io.Writer) error {
+						client, err := cmd.NewTequilapiClient(options, ctx.String(flagToken.Name))
+						if err != nil {
+							return err
+						}
+						_, err = client.StorageBackup(w)
+						return err
+					}
+					return backup(ctx.App.Writer, backendOf(options), options.Directories.Storage, ctx.Args().First(), online)
+				},
+			},
+			{
+				Name:      "restore",
+				Usage:     "Replaces the database with the backup and runs pending migrations, the node has to be stopped",
+				ArgsUsage: "<file>",
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() != 1 {
+						return errors.New("backup file is required")
+					}
+					options := nodeOptions(ctx)
+					dir, backend := options.Directories.Storage, backendOf(options)
+					if err := backend.restore(dir, ctx.Args().First()); err != nil {
+						return describeLocked(err)
+					}
+					_, err := fmt.Fprintln(ctx.App.Writer, "Database restored to", backend.file(dir))
+					return err
+				},
+			},
+			{
+				Name:  "compact",
+				Usage: "Rewrites the database releasing space of deleted records, the node has to be stopped",
+				Action: func(ctx *cli.Context) error {
+					options := nodeOptions(ctx)
+					return compact(ctx.App.Writer, backendOf(options), options.Directories.Storage)
+				},
+			},
+			{
+				Name:  "inspect",
+				Usage: "Lists database buckets, the node has to be stopped",
+				Action: func(ctx *cli.Context) error {
+					options := nodeOptions(ctx)
+					return inspect(ctx.App.Writer, backendOf(options), options.Directories.Storage)
+				},
+			},
+			{
+				Name:  "migrate-sqlite",
+				Usage: "Copies the bolt database to a new SQLite database, the node has to be stopped",
+				Action: func(ctx *cli.Context) error {
+					return migrateSQLite(ctx.App.Writer, nodeOptions(ctx).Directories.Storage, cmd.StorageKinds())
+				},
+			},
+		},
+	}
+}
+
+func nodeOptions(ctx *cli.Context) node.Options {
+	config.ParseFlagsNode(ctx)
+	return *node.GetOptions()
+}
+
+func backendOf(options node.Options) maintenance {
+	if backend, ok := backends[options.Storage.Backend]; ok {
+		return backend
+	}
+	return backends[node.StorageBackendBolt]
+}
+
+func backup(w io.Writer, backend maintenance, dir, file string, online func(w io.Writer) error) error {
+	err := backend.backup(dir, file)
+	if err == boltdb.ErrDatabaseLocked {
+		err = backupOnline(file, online)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, "Database backed up to", file)
+	return err
+}
+
+func backupOnline(file string, online func(w io.Writer) error) error {
+	out, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "could not create backup file")
+	}
+
+	err = online(out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file)
+		return errors.Wrap(err, "could not back up running node")
+	}
+	return nil
+}
+
+func compact(w io.Writer, backend maintenance, dir string) error {
+	result, err := backend.compact(dir)
+	if err != nil {
+		return describeLocked(err)
+	}
+
+	_, err = fmt.Fprintf(w, "Database compacted from %d to %d bytes\n", result.SizeBefore, result.SizeAfter)
+	return err
+}
+
+func inspect(w io.Writer, backend maintenance, dir string) error {
+	buckets, err := backend.inspect(dir)
+	if err != nil {
+		return describeLocked(err)
+	}
+
+	fmt.Fprintln(w, "Database:", backend.file(dir))
+	for _, bucket := range buckets {
+		if _, err := fmt.Fprintf(w, "%-32s %8d keys %10d bytes\n", bucket.Name, bucket.Keys, bucket.Bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateSQLite(w io.Writer, dir string, kinds []storage.Kind) error {
+	result, err := sqlite.ImportBolt(dir, kinds)
+	if err != nil {
+		return describeLocked(err)
+	}
+
+	_, err = fmt.Fprintf(w, "Copied %d records and %d values to %s, start the node with --%s=%s to use it\n",
+		result.Records, result.Values, sqlite.DatabaseFile(dir), config.FlagStorageBackend.Name, node.StorageBackendSQLite)
+	return err
+}
+
+func describeLocked(err error) error {
+	if err == boltdb.ErrDatabaseLocked {
+		return errors.Wrap(err, "stop the node first")
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package db
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRecord struct {
+	ID int64 `storm:"id,increment"`
+}
+
+func TestMaintenance(t *testing.T) {
+	bolt := backends[node.StorageBackendBolt]
+	dir := boltdbtest.CreateTempDir(t)
+	defer boltdbtest.RemoveTempDir(t, dir)
+
+	storage, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.Store("records", &testRecord{}))
+
+	// a running node is backed up through Tequilapi
+	output := bytes.NewBufferString("")
+	file := filepath.Join(dir, "backup.db")
+	onlineCalled := false
+	err = backup(output, bolt, dir, file, func(w io.Writer) error {
+		onlineCalled = true
+		_, err := storage.Backup(w)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.True(t, onlineCalled)
+
+	assert.EqualError(t, inspect(output, bolt, dir), "stop the node first: database is used by a running node")
+	assert.NoError(t, storage.Close())
+
+	output.Reset()
+	assert.NoError(t, inspect(output, bolt, dir))
+	assert.Contains(t, output.String(), "records")
+
+	output.Reset()
+	assert.NoError(t, compact(output, bolt, dir))
+	assert.Contains(t, output.String(), "Database compacted")
+
+	output.Reset()
+	onlineCalled = false
+	assert.NoError(t, backup(output, bolt, dir, file, func(w io.Writer) error {
+		onlineCalled = true
+		return nil
+	}))
+	assert.False(t, onlineCalled)
+	assert.Equal(t, "Database backed up to "+file+"\n", output.String())
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	dir := boltdbtest.CreateTempDir(t)
+	defer boltdbtest.RemoveTempDir(t, dir)
+
+	db, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Store("records", &testRecord{}))
+	kinds := []storage.Kind{{Bucket: "records", Record: &testRecord{}}}
+
+	output := bytes.NewBufferString("")
+	assert.EqualError(t, migrateSQLite(output, dir, kinds), "stop the node first: database is used by a running node")
+	assert.NoError(t, db.Close())
+
+	assert.NoError(t, migrateSQLite(output, dir, kinds))
+	assert.Contains(t, output.String(), "Copied 1 records and 0 values")
+
+	output.Reset()
+	assert.NoError(t, inspect(output, backends[node.StorageBackendSQLite], dir))
+	assert.Contains(t, output.String(), "records/testRecord")
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package feedback
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mysteriumnetwork/node/cmd"
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/config/urfavecli/clicontext"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	flagToken = cli.StringFlag{
+		Name:    "token",
+		Usage:   "JWT or API token with admin scope used to access Tequilapi",
+		EnvVars: []string{"MYST_TEQUILAPI_TOKEN"},
+	}
+	flagMask = cli.BoolFlag{
+		Name:  "mask",
+		Usage: "Mask IP addresses and secrets in the bundle",
+	}
+)
+
+// NewCommand function creates feedback command
+func NewCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "feedback",
+		Usage:  "Collects information for troubleshooting",
+		Before: clicontext.LoadUserConfigQuietly,
+		Subcommands: []*cli.Command{
+			{
+				Name:      "bundle",
+				Usage:     "Downloads diagnostics bundle of the running node to the file, nothing is sent to the feedback service",
+				ArgsUsage: "<file>",
+				Flags:     []cli.Flag{&flagToken, &flagMask},
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() != 1 {
+						return errors.New("bundle file is required")
+					}
+					config.ParseFlagsNode(ctx)
+					client, err := cmd.NewTequilapiClient(*node.GetOptions(), ctx.String(flagToken.Name))
+					if err != nil {
+						return err
+					}
+					download := func(w io.Writer) error {
+						_, err := client.FeedbackBundle(w, ctx.Bool(flagMask.Name))
+						return err
+					}
+					return bundle(ctx.App.Writer, ctx.Args().First(), download)
+				},
+			},
+		},
+	}
+}
+
+func bundle(w io.Writer, file string, download func(w io.Writer) error) error {
+	out, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "could not create bundle file")
+	}
+
+	err = download(out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file)
+		return errors.Wrap(err, "could not download diagnostics bundle")
+	}
+
+	_, err = fmt.Fprintln(w, "Diagnostics bundle saved to", file)
+	return err
+}
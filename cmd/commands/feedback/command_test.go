@@ -0,0 +1,56 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package feedback
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "feedback")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "bundle.zip")
+
+	output := bytes.NewBufferString("")
+	err = bundle(output, file, func(w io.Writer) error {
+		_, err := io.WriteString(w, "bundle")
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Diagnostics bundle saved to "+file+"\n", output.String())
+	content, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "bundle", string(content))
+
+	// partially downloaded bundle is not left behind
+	err = bundle(output, file, func(w io.Writer) error {
+		return errors.New("connection refused")
+	})
+	assert.EqualError(t, err, "could not download diagnostics bundle: connection refused")
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mysteriumnetwork/node/cmd"
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/config/urfavecli/clicontext"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+const (
+	// exitCodeFailure is returned when the node could not be brought to the declared state.
+	exitCodeFailure = 1
+	// exitCodeUsage is returned when the command is called with invalid arguments or manifest.
+	exitCodeUsage = 2
+	// exitCodeDrift is returned when the node is left different from the declared state.
+	exitCodeDrift = 3
+)
+
+var (
+	flagToken = cli.StringFlag{
+		Name:    "token",
+		Usage:   "JWT or API token with admin scope used to access Tequilapi",
+		EnvVars: []string{"MYST_TEQUILAPI_TOKEN"},
+	}
+	flagDryRun = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Only report how the node differs from the manifest, without changing it",
+	}
+	flagPrune = cli.BoolFlag{
+		Name:  "prune",
+		Usage: "Stop running services which are not declared in the manifest",
+	}
+	flagOutput = cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   "Output format: table or json",
+		Value:   outputTable,
+	}
+)
+
+// NewCommand function creates provider command
+func NewCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "provider",
+		Usage:  "Manages provider setup of the running node",
+		Before: clicontext.LoadUserConfigQuietly,
+		Subcommands: []*cli.Command{
+			{
+				Name: "init",
+				Usage: fmt.Sprintf(
+					"Brings the running node to the state declared in the TOML or YAML manifest, exits with code %d if it is left different",
+					exitCodeDrift,
+				),
+				ArgsUsage: "<manifest>",
+				Flags:     []cli.Flag{&flagToken, &flagDryRun, &flagPrune, &flagOutput},
+				Action:    initAction,
+			},
+		},
+	}
+}
+
+func initAction(ctx *cli.Context) error {
+	format := ctx.String(flagOutput.Name)
+	if format != outputTable && format != outputJSON {
+		return cli.Exit(fmt.Sprintf("unknown output format %q, expected %s or %s", format, outputTable, outputJSON), exitCodeUsage)
+	}
+	if ctx.NArg() != 1 {
+		return cli.Exit("manifest file is required", exitCodeUsage)
+	}
+	manifest, err := LoadManifest(ctx.Args().First())
+	if err != nil {
+		return cli.Exit(err.Error(), exitCodeUsage)
+	}
+	passphrase, err := manifest.Identity.Passphrase()
+	if err != nil {
+		return cli.Exit(err.Error(), exitCodeUsage)
+	}
+
+	config.ParseFlagsNode(ctx)
+	client, err := cmd.NewTequilapiClient(*node.GetOptions(), ctx.String(flagToken.Name))
+	if err != nil {
+		return cli.Exit(err.Error(), exitCodeFailure)
+	}
+
+	report, err := Reconcile(client, manifest, passphrase, ctx.Bool(flagDryRun.Name), ctx.Bool(flagPrune.Name))
+	if err != nil {
+		return cli.Exit(err.Error(), exitCodeFailure)
+	}
+	if err := printReport(ctx.App.Writer, format, report); err != nil {
+		return cli.Exit(err.Error(), exitCodeFailure)
+	}
+
+	switch {
+	case report.Failed():
+		return cli.Exit("", exitCodeFailure)
+	case report.Drifted():
+		return cli.Exit("", exitCodeDrift)
+	}
+	return nil
+}
+
+func printReport(w io.Writer, format string, report Report) error {
+	if format == outputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join([]string{"RESOURCE", "STATE", "DETAIL"}, "\t"))
+	for _, item := range report.Items {
+		fmt.Fprintln(tw, strings.Join([]string{item.Resource, item.State, item.Detail}, "\t"))
+	}
+	return tw.Flush()
+}
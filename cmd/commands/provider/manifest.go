@@ -0,0 +1,226 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/services/noop"
+	"github.com/mysteriumnetwork/node/services/openvpn"
+	"github.com/mysteriumnetwork/node/services/proxy"
+	"github.com/mysteriumnetwork/node/services/wireguard"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Manifest declares the state a provider node should be brought to.
+type Manifest struct {
+	Identity IdentitySpec  `toml:"identity" yaml:"identity"`
+	Payout   PayoutSpec    `toml:"payout" yaml:"payout"`
+	Shaping  *ShapingSpec  `toml:"shaping" yaml:"shaping"`
+	Services []ServiceSpec `toml:"services" yaml:"services"`
+}
+
+// IdentitySpec declares the provider identity and where its passphrase comes from.
+// Without an address the last used identity is taken, or a new one is created.
+type IdentitySpec struct {
+	Address        string `toml:"address" yaml:"address"`
+	PassphraseFile string `toml:"passphrase_file" yaml:"passphrase_file"`
+	PassphraseEnv  string `toml:"passphrase_env" yaml:"passphrase_env"`
+	Stake          uint64 `toml:"stake" yaml:"stake"`
+}
+
+// PayoutSpec declares the address provider earnings are paid out to.
+type PayoutSpec struct {
+	Address string `toml:"address" yaml:"address"`
+}
+
+// ShapingSpec declares whether the bandwidth of all services is limited.
+type ShapingSpec struct {
+	Enabled bool `toml:"enabled" yaml:"enabled"`
+}
+
+// ServiceSpec declares a service which has to be running.
+// Only the declared options are compared with the running service, others keep their defaults.
+type ServiceSpec struct {
+	Type           string                 `toml:"type" yaml:"type"`
+	Options        map[string]interface{} `toml:"options" yaml:"options"`
+	AccessPolicies []string               `toml:"access_policies" yaml:"access_policies"`
+	PriceMinute    *float64               `toml:"price_minute" yaml:"price_minute"`
+	PriceGB        *float64               `toml:"price_gb" yaml:"price_gb"`
+}
+
+// LoadManifest reads the manifest from a TOML or YAML file, depending on its extension.
+func LoadManifest(path string) (Manifest, error) {
+	var manifest Manifest
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return manifest, errors.Wrap(err, "could not read manifest")
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		var meta toml.MetaData
+		if meta, err = toml.Decode(string(data), &manifest); err == nil {
+			err = unknownTOMLField(meta)
+		}
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, &manifest)
+	default:
+		return manifest, errors.Errorf("unsupported manifest format %q, expected .toml, .yaml or .yml", filepath.Ext(path))
+	}
+	if err != nil {
+		return manifest, errors.Wrap(err, "could not parse manifest")
+	}
+
+	for i := range manifest.Services {
+		options, err := normalizeOptions(manifest.Services[i].Options)
+		if err != nil {
+			return manifest, errors.Wrapf(err, "invalid options of service %q", manifest.Services[i].Type)
+		}
+		manifest.Services[i].Options = options
+	}
+	return manifest, manifest.validate()
+}
+
+// unknownTOMLField reports the first key which does not match any manifest field.
+// Nested service options are free-form, so their keys are never reported.
+func unknownTOMLField(meta toml.MetaData) error {
+	for _, key := range meta.Undecoded() {
+		if len(key) > 2 && key[0] == "services" && key[1] == "options" {
+			continue
+		}
+		return errors.Errorf("unknown field %q", key.String())
+	}
+	return nil
+}
+
+func (m Manifest) validate() error {
+	if m.Identity.Address != "" && !common.IsHexAddress(m.Identity.Address) {
+		return errors.Errorf("invalid identity address %q", m.Identity.Address)
+	}
+	if m.Identity.PassphraseFile != "" && m.Identity.PassphraseEnv != "" {
+		return errors.New("only one of identity passphrase_file and passphrase_env can be set")
+	}
+	if m.Payout.Address != "" && !common.IsHexAddress(m.Payout.Address) {
+		return errors.Errorf("invalid payout address %q", m.Payout.Address)
+	}
+
+	declared := make(map[string]bool)
+	for _, svc := range m.Services {
+		if _, ok := defaultPrices[svc.Type]; !ok {
+			return errors.Errorf("unknown service type %q", svc.Type)
+		}
+		if declared[svc.Type] {
+			return errors.Errorf("service %q is declared more than once", svc.Type)
+		}
+		declared[svc.Type] = true
+		if (svc.PriceMinute != nil && *svc.PriceMinute < 0) || (svc.PriceGB != nil && *svc.PriceGB < 0) {
+			return errors.Errorf("service %q price can not be negative", svc.Type)
+		}
+	}
+	return nil
+}
+
+// Passphrase resolves the identity passphrase from the declared source, empty passphrase is used if none is declared.
+func (s IdentitySpec) Passphrase() (string, error) {
+	switch {
+	case s.PassphraseFile != "":
+		data, err := ioutil.ReadFile(s.PassphraseFile)
+		if err != nil {
+			return "", errors.Wrap(err, "could not read identity passphrase")
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case s.PassphraseEnv != "":
+		passphrase, ok := os.LookupEnv(s.PassphraseEnv)
+		if !ok {
+			return "", errors.Errorf("identity passphrase variable %s is not set", s.PassphraseEnv)
+		}
+		return passphrase, nil
+	}
+	return "", nil
+}
+
+// prices returns the declared service prices per GB and per minute, falling back to the defaults of the service type.
+func (s ServiceSpec) prices() (perGB, perMinute float64) {
+	defaults := defaultPrices[s.Type]
+	perGB, perMinute = defaults[0], defaults[1]
+	if s.PriceGB != nil {
+		perGB = *s.PriceGB
+	}
+	if s.PriceMinute != nil {
+		perMinute = *s.PriceMinute
+	}
+	return perGB, perMinute
+}
+
+// defaultPrices holds the default price per GB and per minute of each service type.
+var defaultPrices = map[string][2]float64{
+	noop.ServiceType:      {0, config.FlagNoopPriceMinute.Value},
+	wireguard.ServiceType: {config.FlagWireguardPriceGB.Value, config.FlagWireguardPriceMinute.Value},
+	openvpn.ServiceType:   {config.FlagOpenVPNPriceGB.Value, config.FlagOpenVPNPriceMinute.Value},
+	proxy.ServiceType:     {config.FlagProxyPriceGB.Value, config.FlagProxyPriceMinute.Value},
+}
+
+// normalizeOptions converts decoded options to the form they take when decoded from JSON,
+// so that they can be compared with the options reported by the node.
+func normalizeOptions(options map[string]interface{}) (map[string]interface{}, error) {
+	if len(options) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(stringKeys(options))
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]interface{}
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+// stringKeys replaces YAML maps, which are keyed by interface{}, with maps keyed by string.
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = stringKeys(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = stringKeys(item)
+		}
+		return result
+	}
+	return value
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package provider
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeManifest(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "provider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	yamlPath := writeManifest(t, dir, "provider.yaml", `
+identity:
+  address: "0x000000000000000000000000000000000000000a"
+  passphrase_env: PROVIDER_PASSPHRASE
+payout:
+  address: "0x000000000000000000000000000000000000000b"
+shaping:
+  enabled: true
+services:
+  - type: wireguard
+    options:
+      ports: "52820:52830"
+      dnsBlocklist:
+        ads: true
+    access_policies: [mysterium]
+    price_minute: 0.001
+`)
+	tomlPath := writeManifest(t, dir, "provider.toml", `
+[identity]
+address = "0x000000000000000000000000000000000000000a"
+passphrase_env = "PROVIDER_PASSPHRASE"
+
+[payout]
+address = "0x000000000000000000000000000000000000000b"
+
+[shaping]
+enabled = true
+
+[[services]]
+type = "wireguard"
+access_policies = ["mysterium"]
+price_minute = 0.001
+
+[services.options]
+ports = "52820:52830"
+
+[services.options.dnsBlocklist]
+ads = true
+`)
+
+	for _, path := range []string{yamlPath, tomlPath} {
+		manifest, err := LoadManifest(path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, "0x000000000000000000000000000000000000000a", manifest.Identity.Address)
+		assert.Equal(t, "0x000000000000000000000000000000000000000b", manifest.Payout.Address)
+		assert.Equal(t, &ShapingSpec{Enabled: true}, manifest.Shaping)
+		assert.Len(t, manifest.Services, 1)
+		svc := manifest.Services[0]
+		assert.Equal(t, "wireguard", svc.Type)
+		assert.Equal(t, []string{"mysterium"}, svc.AccessPolicies)
+		assert.Equal(t, map[string]interface{}{
+			"ports":        "52820:52830",
+			"dnsBlocklist": map[string]interface{}{"ads": true},
+		}, svc.Options)
+		perGB, perMinute := svc.prices()
+		assert.Equal(t, 0.001, perMinute)
+		assert.Equal(t, defaultPrices["wireguard"][0], perGB)
+	}
+}
+
+func TestLoadManifestErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "provider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := map[string]struct {
+		name    string
+		content string
+		err     string
+	}{
+		"unknown format": {
+			name: "provider.json",
+			err:  `unsupported manifest format ".json", expected .toml, .yaml or .yml`,
+		},
+		"unknown yaml field": {
+			name:    "provider.yml",
+			content: "payout:\n  adress: \"0x000000000000000000000000000000000000000b\"\n",
+			err:     "could not parse manifest: yaml: unmarshal errors:\n  line 2: field adress not found in type provider.PayoutSpec",
+		},
+		"unknown toml field": {
+			name:    "provider.toml",
+			content: "[payout]\nadress = \"0x000000000000000000000000000000000000000b\"\n",
+			err:     `could not parse manifest: unknown field "payout.adress"`,
+		},
+		"invalid payout address": {
+			name:    "provider.toml",
+			content: "[payout]\naddress = \"0x0b\"\n",
+			err:     `invalid payout address "0x0b"`,
+		},
+		"unknown service": {
+			name:    "provider.toml",
+			content: "[[services]]\ntype = \"ipsec\"\n",
+			err:     `unknown service type "ipsec"`,
+		},
+		"duplicate service": {
+			name:    "provider.toml",
+			content: "[[services]]\ntype = \"wireguard\"\n[[services]]\ntype = \"wireguard\"\n",
+			err:     `service "wireguard" is declared more than once`,
+		},
+		"two passphrase sources": {
+			name:    "provider.toml",
+			content: "[identity]\npassphrase_env = \"A\"\npassphrase_file = \"/a\"\n",
+			err:     "only one of identity passphrase_file and passphrase_env can be set",
+		},
+	}
+	for name, test := range tests {
+		path := writeManifest(t, dir, test.name, test.content)
+		_, err := LoadManifest(path)
+		assert.EqualError(t, err, test.err, name)
+	}
+}
+
+func TestIdentitySpec_Passphrase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "provider")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	passphrase, err := IdentitySpec{}.Passphrase()
+	assert.NoError(t, err)
+	assert.Equal(t, "", passphrase)
+
+	file := writeManifest(t, dir, "passphrase", "secret\n")
+	passphrase, err = IdentitySpec{PassphraseFile: file}.Passphrase()
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", passphrase)
+
+	os.Setenv("PROVIDER_TEST_PASSPHRASE", "from env")
+	defer os.Unsetenv("PROVIDER_TEST_PASSPHRASE")
+	passphrase, err = IdentitySpec{PassphraseEnv: "PROVIDER_TEST_PASSPHRASE"}.Passphrase()
+	assert.NoError(t, err)
+	assert.Equal(t, "from env", passphrase)
+
+	_, err = IdentitySpec{PassphraseEnv: "PROVIDER_TEST_MISSING"}.Passphrase()
+	assert.EqualError(t, err, "identity passphrase variable PROVIDER_TEST_MISSING is not set")
+}
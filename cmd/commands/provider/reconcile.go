@@ -0,0 +1,413 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/pkg/errors"
+)
+
+// nodeAPI is the part of Tequilapi client used to bring the node to the declared state.
+type nodeAPI interface {
+	GetIdentities() ([]contract.IdentityRefDTO, error)
+	CurrentIdentity(identity, passphrase string) (contract.IdentityRefDTO, error)
+	Identity(identityAddress string) (contract.IdentityDTO, error)
+	GetTransactorFees() (tequilapi_client.Fees, error)
+	RegisterIdentity(address, beneficiary string, stake, fee uint64) error
+	PayoutInfo(identity string) (tequilapi_client.PayoutInfoDTO, error)
+	Payout(identity, ethAddress string) error
+	UserConfig() (tequilapi_client.ConfigDTO, error)
+	SetUserConfig(data map[string]interface{}) (tequilapi_client.ConfigDTO, error)
+	Services() (tequilapi_client.ServiceListDTO, error)
+	ServiceStart(providerID, serviceType string, options interface{}, ap tequilapi_client.AccessPoliciesRequest, pm contract.PaymentMethodDTO) (tequilapi_client.ServiceInfoDTO, error)
+	ServiceStop(id string) error
+}
+
+// States of the reconciled resources.
+const (
+	// StateOK means the resource matches the declaration.
+	StateOK = "ok"
+	// StateCreated means the resource was missing and has been created.
+	StateCreated = "created"
+	// StateUpdated means the resource differed from the declaration and has been changed.
+	StateUpdated = "updated"
+	// StateRemoved means the undeclared resource has been removed.
+	StateRemoved = "removed"
+	// StatePending means the resource is being changed by the node.
+	StatePending = "pending"
+	// StateDrift means the resource differs from the declaration and was left as is.
+	StateDrift = "drift"
+	// StateFailed means the resource could not be checked or changed.
+	StateFailed = "failed"
+)
+
+// Item describes the state of a single reconciled resource.
+type Item struct {
+	Resource string `json:"resource"`
+	State    string `json:"state"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Report lists the states of all resources declared in the manifest.
+type Report struct {
+	Identity string `json:"identity"`
+	DryRun   bool   `json:"dry_run"`
+	Items    []Item `json:"items"`
+}
+
+// Drifted tells whether any resource was left different from the declaration.
+func (r Report) Drifted() bool {
+	return r.has(StateDrift)
+}
+
+// Failed tells whether any resource could not be reconciled.
+func (r Report) Failed() bool {
+	return r.has(StateFailed)
+}
+
+func (r Report) has(state string) bool {
+	for _, item := range r.Items {
+		if item.State == state {
+			return true
+		}
+	}
+	return false
+}
+
+// reconciler brings the node to the state declared in the manifest.
+// In dry run mode nothing is changed and the differences are reported as drift.
+type reconciler struct {
+	api    nodeAPI
+	dryRun bool
+	prune  bool
+	report Report
+}
+
+// Reconcile compares the node with the manifest and changes the node where it differs.
+// The error is returned only if the provider identity can not be resolved, other problems are reported as failed items.
+func Reconcile(api nodeAPI, manifest Manifest, passphrase string, dryRun, prune bool) (Report, error) {
+	r := &reconciler{api: api, dryRun: dryRun, prune: prune, report: Report{DryRun: dryRun}}
+
+	id, err := r.identity(manifest.Identity, passphrase)
+	if err != nil || id == "" {
+		return r.report, err
+	}
+	r.report.Identity = id
+
+	r.registration(id, manifest.Identity.Stake, manifest.Payout.Address)
+	if manifest.Payout.Address != "" {
+		r.payout(id, manifest.Payout.Address)
+	}
+	if manifest.Shaping != nil {
+		r.shaping(manifest.Shaping.Enabled)
+	}
+	r.services(id, manifest.Services)
+	return r.report, nil
+}
+
+func (r *reconciler) add(resource, state, format string, args ...interface{}) {
+	r.report.Items = append(r.report.Items, Item{Resource: resource, State: state, Detail: fmt.Sprintf(format, args...)})
+}
+
+// identity unlocks the declared identity, or the last used one which is created if the keystore is empty.
+// Empty address is returned when there is no identity to reconcile in dry run mode.
+func (r *reconciler) identity(spec IdentitySpec, passphrase string) (string, error) {
+	existing, err := r.api.GetIdentities()
+	if err != nil {
+		return "", errors.Wrap(err, "could not list identities")
+	}
+	known := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		known[strings.ToLower(id.Address)] = true
+	}
+
+	if spec.Address != "" && !known[strings.ToLower(spec.Address)] {
+		return "", errors.Errorf("identity %s is not in the keystore", spec.Address)
+	}
+	if r.dryRun && len(existing) == 0 {
+		r.add("identity", StateDrift, "no identity, a new one would be created")
+		return "", nil
+	}
+
+	id, err := r.api.CurrentIdentity(spec.Address, passphrase)
+	if err != nil {
+		return "", errors.Wrap(err, "could not unlock identity")
+	}
+	if known[strings.ToLower(id.Address)] {
+		r.add("identity", StateOK, id.Address)
+	} else {
+		r.add("identity", StateCreated, id.Address)
+	}
+	return id.Address, nil
+}
+
+// registration requests the identity registration, without waiting for it to complete on blockchain.
+func (r *reconciler) registration(id string, stake uint64, beneficiary string) {
+	const resource = "registration"
+	identity, err := r.api.Identity(id)
+	if err != nil {
+		r.add(resource, StateFailed, "could not get registration status: %v", err)
+		return
+	}
+
+	switch identity.RegistrationStatus {
+	case registry.RegisteredProvider.String(), registry.RegisteredConsumer.String():
+		r.add(resource, StateOK, identity.RegistrationStatus)
+		return
+	case registry.InProgress.String(), registry.Promoting.String():
+		r.add(resource, StatePending, identity.RegistrationStatus)
+		return
+	}
+
+	if r.dryRun {
+		r.add(resource, StateDrift, "identity is %s", identity.RegistrationStatus)
+		return
+	}
+	fees, err := r.api.GetTransactorFees()
+	if err != nil {
+		r.add(resource, StateFailed, "could not get transactor fees: %v", err)
+		return
+	}
+	if err := r.api.RegisterIdentity(id, beneficiary, stake, fees.Registration); err != nil {
+		r.add(resource, StateFailed, "could not register identity: %v", err)
+		return
+	}
+	r.add(resource, StateCreated, "registration requested")
+}
+
+func (r *reconciler) payout(id, address string) {
+	const resource = "payout"
+	// the node responds with an error if the payout info was never registered
+	current, err := r.api.PayoutInfo(id)
+	if err == nil && strings.EqualFold(current.EthAddress, address) {
+		r.add(resource, StateOK, address)
+		return
+	}
+
+	if r.dryRun {
+		r.add(resource, StateDrift, "payout address is %q, declared %s", current.EthAddress, address)
+		return
+	}
+	if err := r.api.Payout(id, address); err != nil {
+		r.add(resource, StateFailed, "could not set payout address: %v", err)
+		return
+	}
+	if current.EthAddress == "" {
+		r.add(resource, StateCreated, address)
+	} else {
+		r.add(resource, StateUpdated, "%s, was %s", address, current.EthAddress)
+	}
+}
+
+// shaperEnabledKey is the user configuration key of the bandwidth limiting.
+const shaperEnabledKey = "shaper.enabled"
+
+func (r *reconciler) shaping(enabled bool) {
+	const resource = "shaping"
+	cfg, err := r.api.UserConfig()
+	if err != nil {
+		r.add(resource, StateFailed, "could not get user config: %v", err)
+		return
+	}
+
+	current, _ := lookupKey(cfg.Data, shaperEnabledKey).(bool)
+	if current == enabled {
+		r.add(resource, StateOK, "enabled: %t", enabled)
+		return
+	}
+	if r.dryRun {
+		r.add(resource, StateDrift, "enabled: %t, declared %t", current, enabled)
+		return
+	}
+	if _, err := r.api.SetUserConfig(map[string]interface{}{shaperEnabledKey: enabled}); err != nil {
+		r.add(resource, StateFailed, "could not update user config: %v", err)
+		return
+	}
+	r.add(resource, StateUpdated, "enabled: %t", enabled)
+}
+
+// lookupKey finds the value of a dotted key in the nested user configuration.
+func lookupKey(data map[string]interface{}, key string) interface{} {
+	parts := strings.SplitN(key, ".", 2)
+	value, ok := data[parts[0]]
+	if !ok || len(parts) == 1 {
+		return value
+	}
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return lookupKey(nested, parts[1])
+}
+
+func (r *reconciler) services(id string, specs []ServiceSpec) {
+	running, err := r.api.Services()
+	if err != nil {
+		r.add("services", StateFailed, "could not list services: %v", err)
+		return
+	}
+
+	matched := make(map[string]bool)
+	for _, spec := range specs {
+		var current *tequilapi_client.ServiceInfoDTO
+		for i := range running {
+			if running[i].ServiceType == spec.Type && running[i].ProviderID == id && !matched[running[i].ID] {
+				current = &running[i]
+				matched[current.ID] = true
+				break
+			}
+		}
+		r.service(id, spec, current)
+	}
+
+	for _, svc := range running {
+		if matched[svc.ID] {
+			continue
+		}
+		resource := "service " + svc.ServiceType
+		if !r.prune || r.dryRun {
+			r.add(resource, StateDrift, "%s is not declared", svc.ID)
+			continue
+		}
+		if err := r.api.ServiceStop(svc.ID); err != nil {
+			r.add(resource, StateFailed, "could not stop %s: %v", svc.ID, err)
+			continue
+		}
+		r.add(resource, StateRemoved, svc.ID)
+	}
+}
+
+func (r *reconciler) service(id string, spec ServiceSpec, current *tequilapi_client.ServiceInfoDTO) {
+	resource := "service " + spec.Type
+	payment := contract.NewPaymentMethodDTO(pingpong.NewPaymentMethod(spec.prices()))
+
+	state := StateCreated
+	if current != nil {
+		differences := serviceDifferences(spec, payment, *current)
+		if len(differences) == 0 {
+			r.add(resource, StateOK, current.ID)
+			return
+		}
+		if r.dryRun {
+			r.add(resource, StateDrift, strings.Join(differences, "; "))
+			return
+		}
+		if err := r.api.ServiceStop(current.ID); err != nil {
+			r.add(resource, StateFailed, "could not stop %s: %v", current.ID, err)
+			return
+		}
+		state = StateUpdated
+	} else if r.dryRun {
+		r.add(resource, StateDrift, "not running")
+		return
+	}
+
+	options := spec.Options
+	if options == nil {
+		options = map[string]interface{}{}
+	}
+	started, err := r.api.ServiceStart(id, spec.Type, options, tequilapi_client.AccessPoliciesRequest{IDs: spec.AccessPolicies}, payment)
+	if err != nil {
+		r.add(resource, StateFailed, "could not start: %v", err)
+		return
+	}
+	r.add(resource, state, started.ID)
+}
+
+// serviceDifferences describes how the running service differs from the declaration.
+func serviceDifferences(spec ServiceSpec, payment contract.PaymentMethodDTO, current tequilapi_client.ServiceInfoDTO) []string {
+	var differences []string
+
+	var options map[string]interface{}
+	if len(current.Options) > 0 {
+		if err := json.Unmarshal(current.Options, &options); err != nil {
+			differences = append(differences, "options can not be read")
+		}
+	}
+	keys := make([]string, 0, len(spec.Options))
+	for key := range spec.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !matchesDeclared(spec.Options[key], options[key]) {
+			actual, _ := json.Marshal(options[key])
+			declared, _ := json.Marshal(spec.Options[key])
+			differences = append(differences, fmt.Sprintf("option %s is %s, declared %s", key, actual, declared))
+		}
+	}
+
+	var policies []string
+	if current.Proposal.AccessPolicies != nil {
+		for _, policy := range *current.Proposal.AccessPolicies {
+			policies = append(policies, policy.ID)
+		}
+	}
+	if !sameSet(policies, spec.AccessPolicies) {
+		differences = append(differences, fmt.Sprintf("access policies are [%s], declared [%s]", strings.Join(policies, ","), strings.Join(spec.AccessPolicies, ",")))
+	}
+
+	if current.Proposal.PaymentMethod != payment {
+		differences = append(differences, "price differs")
+	}
+	return differences
+}
+
+// matchesDeclared compares the declared option value with the actual one, only the declared keys of nested objects are compared.
+func matchesDeclared(declared, actual interface{}) bool {
+	declaredMap, ok := declared.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(declared, actual)
+	}
+	actualMap, ok := actual.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key, value := range declaredMap {
+		if !matchesDeclared(value, actualMap[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		counts[s]--
+		if counts[s] < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,266 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	providerID = "0x000000000000000000000000000000000000000a"
+	payoutID   = "0x000000000000000000000000000000000000000b"
+)
+
+// fakeNode keeps the state changed by the reconciler and records the changing calls.
+type fakeNode struct {
+	identities []string
+	status     string
+	payout     string
+	shaper     bool
+	services   tequilapi_client.ServiceListDTO
+	calls      []string
+}
+
+func (n *fakeNode) GetIdentities() ([]contract.IdentityRefDTO, error) {
+	var ids []contract.IdentityRefDTO
+	for _, id := range n.identities {
+		ids = append(ids, contract.IdentityRefDTO{Address: id})
+	}
+	return ids, nil
+}
+
+func (n *fakeNode) CurrentIdentity(identity, passphrase string) (contract.IdentityRefDTO, error) {
+	if passphrase != "secret" {
+		return contract.IdentityRefDTO{}, errors.New("wrong passphrase")
+	}
+	if len(n.identities) == 0 {
+		n.calls = append(n.calls, "create identity")
+		n.identities = append(n.identities, providerID)
+	}
+	return contract.IdentityRefDTO{Address: n.identities[0]}, nil
+}
+
+func (n *fakeNode) Identity(identityAddress string) (contract.IdentityDTO, error) {
+	return contract.IdentityDTO{Address: identityAddress, RegistrationStatus: n.status}, nil
+}
+
+func (n *fakeNode) GetTransactorFees() (tequilapi_client.Fees, error) {
+	return tequilapi_client.Fees{Registration: 100}, nil
+}
+
+func (n *fakeNode) RegisterIdentity(address, beneficiary string, stake, fee uint64) error {
+	n.calls = append(n.calls, fmt.Sprintf("register %s %s %d %d", address, beneficiary, stake, fee))
+	n.status = registry.InProgress.String()
+	return nil
+}
+
+func (n *fakeNode) PayoutInfo(identity string) (tequilapi_client.PayoutInfoDTO, error) {
+	if n.payout == "" {
+		return tequilapi_client.PayoutInfoDTO{}, errors.New("payout info not found")
+	}
+	return tequilapi_client.PayoutInfoDTO{EthAddress: n.payout}, nil
+}
+
+func (n *fakeNode) Payout(identity, ethAddress string) error {
+	n.calls = append(n.calls, "payout "+ethAddress)
+	n.payout = ethAddress
+	return nil
+}
+
+func (n *fakeNode) UserConfig() (tequilapi_client.ConfigDTO, error) {
+	return tequilapi_client.ConfigDTO{Data: map[string]interface{}{
+		"shaper": map[string]interface{}{"enabled": n.shaper},
+	}}, nil
+}
+
+func (n *fakeNode) SetUserConfig(data map[string]interface{}) (tequilapi_client.ConfigDTO, error) {
+	n.calls = append(n.calls, fmt.Sprintf("config %v", data))
+	n.shaper = data[shaperEnabledKey].(bool)
+	return n.UserConfig()
+}
+
+func (n *fakeNode) Services() (tequilapi_client.ServiceListDTO, error) {
+	return append(tequilapi_client.ServiceListDTO{}, n.services...), nil
+}
+
+func (n *fakeNode) ServiceStart(providerID, serviceType string, options interface{}, ap tequilapi_client.AccessPoliciesRequest, pm contract.PaymentMethodDTO) (tequilapi_client.ServiceInfoDTO, error) {
+	id := fmt.Sprintf("%s-%d", serviceType, len(n.calls))
+	n.calls = append(n.calls, "start "+id)
+	svc := newService(id, serviceType, options, ap.IDs, pm)
+	n.services = append(n.services, svc)
+	return svc, nil
+}
+
+func (n *fakeNode) ServiceStop(id string) error {
+	n.calls = append(n.calls, "stop "+id)
+	for i := range n.services {
+		if n.services[i].ID == id {
+			n.services = append(n.services[:i], n.services[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("service not found")
+}
+
+func newService(id, serviceType string, options interface{}, policies []string, pm contract.PaymentMethodDTO) tequilapi_client.ServiceInfoDTO {
+	raw, _ := json.Marshal(options)
+	var ap []market.AccessPolicy
+	for _, policy := range policies {
+		ap = append(ap, market.AccessPolicy{ID: policy})
+	}
+	return tequilapi_client.ServiceInfoDTO{
+		ID:          id,
+		ProviderID:  providerID,
+		ServiceType: serviceType,
+		Options:     raw,
+		Status:      "Running",
+		Proposal:    contract.ProposalDTO{AccessPolicies: &ap, PaymentMethod: pm},
+	}
+}
+
+func price(perGB, perMinute float64) contract.PaymentMethodDTO {
+	return contract.NewPaymentMethodDTO(pingpong.NewPaymentMethod(perGB, perMinute))
+}
+
+var (
+	priceMinute = 0.001
+	manifest    = Manifest{
+		Payout:  PayoutSpec{Address: payoutID},
+		Shaping: &ShapingSpec{Enabled: true},
+		Services: []ServiceSpec{{
+			Type:           "wireguard",
+			Options:        map[string]interface{}{"ports": "52820:52830", "dnsBlocklist": map[string]interface{}{"ads": true}},
+			AccessPolicies: []string{"mysterium"},
+			PriceMinute:    &priceMinute,
+		}},
+	}
+)
+
+func TestReconcile_BootstrapsNodeIdempotently(t *testing.T) {
+	node := &fakeNode{status: registry.Unregistered.String()}
+
+	report, err := Reconcile(node, manifest, "secret", false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, providerID, report.Identity)
+	assert.Equal(t, []Item{
+		{Resource: "identity", State: StateCreated, Detail: providerID},
+		{Resource: "registration", State: StateCreated, Detail: "registration requested"},
+		{Resource: "payout", State: StateCreated, Detail: payoutID},
+		{Resource: "shaping", State: StateUpdated, Detail: "enabled: true"},
+		{Resource: "service wireguard", State: StateCreated, Detail: "wireguard-4"},
+	}, report.Items)
+	assert.Equal(t, []string{
+		"create identity",
+		"register " + providerID + " " + payoutID + " 0 100",
+		"payout " + payoutID,
+		"config map[shaper.enabled:true]",
+		"start wireguard-4",
+	}, node.calls)
+	assert.False(t, report.Drifted())
+	assert.False(t, report.Failed())
+
+	// the node reports more options than declared, only the declared ones are compared
+	node.services[0].Options = json.RawMessage(`{"ports":"52820:52830","subnet":"10.182.0.0/16","dnsBlocklist":{"ads":true,"malware":false}}`)
+	node.calls = nil
+
+	report, err = Reconcile(node, manifest, "secret", false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{
+		{Resource: "identity", State: StateOK, Detail: providerID},
+		{Resource: "registration", State: StatePending, Detail: registry.InProgress.String()},
+		{Resource: "payout", State: StateOK, Detail: payoutID},
+		{Resource: "shaping", State: StateOK, Detail: "enabled: true"},
+		{Resource: "service wireguard", State: StateOK, Detail: "wireguard-4"},
+	}, report.Items)
+	assert.Empty(t, node.calls)
+}
+
+func TestReconcile_DryRunReportsDrift(t *testing.T) {
+	node := &fakeNode{
+		identities: []string{providerID},
+		status:     registry.RegisteredProvider.String(),
+		payout:     "0x000000000000000000000000000000000000000c",
+		services: tequilapi_client.ServiceListDTO{
+			newService("wg", "wireguard", map[string]interface{}{"ports": "51820", "dnsBlocklist": map[string]interface{}{"ads": true}}, nil, price(0, 0.002)),
+			newService("ovpn", "openvpn", map[string]interface{}{}, nil, price(0, 0)),
+		},
+	}
+
+	report, err := Reconcile(node, manifest, "secret", true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{
+		{Resource: "identity", State: StateOK, Detail: providerID},
+		{Resource: "registration", State: StateOK, Detail: registry.RegisteredProvider.String()},
+		{Resource: "payout", State: StateDrift, Detail: `payout address is "0x000000000000000000000000000000000000000c", declared ` + payoutID},
+		{Resource: "shaping", State: StateDrift, Detail: "enabled: false, declared true"},
+		{Resource: "service wireguard", State: StateDrift, Detail: `option ports is "51820", declared "52820:52830"; access policies are [], declared [mysterium]; price differs`},
+		{Resource: "service openvpn", State: StateDrift, Detail: "ovpn is not declared"},
+	}, report.Items)
+	assert.True(t, report.Drifted())
+	assert.Empty(t, node.calls)
+}
+
+func TestReconcile_RestartsDriftedAndPrunesUndeclaredServices(t *testing.T) {
+	node := &fakeNode{
+		identities: []string{providerID},
+		status:     registry.RegisteredProvider.String(),
+		payout:     payoutID,
+		shaper:     true,
+		services: tequilapi_client.ServiceListDTO{
+			newService("ovpn", "openvpn", map[string]interface{}{}, nil, price(0, 0)),
+			newService("wg", "wireguard", manifest.Services[0].Options, []string{"mysterium"}, price(0, 0.002)),
+		},
+	}
+
+	report, err := Reconcile(node, manifest, "secret", false, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{
+		{Resource: "identity", State: StateOK, Detail: providerID},
+		{Resource: "registration", State: StateOK, Detail: registry.RegisteredProvider.String()},
+		{Resource: "payout", State: StateOK, Detail: payoutID},
+		{Resource: "shaping", State: StateOK, Detail: "enabled: true"},
+		{Resource: "service wireguard", State: StateUpdated, Detail: "wireguard-1"},
+		{Resource: "service openvpn", State: StateRemoved, Detail: "ovpn"},
+	}, report.Items)
+	assert.Equal(t, []string{"stop wg", "start wireguard-1", "stop ovpn"}, node.calls)
+	assert.False(t, report.Drifted())
+}
+
+func TestReconcile_IdentityErrors(t *testing.T) {
+	node := &fakeNode{identities: []string{providerID}}
+
+	_, err := Reconcile(node, Manifest{Identity: IdentitySpec{Address: payoutID}}, "secret", false, false)
+	assert.EqualError(t, err, "identity "+payoutID+" is not in the keystore")
+
+	_, err = Reconcile(node, Manifest{}, "wrong", false, false)
+	assert.EqualError(t, err, "could not unlock identity: wrong passphrase")
+
+	report, err := Reconcile(&fakeNode{}, Manifest{}, "secret", true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{{Resource: "identity", State: StateDrift, Detail: "no identity, a new one would be created"}}, report.Items)
+}
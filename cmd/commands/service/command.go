@@ -64,8 +64,12 @@ func NewCommand(licenseCommandName string) *cli.Command {
 
 			cmd.RegisterSignalCallback(func() { quit <- nil })
 
+			tequilapiClient, err := di.LocalTequilapiClient(*nodeOptions)
+			if err != nil {
+				return err
+			}
 			cmdService := &serviceCommand{
-				tequilapi:    client.NewClient(nodeOptions.TequilapiAddress, nodeOptions.TequilapiPort),
+				tequilapi:    tequilapiClient,
 				errorChannel: quit,
 				ap: client.AccessPoliciesRequest{
 					IDs: services.SharedConfiguredOptions().AccessPolicyList,
@@ -158,6 +162,7 @@ func registerFlags(flags *[]cli.Flag) {
 	config.RegisterFlagsServiceShared(flags)
 	config.RegisterFlagsServiceOpenvpn(flags)
 	config.RegisterFlagsServiceWireguard(flags)
+	config.RegisterFlagsServiceProxy(flags)
 }
 
 // parseIdentityFlags function fills in service command options from CLI context
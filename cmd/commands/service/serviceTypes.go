@@ -23,6 +23,7 @@ import (
 	"github.com/mysteriumnetwork/node/services/noop"
 	"github.com/mysteriumnetwork/node/services/openvpn"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn/service"
+	"github.com/mysteriumnetwork/node/services/proxy"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
 	"github.com/mysteriumnetwork/node/session/pingpong"
@@ -31,7 +32,7 @@ import (
 )
 
 var (
-	serviceTypes = []string{"openvpn", "wireguard", "noop"}
+	serviceTypes = []string{"openvpn", "wireguard", "noop", "proxy"}
 
 	serviceTypesFlagsParser = map[string]func(ctx *cli.Context) (service.Options, contract.PaymentMethodDTO){
 		noop.ServiceType: func(ctx *cli.Context) (service.Options, contract.PaymentMethodDTO) {
@@ -57,5 +58,13 @@ var (
 			))
 			return wireguard_service.GetOptions(), payment
 		},
+		proxy.ServiceType: func(ctx *cli.Context) (service.Options, contract.PaymentMethodDTO) {
+			config.ParseFlagsServiceProxy(ctx)
+			payment := contract.NewPaymentMethodDTO(pingpong.NewPaymentMethod(
+				config.GetFloat64(config.FlagProxyPriceGB),
+				config.GetFloat64(config.FlagProxyPriceMinute),
+			))
+			return proxy.ParseFlags(ctx), payment
+		},
 	}
 )
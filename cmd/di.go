@@ -20,6 +20,8 @@ package cmd
 import (
 	"fmt"
 	"net"
+	"net/http"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -32,6 +34,7 @@ import (
 	"github.com/mysteriumnetwork/node/config"
 	appconfig "github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/consumer/bandwidth"
+	"github.com/mysteriumnetwork/node/consumer/favourite"
 	consumer_session "github.com/mysteriumnetwork/node/consumer/session"
 	"github.com/mysteriumnetwork/node/consumer/statistics"
 	"github.com/mysteriumnetwork/node/core/auth"
@@ -47,8 +50,10 @@ import (
 	"github.com/mysteriumnetwork/node/core/quality"
 	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/mysteriumnetwork/node/core/state"
+	"github.com/mysteriumnetwork/node/core/storage"
 	"github.com/mysteriumnetwork/node/core/storage/boltdb"
 	"github.com/mysteriumnetwork/node/core/storage/boltdb/migrations/history"
+	"github.com/mysteriumnetwork/node/core/storage/sqlite"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/feedback"
 	"github.com/mysteriumnetwork/node/firewall"
@@ -72,6 +77,7 @@ import (
 	service_openvpn "github.com/mysteriumnetwork/node/services/openvpn"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/mysteriumnetwork/node/session/messaging"
 	"github.com/mysteriumnetwork/node/session/pingpong"
 	"github.com/mysteriumnetwork/node/tequilapi"
 	tequilapi_endpoints "github.com/mysteriumnetwork/node/tequilapi/endpoints"
@@ -79,8 +85,12 @@ import (
 	paymentClient "github.com/mysteriumnetwork/payments/client"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
 )
 
+// userConfigWatchInterval defines how often user configuration file is checked for changes
+const userConfigWatchInterval = 2 * time.Second
+
 // UIServer represents our web server
 type UIServer interface {
 	Serve() error
@@ -100,17 +110,20 @@ type Dependencies struct {
 	BrokerConnector  *nats.BrokerConnector
 	BrokerConnection nats.Connection
 
-	NATService       nat.NATService
-	Storage          *boltdb.Bolt
-	Keystore         *identity.Keystore
-	IdentityManager  identity.Manager
-	SignerFactory    identity.SignerFactory
-	IdentityRegistry identity_registry.IdentityRegistry
-	IdentitySelector identity_selector.Handler
+	NATService         nat.NATService
+	Storage            storage.Storage
+	StorageJanitor     *storage.RetentionJanitor
+	Keystore           *identity.Keystore
+	IdentityManager    identity.Manager
+	SignerFactory      identity.SignerFactory
+	IdentityEncryption identity.Encryption
+	IdentityRegistry   identity_registry.IdentityRegistry
+	IdentitySelector   identity_selector.Handler
 
 	DiscoveryFactory   service.DiscoveryFactory
 	ProposalRepository proposal.Repository
 	DiscoveryWorker    brokerdiscovery.Worker
+	LANDiscoveryWorker brokerdiscovery.Worker
 
 	QualityClient *quality.MysteriumMORQA
 
@@ -122,9 +135,12 @@ type Dependencies struct {
 	StatisticsReporter               *statistics.SessionStatisticsReporter
 	SessionStorage                   *consumer_session.Storage
 	SessionConnectivityStatusStorage connectivity.StatusStorage
+	FavouriteStorage                 *favourite.Storage
 
 	EventBus eventbus.EventBus
 
+	ConfigWatcher *appconfig.UserConfigWatcher
+
 	ConnectionManager  connection.Manager
 	ConnectionRegistry *connection.Registry
 
@@ -151,16 +167,19 @@ type Dependencies struct {
 	ProviderRegistrar *registry.ProviderRegistrar
 
 	LogCollector *logconfig.Collector
+	Bundler      *feedback.Bundler
 	Reporter     *feedback.Reporter
 
-	ProviderInvoiceStorage   *pingpong.ProviderInvoiceStorage
-	ConsumerTotalsStorage    *pingpong.ConsumerTotalsStorage
-	AccountantPromiseStorage *pingpong.AccountantPromiseStorage
-	ConsumerBalanceTracker   *pingpong.ConsumerBalanceTracker
-	AccountantPromiseSettler pingpong.AccountantPromiseSettler
-	AccountantCaller         *pingpong.AccountantCaller
-	ChannelAddressCalculator *pingpong.ChannelAddressCalculator
-	AccountantPromiseHandler *pingpong.AccountantPromiseHandler
+	ProviderInvoiceStorage    *pingpong.ProviderInvoiceStorage
+	ConsumerTotalsStorage     *pingpong.ConsumerTotalsStorage
+	AccountantPromiseStorage  *pingpong.AccountantPromiseStorage
+	TransactionLedger         *pingpong.TransactionLedger
+	PaymentEvidenceStorage    *pingpong.PaymentEvidenceStorage
+	ConsumerBalanceTracker    *pingpong.ConsumerBalanceTracker
+	AccountantPromiseSettler  pingpong.AccountantPromiseSettler
+	AccountantCaller          *pingpong.AccountantCaller
+	ChannelAddressCalculator  *pingpong.ChannelAddressCalculator
+	AccountantPromiseHandlers pingpong.AccountantPromiseHandlers
 }
 
 // Bootstrap initiates all container dependencies
@@ -190,7 +209,7 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 
 	di.bootstrapEventBus()
 
-	if err := di.bootstrapStorage(nodeOptions.Directories.Storage); err != nil {
+	if err := di.bootstrapStorage(nodeOptions.Directories.Storage, nodeOptions.Storage); err != nil {
 		return err
 	}
 
@@ -198,7 +217,9 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 		return err
 	}
 
-	di.bootstrapIdentityComponents(nodeOptions)
+	if err := di.bootstrapIdentityComponents(nodeOptions); err != nil {
+		return err
+	}
 
 	if err := di.bootstrapDiscoveryComponents(nodeOptions.Discovery); err != nil {
 		return err
@@ -210,7 +231,9 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 		return err
 	}
 
-	di.bootstrapUIServer(nodeOptions)
+	if err := di.bootstrapUIServer(nodeOptions); err != nil {
+		return err
+	}
 	di.bootstrapMMN(nodeOptions)
 	if err := di.bootstrapNATComponents(nodeOptions); err != nil {
 		return err
@@ -223,8 +246,7 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 	} else {
 		di.PortMapper = mapping.NewNoopPortMapper(di.EventBus)
 	}
-	di.bootstrapP2P(nodeOptions.P2PPorts)
-	di.SessionConnectivityStatusStorage = connectivity.NewStatusStorage()
+	di.bootstrapP2P(nodeOptions.P2PPorts, nodeOptions.P2PDirectPort)
 
 	if err := di.bootstrapServices(nodeOptions, services.SharedConfiguredOptions()); err != nil {
 		return err
@@ -247,12 +269,31 @@ func (di *Dependencies) Bootstrap(nodeOptions node.Options) error {
 	}
 
 	appconfig.Current.EnableEventPublishing(di.EventBus)
+	if err := di.bootstrapConfigWatcher(); err != nil {
+		return err
+	}
 
 	log.Info().Msg("Mysterium node started!")
 	return nil
 }
 
-func (di *Dependencies) bootstrapP2P(p2pPorts *port.Range) {
+func (di *Dependencies) bootstrapConfigWatcher() error {
+	var flags []cli.Flag
+	if err := appconfig.RegisterFlagsNode(&flags); err != nil {
+		return err
+	}
+	appconfig.RegisterFlagsServiceShared(&flags)
+	appconfig.RegisterFlagsServiceOpenvpn(&flags)
+	appconfig.RegisterFlagsServiceWireguard(&flags)
+	appconfig.RegisterFlagsServiceProxy(&flags)
+	appconfig.Current.SetSchema(appconfig.NewSchema(flags))
+
+	di.ConfigWatcher = appconfig.NewUserConfigWatcher(appconfig.Current, userConfigWatchInterval)
+	di.ConfigWatcher.Start()
+	return nil
+}
+
+func (di *Dependencies) bootstrapP2P(p2pPorts *port.Range, directPort int) {
 	portPool := di.PortPool
 	natPinger := di.NATPinger
 	identityVerifier := identity.NewVerifierSigned()
@@ -262,7 +303,7 @@ func (di *Dependencies) bootstrapP2P(p2pPorts *port.Range) {
 		natPinger = traversal.NewNoopPinger()
 	}
 
-	di.P2PListener = p2p.NewListener(di.BrokerConnection, di.SignerFactory, identityVerifier, di.IPResolver, natPinger, portPool, di.PortMapper)
+	di.P2PListener = p2p.NewListener(di.BrokerConnection, di.SignerFactory, identityVerifier, di.IPResolver, natPinger, portPool, di.PortMapper, directPort)
 	di.P2PDialer = p2p.NewDialer(di.BrokerConnector, di.SignerFactory, identityVerifier, di.IPResolver, natPinger, portPool)
 }
 
@@ -275,6 +316,32 @@ func (di *Dependencies) createTequilaListener(nodeOptions node.Options) (net.Lis
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("the port %v seems to be taken. Either you're already running a node or it is already used by another application", nodeOptions.TequilapiPort))
 	}
+
+	transport := nodeOptions.Tequilapi
+	if transport.TLS {
+		if transport.AutoCert {
+			hosts := []string{"localhost", "127.0.0.1", nodeOptions.TequilapiAddress}
+			if err := tequilapi.EnsureSelfSignedCert(transport.TLSCert, transport.TLSKey, hosts); err != nil {
+				tequilaListener.Close()
+				return nil, err
+			}
+		}
+		tlsConfig, err := tequilapi.NewTLSConfig(transport.TLSCert, transport.TLSKey, transport.TLSClientCA)
+		if err != nil {
+			tequilaListener.Close()
+			return nil, err
+		}
+		tequilaListener = tequilapi.NewTLSListener(tequilaListener, tlsConfig)
+	}
+
+	if transport.SocketPath != "" {
+		socketListener, err := tequilapi.NewUnixListener(transport.SocketPath, transport.SocketMode)
+		if err != nil {
+			tequilaListener.Close()
+			return nil, errors.Wrap(err, "failed to listen on Tequilapi socket")
+		}
+		tequilaListener = tequilapi.NewMultiListener(tequilaListener, socketListener)
+	}
 	return tequilaListener, nil
 }
 
@@ -344,6 +411,10 @@ func (di *Dependencies) Shutdown() (err error) {
 		di.PolicyOracle.Stop()
 	}
 
+	if di.ConfigWatcher != nil {
+		di.ConfigWatcher.Stop()
+	}
+
 	if di.NATService != nil {
 		if err := di.NATService.Disable(); err != nil {
 			errs = append(errs, err)
@@ -352,6 +423,14 @@ func (di *Dependencies) Shutdown() (err error) {
 	if di.DiscoveryWorker != nil {
 		di.DiscoveryWorker.Stop()
 	}
+	if di.LANDiscoveryWorker != nil {
+		di.LANDiscoveryWorker.Stop()
+	}
+	if di.P2PListener != nil {
+		if err := di.P2PListener.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if di.BrokerConnection != nil {
 		di.BrokerConnection.Close()
 	}
@@ -370,6 +449,9 @@ func (di *Dependencies) Shutdown() (err error) {
 			errs = append(errs, err)
 		}
 	}
+	if di.StorageJanitor != nil {
+		di.StorageJanitor.Stop()
+	}
 	if di.Storage != nil {
 		if err := di.Storage.Close(); err != nil {
 			errs = append(errs, err)
@@ -379,24 +461,78 @@ func (di *Dependencies) Shutdown() (err error) {
 	return nil
 }
 
-func (di *Dependencies) bootstrapStorage(path string) error {
-	localStorage, err := boltdb.NewStorage(path)
-	if err != nil {
-		return err
+// StorageKinds lists the records the node keeps in its database.
+func StorageKinds() []storage.Kind {
+	var kinds []storage.Kind
+	kinds = append(kinds, consumer_session.StorageKinds()...)
+	kinds = append(kinds, pingpong.StorageKinds()...)
+	kinds = append(kinds, registry.StorageKinds()...)
+	kinds = append(kinds, auth.StorageKinds()...)
+	kinds = append(kinds, connectivity.StorageKinds()...)
+	kinds = append(kinds, favourite.StorageKinds()...)
+	return kinds
+}
+
+func openStorage(path, backend string) (storage.Storage, error) {
+	switch backend {
+	case node.StorageBackendSQLite:
+		_, err := os.Stat(sqlite.DatabaseFile(path))
+		if os.IsNotExist(err) {
+			if _, err := os.Stat(boltdb.DatabaseFile(path)); err == nil {
+				return nil, errors.New("bolt database has to be migrated with 'myst db migrate-sqlite' before switching to SQLite")
+			}
+		}
+		return sqlite.NewStorage(path)
+	case node.StorageBackendBolt, "":
+		localStorage, err := boltdb.NewStorage(path)
+		if err != nil {
+			return nil, err
+		}
+
+		migrator := boltdb.NewMigrator(localStorage)
+		if err := migrator.RunMigrations(history.Sequence); err != nil {
+			localStorage.Close()
+			return nil, err
+		}
+		return localStorage, nil
+	default:
+		return nil, errors.Errorf("unknown storage backend %q", backend)
 	}
+}
 
-	migrator := boltdb.NewMigrator(localStorage)
-	err = migrator.RunMigrations(history.Sequence)
+func (di *Dependencies) bootstrapStorage(path string, options node.OptionsStorage) error {
+	localStorage, err := openStorage(path, options.Backend)
 	if err != nil {
 		return err
 	}
 
 	di.Storage = localStorage
 
+	retentionPolicies := []storage.RetentionPolicy{
+		consumer_session.RetentionPolicy(options.SessionHistoryRetention),
+		pingpong.SettlementHistoryRetentionPolicy(options.SettlementHistoryRetention),
+		connectivity.RetentionPolicy(options.ConnectivityStatusRetention),
+	}
+	retentionPolicies = append(retentionPolicies, pingpong.PaymentEvidenceRetentionPolicies(options.PaymentEvidenceRetention)...)
+	di.StorageJanitor = storage.NewRetentionJanitor(di.Storage, retentionPolicies, storage.RetentionCheckInterval)
+	di.StorageJanitor.Start()
+
 	invoiceStorage := pingpong.NewInvoiceStorage(di.Storage)
 	di.ProviderInvoiceStorage = pingpong.NewProviderInvoiceStorage(invoiceStorage)
 	di.ConsumerTotalsStorage = pingpong.NewConsumerTotalsStorage(di.Storage, di.EventBus)
 	di.AccountantPromiseStorage = pingpong.NewAccountantPromiseStorage(di.Storage)
+	di.TransactionLedger = pingpong.NewTransactionLedger(di.Storage)
+	if err := di.TransactionLedger.Subscribe(di.EventBus); err != nil {
+		return err
+	}
+	di.PaymentEvidenceStorage = pingpong.NewPaymentEvidenceStorage(di.Storage)
+	statusStorage := connectivity.NewStatusStorage(di.Storage)
+	if err := statusStorage.Subscribe(di.EventBus); err != nil {
+		return err
+	}
+	di.SessionConnectivityStatusStorage = statusStorage
+	di.FavouriteStorage = favourite.NewStorage(di.Storage)
+
 	di.SessionStorage = consumer_session.NewSessionStorage(di.Storage)
 	return di.SessionStorage.Subscribe(di.EventBus)
 }
@@ -464,36 +600,49 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 		return errors.Wrap(err, "could not subscribe consumer balance tracker to relevant events")
 	}
 
-	di.AccountantPromiseHandler = pingpong.NewAccountantPromiseHandler(pingpong.AccountantPromiseHandlerDeps{
-		AccountantPromiseStorage: di.AccountantPromiseStorage,
-		AccountantCaller:         di.AccountantCaller,
-		AccountantID:             common.HexToAddress(nodeOptions.Accountant.AccountantID),
-		FeeProvider:              di.Transactor,
-		Encryption:               di.Keystore,
-		EventBus:                 di.EventBus,
-	})
+	di.AccountantPromiseHandlers = make(pingpong.AccountantPromiseHandlers)
+	for _, accountant := range nodeOptions.Accountant.Accountants() {
+		accountantCaller := di.AccountantCaller
+		if accountant.ID != nodeOptions.Accountant.AccountantID {
+			accountantCaller = pingpong.NewAccountantCaller(di.HTTPClient, accountant.EndpointAddress)
+		}
+		di.AccountantPromiseHandlers[common.HexToAddress(accountant.ID)] = pingpong.NewAccountantPromiseHandler(pingpong.AccountantPromiseHandlerDeps{
+			AccountantPromiseStorage: di.AccountantPromiseStorage,
+			AccountantCaller:         accountantCaller,
+			AccountantID:             common.HexToAddress(accountant.ID),
+			FeeProvider:              di.Transactor,
+			Encryption:               di.IdentityEncryption,
+			EventBus:                 di.EventBus,
+		})
+	}
 
-	if err := di.AccountantPromiseHandler.Subscribe(di.EventBus); err != nil {
+	if err := di.AccountantPromiseHandlers.Subscribe(di.EventBus); err != nil {
 		return err
 	}
 
+	connectionConfig := connection.DefaultConfig()
+	connectionConfig.LocalDNS.Cache = nodeOptions.DNS.Cache
+	connectionConfig.LocalDNS.Prefetch = nodeOptions.DNS.Prefetch
+	connectionConfig.LocalDNS.LogQueries = nodeOptions.DNS.LogQueries
+	connectionConfig.P2POnly = nodeOptions.P2POnly
+
 	di.ConnectionRegistry = connection.NewRegistry()
 	di.ConnectionManager = connection.NewManager(
 		dialogFactory,
 		pingpong.ExchangeFactoryFunc(
-			di.Keystore,
 			di.SignerFactory,
 			di.ConsumerTotalsStorage,
 			nodeOptions.Transactor.ChannelImplementation,
 			nodeOptions.Transactor.RegistryAddress,
 			di.EventBus,
 			nodeOptions.Payments.ConsumerDataLeewayMegabytes,
+			di.PaymentEvidenceStorage,
 		),
 		di.ConnectionRegistry.CreateConnection,
 		di.EventBus,
 		connectivity.NewStatusSender(),
 		di.IPResolver,
-		connection.DefaultConfig(),
+		connectionConfig,
 		connection.DefaultStatsReportInterval,
 		connection.NewValidator(
 			di.ConsumerBalanceTracker,
@@ -512,6 +661,7 @@ func (di *Dependencies) bootstrapNodeComponents(nodeOptions node.Options, tequil
 	if err := di.bootstrapStateKeeper(nodeOptions); err != nil {
 		return err
 	}
+	di.Bundler = feedback.NewBundler(di.LogCollector, config.Current, di.StateKeeper, di.SessionConnectivityStatusStorage)
 
 	tequilapiHTTPServer, err := di.bootstrapTequilapi(nodeOptions, tequilaListener)
 	if err != nil {
@@ -531,7 +681,8 @@ func (di *Dependencies) bootstrapTequilapi(nodeOptions node.Options, listener ne
 	tequilapi_endpoints.AddRouteForStop(router, utils.SoftKiller(di.Shutdown))
 	tequilapi_endpoints.AddRoutesForAuthentication(router, di.Authenticator, di.JWTAuthenticator)
 	tequilapi_endpoints.AddRoutesForIdentities(router, di.IdentityManager, di.IdentitySelector, di.IdentityRegistry, di.ConsumerBalanceTracker, di.ChannelAddressCalculator, di.AccountantPromiseSettler)
-	tequilapi_endpoints.AddRoutesForConnection(router, di.ConnectionManager, di.StateKeeper, di.ProposalRepository, di.IdentityRegistry)
+	accountantSelector := pingpong.NewAccountantSelector(di.BCHelper, accountantAddresses(nodeOptions.Accountant))
+	tequilapi_endpoints.AddRoutesForConnection(router, di.ConnectionManager, di.StateKeeper, di.ProposalRepository, di.IdentityRegistry, accountantSelector)
 	tequilapi_endpoints.AddRoutesForConnectionSessions(router, di.SessionStorage)
 	tequilapi_endpoints.AddRoutesForConnectionLocation(router, di.IPResolver, di.LocationResolver, di.LocationResolver)
 	tequilapi_endpoints.AddRoutesForProposals(router, di.ProposalRepository, di.QualityClient)
@@ -541,8 +692,11 @@ func (di *Dependencies) bootstrapTequilapi(nodeOptions node.Options, listener ne
 	tequilapi_endpoints.AddRoutesForAccessPolicies(di.HTTPClient, router, services.SharedConfiguredOptions().AccessPolicyAddress)
 	tequilapi_endpoints.AddRoutesForNAT(router, di.StateKeeper)
 	tequilapi_endpoints.AddRoutesForTransactor(router, di.Transactor, di.AccountantPromiseSettler)
+	tequilapi_endpoints.AddRoutesForLedger(router, di.TransactionLedger)
+	tequilapi_endpoints.AddRoutesForPaymentEvidence(router, di.PaymentEvidenceStorage, di.SignerFactory)
+	tequilapi_endpoints.AddRoutesForStorage(router, di.Storage)
 	tequilapi_endpoints.AddRoutesForConfig(router)
-	tequilapi_endpoints.AddRoutesForFeedback(router, di.Reporter)
+	tequilapi_endpoints.AddRoutesForFeedback(router, di.Reporter, di.Bundler)
 	tequilapi_endpoints.AddRoutesForConnectivityStatus(router, di.SessionConnectivityStatusStorage)
 	if err := tequilapi_endpoints.AddRoutesForSSE(router, di.StateKeeper, di.EventBus); err != nil {
 		return nil, err
@@ -551,9 +705,17 @@ func (di *Dependencies) bootstrapTequilapi(nodeOptions node.Options, listener ne
 	if config.GetBool(config.FlagPProfEnable) {
 		tequilapi_endpoints.AddRoutesForPProf(router)
 	}
+	if err := tequilapi_endpoints.AddRoutesForV2(router, metadata.VersionAsString()); err != nil {
+		return nil, err
+	}
+
+	var handler http.Handler = router
+	if nodeOptions.TequilapiAuth {
+		handler = tequilapi.ApplyAuthorization(router, auth.NewTokenValidator(di.JWTAuthenticator, di.Authenticator), tequilapi.DefaultRouteScopes)
+	}
 
 	corsPolicy := tequilapi.NewMysteriumCorsPolicy()
-	return tequilapi.NewServer(listener, router, corsPolicy), nil
+	return tequilapi.NewServer(listener, handler, corsPolicy), nil
 }
 
 func newSessionManagerFactory(
@@ -566,13 +728,14 @@ func newSessionManagerFactory(
 	serviceID string,
 	eventbus eventbus.EventBus,
 	bcHelper *paymentClient.BlockchainWithRetries,
-	promiseHandler *pingpong.AccountantPromiseHandler,
+	promiseHandlers pingpong.AccountantPromiseHandlers,
+	evidenceStorage *pingpong.PaymentEvidenceStorage,
 	httpClient *requests.HTTPClient,
 	keystore *identity.Keystore,
 ) session.ManagerFactory {
 	return func(dialog communication.Dialog) *session.Manager {
 		paymentEngineFactory := pingpong.InvoiceFactoryCreator(
-			dialog, nil, nodeOptions.Payments.ProviderInvoiceFrequency,
+			messaging.NewDialogProvider(dialog), nodeOptions.Payments.ProviderInvoiceFrequency,
 			pingpong.PromiseWaitTimeout, providerInvoiceStorage,
 			nodeOptions.Transactor.RegistryAddress,
 			nodeOptions.Transactor.ChannelImplementation,
@@ -581,7 +744,8 @@ func newSessionManagerFactory(
 			bcHelper,
 			eventbus,
 			proposal,
-			promiseHandler,
+			promiseHandlers,
+			evidenceStorage,
 		)
 		return session.NewManager(
 			proposal,
@@ -597,6 +761,15 @@ func newSessionManagerFactory(
 	}
 }
 
+func accountantAddresses(options node.OptionsAccountant) []common.Address {
+	accountants := options.Accountants()
+	addresses := make([]common.Address, len(accountants))
+	for i, accountant := range accountants {
+		addresses[i] = common.HexToAddress(accountant.ID)
+	}
+	return addresses
+}
+
 // function decides on network definition combined from testnet/localnet flags and possible overrides
 func (di *Dependencies) bootstrapNetworkComponents(options node.Options) (err error) {
 	optionsNetwork := options.OptionsNetwork
@@ -675,7 +848,7 @@ func (di *Dependencies) bootstrapEventBus() {
 	di.EventBus = eventbus.New()
 }
 
-func (di *Dependencies) bootstrapIdentityComponents(options node.Options) {
+func (di *Dependencies) bootstrapIdentityComponents(options node.Options) error {
 	var ks *keystore.KeyStore
 	if options.Keystore.UseLightweight {
 		log.Debug().Msg("Using lightweight keystore")
@@ -687,8 +860,19 @@ func (di *Dependencies) bootstrapIdentityComponents(options node.Options) {
 
 	di.Keystore = identity.NewKeystoreFilesystem(options.Directories.Keystore, ks, keystore.DecryptKey)
 	di.IdentityManager = identity.NewIdentityManager(di.Keystore, di.EventBus)
-	di.SignerFactory = func(id identity.Identity) identity.Signer {
-		return identity.NewSigner(di.Keystore, id)
+	if options.Keystore.ExternalSigner != "" {
+		log.Info().Msgf("Using external signer: %s", options.Keystore.ExternalSigner)
+		signerFactory, err := identity.NewExternalSignerFactory(options.Keystore.ExternalSigner, options.Keystore.ExternalSignerTimeout)
+		if err != nil {
+			return err
+		}
+		di.SignerFactory = signerFactory
+		di.IdentityEncryption = identity.NewSignerEncryption(signerFactory)
+	} else {
+		di.SignerFactory = func(id identity.Identity) identity.Signer {
+			return identity.NewSigner(di.Keystore, id)
+		}
+		di.IdentityEncryption = di.Keystore
 	}
 	di.IdentitySelector = identity_selector.NewHandler(
 		di.IdentityManager,
@@ -696,7 +880,7 @@ func (di *Dependencies) bootstrapIdentityComponents(options node.Options) {
 		identity.NewIdentityCache(options.Directories.Keystore, "remember.json"),
 		di.SignerFactory,
 	)
-
+	return nil
 }
 
 func (di *Dependencies) bootstrapQualityComponents(bindAddress string, options node.OptionsQuality) (err error) {
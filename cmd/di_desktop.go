@@ -18,6 +18,7 @@
 package cmd
 
 import (
+	"crypto/tls"
 	"fmt"
 	"time"
 
@@ -42,6 +43,7 @@ import (
 	service_openvpn "github.com/mysteriumnetwork/node/services/openvpn"
 	openvpn_discovery "github.com/mysteriumnetwork/node/services/openvpn/discovery"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn/service"
+	service_proxy "github.com/mysteriumnetwork/node/services/proxy"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 	wireguard_connection "github.com/mysteriumnetwork/node/services/wireguard/connection"
 	"github.com/mysteriumnetwork/node/services/wireguard/endpoint"
@@ -49,7 +51,9 @@ import (
 	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/mysteriumnetwork/node/session/messaging"
 	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/mysteriumnetwork/node/tequilapi"
 	pingpong_noop "github.com/mysteriumnetwork/node/session/pingpong/noop"
 	"github.com/mysteriumnetwork/node/ui"
 	uinoop "github.com/mysteriumnetwork/node/ui/noop"
@@ -60,7 +64,7 @@ import (
 
 // bootstrapServices loads all the components required for running services
 func (di *Dependencies) bootstrapServices(nodeOptions node.Options, servicesOptions config.ServicesOptions) error {
-	if nodeOptions.MobileConsumer {
+	if nodeOptions.MobileConsumer && !nodeOptions.MobileProvider {
 		return nil
 	}
 
@@ -69,9 +73,14 @@ func (di *Dependencies) bootstrapServices(nodeOptions node.Options, servicesOpti
 		return errors.Wrap(err, "service bootstrap failed")
 	}
 
+	if nodeOptions.MobileProvider {
+		return nil
+	}
+
 	di.bootstrapServiceOpenvpn(nodeOptions)
 	di.bootstrapServiceNoop(nodeOptions)
 	di.bootstrapServiceWireguard(nodeOptions)
+	di.bootstrapServiceProxy(nodeOptions)
 
 	return nil
 }
@@ -174,8 +183,22 @@ func (di *Dependencies) bootstrapServiceNoop(nodeOptions node.Options) {
 	)
 }
 
+func (di *Dependencies) bootstrapServiceProxy(nodeOptions node.Options) {
+	di.ServiceRegistry.Register(
+		service_proxy.ServiceType,
+		func(serviceOptions service.Options) (service.Service, market.ServiceProposal, error) {
+			loc, err := di.LocationResolver.DetectLocation()
+			if err != nil {
+				return nil, market.ServiceProposal{}, err
+			}
+
+			return service_proxy.NewManager(di.EventBus), service_proxy.GetProposal(loc), nil
+		},
+	)
+}
+
 func (di *Dependencies) bootstrapProviderRegistrar(nodeOptions node.Options) error {
-	if nodeOptions.MobileConsumer {
+	if nodeOptions.MobileConsumer && !nodeOptions.MobileProvider {
 		return nil
 	}
 
@@ -191,30 +214,43 @@ func (di *Dependencies) bootstrapProviderRegistrar(nodeOptions node.Options) err
 }
 
 func (di *Dependencies) bootstrapAccountantPromiseSettler(nodeOptions node.Options) error {
-	if nodeOptions.MobileConsumer {
+	if nodeOptions.MobileConsumer && !nodeOptions.MobileProvider {
 		di.AccountantPromiseSettler = &pingpong_noop.NoopAccountantPromiseSettler{}
 		return nil
 	}
 
-	di.AccountantPromiseSettler = pingpong.NewAccountantPromiseSettler(
-		di.EventBus,
-		di.Transactor,
-		di.AccountantPromiseStorage,
-		di.BCHelper,
-		di.IdentityRegistry,
-		di.Keystore,
-		pingpong.AccountantPromiseSettlerConfig{
-			AccountantAddress:    common.HexToAddress(nodeOptions.Accountant.AccountantID),
-			Threshold:            nodeOptions.Payments.AccountantPromiseSettlingThreshold,
-			MaxWaitForSettlement: nodeOptions.Payments.SettlementTimeout,
-		},
-	)
+	history := pingpong.NewSettlementHistoryStorage(di.Storage)
+	settlers := make(map[common.Address]pingpong.AccountantPromiseSettler)
+	for _, accountantID := range accountantAddresses(nodeOptions.Accountant) {
+		settlers[accountantID] = pingpong.NewAccountantPromiseSettler(
+			di.EventBus,
+			di.Transactor,
+			di.AccountantPromiseStorage,
+			history,
+			di.BCHelper,
+			di.IdentityRegistry,
+			di.Keystore,
+			pingpong.AccountantPromiseSettlerConfig{
+				AccountantAddress:    accountantID,
+				Threshold:            nodeOptions.Payments.AccountantPromiseSettlingThreshold,
+				MaxWaitForSettlement: nodeOptions.Payments.SettlementTimeout,
+				Schedule:             nodeOptions.Payments.SettlementSchedule,
+				FeeMultiplier:        nodeOptions.Payments.SettlementMinFeeMultiplier,
+				MaxSettlementsPerDay: nodeOptions.Payments.SettlementMaxPerDay,
+			},
+		)
+	}
+	di.AccountantPromiseSettler = pingpong.NewMultiAccountantPromiseSettler(common.HexToAddress(nodeOptions.Accountant.AccountantID), settlers)
 	return di.AccountantPromiseSettler.Subscribe()
 }
 
 // bootstrapServiceComponents initiates ServicesManager dependency
 func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options, servicesOptions config.ServicesOptions) error {
-	di.NATService = nat.NewService()
+	if nodeOptions.MobileProvider {
+		di.NATService = nat.NewNoopService()
+	} else {
+		di.NATService = nat.NewService()
+	}
 	if err := di.NATService.Enable(); err != nil {
 		log.Warn().Err(err).Msg("Failed to enable NAT forwarding")
 	}
@@ -238,8 +274,8 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options, ser
 		), nil
 	}
 	newP2PSessionHandler := func(proposal market.ServiceProposal, serviceID string, channel p2p.Channel) *session.Manager {
-		paymentEngineFactory := pingpong.InvoiceFactoryCreator(nil,
-			channel, nodeOptions.Payments.ProviderInvoiceFrequency,
+		paymentEngineFactory := pingpong.InvoiceFactoryCreator(
+			messaging.NewP2PProvider(channel), nodeOptions.Payments.ProviderInvoiceFrequency,
 			pingpong.PromiseWaitTimeout, di.ProviderInvoiceStorage,
 			nodeOptions.Transactor.RegistryAddress,
 			nodeOptions.Transactor.ChannelImplementation,
@@ -248,7 +284,8 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options, ser
 			di.BCHelper,
 			di.EventBus,
 			proposal,
-			di.AccountantPromiseHandler,
+			di.AccountantPromiseHandlers,
+			di.PaymentEvidenceStorage,
 		)
 		return session.NewManager(
 			proposal,
@@ -274,7 +311,8 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options, ser
 			serviceID,
 			di.EventBus,
 			di.BCHelper,
-			di.AccountantPromiseHandler,
+			di.AccountantPromiseHandlers,
+			di.PaymentEvidenceStorage,
 			di.HTTPClient,
 			di.Keystore,
 		)
@@ -287,6 +325,14 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options, ser
 		), nil
 	}
 
+	if nodeOptions.P2POnly {
+		newDialogWaiter, newDialogHandler = nil, nil
+	}
+
+	var acceptedAccountants []string
+	for _, accountantID := range accountantAddresses(nodeOptions.Accountant) {
+		acceptedAccountants = append(acceptedAccountants, accountantID.Hex())
+	}
 	di.ServicesManager = service.NewManager(
 		di.ServiceRegistry,
 		newDialogWaiter,
@@ -297,6 +343,7 @@ func (di *Dependencies) bootstrapServiceComponents(nodeOptions node.Options, ser
 		di.P2PListener,
 		newP2PSessionHandler,
 		di.SessionConnectivityStatusStorage,
+		acceptedAccountants,
 	)
 
 	serviceCleaner := service.Cleaner{SessionStorage: di.ServiceSessionStorage}
@@ -316,6 +363,7 @@ func (di *Dependencies) registerConnections(nodeOptions node.Options) {
 	di.registerOpenvpnConnection(nodeOptions)
 	di.registerNoopConnection()
 	di.registerWireguardConnection(nodeOptions)
+	di.registerProxyConnection()
 }
 
 func (di *Dependencies) registerWireguardConnection(nodeOptions node.Options) {
@@ -336,13 +384,30 @@ func (di *Dependencies) registerWireguardConnection(nodeOptions node.Options) {
 	di.ConnectionRegistry.Register(wireguard.ServiceType, connFactory)
 }
 
-func (di *Dependencies) bootstrapUIServer(options node.Options) {
+func (di *Dependencies) registerProxyConnection() {
+	service_proxy.Bootstrap()
+	connFactory := func() (connection.Connection, error) {
+		return service_proxy.NewConnection(config.GetString(config.FlagProxyLocalAddress))
+	}
+	di.ConnectionRegistry.Register(service_proxy.ServiceType, connFactory)
+}
+
+func (di *Dependencies) bootstrapUIServer(options node.Options) error {
 	if options.UI.UIEnabled {
-		di.UIServer = ui.NewServer(options.BindAddress, options.UI.UIPort, options.TequilapiPort, di.JWTAuthenticator, di.HTTPClient)
-		return
+		var tequilapiTLS *tls.Config
+		if options.Tequilapi.TLS {
+			var err error
+			tequilapiTLS, err = tequilapi.NewLocalClientTLSConfig(options.Tequilapi.TLSCert, options.Tequilapi.TLSKey)
+			if err != nil {
+				return err
+			}
+		}
+		di.UIServer = ui.NewServer(options.BindAddress, options.UI.UIPort, options.TequilapiPort, tequilapiTLS, di.JWTAuthenticator, di.HTTPClient)
+		return nil
 	}
 
 	di.UIServer = uinoop.NewServer()
+	return nil
 }
 
 func (di *Dependencies) bootstrapMMN(options node.Options) {
@@ -23,6 +23,7 @@ import (
 	"github.com/mysteriumnetwork/node/core/discovery"
 	"github.com/mysteriumnetwork/node/core/discovery/apidiscovery"
 	"github.com/mysteriumnetwork/node/core/discovery/brokerdiscovery"
+	"github.com/mysteriumnetwork/node/core/discovery/landiscovery"
 	"github.com/mysteriumnetwork/node/core/node"
 	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/pkg/errors"
@@ -48,6 +49,19 @@ func (di *Dependencies) bootstrapDiscoveryComponents(options node.OptionsDiscove
 				}
 			}
 			proposalRepository.Add(brokerRepository)
+		case node.DiscoveryTypeLAN:
+			discoveryRegistry.AddRegistry(landiscovery.NewRegistry())
+
+			storage := brokerdiscovery.NewStorage(di.EventBus)
+			lanRepository := landiscovery.NewRepository(storage, options.FetchInterval)
+			if options.FetchEnabled {
+				di.LANDiscoveryWorker = lanRepository
+				if err := di.LANDiscoveryWorker.Start(); err != nil {
+					return errors.Wrap(err, "failed to enable LAN discovery")
+				}
+			}
+			// Providers found on the local network are preferred over the same ones found elsewhere.
+			proposalRepository.AddPreferred(lanRepository)
 		default:
 			return errors.Errorf("unknown discovery adapter: %s", discoveryType)
 		}
@@ -22,7 +22,10 @@ import (
 
 	command_cli "github.com/mysteriumnetwork/node/cmd/commands/cli"
 	"github.com/mysteriumnetwork/node/cmd/commands/daemon"
+	"github.com/mysteriumnetwork/node/cmd/commands/db"
+	"github.com/mysteriumnetwork/node/cmd/commands/feedback"
 	"github.com/mysteriumnetwork/node/cmd/commands/license"
+	"github.com/mysteriumnetwork/node/cmd/commands/provider"
 	"github.com/mysteriumnetwork/node/cmd/commands/service"
 	"github.com/mysteriumnetwork/node/cmd/commands/version"
 	"github.com/mysteriumnetwork/node/config"
@@ -37,12 +40,15 @@ var (
 		"run command 'license --warranty'",
 		"run command 'license --conditions'",
 	)
-	versionSummary = metadata.VersionAsSummary(licenseCopyright)
-	daemonCommand  = daemon.NewCommand()
-	versionCommand = version.NewCommand(versionSummary)
-	licenseCommand = license.NewCommand(licenseCopyright)
-	serviceCommand = service.NewCommand(licenseCommand.Name)
-	cliCommand     = command_cli.NewCommand()
+	versionSummary  = metadata.VersionAsSummary(licenseCopyright)
+	daemonCommand   = daemon.NewCommand()
+	versionCommand  = version.NewCommand(versionSummary)
+	licenseCommand  = license.NewCommand(licenseCopyright)
+	serviceCommand  = service.NewCommand(licenseCommand.Name)
+	cliCommand      = command_cli.NewCommand()
+	dbCommand       = db.NewCommand()
+	feedbackCommand = feedback.NewCommand()
+	providerCommand = provider.NewCommand()
 )
 
 func main() {
@@ -82,6 +88,9 @@ func NewCommand() (*cli.App, error) {
 		serviceCommand,
 		daemonCommand,
 		cliCommand,
+		dbCommand,
+		feedbackCommand,
+		providerCommand,
 	}
 
 	return app, nil
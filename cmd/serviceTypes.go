@@ -21,6 +21,7 @@ import (
 	service_noop "github.com/mysteriumnetwork/node/services/noop"
 	service_openvpn "github.com/mysteriumnetwork/node/services/openvpn"
 	openvpn_service "github.com/mysteriumnetwork/node/services/openvpn/service"
+	service_proxy "github.com/mysteriumnetwork/node/services/proxy"
 	service_wireguard "github.com/mysteriumnetwork/node/services/wireguard"
 	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
 	"github.com/mysteriumnetwork/node/tequilapi/endpoints"
@@ -31,5 +32,6 @@ var (
 		service_noop.ServiceType:      service_noop.ParseJSONOptions,
 		service_openvpn.ServiceType:   openvpn_service.ParseJSONOptions,
 		service_wireguard.ServiceType: wireguard_service.ParseJSONOptions,
+		service_proxy.ServiceType:     service_proxy.ParseJSONOptions,
 	}
 )
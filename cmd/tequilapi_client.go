@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"github.com/mysteriumnetwork/node/core/auth"
+	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/tequilapi"
+	tequilapi_client "github.com/mysteriumnetwork/node/tequilapi/client"
+)
+
+// NewTequilapiClient creates Tequilapi client for local tools, connecting over unix socket or TLS if configured.
+func NewTequilapiClient(options node.Options, token string) (*tequilapi_client.Client, error) {
+	clientOptions := tequilapi_client.Options{
+		Token:      token,
+		SocketPath: options.Tequilapi.SocketPath,
+	}
+	if clientOptions.SocketPath == "" && options.Tequilapi.TLS {
+		tlsConfig, err := tequilapi.NewLocalClientTLSConfig(options.Tequilapi.TLSCert, options.Tequilapi.TLSKey)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.TLS = tlsConfig
+	}
+
+	return tequilapi_client.NewClientWithOptions(options.TequilapiAddress, options.TequilapiPort, clientOptions), nil
+}
+
+// LocalTequilapiClient creates Tequilapi client for commands running the node in the same process.
+func (di *Dependencies) LocalTequilapiClient(options node.Options) (*tequilapi_client.Client, error) {
+	var token string
+	if options.TequilapiAuth {
+		jwt, err := di.JWTAuthenticator.CreateToken("myst", auth.Scopes{auth.ScopeAdmin})
+		if err != nil {
+			return nil, err
+		}
+		token = jwt.Token
+	}
+	return NewTequilapiClient(options, token)
+}
@@ -19,7 +19,12 @@ package config
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -44,6 +49,9 @@ type Config struct {
 	user               map[string]interface{}
 	cli                map[string]interface{}
 	eventBus           eventbus.EventBus
+	schema             *Schema
+	history            *ChangeHistory
+	lock               sync.RWMutex
 }
 
 // Current global configuration instance.
@@ -56,6 +64,7 @@ func NewConfig() *Config {
 		defaults:           make(map[string]interface{}),
 		user:               make(map[string]interface{}),
 		cli:                make(map[string]interface{}),
+		history:            NewChangeHistory(""),
 	}
 }
 
@@ -68,10 +77,28 @@ func (cfg *Config) EnableEventPublishing(eb eventbus.EventBus) {
 	cfg.eventBus = eb
 }
 
+// SetSchema sets the schema which user configuration changes are validated against.
+func (cfg *Config) SetSchema(schema *Schema) {
+	cfg.schema = schema
+}
+
+// UserConfigLocation returns location of the loaded user config, or empty string if it is not loaded.
+func (cfg *Config) UserConfigLocation() string {
+	return cfg.userConfigLocation
+}
+
+// History returns the audit log of user configuration changes.
+func (cfg *Config) History() ([]Change, error) {
+	return cfg.history.List()
+}
+
 // LoadUserConfig loads and remembers user config location.
 func (cfg *Config) LoadUserConfig(location string) error {
 	log.Debug().Msg("Loading user configuration: " + location)
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
 	cfg.userConfigLocation = location
+	cfg.history = NewChangeHistory(filepath.Join(filepath.Dir(location), historyFileName))
 	_, err := toml.DecodeFile(cfg.userConfigLocation, &cfg.user)
 	if err != nil {
 		return errors.Wrap(err, "failed to decode configuration file")
@@ -86,6 +113,12 @@ func (cfg *Config) LoadUserConfig(location string) error {
 
 // SaveUserConfig saves user configuration to the file from which it was loaded.
 func (cfg *Config) SaveUserConfig() error {
+	cfg.lock.RLock()
+	defer cfg.lock.RUnlock()
+	return cfg.saveUserConfig()
+}
+
+func (cfg *Config) saveUserConfig() error {
 	log.Info().Msg("Saving user configuration")
 	if !cfg.userConfigLoaded() {
 		return errors.New("user configuration cannot be saved, because it must be loaded first")
@@ -107,13 +140,213 @@ func (cfg *Config) SaveUserConfig() error {
 	return nil
 }
 
-// GetUserConfig returns user configuration.
+// GetUserConfig returns a copy of user configuration.
 func (cfg *Config) GetUserConfig() map[string]interface{} {
-	return cfg.user
+	cfg.lock.RLock()
+	defer cfg.lock.RUnlock()
+	return copyMap(cfg.user)
+}
+
+// GetEffectiveConfig returns a copy of the configuration in effect: defaults overridden by user configuration and CLI flags.
+func (cfg *Config) GetEffectiveConfig() map[string]interface{} {
+	cfg.lock.RLock()
+	defer cfg.lock.RUnlock()
+
+	result := make(map[string]interface{})
+	for _, source := range []map[string]interface{}{cfg.defaults, cfg.user, cfg.cli} {
+		mergeMaps(result, source)
+	}
+	return result
+}
+
+// UpdateUser validates user configuration changes and applies them all at once. Keys with nil values are removed.
+// Changes are persisted to the config file, if that fails the previous configuration is restored.
+func (cfg *Config) UpdateUser(changes map[string]interface{}) error {
+	values := make(map[string]interface{})
+	for key, value := range changes {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(key, nested, values)
+		} else {
+			values[strings.ToLower(key)] = value
+		}
+	}
+
+	cfg.lock.Lock()
+	previous := flatten("", cfg.user, make(map[string]interface{}))
+	records := changeRecords(ChangeSourceAPI, previous, values)
+	if err := cfg.validate(values); err != nil {
+		cfg.lock.Unlock()
+		cfg.recordChanges(records, err)
+		return err
+	}
+
+	backup := copyMap(cfg.user)
+	for key, value := range values {
+		if isNil(value) {
+			cfg.remove(&cfg.user, key)
+		} else {
+			cfg.set(&cfg.user, key, value)
+		}
+	}
+	if err := cfg.saveUserConfig(); err != nil {
+		cfg.user = backup
+		cfg.lock.Unlock()
+		cfg.recordChanges(records, err)
+		return err
+	}
+	cfg.lock.Unlock()
+
+	cfg.recordChanges(records, nil)
+	cfg.publishChanges(records)
+	return nil
+}
+
+// ReloadUserConfig reads the user config file again and applies changed values.
+// Invalid file contents are rejected: the file is moved aside and rewritten from the current configuration.
+func (cfg *Config) ReloadUserConfig() error {
+	if !cfg.userConfigLoaded() {
+		return errors.New("user configuration cannot be reloaded, because it must be loaded first")
+	}
+
+	user := make(map[string]interface{})
+	if _, err := toml.DecodeFile(cfg.userConfigLocation, &user); err != nil {
+		err = errors.Wrap(err, "failed to decode configuration file")
+		cfg.lock.Lock()
+		cfg.restoreUserConfigFile()
+		cfg.lock.Unlock()
+		cfg.recordChanges([]Change{{Source: ChangeSourceFile}}, err)
+		return err
+	}
+
+	cfg.lock.Lock()
+	current := flatten("", cfg.user, make(map[string]interface{}))
+	next := flatten("", user, make(map[string]interface{}))
+	changed := make(map[string]interface{})
+	for key, value := range next {
+		changed[key] = value
+	}
+	for key := range current {
+		if _, ok := next[key]; !ok {
+			changed[key] = nil
+		}
+	}
+	records := changeRecords(ChangeSourceFile, current, changed)
+	if len(records) == 0 {
+		cfg.lock.Unlock()
+		return nil
+	}
+	if err := cfg.validate(next); err != nil {
+		cfg.restoreUserConfigFile()
+		cfg.lock.Unlock()
+		cfg.recordChanges(records, err)
+		return err
+	}
+	cfg.user = user
+	cfg.lock.Unlock()
+
+	cfg.recordChanges(records, nil)
+	cfg.publishChanges(records)
+	return nil
+}
+
+// restoreUserConfigFile keeps the rejected user config file for inspection and writes the current configuration in its place,
+// so that the file on disk matches the configuration in effect.
+func (cfg *Config) restoreUserConfigFile() {
+	rejected := cfg.userConfigLocation + ".rejected"
+	if err := os.Rename(cfg.userConfigLocation, rejected); err != nil {
+		log.Error().Err(err).Msg("Failed to move rejected configuration file aside")
+		return
+	}
+	log.Warn().Msgf("Rejected configuration file moved to %s", rejected)
+
+	if err := cfg.saveUserConfig(); err != nil {
+		log.Error().Err(err).Msg("Failed to restore configuration file")
+	}
+}
+
+func (cfg *Config) validate(values map[string]interface{}) error {
+	invalid := make(ValidationError)
+	for key, value := range values {
+		if isNil(value) {
+			continue
+		}
+		if err := cfg.schema.Validate(key, value); err != nil {
+			invalid[key] = err
+		}
+	}
+	if len(invalid) > 0 {
+		return invalid
+	}
+	return nil
+}
+
+func (cfg *Config) recordChanges(records []Change, err error) {
+	now := time.Now().UTC()
+	for i := range records {
+		records[i].Time = now
+		records[i].Applied = err == nil
+		if err == nil {
+			continue
+		}
+		if invalid, ok := err.(ValidationError); ok && invalid[records[i].Key] != nil {
+			records[i].Error = invalid[records[i].Key].Error()
+		} else {
+			records[i].Error = err.Error()
+		}
+	}
+	if err := cfg.history.Append(records...); err != nil {
+		log.Error().Err(err).Msg("Failed to record configuration changes")
+	}
+}
+
+func (cfg *Config) publishChanges(records []Change) {
+	if cfg.eventBus == nil {
+		return
+	}
+	for _, record := range records {
+		cfg.eventBus.Publish(AppTopicConfig(record.Key), cfg.Get(record.Key))
+	}
+}
+
+// changeRecords lists changes of values which differ from the previous ones, sorted by key.
+func changeRecords(source string, previous, values map[string]interface{}) []Change {
+	records := make([]Change, 0, len(values))
+	for key, value := range values {
+		old, existed := previous[key]
+		if isNil(value) && !existed || existed && reflect.DeepEqual(old, value) {
+			continue
+		}
+		records = append(records, Change{Source: source, Key: key, OldValue: old, NewValue: value})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Key < records[j].Key
+	})
+	return records
+}
+
+func copyMap(source map[string]interface{}) map[string]interface{} {
+	target := make(map[string]interface{}, len(source))
+	for key, value := range source {
+		if nested, ok := value.(map[string]interface{}); ok {
+			value = copyMap(nested)
+		}
+		target[key] = value
+	}
+	return target
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
 }
 
 // SetDefault sets default value for key.
 func (cfg *Config) SetDefault(key string, value interface{}) {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
 	cfg.set(&cfg.defaults, key, value)
 }
 
@@ -122,25 +355,33 @@ func (cfg *Config) SetUser(key string, value interface{}) {
 	if cfg.eventBus != nil {
 		cfg.eventBus.Publish(AppTopicConfig(key), value)
 	}
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
 	cfg.set(&cfg.user, key, value)
 }
 
 // SetCLI sets value passed via CLI flag for key.
 func (cfg *Config) SetCLI(key string, value interface{}) {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
 	cfg.set(&cfg.cli, key, value)
 }
 
 // RemoveUser removes user configuration value for key.
 func (cfg *Config) RemoveUser(key string) {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
 	cfg.remove(&cfg.user, key)
 }
 
 // RemoveCLI removes configured CLI flag value by key.
 func (cfg *Config) RemoveCLI(key string) {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
 	cfg.remove(&cfg.cli, key)
 }
 
-// set sets value to a particular configuration value map.
+// set sets value to a particular configuration value map, the caller must hold the lock.
 func (cfg *Config) set(configMap *map[string]interface{}, key string, value interface{}) {
 	key = strings.ToLower(key)
 	segments := strings.Split(key, ".")
@@ -152,7 +393,7 @@ func (cfg *Config) set(configMap *map[string]interface{}, key string, value inte
 	deepestMap[lastKey] = value
 }
 
-// remove removes a configured value from a particular configuration map.
+// remove removes a configured value from a particular configuration map, the caller must hold the lock.
 func (cfg *Config) remove(configMap *map[string]interface{}, key string) {
 	key = strings.ToLower(key)
 	segments := strings.Split(key, ".")
@@ -166,6 +407,8 @@ func (cfg *Config) remove(configMap *map[string]interface{}, key string) {
 
 // Get returns stored config value as-is.
 func (cfg *Config) Get(key string) interface{} {
+	cfg.lock.RLock()
+	defer cfg.lock.RUnlock()
 	segments := strings.Split(strings.ToLower(key), ".")
 	cliValue := cfg.searchMap(cfg.cli, segments)
 	if cliValue != nil {
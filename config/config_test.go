@@ -21,9 +21,11 @@ import (
 	"flag"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v2"
 )
@@ -148,3 +150,132 @@ func TestConfig_ParseStringSliceFlag(t *testing.T) {
 func must(t *testing.T, err error) {
 	assert.NoError(t, err)
 }
+
+func TestUserConfig_UpdateUser(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configFileName := filepath.Join(dir, "config.toml")
+	assert.NoError(t, ioutil.WriteFile(configFileName, nil, 0700))
+	cfg := NewConfig()
+	cfg.SetSchema(NewSchema([]cli.Flag{&FlagOpenvpnPort, &FlagOpenvpnProtocol}))
+	assert.NoError(t, cfg.LoadUserConfig(configFileName))
+	bus := eventbus.New()
+	cfg.EnableEventPublishing(bus)
+	var published []interface{}
+	assert.NoError(t, bus.Subscribe(AppTopicConfig("openvpn.port"), func(value interface{}) {
+		published = append(published, value)
+	}))
+
+	// when: one of the values is invalid
+	err = cfg.UpdateUser(map[string]interface{}{
+		"openvpn": map[string]interface{}{"port": 1195.0, "proto": "icmp"},
+	})
+	// then: nothing is applied
+	assert.EqualError(t, err, "invalid configuration: openvpn.proto: must be one of: udp, tcp")
+	assert.Nil(t, cfg.Get("openvpn.port"))
+	assert.Empty(t, published)
+
+	// when: all values are valid
+	err = cfg.UpdateUser(map[string]interface{}{"openvpn.port": 1195.0, "openvpn.proto": "tcp"})
+	// then: values are applied, saved and published
+	assert.NoError(t, err)
+	assert.Equal(t, 1195, cfg.GetInt("openvpn.port"))
+	assert.Equal(t, []interface{}{1195.0}, published)
+	tomlContent, err := ioutil.ReadFile(configFileName)
+	assert.NoError(t, err)
+	assert.Contains(t, string(tomlContent), `proto = "tcp"`)
+
+	history, err := cfg.History()
+	assert.NoError(t, err)
+	assert.Len(t, history, 4)
+	assert.Equal(t, "openvpn.proto", history[1].Key)
+	assert.False(t, history[1].Applied)
+	assert.Equal(t, "must be one of: udp, tcp", history[1].Error)
+	assert.Equal(t, "openvpn.port", history[2].Key)
+	assert.True(t, history[2].Applied)
+	assert.Equal(t, ChangeSourceAPI, history[2].Source)
+}
+
+func TestUserConfig_ReloadUserConfig(t *testing.T) {
+	// given
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configFileName := filepath.Join(dir, "config.toml")
+	assert.NoError(t, ioutil.WriteFile(configFileName, []byte("[openvpn]\nport = 1194\n"), 0700))
+	cfg := NewConfig()
+	cfg.SetSchema(NewSchema([]cli.Flag{&FlagOpenvpnPort}))
+	assert.NoError(t, cfg.LoadUserConfig(configFileName))
+
+	// when: file contains an invalid value
+	assert.NoError(t, ioutil.WriteFile(configFileName, []byte("[openvpn]\nport = 99999\n"), 0700))
+	err = cfg.ReloadUserConfig()
+	// then: previous configuration is kept and restored on disk
+	assert.Error(t, err)
+	assert.Equal(t, 1194, cfg.GetInt("openvpn.port"))
+	rejected, err := ioutil.ReadFile(configFileName + ".rejected")
+	assert.NoError(t, err)
+	assert.Equal(t, "[openvpn]\nport = 99999\n", string(rejected))
+	restored, err := ioutil.ReadFile(configFileName)
+	assert.NoError(t, err)
+	assert.Contains(t, string(restored), "port = 1194")
+
+	// when: file is fixed
+	assert.NoError(t, ioutil.WriteFile(configFileName, []byte("[openvpn]\nport = 1195\n[shaper]\nenabled = true\n"), 0700))
+	err = cfg.ReloadUserConfig()
+	// then: changes are applied
+	assert.NoError(t, err)
+	assert.Equal(t, 1195, cfg.GetInt("openvpn.port"))
+	assert.True(t, cfg.GetBool("shaper.enabled"))
+
+	history, err := cfg.History()
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+	for _, change := range history {
+		assert.Equal(t, ChangeSourceFile, change.Source)
+	}
+	assert.False(t, history[0].Applied)
+	assert.True(t, history[1].Applied)
+	assert.Equal(t, 1195.0, history[1].NewValue)
+
+	// when: file can't be decoded
+	assert.NoError(t, ioutil.WriteFile(configFileName, []byte("[openvpn\n"), 0700))
+	err = cfg.ReloadUserConfig()
+	// then: it is replaced with the current configuration
+	assert.Error(t, err)
+	restored, err = ioutil.ReadFile(configFileName)
+	assert.NoError(t, err)
+	assert.Contains(t, string(restored), "port = 1195")
+}
+
+func TestConfig_GetUserConfigReturnsCopy(t *testing.T) {
+	cfg := NewConfig()
+	cfg.SetUser("openvpn.port", 31338)
+
+	user := cfg.GetUserConfig()
+	assert.Equal(t, map[string]interface{}{"openvpn": map[string]interface{}{"port": 31338}}, user)
+
+	user["openvpn"].(map[string]interface{})["port"] = 1
+	assert.Equal(t, 31338, cfg.GetInt("openvpn.port"))
+}
+
+func TestConfig_GetEffectiveConfig(t *testing.T) {
+	cfg := NewConfig()
+	cfg.SetDefault("openvpn.port", 1194)
+	cfg.SetDefault("openvpn.proto", "udp")
+	cfg.SetUser("openvpn.port", 31338)
+	cfg.SetCLI("openvpn.proto", "tcp")
+	cfg.SetCLI("log-level", "debug")
+
+	effective := cfg.GetEffectiveConfig()
+
+	assert.Equal(t, map[string]interface{}{
+		"openvpn":   map[string]interface{}{"port": 31338, "proto": "tcp"},
+		"log-level": "debug",
+	}, effective)
+
+	effective["openvpn"].(map[string]interface{})["port"] = 1
+	assert.Equal(t, 31338, cfg.GetInt("openvpn.port"))
+}
@@ -35,6 +35,12 @@ var (
 		Usage: "accountant contract address used to register identity",
 		Value: metadata.DefaultNetwork.AccountantID,
 	}
+	// FlagAccountantAdditional lists accountants the node works with besides the default one
+	FlagAccountantAdditional = cli.StringSliceFlag{
+		Name:  "accountant.additional",
+		Usage: `Additional accountants in the form of "<accountant contract address>=<accountant URL address>", separated by comma`,
+		Value: cli.NewStringSlice(),
+	}
 )
 
 // RegisterFlagsAccountant function register network flags to flag list
@@ -43,6 +49,7 @@ func RegisterFlagsAccountant(flags *[]cli.Flag) {
 		*flags,
 		&FlagAccountantAddress,
 		&FlagAccountantID,
+		&FlagAccountantAdditional,
 	)
 }
 
@@ -50,4 +57,5 @@ func RegisterFlagsAccountant(flags *[]cli.Flag) {
 func ParseFlagsAccountant(ctx *cli.Context) {
 	Current.ParseStringFlag(ctx, FlagAccountantAddress)
 	Current.ParseStringFlag(ctx, FlagAccountantID)
+	Current.ParseStringSliceFlag(ctx, FlagAccountantAdditional)
 }
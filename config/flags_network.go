@@ -69,6 +69,18 @@ var (
 		Usage: "Max number of devices to try pass for NAT hole punching",
 		Value: 10,
 	}
+	// FlagP2POnly exchanges session messages over p2p channels only.
+	FlagP2POnly = cli.BoolFlag{
+		Name:  "p2p-only",
+		Usage: "Exchange session messages over p2p channels only, without the NATS dialogs",
+		Value: false,
+	}
+	// FlagP2PDirectPort is the UDP port for exchanging p2p config straight with consumers.
+	FlagP2PDirectPort = cli.IntFlag{
+		Name:  "p2p.direct.port",
+		Usage: "UDP port for exchanging p2p config with consumers without the broker, zero disables it",
+		Value: 0,
+	}
 	// FlagIncomingFirewall enables incoming traffic filtering.
 	FlagIncomingFirewall = cli.BoolFlag{
 		Name:  "incoming-firewall",
@@ -90,6 +102,8 @@ func RegisterFlagsNetwork(flags *[]cli.Flag) {
 		&FlagBrokerAddress,
 		&FlagEtherRPC,
 		&FlagIncomingFirewall,
+		&FlagP2POnly,
+		&FlagP2PDirectPort,
 	)
 }
 
@@ -104,4 +118,6 @@ func ParseFlagsNetwork(ctx *cli.Context) {
 	Current.ParseBoolFlag(ctx, FlagNATPunching)
 	Current.ParseIntFlag(ctx, FlagNATPunchingMaxTTL)
 	Current.ParseBoolFlag(ctx, FlagIncomingFirewall)
+	Current.ParseBoolFlag(ctx, FlagP2POnly)
+	Current.ParseIntFlag(ctx, FlagP2PDirectPort)
 }
@@ -36,7 +36,7 @@ var (
 	// FlagDiscoveryType proposal discovery adapter.
 	FlagDiscoveryType = cli.StringSliceFlag{
 		Name:  "discovery.type",
-		Usage: `Proposal discovery adapter(s) separated by comma Options: { "api", "broker", "api,broker" }`,
+		Usage: `Proposal discovery adapter(s) separated by comma Options: { "api", "broker", "lan", "api,broker,lan" }`,
 		Value: cli.NewStringSlice("api", "broker"),
 	}
 	// FlagDiscoveryPingInterval proposal ping interval in seconds.
@@ -63,6 +63,21 @@ var (
 		Usage: "URL of Feedback API",
 		Value: "https://feedback.mysterium.network",
 	}
+	// FlagDNSCache points the tunnel DNS to a local proxy caching answers of the tunnel DNS servers.
+	FlagDNSCache = cli.BoolFlag{
+		Name:  "dns.cache.enabled",
+		Usage: "Serve consumer DNS by a local proxy caching answers of the tunnel DNS servers",
+	}
+	// FlagDNSCachePrefetch refreshes popular answers of the local DNS proxy before they expire.
+	FlagDNSCachePrefetch = cli.BoolFlag{
+		Name:  "dns.cache.prefetch",
+		Usage: "Refresh popular answers of the local DNS proxy before they expire",
+	}
+	// FlagDNSQueryLog logs consumer DNS queries served by the local DNS proxy.
+	FlagDNSQueryLog = cli.BoolFlag{
+		Name:  "dns.query-log",
+		Usage: "Log consumer DNS queries served by the local DNS proxy along with the connection session",
+	}
 	// FlagFirewallKillSwitch always blocks non-tunneled outgoing consumer traffic.
 	FlagFirewallKillSwitch = cli.BoolFlag{
 		Name:  "firewall.killSwitch.always",
@@ -80,6 +95,18 @@ var (
 		Usage: "Determines the scrypt memory complexity. If set to true, will use 4MB blocks instead of the standard 256MB ones",
 		Value: true,
 	}
+	// FlagKeystoreExternalSigner address of external signing service.
+	FlagKeystoreExternalSigner = cli.StringFlag{
+		Name:  "keystore.signer",
+		Usage: "HTTP URL or IPC socket path of clef-style external signer (account_signData). If set, identity keys are not used for signing",
+		Value: "",
+	}
+	// FlagKeystoreExternalSignerTimeout timeout of external signer requests.
+	FlagKeystoreExternalSignerTimeout = cli.DurationFlag{
+		Name:  "keystore.signer.timeout",
+		Usage: "Timeout of external signer requests",
+		Value: 30 * time.Second,
+	}
 	// FlagLogHTTP enables HTTP payload logging.
 	FlagLogHTTP = cli.BoolFlag{
 		Name:  "log.http",
@@ -147,6 +174,44 @@ var (
 		Usage: "Port for listening incoming api requests",
 		Value: 4050,
 	}
+	// FlagTequilapiAuth enables authentication and scope checks of TequilAPI requests.
+	FlagTequilapiAuth = cli.BoolFlag{
+		Name:  "tequilapi.auth",
+		Usage: "Require JWT or API token with a matching scope for TequilAPI requests",
+		Value: false,
+	}
+	// FlagTequilapiTLS enables HTTPS for TequilAPI.
+	FlagTequilapiTLS = cli.BoolFlag{
+		Name:  "tequilapi.tls",
+		Usage: "Serve TequilAPI over HTTPS. Self-signed certificate is generated unless certificate and key are given",
+		Value: false,
+	}
+	// FlagTequilapiTLSCert TLS certificate file of TequilAPI.
+	FlagTequilapiTLSCert = cli.StringFlag{
+		Name:  "tequilapi.tls.cert",
+		Usage: "PEM encoded TLS certificate file for TequilAPI",
+	}
+	// FlagTequilapiTLSKey TLS private key file of TequilAPI.
+	FlagTequilapiTLSKey = cli.StringFlag{
+		Name:  "tequilapi.tls.key",
+		Usage: "PEM encoded TLS private key file for TequilAPI",
+	}
+	// FlagTequilapiTLSClientCA enables mutual TLS for TequilAPI.
+	FlagTequilapiTLSClientCA = cli.StringFlag{
+		Name:  "tequilapi.tls.client-ca",
+		Usage: "PEM encoded CA certificates file. If set, TequilAPI clients must present a certificate signed by one of them",
+	}
+	// FlagTequilapiSocket unix domain socket path of TequilAPI.
+	FlagTequilapiSocket = cli.StringFlag{
+		Name:  "tequilapi.socket",
+		Usage: "Additionally serve TequilAPI on the given unix domain socket path",
+	}
+	// FlagTequilapiSocketMode unix domain socket permissions of TequilAPI.
+	FlagTequilapiSocketMode = cli.StringFlag{
+		Name:  "tequilapi.socket.mode",
+		Usage: "File permissions of TequilAPI unix domain socket",
+		Value: "0660",
+	}
 	// FlagPProfEnable enables pprof via TequilAPI.
 	FlagPProfEnable = cli.BoolFlag{
 		Name:  "pprof.enable",
@@ -190,6 +255,7 @@ func RegisterFlagsNode(flags *[]cli.Flag) error {
 	RegisterFlagsTransactor(flags)
 	RegisterFlagsAccountant(flags)
 	RegisterFlagsPayments(flags)
+	RegisterFlagsStorage(flags)
 
 	*flags = append(*flags,
 		&FlagBindAddress,
@@ -197,9 +263,14 @@ func RegisterFlagsNode(flags *[]cli.Flag) error {
 		&FlagDiscoveryPingInterval,
 		&FlagDiscoveryFetchInterval,
 		&FlagFeedbackURL,
+		&FlagDNSCache,
+		&FlagDNSCachePrefetch,
+		&FlagDNSQueryLog,
 		&FlagFirewallKillSwitch,
 		&FlagFirewallProtectedNetworks,
 		&FlagKeystoreLightweight,
+		&FlagKeystoreExternalSigner,
+		&FlagKeystoreExternalSignerTimeout,
 		&FlagLogHTTP,
 		&FlagLogLevel,
 		&FlagMMNAddress,
@@ -209,6 +280,13 @@ func RegisterFlagsNode(flags *[]cli.Flag) error {
 		&FlagQualityAddress,
 		&FlagTequilapiAddress,
 		&FlagTequilapiPort,
+		&FlagTequilapiAuth,
+		&FlagTequilapiTLS,
+		&FlagTequilapiTLSCert,
+		&FlagTequilapiTLSKey,
+		&FlagTequilapiTLSClientCA,
+		&FlagTequilapiSocket,
+		&FlagTequilapiSocketMode,
 		&FlagUIEnable,
 		&FlagPProfEnable,
 		&FlagUIPort,
@@ -228,15 +306,21 @@ func ParseFlagsNode(ctx *cli.Context) {
 	ParseFlagsTransactor(ctx)
 	ParseFlagsAccountant(ctx)
 	ParseFlagsPayments(ctx)
+	ParseFlagsStorage(ctx)
 
 	Current.ParseStringFlag(ctx, FlagBindAddress)
 	Current.ParseStringSliceFlag(ctx, FlagDiscoveryType)
 	Current.ParseDurationFlag(ctx, FlagDiscoveryPingInterval)
 	Current.ParseDurationFlag(ctx, FlagDiscoveryFetchInterval)
 	Current.ParseStringFlag(ctx, FlagFeedbackURL)
+	Current.ParseBoolFlag(ctx, FlagDNSCache)
+	Current.ParseBoolFlag(ctx, FlagDNSCachePrefetch)
+	Current.ParseBoolFlag(ctx, FlagDNSQueryLog)
 	Current.ParseBoolFlag(ctx, FlagFirewallKillSwitch)
 	Current.ParseStringFlag(ctx, FlagFirewallProtectedNetworks)
 	Current.ParseBoolFlag(ctx, FlagKeystoreLightweight)
+	Current.ParseStringFlag(ctx, FlagKeystoreExternalSigner)
+	Current.ParseDurationFlag(ctx, FlagKeystoreExternalSignerTimeout)
 	Current.ParseBoolFlag(ctx, FlagLogHTTP)
 	Current.ParseStringFlag(ctx, FlagLogLevel)
 	Current.ParseStringFlag(ctx, FlagMMNAddress)
@@ -246,6 +330,13 @@ func ParseFlagsNode(ctx *cli.Context) {
 	Current.ParseStringFlag(ctx, FlagQualityType)
 	Current.ParseStringFlag(ctx, FlagTequilapiAddress)
 	Current.ParseIntFlag(ctx, FlagTequilapiPort)
+	Current.ParseBoolFlag(ctx, FlagTequilapiAuth)
+	Current.ParseBoolFlag(ctx, FlagTequilapiTLS)
+	Current.ParseStringFlag(ctx, FlagTequilapiTLSCert)
+	Current.ParseStringFlag(ctx, FlagTequilapiTLSKey)
+	Current.ParseStringFlag(ctx, FlagTequilapiTLSClientCA)
+	Current.ParseStringFlag(ctx, FlagTequilapiSocket)
+	Current.ParseStringFlag(ctx, FlagTequilapiSocketMode)
 	Current.ParseBoolFlag(ctx, FlagPProfEnable)
 	Current.ParseBoolFlag(ctx, FlagUIEnable)
 	Current.ParseIntFlag(ctx, FlagUIPort)
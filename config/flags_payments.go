@@ -48,6 +48,24 @@ var (
 		Value: time.Hour * 2,
 		Usage: "The duration we'll wait before timing out our wait for promise settle.",
 	}
+	// FlagPaymentsSettleSchedule represents the cron schedule of additional promise settlements.
+	FlagPaymentsSettleSchedule = cli.StringFlag{
+		Name:  "payments.settle.schedule",
+		Value: "",
+		Usage: "Cron expression (e.g. \"0 3 * * *\") of additional promise settlements. Empty disables scheduled settlements",
+	}
+	// FlagPaymentsSettleMinFeeMultiplier represents the multiple of the settlement fee unsettled earnings have to exceed.
+	FlagPaymentsSettleMinFeeMultiplier = cli.Float64Flag{
+		Name:  "payments.settle.min-fee-multiplier",
+		Value: 0,
+		Usage: "Settle automatically only when unsettled earnings exceed the settlement fee multiplied by this value. 0 disables the check",
+	}
+	// FlagPaymentsSettleMaxPerDay represents the max number of automatic settlements per day.
+	FlagPaymentsSettleMaxPerDay = cli.IntFlag{
+		Name:  "payments.settle.max-per-day",
+		Value: 0,
+		Usage: "The max number of automatic settlements during the last 24 hours. 0 means no limit",
+	}
 	// FlagPaymentsMystSCAddress represents the myst smart contract address
 	FlagPaymentsMystSCAddress = cli.StringFlag{
 		Name:  "payments.mystscaddress",
@@ -100,6 +118,9 @@ func RegisterFlagsPayments(flags *[]cli.Flag) {
 		&FlagPaymentsBCTimeout,
 		&FlagPaymentsAccountantPromiseSettleThreshold,
 		&FlagPaymentsAccountantPromiseSettleTimeout,
+		&FlagPaymentsSettleSchedule,
+		&FlagPaymentsSettleMinFeeMultiplier,
+		&FlagPaymentsSettleMaxPerDay,
 		&FlagPaymentsMystSCAddress,
 		&FlagPaymentsProviderInvoiceFrequency,
 		&FlagPaymentsConsumerPricePerMinuteUpperBound,
@@ -116,6 +137,9 @@ func ParseFlagsPayments(ctx *cli.Context) {
 	Current.ParseDurationFlag(ctx, FlagPaymentsBCTimeout)
 	Current.ParseFloat64Flag(ctx, FlagPaymentsAccountantPromiseSettleThreshold)
 	Current.ParseDurationFlag(ctx, FlagPaymentsAccountantPromiseSettleTimeout)
+	Current.ParseStringFlag(ctx, FlagPaymentsSettleSchedule)
+	Current.ParseFloat64Flag(ctx, FlagPaymentsSettleMinFeeMultiplier)
+	Current.ParseIntFlag(ctx, FlagPaymentsSettleMaxPerDay)
 	Current.ParseStringFlag(ctx, FlagPaymentsMystSCAddress)
 	Current.ParseDurationFlag(ctx, FlagPaymentsProviderInvoiceFrequency)
 	Current.ParseUInt64Flag(ctx, FlagPaymentsConsumerPricePerMinuteUpperBound)
@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// FlagProxyLocalAddress address on which consumer exposes the local proxy port.
+	FlagProxyLocalAddress = cli.StringFlag{
+		Name:  "proxy.local-address",
+		Usage: "Address on which consumer exposes SOCKS5 and HTTP CONNECT proxy when connected to the proxy service",
+		Value: "127.0.0.1:1080",
+	}
+	// FlagProxyPriceMinute sets the price per minute for provided proxy service.
+	FlagProxyPriceMinute = cli.Float64Flag{
+		Name:  "proxy.price-minute",
+		Usage: "Sets the price of the proxy service per minute.",
+		Value: 0.0005,
+	}
+	// FlagProxyPriceGB sets the price per GiB for provided proxy service.
+	FlagProxyPriceGB = cli.Float64Flag{
+		Name:  "proxy.price-gb",
+		Usage: "Sets the price of the proxy service per GiB.",
+		Value: 0.07,
+	}
+)
+
+// RegisterFlagsServiceProxy function register proxy flags to flag list
+func RegisterFlagsServiceProxy(flags *[]cli.Flag) {
+	*flags = append(*flags,
+		&FlagProxyLocalAddress,
+		&FlagProxyPriceMinute,
+		&FlagProxyPriceGB,
+	)
+}
+
+// ParseFlagsServiceProxy parses CLI flags and registers value to configuration
+func ParseFlagsServiceProxy(ctx *cli.Context) {
+	Current.ParseStringFlag(ctx, FlagProxyLocalAddress)
+	Current.ParseFloat64Flag(ctx, FlagProxyPriceMinute)
+	Current.ParseFloat64Flag(ctx, FlagProxyPriceGB)
+}
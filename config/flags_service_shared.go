@@ -73,6 +73,29 @@ var (
 		Name:  "shaper.enabled",
 		Usage: "Limit service bandwidth",
 	}
+	// FlagDNSUpstreams sets the resolvers the provider DNS proxy forwards queries to.
+	FlagDNSUpstreams = cli.StringFlag{
+		Name:  "dns.upstreams",
+		Usage: "Comma separated DNS upstreams of the provider DNS proxy: doh:<https URL>, dot:<host>[:port] or resolver IP. System DNS servers are used if empty",
+		Value: "",
+	}
+	// FlagDNSBlocklistSources sets the blocklists the provider DNS proxy answers NXDOMAIN for.
+	FlagDNSBlocklistSources = cli.StringFlag{
+		Name:  "dns.blocklist.sources",
+		Usage: "Comma separated domain blocklists (hosts-file or plain list format) given as local file paths or http(s) URLs",
+		Value: "",
+	}
+	// FlagDNSBlocklistRefresh sets how often the DNS blocklists are reloaded.
+	FlagDNSBlocklistRefresh = cli.DurationFlag{
+		Name:  "dns.blocklist.refresh",
+		Usage: `DNS blocklist refresh interval { "30m", "6h" }, 0 disables refreshing`,
+		Value: 24 * time.Hour,
+	}
+	// FlagDNSBlocklistBlockIPs enables blocking of the addresses resolved for blocked domains.
+	FlagDNSBlocklistBlockIPs = cli.BoolFlag{
+		Name:  "dns.blocklist.block-ips",
+		Usage: "Also block consumer traffic to the IP addresses of blocked domains in the firewall",
+	}
 	// FlagNoopPriceMinute sets the price per minute for provided noop service.
 	FlagNoopPriceMinute = cli.Float64Flag{
 		Name:   "noop.price-minute",
@@ -92,6 +115,10 @@ func RegisterFlagsServiceShared(flags *[]cli.Flag) {
 		&FlagAccessPolicyList,
 		&FlagAccessPolicyFetchInterval,
 		&FlagShaperEnabled,
+		&FlagDNSUpstreams,
+		&FlagDNSBlocklistSources,
+		&FlagDNSBlocklistRefresh,
+		&FlagDNSBlocklistBlockIPs,
 		&FlagNoopPriceMinute,
 	)
 }
@@ -105,5 +132,9 @@ func ParseFlagsServiceShared(ctx *cli.Context) {
 	Current.ParseStringFlag(ctx, FlagAccessPolicyList)
 	Current.ParseDurationFlag(ctx, FlagAccessPolicyFetchInterval)
 	Current.ParseBoolFlag(ctx, FlagShaperEnabled)
+	Current.ParseStringFlag(ctx, FlagDNSUpstreams)
+	Current.ParseStringFlag(ctx, FlagDNSBlocklistSources)
+	Current.ParseDurationFlag(ctx, FlagDNSBlocklistRefresh)
+	Current.ParseBoolFlag(ctx, FlagDNSBlocklistBlockIPs)
 	Current.ParseFloat64Flag(ctx, FlagNoopPriceMinute)
 }
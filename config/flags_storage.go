@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// FlagStorageBackend selects the database the node keeps its data in
+	FlagStorageBackend = cli.StringFlag{
+		Name:  "storage.backend",
+		Usage: "Database backend: bolt or sqlite. Migrate existing bolt data with 'myst db migrate-sqlite' before switching",
+		Value: "bolt",
+	}
+	// FlagStorageRetentionSessionHistory limits how long consumer session history is kept
+	FlagStorageRetentionSessionHistory = cli.IntFlag{
+		Name:  "storage.retention.session-history",
+		Usage: "Number of days to keep consumer session history for, 0 keeps it forever",
+		Value: 0,
+	}
+	// FlagStorageRetentionPaymentEvidence limits how long invoices and exchange messages of sessions are kept
+	FlagStorageRetentionPaymentEvidence = cli.IntFlag{
+		Name:  "storage.retention.payment-evidence",
+		Usage: "Number of days to keep invoices and exchange messages of sessions for, 0 keeps them forever",
+		Value: 0,
+	}
+	// FlagStorageRetentionSettlementHistory limits how long settlement history is kept
+	FlagStorageRetentionSettlementHistory = cli.IntFlag{
+		Name:  "storage.retention.settlement-history",
+		Usage: "Number of days to keep settlement history for, 0 keeps it forever",
+		Value: 0,
+	}
+	// FlagStorageRetentionConnectivityStatus limits how long session connectivity statuses are kept
+	FlagStorageRetentionConnectivityStatus = cli.IntFlag{
+		Name:  "storage.retention.connectivity-status",
+		Usage: "Number of days to keep session connectivity statuses for, 0 keeps them forever",
+		Value: 30,
+	}
+)
+
+// RegisterFlagsStorage function register storage flags to flag list
+func RegisterFlagsStorage(flags *[]cli.Flag) {
+	*flags = append(
+		*flags,
+		&FlagStorageBackend,
+		&FlagStorageRetentionSessionHistory,
+		&FlagStorageRetentionPaymentEvidence,
+		&FlagStorageRetentionSettlementHistory,
+		&FlagStorageRetentionConnectivityStatus,
+	)
+}
+
+// ParseFlagsStorage function fills in storage options from CLI context
+func ParseFlagsStorage(ctx *cli.Context) {
+	Current.ParseStringFlag(ctx, FlagStorageBackend)
+	Current.ParseIntFlag(ctx, FlagStorageRetentionSessionHistory)
+	Current.ParseIntFlag(ctx, FlagStorageRetentionPaymentEvidence)
+	Current.ParseIntFlag(ctx, FlagStorageRetentionSettlementHistory)
+	Current.ParseIntFlag(ctx, FlagStorageRetentionConnectivityStatus)
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Sources of user configuration changes.
+const (
+	ChangeSourceAPI  = "api"
+	ChangeSourceFile = "file"
+)
+
+const (
+	historyFileName = "config-history.jsonl"
+	// maxHistoryChanges limits how many changes are kept, the oldest ones are dropped first.
+	maxHistoryChanges = 1000
+)
+
+// Change describes a single attempted change of user configuration.
+type Change struct {
+	Time     time.Time   `json:"time"`
+	Source   string      `json:"source"`
+	Key      string      `json:"key"`
+	OldValue interface{} `json:"old_value,omitempty"`
+	NewValue interface{} `json:"new_value,omitempty"`
+	Applied  bool        `json:"applied"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// ChangeHistory is an audit log of the latest user configuration changes.
+// Changes are kept as JSON lines in a file next to the user configuration, or in memory if no file is set.
+type ChangeHistory struct {
+	path    string
+	limit   int
+	changes []Change
+	lock    sync.Mutex
+}
+
+// NewChangeHistory creates change history stored in the given file.
+func NewChangeHistory(path string) *ChangeHistory {
+	return &ChangeHistory{path: path, limit: maxHistoryChanges}
+}
+
+// Append records the given changes, dropping the oldest ones above the limit.
+func (ch *ChangeHistory) Append(changes ...Change) error {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	if ch.path == "" {
+		ch.changes = ch.latest(append(ch.changes, changes...))
+		return nil
+	}
+
+	existing, err := ch.list()
+	if err != nil {
+		return err
+	}
+	return ch.write(ch.latest(append(existing, changes...)))
+}
+
+// write replaces the history file, so that it is never left truncated.
+func (ch *ChangeHistory) write(changes []Change) error {
+	tmp := ch.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to open configuration history")
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, change := range changes {
+		if err := encoder.Encode(change); err != nil {
+			file.Close()
+			return errors.Wrap(err, "failed to write configuration history")
+		}
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "failed to write configuration history")
+	}
+	return errors.Wrap(os.Rename(tmp, ch.path), "failed to replace configuration history")
+}
+
+// List returns recorded changes, the oldest first.
+func (ch *ChangeHistory) List() ([]Change, error) {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	if ch.path == "" {
+		return append([]Change{}, ch.changes...), nil
+	}
+	return ch.list()
+}
+
+func (ch *ChangeHistory) list() ([]Change, error) {
+	file, err := os.Open(ch.path)
+	if os.IsNotExist(err) {
+		return []Change{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open configuration history")
+	}
+	defer file.Close()
+
+	changes := []Change{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var change Change
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			return nil, errors.Wrap(err, "failed to parse configuration history")
+		}
+		changes = append(changes, change)
+	}
+	return changes, scanner.Err()
+}
+
+func (ch *ChangeHistory) latest(changes []Change) []Change {
+	if len(changes) <= ch.limit {
+		return changes
+	}
+	return append([]Change{}, changes[len(changes)-ch.limit:]...)
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeHistory_KeepsLatestChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, history := range []*ChangeHistory{NewChangeHistory(""), NewChangeHistory(filepath.Join(dir, historyFileName))} {
+		history.limit = 3
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, history.Append(Change{Key: "openvpn.port", NewValue: float64(i)}))
+		}
+		assert.NoError(t, history.Append(Change{Key: "openvpn.port", NewValue: -1.0}, Change{Key: "openvpn.port", NewValue: -2.0}))
+
+		changes, err := history.List()
+		assert.NoError(t, err)
+		assert.Len(t, changes, 3)
+		assert.Equal(t, []interface{}{2.0, -1.0, -2.0}, []interface{}{changes[0].NewValue, changes[1].NewValue, changes[2].NewValue})
+	}
+}
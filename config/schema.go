@@ -0,0 +1,244 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cast"
+	"github.com/urfave/cli/v2"
+)
+
+// Rule constrains a flag value beyond its type.
+type Rule struct {
+	Min  *float64
+	Max  *float64
+	Enum []string
+}
+
+func between(min, max float64) Rule {
+	return Rule{Min: &min, Max: &max}
+}
+
+func oneOf(values ...string) Rule {
+	return Rule{Enum: values}
+}
+
+// flagRules holds value constraints of known flags.
+var flagRules = map[string]Rule{
+	FlagLocationType.Name:  oneOf("oracle", "builtin", "mmdb", "manual"),
+	FlagDiscoveryType.Name: oneOf("api", "broker"),
+	FlagQualityType.Name:   oneOf("elastic", "morqa", "none"),
+	FlagLogLevel.Name: oneOf(
+		zerolog.TraceLevel.String(),
+		zerolog.DebugLevel.String(),
+		zerolog.InfoLevel.String(),
+		zerolog.WarnLevel.String(),
+		zerolog.ErrorLevel.String(),
+		zerolog.FatalLevel.String(),
+		zerolog.PanicLevel.String(),
+		zerolog.Disabled.String(),
+	),
+	FlagOpenvpnProtocol.Name:                          oneOf("udp", "tcp"),
+	FlagOpenvpnPort.Name:                              between(0, 65535),
+	FlagTequilapiPort.Name:                            between(0, 65535),
+	FlagUIPort.Name:                                   between(0, 65535),
+	FlagPaymentsMaxAccountantFee.Name:                 between(0, 10000),
+	FlagPaymentsAccountantPromiseSettleThreshold.Name: between(0, 1),
+	FlagOpenVPNPriceMinute.Name:                       between(0, 1e6),
+	FlagOpenVPNPriceGB.Name:                           between(0, 1e6),
+	FlagWireguardPriceMinute.Name:                     between(0, 1e6),
+	FlagWireguardPriceGB.Name:                         between(0, 1e6),
+	FlagProxyPriceMinute.Name:                         between(0, 1e6),
+	FlagProxyPriceGB.Name:                             between(0, 1e6),
+	FlagNATPunchingMaxTTL.Name:                        between(1, 255),
+	FlagStorageBackend.Name:                           oneOf("bolt", "sqlite"),
+	FlagStorageRetentionSessionHistory.Name:           between(0, 36500),
+	FlagStorageRetentionPaymentEvidence.Name:          between(0, 36500),
+	FlagStorageRetentionSettlementHistory.Name:        between(0, 36500),
+	FlagStorageRetentionConnectivityStatus.Name:       between(0, 36500),
+}
+
+type valueKind string
+
+const (
+	kindBool        valueKind = "bool"
+	kindInt         valueKind = "int"
+	kindUint        valueKind = "uint"
+	kindFloat       valueKind = "float"
+	kindDuration    valueKind = "duration"
+	kindString      valueKind = "string"
+	kindStringSlice valueKind = "string slice"
+)
+
+type schemaField struct {
+	kind valueKind
+	rule Rule
+}
+
+// Schema validates user configuration values against flag definitions.
+// Keys which are not defined by any flag are accepted as-is.
+type Schema struct {
+	fields map[string]schemaField
+}
+
+// NewSchema derives configuration schema from the given flags.
+func NewSchema(flags []cli.Flag) *Schema {
+	schema := &Schema{fields: make(map[string]schemaField)}
+	for _, flag := range flags {
+		var kind valueKind
+		switch flag.(type) {
+		case *cli.BoolFlag:
+			kind = kindBool
+		case *cli.IntFlag, *cli.Int64Flag:
+			kind = kindInt
+		case *cli.UintFlag, *cli.Uint64Flag:
+			kind = kindUint
+		case *cli.Float64Flag:
+			kind = kindFloat
+		case *cli.DurationFlag:
+			kind = kindDuration
+		case *cli.StringFlag, *cli.PathFlag:
+			kind = kindString
+		case *cli.StringSliceFlag:
+			kind = kindStringSlice
+		default:
+			continue
+		}
+		for _, name := range flag.Names() {
+			schema.fields[strings.ToLower(name)] = schemaField{kind: kind, rule: flagRules[name]}
+		}
+	}
+	return schema
+}
+
+// Validate checks whether the value is acceptable for the given configuration key.
+func (s *Schema) Validate(key string, value interface{}) error {
+	if s == nil {
+		return nil
+	}
+	field, ok := s.fields[strings.ToLower(key)]
+	if !ok {
+		return nil
+	}
+
+	var number float64
+	var values []string
+	var err error
+	switch field.kind {
+	case kindBool:
+		_, err = cast.ToBoolE(value)
+	case kindInt:
+		var v int64
+		v, err = toInteger(value)
+		number = float64(v)
+	case kindUint:
+		var v int64
+		if v, err = toInteger(value); err == nil && v < 0 {
+			err = fmt.Errorf("negative value %d", v)
+		}
+		number = float64(v)
+	case kindFloat:
+		number, err = cast.ToFloat64E(value)
+	case kindDuration:
+		_, err = cast.ToDurationE(value)
+	case kindString:
+		var v string
+		v, err = cast.ToStringE(value)
+		values = []string{v}
+	case kindStringSlice:
+		values, err = cast.ToStringSliceE(value)
+	}
+	if err != nil {
+		return fmt.Errorf("expected %s value", field.kind)
+	}
+
+	for _, v := range values {
+		if len(field.rule.Enum) > 0 && !contains(field.rule.Enum, v) {
+			return fmt.Errorf("must be one of: %s", strings.Join(field.rule.Enum, ", "))
+		}
+	}
+
+	if field.rule.Min != nil && number < *field.rule.Min {
+		return fmt.Errorf("must be at least %v", *field.rule.Min)
+	}
+	if field.rule.Max != nil && number > *field.rule.Max {
+		return fmt.Errorf("must be at most %v", *field.rule.Max)
+	}
+	return nil
+}
+
+// toInteger accepts integers and integral floats, since JSON decodes all numbers as floats.
+func toInteger(value interface{}) (int64, error) {
+	if f, ok := value.(float64); ok {
+		if f != float64(int64(f)) {
+			return 0, fmt.Errorf("not an integer: %v", f)
+		}
+		return int64(f), nil
+	}
+	return cast.ToInt64E(value)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidationError lists invalid configuration keys with their errors.
+type ValidationError map[string]error
+
+// Error returns all key errors in a single message.
+func (ve ValidationError) Error() string {
+	keys := make([]string, 0, len(ve))
+	for key := range ve {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	messages := make([]string, len(keys))
+	for i, key := range keys {
+		messages[i] = fmt.Sprintf("%s: %v", key, ve[key])
+	}
+	return "invalid configuration: " + strings.Join(messages, "; ")
+}
+
+// flatten converts nested configuration tables to dotted keys.
+func flatten(prefix string, source map[string]interface{}, target map[string]interface{}) map[string]interface{} {
+	for key, value := range source {
+		fullKey := strings.ToLower(key)
+		if prefix != "" {
+			fullKey = prefix + "." + fullKey
+		}
+		switch nested := value.(type) {
+		case map[string]interface{}:
+			flatten(fullKey, nested, target)
+		case map[interface{}]interface{}:
+			flatten(fullKey, cast.ToStringMap(nested), target)
+		default:
+			target[fullKey] = value
+		}
+	}
+	return target
+}
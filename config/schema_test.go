@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func TestSchema_Validate(t *testing.T) {
+	schema := NewSchema([]cli.Flag{
+		&FlagOpenvpnPort,
+		&FlagOpenvpnProtocol,
+		&FlagDiscoveryType,
+		&FlagUIEnable,
+		&FlagPaymentsBCTimeout,
+		&FlagPaymentsAccountantPromiseSettleThreshold,
+		&FlagPaymentsConsumerPricePerGBUpperBound,
+	})
+
+	tests := []struct {
+		key   string
+		value interface{}
+		err   string
+	}{
+		{"openvpn.port", int64(1194), ""},
+		{"openvpn.port", float64(1194), ""},
+		{"openvpn.port", 1194.5, "expected int value"},
+		{"openvpn.port", 70000, "must be at most 65535"},
+		{"openvpn.port", "abc", "expected int value"},
+		{"openvpn.proto", "udp", ""},
+		{"openvpn.proto", "icmp", "must be one of: udp, tcp"},
+		{"discovery.type", []interface{}{"api", "broker"}, ""},
+		{"discovery.type", []interface{}{"dht"}, "must be one of: api, broker"},
+		{"ui.enable", "true", ""},
+		{"ui.enable", "maybe", "expected bool value"},
+		{"payments.bc.timeout", "45s", ""},
+		{"payments.bc.timeout", "soon", "expected duration value"},
+		{"payments.accountant.promise.threshold", 1.5, "must be at most 1"},
+		{"payments.consumer.price-pergib-max", -1, "expected uint value"},
+		{"unknown.key", "anything", ""},
+	}
+
+	for _, test := range tests {
+		err := schema.Validate(test.key, test.value)
+		if test.err == "" {
+			assert.NoError(t, err, test.key)
+		} else {
+			assert.EqualError(t, err, test.err, test.key)
+		}
+	}
+}
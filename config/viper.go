@@ -106,3 +106,20 @@ func deepSearch(m map[string]interface{}, path []string) map[string]interface{}
 	}
 	return m
 }
+
+// mergeMaps deeply copies src into dst, values of src take precedence.
+func mergeMaps(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, ok := v.(map[string]interface{})
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dstMap, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dstMap = make(map[string]interface{})
+			dst[k] = dstMap
+		}
+		mergeMaps(dstMap, srcMap)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// UserConfigWatcher reloads user configuration when its file changes on disk.
+type UserConfigWatcher struct {
+	config   *Config
+	interval time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewUserConfigWatcher creates watcher polling user configuration file of the given config.
+func NewUserConfigWatcher(config *Config, interval time.Duration) *UserConfigWatcher {
+	return &UserConfigWatcher{
+		config:   config,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start starts watching user configuration file in the background.
+func (w *UserConfigWatcher) Start() {
+	location := w.config.UserConfigLocation()
+	if location == "" {
+		log.Warn().Msg("User configuration is not loaded, skipping configuration watch")
+		return
+	}
+
+	lastModified := modificationTime(location)
+	go func() {
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-time.After(w.interval):
+			}
+
+			modified := modificationTime(location)
+			if modified.Equal(lastModified) {
+				continue
+			}
+			lastModified = modified
+
+			log.Info().Msg("User configuration file changed, reloading")
+			if err := w.config.ReloadUserConfig(); err != nil {
+				log.Error().Err(err).Msg("User configuration file change rejected")
+			}
+		}
+	}()
+}
+
+// Stop stops watching user configuration file.
+func (w *UserConfigWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+}
+
+func modificationTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package favourite
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/pkg/errors"
+)
+
+const favouriteStorageBucketName = "favourite-providers"
+
+// StorageKinds returns the records kept by the favourite storage.
+func StorageKinds() []storage.Kind {
+	return []storage.Kind{{Bucket: favouriteStorageBucketName, Record: &Favourite{}}}
+}
+
+// Favourite is a provider marked by the consumer to be easily found again.
+type Favourite struct {
+	ProviderID string `storm:"id"`
+	Note       string
+	AddedAt    time.Time
+}
+
+type persistentStorage interface {
+	Store(bucket string, data interface{}) error
+	Delete(bucket string, data interface{}) error
+	GetAllFrom(bucket string, data interface{}) error
+	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
+}
+
+// Storage keeps favourite providers of the consumer.
+type Storage struct {
+	db         persistentStorage
+	timeGetter func() time.Time
+}
+
+// NewStorage returns new favourite providers storage.
+func NewStorage(db persistentStorage) *Storage {
+	return &Storage{db: db, timeGetter: time.Now}
+}
+
+// Add marks the provider as favourite, adding it again only updates the note.
+func (s *Storage) Add(providerID, note string) (Favourite, error) {
+	providerID = strings.ToLower(providerID)
+	if providerID == "" {
+		return Favourite{}, errors.New("provider ID is required")
+	}
+
+	favourite, err := s.Get(providerID)
+	if err == storage.ErrNotFound {
+		favourite = Favourite{ProviderID: providerID, AddedAt: s.timeGetter().UTC()}
+	} else if err != nil {
+		return Favourite{}, err
+	}
+	favourite.Note = note
+
+	err = s.db.Store(favouriteStorageBucketName, &favourite)
+	return favourite, errors.Wrap(err, "could not store favourite provider")
+}
+
+// Remove unmarks the provider, removing a provider which is not favourite is not an error.
+func (s *Storage) Remove(providerID string) error {
+	err := s.db.Delete(favouriteStorageBucketName, &Favourite{ProviderID: strings.ToLower(providerID)})
+	if err == storage.ErrNotFound {
+		return nil
+	}
+	return errors.Wrap(err, "could not remove favourite provider")
+}
+
+// Get returns the favourite provider or storage.ErrNotFound.
+func (s *Storage) Get(providerID string) (Favourite, error) {
+	var favourite Favourite
+	err := s.db.GetOneByField(favouriteStorageBucketName, "ProviderID", strings.ToLower(providerID), &favourite)
+	return favourite, err
+}
+
+// List returns all favourite providers, the most recently added first.
+func (s *Storage) List() ([]Favourite, error) {
+	var favourites []Favourite
+	err := s.db.GetAllFrom(favouriteStorageBucketName, &favourites)
+	if err == storage.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load favourite providers")
+	}
+	sort.SliceStable(favourites, func(i, j int) bool {
+		return favourites[i].AddedAt.After(favourites[j].AddedAt)
+	})
+	return favourites, nil
+}
+
+// IDs returns the set of favourite provider IDs.
+func (s *Storage) IDs() (map[string]bool, error) {
+	favourites, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(favourites))
+	for _, favourite := range favourites {
+		ids[favourite.ProviderID] = true
+	}
+	return ids, nil
+}
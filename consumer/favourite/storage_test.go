@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package favourite
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/core/storage/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage(t *testing.T) {
+	backends := map[string]func(dir string) (storage.Storage, error){
+		"bolt":   func(dir string) (storage.Storage, error) { return boltdb.NewStorage(dir) },
+		"sqlite": func(dir string) (storage.Storage, error) { return sqlite.NewStorage(dir) },
+	}
+	for name, open := range backends {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "favouriteStorageTest")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+			db, err := open(dir)
+			assert.NoError(t, err)
+			defer db.Close()
+
+			now := time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+			favourites := NewStorage(db)
+			favourites.timeGetter = func() time.Time { return now }
+
+			list, err := favourites.List()
+			assert.NoError(t, err)
+			assert.Empty(t, list)
+
+			_, err = favourites.Add("", "")
+			assert.EqualError(t, err, "provider ID is required")
+
+			first, err := favourites.Add("0xAA", "fast")
+			assert.NoError(t, err)
+			assert.Equal(t, Favourite{ProviderID: "0xaa", Note: "fast", AddedAt: now}, first)
+
+			now = now.Add(time.Minute)
+			_, err = favourites.Add("0xbb", "")
+			assert.NoError(t, err)
+
+			// adding again keeps the original time
+			now = now.Add(time.Minute)
+			updated, err := favourites.Add("0xaa", "fastest")
+			assert.NoError(t, err)
+			assert.Equal(t, first.AddedAt, updated.AddedAt)
+
+			list, err = favourites.List()
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"0xbb", "0xaa"}, []string{list[0].ProviderID, list[1].ProviderID})
+			assert.Equal(t, "fastest", list[1].Note)
+
+			assert.NoError(t, favourites.Remove("0xBB"))
+			assert.NoError(t, favourites.Remove("0xbb"))
+			ids, err := favourites.IDs()
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]bool{"0xaa": true}, ids)
+
+			_, err = favourites.Get("0xbb")
+			assert.Equal(t, storage.ErrNotFound, err)
+		})
+	}
+}
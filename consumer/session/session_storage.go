@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/core/storage"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/session"
@@ -41,6 +42,28 @@ type Storer interface {
 	Store(bucket string, object interface{}) error
 	Update(bucket string, object interface{}) error
 	GetAllFrom(bucket string, array interface{}) error
+	Find(bucket string, query storage.Query, to interface{}) error
+}
+
+// Filter narrows session history to the sessions started within [From, To), zero bounds are left open.
+type Filter struct {
+	From        time.Time
+	To          time.Time
+	Status      string
+	ServiceType string
+	Offset      int
+	// Limit is the maximum number of sessions returned, zero means no limit.
+	Limit int
+}
+
+// RetentionPolicy returns the policy dropping session history older than maxAge.
+func RetentionPolicy(maxAge time.Duration) storage.RetentionPolicy {
+	return storage.RetentionPolicy{Bucket: sessionStorageBucketName, Kind: &History{}, Field: "Started", MaxAge: maxAge}
+}
+
+// StorageKinds returns the records kept by the session storage.
+func StorageKinds() []storage.Kind {
+	return []storage.Kind{{Bucket: sessionStorageBucketName, Record: &History{}}}
 }
 
 type timeGetter func() time.Time
@@ -85,6 +108,23 @@ func (repo *Storage) GetAll() ([]History, error) {
 	return sessions, nil
 }
 
+// List returns sessions matching the filter ordered by start time
+func (repo *Storage) List(filter Filter) ([]History, error) {
+	query := storage.Query{OrderBy: "Started", Skip: filter.Offset, Limit: filter.Limit}.Between("Started", filter.From, filter.To)
+	if filter.Status != "" {
+		query = query.Where("Status", storage.OpEq, filter.Status)
+	}
+	if filter.ServiceType != "" {
+		query = query.Where("ServiceType", storage.OpEq, filter.ServiceType)
+	}
+
+	var sessions []History
+	if err := repo.storage.Find(sessionStorageBucketName, query, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
 // consumeSessionEvent consumes the session state change events
 func (repo *Storage) consumeSessionEvent(sessionEvent connection.AppEventConnectionSession) {
 	switch sessionEvent.Status {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/mysteriumnetwork/node/core/connection"
+	core_storage "github.com/mysteriumnetwork/node/core/storage"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	node_session "github.com/mysteriumnetwork/node/session"
@@ -70,6 +71,22 @@ func TestSessionStorageGetAllReturnsError(t *testing.T) {
 	assert.Nil(t, sessions)
 }
 
+func TestSessionStorageList(t *testing.T) {
+	storer := &StubSessionStorer{}
+	storage := NewSessionStorage(storer)
+	from := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := storage.List(Filter{From: from, Status: SessionStatusCompleted, Offset: 10, Limit: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, "Started", storer.FoundQuery.OrderBy)
+	assert.Equal(t, 10, storer.FoundQuery.Skip)
+	assert.Equal(t, 5, storer.FoundQuery.Limit)
+	assert.Equal(t, []core_storage.Filter{
+		{Field: "Started", Op: core_storage.OpGte, Value: from},
+		{Field: "Status", Op: core_storage.OpEq, Value: SessionStatusCompleted},
+	}, storer.FoundQuery.Filters)
+}
+
 func TestSessionStorage_consumeEventEndedOK(t *testing.T) {
 	storer := &StubSessionStorer{}
 
@@ -186,6 +203,7 @@ type StubSessionStorer struct {
 	UpdatedObject interface{}
 	GetAllCalled  bool
 	GetAllError   error
+	FoundQuery    core_storage.Query
 }
 
 func (sss *StubSessionStorer) Store(from string, object interface{}) error {
@@ -203,6 +221,11 @@ func (sss *StubSessionStorer) GetAllFrom(from string, array interface{}) error {
 	return sss.GetAllError
 }
 
+func (sss *StubSessionStorer) Find(from string, query core_storage.Query, to interface{}) error {
+	sss.FoundQuery = query
+	return sss.GetAllError
+}
+
 type StubServiceDefinition struct{}
 
 func (fs *StubServiceDefinition) GetLocation() market.Location {
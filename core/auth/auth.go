@@ -33,17 +33,24 @@ func NewAuthenticator(storage Storage) *Authenticator {
 
 // CheckCredentials authenticates user by password
 func (a *Authenticator) CheckCredentials(username, password string) error {
+	if username != defaultUsername {
+		return a.checkUserPassword(username, password)
+	}
 	return NewCredentials(username, password, a.storage).Validate()
 }
 
 // ChangePassword changes user password
 func (a *Authenticator) ChangePassword(username, oldPassword, newPassword string) (err error) {
-	err = NewCredentials(username, oldPassword, a.storage).Validate()
+	err = a.CheckCredentials(username, oldPassword)
 	if err != nil {
 		log.Info().Err(err).Msg("Bad credentials for changing password")
 		return ErrUnauthorized
 	}
-	err = NewCredentials(username, newPassword, a.storage).Set()
+	if username != defaultUsername {
+		err = a.setUserPassword(username, newPassword)
+	} else {
+		err = NewCredentials(username, newPassword, a.storage).Set()
+	}
 	if err != nil {
 		log.Info().Err(err).Msg("Error changing password")
 		return err
@@ -24,14 +24,18 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Storage for Credentials
+// Storage for Credentials, users and API tokens
 type Storage interface {
 	GetValue(bucket string, key interface{}, to interface{}) error
 	SetValue(bucket string, key interface{}, to interface{}) error
+	Store(bucket string, data interface{}) error
+	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
+	GetAllFrom(bucket string, data interface{}) error
+	Delete(bucket string, data interface{}) error
 }
 
 const (
-	username            = "myst"
+	defaultUsername     = "myst"
 	initialPassword     = "mystberry"
 	credentialsDBBucket = "app-credentials"
 )
@@ -58,7 +62,7 @@ func (credentials *Credentials) Validate() (err error) {
 	if err != nil {
 		return errors.Wrap(err, "could not load credentials")
 	}
-	if credentials.username != username {
+	if credentials.username != defaultUsername {
 		return errors.New("bad credentials")
 	}
 	err = bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(credentials.password))
@@ -70,14 +74,14 @@ func (credentials *Credentials) Validate() (err error) {
 
 func (credentials *Credentials) loadOrInitialize() (s string, err error) {
 	var storedHash string
-	err = credentials.db.GetValue(credentialsDBBucket, username, &storedHash)
+	err = credentials.db.GetValue(credentialsDBBucket, defaultUsername, &storedHash)
 	if err == storage.ErrNotFound {
 		log.Info().Msg("Credentials not found, initializing to default")
-		err = NewCredentials(username, initialPassword, credentials.db).Set()
+		err = NewCredentials(defaultUsername, initialPassword, credentials.db).Set()
 		if err != nil {
 			return "", errors.Wrap(err, "failed to set initial credentials")
 		}
-		err = credentials.db.GetValue(credentialsDBBucket, username, &storedHash)
+		err = credentials.db.GetValue(credentialsDBBucket, defaultUsername, &storedHash)
 	}
 	return storedHash, err
 }
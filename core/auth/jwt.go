@@ -43,6 +43,7 @@ const JWTCookieName string = "token"
 
 type jwtClaims struct {
 	Username string `json:"username"`
+	Scopes   Scopes `json:"scopes,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -58,10 +59,11 @@ func NewJWTAuthenticator(encryptionKey JWTEncryptionKey) *JWTAuthenticator {
 }
 
 // CreateToken creates a new JWT token
-func (jwtAuth *JWTAuthenticator) CreateToken(username string) (JWT, error) {
+func (jwtAuth *JWTAuthenticator) CreateToken(username string, scopes Scopes) (JWT, error) {
 	expirationTime := jwtAuth.getExpirationTime()
 	claims := &jwtClaims{
 		Username: username,
+		Scopes:   scopes,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expirationTime.Unix(),
 		},
@@ -78,20 +80,34 @@ func (jwtAuth *JWTAuthenticator) CreateToken(username string) (JWT, error) {
 
 // ValidateToken validates a JWT token
 func (jwtAuth *JWTAuthenticator) ValidateToken(token string) (bool, error) {
+	if _, err := jwtAuth.ParseToken(token); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ParseToken validates a JWT token and returns the principal it was issued to
+func (jwtAuth *JWTAuthenticator) ParseToken(token string) (Principal, error) {
 	claims := &jwtClaims{}
 
 	tkn, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
 		return jwtAuth.encryptionKey, nil
 	})
 	if err != nil {
-		return false, err
+		return Principal{}, err
 	}
 
 	if tkn == nil || !tkn.Valid {
-		return false, errors.New("invalid JWT token")
+		return Principal{}, errors.New("invalid JWT token")
 	}
 
-	return true, nil
+	scopes := claims.Scopes
+	if len(scopes) == 0 {
+		// tokens issued before scopes were introduced belong to the default admin user
+		scopes = Scopes{ScopeAdmin}
+	}
+	return Principal{Username: claims.Username, Scopes: scopes}, nil
 }
 
 func (jwtAuth *JWTAuthenticator) getExpirationTime() time.Time {
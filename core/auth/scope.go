@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Scope defines which part of Tequilapi a user or an API token is allowed to use.
+type Scope string
+
+const (
+	// ScopeAdmin grants full control over the node.
+	ScopeAdmin Scope = "admin"
+	// ScopeRead grants read-only access to the node state.
+	ScopeRead Scope = "read"
+	// ScopeConnect allows to manage consumer connections.
+	ScopeConnect Scope = "connect"
+	// ScopeServices allows to manage provider services.
+	ScopeServices Scope = "services"
+	// ScopePayments allows to register identities, top up and settle.
+	ScopePayments Scope = "payments"
+)
+
+var knownScopes = []Scope{ScopeAdmin, ScopeRead, ScopeConnect, ScopeServices, ScopePayments}
+
+// Scopes is a list of scopes granted to a user or an API token.
+type Scopes []Scope
+
+// Allows checks if the given scope is granted. Admin scope grants everything,
+// read access is granted by any scope.
+func (s Scopes) Allows(required Scope) bool {
+	for _, scope := range s {
+		if scope == ScopeAdmin || scope == required {
+			return true
+		}
+	}
+	return required == ScopeRead && len(s) > 0
+}
+
+// ParseScopes validates the given scope names.
+func ParseScopes(names []string) (Scopes, error) {
+	if len(names) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+
+	scopes := make(Scopes, 0, len(names))
+	for _, name := range names {
+		scope := Scope(strings.TrimSpace(name))
+		if !isKnownScope(scope) {
+			return nil, errors.Errorf("unknown scope %q", name)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+func isKnownScope(scope Scope) bool {
+	for _, known := range knownScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
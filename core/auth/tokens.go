@@ -0,0 +1,156 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	apiTokensDBBucket = "app-api-tokens"
+	// APITokenPrefix marks long-lived API tokens, distinguishing them from JWT tokens.
+	APITokenPrefix = "myst_"
+)
+
+// Principal describes an authenticated Tequilapi caller.
+type Principal struct {
+	Username string
+	Scopes   Scopes
+}
+
+// APIToken is a long-lived token with limited scopes, e.g. for dashboards and scripts.
+// Only a hash of the token secret is stored.
+type APIToken struct {
+	ID         string `storm:"id"`
+	Name       string
+	Scopes     Scopes
+	SecretHash string
+	CreatedAt  time.Time
+}
+
+// CreateAPIToken creates a new API token and returns its plain value, which is never stored
+func (a *Authenticator) CreateAPIToken(name string, scopes Scopes) (string, APIToken, error) {
+	if len(scopes) == 0 {
+		return "", APIToken{}, errors.New("at least one scope is required")
+	}
+
+	id, err := generateRandomBytes(8)
+	if err != nil {
+		return "", APIToken{}, errors.Wrap(err, "failed to generate token ID")
+	}
+	secret, err := generateRandomBytes(32)
+	if err != nil {
+		return "", APIToken{}, errors.Wrap(err, "failed to generate token secret")
+	}
+
+	token := APIToken{
+		ID:         hex.EncodeToString(id),
+		Name:       name,
+		Scopes:     scopes,
+		SecretHash: hashTokenSecret(hex.EncodeToString(secret)),
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := a.storage.Store(apiTokensDBBucket, &token); err != nil {
+		return "", APIToken{}, errors.Wrap(err, "failed to store API token")
+	}
+
+	return APITokenPrefix + token.ID + "." + hex.EncodeToString(secret), token, nil
+}
+
+// APITokens returns all API tokens
+func (a *Authenticator) APITokens() ([]APIToken, error) {
+	var tokens []APIToken
+	err := a.storage.GetAllFrom(apiTokensDBBucket, &tokens)
+	if err != nil && err != storage.ErrNotFound {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken deletes the API token with the given ID
+func (a *Authenticator) RevokeAPIToken(id string) error {
+	var token APIToken
+	if err := a.storage.GetOneByField(apiTokensDBBucket, "ID", id, &token); err != nil {
+		return err
+	}
+	return a.storage.Delete(apiTokensDBBucket, &token)
+}
+
+// ValidateAPIToken checks the given API token and returns the principal it represents
+func (a *Authenticator) ValidateAPIToken(value string) (Principal, error) {
+	parts := strings.SplitN(strings.TrimPrefix(value, APITokenPrefix), ".", 2)
+	if !strings.HasPrefix(value, APITokenPrefix) || len(parts) != 2 {
+		return Principal{}, ErrUnauthorized
+	}
+
+	var token APIToken
+	if err := a.storage.GetOneByField(apiTokensDBBucket, "ID", parts[0], &token); err != nil {
+		return Principal{}, ErrUnauthorized
+	}
+	if subtle.ConstantTimeCompare([]byte(token.SecretHash), []byte(hashTokenSecret(parts[1]))) != 1 {
+		return Principal{}, ErrUnauthorized
+	}
+
+	return Principal{Username: "token:" + token.Name, Scopes: token.Scopes}, nil
+}
+
+func hashTokenSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// TokenValidator validates both JWT session tokens and long-lived API tokens.
+type TokenValidator struct {
+	jwtAuth *JWTAuthenticator
+	auth    *Authenticator
+}
+
+// NewTokenValidator creates a new TokenValidator
+func NewTokenValidator(jwtAuth *JWTAuthenticator, auth *Authenticator) *TokenValidator {
+	return &TokenValidator{
+		jwtAuth: jwtAuth,
+		auth:    auth,
+	}
+}
+
+// Authenticate resolves the principal of the given token
+func (tv *TokenValidator) Authenticate(token string) (Principal, error) {
+	if strings.HasPrefix(token, APITokenPrefix) {
+		return tv.auth.ValidateAPIToken(token)
+	}
+
+	principal, err := tv.jwtAuth.ParseToken(token)
+	if err != nil {
+		return Principal{}, ErrUnauthorized
+	}
+	if principal.Username != defaultUsername {
+		// users can be deleted or changed while their session is still valid
+		principal.Scopes, err = tv.auth.UserScopes(principal.Username)
+		if err != nil {
+			return Principal{}, ErrUnauthorized
+		}
+	}
+	return principal, nil
+}
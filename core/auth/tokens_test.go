@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAuth(t *testing.T) (*Authenticator, *TokenValidator, func()) {
+	dir := boltdbtest.CreateTempDir(t)
+	db, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+
+	authenticator := NewAuthenticator(db)
+	validator := NewTokenValidator(NewJWTAuthenticator([]byte("secret")), authenticator)
+	return authenticator, validator, func() {
+		db.Close()
+		boltdbtest.RemoveTempDir(t, dir)
+	}
+}
+
+func TestScopes_Allows(t *testing.T) {
+	assert.True(t, Scopes{ScopeAdmin}.Allows(ScopePayments))
+	assert.True(t, Scopes{ScopeConnect}.Allows(ScopeConnect))
+	assert.True(t, Scopes{ScopeConnect}.Allows(ScopeRead))
+	assert.False(t, Scopes{ScopeRead}.Allows(ScopePayments))
+	assert.False(t, Scopes{}.Allows(ScopeRead))
+}
+
+func TestAuthenticator_Users(t *testing.T) {
+	authenticator, validator, cleanup := newTestAuth(t)
+	defer cleanup()
+
+	_, err := authenticator.CreateUser("dashboard", "pass", Scopes{ScopeRead})
+	assert.NoError(t, err)
+	_, err = authenticator.CreateUser("dashboard", "pass", Scopes{ScopeRead})
+	assert.Equal(t, ErrUserExists, err)
+
+	assert.NoError(t, authenticator.CheckCredentials("dashboard", "pass"))
+	assert.Error(t, authenticator.CheckCredentials("dashboard", "wrong"))
+	assert.NoError(t, authenticator.CheckCredentials("myst", "mystberry"))
+
+	assert.NoError(t, authenticator.ChangePassword("dashboard", "pass", "new-pass"))
+	assert.NoError(t, authenticator.CheckCredentials("dashboard", "new-pass"))
+
+	users, err := authenticator.Users()
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	jwt, err := validator.jwtAuth.CreateToken("dashboard", Scopes{ScopeRead})
+	assert.NoError(t, err)
+	principal, err := validator.Authenticate(jwt.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, Scopes{ScopeRead}, principal.Scopes)
+
+	assert.NoError(t, authenticator.DeleteUser("dashboard"))
+	_, err = validator.Authenticate(jwt.Token)
+	assert.Equal(t, ErrUnauthorized, err)
+	assert.Error(t, authenticator.DeleteUser("myst"))
+}
+
+func TestAuthenticator_APITokens(t *testing.T) {
+	authenticator, validator, cleanup := newTestAuth(t)
+	defer cleanup()
+
+	value, token, err := authenticator.CreateAPIToken("grafana", Scopes{ScopeRead})
+	assert.NoError(t, err)
+	assert.NotContains(t, token.SecretHash, value)
+
+	principal, err := validator.Authenticate(value)
+	assert.NoError(t, err)
+	assert.Equal(t, Scopes{ScopeRead}, principal.Scopes)
+
+	_, err = validator.Authenticate(value + "0")
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = validator.Authenticate(APITokenPrefix + "invalid")
+	assert.Equal(t, ErrUnauthorized, err)
+
+	assert.NoError(t, authenticator.RevokeAPIToken(token.ID))
+	_, err = validator.Authenticate(value)
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestJWTAuthenticator_LegacyTokenHasAdminScope(t *testing.T) {
+	jwtAuth := NewJWTAuthenticator([]byte("secret"))
+	jwt, err := jwtAuth.CreateToken("myst", nil)
+	assert.NoError(t, err)
+
+	principal, err := jwtAuth.ParseToken(jwt.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, Principal{Username: "myst", Scopes: Scopes{ScopeAdmin}}, principal)
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package auth
+
+import (
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const usersDBBucket = "app-users"
+
+// User is an additional Tequilapi user with limited scopes.
+type User struct {
+	Username     string `storm:"id"`
+	PasswordHash string
+	Scopes       Scopes
+	CreatedAt    time.Time
+}
+
+// StorageKinds returns the records kept by the authenticator.
+func StorageKinds() []storage.Kind {
+	return []storage.Kind{
+		{Bucket: usersDBBucket, Record: &User{}},
+		{Bucket: apiTokensDBBucket, Record: &APIToken{}},
+	}
+}
+
+// ErrUserExists is returned when creating a user with a taken username.
+var ErrUserExists = errors.New("user already exists")
+
+// ErrUserNotFound is returned when the user does not exist.
+var ErrUserNotFound = errors.New("user not found")
+
+// CreateUser creates a new user with the given scopes
+func (a *Authenticator) CreateUser(username, password string, scopes Scopes) (User, error) {
+	if username == "" || password == "" {
+		return User{}, errors.New("username and password are required")
+	}
+	if len(scopes) == 0 {
+		return User{}, errors.New("at least one scope is required")
+	}
+	if _, err := a.getUser(username); err != ErrUserNotFound {
+		if err == nil {
+			return User{}, ErrUserExists
+		}
+		return User{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, errors.Wrap(err, "unable to generate password hash")
+	}
+	user := User{
+		Username:     username,
+		PasswordHash: string(hash),
+		Scopes:       scopes,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := a.storage.Store(usersDBBucket, &user); err != nil {
+		return User{}, errors.Wrap(err, "unable to store user")
+	}
+	return user, nil
+}
+
+// DeleteUser removes the given user
+func (a *Authenticator) DeleteUser(username string) error {
+	if username == defaultUsername {
+		return errors.New("default user can not be deleted")
+	}
+	user, err := a.getUser(username)
+	if err != nil {
+		return err
+	}
+	return a.storage.Delete(usersDBBucket, &user)
+}
+
+// Users returns all users including the default one
+func (a *Authenticator) Users() ([]User, error) {
+	var users []User
+	err := a.storage.GetAllFrom(usersDBBucket, &users)
+	if err != nil && err != storage.ErrNotFound {
+		return nil, err
+	}
+	return append([]User{{Username: defaultUsername, Scopes: Scopes{ScopeAdmin}}}, users...), nil
+}
+
+// UserScopes returns scopes granted to the given user
+func (a *Authenticator) UserScopes(username string) (Scopes, error) {
+	if username == defaultUsername {
+		return Scopes{ScopeAdmin}, nil
+	}
+	user, err := a.getUser(username)
+	if err != nil {
+		return nil, err
+	}
+	return user.Scopes, nil
+}
+
+func (a *Authenticator) checkUserPassword(username, password string) error {
+	user, err := a.getUser(username)
+	if err != nil {
+		return errors.New("bad credentials")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return errors.Wrap(err, "bad credentials")
+	}
+	return nil
+}
+
+func (a *Authenticator) setUserPassword(username, password string) error {
+	user, err := a.getUser(username)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.Wrap(err, "unable to generate password hash")
+	}
+	user.PasswordHash = string(hash)
+	return a.storage.Store(usersDBBucket, &user)
+}
+
+func (a *Authenticator) getUser(username string) (User, error) {
+	var user User
+	err := a.storage.GetOneByField(usersDBBucket, "Username", username, &user)
+	if err == storage.ErrNotFound {
+		return user, ErrUserNotFound
+	}
+	return user, err
+}
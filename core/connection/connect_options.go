@@ -18,6 +18,8 @@
 package connection
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"net"
 
 	"github.com/mysteriumnetwork/node/identity"
@@ -31,6 +33,10 @@ type ConnectParams struct {
 	DisableKillSwitch bool
 	// DNS servers to use
 	DNS DNSOption
+	// ProxyPort runs the tunnel in a userspace network stack exposed only as a local SOCKS5/HTTP proxy on this port,
+	// instead of a system-wide tunnel. Kill switch and DNS apply to the proxy clients only.
+	// Clients authenticate with credentials generated for each connection, see Status.LocalProxy.
+	ProxyPort int
 }
 
 // ConnectOptions represents the params we need to ensure a successful connection
@@ -43,4 +49,45 @@ type ConnectOptions struct {
 	SessionConfig   []byte
 	ProviderNATConn *net.UDPConn
 	ChannelConn     *net.UDPConn
+	LocalDNS        DNSForwarder
+	LocalProxy      *LocalProxy
+}
+
+// LocalProxy is the local SOCKS5/HTTP proxy the tunnel is exposed on, see ConnectParams.ProxyPort.
+// Proxy clients have to authenticate with the username and password.
+type LocalProxy struct {
+	Port     int
+	Username string
+	Password string
+}
+
+// localProxyUsername is the username of local proxy clients, the password is unique for each connection.
+const localProxyUsername = "myst"
+
+func newLocalProxy(port int) (*LocalProxy, error) {
+	password := make([]byte, 16)
+	if _, err := rand.Read(password); err != nil {
+		return nil, err
+	}
+	return &LocalProxy{
+		Port:     port,
+		Username: localProxyUsername,
+		Password: hex.EncodeToString(password),
+	}, nil
+}
+
+// DNSForwarder points the local DNS proxy to the tunnel DNS servers.
+type DNSForwarder interface {
+	// Forward makes the proxy forward queries to the servers and returns the servers the tunnel DNS should be pointed to.
+	Forward(sessionID string, servers []string) ([]string, error)
+}
+
+// ResolveDNS resolves DNS servers the tunnel should use using `providerDNS` as received from the provider.
+// When the local DNS proxy is running, the tunnel is pointed to it.
+func (o ConnectOptions) ResolveDNS(providerDNS string) ([]string, error) {
+	servers, err := o.DNS.ResolveIPs(providerDNS)
+	if err != nil || o.LocalDNS == nil {
+		return servers, err
+	}
+	return o.LocalDNS.Forward(string(o.SessionID), servers)
 }
@@ -47,8 +47,17 @@ func NewDNSOption(str string) (DNSOption, error) {
 	case DNSOptionAuto, DNSOptionProvider, DNSOptionSystem, "":
 		return opt, nil
 	}
-	// It may also be a set of IP addresses, e.g. 1.1.1.1,8.8.8.8
 	split := strings.Split(str, ",")
+	// It may be a set of encrypted upstreams, e.g. doh:https://cloudflare-dns.com/dns-query,dot:dns.quad9.net
+	if _, ok := opt.Encrypted(); ok {
+		for _, s := range split {
+			if err := dns.ValidateUpstream(s); err != nil {
+				return "", err
+			}
+		}
+		return opt, nil
+	}
+	// It may also be a set of IP addresses, e.g. 1.1.1.1,8.8.8.8
 	for _, s := range split {
 		if ip := net.ParseIP(s); ip == nil {
 			return "", errors.New("invalid IP address provided as a DNS option: " + s)
@@ -77,9 +86,24 @@ func (o DNSOption) Exact() (servers []string, ok bool) {
 	case DNSOptionAuto, DNSOptionProvider, DNSOptionSystem:
 		return nil, false
 	}
+	if _, encrypted := o.Encrypted(); encrypted {
+		return nil, false
+	}
 	return stringutil.Split(string(o), ','), true
 }
 
+// Encrypted returns DNS-over-HTTPS and DNS-over-TLS upstreams, if they were set.
+// Encrypted upstreams are served to the tunnel by a local DNS proxy.
+func (o DNSOption) Encrypted() (upstreams []string, ok bool) {
+	upstreams = stringutil.Split(string(o), ',')
+	for _, upstream := range upstreams {
+		if dns.IsEncryptedUpstream(upstream) {
+			return upstreams, true
+		}
+	}
+	return nil, false
+}
+
 // ResolveIPs resolves DNS server IPs on the consumer side using self as the
 // consumer preference and `providerDNS` argument as received from the provider
 func (o *DNSOption) ResolveIPs(providerDNS string) ([]string, error) {
@@ -87,6 +111,9 @@ func (o *DNSOption) ResolveIPs(providerDNS string) ([]string, error) {
 	if exact, ok := o.Exact(); ok {
 		return exact, nil
 	}
+	if _, ok := o.Encrypted(); ok {
+		return nil, errors.New("encrypted DNS upstreams must be served by a local DNS proxy")
+	}
 	switch *o {
 	case DNSOptionProvider:
 		return selectProviderDNS(providerDNS)
@@ -36,6 +36,10 @@ func TestNewDNSOption(t *testing.T) {
 		{input: "1.1.1.1,9.9.9.9", expect: DNSOption("1.1.1.1,9.9.9.9")},
 		{input: "1.1.1.1", expect: DNSOption("1.1.1.1")},
 		{input: "", expect: DNSOption("")},
+		{input: "doh:https://cloudflare-dns.com/dns-query", expect: DNSOption("doh:https://cloudflare-dns.com/dns-query")},
+		{input: "dot:dns.quad9.net,1.1.1.1", expect: DNSOption("dot:dns.quad9.net,1.1.1.1")},
+		{input: "doh:http://cloudflare-dns.com/dns-query", expectErr: true},
+		{input: "dot:dns.quad9.net,dns.google", expectErr: true},
 		{input: "AA", expectErr: true},
 		{input: "512.512.512.512", expectErr: true},
 		{input: "1.1.1.1,512.512.512.512", expectErr: true},
@@ -86,6 +90,7 @@ func TestDNSOption_Exact(t *testing.T) {
 		{option: DNSOption("1.1.1.1,9.9.9.9"), expectServers: []string{"1.1.1.1", "9.9.9.9"}, expectOK: true},
 		{option: DNSOption("9.9.9.9"), expectServers: []string{"9.9.9.9"}, expectOK: true},
 		{option: DNSOption(""), expectServers: nil, expectOK: true},
+		{option: DNSOption("dot:dns.quad9.net"), expectOK: false},
 	}
 	for _, tt := range tests {
 		servers, ok := tt.option.Exact()
@@ -93,3 +98,39 @@ func TestDNSOption_Exact(t *testing.T) {
 		assert.Equal(tt.expectServers, servers)
 	}
 }
+
+func TestDNSOption_Encrypted(t *testing.T) {
+	upstreams, ok := DNSOption("doh:https://cloudflare-dns.com/dns-query,dot:dns.quad9.net,1.1.1.1").Encrypted()
+	assert.True(t, ok)
+	assert.Equal(t, []string{"doh:https://cloudflare-dns.com/dns-query", "dot:dns.quad9.net", "1.1.1.1"}, upstreams)
+
+	_, ok = DNSOption("1.1.1.1").Encrypted()
+	assert.False(t, ok)
+	_, ok = DNSOptionAuto.Encrypted()
+	assert.False(t, ok)
+}
+
+func TestConnectOptions_ResolveDNS(t *testing.T) {
+	options := ConnectOptions{SessionID: "session1", DNS: DNSOption("1.1.1.1,9.9.9.9")}
+	servers, err := options.ResolveDNS("10.182.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.1.1.1", "9.9.9.9"}, servers)
+
+	forwarder := &fakeDNSForwarder{}
+	options.LocalDNS = forwarder
+	servers, err = options.ResolveDNS("10.182.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1"}, servers)
+	assert.Equal(t, "session1", forwarder.sessionID)
+	assert.Equal(t, []string{"1.1.1.1", "9.9.9.9"}, forwarder.servers)
+}
+
+type fakeDNSForwarder struct {
+	sessionID string
+	servers   []string
+}
+
+func (f *fakeDNSForwarder) Forward(sessionID string, servers []string) ([]string, error) {
+	f.sessionID, f.servers = sessionID, servers
+	return []string{"127.0.0.1"}, nil
+}
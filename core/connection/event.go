@@ -74,6 +74,8 @@ type Status struct {
 	State        State
 	SessionID    session.ID
 	Proposal     market.ServiceProposal
+	// LocalProxy is set when the tunnel is exposed on a local proxy only.
+	LocalProxy *LocalProxy
 }
 
 // Duration returns elapsed time from marked session start
@@ -22,6 +22,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/dns"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 )
@@ -42,6 +43,12 @@ type Connection interface {
 	Statistics() (Statistics, error)
 }
 
+// LocalProxyConnection is a connection able to expose the tunnel only on a local proxy port, see ConnectParams.ProxyPort.
+type LocalProxyConnection interface {
+	Connection
+	SupportsLocalProxy() bool
+}
+
 // StateChannel is the channel we receive state change events on
 type StateChannel chan State
 
@@ -63,4 +70,6 @@ type Manager interface {
 	Status() Status
 	// Disconnect closes established connection, reports error if no connection
 	Disconnect() error
+	// DNSStats returns statistics of the local caching DNS proxy, reports false if it is disabled
+	DNSStats() (dns.ConsumerStats, bool)
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/dns"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
@@ -37,6 +38,7 @@ import (
 	"github.com/mysteriumnetwork/node/pb"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/mysteriumnetwork/node/session/messaging"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
@@ -56,6 +58,10 @@ var (
 	ErrInsufficientBalance = errors.New("insufficient balance")
 	// ErrUnlockRequired indicates that the consumer identity has not been unlocked yet
 	ErrUnlockRequired = errors.New("unlock required")
+	// ErrP2PNotSupported indicates that the provider can't be reached over p2p while the consumer runs p2p only
+	ErrP2PNotSupported = errors.New("provider does not support p2p")
+	// ErrLocalProxyNotSupported indicates that the connection of the proposal service type can not be exposed as a local proxy
+	ErrLocalProxyNotSupported = errors.New("local proxy is not supported by the service type")
 )
 
 // IPCheckConfig contains common params for connection ip check.
@@ -71,10 +77,26 @@ type KeepAliveConfig struct {
 	MaxSendErrCount int
 }
 
+// LocalDNSConfig contains options of the local DNS proxy the tunnel DNS is pointed to.
+// The proxy serves encrypted DNS upstreams and, if enabled, caches answers of the tunnel DNS servers.
+type LocalDNSConfig struct {
+	// ListenIP is the address the tunnel DNS is pointed to, the proxy listens on port 53 of it.
+	ListenIP string
+	// Cache enables the proxy for the tunnel DNS servers.
+	Cache bool
+	// Prefetch refreshes popular cached answers before they expire.
+	Prefetch bool
+	// LogQueries logs queries per connection session.
+	LogQueries bool
+}
+
 // Config contains common configuration options for connection manager.
 type Config struct {
 	IPCheck   IPCheckConfig
 	KeepAlive KeepAliveConfig
+	LocalDNS  LocalDNSConfig
+	// P2POnly refuses providers which can only be reached over the NATS dialog.
+	P2POnly bool
 }
 
 // DefaultConfig returns default params.
@@ -89,6 +111,9 @@ func DefaultConfig() Config {
 			SendTimeout:     5 * time.Second,
 			MaxSendErrCount: 5,
 		},
+		LocalDNS: LocalDNSConfig{
+			ListenIP: "127.0.0.1",
+		},
 	}
 }
 
@@ -110,7 +135,7 @@ type TimeGetter func() time.Time
 
 // PaymentEngineFactory creates a new payment issuer from the given params
 type PaymentEngineFactory func(paymentInfo session.PaymentInfo,
-	dialog communication.Dialog, channel p2p.Channel,
+	messenger messaging.Consumer,
 	consumer, provider identity.Identity, accountant common.Address, proposal market.ServiceProposal, sessionID string) (PaymentIssuer, error)
 
 type connectionManager struct {
@@ -126,6 +151,7 @@ type connectionManager struct {
 	validator                validator
 	p2pDialer                p2p.Dialer
 	timeGetter               TimeGetter
+	localDNS                 *dns.ConsumerResolver
 
 	// These are populated by Connect at runtime.
 	ctx                    context.Context
@@ -154,6 +180,14 @@ func NewManager(
 	validator validator,
 	p2pDialer p2p.Dialer,
 ) *connectionManager {
+	var localDNS *dns.ConsumerResolver
+	if config.LocalDNS.Cache {
+		localDNS = dns.NewConsumerResolver(dns.ConsumerOptions{
+			Prefetch:   config.LocalDNS.Prefetch,
+			LogQueries: config.LocalDNS.LogQueries,
+		})
+	}
+
 	return &connectionManager{
 		newDialog:                dialogCreator,
 		newConnection:            connectionCreator,
@@ -168,6 +202,7 @@ func NewManager(
 		validator:                validator,
 		p2pDialer:                p2pDialer,
 		timeGetter:               time.Now,
+		localDNS:                 localDNS,
 	}
 }
 
@@ -195,29 +230,44 @@ func (m *connectionManager) Connect(consumerID identity.Identity, accountantID c
 
 	providerID := identity.FromAddress(proposal.ProviderID)
 
-	var channel p2p.Channel
-	if contact, err := p2p.ParseContact(proposal.ProviderContacts); err == nil {
-		channel, err = m.createP2PChannel(m.currentCtx(), consumerID, providerID, proposal.ServiceType, contact)
+	connection, err := m.newConnection(proposal.ServiceType)
+	if err != nil {
+		return err
+	}
+	// Checked before the provider is contacted, so that no session is opened for a connection which can't be used.
+	if params.ProxyPort != 0 {
+		if proxyConn, ok := connection.(LocalProxyConnection); !ok || !proxyConn.SupportsLocalProxy() {
+			return ErrLocalProxyNotSupported
+		}
+	}
+
+	// Local proxy connections leave the system DNS as is, their DNS servers are queried through the tunnel.
+	var localProxy *LocalProxy
+	var localDNS DNSForwarder
+	if params.ProxyPort != 0 {
+		if _, ok := params.DNS.Encrypted(); ok {
+			return errors.New("encrypted DNS is not supported by local proxy connections")
+		}
+		localProxy, err = newLocalProxy(params.ProxyPort)
 		if err != nil {
-			return fmt.Errorf("could not create p2p channel: %w", err)
+			return errors.Wrap(err, "could not generate local proxy credentials")
 		}
+		m.setStatus(func(status *Status) {
+			status.LocalProxy = localProxy
+		})
 	} else {
-		if stdErrors.Is(err, p2p.ErrContactNotFound) {
-			log.Debug().Msgf("Provider %s doesn't support p2p, will fallback to dialog", providerID.Address)
-		} else {
+		// Encrypted DNS upstreams are resolved before the tunnel changes the system DNS.
+		params.DNS, err = m.startEncryptedDNS(params.DNS)
+		if err != nil {
 			return err
 		}
-	}
-
-	var dialog communication.Dialog
-	if channel == nil {
-		dialog, err = m.createDialog(consumerID, providerID, proposal.ProviderContacts[0])
+		localDNS, err = m.startLocalDNS(params.DNS)
 		if err != nil {
 			return err
 		}
 	}
 
-	connection, err := m.newConnection(proposal.ServiceType)
+	messenger, channel, err := m.createMessenger(consumerID, providerID, proposal)
 	if err != nil {
 		return err
 	}
@@ -226,26 +276,25 @@ func (m *connectionManager) Connect(consumerID identity.Identity, accountantID c
 	var sessionDTO session.CreateResponse
 
 	if channel != nil {
-		sessionDTO, err = m.createP2PSession(m.currentCtx(), connection, channel, consumerID, accountantID, proposal)
 		serviceConn = channel.ServiceConn()
 		channelConn = channel.Conn()
-	} else {
-		sessionDTO, err = m.createSession(connection, dialog, consumerID, accountantID, proposal)
 	}
+	sessionDTO, err = m.createSession(connection, messenger, consumerID, accountantID, proposal)
 	if err != nil {
-		m.sendSessionStatus(dialog, channel, consumerID, sessionDTO.Session.ID, connectivity.StatusSessionEstablishmentFailed, err)
+		m.sendSessionStatus(messenger, providerID, sessionDTO.Session.ID, connectivity.StatusSessionEstablishmentFailed, err)
 		return err
 	}
 
-	err = m.launchPayments(sessionDTO.PaymentInfo, dialog, channel, consumerID, providerID, accountantID, proposal, sessionDTO.Session.ID)
+	err = m.launchPayments(sessionDTO.PaymentInfo, messenger, consumerID, providerID, accountantID, proposal, sessionDTO.Session.ID)
 	if err != nil {
-		m.sendSessionStatus(dialog, channel, consumerID, sessionDTO.Session.ID, connectivity.StatusSessionPaymentsFailed, err)
+		m.sendSessionStatus(messenger, providerID, sessionDTO.Session.ID, connectivity.StatusSessionPaymentsFailed, err)
 		return err
 	}
 
 	originalPublicIP := m.getPublicIP()
 	// Try to establish connection with peer.
-	err = m.startConnection(m.currentCtx(), connection, params.DisableKillSwitch, ConnectOptions{
+	// Nothing but local proxy clients is tunnelled by local proxy connections, so they need no system-wide kill switch.
+	err = m.startConnection(m.currentCtx(), connection, params.DisableKillSwitch || localProxy != nil, ConnectOptions{
 		SessionID:       sessionDTO.Session.ID,
 		SessionConfig:   sessionDTO.Session.Config,
 		DNS:             params.DNS,
@@ -254,13 +303,15 @@ func (m *connectionManager) Connect(consumerID identity.Identity, accountantID c
 		Proposal:        proposal,
 		ProviderNATConn: serviceConn,
 		ChannelConn:     channelConn,
+		LocalDNS:        localDNS,
+		LocalProxy:      localProxy,
 	})
 	if err != nil {
 		if err == context.Canceled {
 			return ErrConnectionCancelled
 		}
 		m.addCleanupAfterDisconnect(func() error {
-			return m.sendSessionStatus(dialog, channel, consumerID, sessionDTO.Session.ID, connectivity.StatusConnectionFailed, err)
+			return m.sendSessionStatus(messenger, providerID, sessionDTO.Session.ID, connectivity.StatusConnectionFailed, err)
 		})
 		m.publishStateEvent(StateConnectionFailed)
 
@@ -270,13 +321,16 @@ func (m *connectionManager) Connect(consumerID identity.Identity, accountantID c
 	}
 
 	go m.keepAliveLoop(channel, sessionDTO.Session.ID)
-	go m.checkSessionIP(dialog, channel, consumerID, sessionDTO.Session.ID, originalPublicIP)
+	// Public IP of the host does not change when only local proxy clients are tunnelled.
+	if localProxy == nil {
+		go m.checkSessionIP(messenger, providerID, sessionDTO.Session.ID, originalPublicIP)
+	}
 
 	return err
 }
 
 // checkSessionIP checks if IP has changed after connection was established.
-func (m *connectionManager) checkSessionIP(dialog communication.Dialog, channel p2p.Channel, consumerID identity.Identity, sessionID session.ID, originalPublicIP string) {
+func (m *connectionManager) checkSessionIP(messenger messaging.Consumer, providerID identity.Identity, sessionID session.ID, originalPublicIP string) {
 	for i := 1; i <= m.config.IPCheck.MaxAttempts; i++ {
 		// Skip check if not connected. This may happen when context was canceled via Disconnect.
 		if m.Status().State != Connected {
@@ -286,13 +340,13 @@ func (m *connectionManager) checkSessionIP(dialog communication.Dialog, channel
 		newPublicIP := m.getPublicIP()
 		// If ip is changed notify peer that connection is successful.
 		if originalPublicIP != newPublicIP {
-			m.sendSessionStatus(dialog, channel, consumerID, sessionID, connectivity.StatusConnectionOk, nil)
+			m.sendSessionStatus(messenger, providerID, sessionID, connectivity.StatusConnectionOk, nil)
 			return
 		}
 
 		// Notify peer and quality oracle that ip is not changed after tunnel connection was established.
 		if i == m.config.IPCheck.MaxAttempts {
-			m.sendSessionStatus(dialog, channel, consumerID, sessionID, connectivity.StatusSessionIPNotChanged, nil)
+			m.sendSessionStatus(messenger, providerID, sessionID, connectivity.StatusSessionIPNotChanged, nil)
 			m.publishStateEvent(StateIPNotChanged)
 			return
 		}
@@ -301,38 +355,83 @@ func (m *connectionManager) checkSessionIP(dialog communication.Dialog, channel
 	}
 }
 
-// sendSessionStatus sends session connectivity status to other peer.
-func (m *connectionManager) sendSessionStatus(dialog communication.Dialog, channel p2p.ChannelSender, consumerID identity.Identity, sessionID session.ID, code connectivity.StatusCode, errDetails error) error {
-	var errDetailsMsg string
-	if errDetails != nil {
-		errDetailsMsg = errDetails.Error()
+// startEncryptedDNS serves encrypted DNS upstreams of the option by a local DNS proxy
+// and returns the option pointing the tunnel DNS to the proxy.
+func (m *connectionManager) startEncryptedDNS(option DNSOption) (DNSOption, error) {
+	upstreams, ok := option.Encrypted()
+	if !ok {
+		return option, nil
 	}
 
-	if channel == nil {
-		return m.connectivityStatusSender.Send(dialog, &connectivity.StatusMessage{
-			SessionID:  string(sessionID),
-			StatusCode: code,
-			Message:    errDetailsMsg,
-		})
+	handler, err := dns.ResolveVia(upstreams)
+	if err != nil {
+		return "", fmt.Errorf("could not create encrypted DNS resolver: %w", err)
 	}
+	proxy := dns.NewProxy(m.config.LocalDNS.ListenIP, 53, handler)
+	if err := proxy.Run(); err != nil {
+		return "", fmt.Errorf("could not start encrypted DNS proxy: %w", err)
+	}
+	m.addCleanupAfterDisconnect(proxy.Stop)
 
-	sessionStatus := &pb.SessionStatus{
-		ConsumerID: consumerID.Address,
-		SessionID:  string(sessionID),
-		Code:       uint32(code),
-		Message:    errDetailsMsg,
+	return DNSOption(m.config.LocalDNS.ListenIP), nil
+}
+
+// startLocalDNS serves the tunnel DNS servers by a local caching proxy, if it is enabled.
+// Options pointing to the proxy already are left as is.
+func (m *connectionManager) startLocalDNS(option DNSOption) (DNSForwarder, error) {
+	if m.localDNS == nil || option == DNSOption(m.config.LocalDNS.ListenIP) {
+		return nil, nil
 	}
 
-	log.Debug().Msgf("Sending session status P2P message to %q: %s", p2p.TopicSessionStatus, sessionStatus.String())
+	proxy := dns.NewProxy(m.config.LocalDNS.ListenIP, 53, m.localDNS)
+	if err := proxy.Run(); err != nil {
+		return nil, fmt.Errorf("could not start local DNS proxy: %w", err)
+	}
+	m.addCleanupAfterDisconnect(func() error {
+		m.localDNS.Pause()
+		return proxy.Stop()
+	})
 
-	ctx, cancel := context.WithTimeout(m.currentCtx(), 20*time.Second)
-	defer cancel()
-	_, err := channel.Send(ctx, p2p.TopicSessionStatus, p2p.ProtoMessage(sessionStatus))
-	if err != nil {
-		return fmt.Errorf("could not send p2p session status message: %w", err)
+	return &localDNSForwarder{resolver: m.localDNS, listenIP: m.config.LocalDNS.ListenIP}, nil
+}
+
+// DNSStats returns statistics of the local caching DNS proxy, if it is enabled.
+func (m *connectionManager) DNSStats() (dns.ConsumerStats, bool) {
+	if m.localDNS == nil {
+		return dns.ConsumerStats{}, false
+	}
+	return m.localDNS.Stats(), true
+}
+
+type localDNSForwarder struct {
+	resolver *dns.ConsumerResolver
+	listenIP string
+}
+
+func (f *localDNSForwarder) Forward(sessionID string, servers []string) ([]string, error) {
+	if err := f.resolver.Forward(sessionID, servers); err != nil {
+		return nil, fmt.Errorf("could not forward local DNS to the tunnel: %w", err)
 	}
+	return []string{f.listenIP}, nil
+}
 
-	return nil
+// sendSessionStatus sends session connectivity status to other peer.
+func (m *connectionManager) sendSessionStatus(messenger messaging.Consumer, providerID identity.Identity, sessionID session.ID, code connectivity.StatusCode, errDetails error) error {
+	var errDetailsMsg string
+	if errDetails != nil {
+		errDetailsMsg = errDetails.Error()
+	}
+
+	m.eventPublisher.Publish(connectivity.AppTopicConnectivityStatus, connectivity.StatusEntry{
+		PeerID:       providerID,
+		SessionID:    string(sessionID),
+		StatusCode:   code,
+		Message:      errDetailsMsg,
+		CreatedAtUTC: time.Now().UTC(),
+		Outgoing:     true,
+	})
+
+	return messenger.SendSessionStatus(m.currentCtx(), sessionID, code, errDetailsMsg)
 }
 
 func (m *connectionManager) getPublicIP() string {
@@ -344,8 +443,8 @@ func (m *connectionManager) getPublicIP() string {
 	return currentPublicIP
 }
 
-func (m *connectionManager) launchPayments(paymentInfo session.PaymentInfo, dialog communication.Dialog, channel p2p.Channel, consumerID, providerID identity.Identity, accountantID common.Address, proposal market.ServiceProposal, sessionID session.ID) error {
-	payments, err := m.paymentEngineFactory(paymentInfo, dialog, channel, consumerID, providerID, accountantID, proposal, string(sessionID))
+func (m *connectionManager) launchPayments(paymentInfo session.PaymentInfo, messenger messaging.Consumer, consumerID, providerID identity.Identity, accountantID common.Address, proposal market.ServiceProposal, sessionID session.ID) error {
+	payments, err := m.paymentEngineFactory(paymentInfo, messenger, consumerID, providerID, accountantID, proposal, string(sessionID))
 	if err != nil {
 		return err
 	}
@@ -388,6 +487,28 @@ func (m *connectionManager) cleanAfterDisconnect() {
 	m.cleanupAfterDisconnect = nil
 }
 
+// createMessenger connects to the provider over p2p, falling back to the NATS dialog for providers
+// not supporting p2p yet. The p2p channel is returned as well, nil for the dialog.
+func (m *connectionManager) createMessenger(consumerID, providerID identity.Identity, proposal market.ServiceProposal) (messaging.Consumer, p2p.Channel, error) {
+	channel, err := m.createP2PChannel(m.currentCtx(), consumerID, providerID, proposal.ServiceType, proposal.ProviderContacts)
+	if err == nil {
+		return messaging.NewP2PConsumer(channel, consumerID), channel, nil
+	}
+	if !stdErrors.Is(err, p2p.ErrContactNotFound) {
+		return nil, nil, fmt.Errorf("could not create p2p channel: %w", err)
+	}
+	if m.config.P2POnly {
+		return nil, nil, fmt.Errorf("could not connect to provider %s: %w", providerID.Address, ErrP2PNotSupported)
+	}
+
+	log.Debug().Msgf("Provider %s doesn't support p2p, will fallback to dialog", providerID.Address)
+	dialog, err := m.createDialog(consumerID, providerID, proposal.ProviderContacts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return messaging.NewDialogConsumer(dialog, m.connectivityStatusSender), nil, nil
+}
+
 func (m *connectionManager) createDialog(consumerID, providerID identity.Identity, contact market.Contact) (communication.Dialog, error) {
 	dialog, err := m.newDialog(consumerID, providerID, contact)
 	if err != nil {
@@ -402,8 +523,8 @@ func (m *connectionManager) createDialog(consumerID, providerID identity.Identit
 	return dialog, err
 }
 
-func (m *connectionManager) createP2PChannel(ctx context.Context, consumerID, providerID identity.Identity, serviceType string, contactDef p2p.ContactDefinition) (p2p.Channel, error) {
-	channel, err := m.p2pDialer.Dial(ctx, consumerID, providerID, serviceType, contactDef)
+func (m *connectionManager) createP2PChannel(ctx context.Context, consumerID, providerID identity.Identity, serviceType string, contacts market.ContactList) (p2p.Channel, error) {
+	channel, err := m.p2pDialer.Dial(ctx, consumerID, providerID, serviceType, contacts)
 	if err != nil {
 		return nil, err
 	}
@@ -428,7 +549,7 @@ func (m *connectionManager) addCleanup(fn func() error) {
 	m.cleanup = append(m.cleanup, fn)
 }
 
-func (m *connectionManager) createP2PSession(ctx context.Context, c Connection, p2pChannel p2p.ChannelSender, consumerID identity.Identity, accountantID common.Address, proposal market.ServiceProposal) (session.CreateResponse, error) {
+func (m *connectionManager) createSession(c Connection, messenger messaging.Consumer, consumerID identity.Identity, accountantID common.Address, proposal market.ServiceProposal) (session.CreateResponse, error) {
 	sessionCreateConfig, err := c.GetConfig()
 	if err != nil {
 		return session.CreateResponse{}, fmt.Errorf("could not get session config: %w", err)
@@ -439,86 +560,7 @@ func (m *connectionManager) createP2PSession(ctx context.Context, c Connection,
 		return session.CreateResponse{}, fmt.Errorf("could not marshal session config: %w", err)
 	}
 
-	sessionRequest := &pb.SessionRequest{
-		Consumer: &pb.ConsumerInfo{
-			Id:             consumerID.Address,
-			AccountantID:   accountantID.Hex(),
-			PaymentVersion: string(session.PaymentVersionV3),
-		},
-		ProposalID: int64(proposal.ID),
-		Config:     config,
-	}
-	log.Debug().Msgf("Sending P2P message to %q: %s", p2p.TopicSessionCreate, sessionRequest.String())
-	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
-	defer cancel()
-	res, err := p2pChannel.Send(ctx, p2p.TopicSessionCreate, p2p.ProtoMessage(sessionRequest))
-	if err != nil {
-		return session.CreateResponse{}, fmt.Errorf("could not send p2p session create request: %w", err)
-	}
-
-	var sessionResponse pb.SessionResponse
-	err = res.UnmarshalProto(&sessionResponse)
-	if err != nil {
-		return session.CreateResponse{}, fmt.Errorf("could not unmarshal session reply to proto: %w", err)
-	}
-
-	m.acknowledge = func() {
-		pc := &pb.SessionInfo{
-			ConsumerID: consumerID.Address,
-			SessionID:  sessionResponse.GetID(),
-		}
-		log.Debug().Msgf("Sending P2P message to %q: %s", p2p.TopicSessionAcknowledge, pc.String())
-		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-		defer cancel()
-		_, err := p2pChannel.Send(ctx, p2p.TopicSessionAcknowledge, p2p.ProtoMessage(pc))
-		if err != nil {
-			log.Warn().Err(err).Msg("Acknowledge failed")
-		}
-	}
-	m.addCleanupAfterDisconnect(func() error {
-		log.Trace().Msg("Cleaning: requesting session destroy")
-		defer log.Trace().Msg("Cleaning: requesting session destroy DONE")
-
-		sessionDestroy := &pb.SessionInfo{
-			ConsumerID: consumerID.Address,
-			SessionID:  sessionResponse.GetID(),
-		}
-
-		log.Debug().Msgf("Sending P2P message to %q: %s", p2p.TopicSessionDestroy, sessionDestroy.String())
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		_, err := p2pChannel.Send(ctx, p2p.TopicSessionDestroy, p2p.ProtoMessage(sessionDestroy))
-		if err != nil {
-			return fmt.Errorf("could not send session destroy request: %w", err)
-		}
-
-		return nil
-	})
-
-	sessionID := session.ID(sessionResponse.GetID())
-	m.publishSessionCreate(sessionID)
-
-	return session.CreateResponse{
-		Session: session.SessionDto{
-			ID:     sessionID,
-			Config: sessionResponse.GetConfig(),
-		},
-		PaymentInfo: session.PaymentInfo{Supports: sessionResponse.GetPaymentInfo()},
-	}, nil
-}
-
-func (m *connectionManager) createSession(c Connection, dialog communication.Dialog, consumerID identity.Identity, accountantID common.Address, proposal market.ServiceProposal) (session.CreateResponse, error) {
-	sessionCreateConfig, err := c.GetConfig()
-	if err != nil {
-		return session.CreateResponse{}, fmt.Errorf("could not get session config: %w", err)
-	}
-
-	config, err := json.Marshal(sessionCreateConfig)
-	if err != nil {
-		return session.CreateResponse{}, fmt.Errorf("could not marshal session config: %w", err)
-	}
-
-	sessionResponse, err := session.RequestSessionCreate(dialog, session.CreateRequest{
+	sessionResponse, err := messenger.CreateSession(m.currentCtx(), session.CreateRequest{
 		ProposalID: proposal.ID,
 		Config:     config,
 		ConsumerInfo: &session.ConsumerInfo{
@@ -532,7 +574,7 @@ func (m *connectionManager) createSession(c Connection, dialog communication.Dia
 	}
 
 	m.acknowledge = func() {
-		err := session.AcknowledgeSession(dialog, string(sessionResponse.Session.ID))
+		err := messenger.AcknowledgeSession(context.Background(), sessionResponse.Session.ID)
 		if err != nil {
 			log.Warn().Err(err).Msg("Acknowledge failed")
 		}
@@ -540,7 +582,7 @@ func (m *connectionManager) createSession(c Connection, dialog communication.Dia
 	m.addCleanupAfterDisconnect(func() error {
 		log.Trace().Msg("Cleaning: requesting session destroy")
 		defer log.Trace().Msg("Cleaning: requesting session destroy DONE")
-		return session.RequestSessionDestroy(dialog, sessionResponse.Session.ID)
+		return messenger.DestroySession(context.Background(), sessionResponse.Session.ID)
 	})
 
 	m.publishSessionCreate(sessionResponse.Session.ID)
@@ -761,8 +803,14 @@ func (m *connectionManager) onStateChanged(state State) {
 	// React just to certains stains from connection. Because disconnect happens in connectionWaiter
 	switch state {
 	case Connected:
+		if m.localDNS != nil {
+			m.localDNS.Resume()
+		}
 		m.statusConnected()
 	case Reconnecting:
+		if m.localDNS != nil {
+			m.localDNS.Pause()
+		}
 		m.statusReconnecting()
 	}
 }
@@ -36,7 +36,9 @@ import (
 	"github.com/mysteriumnetwork/node/pb"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/mysteriumnetwork/node/session/messaging"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -81,6 +83,7 @@ func (tc *testContext) SetupTest() {
 	defer tc.Unlock()
 
 	tc.stubPublisher = NewStubPublisher()
+	tc.mockDialog = nil
 	dialogCreator := func(consumer, provider identity.Identity, contact market.Contact) (communication.Dialog, error) {
 		tc.Lock()
 		defer tc.Unlock()
@@ -138,7 +141,7 @@ func (tc *testContext) SetupTest() {
 	tc.connManager = NewManager(
 		dialogCreator,
 		func(paymentInfo session.PaymentInfo,
-			dialog communication.Dialog, channel p2p.Channel,
+			messenger messaging.Consumer,
 			consumer, provider identity.Identity, accountant common.Address, proposal market.ServiceProposal, sessionID string) (PaymentIssuer, error) {
 			tc.MockPaymentIssuer = &MockPaymentIssuer{
 				initialState:      paymentInfo,
@@ -558,6 +561,80 @@ func (tc *testContext) Test_ManagerNotifiesAboutSuccessfulConnection() {
 	assert.Equal(tc.T(), expectedStatusMsg, tc.mockP2P.ch.getSentMsg())
 }
 
+func (tc *testContext) Test_ManagerFallsBackToDialogWithoutP2PContact() {
+	proposal := activeProposal
+	proposal.ProviderContacts = []market.Contact{{Type: "nats/v1"}}
+
+	err := tc.connManager.Connect(consumerID, accountantID, proposal, ConnectParams{})
+	assert.NoError(tc.T(), err)
+	assert.Equal(tc.T(), establishedSessionID, tc.connManager.Status().SessionID)
+	assert.NotNil(tc.T(), tc.mockDialog)
+}
+
+func (tc *testContext) Test_ManagerRefusesDialogWhenP2POnly() {
+	tc.connManager.config.P2POnly = true
+	proposal := activeProposal
+	proposal.ProviderContacts = []market.Contact{{Type: "nats/v1"}}
+
+	err := tc.connManager.Connect(consumerID, accountantID, proposal, ConnectParams{})
+	assert.True(tc.T(), errors.Is(err, ErrP2PNotSupported))
+	assert.Nil(tc.T(), tc.mockDialog)
+	assert.Equal(tc.T(), NotConnected, tc.connManager.Status().State)
+}
+
+func (tc *testContext) Test_ManagerRefusesLocalProxyWhenNotSupported() {
+	proposal := activeProposal
+	proposal.ProviderContacts = []market.Contact{{Type: "nats/v1"}}
+
+	err := tc.connManager.Connect(consumerID, accountantID, proposal, ConnectParams{ProxyPort: 1080})
+	assert.Equal(tc.T(), ErrLocalProxyNotSupported, err)
+	assert.Nil(tc.T(), tc.mockDialog, "provider must not be contacted")
+	assert.Equal(tc.T(), NotConnected, tc.connManager.Status().State)
+}
+
+func (tc *testContext) Test_ManagerRefusesLocalProxyWithEncryptedDNS() {
+	tc.fakeConnectionFactory.mockConnection.supportsLocalProxy = true
+
+	err := tc.connManager.Connect(consumerID, accountantID, activeProposal, ConnectParams{
+		ProxyPort: 1080,
+		DNS:       DNSOption("doh:https://cloudflare-dns.com/dns-query"),
+	})
+	assert.Error(tc.T(), err)
+	assert.Equal(tc.T(), NotConnected, tc.connManager.Status().State)
+}
+
+func (tc *testContext) Test_ManagerStartsLocalProxyConnection() {
+	tc.stubPublisher.Clear()
+	startedWith := make(chan ConnectOptions, 1)
+	tc.fakeConnectionFactory.mockConnection.supportsLocalProxy = true
+	tc.fakeConnectionFactory.mockConnection.onStart = func(options ConnectOptions) {
+		startedWith <- options
+	}
+	tc.fakeConnectionFactory.mockConnection.onStartReportStates = []fakeState{
+		connectedState,
+	}
+
+	err := tc.connManager.Connect(consumerID, accountantID, activeProposal, ConnectParams{ProxyPort: 1080, DNS: DNSOptionProvider})
+	assert.NoError(tc.T(), err)
+	options := <-startedWith
+	require.NotNil(tc.T(), options.LocalProxy)
+	assert.Equal(tc.T(), 1080, options.LocalProxy.Port)
+	assert.NotEmpty(tc.T(), options.LocalProxy.Username)
+	assert.Len(tc.T(), options.LocalProxy.Password, 32)
+	assert.Equal(tc.T(), options.LocalProxy, tc.connManager.Status().LocalProxy)
+	assert.Equal(tc.T(), DNSOptionProvider, options.DNS)
+	assert.Nil(tc.T(), options.LocalDNS)
+
+	waitABit()
+
+	// Public IP of the host is not expected to change.
+	for _, v := range tc.stubPublisher.GetEventHistory() {
+		if v.calledWithTopic == AppTopicConnectionState {
+			assert.NotEqual(tc.T(), StateIPNotChanged, v.calledWithData.(AppEventConnectionState).State)
+		}
+	}
+}
+
 func TestConnectionManagerSuite(t *testing.T) {
 	suite.Run(t, new(testContext))
 }
@@ -649,7 +726,10 @@ type mockP2PDialer struct {
 	ch *mockP2PChannel
 }
 
-func (m mockP2PDialer) Dial(ctx context.Context, consumerID identity.Identity, providerID identity.Identity, serviceType string, contactDef p2p.ContactDefinition) (p2p.Channel, error) {
+func (m mockP2PDialer) Dial(ctx context.Context, consumerID identity.Identity, providerID identity.Identity, serviceType string, contacts market.ContactList) (p2p.Channel, error) {
+	if _, err := p2p.ParseContact(contacts); err != nil {
+		return nil, err
+	}
 	return m.ch, nil
 }
 
@@ -127,6 +127,8 @@ func (c *connectionFactoryFake) CreateConnection(serviceType string) (Connection
 		onStartReportStats:  c.mockConnection.onStartReportStats,
 		fakeProcess:         sync.WaitGroup{},
 		stopBlock:           c.mockConnection.stopBlock,
+		supportsLocalProxy:  c.mockConnection.supportsLocalProxy,
+		onStart:             c.mockConnection.onStart,
 	}
 
 	return &copy, nil
@@ -141,6 +143,8 @@ type connectionMock struct {
 	onStartReportStats  Statistics
 	fakeProcess         sync.WaitGroup
 	stopBlock           chan struct{}
+	supportsLocalProxy  bool
+	onStart             func(options ConnectOptions)
 	sync.RWMutex
 }
 
@@ -160,6 +164,9 @@ func (foc *connectionMock) Start(ctx context.Context, connectionParams ConnectOp
 	foc.RLock()
 	defer foc.RUnlock()
 
+	if foc.onStart != nil {
+		foc.onStart(connectionParams)
+	}
 	if foc.onStartReturnError != nil {
 		return foc.onStartReturnError
 	}
@@ -171,6 +178,10 @@ func (foc *connectionMock) Start(ctx context.Context, connectionParams ConnectOp
 	return nil
 }
 
+func (foc *connectionMock) SupportsLocalProxy() bool {
+	return foc.supportsLocalProxy
+}
+
 func (foc *connectionMock) Wait() error {
 	foc.fakeProcess.Wait()
 	return nil
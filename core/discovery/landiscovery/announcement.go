@@ -0,0 +1,112 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package landiscovery
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/pkg/errors"
+)
+
+// txtChunkSize keeps each TXT string below the 255 bytes limit of DNS character strings.
+const txtChunkSize = 250
+
+// maxAnnouncementSize limits decompressed announcement, proposals are a few kilobytes at most.
+const maxAnnouncementSize = 64 * 1024
+
+type announcement struct {
+	Proposal  json.RawMessage `json:"proposal"`
+	Signature string          `json:"signature"`
+}
+
+// encodeProposal signs the proposal and packs it into TXT strings.
+// Announcement is gzipped and base64 encoded, so that it survives DNS escaping and fits a single packet.
+func encodeProposal(proposal market.ServiceProposal, signer identity.Signer) ([]string, error) {
+	proposalJSON, err := json.Marshal(proposal)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal proposal")
+	}
+	signature, err := signer.Sign(proposalJSON)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not sign proposal")
+	}
+	announcementJSON, err := json.Marshal(announcement{Proposal: proposalJSON, Signature: signature.Base64()})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal announcement")
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(announcementJSON); err != nil {
+		return nil, errors.Wrap(err, "could not compress announcement")
+	}
+	if err := zw.Close(); err != nil {
+		return nil, errors.Wrap(err, "could not compress announcement")
+	}
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	var text []string
+	for len(encoded) > txtChunkSize {
+		text = append(text, encoded[:txtChunkSize])
+		encoded = encoded[txtChunkSize:]
+	}
+	return append(text, encoded), nil
+}
+
+// decodeProposal unpacks the proposal from TXT strings and verifies it was signed by its provider.
+func decodeProposal(text []string) (market.ServiceProposal, error) {
+	var proposal market.ServiceProposal
+
+	compressed, err := base64.StdEncoding.DecodeString(strings.Join(text, ""))
+	if err != nil {
+		return proposal, errors.Wrap(err, "could not decode announcement")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return proposal, errors.Wrap(err, "could not decompress announcement")
+	}
+	announcementJSON, err := ioutil.ReadAll(io.LimitReader(zr, maxAnnouncementSize+1))
+	if err != nil {
+		return proposal, errors.Wrap(err, "could not decompress announcement")
+	}
+	if len(announcementJSON) > maxAnnouncementSize {
+		return proposal, errors.Errorf("announcement exceeds %d bytes", maxAnnouncementSize)
+	}
+
+	var a announcement
+	if err := json.Unmarshal(announcementJSON, &a); err != nil {
+		return proposal, errors.Wrap(err, "could not unmarshal announcement")
+	}
+	if err := json.Unmarshal(a.Proposal, &proposal); err != nil {
+		return proposal, errors.Wrap(err, "could not unmarshal proposal")
+	}
+
+	verifier := identity.NewVerifierIdentity(identity.FromAddress(proposal.ProviderID))
+	if !verifier.Verify(a.Proposal, identity.SignatureBase64(a.Signature)) {
+		return proposal, errors.Errorf("invalid signature of proposal from %s", proposal.ProviderID)
+	}
+	return proposal, nil
+}
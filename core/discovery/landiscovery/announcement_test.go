@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package landiscovery
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/stretchr/testify/assert"
+)
+
+const providerAddress = "0x53a835143c0ef3bbcbfa796d7eb738ca7dd28f68"
+
+func init() {
+	market.RegisterServiceDefinitionUnserializer(
+		"mock_service",
+		func(rawDefinition *json.RawMessage) (market.ServiceDefinition, error) {
+			return mockServiceDefinition{}, nil
+		},
+	)
+	market.RegisterPaymentMethodUnserializer(
+		"mock_payment",
+		func(rawDefinition *json.RawMessage) (market.PaymentMethod, error) {
+			return mockPaymentMethod{}, nil
+		},
+	)
+	market.RegisterContactUnserializer("mock_contact",
+		func(rawMessage *json.RawMessage) (market.ContactDefinition, error) {
+			return mockContact{}, nil
+		},
+	)
+}
+
+func newProposal(providerID string) market.ServiceProposal {
+	return market.ServiceProposal{
+		ProviderID:        providerID,
+		ServiceType:       "mock_service",
+		ServiceDefinition: mockServiceDefinition{},
+		PaymentMethodType: "mock_payment",
+		PaymentMethod:     mockPaymentMethod{},
+		ProviderContacts:  []market.Contact{{Type: "mock_contact", Definition: mockContact{}}},
+	}
+}
+
+func newSigner(t *testing.T) identity.Signer {
+	ks := identity.NewKeystoreFilesystem("dir", identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
+	manager := identity.NewIdentityManager(ks, eventbus.New())
+	assert.NoError(t, manager.Unlock(providerAddress, ""))
+	return identity.NewSigner(ks, identity.FromAddress(providerAddress))
+}
+
+type mockServiceDefinition struct{}
+
+func (service mockServiceDefinition) GetLocation() market.Location {
+	return market.Location{}
+}
+
+type mockPaymentMethod struct{}
+
+func (method mockPaymentMethod) GetPrice() money.Money {
+	return money.Money{}
+}
+
+func (method mockPaymentMethod) GetType() string {
+	return "mock"
+}
+
+func (method mockPaymentMethod) GetRate() market.PaymentRate {
+	return market.PaymentRate{}
+}
+
+type mockContact struct{}
+
+func TestAnnouncement_EncodeDecode(t *testing.T) {
+	text, err := encodeProposal(newProposal(providerAddress), newSigner(t))
+	assert.NoError(t, err)
+	for _, s := range text {
+		assert.True(t, len(s) <= txtChunkSize)
+	}
+
+	decoded, err := decodeProposal(text)
+	assert.NoError(t, err)
+	assert.Equal(t, newProposal(providerAddress), decoded)
+}
+
+func TestAnnouncement_DecodeRejectsForeignSignature(t *testing.T) {
+	text, err := encodeProposal(newProposal("0x1"), newSigner(t))
+	assert.NoError(t, err)
+
+	_, err = decodeProposal(text)
+	assert.EqualError(t, err, "invalid signature of proposal from 0x1")
+}
+
+func TestAnnouncement_DecodeRejectsGarbage(t *testing.T) {
+	_, err := decodeProposal([]string{"not base64!"})
+	assert.Error(t, err)
+}
+
+func TestAnnouncement_DecodeRejectsOversized(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(make([]byte, 10*maxAnnouncementSize))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	_, err = decodeProposal([]string{base64.StdEncoding.EncodeToString(buf.Bytes())})
+	assert.EqualError(t, err, fmt.Sprintf("announcement exceeds %d bytes", maxAnnouncementSize))
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package landiscovery
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/oleksandr/bonjour"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	serviceName = "_mysterium-proposal._udp"
+	domain      = "local."
+	// announcedPort is required by mDNS service records, the proposal itself travels in TXT records.
+	announcedPort = 9
+)
+
+type announcer interface {
+	SetText(text []string)
+	Shutdown()
+}
+
+type registryLAN struct {
+	announce func(instance string, text []string) (announcer, error)
+
+	mu         sync.Mutex
+	announcers map[market.ProposalID]announcer
+}
+
+// NewRegistry creates an instance of registry announcing proposals on the local network via mDNS.
+// Announcing is best-effort, failures are logged and never prevent registration in other registries.
+func NewRegistry() *registryLAN {
+	return &registryLAN{
+		announce:   announceMDNS,
+		announcers: make(map[market.ProposalID]announcer),
+	}
+}
+
+// RegisterProposal starts announcing service proposal on the local network.
+func (rl *registryLAN) RegisterProposal(proposal market.ServiceProposal, signer identity.Signer) error {
+	rl.update(proposal, signer)
+	return nil
+}
+
+// PingProposal refreshes announced service proposal.
+func (rl *registryLAN) PingProposal(proposal market.ServiceProposal, signer identity.Signer) error {
+	rl.update(proposal, signer)
+	return nil
+}
+
+// UnregisterProposal stops announcing service proposal.
+func (rl *registryLAN) UnregisterProposal(proposal market.ServiceProposal, signer identity.Signer) error {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if a, ok := rl.announcers[proposal.UniqueID()]; ok {
+		a.Shutdown()
+		delete(rl.announcers, proposal.UniqueID())
+	}
+	return nil
+}
+
+func (rl *registryLAN) update(proposal market.ServiceProposal, signer identity.Signer) {
+	text, err := encodeProposal(proposal, signer)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode proposal for LAN announcement")
+		return
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if a, ok := rl.announcers[proposal.UniqueID()]; ok {
+		a.SetText(text)
+		return
+	}
+
+	instance := fmt.Sprintf("%s-%s", proposal.ProviderID, proposal.ServiceType)
+	a, err := rl.announce(instance, text)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Failed to announce proposal %s on LAN", instance)
+		return
+	}
+	rl.announcers[proposal.UniqueID()] = a
+}
+
+// announceMDNS registers mDNS service with explicit host address,
+// as hostname of the node often does not resolve to its LAN address.
+func announceMDNS(instance string, text []string) (announcer, error) {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "mysterium-node"
+	}
+	ip, err := lanIP()
+	if err != nil {
+		return nil, err
+	}
+	return bonjour.RegisterProxy(instance, serviceName, domain, announcedPort, host, ip.String(), text, nil)
+}
+
+func lanIP() (net.IP, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list interface addresses")
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+	}
+	return nil, errors.New("no LAN address found")
+}
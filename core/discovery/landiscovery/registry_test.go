@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package landiscovery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAnnouncer struct {
+	text     []string
+	shutdown bool
+}
+
+func (m *mockAnnouncer) SetText(text []string) {
+	m.text = text
+}
+
+func (m *mockAnnouncer) Shutdown() {
+	m.shutdown = true
+}
+
+func TestRegistry_AnnouncesProposal(t *testing.T) {
+	announcers := make(map[string]*mockAnnouncer)
+	registry := NewRegistry()
+	registry.announce = func(instance string, text []string) (announcer, error) {
+		a := &mockAnnouncer{text: text}
+		announcers[instance] = a
+		return a, nil
+	}
+	signer := newSigner(t)
+	p := newProposal(providerAddress)
+
+	assert.NoError(t, registry.RegisterProposal(p, signer))
+	a, ok := announcers[providerAddress+"-mock_service"]
+	assert.True(t, ok)
+	decoded, err := decodeProposal(a.text)
+	assert.NoError(t, err)
+	assert.Equal(t, p, decoded)
+
+	a.text = nil
+	assert.NoError(t, registry.PingProposal(p, signer))
+	assert.Len(t, announcers, 1)
+	assert.NotEmpty(t, a.text)
+
+	assert.NoError(t, registry.UnregisterProposal(p, signer))
+	assert.True(t, a.shutdown)
+	assert.Empty(t, registry.announcers)
+}
+
+func TestRegistry_AnnounceFailureIsNotFatal(t *testing.T) {
+	registry := NewRegistry()
+	registry.announce = func(instance string, text []string) (announcer, error) {
+		return nil, errors.New("no multicast")
+	}
+
+	assert.NoError(t, registry.RegisterProposal(newProposal(providerAddress), newSigner(t)))
+	assert.Empty(t, registry.announcers)
+}
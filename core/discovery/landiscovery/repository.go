@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package landiscovery
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/discovery/brokerdiscovery"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/oleksandr/bonjour"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// browseFunc returns TXT records of all proposals announced on the local network.
+type browseFunc func(timeout time.Duration) ([][]string, error)
+
+// Repository provides proposals announced on the local network.
+type Repository struct {
+	storage       *brokerdiscovery.ProposalStorage
+	browse        browseFunc
+	browseTimeout time.Duration
+	fetchInterval time.Duration
+
+	stopOnce sync.Once
+	stopChan chan struct{}
+}
+
+// NewRepository constructs a new proposal repository (backed by mDNS on the local network).
+func NewRepository(storage *brokerdiscovery.ProposalStorage, fetchInterval time.Duration) *Repository {
+	return &Repository{
+		storage:       storage,
+		browse:        browseMDNS,
+		browseTimeout: 3 * time.Second,
+		fetchInterval: fetchInterval,
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// Proposal returns a single proposal by its ID.
+func (r *Repository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	return r.storage.GetProposal(id)
+}
+
+// Proposals returns proposals matching the filter.
+func (r *Repository) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
+	return r.storage.FindProposals(*filter)
+}
+
+// Start begins periodic browsing of the local network.
+func (r *Repository) Start() error {
+	go r.fetchLoop()
+	return nil
+}
+
+// Stop ends browsing of the local network.
+func (r *Repository) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+	})
+}
+
+func (r *Repository) fetchLoop() {
+	for {
+		if err := r.fetch(); err != nil {
+			log.Warn().Err(err).Msg("Failed to browse LAN proposals")
+		}
+
+		select {
+		case <-r.stopChan:
+			return
+		case <-time.After(r.fetchInterval):
+		}
+	}
+}
+
+func (r *Repository) fetch() error {
+	records, err := r.browse(r.browseTimeout)
+	if err != nil {
+		return err
+	}
+
+	proposals := make([]market.ServiceProposal, 0, len(records))
+	for _, text := range records {
+		p, err := decodeProposal(text)
+		if err != nil {
+			log.Debug().Err(err).Msg("Skipping LAN announcement")
+			continue
+		}
+		if !p.IsSupported() {
+			continue
+		}
+		proposals = append(proposals, p)
+	}
+
+	log.Debug().Msgf("Found %d proposals on LAN", len(proposals))
+	r.storage.Set(proposals)
+	return nil
+}
+
+func browseMDNS(timeout time.Duration) ([][]string, error) {
+	resolver, err := bonjour.NewResolver(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create mDNS resolver")
+	}
+
+	entries := make(chan *bonjour.ServiceEntry, 32)
+	if err := resolver.Browse(serviceName, domain, entries); err != nil {
+		return nil, errors.Wrap(err, "could not browse mDNS")
+	}
+
+	var records [][]string
+	deadline := time.After(timeout)
+	for {
+		select {
+		case e := <-entries:
+			if len(e.Text) > 0 {
+				records = append(records, e.Text)
+			}
+		case <-deadline:
+			exited := make(chan struct{})
+			go func() {
+				resolver.Exit <- true
+				close(exited)
+			}()
+			// Keep draining entries, so that resolver is never blocked on delivering one.
+			for {
+				select {
+				case <-entries:
+				case <-exited:
+					return records, nil
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package landiscovery
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/discovery/brokerdiscovery"
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_FetchesLANProposals(t *testing.T) {
+	valid, err := encodeProposal(newProposal(providerAddress), newSigner(t))
+	assert.NoError(t, err)
+	forged, err := encodeProposal(newProposal("0x1"), newSigner(t))
+	assert.NoError(t, err)
+
+	repo := NewRepository(brokerdiscovery.NewStorage(eventbus.New()), time.Minute)
+	repo.browse = func(timeout time.Duration) ([][]string, error) {
+		return [][]string{valid, forged, {"garbage"}}, nil
+	}
+	assert.NoError(t, repo.fetch())
+
+	proposals, err := repo.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{newProposal(providerAddress)}, proposals)
+
+	p, err := repo.Proposal(market.ProposalID{ProviderID: providerAddress, ServiceType: "mock_service"})
+	assert.NoError(t, err)
+	assert.Equal(t, providerAddress, p.ProviderID)
+
+	repo.browse = func(timeout time.Duration) ([][]string, error) {
+		return nil, nil
+	}
+	assert.NoError(t, repo.fetch())
+	proposals, err = repo.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+	assert.Empty(t, proposals)
+}
+
+func TestRepository_FetchError(t *testing.T) {
+	repo := NewRepository(brokerdiscovery.NewStorage(eventbus.New()), time.Minute)
+	repo.browse = func(timeout time.Duration) ([][]string, error) {
+		return nil, errors.New("no multicast")
+	}
+	assert.EqualError(t, repo.fetch(), "no multicast")
+}
@@ -28,6 +28,7 @@ import (
 
 // repository provides proposals from multiple other repositories.
 type repository struct {
+	preferred []proposal.Repository
 	delegates []proposal.Repository
 }
 
@@ -37,15 +38,26 @@ func NewRepository() *repository {
 }
 
 // Add adds a delegate repositories from which proposals can be acquired.
+// Proposals of delegates added later override the same proposals of delegates added earlier.
 func (c *repository) Add(repository proposal.Repository) {
 	c.delegates = append(c.delegates, repository)
 }
 
+// AddPreferred adds a delegate repository which takes precedence over all delegates added with Add.
+// Its proposals are listed first and are not overridden.
+func (c *repository) AddPreferred(repository proposal.Repository) {
+	c.preferred = append(c.preferred, repository)
+}
+
+func (c *repository) all() []proposal.Repository {
+	return append(append([]proposal.Repository{}, c.preferred...), c.delegates...)
+}
+
 // Proposal returns a single proposal by its ID.
 func (c *repository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
 	allErrors := utils.ErrorCollection{}
 
-	for _, delegate := range c.delegates {
+	for _, delegate := range c.all() {
 		serviceProposal, err := delegate.Proposal(id)
 		if err == nil {
 			return serviceProposal, nil
@@ -58,12 +70,13 @@ func (c *repository) Proposal(id market.ProposalID) (*market.ServiceProposal, er
 
 // Proposals returns proposals matching the filter.
 func (c *repository) Proposals(filter *proposal.Filter) ([]market.ServiceProposal, error) {
-	log.Debug().Msgf("Retrieving proposals from %d repositories", len(c.delegates))
-	proposals := make([][]market.ServiceProposal, len(c.delegates))
-	errors := make([]error, len(c.delegates))
+	delegates := c.all()
+	log.Debug().Msgf("Retrieving proposals from %d repositories", len(delegates))
+	proposals := make([][]market.ServiceProposal, len(delegates))
+	errors := make([]error, len(delegates))
 
 	var wg sync.WaitGroup
-	for i, delegate := range c.delegates {
+	for i, delegate := range delegates {
 		wg.Add(1)
 		go func(idx int, repo proposal.Repository) {
 			defer wg.Done()
@@ -72,19 +85,27 @@ func (c *repository) Proposals(filter *proposal.Filter) ([]market.ServiceProposa
 	}
 	wg.Wait()
 
-	uniqueProposals := make(map[market.ProposalID]market.ServiceProposal)
+	// Proposals of preferred delegates are kept, others are overridden by later delegates.
+	positions := make(map[market.ProposalID]int)
+	preferred := make(map[market.ProposalID]struct{})
+	var result []market.ServiceProposal
 	for i, repoProposals := range proposals {
 		log.Trace().Msgf("Retrieved %d proposals from repository %d", len(repoProposals), i)
 		for _, p := range repoProposals {
-			uniqueProposals[p.UniqueID()] = p
+			id := p.UniqueID()
+			pos, ok := positions[id]
+			if !ok {
+				positions[id] = len(result)
+				result = append(result, p)
+			} else if _, ok := preferred[id]; !ok {
+				result[pos] = p
+			}
+			if i < len(c.preferred) {
+				preferred[id] = struct{}{}
+			}
 		}
 	}
 
-	var result []market.ServiceProposal
-	for _, val := range uniqueProposals {
-		result = append(result, val)
-	}
-
 	allErrors := utils.ErrorCollection{}
 	allErrors.Add(errors...)
 
@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package discovery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepository struct {
+	proposals []market.ServiceProposal
+}
+
+func (m *mockRepository) Proposal(id market.ProposalID) (*market.ServiceProposal, error) {
+	for _, p := range m.proposals {
+		if p.UniqueID() == id {
+			return &p, nil
+		}
+	}
+	return nil, errors.New("proposal does not exist")
+}
+
+func (m *mockRepository) Proposals(_ *proposal.Filter) ([]market.ServiceProposal, error) {
+	return m.proposals, nil
+}
+
+func TestRepository_ProposalsKeepDelegateOrder(t *testing.T) {
+	api := &mockRepository{proposals: []market.ServiceProposal{
+		{ProviderID: "0x1", ServiceType: "wireguard"},
+		{ProviderID: "0x2", ServiceType: "wireguard", PaymentMethodType: "api"},
+	}}
+	lan := &mockRepository{proposals: []market.ServiceProposal{
+		{ProviderID: "0x2", ServiceType: "wireguard", PaymentMethodType: "lan"},
+	}}
+
+	repo := NewRepository()
+	repo.Add(api)
+	repo.AddPreferred(lan)
+
+	proposals, err := repo.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{
+		{ProviderID: "0x2", ServiceType: "wireguard", PaymentMethodType: "lan"},
+		{ProviderID: "0x1", ServiceType: "wireguard"},
+	}, proposals)
+
+	p, err := repo.Proposal(market.ProposalID{ProviderID: "0x2", ServiceType: "wireguard"})
+	assert.NoError(t, err)
+	assert.Equal(t, "lan", p.PaymentMethodType)
+}
+
+func TestRepository_ProposalsOfLaterDelegatesOverrideEarlier(t *testing.T) {
+	api := &mockRepository{proposals: []market.ServiceProposal{
+		{ProviderID: "0x1", ServiceType: "wireguard", PaymentMethodType: "api"},
+		{ProviderID: "0x2", ServiceType: "wireguard", PaymentMethodType: "api"},
+	}}
+	broker := &mockRepository{proposals: []market.ServiceProposal{
+		{ProviderID: "0x2", ServiceType: "wireguard", PaymentMethodType: "broker"},
+	}}
+
+	repo := NewRepository()
+	repo.Add(api)
+	repo.Add(broker)
+
+	proposals, err := repo.Proposals(&proposal.Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []market.ServiceProposal{
+		{ProviderID: "0x1", ServiceType: "wireguard", PaymentMethodType: "api"},
+		{ProviderID: "0x2", ServiceType: "wireguard", PaymentMethodType: "broker"},
+	}, proposals)
+}
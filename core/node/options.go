@@ -19,6 +19,7 @@ package node
 
 import (
 	"path"
+	"time"
 
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/port"
@@ -58,6 +59,8 @@ type Options struct {
 	TequilapiAddress string
 	TequilapiPort    int
 	TequilapiEnabled bool
+	TequilapiAuth    bool
+	Tequilapi        OptionsTequilapi
 	BindAddress      string
 	UI               OptionsUI
 	FeedbackURL      string
@@ -75,10 +78,14 @@ type Options struct {
 
 	Openvpn  Openvpn
 	Firewall OptionsFirewall
+	DNS      OptionsDNS
 
 	Payments OptionsPayments
+	Storage  OptionsStorage
 
 	MobileConsumer bool
+	// MobileProvider enables provider components on mobile, services are registered by the mobile SDK.
+	MobileProvider bool
 
 	P2PPorts *port.Range
 }
@@ -90,6 +97,8 @@ func GetOptions() *Options {
 		TequilapiAddress: config.GetString(config.FlagTequilapiAddress),
 		TequilapiPort:    config.GetInt(config.FlagTequilapiPort),
 		TequilapiEnabled: true,
+		TequilapiAuth:    config.GetBool(config.FlagTequilapiAuth),
+		Tequilapi:        *GetOptionsTequilapi(config.GetString(config.FlagDataDir)),
 		BindAddress:      config.GetString(config.FlagBindAddress),
 		UI: OptionsUI{
 			UIEnabled: config.GetBool(config.FlagUIEnable),
@@ -97,13 +106,17 @@ func GetOptions() *Options {
 		},
 		FeedbackURL: config.GetString(config.FlagFeedbackURL),
 		Keystore: OptionsKeystore{
-			UseLightweight: config.GetBool(config.FlagKeystoreLightweight),
+			UseLightweight:        config.GetBool(config.FlagKeystoreLightweight),
+			ExternalSigner:        config.GetString(config.FlagKeystoreExternalSigner),
+			ExternalSignerTimeout: config.GetDuration(config.FlagKeystoreExternalSignerTimeout),
 		},
 		LogOptions: *GetLogOptions(),
 		OptionsNetwork: OptionsNetwork{
 			Testnet:               config.GetBool(config.FlagTestnet),
 			Localnet:              config.GetBool(config.FlagLocalnet),
 			ExperimentNATPunching: config.GetBool(config.FlagNATPunching),
+			P2POnly:               config.GetBool(config.FlagP2POnly),
+			P2PDirectPort:         config.GetInt(config.FlagP2PDirectPort),
 			MysteriumAPIAddress:   config.GetString(config.FlagAPIAddress),
 			BrokerAddress:         config.GetString(config.FlagBrokerAddress),
 			EtherClientRPC:        config.GetString(config.FlagEtherRPC),
@@ -138,6 +151,9 @@ func GetOptions() *Options {
 			BCTimeout:                          config.GetDuration(config.FlagPaymentsBCTimeout),
 			AccountantPromiseSettlingThreshold: config.GetFloat64(config.FlagPaymentsAccountantPromiseSettleThreshold),
 			SettlementTimeout:                  config.GetDuration(config.FlagPaymentsAccountantPromiseSettleTimeout),
+			SettlementSchedule:                 config.GetString(config.FlagPaymentsSettleSchedule),
+			SettlementMinFeeMultiplier:         config.GetFloat64(config.FlagPaymentsSettleMinFeeMultiplier),
+			SettlementMaxPerDay:                config.GetInt(config.FlagPaymentsSettleMaxPerDay),
 			MystSCAddress:                      config.GetString(config.FlagPaymentsMystSCAddress),
 			ConsumerUpperGBPriceBound:          config.GetUInt64(config.FlagPaymentsConsumerPricePerGBUpperBound),
 			ConsumerLowerGBPriceBound:          config.GetUInt64(config.FlagPaymentsConsumerPricePerGBLowerBound),
@@ -149,6 +165,14 @@ func GetOptions() *Options {
 		Accountant: OptionsAccountant{
 			AccountantID:              config.GetString(config.FlagAccountantID),
 			AccountantEndpointAddress: config.GetString(config.FlagAccountantAddress),
+			AdditionalAccountants:     parseAccountantDefinitions(config.GetStringSlice(config.FlagAccountantAdditional)),
+		},
+		Storage: OptionsStorage{
+			Backend:                     config.GetString(config.FlagStorageBackend),
+			SessionHistoryRetention:     retentionDays(config.GetInt(config.FlagStorageRetentionSessionHistory)),
+			PaymentEvidenceRetention:    retentionDays(config.GetInt(config.FlagStorageRetentionPaymentEvidence)),
+			SettlementHistoryRetention:  retentionDays(config.GetInt(config.FlagStorageRetentionSettlementHistory)),
+			ConnectivityStatusRetention: retentionDays(config.GetInt(config.FlagStorageRetentionConnectivityStatus)),
 		},
 		Openvpn: wrapper{nodeOptions: openvpn_core.NodeOptions{
 			BinaryPath: config.GetString(config.FlagOpenvpnBinary),
@@ -156,6 +180,11 @@ func GetOptions() *Options {
 		Firewall: OptionsFirewall{
 			BlockAlways: config.GetBool(config.FlagFirewallKillSwitch),
 		},
+		DNS: OptionsDNS{
+			Cache:      config.GetBool(config.FlagDNSCache),
+			Prefetch:   config.GetBool(config.FlagDNSCachePrefetch),
+			LogQueries: config.GetBool(config.FlagDNSQueryLog),
+		},
 		P2PPorts: getP2PListenPorts(),
 	}
 }
@@ -197,6 +226,9 @@ func GetDiscoveryOptions() *OptionsDiscovery {
 // OptionsKeystore stores the keystore configuration
 type OptionsKeystore struct {
 	UseLightweight bool
+	// ExternalSigner is an address of external signing service, empty means keys from keystore are used.
+	ExternalSigner        string
+	ExternalSignerTimeout time.Duration
 }
 
 func getP2PListenPorts() *port.Range {
@@ -1,5 +1,5 @@
 /*
- * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
  *
  * This program is free software: you can redistribute it and/or modify
  * it under the terms of the GNU General Public License as published by
@@ -17,8 +17,48 @@
 
 package node
 
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
 // OptionsAccountant describes possible parameters for interaction with Accountant
 type OptionsAccountant struct {
 	AccountantEndpointAddress string
 	AccountantID              string
+	// AdditionalAccountants lists accountants the node works with besides the default one.
+	AdditionalAccountants []AccountantDefinition
+}
+
+// AccountantDefinition describes a single accountant.
+type AccountantDefinition struct {
+	ID              string
+	EndpointAddress string
+}
+
+// Accountants returns all accountants the node works with, the default accountant being the first one.
+func (oa OptionsAccountant) Accountants() []AccountantDefinition {
+	accountants := []AccountantDefinition{{ID: oa.AccountantID, EndpointAddress: oa.AccountantEndpointAddress}}
+	for _, accountant := range oa.AdditionalAccountants {
+		if strings.EqualFold(accountant.ID, oa.AccountantID) {
+			continue
+		}
+		accountants = append(accountants, accountant)
+	}
+	return accountants
+}
+
+func parseAccountantDefinitions(values []string) []AccountantDefinition {
+	accountants := make([]AccountantDefinition, 0, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || !common.IsHexAddress(parts[0]) || parts[1] == "" {
+			log.Warn().Msgf("Skipping malformed accountant definition %q, expected <accountant contract address>=<accountant URL address>", value)
+			continue
+		}
+		accountants = append(accountants, AccountantDefinition{ID: parts[0], EndpointAddress: parts[1]})
+	}
+	return accountants
 }
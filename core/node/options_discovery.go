@@ -27,6 +27,8 @@ const (
 	DiscoveryTypeAPI = DiscoveryType("api")
 	// DiscoveryTypeBroker defines type which discovers proposals through Broker (Mysterium Communication)
 	DiscoveryTypeBroker = DiscoveryType("broker")
+	// DiscoveryTypeLAN defines type which announces and discovers proposals on the local network via mDNS
+	DiscoveryTypeLAN = DiscoveryType("lan")
 )
 
 // OptionsDiscovery describes possible parameters of discovery configuration
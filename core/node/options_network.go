@@ -23,6 +23,8 @@ type OptionsNetwork struct {
 	Localnet bool
 
 	ExperimentNATPunching bool
+	P2POnly               bool
+	P2PDirectPort         int
 
 	MysteriumAPIAddress string
 	BrokerAddress       string
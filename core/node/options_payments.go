@@ -25,6 +25,9 @@ type OptionsPayments struct {
 	BCTimeout                          time.Duration
 	AccountantPromiseSettlingThreshold float64
 	SettlementTimeout                  time.Duration
+	SettlementSchedule                 string
+	SettlementMinFeeMultiplier         float64
+	SettlementMaxPerDay                int
 	MystSCAddress                      string
 	ConsumerUpperGBPriceBound          uint64
 	ConsumerLowerGBPriceBound          uint64
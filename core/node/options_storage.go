@@ -0,0 +1,40 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node
+
+import "time"
+
+// OptionsStorage describes the database of the node and how long it keeps records in it
+type OptionsStorage struct {
+	Backend                     string
+	SessionHistoryRetention     time.Duration
+	PaymentEvidenceRetention    time.Duration
+	SettlementHistoryRetention  time.Duration
+	ConnectivityStatusRetention time.Duration
+}
+
+const (
+	// StorageBackendBolt keeps the data in a BoltDB file
+	StorageBackendBolt = "bolt"
+	// StorageBackendSQLite keeps the data in an SQLite database
+	StorageBackendSQLite = "sqlite"
+)
+
+func retentionDays(days int) time.Duration {
+	return time.Duration(days) * 24 * time.Hour
+}
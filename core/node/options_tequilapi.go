@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/rs/zerolog/log"
+)
+
+// OptionsTequilapi describes TLS and unix socket transport of Tequilapi
+type OptionsTequilapi struct {
+	TLS bool
+	// AutoCert is set when no certificate is configured and a self-signed one should be generated
+	AutoCert    bool
+	TLSCert     string
+	TLSKey      string
+	TLSClientCA string
+
+	SocketPath string
+	SocketMode os.FileMode
+}
+
+// GetOptionsTequilapi retrieves Tequilapi transport options from the app configuration.
+func GetOptionsTequilapi(dataDir string) *OptionsTequilapi {
+	options := &OptionsTequilapi{
+		TLS:         config.GetBool(config.FlagTequilapiTLS),
+		TLSCert:     config.GetString(config.FlagTequilapiTLSCert),
+		TLSKey:      config.GetString(config.FlagTequilapiTLSKey),
+		TLSClientCA: config.GetString(config.FlagTequilapiTLSClientCA),
+		SocketPath:  config.GetString(config.FlagTequilapiSocket),
+		SocketMode:  0660,
+	}
+	if options.TLSCert == "" && options.TLSKey == "" {
+		options.AutoCert = true
+		options.TLSCert = filepath.Join(dataDir, "tequilapi.crt")
+		options.TLSKey = filepath.Join(dataDir, "tequilapi.key")
+	}
+
+	mode, err := strconv.ParseUint(config.GetString(config.FlagTequilapiSocketMode), 8, 32)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to parse Tequilapi socket mode, using default value")
+	} else {
+		options.SocketMode = os.FileMode(mode)
+	}
+	return options
+}
@@ -69,7 +69,8 @@ type Discovery interface {
 // WaitForNATHole blocks until NAT hole is punched towards consumer through local NAT or until hole punching failed
 type WaitForNATHole func() error
 
-// NewManager creates new instance of pluggable instances manager
+// NewManager creates new instance of pluggable instances manager.
+// Services are served over p2p only when the dialog factories are nil.
 func NewManager(
 	serviceRegistry *Registry,
 	dialogWaiterFactory DialogWaiterFactory,
@@ -80,6 +81,7 @@ func NewManager(
 	p2pListener p2p.Listener,
 	sessionManager func(proposal market.ServiceProposal, serviceID string, channel p2p.Channel) *session.Manager,
 	statusStorage connectivity.StatusStorage,
+	accountantIDs []string,
 ) *Manager {
 	return &Manager{
 		serviceRegistry:      serviceRegistry,
@@ -92,6 +94,7 @@ func NewManager(
 		p2pListener:          p2pListener,
 		sessionManager:       sessionManager,
 		statusStorage:        statusStorage,
+		accountantIDs:        accountantIDs,
 	}
 }
 
@@ -110,6 +113,7 @@ type Manager struct {
 	p2pListener    p2p.Listener
 	sessionManager func(proposal market.ServiceProposal, serviceID string, channel p2p.Channel) *session.Manager
 	statusStorage  connectivity.StatusStorage
+	accountantIDs  []string
 }
 
 // Start starts an instance of the given service type if knows one in service registry.
@@ -122,6 +126,7 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 	}
 
 	proposal.SetPaymentMethod(pm)
+	proposal.SetAccountantIDs(manager.accountantIDs)
 	proposal.SetAccessPolicies(nil)
 	policyRules := policy.NewRepository()
 	if len(policyIDs) > 0 {
@@ -133,22 +138,29 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 		proposal.SetAccessPolicies(&policies)
 	}
 
-	dialogWaiter, err := manager.dialogWaiterFactory(providerID, serviceType, policyRules)
-	if err != nil {
-		return id, err
+	var dialogWaiter communication.DialogWaiter
+	contacts := manager.p2pListener.GetContacts()
+	if manager.dialogWaiterFactory != nil {
+		dialogWaiter, err = manager.dialogWaiterFactory(providerID, serviceType, policyRules)
+		if err != nil {
+			return id, err
+		}
+		contacts = append(market.ContactList{dialogWaiter.GetContact()}, contacts...)
 	}
-	proposal.SetProviderContacts(providerID, market.ContactList{dialogWaiter.GetContact(), manager.p2pListener.GetContact()})
+	proposal.SetProviderContacts(providerID, contacts)
 
 	id, err = generateID()
 	if err != nil {
 		return id, err
 	}
-	dialogHandler, err := manager.dialogHandlerFactory(proposal, service, string(id))
-	if err != nil {
-		return id, err
-	}
-	if err = dialogWaiter.Start(dialogHandler); err != nil {
-		return id, err
+	if dialogWaiter != nil {
+		dialogHandler, err := manager.dialogHandlerFactory(proposal, service, string(id))
+		if err != nil {
+			return id, err
+		}
+		if err = dialogWaiter.Start(dialogHandler); err != nil {
+			return id, err
+		}
 	}
 
 	discovery := manager.discoveryFactory()
@@ -169,7 +181,7 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 	channelHandlers := func(ch p2p.Channel) {
 		instance.addP2PChannel(ch)
 		mng := manager.sessionManager(proposal, string(id), ch)
-		subscribeSessionCreate(mng, ch, service)
+		subscribeSessionCreate(mng, ch, service, policyRules)
 		subscribeSessionStatus(mng, ch, manager.statusStorage)
 		subscribeSessionAcknowledge(mng, ch)
 		subscribeSessionDestroy(mng, ch, func() {
@@ -178,7 +190,8 @@ func (manager *Manager) Start(providerID identity.Identity, serviceType string,
 			instance.closeP2PChannel(ch)
 		})
 	}
-	if err := manager.p2pListener.Listen(providerID, serviceType, channelHandlers); err != nil {
+	instance.p2pListenerStop, err = manager.p2pListener.Listen(providerID, serviceType, channelHandlers)
+	if err != nil {
 		return id, fmt.Errorf("could not subscribe to p2p channels: %w", err)
 	}
 
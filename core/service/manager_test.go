@@ -54,7 +54,7 @@ func TestManager_StartRemovesServiceFromPoolIfServiceCrashes(t *testing.T) {
 		discoveryFactory,
 		mocks.NewEventBus(),
 		mockPolicyOracle,
-		&mockP2PListener{}, nil, nil,
+		&mockP2PListener{}, nil, nil, nil,
 	)
 	_, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
 	assert.Nil(t, err)
@@ -80,7 +80,7 @@ func TestManager_StartDoesNotCrashIfStoppedByUser(t *testing.T) {
 		discoveryFactory,
 		mocks.NewEventBus(),
 		mockPolicyOracle,
-		&mockP2PListener{}, nil, nil,
+		&mockP2PListener{}, nil, nil, nil,
 	)
 	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
 	assert.Nil(t, err)
@@ -90,6 +90,34 @@ func TestManager_StartDoesNotCrashIfStoppedByUser(t *testing.T) {
 	assert.Len(t, manager.servicePool.List(), 0)
 }
 
+func TestManager_StartWithoutDialogAnnouncesP2PContactOnly(t *testing.T) {
+	registry := NewRegistry()
+	mockCopy := *serviceMock
+	mockCopy.mockProcess = make(chan struct{})
+	registry.Register(serviceType, func(options Options) (Service, market.ServiceProposal, error) {
+		return &mockCopy, proposalMock, nil
+	})
+
+	discovery := mockDiscovery{}
+	discoveryFactory := MockDiscoveryFactoryFunc(&discovery)
+	manager := NewManager(
+		registry,
+		nil,
+		nil,
+		discoveryFactory,
+		mocks.NewEventBus(),
+		mockPolicyOracle,
+		&mockP2PListener{}, nil, nil, nil,
+	)
+	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, mockP2PListener{}.GetContacts(), manager.Service(id).Proposal().ProviderContacts)
+
+	err = manager.Stop(id)
+	assert.NoError(t, err)
+	discovery.Wait()
+}
+
 func TestManager_StopSendsEvent_SucceedsAndPublishesEvent(t *testing.T) {
 	registry := NewRegistry()
 	mockCopy := *serviceMock
@@ -108,7 +136,7 @@ func TestManager_StopSendsEvent_SucceedsAndPublishesEvent(t *testing.T) {
 		discoveryFactory,
 		eventBus,
 		mockPolicyOracle,
-		&mockP2PListener{}, nil, nil,
+		&mockP2PListener{}, nil, nil, nil,
 	)
 
 	id, err := manager.Start(identity.FromAddress(proposalMock.ProviderID), serviceType, nil, struct{}{}, nil)
@@ -149,10 +177,14 @@ func TestManager_StopSendsEvent_SucceedsAndPublishesEvent(t *testing.T) {
 type mockP2PListener struct {
 }
 
-func (m mockP2PListener) GetContact() market.Contact {
-	return market.Contact{}
+func (m mockP2PListener) GetContacts() market.ContactList {
+	return market.ContactList{{}}
+}
+
+func (m mockP2PListener) Listen(providerID identity.Identity, serviceType string, channelHandler func(ch p2p.Channel)) (func(), error) {
+	return func() {}, nil
 }
 
-func (m mockP2PListener) Listen(providerID identity.Identity, serviceType string, channelHandler func(ch p2p.Channel)) error {
+func (m mockP2PListener) Close() error {
 	return nil
 }
@@ -157,6 +157,7 @@ type Instance struct {
 	eventPublisher  Publisher
 	p2pChannelsLock sync.Mutex
 	p2pChannels     []p2p.Channel
+	p2pListenerStop func()
 }
 
 // Options returns options used to start service
@@ -220,6 +221,9 @@ func (i *Instance) stop() error {
 	if i.dialogWaiter != nil {
 		errStop.Add(i.dialogWaiter.Stop())
 	}
+	if i.p2pListenerStop != nil {
+		i.p2pListenerStop()
+	}
 	if i.service != nil {
 		errStop.Add(i.service.Stop())
 	}
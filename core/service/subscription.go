@@ -19,9 +19,11 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/mysteriumnetwork/node/core/policy"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/p2p"
 	"github.com/mysteriumnetwork/node/pb"
@@ -30,7 +32,7 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func subscribeSessionCreate(mng *session.Manager, ch p2p.Channel, service Service) {
+func subscribeSessionCreate(mng *session.Manager, ch p2p.Channel, service Service, policies *policy.Repository) {
 	ch.Handle(p2p.TopicSessionCreate, func(c p2p.Context) error {
 		var sr pb.SessionRequest
 		if err := c.Request().UnmarshalProto(&sr); err != nil {
@@ -39,6 +41,10 @@ func subscribeSessionCreate(mng *session.Manager, ch p2p.Channel, service Servic
 		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicSessionCreate, sr.String())
 
 		consumerID := identity.FromAddress(sr.GetConsumer().GetId())
+		if !policies.IsIdentityAllowed(consumerID) {
+			return c.Error(fmt.Errorf("consumer %s is not allowed by access policies", consumerID.Address))
+		}
+
 		consumerConfig := sr.GetConfig()
 		consumerInfo := session.ConsumerInfo{
 			IssuerID:       consumerID,
@@ -47,35 +53,38 @@ func subscribeSessionCreate(mng *session.Manager, ch p2p.Channel, service Servic
 		}
 
 		paymentVersion := string(session.PaymentVersionV3)
-		session, err := session.NewSession()
+		sess, err := session.NewSession()
 		if err != nil {
 			return fmt.Errorf("cannot create new session: %w", err)
 		}
 
-		config, err := service.ProvideConfig(string(session.ID), consumerConfig, ch.ServiceConn())
+		config, err := service.ProvideConfig(string(sess.ID), consumerConfig, ch.ServiceConn())
 		if err != nil {
-			return fmt.Errorf("cannot get provider config for session %s: %w", string(session.ID), err)
+			return fmt.Errorf("cannot get provider config for session %s: %w", string(sess.ID), err)
 		}
 
-		err = mng.Start(session, consumerID, consumerInfo, int(sr.GetProposalID()), config, nil)
+		err = mng.Start(sess, consumerID, consumerInfo, int(sr.GetProposalID()), config, nil)
+		if errors.Is(err, session.ErrorInvalidProposal) {
+			return c.Error(err)
+		}
 		if err != nil {
-			return fmt.Errorf("cannot start session %s: %w", string(session.ID), err)
+			return fmt.Errorf("cannot start session %s: %w", string(sess.ID), err)
 		}
 
 		if config.SessionDestroyCallback != nil {
 			go func() {
-				<-session.Done()
+				<-sess.Done()
 				config.SessionDestroyCallback()
 			}()
 		}
 
 		data, err := json.Marshal(config.SessionServiceConfig)
 		if err != nil {
-			return fmt.Errorf("cannot pack session %s service config: %w", string(session.ID), err)
+			return fmt.Errorf("cannot pack session %s service config: %w", string(sess.ID), err)
 		}
 
 		pc := p2p.ProtoMessage(&pb.SessionResponse{
-			ID:          string(session.ID),
+			ID:          string(sess.ID),
 			PaymentInfo: paymentVersion,
 			Config:      data,
 		})
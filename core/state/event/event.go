@@ -28,6 +28,7 @@ import (
 	"github.com/mysteriumnetwork/node/identity/registry"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
+	pingpongEvent "github.com/mysteriumnetwork/node/session/pingpong/event"
 	"github.com/mysteriumnetwork/payments/crypto"
 )
 
@@ -51,6 +52,8 @@ type Identity struct {
 	Balance            uint64
 	Earnings           uint64
 	EarningsTotal      uint64
+	// EarningsPerAccountant holds earnings of the identity with every accountant, Earnings and EarningsTotal are their sums.
+	EarningsPerAccountant map[common.Address]pingpongEvent.Earnings
 }
 
 // Connection represents consumer connection state.
@@ -73,6 +73,7 @@ type balanceProvider interface {
 
 type earningsProvider interface {
 	GetEarnings(id identity.Identity) pingpongEvent.Earnings
+	GetEarningsByAccountant(id identity.Identity) map[common.Address]pingpongEvent.Earnings
 }
 
 // Keeper keeps track of state through eventual consistency.
@@ -157,12 +158,13 @@ func (k *Keeper) fetchIdentities() []stateEvent.Identity {
 
 		earnings := k.deps.EarningsProvider.GetEarnings(id)
 		stateIdentity := event.Identity{
-			Address:            id.Address,
-			RegistrationStatus: status,
-			ChannelAddress:     channelAddress,
-			Balance:            k.deps.BalanceProvider.GetBalance(id),
-			Earnings:           earnings.UnsettledBalance,
-			EarningsTotal:      earnings.LifetimeBalance,
+			Address:               id.Address,
+			RegistrationStatus:    status,
+			ChannelAddress:        channelAddress,
+			Balance:               k.deps.BalanceProvider.GetBalance(id),
+			Earnings:              earnings.UnsettledBalance,
+			EarningsTotal:         earnings.LifetimeBalance,
+			EarningsPerAccountant: k.deps.EarningsProvider.GetEarningsByAccountant(id),
 		}
 		identities[idx] = stateIdentity
 	}
@@ -446,8 +448,20 @@ func (k *Keeper) consumeEarningsChangedEvent(e interface{}) {
 		log.Warn().Msgf("Couldn't find a matching identity for earnings change: %s", evt.Identity.Address)
 		return
 	}
-	id.Earnings = evt.Current.UnsettledBalance
-	id.EarningsTotal = evt.Current.LifetimeBalance
+
+	// A new map is built, as the previous one may still be referenced by already announced states.
+	earningsPerAccountant := make(map[common.Address]pingpongEvent.Earnings, len(id.EarningsPerAccountant)+1)
+	for accountantID, earnings := range id.EarningsPerAccountant {
+		earningsPerAccountant[accountantID] = earnings
+	}
+	earningsPerAccountant[evt.AccountantID] = evt.Current
+
+	id.EarningsPerAccountant = earningsPerAccountant
+	id.Earnings, id.EarningsTotal = 0, 0
+	for _, earnings := range earningsPerAccountant {
+		id.Earnings += earnings.UnsettledBalance
+		id.EarningsTotal += earnings.LifetimeBalance
+	}
 	go k.announceStateChanges(nil)
 }
 
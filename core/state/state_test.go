@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/mysteriumnetwork/node/core/service/servicestate"
@@ -452,15 +453,33 @@ func Test_ConsumesEarningsChangeEvent(t *testing.T) {
 
 	// when
 	eventBus.Publish(pingpongEvent.AppTopicEarningsChanged, pingpongEvent.AppEventEarningsChanged{
-		Identity: identity.Identity{Address: "0x000000000000000000000000000000000000000a"},
-		Previous: pingpongEvent.Earnings{},
-		Current:  pingpongEvent.Earnings{LifetimeBalance: 100, UnsettledBalance: 10},
+		Identity:     identity.Identity{Address: "0x000000000000000000000000000000000000000a"},
+		AccountantID: common.HexToAddress("0x1"),
+		Previous:     pingpongEvent.Earnings{},
+		Current:      pingpongEvent.Earnings{LifetimeBalance: 100, UnsettledBalance: 10},
 	})
 
 	// then
 	assert.Eventually(t, func() bool {
 		return keeper.GetState().Identities[0].Earnings == 10 && keeper.GetState().Identities[0].EarningsTotal == 100
 	}, 2*time.Second, 10*time.Millisecond)
+
+	// when
+	eventBus.Publish(pingpongEvent.AppTopicEarningsChanged, pingpongEvent.AppEventEarningsChanged{
+		Identity:     identity.Identity{Address: "0x000000000000000000000000000000000000000a"},
+		AccountantID: common.HexToAddress("0x2"),
+		Previous:     pingpongEvent.Earnings{},
+		Current:      pingpongEvent.Earnings{LifetimeBalance: 50, UnsettledBalance: 5},
+	})
+
+	// then
+	assert.Eventually(t, func() bool {
+		return keeper.GetState().Identities[0].Earnings == 15 && keeper.GetState().Identities[0].EarningsTotal == 150
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, map[common.Address]pingpongEvent.Earnings{
+		common.HexToAddress("0x1"): {LifetimeBalance: 100, UnsettledBalance: 10},
+		common.HexToAddress("0x2"): {LifetimeBalance: 50, UnsettledBalance: 5},
+	}, keeper.GetState().Identities[0].EarningsPerAccountant)
 }
 
 func Test_ConsumesIdentityRegistrationEvent(t *testing.T) {
@@ -632,10 +651,16 @@ func (mbp *mockBalanceProvider) GetBalance(_ identity.Identity) uint64 {
 }
 
 type mockEarningsProvider struct {
-	Earnings pingpongEvent.Earnings
+	Earnings             pingpongEvent.Earnings
+	EarningsByAccountant map[common.Address]pingpongEvent.Earnings
 }
 
 // GetEarnings returns a pre-defined settlement state.
 func (mep *mockEarningsProvider) GetEarnings(_ identity.Identity) pingpongEvent.Earnings {
 	return mep.Earnings
 }
+
+// GetEarningsByAccountant returns a pre-defined settlement state keyed by accountant.
+func (mep *mockEarningsProvider) GetEarningsByAccountant(_ identity.Identity) map[common.Address]pingpongEvent.Earnings {
+	return mep.EarningsByAccountant
+}
@@ -0,0 +1,320 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package boltdb
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/migrations"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	dbFileName = "myst.db"
+	// stormMetaBucketName is the bucket storm keeps its codec and version in.
+	stormMetaBucketName = "__storm_db"
+	// stormInternalPrefix prefixes buckets storm keeps its metadata and indexes in.
+	stormInternalPrefix = "__storm_"
+	// lockTimeout limits how long maintenance waits for the database file lock held by a running node.
+	lockTimeout = time.Second
+	// restoredSuffix and restoredTimeFormat name the database files replaced by Restore, so earlier ones are not overwritten.
+	restoredSuffix     = ".before-restore-"
+	restoredTimeFormat = "20060102T150405.000000000"
+)
+
+// ErrDatabaseLocked is returned when the database is opened by a running node.
+var ErrDatabaseLocked = errors.New("database is used by a running node")
+
+// Backup writes a consistent snapshot of the open database to the given writer.
+func (b *Bolt) Backup(w io.Writer) (int64, error) {
+	var written int64
+	err := b.db.Bolt.View(func(tx *bolt.Tx) error {
+		var err error
+		written, err = tx.WriteTo(w)
+		return err
+	})
+	return written, errors.Wrap(err, "could not write database snapshot")
+}
+
+// DatabaseFile returns the database file path in the given storage directory.
+func DatabaseFile(path string) string {
+	return filepath.Join(path, dbFileName)
+}
+
+func openForMaintenance(file string, readOnly bool) (*bolt.DB, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, errors.Wrap(err, "could not find database")
+	}
+
+	db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: lockTimeout, ReadOnly: readOnly})
+	if err == bolt.ErrTimeout {
+		return nil, ErrDatabaseLocked
+	}
+	return db, errors.Wrapf(err, "could not open database %s", file)
+}
+
+// BackupFile writes a snapshot of the database in the given storage directory to the destination file.
+// The node using the database has to be stopped, use Backup for the open database.
+func BackupFile(path, destination string) error {
+	db, err := openForMaintenance(DatabaseFile(path), true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return writeFileAtomically(destination, func(w io.Writer) error {
+		return db.View(func(tx *bolt.Tx) error {
+			_, err := tx.WriteTo(w)
+			return err
+		})
+	})
+}
+
+// Restore replaces the database in the given storage directory with the backup and runs pending migrations on it.
+// The replaced database is kept next to it with the ".before-restore-<timestamp>" suffix
+// and is put back in place if the restore fails.
+func Restore(path, backupFile string, sequence []migrations.Migration) (err error) {
+	backup, err := openForMaintenance(backupFile, true)
+	if err != nil {
+		return errors.Wrap(err, "invalid backup")
+	}
+	backup.Close()
+
+	file := DatabaseFile(path)
+	var saved string
+	if _, err := os.Stat(file); err == nil {
+		current, err := openForMaintenance(file, true)
+		if err == ErrDatabaseLocked {
+			return err
+		}
+		if err == nil {
+			current.Close()
+		}
+		saved = file + restoredSuffix + time.Now().UTC().Format(restoredTimeFormat)
+		if err := os.Rename(file, saved); err != nil {
+			return errors.Wrap(err, "could not keep current database")
+		}
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if saved == "" {
+			os.Remove(file)
+			return
+		}
+		if rollbackErr := os.Rename(saved, file); rollbackErr != nil {
+			err = errors.Wrapf(err, "could not put back replaced database %s: %v", saved, rollbackErr)
+		}
+	}()
+
+	err = writeFileAtomically(file, func(w io.Writer) error {
+		src, err := os.Open(backupFile)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		_, err = io.Copy(w, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	storage, err := NewStorage(path)
+	if err != nil {
+		return err
+	}
+	// the storage holds the file lock, it has to be released before the replaced database can be put back
+	err = NewMigrator(storage).RunMigrations(sequence)
+	if closeErr := storage.Close(); err == nil {
+		err = closeErr
+	}
+	return errors.Wrap(err, "could not migrate restored database")
+}
+
+// Compact rewrites the database in the given storage directory, releasing the space left by deleted records.
+// The node using the database has to be stopped.
+func Compact(path string) (storage.CompactResult, error) {
+	file := DatabaseFile(path)
+	src, err := openForMaintenance(file, false)
+	if err != nil {
+		return storage.CompactResult{}, err
+	}
+
+	before, err := fileSize(file)
+	if err != nil {
+		src.Close()
+		return storage.CompactResult{}, err
+	}
+
+	compacted := file + ".compact"
+	dst, err := bolt.Open(compacted, 0600, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		src.Close()
+		return storage.CompactResult{}, errors.Wrap(err, "could not create compacted database")
+	}
+
+	err = src.View(func(srcTx *bolt.Tx) error {
+		return dst.Update(func(dstTx *bolt.Tx) error {
+			return srcTx.ForEach(func(name []byte, srcBucket *bolt.Bucket) error {
+				dstBucket, err := dstTx.CreateBucket(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(dstBucket, srcBucket)
+			})
+		})
+	})
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := src.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(compacted)
+		return storage.CompactResult{}, errors.Wrap(err, "could not compact database")
+	}
+
+	if err := os.Rename(compacted, file); err != nil {
+		return storage.CompactResult{}, errors.Wrap(err, "could not replace database")
+	}
+
+	after, err := fileSize(file)
+	return storage.CompactResult{SizeBefore: before, SizeAfter: after}, err
+}
+
+func copyBucket(dst, src *bolt.Bucket) error {
+	if err := dst.SetSequence(src.Sequence()); err != nil {
+		return err
+	}
+
+	return src.ForEach(func(key, value []byte) error {
+		if value != nil {
+			return dst.Put(key, value)
+		}
+
+		child, err := dst.CreateBucket(key)
+		if err != nil {
+			return err
+		}
+		return copyBucket(child, src.Bucket(key))
+	})
+}
+
+// Inspect lists top level buckets of the database in the given storage directory.
+func Inspect(path string) ([]storage.BucketStats, error) {
+	db, err := openForMaintenance(DatabaseFile(path), true)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var result []storage.BucketStats
+	err = db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			stats := bucket.Stats()
+			result = append(result, storage.BucketStats{
+				Name:  string(name),
+				Keys:  stats.KeyN,
+				Bytes: stats.LeafInuse + stats.BranchInuse,
+			})
+			return nil
+		})
+	})
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, errors.Wrap(err, "could not inspect database")
+}
+
+// Walk visits every record and key value of the database in the given storage directory.
+// Kind is the type name of the record, it is empty for key values. Storm and migrator internals are skipped.
+// The node using the database has to be stopped.
+func Walk(path string, visit func(bucket, kind string, key, value []byte) error) error {
+	db, err := openForMaintenance(DatabaseFile(path), true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			if string(name) == stormMetaBucketName || string(name) == migrationIndexBucketName {
+				return nil
+			}
+
+			return bucket.ForEach(func(key, value []byte) error {
+				if value != nil {
+					return visit(string(name), "", key, value)
+				}
+				if strings.HasPrefix(string(key), stormInternalPrefix) {
+					return nil
+				}
+
+				return bucket.Bucket(key).ForEach(func(id, record []byte) error {
+					// nested buckets hold storm indexes and metadata of the kind
+					if record == nil {
+						return nil
+					}
+					return visit(string(name), string(key), id, record)
+				})
+			})
+		})
+	})
+}
+
+func writeFileAtomically(file string, write func(w io.Writer) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "could not create file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "could not write file")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "could not write file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "could not write file")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), file), "could not write file")
+}
+
+func fileSize(file string) (int64, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not stat database")
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package boltdb
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asdine/storm/v3"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+type datedTestType struct {
+	ID      int64 `storm:"id,increment"`
+	Created time.Time
+}
+
+func Test_BackupAndRestore(t *testing.T) {
+	dir := boltdbtest.CreateTempDir(t)
+	defer boltdbtest.RemoveTempDir(t, dir)
+
+	db, err := NewStorage(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Store(bucket, &myTestType{ID: 1}))
+
+	// online backup while the database is open
+	var snapshot bytes.Buffer
+	written, err := db.Backup(&snapshot)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(snapshot.Len()), written)
+
+	// offline backup is refused while the database is open
+	backupFile := filepath.Join(dir, "backup.db")
+	assert.Equal(t, ErrDatabaseLocked, BackupFile(dir, backupFile))
+
+	assert.NoError(t, db.Store(bucket, &myTestType{ID: 2}))
+	assert.NoError(t, db.Close())
+
+	assert.NoError(t, ioutil.WriteFile(backupFile, snapshot.Bytes(), 0600))
+	migrated := false
+	sequence := []migrations.Migration{{
+		Name: "test-migration",
+		Date: time.Now(),
+		Migrate: func(*storm.DB) error {
+			migrated = true
+			return nil
+		},
+	}}
+	assert.NoError(t, Restore(dir, backupFile, sequence))
+	assert.True(t, migrated)
+	saved, err := filepath.Glob(DatabaseFile(dir) + restoredSuffix + "*")
+	assert.NoError(t, err)
+	assert.Len(t, saved, 1)
+
+	db, err = NewStorage(dir)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var records []myTestType
+	assert.NoError(t, db.GetAllFrom(bucket, &records))
+	assert.Equal(t, []myTestType{{ID: 1}}, records)
+}
+
+func Test_Restore_RollsBackOnFailedMigration(t *testing.T) {
+	dir := boltdbtest.CreateTempDir(t)
+	defer boltdbtest.RemoveTempDir(t, dir)
+
+	db, err := NewStorage(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Store(bucket, &myTestType{ID: 1}))
+	var snapshot bytes.Buffer
+	_, err = db.Backup(&snapshot)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Store(bucket, &myTestType{ID: 2}))
+	assert.NoError(t, db.Close())
+
+	backupFile := filepath.Join(dir, "backup.db")
+	assert.NoError(t, ioutil.WriteFile(backupFile, snapshot.Bytes(), 0600))
+	sequence := []migrations.Migration{{
+		Name: "failing-migration",
+		Date: time.Now(),
+		Migrate: func(*storm.DB) error {
+			return errors.New("migration failed")
+		},
+	}}
+	assert.Error(t, Restore(dir, backupFile, sequence))
+
+	saved, err := filepath.Glob(DatabaseFile(dir) + restoredSuffix + "*")
+	assert.NoError(t, err)
+	assert.Empty(t, saved)
+
+	db, err = NewStorage(dir)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var records []myTestType
+	assert.NoError(t, db.GetAllFrom(bucket, &records))
+	assert.Equal(t, []myTestType{{ID: 1}, {ID: 2}}, records)
+}
+
+func Test_Restore_RejectsInvalidBackup(t *testing.T) {
+	dir := boltdbtest.CreateTempDir(t)
+	defer boltdbtest.RemoveTempDir(t, dir)
+
+	backupFile := filepath.Join(dir, "backup.db")
+	assert.NoError(t, ioutil.WriteFile(backupFile, []byte("not a database"), 0600))
+
+	assert.Error(t, Restore(dir, backupFile, nil))
+	_, err := os.Stat(DatabaseFile(dir))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_CompactAndInspect(t *testing.T) {
+	dir := boltdbtest.CreateTempDir(t)
+	defer boltdbtest.RemoveTempDir(t, dir)
+
+	db, err := NewStorage(dir)
+	assert.NoError(t, err)
+	for i := 0; i < 500; i++ {
+		assert.NoError(t, db.Store(bucket, &datedTestType{Created: time.Now()}))
+	}
+	_, err = storage.ApplyRetention(db, []storage.RetentionPolicy{{Bucket: bucket, Kind: &datedTestType{}, Field: "Created", MaxAge: time.Nanosecond}}, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, db.Store(bucket, &datedTestType{Created: time.Now()}))
+
+	_, err = Compact(dir)
+	assert.Equal(t, ErrDatabaseLocked, err)
+	assert.NoError(t, db.Close())
+
+	result, err := Compact(dir)
+	assert.NoError(t, err)
+	assert.True(t, result.SizeAfter < result.SizeBefore, "%+v", result)
+
+	buckets, err := Inspect(dir)
+	assert.NoError(t, err)
+	var names []string
+	for _, stats := range buckets {
+		names = append(names, stats.Name)
+	}
+	assert.Contains(t, names, bucket)
+
+	// sequences survive compaction, so new records do not reuse ids
+	db, err = NewStorage(dir)
+	assert.NoError(t, err)
+	defer db.Close()
+	record := datedTestType{Created: time.Now()}
+	assert.NoError(t, db.Store(bucket, &record))
+	assert.Equal(t, int64(502), record.ID)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package boltdb
+
+import (
+	"github.com/asdine/storm/v3"
+	"github.com/asdine/storm/v3/q"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/pkg/errors"
+)
+
+// Find loads records matching the query into the slice pointed by to.
+func (b *Bolt) Find(bucket string, query storage.Query, to interface{}) error {
+	err := b.selectQuery(bucket, query).Find(to)
+	if err == storm.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// Count returns how many records of the given kind match the query.
+func (b *Bolt) Count(bucket string, query storage.Query, kind interface{}) (int, error) {
+	count, err := b.selectQuery(bucket, query).Count(kind)
+	if err == storm.ErrNotFound {
+		return 0, nil
+	}
+	return count, err
+}
+
+// DeleteAll removes records of the given kind matching the query and returns how many were removed.
+func (b *Bolt) DeleteAll(bucket string, query storage.Query, kind interface{}) (int, error) {
+	count, err := b.Count(bucket, query, kind)
+	if err != nil || count == 0 {
+		return 0, errors.Wrapf(err, "could not count records of %s", bucket)
+	}
+
+	err = b.selectQuery(bucket, query).Delete(kind)
+	if err != nil && err != storm.ErrNotFound {
+		return 0, errors.Wrapf(err, "could not delete records of %s", bucket)
+	}
+	return count, nil
+}
+
+func (b *Bolt) selectQuery(bucket string, query storage.Query) storm.Query {
+	matchers := make([]q.Matcher, 0, len(query.Filters))
+	for _, filter := range query.Filters {
+		matchers = append(matchers, filterMatcher(filter))
+	}
+
+	selected := b.db.From(bucket).Select(matchers...)
+	if query.OrderBy != "" {
+		selected = selected.OrderBy(query.OrderBy)
+	}
+	if query.Reverse {
+		selected = selected.Reverse()
+	}
+	if query.Skip > 0 {
+		selected = selected.Skip(query.Skip)
+	}
+	if query.Limit > 0 {
+		selected = selected.Limit(query.Limit)
+	}
+	return selected
+}
+
+func filterMatcher(filter storage.Filter) q.Matcher {
+	switch filter.Op {
+	case storage.OpLt:
+		return q.Lt(filter.Field, filter.Value)
+	case storage.OpLte:
+		return q.Lte(filter.Field, filter.Value)
+	case storage.OpGt:
+		return q.Gt(filter.Field, filter.Value)
+	case storage.OpGte:
+		return q.Gte(filter.Field, filter.Value)
+	default:
+		return q.Eq(filter.Field, filter.Value)
+	}
+}
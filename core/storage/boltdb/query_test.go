@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package boltdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StorageFind(t *testing.T) {
+	db, close, err := createMockStorage(t)
+	assert.Nil(t, err)
+	defer close()
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, db.Store(bucket, &datedTestType{Created: now.Add(time.Duration(-i) * time.Hour)}))
+	}
+
+	var records []datedTestType
+	query := storage.Query{OrderBy: "Created", Reverse: true, Skip: 1, Limit: 2}.Between("Created", now.Add(-4*time.Hour), time.Time{})
+	assert.NoError(t, db.Find(bucket, query, &records))
+	assert.Len(t, records, 2)
+	assert.Equal(t, int64(2), records[0].ID)
+	assert.Equal(t, int64(3), records[1].ID)
+
+	count, err := db.Count(bucket, storage.Query{}.Where("Created", storage.OpLt, now.Add(-90*time.Minute)), &datedTestType{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	records = nil
+	assert.NoError(t, db.Find("empty", storage.Query{}, &records))
+	assert.Len(t, records, 0)
+}
+
+func Test_ApplyRetention(t *testing.T) {
+	db, close, err := createMockStorage(t)
+	assert.Nil(t, err)
+	defer close()
+
+	now := time.Now()
+	old := datedTestType{Created: now.Add(-48 * time.Hour)}
+	assert.NoError(t, db.Store(bucket, &old))
+	recent := datedTestType{Created: now.Add(-time.Hour)}
+	assert.NoError(t, db.Store(bucket, &recent))
+
+	policies := []storage.RetentionPolicy{
+		{Bucket: bucket, Kind: &datedTestType{}, Field: "Created", MaxAge: 24 * time.Hour},
+		{Bucket: "empty", Kind: &datedTestType{}, Field: "Created", MaxAge: 24 * time.Hour},
+		{Bucket: bucket, Kind: &datedTestType{}, Field: "Created"},
+	}
+	dropped, err := storage.ApplyRetention(db, policies, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+
+	var records []datedTestType
+	assert.NoError(t, db.GetAllFrom(bucket, &records))
+	assert.Len(t, records, 1)
+	assert.Equal(t, recent.ID, records[0].ID)
+
+	dropped, err = storage.ApplyRetention(db, policies, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, dropped)
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RetentionCheckInterval is how often RetentionJanitor applies retention policies.
+const RetentionCheckInterval = 6 * time.Hour
+
+// RetentionJanitor periodically applies retention policies to the database.
+type RetentionJanitor struct {
+	db       Storage
+	policies []RetentionPolicy
+	interval time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRetentionJanitor creates a new instance of retention janitor.
+func NewRetentionJanitor(db Storage, policies []RetentionPolicy, interval time.Duration) *RetentionJanitor {
+	return &RetentionJanitor{
+		db:       db,
+		policies: policies,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start applies retention policies right away and keeps applying them in the background until stopped.
+func (rj *RetentionJanitor) Start() {
+	rj.apply()
+	go func() {
+		for {
+			select {
+			case <-rj.stop:
+				return
+			case <-time.After(rj.interval):
+				rj.apply()
+			}
+		}
+	}()
+}
+
+// Stop stops the janitor.
+func (rj *RetentionJanitor) Stop() {
+	rj.stopOnce.Do(func() {
+		close(rj.stop)
+	})
+}
+
+func (rj *RetentionJanitor) apply() {
+	dropped, err := ApplyRetention(rj.db, rj.policies, time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to apply storage retention")
+	}
+	if dropped > 0 {
+		log.Info().Msgf("Dropped %d expired storage records", dropped)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package storage
+
+import "time"
+
+// Operator is a comparison applied by a query filter.
+type Operator string
+
+const (
+	// OpEq matches records with the field equal to the value.
+	OpEq = Operator("=")
+	// OpLt matches records with the field less than the value.
+	OpLt = Operator("<")
+	// OpLte matches records with the field less than or equal to the value.
+	OpLte = Operator("<=")
+	// OpGt matches records with the field greater than the value.
+	OpGt = Operator(">")
+	// OpGte matches records with the field greater than or equal to the value.
+	OpGte = Operator(">=")
+)
+
+// Filter compares a top level struct field against the value.
+type Filter struct {
+	Field string
+	Op    Operator
+	Value interface{}
+}
+
+// Query narrows, orders and pages records of a bucket.
+type Query struct {
+	Filters []Filter
+	// OrderBy is the field records are ordered by, records are ordered by id when empty.
+	OrderBy string
+	Reverse bool
+	Skip    int
+	// Limit is the maximum number of records returned, zero means no limit.
+	Limit int
+}
+
+// Where appends a filter to the query.
+func (q Query) Where(field string, op Operator, value interface{}) Query {
+	q.Filters = append(append([]Filter(nil), q.Filters...), Filter{Field: field, Op: op, Value: value})
+	return q
+}
+
+// Between narrows the query to records with the time field within [from, to).
+// Zero bounds are left open.
+func (q Query) Between(field string, from, to time.Time) Query {
+	if !from.IsZero() {
+		q = q.Where(field, OpGte, from)
+	}
+	if !to.IsZero() {
+		q = q.Where(field, OpLt, to)
+	}
+	return q
+}
@@ -0,0 +1,31 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package storage
+
+import "time"
+
+// RetentionPolicy describes how long records of a bucket are kept.
+type RetentionPolicy struct {
+	Bucket string
+	// Kind is a pointer to the struct the records are stored as.
+	Kind interface{}
+	// Field is the time field the age of the record is measured by.
+	Field string
+	// MaxAge is the age after which records are dropped, zero keeps them forever.
+	MaxAge time.Duration
+}
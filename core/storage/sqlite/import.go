@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/pkg/errors"
+)
+
+// ImportResult describes the data imported from the bolt database.
+type ImportResult struct {
+	Records int
+	Values  int
+}
+
+// ImportBolt copies the bolt database of the given storage directory to a new SQLite database in the same directory.
+// Records of every bucket have to be described by kinds, the import fails on unknown records
+// and leaves no SQLite database behind. The node using the database has to be stopped.
+func ImportBolt(path string, kinds []storage.Kind) (ImportResult, error) {
+	file := DatabaseFile(path)
+	if _, err := os.Stat(file); err == nil {
+		return ImportResult{}, errors.Errorf("SQLite database %s already exists", file)
+	}
+
+	tmp := file + ".import"
+	for _, name := range []string{tmp, tmp + "-wal", tmp + "-shm"} {
+		os.Remove(name)
+	}
+	db, err := openDB(tmp)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	result, err := importBolt(path, db, kinds)
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		for _, name := range []string{tmp, tmp + "-wal", tmp + "-shm"} {
+			os.Remove(name)
+		}
+		return ImportResult{}, err
+	}
+
+	return result, errors.Wrap(os.Rename(tmp, file), "could not create SQLite database")
+}
+
+func importBolt(path string, db *SQLite, kinds []storage.Kind) (ImportResult, error) {
+	types := make(map[string]reflect.Type)
+	for _, kind := range kinds {
+		t := reflect.TypeOf(kind.Record).Elem()
+		types[tableName(kind.Bucket, t)] = t
+	}
+
+	var result ImportResult
+	err := boltdb.Walk(path, func(bucket, kind string, key, value []byte) error {
+		if kind == "" {
+			_, err := db.db.Exec("INSERT OR REPLACE INTO key_values (bucket, key, value) VALUES (?, ?, ?)", bucket, key, value)
+			if err != nil {
+				return errors.Wrapf(err, "could not import value of %s", bucket)
+			}
+			result.Values++
+			return nil
+		}
+
+		t, ok := types[bucket+"/"+kind]
+		if !ok {
+			return errors.Errorf("unknown record kind %s in bucket %s", kind, bucket)
+		}
+		record := reflect.New(t).Interface()
+		if err := json.Unmarshal(value, record); err != nil {
+			return errors.Wrapf(err, "could not decode %s record of %s", kind, bucket)
+		}
+		if err := db.Store(bucket, record); err != nil {
+			return errors.Wrapf(err, "could not import %s record of %s", kind, bucket)
+		}
+		result.Records++
+		return nil
+	})
+	return result, err
+}
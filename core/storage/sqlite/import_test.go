@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ImportBolt(t *testing.T) {
+	dir := boltdbtest.CreateTempDir(t)
+	defer boltdbtest.RemoveTempDir(t, dir)
+
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	created := time.Now().UTC()
+	assert.NoError(t, bolt.Store(bucket, &testRecord{Status: "first", Created: created}))
+	assert.NoError(t, bolt.Store(bucket, &testRecord{Status: "second"}))
+	assert.NoError(t, bolt.Store("named", &namedRecord{Identity: identity.FromAddress("0x1"), Note: "note"}))
+	assert.NoError(t, bolt.SetValue("values", "key", "value"))
+	assert.NoError(t, bolt.Close())
+
+	_, err = ImportBolt(dir, []storage.Kind{{Bucket: bucket, Record: &testRecord{}}})
+	assert.EqualError(t, err, "unknown record kind namedRecord in bucket named")
+	_, err = os.Stat(DatabaseFile(dir))
+	assert.True(t, os.IsNotExist(err))
+
+	kinds := []storage.Kind{{Bucket: bucket, Record: &testRecord{}}, {Bucket: "named", Record: &namedRecord{}}}
+	result, err := ImportBolt(dir, kinds)
+	assert.NoError(t, err)
+	assert.Equal(t, ImportResult{Records: 3, Values: 1}, result)
+
+	_, err = ImportBolt(dir, kinds)
+	assert.Error(t, err, "imports only once")
+
+	db, err := NewStorage(dir)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var records []testRecord
+	assert.NoError(t, db.GetAllFrom(bucket, &records))
+	assert.Len(t, records, 2)
+	assert.Equal(t, "first", records[0].Status)
+	assert.True(t, created.Equal(records[0].Created))
+
+	next := testRecord{Status: "third"}
+	assert.NoError(t, db.Store(bucket, &next))
+	assert.Equal(t, int64(3), next.ID)
+
+	var named namedRecord
+	assert.NoError(t, db.GetOneByField("named", "Identity", identity.FromAddress("0x1"), &named))
+	assert.Equal(t, "note", named.Note)
+
+	var value string
+	assert.NoError(t, db.GetValue("values", "key", &value))
+	assert.Equal(t, "value", value)
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/pkg/errors"
+)
+
+// restoredSuffix and restoredTimeFormat name the database files replaced by Restore, so earlier ones are not overwritten.
+const (
+	restoredSuffix     = ".before-restore-"
+	restoredTimeFormat = "20060102T150405.000000000"
+)
+
+// snapshot writes a consistent copy of the database to a temporary file next to it and returns its path.
+func (s *SQLite) snapshot() (string, error) {
+	var file string
+	if err := s.db.QueryRow("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file); err != nil {
+		return "", errors.Wrap(err, "could not locate database")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".*.snapshot")
+	if err != nil {
+		return "", errors.Wrap(err, "could not create database snapshot")
+	}
+	tmp.Close()
+	// VACUUM INTO refuses to overwrite existing files
+	os.Remove(tmp.Name())
+
+	if _, err := s.db.Exec("VACUUM INTO ?", tmp.Name()); err != nil {
+		os.Remove(tmp.Name())
+		return "", errors.Wrap(err, "could not create database snapshot")
+	}
+	return tmp.Name(), nil
+}
+
+func openExisting(file string) (*SQLite, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, errors.Wrap(err, "could not find database")
+	}
+	return openDB(file)
+}
+
+// BackupFile writes a snapshot of the database in the given storage directory to the destination file.
+func BackupFile(path, destination string) error {
+	db, err := openExisting(DatabaseFile(path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	snapshot, err := db.snapshot()
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(snapshot, destination); err != nil {
+		os.Remove(snapshot)
+		return errors.Wrap(err, "could not write backup")
+	}
+	return nil
+}
+
+// Restore replaces the database in the given storage directory with the backup.
+// The replaced database files are kept next to it with the ".before-restore-<timestamp>" suffix
+// and are put back in place if the restore fails.
+// The node using the database has to be stopped.
+func Restore(path, backupFile string) (err error) {
+	backup, err := openExisting(backupFile)
+	if err != nil {
+		return errors.Wrap(err, "invalid backup")
+	}
+	var integrity string
+	err = backup.db.QueryRow("PRAGMA integrity_check").Scan(&integrity)
+	backup.Close()
+	if err != nil || integrity != "ok" {
+		return errors.Errorf("invalid backup: integrity check failed: %v %s", err, integrity)
+	}
+
+	file := DatabaseFile(path)
+	suffix := restoredSuffix + time.Now().UTC().Format(restoredTimeFormat)
+	var saved []string
+	var restoring bool
+	defer func() {
+		if err == nil {
+			return
+		}
+		if restoring {
+			os.Remove(file)
+		}
+		for _, name := range saved {
+			if rollbackErr := os.Rename(name+suffix, name); rollbackErr != nil {
+				err = errors.Wrapf(err, "could not put back replaced database %s: %v", name+suffix, rollbackErr)
+			}
+		}
+	}()
+
+	// write-ahead log files belong to the replaced database and must not be applied to the restored one
+	for _, name := range []string{file, file + "-wal", file + "-shm"} {
+		if _, err := os.Stat(name); err != nil {
+			continue
+		}
+		if err := os.Rename(name, name+suffix); err != nil {
+			return errors.Wrap(err, "could not keep current database")
+		}
+		saved = append(saved, name)
+	}
+
+	restoring = true
+	return errors.Wrap(copyFile(backupFile, file), "could not restore database")
+}
+
+// Compact rebuilds the database in the given storage directory, releasing the space left by deleted records.
+func Compact(path string) (storage.CompactResult, error) {
+	file := DatabaseFile(path)
+	db, err := openExisting(file)
+	if err != nil {
+		return storage.CompactResult{}, err
+	}
+	defer db.Close()
+
+	before, err := fileSize(file)
+	if err != nil {
+		return storage.CompactResult{}, err
+	}
+	if _, err := db.db.Exec("VACUUM"); err != nil {
+		return storage.CompactResult{}, errors.Wrap(err, "could not compact database")
+	}
+	if _, err := db.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return storage.CompactResult{}, errors.Wrap(err, "could not compact database")
+	}
+
+	after, err := fileSize(file)
+	return storage.CompactResult{SizeBefore: before, SizeAfter: after}, err
+}
+
+// Inspect lists record tables and key value buckets of the database in the given storage directory.
+func Inspect(path string) ([]storage.BucketStats, error) {
+	db, err := openExisting(DatabaseFile(path))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tables, err := db.tableNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []storage.BucketStats
+	for _, name := range tables {
+		stats := storage.BucketStats{Name: name}
+		query := fmt.Sprintf("SELECT COUNT(*), IFNULL(SUM(LENGTH(data)), 0) FROM %s", quote(name))
+		if err := db.db.QueryRow(query).Scan(&stats.Keys, &stats.Bytes); err != nil {
+			return nil, errors.Wrap(err, "could not inspect database")
+		}
+		result = append(result, stats)
+	}
+
+	rows, err := db.db.Query("SELECT bucket, COUNT(*), SUM(LENGTH(value)) FROM key_values GROUP BY bucket ORDER BY bucket")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not inspect database")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var stats storage.BucketStats
+		if err := rows.Scan(&stats.Name, &stats.Keys, &stats.Bytes); err != nil {
+			return nil, errors.Wrap(err, "could not inspect database")
+		}
+		result = append(result, stats)
+	}
+	return result, errors.Wrap(rows.Err(), "could not inspect database")
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+func fileSize(file string) (int64, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not stat database")
+	}
+	return info.Size(), nil
+}
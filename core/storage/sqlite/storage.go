@@ -0,0 +1,450 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	// registers the sqlite3 database driver
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	dbFileName      = "myst.sqlite"
+	keyValuesTable  = "key_values"
+	createKeyValues = `CREATE TABLE IF NOT EXISTS key_values (bucket TEXT NOT NULL, key BLOB NOT NULL, value BLOB NOT NULL, PRIMARY KEY (bucket, key))`
+)
+
+// ErrZeroID is returned when storing a record with zero id, which is not auto incremented.
+var ErrZeroID = errors.New("id field must not be a zero value")
+
+// SQLite is a storage backed by an SQLite database
+type SQLite struct {
+	db *sql.DB
+
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+// DatabaseFile returns the database file path in the given storage directory.
+func DatabaseFile(path string) string {
+	return filepath.Join(path, dbFileName)
+}
+
+// NewStorage creates a new SQLite storage in the given directory
+func NewStorage(path string) (*SQLite, error) {
+	return openDB(DatabaseFile(path))
+}
+
+func openDB(file string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", "file:"+file+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open SQLite")
+	}
+	// a single connection serializes writers, which SQLite does not run concurrently anyway
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(createKeyValues); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to open SQLite")
+	}
+
+	return &SQLite{db: db, tables: make(map[string]*table)}, nil
+}
+
+// GetValue gets key value
+func (s *SQLite) GetValue(bucket string, key interface{}, to interface{}) error {
+	k, err := keyBytes(key)
+	if err != nil {
+		return err
+	}
+
+	var value []byte
+	err = s.db.QueryRow("SELECT value FROM key_values WHERE bucket = ? AND key = ?", bucket, k).Scan(&value)
+	if err == sql.ErrNoRows {
+		return storage.ErrNotFound
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not get value")
+	}
+	return json.Unmarshal(value, to)
+}
+
+// SetValue sets key value
+func (s *SQLite) SetValue(bucket string, key interface{}, to interface{}) error {
+	k, err := keyBytes(key)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(to)
+	if err != nil {
+		return errors.Wrap(err, "could not encode value")
+	}
+
+	_, err = s.db.Exec("INSERT OR REPLACE INTO key_values (bucket, key, value) VALUES (?, ?, ?)", bucket, k, value)
+	return errors.Wrap(err, "could not set value")
+}
+
+// Store allows to keep struct grouped by the bucket
+func (s *SQLite) Store(bucket string, data interface{}) error {
+	return s.inTx(bucket, data, func(tx *sql.Tx, t *table) error {
+		id := reflect.Indirect(reflect.ValueOf(data)).FieldByName(t.idField)
+		if isZero(id) && !t.increment {
+			return ErrZeroID
+		}
+		return t.write(tx, data, true)
+	})
+}
+
+// Update updates non zero fields of the stored struct
+func (s *SQLite) Update(bucket string, object interface{}) error {
+	return s.inTx(bucket, object, func(tx *sql.Tx, t *table) error {
+		ref := reflect.Indirect(reflect.ValueOf(object))
+		id, err := columnValue(ref.FieldByName(t.idField))
+		if err != nil {
+			return err
+		}
+
+		records, err := t.selectRecords(tx, fmt.Sprintf("SELECT data FROM %s WHERE id = ?", quote(t.name)), id)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return storage.ErrNotFound
+		}
+
+		current := records[0]
+		for i := 0; i < ref.NumField(); i++ {
+			if ref.Type().Field(i).PkgPath != "" || isZero(ref.Field(i)) {
+				continue
+			}
+			current.Field(i).Set(ref.Field(i))
+		}
+		return t.write(tx, current.Addr().Interface(), true)
+	})
+}
+
+// Delete removes the given struct from the given bucket
+func (s *SQLite) Delete(bucket string, data interface{}) error {
+	return s.inTx(bucket, data, func(tx *sql.Tx, t *table) error {
+		id, err := columnValue(reflect.Indirect(reflect.ValueOf(data)).FieldByName(t.idField))
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", quote(t.name)), id)
+		if err != nil {
+			return errors.Wrapf(err, "could not delete record from %s", t.name)
+		}
+		if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+			return storage.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// GetAllFrom allows to get all structs from the bucket
+func (s *SQLite) GetAllFrom(bucket string, data interface{}) error {
+	return s.Find(bucket, storage.Query{}, data)
+}
+
+// GetOneByField returns an object from the given bucket by the given field
+func (s *SQLite) GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error {
+	return s.inTx(bucket, to, func(tx *sql.Tx, t *table) error {
+		records, err := s.find(tx, t, storage.Query{Limit: 1}.Where(fieldName, storage.OpEq, key))
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return storage.ErrNotFound
+		}
+		reflect.ValueOf(to).Elem().Set(records[0])
+		return nil
+	})
+}
+
+// GetLast returns the last entry in the bucket
+func (s *SQLite) GetLast(bucket string, to interface{}) error {
+	return s.inTx(bucket, to, func(tx *sql.Tx, t *table) error {
+		records, err := s.find(tx, t, storage.Query{Reverse: true, Limit: 1})
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return storage.ErrNotFound
+		}
+		reflect.ValueOf(to).Elem().Set(records[0])
+		return nil
+	})
+}
+
+// Find loads records matching the query into the slice pointed by to.
+func (s *SQLite) Find(bucket string, query storage.Query, to interface{}) error {
+	return s.inTx(bucket, to, func(tx *sql.Tx, t *table) error {
+		records, err := s.find(tx, t, query)
+		if err != nil {
+			return err
+		}
+
+		results := reflect.MakeSlice(reflect.ValueOf(to).Elem().Type(), 0, len(records))
+		for _, record := range records {
+			results = reflect.Append(results, record)
+		}
+		reflect.ValueOf(to).Elem().Set(results)
+		return nil
+	})
+}
+
+// Count returns how many records of the given kind match the query.
+func (s *SQLite) Count(bucket string, query storage.Query, kind interface{}) (int, error) {
+	var count int
+	err := s.inTx(bucket, kind, func(tx *sql.Tx, t *table) error {
+		selection, args, err := s.selection(tx, t, query)
+		if err != nil {
+			return err
+		}
+		return tx.QueryRow("SELECT COUNT(*) FROM ("+selection+")", args...).Scan(&count)
+	})
+	return count, errors.Wrapf(err, "could not count records of %s", bucket)
+}
+
+// DeleteAll removes records of the given kind matching the query and returns how many were removed.
+func (s *SQLite) DeleteAll(bucket string, query storage.Query, kind interface{}) (int, error) {
+	var count int64
+	err := s.inTx(bucket, kind, func(tx *sql.Tx, t *table) error {
+		selection, args, err := s.selection(tx, t, query)
+		if err != nil {
+			return err
+		}
+		result, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE id IN (%s)", quote(t.name), selection), args...)
+		if err != nil {
+			return err
+		}
+		count, err = result.RowsAffected()
+		return err
+	})
+	return int(count), errors.Wrapf(err, "could not delete records of %s", bucket)
+}
+
+// GetBuckets returns a list of buckets
+func (s *SQLite) GetBuckets() []string {
+	buckets := make(map[string]bool)
+
+	tables, err := s.tableNames()
+	if err != nil {
+		return nil
+	}
+	for _, name := range tables {
+		if i := strings.LastIndex(name, "/"); i > 0 {
+			buckets[name[:i]] = true
+		}
+	}
+
+	rows, err := s.db.Query("SELECT DISTINCT bucket FROM key_values")
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bucket string
+		if rows.Scan(&bucket) == nil {
+			buckets[bucket] = true
+		}
+	}
+
+	result := make([]string, 0, len(buckets))
+	for bucket := range buckets {
+		result = append(result, bucket)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Backup writes a consistent snapshot of the open database to the given writer.
+func (s *SQLite) Backup(w io.Writer) (int64, error) {
+	snapshot, err := s.snapshot()
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(snapshot)
+
+	file, err := os.Open(snapshot)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not read database snapshot")
+	}
+	defer file.Close()
+
+	written, err := io.Copy(w, file)
+	return written, errors.Wrap(err, "could not write database snapshot")
+}
+
+// Close closes database
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// inTx runs fn in a transaction on the table of the record kind pointed by ref.
+// The kind is taken from a struct, a slice of structs or pointers to them.
+func (s *SQLite) inTx(bucket string, ref interface{}, fn func(tx *sql.Tx, t *table) error) error {
+	kind := reflect.TypeOf(ref)
+	for kind != nil && (kind.Kind() == reflect.Ptr || kind.Kind() == reflect.Slice) {
+		kind = kind.Elem()
+	}
+	if kind == nil || kind.Kind() != reflect.Struct {
+		return errors.Errorf("struct or a slice of structs expected, got %T", ref)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+
+	t, err := s.table(tx, bucket, kind)
+	if err == nil {
+		err = fn(tx, t)
+	}
+	if err != nil {
+		tx.Rollback()
+		// schema changes are rolled back too
+		delete(s.tables, tableName(bucket, kind))
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		delete(s.tables, tableName(bucket, kind))
+		return errors.Wrap(err, "could not commit transaction")
+	}
+	return nil
+}
+
+func (s *SQLite) table(tx *sql.Tx, bucket string, kind reflect.Type) (*table, error) {
+	name := tableName(bucket, kind)
+	if t, ok := s.tables[name]; ok {
+		return t, nil
+	}
+
+	t, err := newTable(bucket, kind)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.create(tx); err != nil {
+		return nil, err
+	}
+	s.tables[name] = t
+	return t, nil
+}
+
+func (s *SQLite) find(tx *sql.Tx, t *table, query storage.Query) ([]reflect.Value, error) {
+	selection, args, err := s.selection(tx, t, query)
+	if err != nil {
+		return nil, err
+	}
+	return t.selectRecords(tx, fmt.Sprintf("SELECT data FROM %s WHERE id IN (%s) %s", quote(t.name), selection, orderClause(t, query)), args...)
+}
+
+// selection builds a query selecting ids of the records matching the query.
+func (s *SQLite) selection(tx *sql.Tx, t *table, query storage.Query) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	for _, filter := range query.Filters {
+		if !t.hasField(filter.Field) {
+			return "", nil, errors.Errorf("%s has no field %s", t.kind, filter.Field)
+		}
+		if err := t.ensureIndex(tx, filter.Field); err != nil {
+			return "", nil, err
+		}
+
+		value, err := columnValue(reflect.ValueOf(filter.Value))
+		if err != nil {
+			return "", nil, err
+		}
+
+		op := string(filter.Op)
+		if op == "" {
+			op = string(storage.OpEq)
+		}
+		conditions = append(conditions, fmt.Sprintf("%s %s ?", quote(t.column(filter.Field)), op))
+		args = append(args, value)
+	}
+
+	if query.OrderBy != "" {
+		if !t.hasField(query.OrderBy) {
+			return "", nil, errors.Errorf("%s has no field %s", t.kind, query.OrderBy)
+		}
+		if err := t.ensureIndex(tx, query.OrderBy); err != nil {
+			return "", nil, err
+		}
+	}
+
+	selection := "SELECT id FROM " + quote(t.name)
+	if len(conditions) > 0 {
+		selection += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	selection += " " + orderClause(t, query)
+	if query.Limit > 0 || query.Skip > 0 {
+		limit := query.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		selection += fmt.Sprintf(" LIMIT %d OFFSET %d", limit, query.Skip)
+	}
+	return selection, args, nil
+}
+
+func orderClause(t *table, query storage.Query) string {
+	direction := "ASC"
+	if query.Reverse {
+		direction = "DESC"
+	}
+	order := fmt.Sprintf("ORDER BY %s %s", quote(t.column(query.OrderBy)), direction)
+	if query.OrderBy != "" && query.OrderBy != t.idField {
+		order += ", id " + direction
+	}
+	return order
+}
+
+func (s *SQLite) tableNames() ([]string, error) {
+	rows, err := s.db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name <> ? AND name NOT LIKE 'sqlite_%' ORDER BY name", keyValuesTable)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list tables")
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, errors.Wrap(err, "could not list tables")
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
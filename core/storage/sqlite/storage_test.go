@@ -0,0 +1,242 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+type owner struct {
+	Name string
+}
+
+type testRecord struct {
+	ID      int64 `storm:"id,increment"`
+	Owner   owner
+	Status  string
+	Amount  uint64
+	Created time.Time
+}
+
+type namedRecord struct {
+	Identity identity.Identity `storm:"id"`
+	Note     string
+}
+
+const bucket = "test"
+
+func createStorage(t *testing.T) (*SQLite, string) {
+	dir := boltdbtest.CreateTempDir(t)
+	db, err := NewStorage(dir)
+	assert.NoError(t, err)
+	return db, dir
+}
+
+func closeStorage(t *testing.T, db *SQLite, dir string) {
+	assert.NoError(t, db.Close())
+	boltdbtest.RemoveTempDir(t, dir)
+}
+
+func Test_SQLite_StoreAndGet(t *testing.T) {
+	db, dir := createStorage(t)
+	defer closeStorage(t, db, dir)
+
+	first := testRecord{Owner: owner{Name: "alice"}, Status: "new", Created: time.Now().UTC()}
+	assert.NoError(t, db.Store(bucket, &first))
+	assert.Equal(t, int64(1), first.ID)
+	second := testRecord{Owner: owner{Name: "bob"}, Status: "new"}
+	assert.NoError(t, db.Store(bucket, &second))
+	assert.Equal(t, int64(2), second.ID)
+
+	var result testRecord
+	assert.NoError(t, db.GetOneByField(bucket, "Owner", owner{Name: "alice"}, &result))
+	assert.Equal(t, first.ID, result.ID)
+	assert.True(t, first.Created.Equal(result.Created))
+
+	assert.NoError(t, db.GetOneByField(bucket, "ID", second.ID, &result))
+	assert.Equal(t, "bob", result.Owner.Name)
+
+	assert.Equal(t, storage.ErrNotFound, db.GetOneByField(bucket, "Status", "unknown", &result))
+
+	assert.NoError(t, db.GetLast(bucket, &result))
+	assert.Equal(t, second.ID, result.ID)
+
+	var all []testRecord
+	assert.NoError(t, db.GetAllFrom(bucket, &all))
+	assert.Len(t, all, 2)
+
+	assert.NoError(t, db.Delete(bucket, &first))
+	assert.Equal(t, storage.ErrNotFound, db.Delete(bucket, &first))
+	assert.NoError(t, db.GetAllFrom(bucket, &all))
+	assert.Len(t, all, 1)
+
+	assert.Equal(t, ErrZeroID, db.Store(bucket, &namedRecord{}))
+	named := namedRecord{Identity: identity.FromAddress("0x1"), Note: "first"}
+	assert.NoError(t, db.Store(bucket, &named))
+	named.Note = "second"
+	assert.NoError(t, db.Store(bucket, &named))
+	var names []namedRecord
+	assert.NoError(t, db.GetAllFrom(bucket, &names))
+	assert.Equal(t, []namedRecord{named}, names)
+}
+
+func Test_SQLite_UpdateKeepsZeroFields(t *testing.T) {
+	db, dir := createStorage(t)
+	defer closeStorage(t, db, dir)
+
+	record := testRecord{Owner: owner{Name: "alice"}, Status: "new", Amount: 10}
+	assert.NoError(t, db.Store(bucket, &record))
+
+	assert.NoError(t, db.Update(bucket, &testRecord{ID: record.ID, Status: "done"}))
+	var result testRecord
+	assert.NoError(t, db.GetOneByField(bucket, "ID", record.ID, &result))
+	assert.Equal(t, "done", result.Status)
+	assert.Equal(t, uint64(10), result.Amount)
+	assert.Equal(t, "alice", result.Owner.Name)
+
+	assert.Equal(t, storage.ErrNotFound, db.Update(bucket, &testRecord{ID: 100, Status: "done"}))
+}
+
+func Test_SQLite_Find(t *testing.T) {
+	db, dir := createStorage(t)
+	defer closeStorage(t, db, dir)
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, db.Store(bucket, &testRecord{Amount: uint64(i), Created: now.Add(time.Duration(-i) * time.Hour)}))
+	}
+	assert.NoError(t, db.Store(bucket, &testRecord{Amount: 100}))
+
+	var records []testRecord
+	query := storage.Query{OrderBy: "Created", Reverse: true, Skip: 1, Limit: 2}.Between("Created", now.Add(-4*time.Hour), time.Time{})
+	assert.NoError(t, db.Find(bucket, query, &records))
+	assert.Len(t, records, 2)
+	assert.Equal(t, int64(2), records[0].ID)
+	assert.Equal(t, int64(3), records[1].ID)
+
+	assert.NoError(t, db.Find(bucket, storage.Query{OrderBy: "Created"}, &records))
+	assert.Len(t, records, 6)
+	assert.Equal(t, uint64(100), records[0].Amount, "zero time is ordered first")
+
+	count, err := db.Count(bucket, storage.Query{}.Where("Amount", storage.OpGte, 3), &testRecord{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	assert.Error(t, db.Find(bucket, storage.Query{}.Where("Unknown", storage.OpEq, 1), &records))
+
+	dropped, err := storage.ApplyRetention(db, []storage.RetentionPolicy{
+		{Bucket: bucket, Kind: &testRecord{}, Field: "Created", MaxAge: 150 * time.Minute},
+	}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, dropped, "2 expired and 1 without the time")
+}
+
+func Test_SQLite_KeyValues(t *testing.T) {
+	db, dir := createStorage(t)
+	defer closeStorage(t, db, dir)
+
+	var value string
+	assert.Equal(t, storage.ErrNotFound, db.GetValue("values", "key", &value))
+
+	assert.NoError(t, db.SetValue("values", "key", "first"))
+	assert.NoError(t, db.SetValue("values", "key", "second"))
+	assert.NoError(t, db.SetValue("values", 1, "number"))
+	assert.NoError(t, db.GetValue("values", "key", &value))
+	assert.Equal(t, "second", value)
+	assert.NoError(t, db.GetValue("values", 1, &value))
+	assert.Equal(t, "number", value)
+
+	assert.NoError(t, db.Store(bucket, &testRecord{}))
+	assert.Equal(t, []string{bucket, "values"}, db.GetBuckets())
+}
+
+type testRecordV1 struct {
+	ID     int64 `storm:"id,increment"`
+	Status string
+}
+
+func Test_SQLite_AddsColumnsOfNewFields(t *testing.T) {
+	dir := boltdbtest.CreateTempDir(t)
+	defer boltdbtest.RemoveTempDir(t, dir)
+
+	db, err := NewStorage(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Store(bucket, &testRecordV1{Status: "done"}))
+	assert.NoError(t, db.Close())
+
+	// the same table is read as a newer struct version
+	db, err = NewStorage(dir)
+	assert.NoError(t, err)
+	defer db.Close()
+	_, err = db.db.Exec("ALTER TABLE " + quote(tableName(bucket, reflect.TypeOf(testRecordV1{}))) + " RENAME TO " + quote(tableName(bucket, reflect.TypeOf(testRecord{}))))
+	assert.NoError(t, err)
+
+	var records []testRecord
+	assert.NoError(t, db.Find(bucket, storage.Query{}.Where("Status", storage.OpEq, "done"), &records))
+	assert.Len(t, records, 1)
+	count, err := db.Count(bucket, storage.Query{}.Where("Amount", storage.OpEq, 0), &testRecord{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func Test_SQLite_BackupAndRestore(t *testing.T) {
+	db, dir := createStorage(t)
+	assert.NoError(t, db.Store(bucket, &testRecord{Status: "backed up"}))
+
+	var buf bytes.Buffer
+	written, err := db.Backup(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), written)
+	assert.NoError(t, db.Store(bucket, &testRecord{Status: "lost"}))
+	assert.NoError(t, db.Close())
+	defer boltdbtest.RemoveTempDir(t, dir)
+
+	backup := filepath.Join(dir, "backup.sqlite")
+	assert.NoError(t, ioutil.WriteFile(backup, buf.Bytes(), 0600))
+	assert.Error(t, Restore(dir, filepath.Join(dir, "missing")))
+	assert.NoError(t, Restore(dir, backup))
+	saved, err := filepath.Glob(DatabaseFile(dir) + restoredSuffix + "*")
+	assert.NoError(t, err)
+	assert.Len(t, saved, 1)
+
+	db, err = NewStorage(dir)
+	assert.NoError(t, err)
+	var records []testRecord
+	assert.NoError(t, db.GetAllFrom(bucket, &records))
+	assert.NoError(t, db.Close())
+	assert.Len(t, records, 1)
+	assert.Equal(t, "backed up", records[0].Status)
+
+	result, err := Compact(dir)
+	assert.NoError(t, err)
+	assert.True(t, result.SizeAfter > 0)
+
+	stats, err := Inspect(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.BucketStats{{Name: tableName(bucket, reflect.TypeOf(testRecord{})), Keys: 1, Bytes: stats[0].Bytes}}, stats)
+}
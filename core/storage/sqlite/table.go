@@ -0,0 +1,328 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// table maps a struct kind stored in a bucket to an SQLite table.
+// Every exported top level field gets its own column next to the JSON encoded record,
+// so that records can be filtered and ordered without decoding them.
+type table struct {
+	name      string
+	kind      reflect.Type
+	idField   string
+	increment bool
+	columns   []string
+	indexed   map[string]bool
+}
+
+func tableName(bucket string, kind reflect.Type) string {
+	return bucket + "/" + kind.Name()
+}
+
+func newTable(bucket string, kind reflect.Type) (*table, error) {
+	t := &table{
+		name:    tableName(bucket, kind),
+		kind:    kind,
+		indexed: make(map[string]bool),
+	}
+
+	for i := 0; i < kind.NumField(); i++ {
+		field := kind.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := field.Tag.Get("storm")
+		if tag == "id" || strings.HasPrefix(tag, "id,") {
+			t.idField = field.Name
+			t.increment = strings.Contains(tag, "increment")
+			continue
+		}
+		t.columns = append(t.columns, field.Name)
+	}
+
+	if t.idField == "" {
+		return nil, errors.Errorf("%s has no id field", kind)
+	}
+	return t, nil
+}
+
+func (t *table) create(tx *sql.Tx) error {
+	id := "id PRIMARY KEY NOT NULL"
+	if t.increment {
+		id = "id INTEGER PRIMARY KEY AUTOINCREMENT"
+	}
+	columns := []string{id, "data NOT NULL"}
+	for _, column := range t.columns {
+		columns = append(columns, quote(column))
+	}
+
+	_, err := tx.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", quote(t.name), strings.Join(columns, ", ")))
+	if err != nil {
+		return errors.Wrapf(err, "could not create table %s", t.name)
+	}
+
+	return t.addMissingColumns(tx)
+}
+
+// addMissingColumns adds columns of the fields added to the struct after the table was created
+// and fills them from the stored records.
+func (t *table) addMissingColumns(tx *sql.Tx) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", quote(t.name)))
+	if err != nil {
+		return errors.Wrapf(err, "could not read columns of %s", t.name)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name string
+		var columnType, defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return errors.Wrapf(err, "could not read columns of %s", t.name)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	var missing []string
+	for _, column := range t.columns {
+		if existing[column] {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", quote(t.name), quote(column))); err != nil {
+			return errors.Wrapf(err, "could not add column %s to %s", column, t.name)
+		}
+		missing = append(missing, column)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	records, err := t.selectRecords(tx, "SELECT data FROM "+quote(t.name))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := t.write(tx, record.Addr().Interface(), true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *table) ensureIndex(tx *sql.Tx, field string) error {
+	column := t.column(field)
+	if column == "id" || t.indexed[column] {
+		return nil
+	}
+
+	index := quote(t.name + "/" + column)
+	if _, err := tx.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", index, quote(t.name), quote(column))); err != nil {
+		return errors.Wrapf(err, "could not index %s of %s", column, t.name)
+	}
+	t.indexed[column] = true
+	return nil
+}
+
+func (t *table) column(field string) string {
+	if field == t.idField || field == "" {
+		return "id"
+	}
+	return field
+}
+
+func (t *table) hasField(field string) bool {
+	if field == t.idField {
+		return true
+	}
+	for _, column := range t.columns {
+		if column == field {
+			return true
+		}
+	}
+	return false
+}
+
+// write inserts or replaces the record, an increment id is assigned to zero id records.
+func (t *table) write(tx *sql.Tx, record interface{}, replace bool) error {
+	ref := reflect.Indirect(reflect.ValueOf(record))
+	id := ref.FieldByName(t.idField)
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "could not encode record")
+	}
+
+	columns := []string{"data"}
+	values := []interface{}{data}
+	if !isZero(id) || !t.increment {
+		idValue, err := columnValue(id)
+		if err != nil {
+			return err
+		}
+		columns = append(columns, "id")
+		values = append(values, idValue)
+	}
+	for _, column := range t.columns {
+		value, err := columnValue(ref.FieldByName(column))
+		if err != nil {
+			return err
+		}
+		columns = append(columns, quote(column))
+		values = append(values, value)
+	}
+
+	statement := "INSERT"
+	if replace {
+		statement = "INSERT OR REPLACE"
+	}
+	query := fmt.Sprintf("%s INTO %s (%s) VALUES (?%s)", statement, quote(t.name), strings.Join(columns, ", "), strings.Repeat(", ?", len(values)-1))
+	result, err := tx.Exec(query, values...)
+	if err != nil {
+		return errors.Wrapf(err, "could not write record to %s", t.name)
+	}
+
+	if isZero(id) && t.increment {
+		lastID, err := result.LastInsertId()
+		if err != nil {
+			return errors.Wrap(err, "could not get record id")
+		}
+		id.SetInt(lastID)
+
+		// the stored record has to carry the assigned id too
+		data, err := json.Marshal(record)
+		if err != nil {
+			return errors.Wrap(err, "could not encode record")
+		}
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET data = ? WHERE id = ?", quote(t.name)), data, lastID); err != nil {
+			return errors.Wrapf(err, "could not write record to %s", t.name)
+		}
+	}
+	return nil
+}
+
+// selectRecords decodes records returned by the query selecting the data column.
+func (t *table) selectRecords(tx *sql.Tx, query string, args ...interface{}) ([]reflect.Value, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not query %s", t.name)
+	}
+	defer rows.Close()
+
+	var records []reflect.Value
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, errors.Wrapf(err, "could not query %s", t.name)
+		}
+		record := reflect.New(t.kind).Elem()
+		if err := json.Unmarshal(data, record.Addr().Interface()); err != nil {
+			return nil, errors.Wrapf(err, "could not decode record of %s", t.name)
+		}
+		records = append(records, record)
+	}
+	return records, errors.Wrapf(rows.Err(), "could not query %s", t.name)
+}
+
+// columnValue converts a field value to the value it is stored and compared as.
+// Times are stored as Unix nanoseconds to keep them ordered, values of other than basic kinds as JSON.
+func columnValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		return columnValue(v.Elem())
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return int64(math.MinInt64), nil
+		}
+		return t.UnixNano(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode field value")
+	}
+	return string(data), nil
+}
+
+// keyBytes encodes key value keys the way storm does, so that they survive the migration from bolt.
+func keyBytes(key interface{}) ([]byte, error) {
+	switch k := key.(type) {
+	case []byte:
+		return k, nil
+	case string:
+		return []byte(k), nil
+	case int:
+		return numberBytes(int64(k))
+	case uint:
+		return numberBytes(uint64(k))
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		return numberBytes(k)
+	default:
+		return json.Marshal(key)
+	}
+}
+
+func numberBytes(number interface{}) ([]byte, error) {
+	var buf strings.Builder
+	err := binary.Write(&buf, binary.BigEndian, number)
+	return []byte(buf.String()), err
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+func quote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package storage
+
+import (
+	"io"
+	"time"
+)
+
+// Storage is implemented by every storage backend of the node.
+// Records are structs grouped by buckets and identified by the field tagged with `storm:"id"`,
+// key values are kept in buckets separately from the records.
+type Storage interface {
+	GetValue(bucket string, key interface{}, to interface{}) error
+	SetValue(bucket string, key interface{}, to interface{}) error
+
+	Store(bucket string, data interface{}) error
+	Update(bucket string, object interface{}) error
+	Delete(bucket string, data interface{}) error
+	GetAllFrom(bucket string, data interface{}) error
+	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
+	GetLast(bucket string, to interface{}) error
+
+	// Find loads records matching the query into the slice pointed by to.
+	Find(bucket string, query Query, to interface{}) error
+	// Count returns how many records of the given kind match the query.
+	Count(bucket string, query Query, kind interface{}) (int, error)
+	// DeleteAll removes records of the given kind matching the query and returns how many were removed.
+	DeleteAll(bucket string, query Query, kind interface{}) (int, error)
+
+	GetBuckets() []string
+	// Backup writes a consistent snapshot of the database.
+	Backup(w io.Writer) (int64, error)
+	Close() error
+}
+
+// Kind describes a struct stored in a bucket, it is needed to move the records between backends.
+type Kind struct {
+	Bucket string
+	// Record is a pointer to the struct the records are stored as.
+	Record interface{}
+}
+
+// ApplyRetention drops records older than allowed by the policies and returns how many were dropped.
+func ApplyRetention(db Storage, policies []RetentionPolicy, now time.Time) (int, error) {
+	dropped := 0
+	for _, policy := range policies {
+		if policy.MaxAge <= 0 {
+			continue
+		}
+
+		query := Query{}.Where(policy.Field, OpLt, now.Add(-policy.MaxAge))
+		count, err := db.DeleteAll(policy.Bucket, query, policy.Kind)
+		if err != nil {
+			return dropped, err
+		}
+		dropped += count
+	}
+	return dropped, nil
+}
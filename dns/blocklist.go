@@ -0,0 +1,265 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const blocklistFetchTimeout = 30 * time.Second
+
+// BlocklistOptions describes domain blocklists of the provider DNS proxy.
+type BlocklistOptions struct {
+	// Sources are local files or http(s) URLs of hosts files or plain domain lists.
+	Sources []string
+	// Refresh is how often the sources are reloaded, zero loads them once.
+	Refresh time.Duration
+	// BlockIPs blocks the IPs blocked names resolve to in the incoming firewall.
+	BlockIPs bool
+}
+
+type blocklistOptionsJSON struct {
+	Sources  []string `json:"sources"`
+	Refresh  string   `json:"refresh,omitempty"`
+	BlockIPs bool     `json:"blockIPs"`
+}
+
+// MarshalJSON implements json.Marshaler interface to provide human readable refresh interval.
+func (o BlocklistOptions) MarshalJSON() ([]byte, error) {
+	options := blocklistOptionsJSON{Sources: o.Sources, BlockIPs: o.BlockIPs}
+	if o.Refresh > 0 {
+		options.Refresh = o.Refresh.String()
+	}
+	return json.Marshal(options)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface to receive human readable refresh interval.
+func (o *BlocklistOptions) UnmarshalJSON(data []byte) error {
+	var options blocklistOptionsJSON
+	if err := json.Unmarshal(data, &options); err != nil {
+		return err
+	}
+
+	var refresh time.Duration
+	if options.Refresh != "" {
+		var err error
+		if refresh, err = time.ParseDuration(options.Refresh); err != nil {
+			return errors.Wrap(err, "invalid blocklist refresh interval")
+		}
+	}
+	*o = BlocklistOptions{Sources: options.Sources, Refresh: refresh, BlockIPs: options.BlockIPs}
+	return nil
+}
+
+// Blocklist is a set of blocked domains loaded from hosts files and plain domain lists.
+// Blocking a domain blocks its subdomains too.
+type Blocklist struct {
+	sources []string
+	open    func(source string) (io.ReadCloser, error)
+
+	mu      sync.RWMutex
+	domains map[string]map[string]struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewBlocklist creates an empty blocklist of the sources, call Load to fill it.
+func NewBlocklist(sources []string) *Blocklist {
+	client := &http.Client{Timeout: blocklistFetchTimeout}
+
+	return &Blocklist{
+		sources: sources,
+		open: func(source string) (io.ReadCloser, error) {
+			return openBlocklistSource(client, source)
+		},
+		domains: make(map[string]map[string]struct{}),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Load reloads the sources, a source failing to load keeps the domains loaded previously.
+func (b *Blocklist) Load() error {
+	var failed []string
+	for _, source := range b.sources {
+		domains, err := b.load(source)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Could not load DNS blocklist %s", source)
+			failed = append(failed, source)
+			continue
+		}
+
+		b.mu.Lock()
+		b.domains[source] = domains
+		b.mu.Unlock()
+		log.Info().Msgf("Loaded %d domains from DNS blocklist %s", len(domains), source)
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("could not load DNS blocklists: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// Start reloads the sources periodically until stopped.
+func (b *Blocklist) Start(refresh time.Duration) {
+	if refresh <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.Load()
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops reloading the sources.
+func (b *Blocklist) Stop() {
+	b.stopOnce.Do(func() {
+		close(b.stop)
+	})
+}
+
+// IsBlocked tells whether the name or any of its parent domains is blocked.
+func (b *Blocklist) IsBlocked(name string) bool {
+	name = normalizeDomain(name)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for {
+		for _, domains := range b.domains {
+			if _, ok := domains[name]; ok {
+				return true
+			}
+		}
+
+		dot := strings.IndexByte(name, '.')
+		if dot < 0 {
+			return false
+		}
+		name = name[dot+1:]
+	}
+}
+
+// Len returns how many domains are blocked.
+func (b *Blocklist) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	count := 0
+	for _, domains := range b.domains {
+		count += len(domains)
+	}
+	return count
+}
+
+func (b *Blocklist) load(source string) (map[string]struct{}, error) {
+	reader, err := b.open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return parseBlocklist(reader)
+}
+
+func openBlocklistSource(client *http.Client, source string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.Open(source)
+	}
+
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("blocklist server responded with %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// parseBlocklist reads hosts file lines like "0.0.0.0 ads.example.com" and plain list lines like "ads.example.com".
+func parseBlocklist(reader io.Reader) (map[string]struct{}, error) {
+	domains := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if comment := strings.IndexByte(line, '#'); comment >= 0 {
+			line = line[:comment]
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case len(fields) == 1:
+		case net.ParseIP(fields[0]) != nil:
+			fields = fields[1:]
+		default:
+			continue
+		}
+
+		for _, field := range fields {
+			domain := normalizeDomain(field)
+			if !isBlockableDomain(domain) {
+				continue
+			}
+			domains[domain] = struct{}{}
+		}
+	}
+	return domains, scanner.Err()
+}
+
+func normalizeDomain(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// isBlockableDomain skips the local names hosts files usually contain.
+func isBlockableDomain(domain string) bool {
+	if !strings.Contains(domain, ".") || net.ParseIP(domain) != nil {
+		return false
+	}
+	if domain == "localhost.localdomain" {
+		return false
+	}
+	_, ok := dns.IsDomainName(domain)
+	return ok
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+const hostsBlocklist = `# hosts file
+127.0.0.1 localhost
+::1 localhost ip6-localhost
+0.0.0.0 ads.example.com tracker.example.com # inline comment
+0.0.0.0 0.0.0.0
+`
+
+const plainBlocklist = `# plain list
+Malware.example.org.
+
+not a domain line
+`
+
+func Test_ParseBlocklist(t *testing.T) {
+	domains, err := parseBlocklist(strings.NewReader(hostsBlocklist + plainBlocklist))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"ads.example.com":     {},
+		"tracker.example.com": {},
+		"malware.example.org": {},
+	}, domains)
+}
+
+func Test_Blocklist_LoadsFilesAndURLs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blocklistTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "hosts")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(hostsBlocklist), 0600))
+
+	list := plainBlocklist
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if list == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(list))
+	}))
+	defer server.Close()
+
+	blocklist := NewBlocklist([]string{file, server.URL})
+	assert.NoError(t, blocklist.Load())
+	assert.Equal(t, 3, blocklist.Len())
+	assert.True(t, blocklist.IsBlocked("ads.example.com."))
+	assert.True(t, blocklist.IsBlocked("cdn.Malware.example.org."))
+	assert.False(t, blocklist.IsBlocked("example.org."))
+	assert.False(t, blocklist.IsBlocked("good.example.com."))
+
+	list = ""
+	assert.Error(t, blocklist.Load())
+	assert.True(t, blocklist.IsBlocked("malware.example.org."), "failed source should keep previous domains")
+}
+
+func Test_Blocklist_Refreshes(t *testing.T) {
+	list := "ads.example.com"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(list))
+	}))
+	defer server.Close()
+
+	blocklist := NewBlocklist([]string{server.URL})
+	assert.NoError(t, blocklist.Load())
+	list = "tracker.example.com"
+	blocklist.Start(10 * time.Millisecond)
+	defer blocklist.Stop()
+
+	assert.Eventually(t, func() bool {
+		return blocklist.IsBlocked("tracker.example.com.")
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.False(t, blocklist.IsBlocked("ads.example.com."))
+}
+
+func Test_BlockAnswers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ads.example.com"))
+	}))
+	defer server.Close()
+
+	trafficBlocker := &trafficBlockerMock{}
+	handler := BlockAnswers(answerA("1.2.3.4", 60), BlocklistOptions{Sources: []string{server.URL}, BlockIPs: true}, trafficBlocker)
+	handler.Start()
+	_, network, _ := net.ParseCIDR("10.182.0.0/30")
+	handler.TrackSession("session1", *network)
+
+	sessionWriter := &recordingWriter{writer: &clientWriter{ip: "10.182.0.2"}}
+	handler.ServeDNS(sessionWriter, query("sub.ads.example.com."))
+	assert.Equal(t, dns.RcodeNameError, sessionWriter.responseMsg.Rcode)
+	assert.Equal(t, []string{"1.2.3.4"}, trafficBlocker.blockedIPs)
+
+	otherWriter := &recordingWriter{writer: &clientWriter{ip: "10.8.0.2"}}
+	handler.ServeDNS(otherWriter, query("ads.example.com."))
+	handler.ServeDNS(otherWriter, query("good.example.com."))
+	assert.Equal(t, dns.RcodeSuccess, otherWriter.responseMsg.Rcode)
+	assert.Equal(t, []string{"1.2.3.4"}, trafficBlocker.blockedIPs, "IP should be blocked once")
+
+	assert.Equal(t, map[string]uint64{"session1": 1, "": 1}, handler.Hits())
+	handler.UntrackSession("session1")
+	handler.ServeDNS(sessionWriter, query("ads.example.com."))
+	assert.Equal(t, map[string]uint64{"session1": 1, "": 2}, handler.Hits())
+	assert.Equal(t, uint64(1), handler.ReleaseSession("session1"))
+	assert.Equal(t, map[string]uint64{"": 2}, handler.Hits())
+
+	handler.Stop()
+	assert.Empty(t, trafficBlocker.blockedIPs)
+}
+
+func Test_BlocklistOptions_JSON(t *testing.T) {
+	var options BlocklistOptions
+	err := json.Unmarshal([]byte(`{"sources":["/etc/myst/blocklist"],"refresh":"6h","blockIPs":true}`), &options)
+
+	assert.NoError(t, err)
+	assert.Equal(t, BlocklistOptions{Sources: []string{"/etc/myst/blocklist"}, Refresh: 6 * time.Hour, BlockIPs: true}, options)
+
+	data, err := json.Marshal(options)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"sources":["/etc/myst/blocklist"],"refresh":"6h0m0s","blockIPs":true}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"refresh":"daily"}`), &options))
+}
+
+type clientWriter struct {
+	dns.ResponseWriter
+	ip string
+}
+
+func (w *clientWriter) RemoteAddr() net.Addr {
+	return &net.UDPAddr{IP: net.ParseIP(w.ip), Port: 40000}
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// cacheMaxEntries limits how many answers are cached.
+	cacheMaxEntries = 4096
+	// prefetchMinHits is how many times an answer has to be served from cache to get prefetched.
+	prefetchMinHits = 3
+	// prefetchTTLShare is the share of TTL left when popular answers are prefetched.
+	prefetchTTLShare = 10
+)
+
+// CacheStats contains counters of the DNS answers cache.
+type CacheStats struct {
+	Entries    int
+	Hits       uint64
+	Misses     uint64
+	Prefetches uint64
+}
+
+type cacheKey struct {
+	name   string
+	qtype  uint16
+	qclass uint16
+}
+
+type cacheEntry struct {
+	msg         *dns.Msg
+	stored      time.Time
+	expires     time.Time
+	hits        int
+	prefetching bool
+}
+
+// cache keeps answers for the minimal TTL of their records.
+type cache struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[cacheKey]*cacheEntry
+	stats   CacheStats
+}
+
+func newCache(maxEntries int) *cache {
+	return &cache{
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[cacheKey]*cacheEntry),
+	}
+}
+
+// get returns the cached answer of the query with TTLs reduced by the time it spent in cache.
+func (c *cache) get(req *dns.Msg) *dns.Msg {
+	key, ok := cacheKeyOf(req)
+	if !ok {
+		return nil
+	}
+
+	now := c.now()
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expires) {
+		c.stats.Misses++
+		c.mu.Unlock()
+		return nil
+	}
+	c.stats.Hits++
+	entry.hits++
+	resp := entry.msg.Copy()
+	c.mu.Unlock()
+
+	resp.Id = req.Id
+	elapsed := uint32(now.Sub(entry.stored) / time.Second)
+	for _, section := range [][]dns.RR{resp.Answer, resp.Ns, resp.Extra} {
+		for _, rr := range section {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			rr.Header().Ttl -= elapsed
+		}
+	}
+	return resp
+}
+
+// set caches successful and name error answers which have non zero TTL.
+func (c *cache) set(req, resp *dns.Msg) {
+	key, ok := cacheKeyOf(req)
+	if !ok || resp.Truncated || (resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError) {
+		return
+	}
+	ttl, ok := minTTL(resp)
+	if !ok || ttl == 0 {
+		return
+	}
+
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = &cacheEntry{
+		msg:     resp.Copy(),
+		stored:  now,
+		expires: now.Add(time.Duration(ttl) * time.Second),
+	}
+}
+
+// claimPrefetch tells if the cached answer of the query is popular and about to expire.
+// The answer is claimed by the caller until it gets refreshed by set.
+func (c *cache) claimPrefetch(req *dns.Msg) bool {
+	key, ok := cacheKeyOf(req)
+	if !ok {
+		return false
+	}
+
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || entry.prefetching || entry.hits < prefetchMinHits {
+		return false
+	}
+	if entry.expires.Sub(now) > entry.expires.Sub(entry.stored)/prefetchTTLShare {
+		return false
+	}
+	entry.prefetching = true
+	c.stats.Prefetches++
+	return true
+}
+
+// release gives up the prefetch claim of the query answer.
+func (c *cache) release(req *dns.Msg) {
+	key, ok := cacheKeyOf(req)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[key]; ok {
+		entry.prefetching = false
+	}
+}
+
+// Stats returns the cache counters.
+func (c *cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+// evict drops expired entries, or an arbitrary one if none expired.
+func (c *cache) evict(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
+
+func cacheKeyOf(req *dns.Msg) (cacheKey, bool) {
+	if len(req.Question) != 1 {
+		return cacheKey{}, false
+	}
+	q := req.Question[0]
+	return cacheKey{name: strings.ToLower(q.Name), qtype: q.Qtype, qclass: q.Qclass}, true
+}
+
+func minTTL(msg *dns.Msg) (ttl uint32, ok bool) {
+	for _, section := range [][]dns.RR{msg.Answer, msg.Ns} {
+		for _, rr := range section {
+			if !ok || rr.Header().Ttl < ttl {
+				ttl, ok = rr.Header().Ttl, true
+			}
+		}
+	}
+	return ttl, ok
+}
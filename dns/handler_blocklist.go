@@ -0,0 +1,183 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"net"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/mysteriumnetwork/node/firewall"
+	"github.com/rs/zerolog/log"
+)
+
+// BlocklistHandler answers NXDOMAIN to queries of blocked names and counts them per session.
+type BlocklistHandler struct {
+	resolver       dns.Handler
+	blocklist      *Blocklist
+	options        BlocklistOptions
+	trafficBlocker firewall.IncomingTrafficFirewall
+
+	mu         sync.Mutex
+	sessions   map[string]net.IPNet
+	hits       map[string]uint64
+	blockedIPs map[string]firewall.IncomingRuleRemove
+}
+
+// BlockAnswers creates a DNS handler refusing blocked names and forwarding the rest of queries to the resolver.
+func BlockAnswers(resolver dns.Handler, options BlocklistOptions, trafficBlocker firewall.IncomingTrafficFirewall) *BlocklistHandler {
+	return &BlocklistHandler{
+		resolver:       resolver,
+		blocklist:      NewBlocklist(options.Sources),
+		options:        options,
+		trafficBlocker: trafficBlocker,
+		sessions:       make(map[string]net.IPNet),
+		hits:           make(map[string]uint64),
+		blockedIPs:     make(map[string]firewall.IncomingRuleRemove),
+	}
+}
+
+// Start loads the blocklists and keeps refreshing them.
+// Blocklists failing to load are logged, the rest of them are applied.
+func (bh *BlocklistHandler) Start() {
+	bh.blocklist.Load()
+	bh.blocklist.Start(bh.options.Refresh)
+}
+
+// Stop stops refreshing the blocklists and releases IPs it blocked in the firewall.
+// IPs also blocked by other services stay blocked until they stop too.
+func (bh *BlocklistHandler) Stop() {
+	bh.blocklist.Stop()
+
+	bh.mu.Lock()
+	defer bh.mu.Unlock()
+	for ip, remove := range bh.blockedIPs {
+		if err := remove(); err != nil {
+			log.Warn().Err(err).Msgf("Could not unblock IP %s", ip)
+		}
+	}
+	bh.blockedIPs = make(map[string]firewall.IncomingRuleRemove)
+}
+
+// TrackSession attributes blocked queries from the session network to the session.
+func (bh *BlocklistHandler) TrackSession(sessionID string, network net.IPNet) {
+	bh.mu.Lock()
+	defer bh.mu.Unlock()
+
+	bh.sessions[sessionID] = network
+}
+
+// UntrackSession stops attributing queries to the session, already counted queries are kept until the session is released.
+func (bh *BlocklistHandler) UntrackSession(sessionID string) {
+	bh.mu.Lock()
+	defer bh.mu.Unlock()
+
+	delete(bh.sessions, sessionID)
+}
+
+// ReleaseSession stops tracking the session and returns how many of its queries were blocked.
+func (bh *BlocklistHandler) ReleaseSession(sessionID string) uint64 {
+	bh.mu.Lock()
+	defer bh.mu.Unlock()
+
+	hits := bh.hits[sessionID]
+	delete(bh.sessions, sessionID)
+	delete(bh.hits, sessionID)
+	return hits
+}
+
+// Hits returns how many queries were blocked per session, queries of untracked clients are counted under empty session ID.
+func (bh *BlocklistHandler) Hits() map[string]uint64 {
+	bh.mu.Lock()
+	defer bh.mu.Unlock()
+
+	hits := make(map[string]uint64, len(bh.hits))
+	for sessionID, count := range bh.hits {
+		hits[sessionID] = count
+	}
+	return hits
+}
+
+func (bh *BlocklistHandler) ServeDNS(writer dns.ResponseWriter, req *dns.Msg) {
+	if len(req.Question) == 0 || !bh.blocklist.IsBlocked(req.Question[0].Name) {
+		bh.resolver.ServeDNS(writer, req)
+		return
+	}
+
+	log.Debug().Msgf("Blocked DNS query of %s", req.Question[0].Name)
+	bh.countHit(writer.RemoteAddr())
+	if bh.options.BlockIPs && bh.trafficBlocker != nil {
+		bh.blockResolvedIPs(writer, req)
+	}
+
+	resp := &dns.Msg{}
+	resp.SetRcode(req, dns.RcodeNameError)
+	writer.WriteMsg(resp)
+}
+
+func (bh *BlocklistHandler) countHit(addr net.Addr) {
+	var ip net.IP
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		ip = addr.IP
+	case *net.TCPAddr:
+		ip = addr.IP
+	}
+
+	bh.mu.Lock()
+	defer bh.mu.Unlock()
+
+	for sessionID, network := range bh.sessions {
+		if ip != nil && network.Contains(ip) {
+			bh.hits[sessionID]++
+			return
+		}
+	}
+	bh.hits[""]++
+}
+
+// blockResolvedIPs resolves the blocked name to stop access to it by IP.
+func (bh *BlocklistHandler) blockResolvedIPs(writer dns.ResponseWriter, req *dns.Msg) {
+	resolverWriter := &recordingWriter{writer: writer}
+	bh.resolver.ServeDNS(resolverWriter, req)
+	if resolverWriter.responseMsg == nil {
+		return
+	}
+
+	bh.mu.Lock()
+	defer bh.mu.Unlock()
+
+	for _, record := range resolverWriter.responseMsg.Answer {
+		// Firewall blocks IPv4 destinations only.
+		a, ok := record.(*dns.A)
+		if !ok {
+			continue
+		}
+
+		ip := a.A
+		if _, ok := bh.blockedIPs[ip.String()]; ok {
+			continue
+		}
+		remove, err := bh.trafficBlocker.BlockIPAccess(ip)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Could not block IP %s of %s", ip, req.Question[0].Name)
+			continue
+		}
+		bh.blockedIPs[ip.String()] = remove
+	}
+}
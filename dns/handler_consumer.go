@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+)
+
+// ConsumerOptions configures the consumer DNS resolver.
+type ConsumerOptions struct {
+	// Prefetch refreshes popular answers before they expire.
+	Prefetch bool
+	// LogQueries logs every query along with the connection session it was made in.
+	LogQueries bool
+}
+
+// ConsumerStats describes the state of the consumer DNS resolver.
+type ConsumerStats struct {
+	SessionID string
+	Active    bool
+	Servers   []string
+	Queries   uint64
+	Refused   uint64
+	Cache     CacheStats
+}
+
+// ConsumerResolver serves consumer DNS queries by forwarding them to the DNS servers of the tunnel,
+// answers are cached for their TTL. Queries are answered with SERVFAIL while the tunnel is not up,
+// so that they never leave through any other route.
+type ConsumerResolver struct {
+	options ConsumerOptions
+
+	mu        sync.RWMutex
+	sessionID string
+	servers   []string
+	resolver  *upstreamHandler
+	paused    bool
+	queries   uint64
+	refused   uint64
+}
+
+// NewConsumerResolver creates consumer DNS resolver, which refuses queries until it is pointed to the tunnel and resumed.
+func NewConsumerResolver(options ConsumerOptions) *ConsumerResolver {
+	return &ConsumerResolver{options: options, paused: true}
+}
+
+// Forward points the resolver to the tunnel DNS servers of the connection session.
+// Queries are forwarded once the resolver is resumed, cache of the previous session
+// is dropped, as answers of the other provider may differ.
+func (cr *ConsumerResolver) Forward(sessionID string, servers []string) error {
+	upstreams, err := NewUpstreams(servers)
+	if err != nil {
+		return err
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if cr.sessionID != sessionID {
+		cr.queries, cr.refused = 0, 0
+	}
+	cr.sessionID = sessionID
+	cr.servers = servers
+	cr.resolver = newUpstreamHandler(upstreams, cr.options.Prefetch)
+	return nil
+}
+
+// Resume starts forwarding queries to the tunnel DNS servers, it is called once the tunnel is up.
+func (cr *ConsumerResolver) Resume() {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cr.paused = false
+}
+
+// Pause makes the resolver refuse queries until it is resumed, it is called once the tunnel goes down.
+func (cr *ConsumerResolver) Pause() {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	cr.paused = true
+}
+
+// Stats returns the statistics of the current connection session.
+func (cr *ConsumerResolver) Stats() ConsumerStats {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	stats := ConsumerStats{
+		SessionID: cr.sessionID,
+		Active:    !cr.paused && cr.resolver != nil,
+		Servers:   cr.servers,
+		Queries:   cr.queries,
+		Refused:   cr.refused,
+	}
+	if cr.resolver != nil {
+		stats.Cache = cr.resolver.cache.Stats()
+	}
+	return stats
+}
+
+// ServeDNS forwards the query to the tunnel DNS servers.
+func (cr *ConsumerResolver) ServeDNS(writer dns.ResponseWriter, req *dns.Msg) {
+	cr.mu.Lock()
+	resolver, sessionID := cr.resolver, cr.sessionID
+	if cr.paused {
+		resolver = nil
+	}
+	cr.queries++
+	if resolver == nil {
+		cr.refused++
+	}
+	cr.mu.Unlock()
+
+	if resolver == nil {
+		resp := &dns.Msg{}
+		resp.SetRcode(req, dns.RcodeServerFailure)
+		writer.WriteMsg(resp)
+		return
+	}
+
+	if !cr.options.LogQueries {
+		resolver.ServeDNS(writer, req)
+		return
+	}
+
+	recorder := &recordingWriter{writer: writer}
+	resolver.ServeDNS(recorder, req)
+	logQuery(sessionID, req, recorder.responseMsg)
+	if recorder.responseMsg != nil {
+		writer.WriteMsg(recorder.responseMsg)
+	}
+}
+
+func logQuery(sessionID string, req, resp *dns.Msg) {
+	for _, q := range req.Question {
+		event := log.Info().Str("session", sessionID).Str("name", q.Name).Str("type", dns.TypeToString[q.Qtype])
+		if resp != nil {
+			event = event.Str("rcode", dns.RcodeToString[resp.Rcode]).Int("answers", len(resp.Answer))
+		}
+		event.Msg("DNS query")
+	}
+}
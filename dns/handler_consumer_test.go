@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConsumerResolver_RefusesUntilResumed(t *testing.T) {
+	server, addr, queries := newPlainServer(t, answerA("1.2.3.4", 60))
+	defer server.Shutdown()
+	resolver := NewConsumerResolver(ConsumerOptions{})
+
+	writer := &recordingWriter{writer: &udpWriter{}}
+	resolver.ServeDNS(writer, query("example.com."))
+	assert.Equal(t, dns.RcodeServerFailure, writer.responseMsg.Rcode)
+
+	assert.NoError(t, resolver.Forward("session1", []string{addr}))
+	resolver.ServeDNS(writer, query("example.com."))
+	assert.Equal(t, dns.RcodeServerFailure, writer.responseMsg.Rcode, "queries should not be forwarded until the tunnel is up")
+	assert.Equal(t, int32(0), atomic.LoadInt32(queries))
+
+	resolver.Resume()
+	resolver.ServeDNS(writer, query("example.com."))
+	resolver.ServeDNS(writer, query("example.com."))
+	assert.Equal(t, dns.RcodeSuccess, writer.responseMsg.Rcode)
+	assert.Equal(t, "1.2.3.4", writer.responseMsg.Answer[0].(*dns.A).A.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(queries))
+
+	resolver.Pause()
+	resolver.ServeDNS(writer, query("example.com."))
+	assert.Equal(t, dns.RcodeServerFailure, writer.responseMsg.Rcode)
+
+	assert.Equal(t, ConsumerStats{
+		SessionID: "session1",
+		Servers:   []string{addr},
+		Queries:   4,
+		Refused:   2,
+		Cache:     CacheStats{Entries: 1, Hits: 1, Misses: 1},
+	}, resolver.Stats())
+}
+
+func Test_ConsumerResolver_LogsQueries(t *testing.T) {
+	server, addr, _ := newPlainServer(t, answerA("1.2.3.4", 60))
+	defer server.Shutdown()
+	resolver := NewConsumerResolver(ConsumerOptions{LogQueries: true})
+	assert.NoError(t, resolver.Forward("session1", []string{addr}))
+	resolver.Resume()
+
+	writer := &recordingWriter{writer: &udpWriter{}}
+	resolver.ServeDNS(writer, query("example.com."))
+
+	assert.Equal(t, dns.RcodeSuccess, writer.responseMsg.Rcode)
+	assert.Equal(t, uint16(42), writer.responseMsg.Id)
+}
+
+func Test_ConsumerResolver_NewSessionDropsCache(t *testing.T) {
+	server, addr, queries := newPlainServer(t, answerA("1.2.3.4", 60))
+	defer server.Shutdown()
+	resolver := NewConsumerResolver(ConsumerOptions{})
+	resolver.Resume()
+
+	writer := &recordingWriter{writer: &udpWriter{}}
+	assert.NoError(t, resolver.Forward("session1", []string{addr}))
+	resolver.ServeDNS(writer, query("example.com."))
+	assert.NoError(t, resolver.Forward("session2", []string{addr}))
+	resolver.ServeDNS(writer, query("example.com."))
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(queries))
+	assert.Equal(t, uint64(1), resolver.Stats().Queries)
+	assert.Error(t, resolver.Forward("session3", []string{"not-an-ip"}))
+}
+
+func Test_UpstreamHandler_PrefetchesPopularAnswers(t *testing.T) {
+	handler := newUpstreamHandler([]Upstream{&stubUpstream{resp: answer("example.com.", "1.2.3.4", 100)}}, true)
+	now := time.Now()
+	handler.cache.now = func() time.Time { return now }
+
+	writer := &recordingWriter{writer: &udpWriter{}}
+	handler.ServeDNS(writer, query("example.com."))
+	for i := 0; i < prefetchMinHits; i++ {
+		handler.ServeDNS(writer, query("example.com."))
+	}
+	assert.Equal(t, uint64(0), handler.cache.Stats().Prefetches, "fresh answers should not be prefetched")
+
+	now = now.Add(95 * time.Second)
+	handler.ServeDNS(writer, query("example.com."))
+	assert.Equal(t, uint32(5), writer.responseMsg.Answer[0].Header().Ttl)
+	assert.Equal(t, uint64(1), handler.cache.Stats().Prefetches)
+
+	assert.Eventually(t, func() bool {
+		handler.ServeDNS(writer, query("example.com."))
+		return writer.responseMsg.Answer[0].Header().Ttl == 100
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(1), handler.cache.Stats().Prefetches)
+}
+
+func newPlainServer(t *testing.T, handler dns.Handler) (*dns.Server, string, *int32) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	var queries int32
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		atomic.AddInt32(&queries, 1)
+		handler.ServeDNS(w, req)
+	})}
+	go server.ActivateAndServe()
+
+	return server, conn.LocalAddr().String(), &queries
+}
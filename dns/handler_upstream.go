@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+)
+
+// ResolveVia creates DNS handler forwarding queries to the upstream definitions,
+// system DNS servers are used when no upstreams are defined.
+func ResolveVia(definitions []string) (dns.Handler, error) {
+	if len(definitions) == 0 {
+		return ResolveViaSystem()
+	}
+
+	upstreams, err := NewUpstreams(definitions)
+	if err != nil {
+		return nil, err
+	}
+	return ResolveViaUpstreams(upstreams), nil
+}
+
+// ResolveViaUpstreams creates DNS handler forwarding queries to the upstreams.
+// Upstreams are tried in order starting from the last one which responded,
+// answers are cached for their TTL.
+func ResolveViaUpstreams(upstreams []Upstream) dns.Handler {
+	return newUpstreamHandler(upstreams, false)
+}
+
+func newUpstreamHandler(upstreams []Upstream, prefetch bool) *upstreamHandler {
+	return &upstreamHandler{
+		upstreams: upstreams,
+		prefetch:  prefetch,
+		cache:     newCache(cacheMaxEntries),
+	}
+}
+
+type upstreamHandler struct {
+	upstreams []Upstream
+	prefetch  bool
+	cache     *cache
+
+	mu        sync.Mutex
+	preferred int
+}
+
+func (uh *upstreamHandler) ServeDNS(writer dns.ResponseWriter, req *dns.Msg) {
+	resp := uh.cache.get(req)
+	if resp == nil {
+		resp = uh.exchange(req)
+		uh.cache.set(req, resp)
+	} else if uh.prefetch && uh.cache.claimPrefetch(req) {
+		go uh.refresh(req.Copy())
+	}
+
+	if writer.LocalAddr() != nil && writer.LocalAddr().Network() == "udp" {
+		size := dns.MinMsgSize
+		if opt := req.IsEdns0(); opt != nil {
+			size = int(opt.UDPSize())
+		}
+		resp.Truncate(size)
+	}
+	writer.WriteMsg(resp)
+}
+
+// refresh replaces the cached answer of the query before it expires.
+func (uh *upstreamHandler) refresh(req *dns.Msg) {
+	uh.cache.set(req, uh.exchange(req))
+	uh.cache.release(req)
+}
+
+func (uh *upstreamHandler) exchange(req *dns.Msg) *dns.Msg {
+	uh.mu.Lock()
+	start := uh.preferred
+	uh.mu.Unlock()
+
+	var failed *dns.Msg
+	for i := range uh.upstreams {
+		index := (start + i) % len(uh.upstreams)
+		upstream := uh.upstreams[index]
+
+		resp, err := upstream.Exchange(req)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Error proxying DNS query to %s", upstream)
+			continue
+		}
+		if resp.Rcode == dns.RcodeServerFailure || resp.Rcode == dns.RcodeRefused {
+			log.Warn().Msgf("DNS upstream %s failed the query with %s", upstream, dns.RcodeToString[resp.Rcode])
+			failed = resp
+			continue
+		}
+
+		uh.mu.Lock()
+		uh.preferred = index
+		uh.mu.Unlock()
+		return resp
+	}
+
+	if failed != nil {
+		return failed
+	}
+	resp := &dns.Msg{}
+	resp.SetRcode(req, dns.RcodeServerFailure)
+	return resp
+}
@@ -173,6 +173,7 @@ func createPolicies() *policy.Repository {
 
 type trafficBlockerMock struct {
 	allowIPCalls map[string]int
+	blockedIPs   []string
 }
 
 func (tbn *trafficBlockerMock) Setup() error { return nil }
@@ -198,3 +199,21 @@ func (tbn *trafficBlockerMock) AllowIPAccess(ip net.IP) (firewall.IncomingRuleRe
 		return nil
 	}, nil
 }
+
+func (tbn *trafficBlockerMock) ApplyBlocklist(net.IPNet) (firewall.IncomingRuleRemove, error) {
+	return nil, nil
+}
+
+func (tbn *trafficBlockerMock) BlockIPAccess(ip net.IP) (firewall.IncomingRuleRemove, error) {
+	tbn.blockedIPs = append(tbn.blockedIPs, ip.String())
+
+	return func() error {
+		for i, blocked := range tbn.blockedIPs {
+			if blocked == ip.String() {
+				tbn.blockedIPs = append(tbn.blockedIPs[:i], tbn.blockedIPs[i+1:]...)
+				break
+			}
+		}
+		return nil
+	}, nil
+}
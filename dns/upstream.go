@@ -0,0 +1,184 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+)
+
+const (
+	upstreamPrefixDoH = "doh:"
+	upstreamPrefixDoT = "dot:"
+
+	defaultPlainPort = "53"
+	defaultDoTPort   = "853"
+
+	upstreamTimeout = 5 * time.Second
+)
+
+// Upstream is a resolver DNS queries are forwarded to.
+type Upstream interface {
+	Exchange(req *dns.Msg) (*dns.Msg, error)
+	String() string
+}
+
+// IsEncryptedUpstream tells whether the definition selects DNS-over-HTTPS or DNS-over-TLS upstream.
+func IsEncryptedUpstream(definition string) bool {
+	return strings.HasPrefix(definition, upstreamPrefixDoH) || strings.HasPrefix(definition, upstreamPrefixDoT)
+}
+
+// ValidateUpstream checks the upstream definition without resolving it.
+// Upstreams are defined as doh:<https URL>, dot:<host>[:port] or a plain resolver IP.
+func ValidateUpstream(definition string) error {
+	_, err := parseUpstream(definition)
+	return err
+}
+
+// NewUpstream creates the upstream of the definition. Host names of the encrypted upstreams
+// are resolved right away, so that the upstream doesn't depend on the DNS it serves.
+func NewUpstream(definition string) (Upstream, error) {
+	return newUpstream(definition, &tls.Config{})
+}
+
+// NewUpstreams creates upstreams of the definitions in the given order.
+func NewUpstreams(definitions []string) ([]Upstream, error) {
+	upstreams := make([]Upstream, 0, len(definitions))
+	for _, definition := range definitions {
+		upstream, err := NewUpstream(definition)
+		if err != nil {
+			return nil, err
+		}
+		upstreams = append(upstreams, upstream)
+	}
+	return upstreams, nil
+}
+
+type upstreamDef struct {
+	definition string
+	kind       string
+	host       string
+	port       string
+	url        *url.URL
+}
+
+func parseUpstream(definition string) (upstreamDef, error) {
+	def := upstreamDef{definition: definition}
+	switch {
+	case strings.HasPrefix(definition, upstreamPrefixDoH):
+		u, err := url.Parse(strings.TrimPrefix(definition, upstreamPrefixDoH))
+		if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+			return def, errors.Errorf("invalid DNS-over-HTTPS upstream %q, expected doh:https://<host>/<path>", definition)
+		}
+		def.kind, def.url, def.host, def.port = upstreamPrefixDoH, u, u.Hostname(), u.Port()
+		if def.port == "" {
+			def.port = "443"
+		}
+	case strings.HasPrefix(definition, upstreamPrefixDoT):
+		host, port, err := splitHostPort(strings.TrimPrefix(definition, upstreamPrefixDoT), defaultDoTPort)
+		if err != nil {
+			return def, errors.Wrapf(err, "invalid DNS-over-TLS upstream %q, expected dot:<host>[:port]", definition)
+		}
+		def.kind, def.host, def.port = upstreamPrefixDoT, host, port
+	default:
+		host, port, err := splitHostPort(definition, defaultPlainPort)
+		if err != nil || net.ParseIP(host) == nil {
+			return def, errors.Errorf("invalid DNS upstream %q, expected doh:<https URL>, dot:<host>[:port] or resolver IP", definition)
+		}
+		def.host, def.port = host, port
+	}
+	return def, nil
+}
+
+func splitHostPort(value, defaultPort string) (string, string, error) {
+	if value == "" {
+		return "", "", errors.New("host is empty")
+	}
+	if host, port, err := net.SplitHostPort(value); err == nil {
+		return host, port, nil
+	}
+	if strings.Count(value, ":") > 1 && net.ParseIP(strings.Trim(value, "[]")) == nil {
+		return "", "", errors.Errorf("invalid host %q", value)
+	}
+	return strings.Trim(value, "[]"), defaultPort, nil
+}
+
+func newUpstream(definition string, tlsConfig *tls.Config) (Upstream, error) {
+	def, err := parseUpstream(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := resolveBootstrap(def.host, def.port)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not resolve DNS upstream %q", definition)
+	}
+
+	switch def.kind {
+	case upstreamPrefixDoH:
+		return newDoHUpstream(def, addrs, tlsConfig), nil
+	case upstreamPrefixDoT:
+		return newDoTUpstream(def, addrs, tlsConfig), nil
+	default:
+		return &plainUpstream{
+			addr:   addrs[0],
+			client: &dns.Client{Timeout: upstreamTimeout},
+		}, nil
+	}
+}
+
+// resolveBootstrap resolves the upstream host to addresses using the resolvers configured at the moment.
+func resolveBootstrap(host, port string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{net.JoinHostPort(host, port)}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]string, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.JoinHostPort(ip, port)
+	}
+	return addrs, nil
+}
+
+type plainUpstream struct {
+	addr   string
+	client *dns.Client
+}
+
+func (u *plainUpstream) Exchange(req *dns.Msg) (*dns.Msg, error) {
+	resp, _, err := u.client.Exchange(req, u.addr)
+	return resp, err
+}
+
+func (u *plainUpstream) String() string {
+	return u.addr
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+)
+
+// dohMediaType is the DNS wire format media type of RFC 8484.
+const dohMediaType = "application/dns-message"
+
+// dohUpstream sends queries as POST requests of RFC 8484 to the pinned addresses of the server.
+type dohUpstream struct {
+	url    string
+	client *http.Client
+}
+
+func newDoHUpstream(def upstreamDef, addrs []string, tlsConfig *tls.Config) *dohUpstream {
+	dialer := &net.Dialer{Timeout: upstreamTimeout}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (conn net.Conn, err error) {
+			for _, addr := range addrs {
+				if conn, err = dialer.DialContext(ctx, network, addr); err == nil {
+					return conn, nil
+				}
+			}
+			return nil, err
+		},
+		TLSClientConfig:   tlsConfig.Clone(),
+		ForceAttemptHTTP2: true,
+	}
+
+	return &dohUpstream{
+		url:    def.url.String(),
+		client: &http.Client{Transport: transport, Timeout: upstreamTimeout},
+	}
+}
+
+func (u *dohUpstream) Exchange(req *dns.Msg) (*dns.Msg, error) {
+	// Zero ID makes the queries cacheable by HTTP caches, as recommended by RFC 8484.
+	query := req.Copy()
+	query.Id = 0
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not pack DNS query")
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, u.url, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", dohMediaType)
+	httpReq.Header.Set("Accept", dohMediaType)
+
+	httpResp, err := u.client.Do(httpReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "DNS-over-HTTPS request failed")
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("DNS-over-HTTPS server responded with %s", httpResp.Status)
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, httpResp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read DNS-over-HTTPS response")
+	}
+
+	resp := &dns.Msg{}
+	if err := resp.Unpack(body); err != nil {
+		return nil, errors.Wrap(err, "could not unpack DNS-over-HTTPS response")
+	}
+	resp.Id = req.Id
+	return resp, nil
+}
+
+func (u *dohUpstream) String() string {
+	return upstreamPrefixDoH + u.url
+}
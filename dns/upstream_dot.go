@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"crypto/tls"
+
+	"github.com/miekg/dns"
+)
+
+// dotUpstream sends queries over TLS of RFC 7858 to the pinned addresses of the server.
+type dotUpstream struct {
+	definition string
+	addrs      []string
+	client     *dns.Client
+}
+
+func newDoTUpstream(def upstreamDef, addrs []string, tlsConfig *tls.Config) *dotUpstream {
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.ServerName = def.host
+
+	return &dotUpstream{
+		definition: def.definition,
+		addrs:      addrs,
+		client: &dns.Client{
+			Net:       "tcp-tls",
+			TLSConfig: tlsConfig,
+			Timeout:   upstreamTimeout,
+		},
+	}
+}
+
+func (u *dotUpstream) Exchange(req *dns.Msg) (resp *dns.Msg, err error) {
+	for _, addr := range u.addrs {
+		if resp, _, err = u.client.Exchange(req, addr); err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+func (u *dotUpstream) String() string {
+	return u.definition
+}
@@ -0,0 +1,241 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dns
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateUpstream(t *testing.T) {
+	for _, valid := range []string{
+		"doh:https://cloudflare-dns.com/dns-query",
+		"doh:https://1.1.1.1:8443/dns-query",
+		"dot:dns.quad9.net",
+		"dot:1.1.1.1:8853",
+		"dot:[2606:4700::1111]:853",
+		"dot:2606:4700::1111",
+		"8.8.8.8",
+		"8.8.8.8:5353",
+	} {
+		assert.NoError(t, ValidateUpstream(valid), valid)
+	}
+
+	for _, invalid := range []string{
+		"doh:http://cloudflare-dns.com/dns-query",
+		"doh:cloudflare-dns.com",
+		"dot:",
+		"dns.google",
+		"",
+	} {
+		assert.Error(t, ValidateUpstream(invalid), invalid)
+	}
+}
+
+func Test_DoHUpstream_Exchange(t *testing.T) {
+	server, queries := newDoHServer(t, answerA("1.2.3.4", 60))
+	defer server.Close()
+
+	upstream, err := newUpstream("doh:"+server.URL+"/dns-query", tlsConfigOf(server))
+	assert.NoError(t, err)
+
+	resp, err := upstream.Exchange(query("example.com."))
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(42), resp.Id)
+	assert.Equal(t, "1.2.3.4", resp.Answer[0].(*dns.A).A.String())
+	assert.Equal(t, int32(1), atomic.LoadInt32(queries))
+}
+
+func Test_DoTUpstream_Exchange(t *testing.T) {
+	cert := httptest.NewTLSServer(http.NotFoundHandler())
+	defer cert.Close()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", cert.TLS)
+	assert.NoError(t, err)
+	server := &dns.Server{Listener: listener, Net: "tcp-tls", Handler: answerA("5.6.7.8", 60)}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	tlsConfig := tlsConfigOf(cert)
+	tlsConfig.ServerName = "example.com"
+	upstream, err := newUpstream("dot:"+listener.Addr().String(), tlsConfig)
+	assert.NoError(t, err)
+	// The stand-in certificate is issued for 127.0.0.1.
+	upstream.(*dotUpstream).client.TLSConfig.ServerName = "127.0.0.1"
+
+	resp, err := upstream.Exchange(query("example.com."))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "5.6.7.8", resp.Answer[0].(*dns.A).A.String())
+}
+
+func Test_ResolveViaUpstreams_FailsOver(t *testing.T) {
+	failing := &stubUpstream{err: assert.AnError}
+	refusing := &stubUpstream{resp: &dns.Msg{MsgHdr: dns.MsgHdr{Rcode: dns.RcodeRefused}}}
+	working := &stubUpstream{resp: answer("example.com.", "1.2.3.4", 0)}
+	handler := ResolveViaUpstreams([]Upstream{failing, refusing, working})
+
+	writer := &recordingWriter{writer: &udpWriter{}}
+	handler.ServeDNS(writer, query("example.com."))
+	handler.ServeDNS(writer, query("example.com."))
+
+	assert.Equal(t, dns.RcodeSuccess, writer.responseMsg.Rcode)
+	assert.Equal(t, 1, failing.calls)
+	assert.Equal(t, 1, refusing.calls)
+	assert.Equal(t, 2, working.calls, "the upstream which responded should be tried first")
+}
+
+func Test_ResolveViaUpstreams_AllFailed(t *testing.T) {
+	handler := ResolveViaUpstreams([]Upstream{&stubUpstream{err: assert.AnError}})
+
+	writer := &recordingWriter{writer: &udpWriter{}}
+	handler.ServeDNS(writer, query("example.com."))
+
+	assert.Equal(t, dns.RcodeServerFailure, writer.responseMsg.Rcode)
+	assert.Equal(t, uint16(42), writer.responseMsg.Id)
+}
+
+func Test_ResolveViaUpstreams_CachesAnswers(t *testing.T) {
+	server, queries := newDoHServer(t, answerA("1.2.3.4", 60))
+	defer server.Close()
+	upstream, err := newUpstream("doh:"+server.URL+"/dns-query", tlsConfigOf(server))
+	assert.NoError(t, err)
+
+	handler := ResolveViaUpstreams([]Upstream{upstream}).(*upstreamHandler)
+	now := time.Now()
+	handler.cache.now = func() time.Time { return now }
+
+	writer := &recordingWriter{writer: &udpWriter{}}
+	handler.ServeDNS(writer, query("example.com."))
+	now = now.Add(20 * time.Second)
+	handler.ServeDNS(writer, query("EXAMPLE.com."))
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(queries))
+	assert.Equal(t, uint32(40), writer.responseMsg.Answer[0].Header().Ttl)
+
+	now = now.Add(time.Minute)
+	handler.ServeDNS(writer, query("example.com."))
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(queries))
+}
+
+func Test_Cache_SkipsFailures(t *testing.T) {
+	c := newCache(1)
+	req := query("example.com.")
+	failed := &dns.Msg{}
+	failed.SetRcode(req, dns.RcodeServerFailure)
+
+	c.set(req, failed)
+	assert.Nil(t, c.get(req))
+
+	c.set(req, answer("example.com.", "1.2.3.4", 0))
+	assert.Nil(t, c.get(req), "zero TTL answers should not be cached")
+
+	c.set(req, answer("example.com.", "1.2.3.4", 10))
+	c.set(query("other.com."), answer("other.com.", "1.2.3.5", 10))
+	assert.Len(t, c.entries, 1)
+}
+
+func newDoHServer(t *testing.T, handler dns.Handler) (*httptest.Server, *int32) {
+	var queries int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&queries, 1)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, dohMediaType, r.Header.Get("Content-Type"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		req := &dns.Msg{}
+		assert.NoError(t, req.Unpack(body))
+		assert.Equal(t, uint16(0), req.Id)
+
+		writer := &recordingWriter{}
+		handler.ServeDNS(writer, req)
+		packed, err := writer.responseMsg.Pack()
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", dohMediaType)
+		w.Write(packed)
+	}))
+	return server, &queries
+}
+
+func tlsConfigOf(server *httptest.Server) *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	return &tls.Config{RootCAs: pool}
+}
+
+func answerA(ip string, ttl uint32) dns.HandlerFunc {
+	return func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := answer(req.Question[0].Name, ip, ttl)
+		resp.SetReply(req)
+		w.WriteMsg(resp)
+	}
+}
+
+func answer(name, ip string, ttl uint32) *dns.Msg {
+	return &dns.Msg{
+		Answer: []dns.RR{&dns.A{
+			Hdr: dns.RR_Header{Name: strings.ToLower(name), Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+			A:   net.ParseIP(ip),
+		}},
+	}
+}
+
+func query(name string) *dns.Msg {
+	req := &dns.Msg{}
+	req.SetQuestion(name, dns.TypeA)
+	req.Id = 42
+	return req
+}
+
+type stubUpstream struct {
+	resp  *dns.Msg
+	err   error
+	calls int
+}
+
+func (s *stubUpstream) Exchange(req *dns.Msg) (*dns.Msg, error) {
+	s.calls++
+	return s.resp, s.err
+}
+
+func (s *stubUpstream) String() string {
+	return "stub"
+}
+
+type udpWriter struct {
+	dns.ResponseWriter
+}
+
+func (w *udpWriter) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53}
+}
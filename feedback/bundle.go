@@ -0,0 +1,263 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package feedback
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/state/event"
+	"github.com/mysteriumnetwork/node/metadata"
+	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/pkg/errors"
+)
+
+// maxConnectivityEntries limits the number of connectivity status entries included in the bundle.
+const maxConnectivityEntries = 100
+
+const redacted = "[redacted]"
+
+type configSource interface {
+	GetEffectiveConfig() map[string]interface{}
+}
+
+type stateProvider interface {
+	GetState() event.State
+}
+
+type connectivityStatusProvider interface {
+	GetAllStatusEntries() []connectivity.StatusEntry
+}
+
+// BundleOptions controls the content of a diagnostics bundle.
+type BundleOptions struct {
+	// Mask replaces IP addresses and secret looking values everywhere in the bundle.
+	Mask bool
+}
+
+// Bundler builds diagnostics bundles locally, without sending anything to the feedback service.
+type Bundler struct {
+	logCollector logCollector
+	config       configSource
+	state        stateProvider
+	statuses     connectivityStatusProvider
+	firewall     func() (string, error)
+	now          func() time.Time
+}
+
+// NewBundler constructs a new Bundler
+func NewBundler(
+	logCollector logCollector,
+	config configSource,
+	state stateProvider,
+	statuses connectivityStatusProvider,
+) *Bundler {
+	return &Bundler{
+		logCollector: logCollector,
+		config:       config,
+		state:        state,
+		statuses:     statuses,
+		firewall:     firewallState,
+		now:          time.Now,
+	}
+}
+
+// BuildInfo describes the node binary which produced the bundle.
+type BuildInfo struct {
+	Version     string    `json:"version"`
+	Commit      string    `json:"commit"`
+	Branch      string    `json:"branch"`
+	BuildNumber string    `json:"build_number"`
+	GoVersion   string    `json:"go_version"`
+	OS          string    `json:"os"`
+	Arch        string    `json:"arch"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Write streams the diagnostics bundle as a ZIP archive.
+// Sections which can not be collected are replaced with an error note, so that the rest of the bundle is still delivered.
+func (b *Bundler) Write(w io.Writer, opts BundleOptions) error {
+	bundle := &bundleWriter{archive: zip.NewWriter(w), mask: opts.Mask}
+
+	bundle.json("build.json", BuildInfo{
+		Version:     metadata.VersionAsString(),
+		Commit:      metadata.BuildCommit,
+		Branch:      metadata.BuildBranch,
+		BuildNumber: metadata.BuildNumber,
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		CreatedAt:   b.now().UTC(),
+	})
+	bundle.json("config.json", redactConfig(b.config.GetEffectiveConfig()))
+
+	state := b.state.GetState()
+	bundle.json("state.json", state)
+	bundle.json("nat.json", state.NATStatus)
+
+	entries := b.statuses.GetAllStatusEntries()
+	if len(entries) > maxConnectivityEntries {
+		entries = entries[:maxConnectivityEntries]
+	}
+	bundle.json("connectivity.json", entries)
+
+	firewall, err := b.firewall()
+	bundle.text("firewall.txt", firewall, err)
+
+	b.writeLogs(bundle)
+
+	if bundle.err != nil {
+		return bundle.err
+	}
+	return errors.Wrap(bundle.archive.Close(), "could not finish diagnostics bundle")
+}
+
+func (b *Bundler) writeLogs(bundle *bundleWriter) {
+	archivePath, err := b.logCollector.Archive()
+	if err != nil {
+		bundle.text("logs/error.txt", "", err)
+		return
+	}
+
+	logs, err := zip.OpenReader(archivePath)
+	if err != nil {
+		bundle.text("logs/error.txt", "", errors.Wrap(err, "could not open log archive"))
+		return
+	}
+	defer logs.Close()
+
+	for _, file := range logs.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		content, err := file.Open()
+		if err != nil {
+			bundle.text(path.Join("logs", file.Name+".error.txt"), "", err)
+			continue
+		}
+		bundle.copy(path.Join("logs", file.Name), content)
+		content.Close()
+	}
+}
+
+// bundleWriter writes bundle entries, remembering the first write error.
+type bundleWriter struct {
+	archive *zip.Writer
+	mask    bool
+	err     error
+}
+
+func (bw *bundleWriter) create(name string) io.Writer {
+	if bw.err != nil {
+		return nil
+	}
+	w, err := bw.archive.Create(name)
+	if err != nil {
+		bw.err = errors.Wrapf(err, "could not add %s to diagnostics bundle", name)
+		return nil
+	}
+	return w
+}
+
+func (bw *bundleWriter) write(w io.Writer, data []byte) {
+	if bw.mask {
+		data = maskSensitive(data)
+	}
+	if _, err := w.Write(data); err != nil && bw.err == nil {
+		bw.err = errors.Wrap(err, "could not write diagnostics bundle")
+	}
+}
+
+func (bw *bundleWriter) json(name string, value interface{}) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		bw.text(strings.TrimSuffix(name, ".json")+".error.txt", "", err)
+		return
+	}
+	if w := bw.create(name); w != nil {
+		bw.write(w, data)
+	}
+}
+
+func (bw *bundleWriter) text(name, content string, err error) {
+	if err != nil {
+		content += fmt.Sprintf("\nerror: %v\n", err)
+	}
+	if w := bw.create(name); w != nil {
+		bw.write(w, []byte(content))
+	}
+}
+
+// copy writes content line by line, so that large log files are masked without loading them into memory.
+func (bw *bundleWriter) copy(name string, content io.Reader) {
+	w := bw.create(name)
+	if w == nil {
+		return
+	}
+	reader := bufio.NewReader(content)
+	for bw.err == nil {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			bw.write(w, line)
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			bw.write(w, []byte(fmt.Sprintf("\nerror: %v\n", err)))
+			return
+		}
+	}
+}
+
+var sensitiveKeys = []string{"passphrase", "password", "secret", "token", "private"}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactConfig returns a copy of the configuration with values of secret looking keys replaced.
+func redactConfig(config map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			result[key] = redactConfig(v)
+		default:
+			if isSensitiveKey(key) && value != nil && value != "" {
+				result[key] = redacted
+			} else {
+				result[key] = value
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package feedback
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/state/event"
+	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLogCollector struct {
+	path string
+	err  error
+}
+
+func (m *mockLogCollector) Archive() (string, error) {
+	return m.path, m.err
+}
+
+type mockConfig map[string]interface{}
+
+func (m mockConfig) GetEffectiveConfig() map[string]interface{} {
+	return m
+}
+
+type mockState event.State
+
+func (m mockState) GetState() event.State {
+	return event.State(m)
+}
+
+type mockStatuses []connectivity.StatusEntry
+
+func (m mockStatuses) GetAllStatusEntries() []connectivity.StatusEntry {
+	return m
+}
+
+func newLogArchive(t *testing.T, dir string) string {
+	archivePath := filepath.Join(dir, "mysterium-node.log.zip")
+	file, err := os.Create(archivePath)
+	require.NoError(t, err)
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	w, err := archive.Create("mysterium-node.log")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("Connected to 203.0.113.5:1194 from 2001:db8::1 at 12:30:01\nLogin token=abc123 accepted\n"))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+	return archivePath
+}
+
+func newTestBundler(t *testing.T, logs logCollector) *Bundler {
+	bundler := NewBundler(
+		logs,
+		mockConfig{
+			"identity":  map[string]interface{}{"passphrase": "secret-pass"},
+			"log-level": "debug",
+		},
+		mockState{NATStatus: event.NATStatus{Status: "failure", Error: "no route to 198.51.100.7"}},
+		mockStatuses{{SessionID: "s1", Message: "ok"}},
+	)
+	bundler.firewall = func() (string, error) { return "-P INPUT ACCEPT", nil }
+	bundler.now = func() time.Time { return time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC) }
+	return bundler
+}
+
+func readBundle(t *testing.T, data []byte) map[string]string {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		content, err := file.Open()
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(content)
+		require.NoError(t, err)
+		content.Close()
+		files[file.Name] = string(body)
+	}
+	return files
+}
+
+func TestBundler_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	err = newTestBundler(t, &mockLogCollector{path: newLogArchive(t, dir)}).Write(&out, BundleOptions{})
+	require.NoError(t, err)
+
+	files := readBundle(t, out.Bytes())
+	assert.Contains(t, files, "build.json")
+	assert.Contains(t, files, "state.json")
+	assert.Equal(t, "-P INPUT ACCEPT", files["firewall.txt"])
+	assert.Contains(t, files["logs/mysterium-node.log"], "203.0.113.5")
+	assert.Contains(t, files["nat.json"], "198.51.100.7")
+
+	var config map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(files["config.json"]), &config))
+	assert.Equal(t, map[string]interface{}{"passphrase": redacted}, config["identity"])
+	assert.Equal(t, "debug", config["log-level"])
+
+	var statuses []connectivity.StatusEntry
+	require.NoError(t, json.Unmarshal([]byte(files["connectivity.json"]), &statuses))
+	assert.Len(t, statuses, 1)
+}
+
+func TestBundler_Write_Masked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundle")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	err = newTestBundler(t, &mockLogCollector{path: newLogArchive(t, dir)}).Write(&out, BundleOptions{Mask: true})
+	require.NoError(t, err)
+
+	files := readBundle(t, out.Bytes())
+	assert.Equal(t,
+		"Connected to x.x.x.x:1194 from x:x:x:x at 12:30:01\nLogin token=[redacted] accepted\n",
+		files["logs/mysterium-node.log"],
+	)
+	assert.NotContains(t, files["nat.json"], "198.51.100.7")
+}
+
+func TestBundler_Write_KeepsGoingWhenLogsAreUnavailable(t *testing.T) {
+	var out bytes.Buffer
+	err := newTestBundler(t, &mockLogCollector{err: errors.New("file logging is disabled")}).Write(&out, BundleOptions{})
+	require.NoError(t, err)
+
+	files := readBundle(t, out.Bytes())
+	assert.Contains(t, files["logs/error.txt"], "file logging is disabled")
+	assert.Contains(t, files, "config.json")
+}
+
+func TestMaskSensitive(t *testing.T) {
+	for input, expected := range map[string]string{
+		"peer 10.0.0.1:51820":                "peer x.x.x.x:51820",
+		"v1.2.3 released":                    "v1.2.3 released",
+		"at 2020-06-01T12:30:01Z":            "at 2020-06-01T12:30:01Z",
+		"remote [fe80::1]:53":                "remote [x:x:x:x]:53",
+		`{"password":"hunter2"}`:             `{"password":"[redacted]"}`,
+		"Authorization: Bearer eyJhbGciOi.x": "Authorization: Bearer [redacted]",
+	} {
+		assert.Equal(t, expected, string(maskSensitive([]byte(input))), input)
+	}
+}
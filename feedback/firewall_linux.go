@@ -0,0 +1,37 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package feedback
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/firewall/iptables"
+)
+
+func firewallState() (string, error) {
+	var state strings.Builder
+	for _, table := range []string{"filter", "nat"} {
+		rules, err := iptables.Exec("-t", table, "-S")
+		if err != nil {
+			return state.String(), err
+		}
+		fmt.Fprintf(&state, "# iptables -t %s -S\n%s\n\n", table, strings.Join(rules, "\n"))
+	}
+	return state.String(), nil
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package feedback
+
+import (
+	"net"
+	"regexp"
+)
+
+var (
+	ipv4Pattern   = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern   = regexp.MustCompile(`(?i)[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}`)
+	secretPattern = regexp.MustCompile(`(?i)((?:passphrase|password|secret|token)["']?\s*[:=]\s*["']?)[^\s"',}]+`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`)
+)
+
+// maskSensitive replaces IP addresses and secret looking values in the given text.
+func maskSensitive(data []byte) []byte {
+	data = ipv4Pattern.ReplaceAllFunc(data, maskIP("x.x.x.x"))
+	data = ipv6Pattern.ReplaceAllFunc(data, maskIP("x:x:x:x"))
+	data = secretPattern.ReplaceAll(data, []byte("${1}"+redacted))
+	return bearerPattern.ReplaceAll(data, []byte("${1}"+redacted))
+}
+
+func maskIP(replacement string) func([]byte) []byte {
+	return func(match []byte) []byte {
+		if net.ParseIP(string(match)) == nil {
+			return match
+		}
+		return []byte(replacement)
+	}
+}
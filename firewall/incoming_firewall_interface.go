@@ -28,6 +28,10 @@ type IncomingTrafficFirewall interface {
 	BlockIncomingTraffic(network net.IPNet) (IncomingRuleRemove, error)
 	AllowURLAccess(rawURLs ...string) (IncomingRuleRemove, error)
 	AllowIPAccess(ip net.IP) (IncomingRuleRemove, error)
+	// BlockIPAccess blocks the IP for all services, it is unblocked once every returned remove function is called.
+	BlockIPAccess(ip net.IP) (IncomingRuleRemove, error)
+	// ApplyBlocklist checks traffic forwarded from the network against IPs blocked with BlockIPAccess.
+	ApplyBlocklist(network net.IPNet) (IncomingRuleRemove, error)
 }
 
 // IncomingRuleRemove type defines function for removal of created rule.
@@ -21,6 +21,7 @@ import (
 	"net"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mysteriumnetwork/node/firewall/ipset"
@@ -31,10 +32,17 @@ import (
 const (
 	incomingFirewallChain = "MYST_PROVIDER_FIREWALL"
 	incomingFirewallIpset = "myst-provider-dst-whitelist"
+
+	incomingBlocklistChain = "MYST_PROVIDER_BLOCKLIST"
+	incomingBlocklistIpset = "myst-provider-dst-blocklist"
 )
 
 // incomingFirewallIptables allows incoming traffic blocking in IP granularity.
-type incomingFirewallIptables struct{}
+type incomingFirewallIptables struct {
+	lock sync.Mutex
+	// blockedIPs counts the blocks of every IP in the blocklist ipset, which is shared by all services.
+	blockedIPs map[string]int
+}
 
 func (ibi *incomingFirewallIptables) Setup() error {
 	if err := ibi.checkIpsetVersion(); err != nil {
@@ -45,22 +53,41 @@ func (ibi *incomingFirewallIptables) Setup() error {
 	if err := ibi.cleanupStaleRules(); err != nil {
 		return err
 	}
-	ipset.Exec(ipset.OpDelete(incomingFirewallIpset))
+	// Blocked IPs don't expire, they are reference counted and unblocked once the last service blocking them stops.
+	timeouts := map[string]time.Duration{
+		incomingFirewallIpset:  24 * time.Hour,
+		incomingBlocklistIpset: 0,
+	}
+	for _, set := range []string{incomingFirewallIpset, incomingBlocklistIpset} {
+		ipset.Exec(ipset.OpDelete(set))
 
-	op := ipset.OpCreate(incomingFirewallIpset, ipset.SetTypeHashIP, 24*time.Hour, nil, 0)
-	if _, err := ipset.Exec(op); err != nil {
+		op := ipset.OpCreate(set, ipset.SetTypeHashIP, timeouts[set], nil, 0)
+		if _, err := ipset.Exec(op); err != nil {
+			return err
+		}
+	}
+	ibi.lock.Lock()
+	ibi.blockedIPs = make(map[string]int)
+	ibi.lock.Unlock()
+
+	if err := ibi.setupFirewallChain(); err != nil {
 		return err
 	}
-	return ibi.setupFirewallChain()
+	return ibi.setupBlocklistChain()
 }
 
 func (ibi *incomingFirewallIptables) Teardown() {
 	if err := ibi.cleanupStaleRules(); err != nil {
 		log.Warn().Err(err).Msg("Error cleaning up iptables rules, you might want to do it yourself")
 	}
-	if errOutput, err := ipset.Exec(ipset.OpDelete(incomingFirewallIpset)); err != nil {
-		log.Warn().Err(err).Msgf("Error deleting ipset table. %s", strings.Join(errOutput, ""))
+	for _, set := range []string{incomingFirewallIpset, incomingBlocklistIpset} {
+		if errOutput, err := ipset.Exec(ipset.OpDelete(set)); err != nil {
+			log.Warn().Err(err).Msgf("Error deleting ipset table. %s", strings.Join(errOutput, ""))
+		}
 	}
+	ibi.lock.Lock()
+	ibi.blockedIPs = make(map[string]int)
+	ibi.lock.Unlock()
 }
 
 func (ibi *incomingFirewallIptables) BlockIncomingTraffic(network net.IPNet) (IncomingRuleRemove, error) {
@@ -115,6 +142,62 @@ func (ibi *incomingFirewallIptables) AllowIPAccess(ip net.IP) (IncomingRuleRemov
 	}, nil
 }
 
+// BlockIPAccess rejects forwarded traffic to the IP. The IP stays blocked until every caller blocking it removes the rule.
+func (ibi *incomingFirewallIptables) BlockIPAccess(ip net.IP) (IncomingRuleRemove, error) {
+	ibi.lock.Lock()
+	defer ibi.lock.Unlock()
+
+	if ibi.blockedIPs == nil {
+		ibi.blockedIPs = make(map[string]int)
+	}
+	if ibi.blockedIPs[ip.String()] == 0 {
+		if _, err := ipset.Exec(ipset.OpIPAdd(incomingBlocklistIpset, ip, true)); err != nil {
+			return nil, err
+		}
+	}
+	ibi.blockedIPs[ip.String()]++
+
+	var once sync.Once
+	return func() (err error) {
+		once.Do(func() {
+			err = ibi.unblockIPAccess(ip)
+		})
+		return err
+	}, nil
+}
+
+func (ibi *incomingFirewallIptables) unblockIPAccess(ip net.IP) error {
+	ibi.lock.Lock()
+	defer ibi.lock.Unlock()
+
+	count, ok := ibi.blockedIPs[ip.String()]
+	if !ok {
+		return nil
+	}
+	if count > 1 {
+		ibi.blockedIPs[ip.String()] = count - 1
+		return nil
+	}
+
+	delete(ibi.blockedIPs, ip.String())
+	_, err := ipset.Exec(ipset.OpIPRemove(incomingBlocklistIpset, ip))
+	return err
+}
+
+// ApplyBlocklist rejects traffic forwarded from the network to blocked IPs.
+func (ibi *incomingFirewallIptables) ApplyBlocklist(network net.IPNet) (IncomingRuleRemove, error) {
+	remover, err := iptables.AddRuleWithRemoval(
+		iptables.InsertAt("FORWARD", 1).RuleSpec("-s", network.String(), "-j", incomingBlocklistChain),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		remover()
+		return nil
+	}, nil
+}
+
 func (ibi *incomingFirewallIptables) checkIpsetVersion() error {
 	output, err := ipset.Exec(ipset.OpVersion())
 	if err != nil {
@@ -145,6 +228,17 @@ func (ibi *incomingFirewallIptables) setupFirewallChain() error {
 	return nil
 }
 
+func (ibi *incomingFirewallIptables) setupBlocklistChain() error {
+	// Add chain
+	if _, err := iptables.Exec("-N", incomingBlocklistChain); err != nil {
+		return err
+	}
+
+	// Append rule - packets going to blocklisted destination IPs are rejected
+	_, err := iptables.Exec("-A", incomingBlocklistChain, "-m", "set", "--match-set", incomingBlocklistIpset, "dst", "-j", "REJECT")
+	return err
+}
+
 func (ibi *incomingFirewallIptables) cleanupStaleRules() error {
 	// List rules
 	rules, err := iptables.Exec("-S", "FORWARD")
@@ -153,7 +247,7 @@ func (ibi *incomingFirewallIptables) cleanupStaleRules() error {
 	}
 	for _, rule := range rules {
 		// detect if any references exist in FORWARD chain like -j MYST_PROVIDER_FIREWALL
-		if strings.HasSuffix(rule, incomingFirewallChain) {
+		if strings.HasSuffix(rule, incomingFirewallChain) || strings.HasSuffix(rule, incomingBlocklistChain) {
 			deleteRule := strings.Replace(rule, "-A", "-D", 1)
 			deleteRuleArgs := strings.Split(deleteRule, " ")
 			if _, err := iptables.Exec(deleteRuleArgs...); err != nil {
@@ -162,21 +256,25 @@ func (ibi *incomingFirewallIptables) cleanupStaleRules() error {
 		}
 	}
 
-	// List chain rules
-	if _, err := iptables.Exec("-L", incomingFirewallChain); err != nil {
-		// error means no such chain - log error just in case and bail out
-		log.Info().Err(err).Msg("[setup] Got error while listing kill switch chain rules. Probably nothing to worry about")
-		return nil
-	}
+	for _, chain := range []string{incomingFirewallChain, incomingBlocklistChain} {
+		// List chain rules
+		if _, err := iptables.Exec("-L", chain); err != nil {
+			// error means no such chain - log error just in case and skip it
+			log.Info().Err(err).Msg("[setup] Got error while listing kill switch chain rules. Probably nothing to worry about")
+			continue
+		}
 
-	// Remove chain rules
-	if _, err := iptables.Exec("-F", incomingFirewallChain); err != nil {
-		return err
-	}
+		// Remove chain rules
+		if _, err := iptables.Exec("-F", chain); err != nil {
+			return err
+		}
 
-	// Remove chain
-	_, err = iptables.Exec("-X", incomingFirewallChain)
-	return err
+		// Remove chain
+		if _, err := iptables.Exec("-X", chain); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 var _ IncomingTrafficFirewall = &incomingFirewallIptables{}
@@ -55,6 +55,10 @@ func Test_incomingFirewallIptables_Setup(t *testing.T) {
 	assert.True(t, mockedIptables.VerifyCalledWithArgs("-N MYST_PROVIDER_FIREWALL"))
 	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FIREWALL -m set --match-set myst-provider-dst-whitelist dst -j ACCEPT"))
 	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_FIREWALL -j REJECT"))
+	assert.True(t, mockedIpset.VerifyCalledWithArgs("create myst-provider-dst-blocklist hash:ip"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-N MYST_PROVIDER_BLOCKLIST"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-A MYST_PROVIDER_BLOCKLIST -m set --match-set myst-provider-dst-blocklist dst -j REJECT"))
+	assert.False(t, mockedIptables.VerifyCalledWithArgs("-I FORWARD 1 -j MYST_PROVIDER_BLOCKLIST"))
 }
 
 func Test_incomingFirewallIptables_Teardown(t *testing.T) {
@@ -77,8 +81,11 @@ func Test_incomingFirewallIptables_Teardown(t *testing.T) {
 	fw := &incomingFirewallIptables{}
 	fw.Teardown()
 	assert.True(t, mockedIpset.VerifyCalledWithArgs("destroy myst-provider-dst-whitelist"))
+	assert.True(t, mockedIpset.VerifyCalledWithArgs("destroy myst-provider-dst-blocklist"))
 	assert.True(t, mockedIptables.VerifyCalledWithArgs("-F MYST_PROVIDER_FIREWALL"))
 	assert.True(t, mockedIptables.VerifyCalledWithArgs("-X MYST_PROVIDER_FIREWALL"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-F MYST_PROVIDER_BLOCKLIST"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-X MYST_PROVIDER_BLOCKLIST"))
 }
 
 func Test_incomingFirewallIptables_TeardownIfPreviousCleanupFailed(t *testing.T) {
@@ -94,6 +101,7 @@ func Test_incomingFirewallIptables_TeardownIfPreviousCleanupFailed(t *testing.T)
 					"-P FORWARD ACCEPT",
 					// leftover - DNS direwall is still enabled
 					"-A FORWARD -s 10.8.0.1/24 -j MYST_PROVIDER_FIREWALL",
+					"-A FORWARD -s 10.8.0.1/24 -j MYST_PROVIDER_BLOCKLIST",
 				},
 			},
 			// DNS fw chain still exists
@@ -113,6 +121,7 @@ func Test_incomingFirewallIptables_TeardownIfPreviousCleanupFailed(t *testing.T)
 	fw.Teardown()
 	assert.True(t, mockedIpset.VerifyCalledWithArgs("destroy myst-provider-dst-whitelist"))
 	assert.True(t, mockedIptables.VerifyCalledWithArgs("-D FORWARD -s 10.8.0.1/24 -j MYST_PROVIDER_FIREWALL"))
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-D FORWARD -s 10.8.0.1/24 -j MYST_PROVIDER_BLOCKLIST"))
 	assert.True(t, mockedIptables.VerifyCalledWithArgs("-F MYST_PROVIDER_FIREWALL"))
 	assert.True(t, mockedIptables.VerifyCalledWithArgs("-X MYST_PROVIDER_FIREWALL"))
 }
@@ -134,6 +143,23 @@ func Test_incomingFirewallIptables_BlockIncomingTraffic(t *testing.T) {
 	assert.True(t, mockedIptables.VerifyCalledWithArgs("-D FORWARD -s 10.8.0.0/24 -j MYST_PROVIDER_FIREWALL"))
 }
 
+func Test_incomingFirewallIptables_ApplyBlocklist(t *testing.T) {
+	mockedIptables := iptablesExecMock{
+		mocks: map[string]iptablesExecResult{},
+	}
+	iptables.Exec = mockedIptables.Exec
+
+	fw := &incomingFirewallIptables{}
+
+	_, network, _ := net.ParseCIDR("10.8.0.1/24")
+	removeRule, err := fw.ApplyBlocklist(*network)
+	assert.NoError(t, err)
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-I FORWARD 1 -s 10.8.0.0/24 -j MYST_PROVIDER_BLOCKLIST"))
+
+	removeRule()
+	assert.True(t, mockedIptables.VerifyCalledWithArgs("-D FORWARD -s 10.8.0.0/24 -j MYST_PROVIDER_BLOCKLIST"))
+}
+
 func Test_incomingFirewallIptables_AllowIPAccess(t *testing.T) {
 	mockedIpset := ipsetExecMock{
 		mocks: map[string]ipsetExecResult{},
@@ -150,3 +176,41 @@ func Test_incomingFirewallIptables_AllowIPAccess(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, mockedIpset.VerifyCalledWithArgs("del myst-provider-dst-whitelist 1.2.3.4"))
 }
+
+func Test_incomingFirewallIptables_BlockIPAccess(t *testing.T) {
+	mockedIpset := ipsetExecMock{
+		mocks: map[string]ipsetExecResult{},
+	}
+	ipset.Exec = mockedIpset.Exec
+
+	fw := &incomingFirewallIptables{}
+
+	removeRule, err := fw.BlockIPAccess(net.IP{1, 2, 3, 4})
+	assert.NoError(t, err)
+	assert.True(t, mockedIpset.VerifyCalledWithArgs("add myst-provider-dst-blocklist 1.2.3.4 --exist"))
+
+	err = removeRule()
+	assert.NoError(t, err)
+	assert.True(t, mockedIpset.VerifyCalledWithArgs("del myst-provider-dst-blocklist 1.2.3.4"))
+}
+
+func Test_incomingFirewallIptables_BlockIPAccessIsSharedByServices(t *testing.T) {
+	mockedIpset := ipsetExecMock{
+		mocks: map[string]ipsetExecResult{},
+	}
+	ipset.Exec = mockedIpset.Exec
+
+	fw := &incomingFirewallIptables{}
+
+	removeFirst, err := fw.BlockIPAccess(net.IP{1, 2, 3, 4})
+	assert.NoError(t, err)
+	removeSecond, err := fw.BlockIPAccess(net.IP{1, 2, 3, 4})
+	assert.NoError(t, err)
+
+	assert.NoError(t, removeFirst())
+	assert.NoError(t, removeFirst())
+	assert.False(t, mockedIpset.VerifyCalledWithArgs("del myst-provider-dst-blocklist 1.2.3.4"), "IP is still blocked by the second service")
+
+	assert.NoError(t, removeSecond())
+	assert.True(t, mockedIpset.VerifyCalledWithArgs("del myst-provider-dst-blocklist 1.2.3.4"))
+}
@@ -69,4 +69,22 @@ func (ifn *incomingFirewallNoop) AllowIPAccess(ip net.IP) (IncomingRuleRemove, e
 	}, nil
 }
 
+// ApplyBlocklist logs network for which blocklist was requested.
+func (ifn *incomingFirewallNoop) ApplyBlocklist(network net.IPNet) (IncomingRuleRemove, error) {
+	log.Info().Msgf("Apply blocklist for network %s", network.String())
+	return func() error {
+		log.Info().Msgf("Blocklist for network %s removed", network.String())
+		return nil
+	}, nil
+}
+
+// BlockIPAccess logs IP for which access block was requested.
+func (ifn *incomingFirewallNoop) BlockIPAccess(ip net.IP) (IncomingRuleRemove, error) {
+	log.Info().Msgf("Block IP %s access", ip)
+	return func() error {
+		log.Info().Msgf("Block rule for IP: %s removed", ip)
+		return nil
+	}, nil
+}
+
 var _ IncomingTrafficFirewall = &incomingFirewallNoop{}
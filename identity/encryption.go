@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/awnumar/memguard"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Encryption encrypts and decrypts data with a symmetric key derived from the identity key.
+type Encryption interface {
+	Encrypt(addr common.Address, plaintext []byte) ([]byte, error)
+	Decrypt(addr common.Address, encrypted []byte) ([]byte, error)
+}
+
+// encryptionKeyMessage is signed to derive the encryption key of identities kept by an external signer.
+var encryptionKeyMessage = []byte("Mysterium identity encryption key")
+
+// ErrNondeterministicSigner is returned when the encryption key can't be derived from the signatures of the identity.
+var ErrNondeterministicSigner = errors.New("signer does not produce deterministic signatures")
+
+type signerEncryption struct {
+	signerFactory SignerFactory
+
+	lock sync.Mutex
+	keys map[common.Address]*memguard.Enclave
+}
+
+// NewSignerEncryption returns Encryption for identities whose keys are not held by the node.
+// The key is derived from the identity signature of a fixed message, so the signer has to produce
+// deterministic (RFC 6979) signatures, as the go-ethereum keystore and clef do.
+func NewSignerEncryption(signerFactory SignerFactory) Encryption {
+	return &signerEncryption{
+		signerFactory: signerFactory,
+		keys:          make(map[common.Address]*memguard.Enclave),
+	}
+}
+
+// Encrypt encrypts the plaintext with the key derived from the signature of the given identity.
+func (se *signerEncryption) Encrypt(addr common.Address, plaintext []byte) ([]byte, error) {
+	key, err := se.key(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer memguard.WipeBytes(key)
+
+	return encrypt(key, plaintext)
+}
+
+// Decrypt decrypts the message with the key derived from the signature of the given identity.
+func (se *signerEncryption) Decrypt(addr common.Address, encrypted []byte) ([]byte, error) {
+	key, err := se.key(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer memguard.WipeBytes(key)
+
+	return decrypt(key, encrypted)
+}
+
+func (se *signerEncryption) key(addr common.Address) ([]byte, error) {
+	se.lock.Lock()
+	defer se.lock.Unlock()
+
+	enclave, ok := se.keys[addr]
+	if !ok {
+		key, err := se.deriveKey(addr)
+		if err != nil {
+			return nil, err
+		}
+		enclave = memguard.NewEnclave(key)
+		se.keys[addr] = enclave
+	}
+
+	buffer, err := enclave.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer buffer.Destroy()
+
+	key := make([]byte, buffer.Size())
+	copy(key, buffer.Bytes())
+	return key, nil
+}
+
+func (se *signerEncryption) deriveKey(addr common.Address) ([]byte, error) {
+	signer := se.signerFactory(FromAddress(addr.Hex()))
+	signature, err := signer.Sign(encryptionKeyMessage)
+	if err != nil {
+		return nil, err
+	}
+	// a key derived from a random signature would make everything encrypted before unreadable
+	repeated, err := signer.Sign(encryptionKeyMessage)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(signature.Bytes(), repeated.Bytes()) {
+		return nil, ErrNondeterministicSigner
+	}
+
+	hkdfDeriver := hkdf.New(sha512.New, signature.Bytes(), nil, nil)
+	key := make([]byte, 32)
+	_, err = io.ReadFull(hkdfDeriver, key)
+	return key, err
+}
+
+func encrypt(key, plaintext []byte) ([]byte, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decrypt(key, encrypted []byte) ([]byte, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(encrypted) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, encrypted := encrypted[:nonceSize], encrypted[nonceSize:]
+	return gcm.Open(nil, nonce, encrypted, nil)
+}
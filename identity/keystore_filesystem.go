@@ -18,9 +18,6 @@
 package identity
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
 	"crypto/sha512"
 	"errors"
 	"io"
@@ -160,22 +157,7 @@ func (ks *Keystore) Encrypt(addr common.Address, plaintext []byte) ([]byte, erro
 	}
 	defer memguard.WipeBytes(key)
 
-	c, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(c)
-	if err != nil {
-		return nil, err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
-	}
-
-	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+	return encrypt(key, plaintext)
 }
 
 // Decrypt takes a derived key for the given address and decrypts the encrypted message.
@@ -186,23 +168,7 @@ func (ks *Keystore) Decrypt(addr common.Address, encrypted []byte) ([]byte, erro
 	}
 	defer memguard.WipeBytes(key)
 
-	c, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(c)
-	if err != nil {
-		return nil, err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(encrypted) < nonceSize {
-		return nil, errors.New("ciphertext too short")
-	}
-
-	nonce, encrypted := encrypted[:nonceSize], encrypted[nonceSize:]
-	return gcm.Open(nil, nonce, encrypted, nil)
+	return decrypt(key, encrypted)
 }
 
 // SignHash signs the given hash.
@@ -223,7 +223,7 @@ func (registry *contractRegistry) subscribeToRegistrationEvent(identity identity
 
 		// TODO: maybe add appropriate timeout?
 		select {
-		case <-sink:
+		case info := <-sink:
 			log.Info().Msgf("Received registration event for %v", identity)
 			s, err := registry.storage.Get(identity)
 			if err != nil {
@@ -239,6 +239,7 @@ func (registry *contractRegistry) subscribeToRegistrationEvent(identity identity
 			registry.publisher.Publish(AppTopicIdentityRegistration, AppEventIdentityRegistration{
 				ID:     identity,
 				Status: status,
+				TxHash: info.Raw.TxHash.Hex(),
 			})
 
 			err = registry.storage.Store(StoredRegistrationStatus{
@@ -87,4 +87,6 @@ const AppTopicIdentityRegistration = "registration_event_topic"
 type AppEventIdentityRegistration struct {
 	ID     identity.Identity
 	Status RegistrationStatus
+	// TxHash is the registration transaction hash, known once the registration is confirmed on the blockchain.
+	TxHash string
 }
@@ -21,12 +21,18 @@ import (
 	"sync"
 	"time"
 
+	"github.com/mysteriumnetwork/node/core/storage"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/pkg/errors"
 )
 
 const registrationStatusBucket = "registry_statuses"
 
+// StorageKinds returns the records kept by the registration status storage.
+func StorageKinds() []storage.Kind {
+	return []storage.Kind{{Bucket: registrationStatusBucket, Record: &StoredRegistrationStatus{}}}
+}
+
 type persistentStorage interface {
 	Store(bucket string, data interface{}) error
 	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
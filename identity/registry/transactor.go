@@ -38,6 +38,26 @@ const AppTopicTransactorRegistration = "transactor_identity_registration"
 // AppTopicTransactorTopUp represents the top up topic to which events regarding top up attempts are sent.
 const AppTopicTransactorTopUp = "transactor_top_up"
 
+// AppTopicTransactorResult represents the topic to which outcomes of registration and top up requests are sent.
+const AppTopicTransactorResult = "transactor_result"
+
+// TransactorRequestType identifies the request sent to transactor.
+type TransactorRequestType string
+
+const (
+	// TransactorRequestRegistration marks identity registration requests.
+	TransactorRequestRegistration TransactorRequestType = "registration"
+	// TransactorRequestTopUp marks top up requests.
+	TransactorRequestTopUp TransactorRequestType = "topup"
+)
+
+// AppEventTransactorResult represents the payload that is sent on the AppTopicTransactorResult once transactor responds.
+type AppEventTransactorResult struct {
+	Type     TransactorRequestType
+	Identity string
+	Error    string
+}
+
 // Transactor allows for convenient calls to the transactor service
 type Transactor struct {
 	httpClient            *requests.HTTPClient
@@ -163,7 +183,9 @@ func (t *Transactor) TopUp(id string) error {
 	// This is left as a synchronous call on purpose.
 	t.publisher.Publish(AppTopicTransactorTopUp, id)
 
-	return t.httpClient.DoRequest(req)
+	err = t.httpClient.DoRequest(req)
+	t.publishResult(TransactorRequestTopUp, id, err)
+	return err
 }
 
 // SettleAndRebalance requests the transactor to settle and rebalance the given channel
@@ -205,7 +227,20 @@ func (t *Transactor) RegisterIdentity(id string, regReqDTO *IdentityRegistration
 	// We need to notify registry before returning.
 	t.publisher.Publish(AppTopicTransactorRegistration, regReq)
 
-	return t.httpClient.DoRequest(req)
+	err = t.httpClient.DoRequest(req)
+	t.publishResult(TransactorRequestRegistration, id, err)
+	return err
+}
+
+func (t *Transactor) publishResult(requestType TransactorRequestType, id string, err error) {
+	result := AppEventTransactorResult{
+		Type:     requestType,
+		Identity: id,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	t.publisher.Publish(AppTopicTransactorResult, result)
 }
 
 func (t *Transactor) fillIdentityRegistrationRequest(id string, regReqDTO IdentityRegistrationRequestDTO) (IdentityRegistrationRequest, error) {
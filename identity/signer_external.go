@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+// ExternalSignerContentType is the content type passed to the external signer.
+// The signer is expected to sign keccak256(data) without any prefix, the same way the local keystore does.
+const ExternalSignerContentType = "application/x-mysterium-message"
+
+const externalSignerMethod = "account_signData"
+
+// ExternalSignerClient is a JSON-RPC client talking to the external signing service.
+type ExternalSignerClient interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+type externalSigner struct {
+	client    ExternalSignerClient
+	identity  Identity
+	timeout   time.Duration
+	extractor Extractor
+}
+
+// NewExternalSigner returns Signer which delegates signing to an external clef-style signing service.
+func NewExternalSigner(client ExternalSignerClient, identity Identity, timeout time.Duration) Signer {
+	return &externalSigner{
+		client:    client,
+		identity:  identity,
+		timeout:   timeout,
+		extractor: NewExtractor(),
+	}
+}
+
+// NewExternalSignerFactory dials the external signing service (HTTP URL or IPC socket path)
+// and returns SignerFactory producing signers backed by it.
+func NewExternalSignerFactory(endpoint string, timeout time.Duration) (SignerFactory, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not connect to external signer %q", endpoint)
+	}
+
+	return func(id Identity) Signer {
+		return NewExternalSigner(client, id, timeout)
+	}, nil
+}
+
+// Sign asks the external signer to sign given message and verifies the returned signature
+func (es *externalSigner) Sign(message []byte) (Signature, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), es.timeout)
+	defer cancel()
+
+	var result hexutil.Bytes
+	err := es.client.CallContext(ctx, &result, externalSignerMethod, ExternalSignerContentType, es.identity.Address, hexutil.Bytes(message))
+	if err != nil {
+		return Signature{}, errors.Wrap(err, "external signer failed to sign message")
+	}
+	if len(result) != 65 {
+		return Signature{}, errors.Errorf("external signer returned signature of invalid length %d", len(result))
+	}
+
+	// Clef-style signers return V as 27/28, while the keystore produces 0/1.
+	signatureBytes := make([]byte, len(result))
+	copy(signatureBytes, result)
+	if signatureBytes[64] >= 27 {
+		signatureBytes[64] -= 27
+	}
+
+	signature := SignatureBytes(signatureBytes)
+	signer, err := es.extractor.Extract(message, signature)
+	if err != nil {
+		return Signature{}, errors.Wrap(err, "external signer returned invalid signature")
+	}
+	if signer != es.identity {
+		return Signature{}, errors.Errorf("external signer signed with %s instead of %s", signer.Address, es.identity.Address)
+	}
+
+	return signature, nil
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// MockExternalSigner mimics clef account_signData API using a locally held key.
+type MockExternalSigner struct {
+	// PkHex is the private key of the only account the signer knows.
+	PkHex string
+	// Prefixed makes the signer sign the data with the Ethereum message prefix.
+	Prefixed bool
+	// ContentTypes records the content types of the signing requests.
+	ContentTypes []string
+}
+
+// SignData signs keccak256 of the given data, the way clef does for unknown content types.
+func (s *MockExternalSigner) SignData(contentType string, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	s.ContentTypes = append(s.ContentTypes, contentType)
+	pk, err := crypto.HexToECDSA(s.PkHex)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(pk.PublicKey) != addr.Address() {
+		return nil, errors.New("unknown account")
+	}
+	if s.Prefixed {
+		data = append([]byte("\x19Ethereum Signed Message:\n"), data...)
+	}
+	signature, err := crypto.Sign(crypto.Keccak256(data), pk)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27
+	return signature, nil
+}
+
+// NewMockExternalSignerServer returns JSON-RPC server of the mock signer, to be served over HTTP.
+func NewMockExternalSignerServer(signer *MockExternalSigner) (*rpc.Server, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", signer); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package identity
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newStandInSignerServer(t *testing.T, signer *MockExternalSigner) *httptest.Server {
+	server, err := NewMockExternalSignerServer(signer)
+	assert.NoError(t, err)
+	return httptest.NewServer(server)
+}
+
+func TestExternalSigner_SignsMessage(t *testing.T) {
+	standIn := &MockExternalSigner{PkHex: "6f88637b68ee88816e73f663aef709d7009836c98ae91ef31e3dfac7be3a1657"}
+	server := newStandInSignerServer(t, standIn)
+	defer server.Close()
+
+	factory, err := NewExternalSignerFactory(server.URL, time.Second)
+	assert.NoError(t, err)
+
+	signer := factory(FromAddress("0x53a835143c0ef3bbcbfa796d7eb738ca7dd28f68"))
+	signature, err := signer.Sign([]byte("MystVpnSessionId:Boop!"))
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		SignatureBase64("V6ifmvLuAT+hbtLBX/0xm3C0afywxTIdw1HqLmA4onpwmibHbxVhl50Gr3aRUZMqw1WxkfSIVdhpbCluHGBKsgE="),
+		signature,
+	)
+	assert.Equal(t, []string{ExternalSignerContentType}, standIn.ContentTypes)
+}
+
+func TestExternalSigner_RejectsSignatureOfOtherPayload(t *testing.T) {
+	server := newStandInSignerServer(t, &MockExternalSigner{
+		PkHex:    "6f88637b68ee88816e73f663aef709d7009836c98ae91ef31e3dfac7be3a1657",
+		Prefixed: true,
+	})
+	defer server.Close()
+
+	factory, err := NewExternalSignerFactory(server.URL, time.Second)
+	assert.NoError(t, err)
+
+	_, err = factory(FromAddress("0x53a835143c0ef3bbcbfa796d7eb738ca7dd28f68")).Sign([]byte("message"))
+	assert.Error(t, err)
+}
+
+func TestExternalSigner_ReturnsSignerError(t *testing.T) {
+	server := newStandInSignerServer(t, &MockExternalSigner{PkHex: "6f88637b68ee88816e73f663aef709d7009836c98ae91ef31e3dfac7be3a1657"})
+	defer server.Close()
+
+	factory, err := NewExternalSignerFactory(server.URL, time.Second)
+	assert.NoError(t, err)
+
+	_, err = factory(FromAddress("0x0000000000000000000000000000000000000001")).Sign([]byte("message"))
+	assert.EqualError(t, err, "external signer failed to sign message: unknown account")
+}
+
+func TestSignerEncryption_EncryptsWithKeyDerivedFromSignature(t *testing.T) {
+	server := newStandInSignerServer(t, &MockExternalSigner{PkHex: "6f88637b68ee88816e73f663aef709d7009836c98ae91ef31e3dfac7be3a1657"})
+	defer server.Close()
+
+	factory, err := NewExternalSignerFactory(server.URL, time.Second)
+	assert.NoError(t, err)
+	addr := FromAddress("0x53a835143c0ef3bbcbfa796d7eb738ca7dd28f68").ToCommonAddress()
+
+	encrypted, err := NewSignerEncryption(factory).Encrypt(addr, []byte("secret"))
+	assert.NoError(t, err)
+
+	// a fresh instance derives the same key, so the data survives node restarts
+	decrypted, err := NewSignerEncryption(factory).Decrypt(addr, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), decrypted)
+
+	_, err = NewSignerEncryption(factory).Encrypt(FromAddress("0x0000000000000000000000000000000000000001").ToCommonAddress(), []byte("secret"))
+	assert.Error(t, err)
+}
+
+func TestSignerEncryption_RejectsNondeterministicSigner(t *testing.T) {
+	var count byte
+	factory := func(id Identity) Signer {
+		return &fakeSignerFunc{sign: func(message []byte) (Signature, error) {
+			count++
+			return SignatureBytes([]byte{count}), nil
+		}}
+	}
+
+	_, err := NewSignerEncryption(factory).Encrypt(FromAddress("0x01").ToCommonAddress(), []byte("secret"))
+	assert.Equal(t, ErrNondeterministicSigner, err)
+}
+
+type fakeSignerFunc struct {
+	sign func(message []byte) (Signature, error)
+}
+
+func (fs *fakeSignerFunc) Sign(message []byte) (Signature, error) {
+	return fs.sign(message)
+}
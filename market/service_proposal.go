@@ -19,6 +19,7 @@ package market
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/mysteriumnetwork/node/identity"
 )
@@ -57,6 +58,9 @@ type ServiceProposal struct {
 
 	// AccessPolicies represents the access controls for proposal
 	AccessPolicies *[]AccessPolicy `json:"access_policies,omitempty"`
+
+	// AccountantIDs lists accountants the provider accepts payments through
+	AccountantIDs []string `json:"accountant_ids,omitempty"`
 }
 
 // UniqueID returns unique proposal composite ID
@@ -79,6 +83,7 @@ func (proposal *ServiceProposal) UnmarshalJSON(data []byte) error {
 		PaymentMethod     *json.RawMessage `json:"payment_method"`
 		ProviderContacts  *json.RawMessage `json:"provider_contacts"`
 		AccessPolicies    *[]AccessPolicy  `json:"access_policies,omitempty"`
+		AccountantIDs     []string         `json:"accountant_ids,omitempty"`
 	}
 	if err := json.Unmarshal(data, &jsonData); err != nil {
 		return err
@@ -106,6 +111,7 @@ func (proposal *ServiceProposal) UnmarshalJSON(data []byte) error {
 	proposal.ProviderContacts = unserializeContacts(jsonData.ProviderContacts)
 
 	proposal.AccessPolicies = jsonData.AccessPolicies
+	proposal.AccountantIDs = jsonData.AccountantIDs
 	return nil
 }
 
@@ -123,6 +129,25 @@ func (proposal *ServiceProposal) SetAccessPolicies(ap *[]AccessPolicy) {
 	proposal.AccessPolicies = ap
 }
 
+// SetAccountantIDs updates accountants the provider accepts payments through.
+func (proposal *ServiceProposal) SetAccountantIDs(ids []string) {
+	proposal.AccountantIDs = ids
+}
+
+// AcceptsAccountant checks if the provider accepts payments through the given accountant.
+// Proposals which do not list any accountants are assumed to accept every accountant.
+func (proposal *ServiceProposal) AcceptsAccountant(id string) bool {
+	if len(proposal.AccountantIDs) == 0 {
+		return true
+	}
+	for _, accepted := range proposal.AccountantIDs {
+		if strings.EqualFold(accepted, id) {
+			return true
+		}
+	}
+	return false
+}
+
 // SetPaymentMethod updates payment method in the proposal.
 func (proposal *ServiceProposal) SetPaymentMethod(pm PaymentMethod) {
 	if pm != nil {
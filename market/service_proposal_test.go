@@ -257,3 +257,27 @@ func Test_ServiceProposal_UnserializeAccessPolicy(t *testing.T) {
 	assert.Equal(t, expected, actual)
 	assert.True(t, actual.IsSupported())
 }
+
+func Test_ServiceProposal_UnserializeAccountantIDs(t *testing.T) {
+	jsonData := []byte(`{
+		"id": 1,
+		"service_type": "mock_service",
+		"provider_id": "node",
+		"accountant_ids": ["0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002"]
+	}`)
+
+	var actual ServiceProposal
+	err := json.Unmarshal(jsonData, &actual)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002"}, actual.AccountantIDs)
+}
+
+func Test_ServiceProposal_AcceptsAccountant(t *testing.T) {
+	proposal := ServiceProposal{}
+	assert.True(t, proposal.AcceptsAccountant("0x0000000000000000000000000000000000000001"))
+
+	proposal.SetAccountantIDs([]string{"0x000000000000000000000000000000000000000A"})
+	assert.True(t, proposal.AcceptsAccountant("0x000000000000000000000000000000000000000a"))
+	assert.False(t, proposal.AcceptsAccountant("0x0000000000000000000000000000000000000001"))
+}
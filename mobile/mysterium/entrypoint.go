@@ -31,19 +31,28 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/mysteriumnetwork/node/cmd"
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/consumer/favourite"
+	consumer_session "github.com/mysteriumnetwork/node/consumer/session"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/core/discovery"
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/location"
 	"github.com/mysteriumnetwork/node/core/node"
+	"github.com/mysteriumnetwork/node/core/service"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/feedback"
+	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/selector"
 	"github.com/mysteriumnetwork/node/logconfig"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/metadata"
+	"github.com/mysteriumnetwork/node/nat"
+	natevent "github.com/mysteriumnetwork/node/nat/event"
+	"github.com/mysteriumnetwork/node/nat/mapping"
+	"github.com/mysteriumnetwork/node/nat/traversal"
 	"github.com/mysteriumnetwork/node/services/openvpn"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/rs/zerolog"
@@ -71,6 +80,15 @@ type MobileNode struct {
 	consumerBalanceTracker       *pingpong.ConsumerBalanceTracker
 	registryAddress              string
 	channelImplementationAddress string
+	sessionStorage               *consumer_session.Storage
+	favourites                   *favourite.Storage
+	provider                     *providerController
+	serviceRegistry              *service.Registry
+	natService                   nat.NATService
+	providerNATPinger            traversal.NATPinger
+	natTracker                   *natevent.Tracker
+	portMapper                   mapping.PortMapper
+	serviceFirewall              firewall.IncomingTrafficFirewall
 }
 
 // MobileNodeOptions contains common mobile node options.
@@ -91,6 +109,8 @@ type MobileNodeOptions struct {
 	AccountantEndpointAddress       string
 	AccountantID                    string
 	MystSCAddress                   string
+	// EnableProvider bootstraps provider components, so that StartProvider can be used.
+	EnableProvider bool
 }
 
 // DefaultNodeOptions returns default options.
@@ -200,9 +220,16 @@ func NewNode(appPath string, options *MobileNodeOptions) (*MobileNode, error) {
 			ConsumerUpperGBPriceBound:          7000000,
 		},
 		MobileConsumer: true,
+		MobileProvider: options.EnableProvider,
 		P2PPorts:       port.UnspecifiedRange(),
 	}
 
+	if options.EnableProvider {
+		config.Current.SetDefault(config.FlagAccessPolicyAddress.Name, config.FlagAccessPolicyAddress.Value)
+		config.Current.SetDefault(config.FlagAccessPolicyFetchInterval.Name, config.FlagAccessPolicyFetchInterval.Value)
+		config.Current.SetDefault(config.FlagFirewallProtectedNetworks.Name, config.FlagFirewallProtectedNetworks.Value)
+	}
+
 	err := di.Bootstrap(nodeOptions)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not bootstrap dependencies")
@@ -227,10 +254,19 @@ func NewNode(appPath string, options *MobileNodeOptions) (*MobileNode, error) {
 		identityChannelCalculator:    di.ChannelAddressCalculator,
 		channelImplementationAddress: nodeOptions.Transactor.ChannelImplementation,
 		registryAddress:              nodeOptions.Transactor.RegistryAddress,
+		sessionStorage:               di.SessionStorage,
+		favourites:                   di.FavouriteStorage,
+		serviceRegistry:              di.ServiceRegistry,
+		natService:                   di.NATService,
+		providerNATPinger:            di.NATPinger,
+		natTracker:                   di.NATTracker,
+		portMapper:                   di.PortMapper,
+		serviceFirewall:              di.ServiceFirewall,
 		proposalsManager: newProposalsManager(
 			di.ProposalRepository,
 			di.MysteriumAPI,
 			di.QualityClient,
+			di.FavouriteStorage,
 			&proposal.Filter{
 				UpperTimePriceBound: &nodeOptions.Payments.ConsumerUpperMinutePriceBound,
 				LowerTimePriceBound: &nodeOptions.Payments.ConsumerLowerMinutePriceBound,
@@ -240,6 +276,9 @@ func NewNode(appPath string, options *MobileNodeOptions) (*MobileNode, error) {
 			},
 		),
 	}
+	if di.ServicesManager != nil {
+		mobileNode.provider = newProviderController(di.ServicesManager)
+	}
 	return mobileNode, nil
 }
 
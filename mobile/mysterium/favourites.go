@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mysteriumnetwork/node/consumer/favourite"
+	"github.com/pkg/errors"
+)
+
+// FavouriteProviderRequest represents favourite provider request.
+type FavouriteProviderRequest struct {
+	ProviderID string
+	Note       string
+}
+
+type favouriteDTO struct {
+	ProviderID string `json:"providerId"`
+	Note       string `json:"note,omitempty"`
+	AddedAt    string `json:"addedAt"`
+}
+
+type getFavouriteProvidersResponse struct {
+	Favourites []favouriteDTO `json:"favourites"`
+}
+
+// AddFavouriteProvider marks provider as favourite, adding it again updates the note.
+func (mb *MobileNode) AddFavouriteProvider(req *FavouriteProviderRequest) error {
+	if _, err := mb.favourites.Add(req.ProviderID, req.Note); err != nil {
+		return errors.Wrap(err, "could not add favourite provider")
+	}
+	return nil
+}
+
+// RemoveFavouriteProvider unmarks favourite provider.
+func (mb *MobileNode) RemoveFavouriteProvider(req *FavouriteProviderRequest) error {
+	if err := mb.favourites.Remove(req.ProviderID); err != nil {
+		return errors.Wrap(err, "could not remove favourite provider")
+	}
+	return nil
+}
+
+// GetFavouriteProviders returns favourite providers, the most recently added first. Providers returned as JSON byte array since
+// go mobile does not support complex slices.
+func (mb *MobileNode) GetFavouriteProviders() ([]byte, error) {
+	favourites, err := mb.favourites.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get favourite providers")
+	}
+	return mapToFavouritesResponse(favourites)
+}
+
+func mapToFavouritesResponse(favourites []favourite.Favourite) ([]byte, error) {
+	res := getFavouriteProvidersResponse{Favourites: []favouriteDTO{}}
+	for _, f := range favourites {
+		res.Favourites = append(res.Favourites, favouriteDTO{
+			ProviderID: f.ProviderID,
+			Note:       f.Note,
+			AddedAt:    f.AddedAt.Format(time.RFC3339),
+		})
+	}
+	return json.Marshal(res)
+}
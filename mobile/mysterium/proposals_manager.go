@@ -19,11 +19,16 @@ package mysterium
 
 import (
 	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
+	"github.com/mysteriumnetwork/node/core/discovery/reducer"
 	"github.com/mysteriumnetwork/node/core/quality"
+	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/market/mysterium"
+	"github.com/mysteriumnetwork/node/money"
 	"github.com/mysteriumnetwork/node/services/openvpn"
 	"github.com/mysteriumnetwork/node/services/wireguard"
 )
@@ -43,10 +48,21 @@ const (
 )
 
 // GetProposalsRequest represents proposals request.
+// Zero values of the filter fields match every proposal, when neither service type is shown both are.
 type GetProposalsRequest struct {
 	ShowOpenvpnProposals   bool
 	ShowWireguardProposals bool
 	Refresh                bool
+	// CountryCode is the ISO 3166-1 alpha-2 code of the provider country.
+	CountryCode string
+	// QualityMin is the lowest quality level, from 1 (low) to 3 (high).
+	QualityMin int
+	// PriceMinuteMax is the highest price in MYST per minute.
+	PriceMinuteMax float64
+	// PriceGiBMax is the highest price in MYST per GiB.
+	PriceGiBMax float64
+	// FavouritesOnly limits proposals to the favourite providers.
+	FavouritesOnly bool
 }
 
 // GetProposalRequest represents proposal request.
@@ -61,6 +77,13 @@ type proposalDTO struct {
 	ServiceType  string               `json:"serviceType"`
 	CountryCode  string               `json:"countryCode"`
 	QualityLevel proposalQualityLevel `json:"qualityLevel"`
+	Price        *proposalPriceDTO    `json:"price,omitempty"`
+	Favourite    bool                 `json:"favourite,omitempty"`
+}
+
+type proposalPriceDTO struct {
+	PerMinute float64 `json:"perMinute"`
+	PerGiB    float64 `json:"perGiB"`
 }
 
 type getProposalsResponse struct {
@@ -79,16 +102,22 @@ type qualityFinder interface {
 	ProposalsMetrics() []quality.ConnectMetric
 }
 
+type favouriteProviders interface {
+	IDs() (map[string]bool, error)
+}
+
 func newProposalsManager(
 	repository proposal.Repository,
 	mysteriumAPI mysteriumAPI,
 	qualityFinder qualityFinder,
+	favourites favouriteProviders,
 	filter *proposal.Filter,
 ) *proposalsManager {
 	return &proposalsManager{
 		repository:    repository,
 		mysteriumAPI:  mysteriumAPI,
 		qualityFinder: qualityFinder,
+		favourites:    favourites,
 		filter:        filter,
 	}
 }
@@ -98,26 +127,61 @@ type proposalsManager struct {
 	cache         []market.ServiceProposal
 	mysteriumAPI  mysteriumAPI
 	qualityFinder qualityFinder
+	favourites    favouriteProviders
 	filter        *proposal.Filter
 }
 
 func (m *proposalsManager) getProposals(req *GetProposalsRequest) ([]byte, error) {
 	// Get proposals from cache if exists.
-	if !req.Refresh {
-		cachedProposals := m.getFromCache()
-		if len(cachedProposals) > 0 {
-			return m.mapToProposalsResponse(cachedProposals)
+	proposals := m.getFromCache()
+	if req.Refresh || len(proposals) == 0 {
+		// Get proposals from remote discovery api and store in cache.
+		apiProposals, err := m.getFromRepository()
+		if err != nil {
+			return nil, err
 		}
+		m.addToCache(apiProposals)
+		proposals = apiProposals
 	}
 
-	// Get proposals from remote discovery api and store in cache.
-	apiProposals, err := m.getFromRepository()
+	favourites, err := m.favourites.IDs()
 	if err != nil {
 		return nil, err
 	}
-	m.addToCache(apiProposals)
+	return m.mapToProposalsResponse(m.filterProposals(req, proposals, favourites), req, favourites)
+}
 
-	return m.mapToProposalsResponse(apiProposals)
+// filterProposals keeps the proposals matching the request, quality is filtered after it is known.
+func (m *proposalsManager) filterProposals(req *GetProposalsRequest, proposals []market.ServiceProposal, favourites map[string]bool) []market.ServiceProposal {
+	var conditions []reducer.AndCondition
+	if req.ShowOpenvpnProposals != req.ShowWireguardProposals {
+		serviceType := openvpn.ServiceType
+		if req.ShowWireguardProposals {
+			serviceType = wireguard.ServiceType
+		}
+		conditions = append(conditions, reducer.Equal(reducer.ServiceType, serviceType))
+	}
+	if req.PriceMinuteMax > 0 {
+		conditions = append(conditions, reducer.PriceMinute(0, uint64(req.PriceMinuteMax*money.MystSize)))
+	}
+	if req.PriceGiBMax > 0 {
+		conditions = append(conditions, reducer.PriceGiB(0, uint64(req.PriceGiBMax*money.MystSize)))
+	}
+
+	var res []market.ServiceProposal
+	for _, p := range proposals {
+		if req.CountryCode != "" && !strings.EqualFold(m.getServiceCountryCode(&p), req.CountryCode) {
+			continue
+		}
+		if req.FavouritesOnly && !favourites[strings.ToLower(p.ProviderID)] {
+			continue
+		}
+		if len(conditions) > 0 && !reducer.And(conditions...)(p) {
+			continue
+		}
+		res = append(res, p)
+	}
+	return res
 }
 
 func (m *proposalsManager) getProposal(req *GetProposalRequest) ([]byte, error) {
@@ -159,7 +223,7 @@ func (m *proposalsManager) addToCache(proposals []market.ServiceProposal) {
 	m.cache = proposals
 }
 
-func (m *proposalsManager) mapToProposalsResponse(serviceProposals []market.ServiceProposal) ([]byte, error) {
+func (m *proposalsManager) mapToProposalsResponse(serviceProposals []market.ServiceProposal, req *GetProposalsRequest, favourites map[string]bool) ([]byte, error) {
 	var proposals []*proposalDTO
 	for _, p := range serviceProposals {
 		proposals = append(proposals, &proposalDTO{
@@ -167,11 +231,23 @@ func (m *proposalsManager) mapToProposalsResponse(serviceProposals []market.Serv
 			ProviderID:  p.ProviderID,
 			ServiceType: p.ServiceType,
 			CountryCode: m.getServiceCountryCode(&p),
+			Price:       m.getServicePrice(&p),
+			Favourite:   favourites[strings.ToLower(p.ProviderID)],
 		})
 	}
 
 	m.addQualityData(proposals)
 
+	if req.QualityMin > 0 {
+		var filtered []*proposalDTO
+		for _, p := range proposals {
+			if p.QualityLevel >= proposalQualityLevel(req.QualityMin) {
+				filtered = append(filtered, p)
+			}
+		}
+		proposals = filtered
+	}
+
 	res := &getProposalsResponse{Proposals: proposals}
 	bytes, err := json.Marshal(res)
 	if err != nil {
@@ -186,6 +262,7 @@ func (m *proposalsManager) mapToProposalResponse(p *market.ServiceProposal) ([]b
 		ProviderID:  p.ProviderID,
 		ServiceType: p.ServiceType,
 		CountryCode: m.getServiceCountryCode(p),
+		Price:       m.getServicePrice(p),
 	}
 	res := &getProposalResponse{Proposal: dto}
 	bytes, err := json.Marshal(res)
@@ -202,6 +279,24 @@ func (m *proposalsManager) getServiceCountryCode(p *market.ServiceProposal) stri
 	return p.ServiceDefinition.GetLocation().Country
 }
 
+// getServicePrice returns the proposal price in MYST per minute and per GiB.
+func (m *proposalsManager) getServicePrice(p *market.ServiceProposal) *proposalPriceDTO {
+	if p.PaymentMethod == nil {
+		return nil
+	}
+	price := float64(p.PaymentMethod.GetPrice().Amount) / money.MystSize
+	rate := p.PaymentMethod.GetRate()
+
+	dto := &proposalPriceDTO{}
+	if rate.PerTime > 0 {
+		dto.PerMinute = price * float64(time.Minute) / float64(rate.PerTime)
+	}
+	if rate.PerByte > 0 {
+		dto.PerGiB = price * float64(datasize.GiB.Bytes()) / float64(rate.PerByte)
+	}
+	return dto
+}
+
 func (m *proposalsManager) addQualityData(proposals []*proposalDTO) {
 	metrics := m.qualityFinder.ProposalsMetrics()
 
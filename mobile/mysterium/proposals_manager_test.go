@@ -18,12 +18,14 @@
 package mysterium
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/mysteriumnetwork/node/core/discovery/proposal"
 	"github.com/mysteriumnetwork/node/core/quality"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/market/mysterium"
+	"github.com/mysteriumnetwork/node/session/pingpong"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -34,6 +36,7 @@ type proposalManagerTestSuite struct {
 	repository    *mockRepository
 	mysteriumAPI  mysteriumAPI
 	qualityFinder qualityFinder
+	favourites    *mockFavourites
 
 	proposalsManager *proposalsManager
 }
@@ -42,6 +45,7 @@ func (s *proposalManagerTestSuite) SetupTest() {
 	s.repository = &mockRepository{}
 	s.mysteriumAPI = &mockMysteriumAPI{}
 	s.qualityFinder = &mockQualityFinder{}
+	s.favourites = &mockFavourites{}
 
 	var lowerTime uint64
 	var upperTime uint64 = 50000
@@ -57,6 +61,7 @@ func (s *proposalManagerTestSuite) SetupTest() {
 		s.repository,
 		s.mysteriumAPI,
 		s.qualityFinder,
+		s.favourites,
 		filter,
 	)
 }
@@ -119,6 +124,61 @@ func (s *proposalManagerTestSuite) TestGetSingleProposal() {
 	assert.Equal(s.T(), "{\"proposal\":{\"id\":0,\"providerId\":\"p1\",\"serviceType\":\"wireguard\",\"countryCode\":\"\",\"qualityLevel\":0}}", string(bytes))
 }
 
+func (s *proposalManagerTestSuite) TestGetProposalsFiltered() {
+	s.repository.data = []market.ServiceProposal{
+		{ProviderID: "0x1", ServiceType: "wireguard", ServiceDefinition: mockService{country: "LT"}, PaymentMethod: pingpong.NewPaymentMethod(0.1, 0.001)},
+		{ProviderID: "0x2", ServiceType: "openvpn", ServiceDefinition: mockService{country: "LT"}, PaymentMethod: pingpong.NewPaymentMethod(0.1, 0.001)},
+		{ProviderID: "0x3", ServiceType: "wireguard", ServiceDefinition: mockService{country: "DE"}, PaymentMethod: pingpong.NewPaymentMethod(0.1, 0.001)},
+		{ProviderID: "0x4", ServiceType: "wireguard", ServiceDefinition: mockService{country: "LT"}, PaymentMethod: pingpong.NewPaymentMethod(0.5, 0.001)},
+		{ProviderID: "0x5", ServiceType: "wireguard", ServiceDefinition: mockService{country: "LT"}, PaymentMethod: pingpong.NewPaymentMethod(0.1, 0.01)},
+	}
+	s.favourites.ids = map[string]bool{"0x1": true, "0x5": true}
+	s.proposalsManager.qualityFinder = &mockQualityFinder{
+		metrics: []quality.ConnectMetric{
+			{ProposalID: quality.ProposalID{ProviderID: "0x1", ServiceType: "wireguard"}, ConnectCount: quality.ConnectCount{Success: 9, Fail: 1}},
+			{ProposalID: quality.ProposalID{ProviderID: "0x5", ServiceType: "wireguard"}, ConnectCount: quality.ConnectCount{Success: 1, Fail: 9}},
+		},
+	}
+
+	providers := func(req *GetProposalsRequest) []string {
+		bytes, err := s.proposalsManager.getProposals(req)
+		assert.NoError(s.T(), err)
+		var res getProposalsResponse
+		assert.NoError(s.T(), json.Unmarshal(bytes, &res))
+		var ids []string
+		for _, p := range res.Proposals {
+			ids = append(ids, p.ProviderID)
+		}
+		return ids
+	}
+
+	assert.Equal(s.T(), []string{"0x1", "0x2", "0x3", "0x4", "0x5"}, providers(&GetProposalsRequest{Refresh: true}))
+	assert.Equal(s.T(), []string{"0x2"}, providers(&GetProposalsRequest{ShowOpenvpnProposals: true}))
+	assert.Equal(s.T(), []string{"0x1", "0x2", "0x4", "0x5"}, providers(&GetProposalsRequest{CountryCode: "lt"}))
+	assert.Equal(s.T(), []string{"0x1", "0x3", "0x4"}, providers(&GetProposalsRequest{ShowWireguardProposals: true, PriceMinuteMax: 0.005}))
+	assert.Equal(s.T(), []string{"0x1", "0x2", "0x3", "0x5"}, providers(&GetProposalsRequest{PriceGiBMax: 0.2}))
+	assert.Equal(s.T(), []string{"0x1", "0x5"}, providers(&GetProposalsRequest{FavouritesOnly: true}))
+	assert.Equal(s.T(), []string{"0x1"}, providers(&GetProposalsRequest{QualityMin: int(proposalQualityLevelHigh)}))
+	assert.Equal(s.T(), []string{"0x1", "0x5"}, providers(&GetProposalsRequest{QualityMin: int(proposalQualityLevelLow)}))
+}
+
+func (s *proposalManagerTestSuite) TestGetProposalsWithPriceAndFavourite() {
+	s.repository.data = []market.ServiceProposal{
+		{ProviderID: "0x1", ServiceType: "wireguard", PaymentMethod: pingpong.NewPaymentMethod(0.1, 0.001)},
+	}
+	s.favourites.ids = map[string]bool{"0x1": true}
+
+	bytes, err := s.proposalsManager.getProposals(&GetProposalsRequest{Refresh: true})
+
+	assert.NoError(s.T(), err)
+	var res getProposalsResponse
+	assert.NoError(s.T(), json.Unmarshal(bytes, &res))
+	assert.Len(s.T(), res.Proposals, 1)
+	assert.True(s.T(), res.Proposals[0].Favourite)
+	assert.InDelta(s.T(), 0.001, res.Proposals[0].Price.PerMinute, 1e-6)
+	assert.InDelta(s.T(), 0.1, res.Proposals[0].Price.PerGiB, 1e-6)
+}
+
 func TestProposalManagerSuite(t *testing.T) {
 	suite.Run(t, new(proposalManagerTestSuite))
 }
@@ -146,6 +206,22 @@ func (m *mockMysteriumAPI) QueryProposals(query mysterium.ProposalsQuery) ([]mar
 	return m.proposals, nil
 }
 
+type mockFavourites struct {
+	ids map[string]bool
+}
+
+func (m *mockFavourites) IDs() (map[string]bool, error) {
+	return m.ids, nil
+}
+
+type mockService struct {
+	country string
+}
+
+func (m mockService) GetLocation() market.Location {
+	return market.Location{Country: m.country}
+}
+
 type mockQualityFinder struct {
 	metrics []quality.ConnectMetric
 }
@@ -0,0 +1,277 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"sync"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/services/wireguard"
+	wireguard_service "github.com/mysteriumnetwork/node/services/wireguard/service"
+	sessionEvent "github.com/mysteriumnetwork/node/session/event"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/mysteriumnetwork/node/session/pingpong/event"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ProviderStatusStopped means provider service is not requested to run.
+	ProviderStatusStopped = "Stopped"
+	// ProviderStatusPaused means provider service is requested to run, but network conditions do not allow it.
+	ProviderStatusPaused = "Paused"
+	// ProviderStatusRunning means provider service is running.
+	ProviderStatusRunning = "Running"
+)
+
+// StartProviderRequest represents request to start wireguard provider service.
+type StartProviderRequest struct {
+	IdentityAddress string
+	// PriceMinute and PriceGiB are service prices in MYST, defaults are used when zero.
+	PriceMinute float64
+	PriceGiB    float64
+	// AllowMeteredNetwork keeps service running on metered networks.
+	AllowMeteredNetwork bool
+	// AllowBatterySaver keeps service running while battery saver is on.
+	AllowBatterySaver bool
+	// MaxSessions is the number of consumer sessions served at the same time, 1 when not set.
+	// Further consumers are refused until one of the sessions ends.
+	MaxSessions int
+}
+
+// SocketProtector keeps sockets out of the VPN tunnel of the device, e.g. Android VpnService.protect.
+type SocketProtector interface {
+	Protect(socket int) error
+}
+
+// StartProvider starts wireguard provider service. Consumer traffic is forwarded to the internet by the node itself
+// over sockets protected by protector, so it is not routed into a VPN tunnel the device might have established.
+// Up to StartProviderRequest.MaxSessions consumers are served at the same time.
+// Service is paused while network conditions set by SetNetworkConditions do not allow it to run.
+func (mb *MobileNode) StartProvider(req *StartProviderRequest, protector SocketProtector) error {
+	if mb.provider == nil {
+		return errors.New("provider mode is not enabled")
+	}
+
+	maxSessions := req.MaxSessions
+	if maxSessions <= 0 {
+		maxSessions = 1
+	}
+	mb.registerWireguardService(protector, maxSessions)
+
+	priceMinute := req.PriceMinute
+	if priceMinute == 0 {
+		priceMinute = config.FlagWireguardPriceMinute.Value
+	}
+	priceGiB := req.PriceGiB
+	if priceGiB == 0 {
+		priceGiB = config.FlagWireguardPriceGB.Value
+	}
+	return mb.provider.start(providerParams{
+		providerID:        identity.FromAddress(req.IdentityAddress),
+		options:           wireguard_service.DefaultOptions,
+		paymentMethod:     pingpong.NewPaymentMethod(priceGiB, priceMinute),
+		allowMetered:      req.AllowMeteredNetwork,
+		allowBatterySaver: req.AllowBatterySaver,
+	})
+}
+
+// StopProvider stops wireguard provider service.
+func (mb *MobileNode) StopProvider() error {
+	if mb.provider == nil {
+		return errors.New("provider mode is not enabled")
+	}
+	return mb.provider.stop()
+}
+
+// SetNetworkConditions informs node about current network conditions of the device.
+// Provider service is paused on metered network or in battery saver mode unless allowed in StartProviderRequest,
+// and resumed once conditions allow it again.
+func (mb *MobileNode) SetNetworkConditions(metered bool, batterySaver bool) error {
+	if mb.provider == nil {
+		return nil
+	}
+	return mb.provider.setNetworkConditions(metered, batterySaver)
+}
+
+// GetProviderStatus returns provider service status: Stopped, Paused or Running.
+func (mb *MobileNode) GetProviderStatus() string {
+	if mb.provider == nil {
+		return ProviderStatusStopped
+	}
+	return mb.provider.status()
+}
+
+// ProviderEarningsChangeCallback represents provider earnings change callback.
+type ProviderEarningsChangeCallback interface {
+	OnChange(identityAddress string, lifetimeBalance int64, unsettledBalance int64)
+}
+
+// RegisterProviderEarningsChangeCallback registers callback which is called on provider earnings change.
+func (mb *MobileNode) RegisterProviderEarningsChangeCallback(cb ProviderEarningsChangeCallback) {
+	_ = mb.eventBus.SubscribeAsync(event.AppTopicEarningsChanged, func(e event.AppEventEarningsChanged) {
+		cb.OnChange(e.Identity.Address, int64(e.Current.LifetimeBalance), int64(e.Current.UnsettledBalance))
+	})
+}
+
+// ProviderSessionChangeCallback represents provider session change callback.
+type ProviderSessionChangeCallback interface {
+	OnChange(sessionID string, action string)
+}
+
+// RegisterProviderSessionChangeCallback registers callback which is called when consumer session
+// served by the provider is created, updated or removed.
+func (mb *MobileNode) RegisterProviderSessionChangeCallback(cb ProviderSessionChangeCallback) {
+	_ = mb.eventBus.SubscribeAsync(sessionEvent.AppTopicSession, func(e sessionEvent.Payload) {
+		cb.OnChange(e.ID, string(e.Action))
+	})
+}
+
+func (mb *MobileNode) registerWireguardService(protector SocketProtector, maxSessions int) {
+	endpointFactory := newProviderEndpointFactory(protector, maxSessions)
+	mb.serviceRegistry.Register(
+		wireguard.ServiceType,
+		func(serviceOptions service.Options) (service.Service, market.ServiceProposal, error) {
+			loc, err := mb.locationResolver.DetectLocation()
+			if err != nil {
+				return nil, market.ServiceProposal{}, err
+			}
+
+			svc := wireguard_service.NewManager(
+				mb.ipResolver,
+				loc.Country,
+				mb.natService,
+				mb.providerNATPinger,
+				mb.natTracker,
+				mb.eventBus,
+				serviceOptions.(wireguard_service.Options),
+				port.NewPool(),
+				mb.portMapper,
+				mb.serviceFirewall,
+			)
+			svc.SetConnectionEndpointFactory(endpointFactory)
+			return svc, wireguard_service.GetProposal(loc), nil
+		},
+	)
+}
+
+type providerServices interface {
+	Start(providerID identity.Identity, serviceType string, policyIDs []string, options service.Options, pm market.PaymentMethod) (service.ID, error)
+	Stop(id service.ID) error
+}
+
+type providerParams struct {
+	providerID        identity.Identity
+	options           service.Options
+	paymentMethod     market.PaymentMethod
+	allowMetered      bool
+	allowBatterySaver bool
+}
+
+// providerController keeps provider service running while it is requested and network conditions allow it.
+type providerController struct {
+	services providerServices
+
+	mu           sync.Mutex
+	params       *providerParams
+	metered      bool
+	batterySaver bool
+	serviceID    service.ID
+}
+
+func newProviderController(services providerServices) *providerController {
+	return &providerController{services: services}
+}
+
+func (pc *providerController) start(params providerParams) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.params != nil {
+		return errors.New("provider is already started")
+	}
+
+	pc.params = &params
+	if err := pc.apply(); err != nil {
+		pc.params = nil
+		return err
+	}
+	return nil
+}
+
+func (pc *providerController) stop() error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.params = nil
+	return pc.apply()
+}
+
+func (pc *providerController) setNetworkConditions(metered, batterySaver bool) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.metered = metered
+	pc.batterySaver = batterySaver
+	return pc.apply()
+}
+
+func (pc *providerController) status() string {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	switch {
+	case pc.params == nil:
+		return ProviderStatusStopped
+	case pc.serviceID == "":
+		return ProviderStatusPaused
+	default:
+		return ProviderStatusRunning
+	}
+}
+
+func (pc *providerController) paused() bool {
+	return (pc.metered && !pc.params.allowMetered) || (pc.batterySaver && !pc.params.allowBatterySaver)
+}
+
+func (pc *providerController) apply() error {
+	shouldRun := pc.params != nil && !pc.paused()
+	running := pc.serviceID != ""
+
+	switch {
+	case shouldRun && !running:
+		id, err := pc.services.Start(pc.params.providerID, wireguard.ServiceType, nil, pc.params.options, pc.params.paymentMethod)
+		if err != nil {
+			return errors.Wrap(err, "could not start provider service")
+		}
+		pc.serviceID = id
+		log.Info().Msgf("Provider service %s started", id)
+	case !shouldRun && running:
+		id := pc.serviceID
+		pc.serviceID = ""
+		if err := pc.services.Stop(id); err != nil {
+			return errors.Wrap(err, "could not stop provider service")
+		}
+		log.Info().Msgf("Provider service %s stopped", id)
+	}
+	return nil
+}
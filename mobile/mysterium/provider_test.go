@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProviderServices struct {
+	started  []identity.Identity
+	stopped  []service.ID
+	startErr error
+}
+
+func (m *mockProviderServices) Start(providerID identity.Identity, _ string, _ []string, _ service.Options, _ market.PaymentMethod) (service.ID, error) {
+	if m.startErr != nil {
+		return "", m.startErr
+	}
+	m.started = append(m.started, providerID)
+	return service.ID(fmt.Sprintf("service-%d", len(m.started))), nil
+}
+
+func (m *mockProviderServices) Stop(id service.ID) error {
+	m.stopped = append(m.stopped, id)
+	return nil
+}
+
+func TestProviderController_StartStop(t *testing.T) {
+	services := &mockProviderServices{}
+	pc := newProviderController(services)
+	assert.Equal(t, ProviderStatusStopped, pc.status())
+
+	assert.NoError(t, pc.start(providerParams{providerID: identity.FromAddress("0x1")}))
+	assert.Equal(t, ProviderStatusRunning, pc.status())
+	assert.Equal(t, []identity.Identity{identity.FromAddress("0x1")}, services.started)
+
+	assert.Error(t, pc.start(providerParams{providerID: identity.FromAddress("0x1")}))
+
+	assert.NoError(t, pc.stop())
+	assert.Equal(t, ProviderStatusStopped, pc.status())
+	assert.Equal(t, []service.ID{"service-1"}, services.stopped)
+
+	assert.NoError(t, pc.stop())
+	assert.Len(t, services.stopped, 1)
+}
+
+func TestProviderController_StartFailure(t *testing.T) {
+	services := &mockProviderServices{startErr: errors.New("boom")}
+	pc := newProviderController(services)
+
+	assert.Error(t, pc.start(providerParams{}))
+	assert.Equal(t, ProviderStatusStopped, pc.status())
+
+	services.startErr = nil
+	assert.NoError(t, pc.start(providerParams{}))
+	assert.Equal(t, ProviderStatusRunning, pc.status())
+}
+
+func TestProviderController_PausesOnNetworkConditions(t *testing.T) {
+	services := &mockProviderServices{}
+	pc := newProviderController(services)
+
+	assert.NoError(t, pc.setNetworkConditions(true, false))
+	assert.NoError(t, pc.start(providerParams{}))
+	assert.Equal(t, ProviderStatusPaused, pc.status())
+	assert.Empty(t, services.started)
+
+	assert.NoError(t, pc.setNetworkConditions(false, false))
+	assert.Equal(t, ProviderStatusRunning, pc.status())
+	assert.Len(t, services.started, 1)
+
+	assert.NoError(t, pc.setNetworkConditions(false, true))
+	assert.Equal(t, ProviderStatusPaused, pc.status())
+	assert.Equal(t, []service.ID{"service-1"}, services.stopped)
+
+	assert.NoError(t, pc.stop())
+	assert.Equal(t, ProviderStatusStopped, pc.status())
+	assert.Len(t, services.stopped, 1)
+}
+
+func TestProviderController_AllowedNetworkConditions(t *testing.T) {
+	services := &mockProviderServices{}
+	pc := newProviderController(services)
+
+	assert.NoError(t, pc.start(providerParams{allowMetered: true}))
+	assert.NoError(t, pc.setNetworkConditions(true, false))
+	assert.Equal(t, ProviderStatusRunning, pc.status())
+
+	assert.NoError(t, pc.setNetworkConditions(true, true))
+	assert.Equal(t, ProviderStatusPaused, pc.status())
+	assert.Len(t, services.stopped, 1)
+}
+
+func TestProviderSessionLimit(t *testing.T) {
+	limit := &providerSessionLimit{max: 2}
+	assert.NoError(t, limit.acquire())
+	assert.NoError(t, limit.acquire())
+	assert.Equal(t, errProviderSessionLimit, limit.acquire())
+
+	limit.release()
+	assert.NoError(t, limit.acquire())
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mysteriumnetwork/node/consumer/session"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/pkg/errors"
+)
+
+// GetSessionHistoryRequest represents connection history request.
+// Zero values of the fields match every session, zero limit returns all of them.
+type GetSessionHistoryRequest struct {
+	ServiceType string
+	Status      string
+	// FromUnix and ToUnix bound the session start time in seconds since epoch.
+	FromUnix int64
+	ToUnix   int64
+	Offset   int
+	Limit    int
+}
+
+type sessionHistoryDTO struct {
+	SessionID       string  `json:"sessionId"`
+	ProviderID      string  `json:"providerId"`
+	ServiceType     string  `json:"serviceType"`
+	ProviderCountry string  `json:"providerCountry"`
+	StartedAt       string  `json:"startedAt"`
+	Duration        uint64  `json:"duration"`
+	BytesReceived   uint64  `json:"bytesReceived"`
+	BytesSent       uint64  `json:"bytesSent"`
+	TokensSpent     float64 `json:"tokensSpent"`
+	Status          string  `json:"status"`
+}
+
+type getSessionHistoryResponse struct {
+	Sessions []sessionHistoryDTO `json:"sessions"`
+}
+
+// GetSessionHistory returns consumer connection history ordered by start time. Sessions returned as JSON byte array since
+// go mobile does not support complex slices.
+func (mb *MobileNode) GetSessionHistory(req *GetSessionHistoryRequest) ([]byte, error) {
+	sessions, err := mb.sessionStorage.List(newSessionFilter(req))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get session history")
+	}
+	return mapToSessionHistoryResponse(sessions)
+}
+
+func newSessionFilter(req *GetSessionHistoryRequest) session.Filter {
+	filter := session.Filter{
+		ServiceType: req.ServiceType,
+		Status:      req.Status,
+		Offset:      req.Offset,
+		Limit:       req.Limit,
+	}
+	if req.FromUnix > 0 {
+		filter.From = time.Unix(req.FromUnix, 0).UTC()
+	}
+	if req.ToUnix > 0 {
+		filter.To = time.Unix(req.ToUnix, 0).UTC()
+	}
+	return filter
+}
+
+func mapToSessionHistoryResponse(sessions []session.History) ([]byte, error) {
+	res := getSessionHistoryResponse{Sessions: []sessionHistoryDTO{}}
+	for _, se := range sessions {
+		res.Sessions = append(res.Sessions, sessionHistoryDTO{
+			SessionID:       string(se.SessionID),
+			ProviderID:      se.ProviderID.Address,
+			ServiceType:     se.ServiceType,
+			ProviderCountry: se.ProviderCountry,
+			StartedAt:       se.Started.Format(time.RFC3339),
+			Duration:        uint64(se.GetDuration().Seconds()),
+			BytesReceived:   se.DataStats.BytesReceived,
+			BytesSent:       se.DataStats.BytesSent,
+			TokensSpent:     float64(se.Invoice.AgreementTotal) / money.MystSize,
+			Status:          se.Status,
+		})
+	}
+	return json.Marshal(res)
+}
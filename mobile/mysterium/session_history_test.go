@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/consumer/session"
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/payments/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSessionFilter(t *testing.T) {
+	assert.Equal(t, session.Filter{}, newSessionFilter(&GetSessionHistoryRequest{}))
+	assert.Equal(t,
+		session.Filter{
+			ServiceType: "wireguard",
+			Status:      session.SessionStatusCompleted,
+			From:        time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+			To:          time.Date(2020, 4, 2, 0, 0, 0, 0, time.UTC),
+			Offset:      10,
+			Limit:       5,
+		},
+		newSessionFilter(&GetSessionHistoryRequest{
+			ServiceType: "wireguard",
+			Status:      session.SessionStatusCompleted,
+			FromUnix:    1585699200,
+			ToUnix:      1585785600,
+			Offset:      10,
+			Limit:       5,
+		}),
+	)
+}
+
+func TestMapToSessionHistoryResponse(t *testing.T) {
+	started := time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+
+	bytes, err := mapToSessionHistoryResponse(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"sessions":[]}`, string(bytes))
+
+	bytes, err = mapToSessionHistoryResponse([]session.History{{
+		SessionID:       "s1",
+		ProviderID:      identity.FromAddress("0x1"),
+		ServiceType:     "wireguard",
+		ProviderCountry: "LT",
+		Started:         started,
+		Updated:         started.Add(2 * time.Minute),
+		Status:          session.SessionStatusCompleted,
+		DataStats:       connection.Statistics{BytesReceived: 2048, BytesSent: 1024},
+		Invoice:         crypto.Invoice{AgreementTotal: 50000000},
+	}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"sessions":[{
+		"sessionId":"s1",
+		"providerId":"0x1",
+		"serviceType":"wireguard",
+		"providerCountry":"LT",
+		"startedAt":"2020-04-01T10:00:00Z",
+		"duration":120,
+		"bytesReceived":2048,
+		"bytesSent":1024,
+		"tokensSpent":0.5,
+		"status":"Completed"
+	}]}`, string(bytes))
+}
@@ -0,0 +1,321 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/services/wireguard"
+	"github.com/mysteriumnetwork/node/services/wireguard/endpoint/netstack"
+	"github.com/mysteriumnetwork/node/services/wireguard/key"
+	"github.com/mysteriumnetwork/node/utils/netutil"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"golang.zx2c4.com/wireguard/device"
+)
+
+const providerInterfaceName = "myst-provider"
+
+var errProviderSessionLimit = errors.New("provider is already serving maximum number of sessions")
+
+// providerSessionLimit limits the number of sessions served concurrently.
+type providerSessionLimit struct {
+	mu     sync.Mutex
+	max    int
+	active int
+}
+
+func (l *providerSessionLimit) acquire() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.active >= l.max {
+		return errProviderSessionLimit
+	}
+	l.active++
+	return nil
+}
+
+func (l *providerSessionLimit) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+}
+
+// newProviderEndpointFactory returns wireguard connection endpoint factory for provider sessions.
+// Endpoints run userspace wireguard on top of a userspace network stack, which forwards consumer
+// traffic to the internet over sockets protected by the host app. At most maxSessions endpoints are started at a time.
+func newProviderEndpointFactory(protector SocketProtector, maxSessions int) func() (wireguard.ConnectionEndpoint, error) {
+	limit := &providerSessionLimit{max: maxSessions}
+	protected := protectedNetworks()
+	return func() (wireguard.ConnectionEndpoint, error) {
+		return &providerEndpoint{
+			protector:         protector,
+			limit:             limit,
+			protectedNetworks: protected,
+			socketFd:          peekLookAtSocketFd4,
+		}, nil
+	}
+}
+
+// protectedNetworks returns provider networks consumers are not allowed to access.
+func protectedNetworks() (nets []*net.IPNet) {
+	for _, s := range strings.Split(config.GetString(config.FlagFirewallProtectedNetworks), ",") {
+		if s == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			log.Error().Err(err).Msg("Could not parse protected network string")
+			continue
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+type providerEndpoint struct {
+	protector         SocketProtector
+	limit             *providerSessionLimit
+	protectedNetworks []*net.IPNet
+	socketFd          func(d *device.Device) (int, error)
+
+	mu         sync.Mutex
+	privateKey string
+	ipAddr     net.IPNet
+	dnsPort    int
+	endpoint   net.UDPAddr
+	device     *device.Device
+	acquired   bool
+}
+
+// StartConsumerMode is not supported, consumer connections are handled by wireguardConnection.
+func (pe *providerEndpoint) StartConsumerMode(_ wireguard.ConsumerModeConfig) error {
+	return errors.New("consumer mode is not supported by provider endpoint")
+}
+
+// StartProviderMode starts userspace wireguard device on top of the userspace network stack forwarding consumer traffic.
+func (pe *providerEndpoint) StartProviderMode(config wireguard.ProviderModeConfig) (err error) {
+	if config.PublicIP == "" {
+		return errors.New("public IP is required")
+	}
+	if config.ListenPort == 0 {
+		return errors.New("listen port is required")
+	}
+
+	if err := pe.limit.acquire(); err != nil {
+		return err
+	}
+	pe.mu.Lock()
+	pe.acquired = true
+	pe.mu.Unlock()
+	defer func() {
+		if err != nil {
+			_ = pe.Stop()
+		}
+	}()
+
+	privateKey, err := key.GeneratePrivateKey()
+	if err != nil {
+		return errors.Wrap(err, "could not generate private key")
+	}
+
+	ipAddr := config.Network
+	ipAddr.IP = netutil.FirstIP(ipAddr)
+
+	tunDevice, err := netstack.NewDevice([]net.IP{ipAddr.IP}, androidTunMtu)
+	if err != nil {
+		return errors.Wrap(err, "could not create tunnel device")
+	}
+	if err := tunDevice.Forward(pe.dial, pe.allowed); err != nil {
+		tunDevice.Close()
+		return errors.Wrap(err, "could not forward tunnel traffic")
+	}
+	dev := device.NewDevice(tunDevice, device.NewLogger(device.LogLevelDebug, "[userspace-wg-provider]"))
+
+	pe.mu.Lock()
+	pe.privateKey = privateKey
+	pe.ipAddr = ipAddr
+	pe.dnsPort = config.DNSPort
+	pe.endpoint = net.UDPAddr{IP: net.ParseIP(config.PublicIP), Port: config.ListenPort}
+	pe.device = dev
+	pe.mu.Unlock()
+
+	deviceConfig := wireguard.DeviceConfig{
+		PrivateKey: privateKey,
+		ListenPort: config.ListenPort,
+	}
+	if err := dev.IpcSetOperation(bufio.NewReader(strings.NewReader(deviceConfig.Encode()))); err != nil {
+		return errors.Wrap(err, "could not configure device")
+	}
+	dev.Up()
+
+	socket, err := pe.socketFd(dev)
+	if err != nil {
+		return errors.Wrap(err, "could not get socket")
+	}
+	if err := pe.protector.Protect(socket); err != nil {
+		return errors.Wrap(err, "could not protect socket")
+	}
+	return nil
+}
+
+// allowed checks whether consumer is allowed to reach the destination.
+func (pe *providerEndpoint) allowed(ip net.IP) bool {
+	pe.mu.Lock()
+	tunnelIP := pe.ipAddr.IP
+	pe.mu.Unlock()
+
+	if ip.Equal(tunnelIP) {
+		return true
+	}
+	for _, protected := range pe.protectedNetworks {
+		if protected.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// dial connects to the consumer destination over a protected socket, so that it does not loop back into
+// a VPN tunnel of the device. DNS queries to the provider tunnel address are served by provider DNS proxy.
+func (pe *providerEndpoint) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	pe.mu.Lock()
+	tunnelIP, dnsPort := pe.ipAddr.IP, pe.dnsPort
+	pe.mu.Unlock()
+
+	if net.ParseIP(host).Equal(tunnelIP) {
+		if port != "53" || dnsPort == 0 {
+			return nil, errors.Errorf("%s is not served by provider", address)
+		}
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, net.JoinHostPort("127.0.0.1", strconv.Itoa(dnsPort)))
+	}
+
+	dialer := net.Dialer{Control: pe.protectSocket}
+	return dialer.DialContext(ctx, network, address)
+}
+
+func (pe *providerEndpoint) protectSocket(_, _ string, conn syscall.RawConn) error {
+	var err error
+	if controlErr := conn.Control(func(fd uintptr) {
+		err = pe.protector.Protect(int(fd))
+	}); controlErr != nil {
+		return controlErr
+	}
+	return err
+}
+
+// AddPeer adds consumer peer to the device.
+func (pe *providerEndpoint) AddPeer(_ string, peer wireguard.Peer) error {
+	dev, err := pe.startedDevice()
+	if err != nil {
+		return err
+	}
+	if err := dev.IpcSetOperation(bufio.NewReader(strings.NewReader(peer.Encode()))); err != nil {
+		return errors.Wrap(err, "could not add device peer")
+	}
+	return nil
+}
+
+// PeerStats returns stats of the consumer peer.
+func (pe *providerEndpoint) PeerStats() (*wireguard.Stats, error) {
+	dev, err := pe.startedDevice()
+	if err != nil {
+		return nil, err
+	}
+	deviceState, err := wireguard.ParseUserspaceDevice(dev.IpcGetOperation)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse userspace wg device state")
+	}
+	stats, err := wireguard.ParseDevicePeerStats(deviceState)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get userspace wg peer stats")
+	}
+	return stats, nil
+}
+
+// ConfigureRoutes is not supported, consumer traffic is forwarded by the userspace network stack without routes.
+func (pe *providerEndpoint) ConfigureRoutes(_ net.IP) error {
+	return errors.New("routes are not configurable by provider endpoint")
+}
+
+// Config provides wireguard service configuration for the current session.
+func (pe *providerEndpoint) Config() (wireguard.ServiceConfig, error) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	publicKey, err := key.PrivateKeyToPublicKey(pe.privateKey)
+	if err != nil {
+		return wireguard.ServiceConfig{}, err
+	}
+
+	consumerIP := make(net.IP, len(pe.ipAddr.IP))
+	copy(consumerIP, pe.ipAddr.IP)
+	consumerIP[len(consumerIP)-1] = byte(2)
+
+	var config wireguard.ServiceConfig
+	config.Provider.PublicKey = publicKey
+	config.Provider.Endpoint = pe.endpoint
+	config.Consumer.IPAddress = net.IPNet{IP: consumerIP, Mask: pe.ipAddr.Mask}
+	return config, nil
+}
+
+// InterfaceName returns name of the provider interface.
+func (pe *providerEndpoint) InterfaceName() string {
+	return providerInterfaceName
+}
+
+// Stop closes wireguard device together with the network stack and connections forwarded by it.
+func (pe *providerEndpoint) Stop() error {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	if pe.device != nil {
+		pe.device.Close()
+		pe.device = nil
+	}
+	if pe.acquired {
+		pe.limit.release()
+		pe.acquired = false
+	}
+	return nil
+}
+
+func (pe *providerEndpoint) startedDevice() (*device.Device, error) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	if pe.device == nil {
+		return nil, errors.New("device is not started")
+	}
+	return pe.device, nil
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mysterium
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/services/wireguard"
+	"github.com/mysteriumnetwork/node/services/wireguard/endpoint/netstack"
+	"github.com/mysteriumnetwork/node/services/wireguard/key"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/device"
+)
+
+const fakeWireguardSocket = 1000
+
+type mockSocketProtector struct {
+	mu      sync.Mutex
+	sockets []int
+}
+
+func (m *mockSocketProtector) Protect(socket int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sockets = append(m.sockets, socket)
+	return nil
+}
+
+func (m *mockSocketProtector) protected() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int(nil), m.sockets...)
+}
+
+// newConsumerTunnel connects a userspace consumer device to the provider endpoint over localhost.
+func newConsumerTunnel(t *testing.T, provider *providerEndpoint) *netstack.Device {
+	config, err := provider.Config()
+	require.NoError(t, err)
+
+	tunDevice, err := netstack.NewDevice([]net.IP{config.Consumer.IPAddress.IP}, androidTunMtu)
+	require.NoError(t, err)
+	dev := device.NewDevice(tunDevice, device.NewLogger(device.LogLevelError, "[userspace-wg-consumer]"))
+	t.Cleanup(dev.Close)
+
+	privateKey, err := key.GeneratePrivateKey()
+	require.NoError(t, err)
+	publicKey, err := key.PrivateKeyToPublicKey(privateKey)
+	require.NoError(t, err)
+
+	consumerPeer := wireguard.Peer{
+		PublicKey:  publicKey,
+		AllowedIPs: []string{config.Consumer.IPAddress.IP.String() + "/32"},
+	}
+	require.NoError(t, provider.AddPeer(providerInterfaceName, consumerPeer))
+
+	deviceConfig := wireguard.DeviceConfig{PrivateKey: privateKey}
+	ipcSet(t, dev, deviceConfig.Encode())
+	providerPeer := wireguard.Peer{
+		PublicKey:  config.Provider.PublicKey,
+		Endpoint:   &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: config.Provider.Endpoint.Port},
+		AllowedIPs: []string{"0.0.0.0/0"},
+	}
+	ipcSet(t, dev, providerPeer.Encode())
+	dev.Up()
+	return tunDevice
+}
+
+func ipcSet(t *testing.T, dev *device.Device, config string) {
+	if err := dev.IpcSetOperation(bufio.NewReader(strings.NewReader(config))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestProviderEndpoint(t *testing.T, dnsPort int) (*providerEndpoint, *mockSocketProtector) {
+	udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	listenPort := udpConn.LocalAddr().(*net.UDPAddr).Port
+	require.NoError(t, udpConn.Close())
+
+	protector := &mockSocketProtector{}
+	pe := &providerEndpoint{
+		protector: protector,
+		limit:     &providerSessionLimit{max: 1},
+		socketFd: func(_ *device.Device) (int, error) {
+			return fakeWireguardSocket, nil
+		},
+	}
+	_, network, _ := net.ParseCIDR("10.182.0.0/24")
+	require.NoError(t, pe.StartProviderMode(wireguard.ProviderModeConfig{
+		Network:    *network,
+		ListenPort: listenPort,
+		PublicIP:   "1.2.3.4",
+		DNSPort:    dnsPort,
+	}))
+	t.Cleanup(func() { pe.Stop() })
+	return pe, protector
+}
+
+func externalIP(t *testing.T) net.IP {
+	addrs, err := net.InterfaceAddrs()
+	require.NoError(t, err)
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP
+		}
+	}
+	t.Skip("no external IPv4 address")
+	return nil
+}
+
+func TestProviderEndpoint_ForwardsConsumerTraffic(t *testing.T) {
+	listener, err := net.Listen("tcp4", net.JoinHostPort(externalIP(t).String(), "0"))
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	provider, protector := newTestProviderEndpoint(t, 0)
+	consumer := newConsumerTunnel(t, provider)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := consumer.DialContext(ctx, "tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(reply))
+
+	// Both wireguard socket and the socket forwarding consumer connection are protected.
+	assert.Len(t, protector.protected(), 2)
+	assert.Equal(t, fakeWireguardSocket, protector.protected()[0])
+
+	stats, err := provider.PeerStats()
+	require.NoError(t, err)
+	assert.NotZero(t, stats.BytesReceived)
+	assert.NotZero(t, stats.BytesSent)
+}
+
+func TestProviderEndpoint_ServesDNSByProviderProxy(t *testing.T) {
+	dnsProxy, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer dnsProxy.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := dnsProxy.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			dnsProxy.WriteTo(buf[:n], addr)
+		}
+	}()
+
+	provider, _ := newTestProviderEndpoint(t, dnsProxy.LocalAddr().(*net.UDPAddr).Port)
+	consumer := newConsumerTunnel(t, provider)
+
+	conn, err := consumer.DialContext(context.Background(), "udp", "10.182.0.1:53")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("query"))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(10*time.Second)))
+	reply := make([]byte, 512)
+	n, err := conn.Read(reply)
+	require.NoError(t, err)
+	assert.Equal(t, "query", string(reply[:n]))
+}
+
+func TestProviderEndpoint_RefusesProtectedNetworks(t *testing.T) {
+	_, protected, _ := net.ParseCIDR("192.168.0.0/16")
+	pe := &providerEndpoint{
+		protectedNetworks: []*net.IPNet{protected},
+		ipAddr:            net.IPNet{IP: net.ParseIP("10.182.0.1"), Mask: net.CIDRMask(24, 32)},
+	}
+
+	assert.False(t, pe.allowed(net.ParseIP("192.168.1.1")))
+	assert.True(t, pe.allowed(net.ParseIP("8.8.8.8")))
+	assert.True(t, pe.allowed(net.ParseIP("10.182.0.1")))
+
+	_, err := pe.dial(context.Background(), "tcp", "10.182.0.1:22")
+	assert.Error(t, err)
+}
+
+func TestProviderEndpoint_LimitsSessions(t *testing.T) {
+	provider, _ := newTestProviderEndpoint(t, 0)
+
+	second := &providerEndpoint{limit: provider.limit}
+	err := second.StartProviderMode(wireguard.ProviderModeConfig{ListenPort: 1, PublicIP: "1.2.3.4"})
+	assert.Equal(t, errProviderSessionLimit, err)
+}
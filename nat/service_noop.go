@@ -0,0 +1,47 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+// NewNoopService returns NAT service which does not touch the system.
+// It is used where forwarding of tunnel traffic is done by the host application,
+// e.g. a provider running inside a mobile app.
+func NewNoopService() NATService {
+	return &serviceNoop{}
+}
+
+type serviceNoop struct{}
+
+// Enable does nothing.
+func (s *serviceNoop) Enable() error {
+	return nil
+}
+
+// Setup does nothing and returns no rules.
+func (s *serviceNoop) Setup(_ Options) ([]interface{}, error) {
+	return nil, nil
+}
+
+// Del does nothing.
+func (s *serviceNoop) Del(_ []interface{}) error {
+	return nil
+}
+
+// Disable does nothing.
+func (s *serviceNoop) Disable() error {
+	return nil
+}
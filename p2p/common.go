@@ -35,6 +35,8 @@ const (
 	pingMaxPorts       = 20
 	requiredConnCount  = 2
 	consumerInitialTTL = 128
+
+	channelHandlersReadyValue = "HANDLERS READY"
 )
 
 type brokerConnector interface {
@@ -33,6 +33,8 @@ var (
 const (
 	// ContactTypeV1 is p2p contact type.
 	ContactTypeV1 = "nats/p2p/v1"
+	// ContactTypeDirectV1 is p2p contact type of publicly reachable providers exchanging config without broker.
+	ContactTypeDirectV1 = "direct/p2p/v1"
 )
 
 // ContactDefinition represents p2p contact which contains NATS broker addresses for connection.
@@ -40,6 +42,11 @@ type ContactDefinition struct {
 	BrokerAddresses []string `json:"broker_addresses"`
 }
 
+// DirectContactDefinition represents p2p contact which contains provider UDP address for config exchange.
+type DirectContactDefinition struct {
+	Address string `json:"address"`
+}
+
 // ParseContact tries to parse p2p contact from given contacts list.
 func ParseContact(contacts market.ContactList) (ContactDefinition, error) {
 	for _, c := range contacts {
@@ -54,6 +61,20 @@ func ParseContact(contacts market.ContactList) (ContactDefinition, error) {
 	return ContactDefinition{}, ErrContactNotFound
 }
 
+// ParseDirectContact tries to parse direct p2p contact from given contacts list.
+func ParseDirectContact(contacts market.ContactList) (DirectContactDefinition, error) {
+	for _, c := range contacts {
+		if c.Type == ContactTypeDirectV1 {
+			def, ok := c.Definition.(DirectContactDefinition)
+			if !ok {
+				return DirectContactDefinition{}, fmt.Errorf("invalid direct p2p contact definition: %#v", c.Definition)
+			}
+			return def, nil
+		}
+	}
+	return DirectContactDefinition{}, ErrContactNotFound
+}
+
 // RegisterContactUnserializer registers global proposal contact unserializer.
 func RegisterContactUnserializer() {
 	market.RegisterContactUnserializer(
@@ -64,4 +85,12 @@ func RegisterContactUnserializer() {
 			return contact, err
 		},
 	)
+	market.RegisterContactUnserializer(
+		ContactTypeDirectV1,
+		func(rawDefinition *json.RawMessage) (market.ContactDefinition, error) {
+			var contact DirectContactDefinition
+			err := json.Unmarshal(*rawDefinition, &contact)
+			return contact, err
+		},
+	)
 }
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	nats_lib "github.com/nats-io/go-nats"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/pb"
 
 	"github.com/rs/zerolog/log"
@@ -39,11 +41,18 @@ import (
 
 // Dialer knows how to exchange p2p keys and encrypted configuration and creates ready to use p2p channels.
 type Dialer interface {
-	// Dial exchanges p2p configuration via broker, performs NAT pinging if needed
+	// Dial exchanges p2p configuration with provider found in given contacts, performs NAT pinging if needed
 	// and create p2p channel which is ready for communication.
-	Dial(ctx context.Context, consumerID, providerID identity.Identity, serviceType string, contactDef ContactDefinition) (Channel, error)
+	Dial(ctx context.Context, consumerID, providerID identity.Identity, serviceType string, contacts market.ContactList) (Channel, error)
 }
 
+// directDialTimeout limits direct config exchange, so there is time left to fall back to broker
+// when provider is not reachable.
+const directDialTimeout = 10 * time.Second
+
+// exchangeSendFunc sends config exchange message to provider and returns its reply.
+type exchangeSendFunc func(ctx context.Context, subject string, data []byte) ([]byte, error)
+
 // NewDialer creates new p2p communication dialer which is used on consumer side.
 func NewDialer(broker brokerConnector, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, consumerPinger natConsumerPinger, portPool port.ServicePortSupplier) Dialer {
 	return &dialer{
@@ -53,6 +62,7 @@ func NewDialer(broker brokerConnector, signer identity.SignerFactory, verifier i
 		verifier:       verifier,
 		portPool:       portPool,
 		consumerPinger: consumerPinger,
+		directTimeout:  directDialTimeout,
 	}
 }
 
@@ -64,11 +74,74 @@ type dialer struct {
 	signer         identity.SignerFactory
 	verifier       identity.Verifier
 	ipResolver     ip.Resolver
+	directTimeout  time.Duration
+}
+
+// Dial exchanges p2p configuration with provider found in given contacts, performs NAT pinging if needed
+// and create p2p channel which is ready for communication. Direct contact is preferred and broker is used
+// if provider is not reachable directly.
+func (m *dialer) Dial(ctx context.Context, consumerID, providerID identity.Identity, serviceType string, contacts market.ContactList) (Channel, error) {
+	var directErr error
+	if directDef, err := ParseDirectContact(contacts); err == nil {
+		channel, err := m.dialDirect(ctx, consumerID, providerID, serviceType, directDef)
+		if err == nil {
+			return channel, nil
+		}
+		log.Warn().Err(err).Msgf("Could not dial provider %s directly, falling back to broker", providerID.Address)
+		directErr = err
+	} else if !errors.Is(err, ErrContactNotFound) {
+		return nil, err
+	}
+
+	contactDef, err := ParseContact(contacts)
+	if errors.Is(err, ErrContactNotFound) && directErr != nil {
+		return nil, fmt.Errorf("could not dial provider directly: %w", directErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.dialBroker(ctx, consumerID, providerID, serviceType, contactDef)
+}
+
+// dialDirect exchanges p2p configuration straight with publicly reachable provider.
+func (m *dialer) dialDirect(ctx context.Context, consumerID, providerID identity.Identity, serviceType string, contactDef DirectContactDefinition) (Channel, error) {
+	addr, err := net.ResolveUDPAddr("udp4", contactDef.Address)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve provider address %s: %w", contactDef.Address, err)
+	}
+	if _, err := firewall.AllowIPAccess(addr.IP.String()); err != nil {
+		return nil, fmt.Errorf("could not add provider IP firewall rule: %w", err)
+	}
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not create UDP conn for config exchange: %w", err)
+	}
+	defer conn.Close()
+
+	exchangeCtx, cancel := context.WithTimeout(ctx, m.directTimeout)
+	defer cancel()
+
+	var id uint64
+	send := func(ctx context.Context, subject string, data []byte) ([]byte, error) {
+		id++
+		return sendDirect(ctx, conn, id, subject, data)
+	}
+	config, ackReply, err := m.exchangeConfig(exchangeCtx, send, providerID, serviceType, consumerID)
+	if err != nil {
+		return nil, fmt.Errorf("could not exchange config: %w", err)
+	}
+	// Provider replies to the ack only when channel handlers are ready.
+	if err := m.channelHandlersReady(ackReply); err != nil {
+		return nil, err
+	}
+
+	peerReady := make(chan struct{})
+	close(peerReady)
+	return m.connect(ctx, providerID, config, peerReady)
 }
 
-// Dial exchanges p2p configuration via broker, performs NAT pinging if needed
-// and create p2p channel which is ready for communication.
-func (m *dialer) Dial(ctx context.Context, consumerID, providerID identity.Identity, serviceType string, contactDef ContactDefinition) (Channel, error) {
+// dialBroker exchanges p2p configuration with provider via broker.
+func (m *dialer) dialBroker(ctx context.Context, consumerID, providerID identity.Identity, serviceType string, contactDef ContactDefinition) (Channel, error) {
 	brokerConn, err := m.broker.Connect(contactDef.BrokerAddresses...)
 	if err != nil {
 		return nil, fmt.Errorf("could not open broker conn: %w", err)
@@ -79,22 +152,31 @@ func (m *dialer) Dial(ctx context.Context, consumerID, providerID identity.Ident
 	var once sync.Once
 	_, err = brokerConn.Subscribe(channelHandlersReadySubject(providerID, serviceType), func(msg *nats_lib.Msg) {
 		defer once.Do(func() { close(peerReady) })
-		if err := m.channelHandlersReady(msg); err != nil {
+		if err := m.channelHandlersReady(msg.Data); err != nil {
 			log.Err(err).Msg("Channel handlers ready handler setup failed")
 			return
 		}
 	})
 
-	config, err := m.exchangeConfig(ctx, brokerConn, providerID, serviceType, consumerID)
+	send := func(ctx context.Context, subject string, data []byte) ([]byte, error) {
+		return m.sendSignedMsg(ctx, subject, data, brokerConn)
+	}
+	config, _, err := m.exchangeConfig(ctx, send, providerID, serviceType, consumerID)
 	if err != nil {
 		return nil, fmt.Errorf("could not exchange config: %w", err)
 	}
+	return m.connect(ctx, providerID, config, peerReady)
+}
 
+// connect connects to the provider using exchanged config, pinging it if needed, and creates p2p channel
+// once provider's channel handlers are ready.
+func (m *dialer) connect(ctx context.Context, providerID identity.Identity, config *p2pConnectConfig, peerReady <-chan struct{}) (Channel, error) {
 	if _, err := firewall.AllowIPAccess(config.peerPublicIP); err != nil {
 		return nil, fmt.Errorf("could not add peer IP firewall rule: %w", err)
 	}
 
 	var conn1, conn2 *net.UDPConn
+	var err error
 	if len(config.peerPorts) == requiredConnCount {
 		log.Debug().Msg("Skipping provider ping")
 		conn1, err = net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[0]}, &net.UDPAddr{IP: net.ParseIP(config.peerIP()), Port: config.peerPorts[0]})
@@ -131,10 +213,12 @@ func (m *dialer) Dial(ctx context.Context, consumerID, providerID identity.Ident
 	return channel, nil
 }
 
-func (m *dialer) exchangeConfig(ctx context.Context, brokerConn nats.Connection, providerID identity.Identity, serviceType string, consumerID identity.Identity) (*p2pConnectConfig, error) {
+// exchangeConfig exchanges keys and encrypted connection configs with the provider. It returns the reply to
+// the final ack message along with the config.
+func (m *dialer) exchangeConfig(ctx context.Context, send exchangeSendFunc, providerID identity.Identity, serviceType string, consumerID identity.Identity) (*p2pConnectConfig, []byte, error) {
 	pubKey, privateKey, err := GenerateKey()
 	if err != nil {
-		return nil, fmt.Errorf("could not generate consumer p2p keys: %w", err)
+		return nil, nil, fmt.Errorf("could not generate consumer p2p keys: %w", err)
 	}
 
 	// Send initial exchange with signed consumer public key.
@@ -144,40 +228,40 @@ func (m *dialer) exchangeConfig(ctx context.Context, brokerConn nats.Connection,
 	log.Debug().Msgf("Consumer %s sending public key %s to provider %s", consumerID.Address, beginExchangeMsg.PublicKey, providerID.Address)
 	packedMsg, err := packSignedMsg(m.signer, consumerID, beginExchangeMsg)
 	if err != nil {
-		return nil, fmt.Errorf("could not pack signed message: %v", err)
+		return nil, nil, fmt.Errorf("could not pack signed message: %v", err)
 	}
-	exchangeMsgBrokerReply, err := m.sendSignedMsg(ctx, configExchangeSubject(providerID, serviceType), packedMsg, brokerConn)
+	exchangeMsgBrokerReply, err := send(ctx, configExchangeSubject(providerID, serviceType), packedMsg)
 	if err != nil {
-		return nil, fmt.Errorf("could not send signed message: %w", err)
+		return nil, nil, fmt.Errorf("could not send signed message: %w", err)
 	}
 
 	// Parse provider response with public key and encrypted and signed connection config.
 	exchangeMsgReplySignedMsg, err := unpackSignedMsg(m.verifier, exchangeMsgBrokerReply)
 	if err != nil {
-		return nil, fmt.Errorf("could not unpack peer siged message: %w", err)
+		return nil, nil, fmt.Errorf("could not unpack peer siged message: %w", err)
 	}
 	var exchangeMsgReply pb.P2PConfigExchangeMsg
 	if err := proto.Unmarshal(exchangeMsgReplySignedMsg.Data, &exchangeMsgReply); err != nil {
-		return nil, fmt.Errorf("could not unmarshal peer signed message payload: %w", err)
+		return nil, nil, fmt.Errorf("could not unmarshal peer signed message payload: %w", err)
 	}
 	peerPubKey, err := DecodePublicKey(exchangeMsgReply.PublicKey)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	peerConnConfig, err := decryptConnConfigMsg(exchangeMsgReply.ConfigCiphertext, privateKey, peerPubKey)
 	if err != nil {
-		return nil, fmt.Errorf("could not decrypt peer conn config: %w", err)
+		return nil, nil, fmt.Errorf("could not decrypt peer conn config: %w", err)
 	}
 	log.Debug().Msgf("Consumer %s received provider %s with config: %v", consumerID.Address, providerID.Address, peerConnConfig)
 
 	// Finally send consumer encrypted and signed connect config in ack message.
 	publicIP, err := m.ipResolver.GetPublicIP()
 	if err != nil {
-		return nil, fmt.Errorf("could not get public IP: %v", err)
+		return nil, nil, fmt.Errorf("could not get public IP: %v", err)
 	}
 	localPorts, err := acquireLocalPorts(m.portPool, len(peerConnConfig.Ports))
 	if err != nil {
-		return nil, fmt.Errorf("could not acquire local ports: %v", err)
+		return nil, nil, fmt.Errorf("could not acquire local ports: %v", err)
 	}
 	connConfig := &pb.P2PConnectConfig{
 		PublicIP: publicIP,
@@ -185,7 +269,7 @@ func (m *dialer) exchangeConfig(ctx context.Context, brokerConn nats.Connection,
 	}
 	connConfigCiphertext, err := encryptConnConfigMsg(connConfig, privateKey, peerPubKey)
 	if err != nil {
-		return nil, fmt.Errorf("could not encrypt config msg: %v", err)
+		return nil, nil, fmt.Errorf("could not encrypt config msg: %v", err)
 	}
 	endExchangeMsg := &pb.P2PConfigExchangeMsg{
 		PublicKey:        pubKey.Hex(),
@@ -194,11 +278,11 @@ func (m *dialer) exchangeConfig(ctx context.Context, brokerConn nats.Connection,
 	log.Debug().Msgf("Consumer %s sending ack with encrypted config to provider %s", consumerID.Address, providerID.Address)
 	packedMsg, err = packSignedMsg(m.signer, consumerID, endExchangeMsg)
 	if err != nil {
-		return nil, fmt.Errorf("could not pack signed message: %v", err)
+		return nil, nil, fmt.Errorf("could not pack signed message: %v", err)
 	}
-	_, err = m.sendSignedMsg(ctx, configExchangeACKSubject(providerID, serviceType), packedMsg, brokerConn)
+	ackReply, err := send(ctx, configExchangeACKSubject(providerID, serviceType), packedMsg)
 	if err != nil {
-		return nil, fmt.Errorf("could not send signed msg: %v", err)
+		return nil, nil, fmt.Errorf("could not send signed msg: %v", err)
 	}
 
 	return &p2pConnectConfig{
@@ -208,7 +292,7 @@ func (m *dialer) exchangeConfig(ctx context.Context, brokerConn nats.Connection,
 		peerPubKey:   peerPubKey,
 		peerPublicIP: peerConnConfig.PublicIP,
 		peerPorts:    int32ToIntSlice(peerConnConfig.Ports),
-	}, nil
+	}, ackReply, nil
 }
 
 func (m *dialer) sendSignedMsg(ctx context.Context, subject string, msg []byte, brokerConn nats.Connection) ([]byte, error) {
@@ -219,12 +303,12 @@ func (m *dialer) sendSignedMsg(ctx context.Context, subject string, msg []byte,
 	return reply.Data, nil
 }
 
-func (m *dialer) channelHandlersReady(msg *nats_lib.Msg) error {
+func (m *dialer) channelHandlersReady(data []byte) error {
 	var handlersReady pb.P2PChannelHandlersReady
-	if err := proto.Unmarshal(msg.Data, &handlersReady); err != nil {
+	if err := proto.Unmarshal(data, &handlersReady); err != nil {
 		return fmt.Errorf("failed to unmarshal handlers ready message: %w", err)
 	}
-	if handlersReady.Value != "HANDLERS READY" {
+	if handlersReady.Value != channelHandlersReadyValue {
 		return errors.New("incorrect handlers ready message value")
 	}
 
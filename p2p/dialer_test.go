@@ -19,9 +19,11 @@ package p2p
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"net"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -29,6 +31,7 @@ import (
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/nat/mapping"
 	"github.com/mysteriumnetwork/node/nat/traversal"
 	"github.com/stretchr/testify/assert"
@@ -88,8 +91,8 @@ func TestDialer_Exchange_And_Communication_With_Provider(t *testing.T) {
 			portPool := port.NewPool()
 
 			// Provider starts listening.
-			channelListener := NewListener(brokerConn, signerFactory, verifier, test.ipResolver, test.natProviderPinger, portPool, test.portMapper)
-			err := channelListener.Listen(providerID, "wireguard", func(ch Channel) {
+			channelListener := NewListener(brokerConn, signerFactory, verifier, test.ipResolver, test.natProviderPinger, portPool, test.portMapper, 0)
+			_, err := channelListener.Listen(providerID, "wireguard", func(ch Channel) {
 				ch.Handle("test", func(c Context) error {
 					return c.OkWithReply(&Message{Data: []byte("pong")})
 				})
@@ -100,7 +103,9 @@ func TestDialer_Exchange_And_Communication_With_Provider(t *testing.T) {
 			channelDialer := NewDialer(mockBroker, signerFactory, verifier, test.ipResolver, test.natConsumerPinger, portPool)
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
-			consumerChannel, err := channelDialer.Dial(ctx, consumerID, providerID, "wireguard", ContactDefinition{BrokerAddresses: []string{"broker"}})
+			consumerChannel, err := channelDialer.Dial(ctx, consumerID, providerID, "wireguard", market.ContactList{
+				{Type: ContactTypeV1, Definition: ContactDefinition{BrokerAddresses: []string{"broker"}}},
+			})
 			assert.NoError(t, err)
 			defer consumerChannel.Close()
 
@@ -111,6 +116,98 @@ func TestDialer_Exchange_And_Communication_With_Provider(t *testing.T) {
 	}
 }
 
+func TestDialer_Exchange_With_Provider_Directly(t *testing.T) {
+	consumerID, providerID, ks, cleanup := createTestIdentities(t)
+	defer cleanup()
+
+	signerFactory := func(id identity.Identity) identity.Signer {
+		return identity.NewSigner(ks, identity.FromAddress(id.Address))
+	}
+	verifier := identity.NewVerifierSigned()
+	ipResolver := ip.NewResolverMock("127.0.0.1")
+	portPool := port.NewPool()
+	ports, err := acquirePorts(1)
+	assert.NoError(t, err)
+
+	// Provider starts listening without broker.
+	brokerConn := nats.StartConnectionMock()
+	defer brokerConn.Close()
+	channelListener := NewListener(brokerConn, signerFactory, verifier, ipResolver, &mockProviderNATPinger{}, portPool, &mockPortMapper{}, ports[0])
+	_, err = channelListener.Listen(providerID, "wireguard", func(ch Channel) {
+		ch.Handle("test", func(c Context) error {
+			return c.OkWithReply(&Message{Data: []byte("pong")})
+		})
+	})
+	assert.NoError(t, err)
+
+	contacts := channelListener.GetContacts()
+	assert.Len(t, contacts, 2)
+	assert.Equal(t, market.Contact{
+		Type:       ContactTypeDirectV1,
+		Definition: DirectContactDefinition{Address: net.JoinHostPort("127.0.0.1", strconv.Itoa(ports[0]))},
+	}, contacts[1])
+
+	// Consumer dials provider using direct contact only.
+	channelDialer := NewDialer(&mockBroker{}, signerFactory, verifier, ipResolver, &mockConsumerNATPinger{}, portPool)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	consumerChannel, err := channelDialer.Dial(ctx, consumerID, providerID, "wireguard", contacts[1:])
+	assert.NoError(t, err)
+	defer consumerChannel.Close()
+
+	res, err := consumerChannel.Send(context.Background(), "test", &Message{Data: []byte("ping")})
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", string(res.Data))
+}
+
+func TestDialer_Falls_Back_To_Broker_When_Provider_Is_Unreachable(t *testing.T) {
+	consumerID, providerID, ks, cleanup := createTestIdentities(t)
+	defer cleanup()
+
+	signerFactory := func(id identity.Identity) identity.Signer {
+		return identity.NewSigner(ks, identity.FromAddress(id.Address))
+	}
+	verifier := identity.NewVerifierSigned()
+	ipResolver := ip.NewResolverMock("127.0.0.1")
+	brokerConn := nats.StartConnectionMock()
+	defer brokerConn.Close()
+	portPool := port.NewPool()
+	ports, err := acquirePorts(1)
+	assert.NoError(t, err)
+
+	channelListener := NewListener(brokerConn, signerFactory, verifier, ipResolver, &mockProviderNATPinger{}, portPool, &mockPortMapper{}, 0)
+	_, err = channelListener.Listen(providerID, "wireguard", func(ch Channel) {
+		ch.Handle("test", func(c Context) error {
+			return c.OkWithReply(&Message{Data: []byte("pong")})
+		})
+	})
+	assert.NoError(t, err)
+
+	channelDialer := NewDialer(&mockBroker{conn: brokerConn}, signerFactory, verifier, ipResolver, &mockConsumerNATPinger{}, portPool)
+	channelDialer.(*dialer).directTimeout = 500 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	consumerChannel, err := channelDialer.Dial(ctx, consumerID, providerID, "wireguard", market.ContactList{
+		{Type: ContactTypeV1, Definition: ContactDefinition{BrokerAddresses: []string{"broker"}}},
+		{Type: ContactTypeDirectV1, Definition: DirectContactDefinition{Address: net.JoinHostPort("127.0.0.1", strconv.Itoa(ports[0]))}},
+	})
+	assert.NoError(t, err)
+	defer consumerChannel.Close()
+
+	res, err := consumerChannel.Send(context.Background(), "test", &Message{Data: []byte("ping")})
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", string(res.Data))
+}
+
+func TestDialer_Dial_Without_P2P_Contact(t *testing.T) {
+	channelDialer := NewDialer(&mockBroker{}, nil, nil, nil, nil, nil)
+
+	_, err := channelDialer.Dial(context.Background(), identity.Identity{}, identity.Identity{}, "wireguard", market.ContactList{
+		{Type: "openvpn/v1"},
+	})
+	assert.True(t, errors.Is(err, ErrContactNotFound))
+}
+
 func natTestPingers(t *testing.T) (providerPinger natProviderPinger, consumerPinger natConsumerPinger) {
 	ports, err := acquirePorts(2)
 	assert.NoError(t, err)
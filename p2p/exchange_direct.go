@@ -0,0 +1,287 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// directResendInterval is how often config exchange requests are resent until the reply arrives,
+	// as the datagrams can get lost on the way.
+	directResendInterval = time.Second
+	// directReplyTTL is how long replies are kept to answer resent requests.
+	directReplyTTL   = time.Minute
+	directMaxMsgSize = 64 * 1024
+	// directMaxReplies limits requests kept for answering resends.
+	directMaxReplies = 1024
+	// directSourceLimit is how many new requests single source IP can send during directSourceWindow.
+	directSourceLimit  = 10
+	directSourceWindow = 10 * time.Second
+)
+
+// directHandler handles config exchange request data and returns reply data.
+type directHandler func(data []byte) ([]byte, error)
+
+// directServer serves config exchange requests sent straight to the provider over UDP.
+type directServer struct {
+	conn *net.UDPConn
+
+	mu       sync.Mutex
+	handlers map[string]directHandler
+	replies  map[string]*directReply
+	sources  map[string]*directSource
+}
+
+// directSource counts new requests from single source IP.
+type directSource struct {
+	count   int
+	started time.Time
+}
+
+type directReply struct {
+	msg     *transportMsg
+	created time.Time
+}
+
+func newDirectServer(port int) (*directServer, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: port})
+	if err != nil {
+		return nil, fmt.Errorf("could not listen direct config exchange port %d: %w", port, err)
+	}
+
+	s := &directServer{
+		conn:     conn,
+		handlers: map[string]directHandler{},
+		replies:  map[string]*directReply{},
+		sources:  map[string]*directSource{},
+	}
+	go s.serve()
+	return s, nil
+}
+
+// handle registers handler for requests of given topic.
+func (s *directServer) handle(topic string, handler directHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[topic] = handler
+}
+
+// unhandle removes handlers of given topics.
+func (s *directServer) unhandle(topics ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, topic := range topics {
+		delete(s.handlers, topic)
+	}
+}
+
+func (s *directServer) serve() {
+	buf := make([]byte, directMaxMsgSize)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errNetClose(err) {
+				return
+			}
+			log.Err(err).Msg("Could not read direct config exchange request")
+			continue
+		}
+
+		var req transportMsg
+		if err := unmarshalDatagram(buf[:n], &req); err != nil {
+			log.Err(err).Msgf("Could not parse direct config exchange request from %s", addr)
+			continue
+		}
+		go s.handleRequest(addr, &req)
+	}
+}
+
+// handleRequest handles the request once, resent requests are answered with the same reply.
+func (s *directServer) handleRequest(addr *net.UDPAddr, req *transportMsg) {
+	key := fmt.Sprintf("%s/%s/%d", addr, req.topic, req.id)
+
+	s.mu.Lock()
+	s.expireReplies()
+	reply, seen := s.replies[key]
+	if !seen {
+		if !s.allow(addr.IP) {
+			s.mu.Unlock()
+			log.Debug().Msgf("Dropping direct config exchange request %q from %s", req.topic, addr)
+			return
+		}
+		s.replies[key] = &directReply{created: time.Now()}
+	}
+	handler, ok := s.handlers[req.topic]
+	s.mu.Unlock()
+
+	if seen {
+		// Reply is nil while the first request is still being handled.
+		if reply.msg != nil {
+			s.send(addr, reply.msg)
+		}
+		return
+	}
+
+	res := &transportMsg{id: req.id, topic: req.topic, statusCode: statusCodeOK}
+	if !ok {
+		res.statusCode = statusCodeHandlerNotFoundErr
+		res.data = []byte(fmt.Sprintf("handler %q not found", req.topic))
+	} else if data, err := handler(req.data); err != nil {
+		log.Err(err).Msgf("Could not handle direct config exchange request %q", req.topic)
+		res.statusCode = statusCodePublicErr
+		res.data = []byte(err.Error())
+	} else {
+		res.data = data
+	}
+
+	s.mu.Lock()
+	// Reply could have expired while the handler was running.
+	if reply, ok := s.replies[key]; ok {
+		reply.msg = res
+	}
+	s.mu.Unlock()
+	s.send(addr, res)
+}
+
+// allow checks if new request from given IP can be handled. Requests are dropped when the source
+// exceeds its rate limit or too many requests are kept already.
+func (s *directServer) allow(ip net.IP) bool {
+	if len(s.replies) >= directMaxReplies {
+		return false
+	}
+
+	source, ok := s.sources[ip.String()]
+	if !ok || time.Since(source.started) > directSourceWindow {
+		source = &directSource{started: time.Now()}
+		s.sources[ip.String()] = source
+	}
+	if source.count >= directSourceLimit {
+		return false
+	}
+	source.count++
+	return true
+}
+
+func (s *directServer) expireReplies() {
+	for key, reply := range s.replies {
+		if time.Since(reply.created) > directReplyTTL {
+			delete(s.replies, key)
+		}
+	}
+	for ip, source := range s.sources {
+		if time.Since(source.started) > directSourceWindow {
+			delete(s.sources, ip)
+		}
+	}
+}
+
+func (s *directServer) send(addr *net.UDPAddr, msg *transportMsg) {
+	data, err := marshalDatagram(msg)
+	if err != nil {
+		log.Err(err).Msg("Could not marshal direct config exchange reply")
+		return
+	}
+	if _, err := s.conn.WriteToUDP(data, addr); err != nil {
+		log.Err(err).Msgf("Could not send direct config exchange reply to %s", addr)
+	}
+}
+
+func (s *directServer) close() error {
+	return s.conn.Close()
+}
+
+// sendDirect sends the request to the provider and waits for the reply, resending the request
+// until the reply arrives or the context is done.
+func sendDirect(ctx context.Context, conn *net.UDPConn, id uint64, topic string, data []byte) ([]byte, error) {
+	req, err := marshalDatagram(&transportMsg{id: id, topic: topic, data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, directMaxMsgSize)
+	for {
+		if _, err := conn.Write(req); err != nil {
+			return nil, fmt.Errorf("could not send direct request %q: %w", topic, err)
+		}
+
+		deadline := time.Now().Add(directResendInterval)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+				// Unreachable peer is reported by ICMP errors, keep trying until the context is done.
+				log.Debug().Err(err).Msgf("Could not read direct reply to %q", topic)
+				break
+			}
+
+			var res transportMsg
+			if err := unmarshalDatagram(buf[:n], &res); err != nil || res.id != id {
+				continue
+			}
+			if res.statusCode != statusCodeOK {
+				return nil, fmt.Errorf("peer error for %q: %s", topic, string(res.data))
+			}
+			return res.data, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout waiting for direct reply to %q: %w", topic, ctx.Err())
+		case <-time.After(time.Until(deadline)):
+		}
+	}
+}
+
+func marshalDatagram(msg *transportMsg) ([]byte, error) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if err := msg.writeTo(textproto.NewWriter(w)); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unmarshalDatagram(data []byte, msg *transportMsg) error {
+	return msg.readFrom(textproto.NewReader(bufio.NewReader(bytes.NewReader(data))))
+}
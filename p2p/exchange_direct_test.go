@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectServer_ReplyExpiredWhileHandling(t *testing.T) {
+	ports, err := acquirePorts(1)
+	assert.NoError(t, err)
+	server, err := newDirectServer(ports[0])
+	assert.NoError(t, err)
+	defer server.close()
+
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	server.handle("topic", func(data []byte) ([]byte, error) {
+		close(handling)
+		<-release
+		return []byte("reply"), nil
+	})
+
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: ports[0]})
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	res := make(chan result, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		data, err := sendDirect(ctx, conn, 1, "topic", []byte("request"))
+		res <- result{data, err}
+	}()
+
+	<-handling
+	server.mu.Lock()
+	for _, reply := range server.replies {
+		reply.created = time.Now().Add(-2 * directReplyTTL)
+	}
+	server.expireReplies()
+	server.mu.Unlock()
+	close(release)
+
+	r := <-res
+	assert.NoError(t, r.err)
+	assert.Equal(t, "reply", string(r.data))
+}
+
+func TestDirectServer_LimitsRequestsFromSource(t *testing.T) {
+	s := &directServer{
+		replies: map[string]*directReply{},
+		sources: map[string]*directSource{},
+	}
+	ip := net.ParseIP("1.1.1.1")
+
+	for i := 0; i < directSourceLimit; i++ {
+		assert.True(t, s.allow(ip))
+	}
+	assert.False(t, s.allow(ip))
+	assert.True(t, s.allow(net.ParseIP("2.2.2.2")))
+
+	s.sources[ip.String()].started = time.Now().Add(-2 * directSourceWindow)
+	assert.True(t, s.allow(ip))
+}
+
+func TestDirectServer_LimitsKeptReplies(t *testing.T) {
+	s := &directServer{
+		replies: map[string]*directReply{},
+		sources: map[string]*directSource{},
+	}
+	for i := 0; i < directMaxReplies; i++ {
+		s.replies[strconv.Itoa(i)] = &directReply{created: time.Now()}
+	}
+
+	assert.False(t, s.allow(net.ParseIP("1.1.1.1")))
+}
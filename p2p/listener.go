@@ -19,8 +19,10 @@ package p2p
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 
@@ -41,18 +43,24 @@ import (
 // Listener knows how to exchange p2p keys and encrypted configuration and creates ready to use p2p channels.
 type Listener interface {
 	// Listen listens for incoming peer connections to establish new p2p channels. Establishes p2p channel and passes it
-	// to channelHandlers
-	Listen(providerID identity.Identity, serviceType string, channelHandler func(ch Channel)) error
+	// to channelHandlers. Returned func stops listening.
+	Listen(providerID identity.Identity, serviceType string, channelHandler func(ch Channel)) (func(), error)
 
-	// GetContact returns contract which is later can be added to proposal contacts definition so consumer can
+	// GetContacts returns contacts which are later added to proposal contacts definition so consumer can
 	// know how to connect to this p2p listener.
-	GetContact() market.Contact
+	GetContacts() market.ContactList
+
+	// Close stops serving direct config exchange.
+	Close() error
 }
 
 // NewListener creates new p2p communication listener which is used on provider side.
-func NewListener(brokerConn nats.Connection, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, providerPinger natProviderPinger, portPool port.ServicePortSupplier, portMapper mapping.PortMapper) Listener {
+// Consumers exchange config straight with the provider over UDP on the direct port, if it is set,
+// otherwise they exchange it via broker.
+func NewListener(brokerConn nats.Connection, signer identity.SignerFactory, verifier identity.Verifier, ipResolver ip.Resolver, providerPinger natProviderPinger, portPool port.ServicePortSupplier, portMapper mapping.PortMapper, directPort int) Listener {
 	return &listener{
 		brokerConn:     brokerConn,
+		directPort:     directPort,
 		pendingConfigs: map[PublicKey]p2pConnectConfig{},
 		ipResolver:     ipResolver,
 		signer:         signer,
@@ -73,12 +81,27 @@ type listener struct {
 	ipResolver     ip.Resolver
 	portMapper     mapping.PortMapper
 
+	directPort int
+	directMu   sync.Mutex
+	direct     *directServer
+	closed     bool
+
 	// Keys holds pendingConfigs temporary configs for provider side since it
 	// need to handle key exchange in two steps.
 	pendingConfigs   map[PublicKey]p2pConnectConfig
 	pendingConfigsMu sync.Mutex
 }
 
+const (
+	// pendingConfigTTL is how long provider waits for exchange ack before releasing prepared ports.
+	pendingConfigTTL = time.Minute
+	// maxPendingConfigs limits config exchanges waiting for ack.
+	maxPendingConfigs = 100
+)
+
+// errTooManyPendingConfigs is returned when too many config exchanges are waiting for ack.
+var errTooManyPendingConfigs = errors.New("too many pending config exchanges")
+
 type p2pConnectConfig struct {
 	publicIP         string
 	peerPublicIP     string
@@ -87,6 +110,13 @@ type p2pConnectConfig struct {
 	privateKey       PrivateKey
 	peerPubKey       PublicKey
 	upnpPortsRelease []func()
+	created          time.Time
+}
+
+func (c *p2pConnectConfig) releasePorts() {
+	for _, release := range c.upnpPortsRelease {
+		release()
+	}
 }
 
 func (c *p2pConnectConfig) peerIP() string {
@@ -97,29 +127,66 @@ func (c *p2pConnectConfig) peerIP() string {
 	return c.peerPublicIP
 }
 
-func (m *listener) GetContact() market.Contact {
-	return market.Contact{
+func (m *listener) GetContacts() market.ContactList {
+	contacts := market.ContactList{{
 		Type:       ContactTypeV1,
-		Definition: ContactDefinition{BrokerAddresses: m.brokerConn.Servers()}}
+		Definition: ContactDefinition{BrokerAddresses: m.brokerConn.Servers()},
+	}}
+	if m.directPort == 0 {
+		return contacts
+	}
+
+	publicIP, err := m.ipResolver.GetPublicIP()
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not get public IP, direct p2p contact is left out")
+		return contacts
+	}
+	return append(contacts, market.Contact{
+		Type:       ContactTypeDirectV1,
+		Definition: DirectContactDefinition{Address: net.JoinHostPort(publicIP, strconv.Itoa(m.directPort))},
+	})
 }
 
 // Listen listens for incoming peer connections to establish new p2p channels. Establishes p2p channel and passes it
-// to channelHandlers
-func (m *listener) Listen(providerID identity.Identity, serviceType string, channelHandlers func(ch Channel)) error {
+// to channelHandlers. Returned func stops listening.
+func (m *listener) Listen(providerID identity.Identity, serviceType string, channelHandlers func(ch Channel)) (func(), error) {
 	outboundIP, err := m.ipResolver.GetOutboundIPAsString()
 	if err != nil {
-		return fmt.Errorf("could not get outbound IP: %w", err)
+		return nil, fmt.Errorf("could not get outbound IP: %w", err)
 	}
 
-	_, err = m.brokerConn.Subscribe(configExchangeSubject(providerID, serviceType), func(msg *nats_lib.Msg) {
-		if err := m.providerStartConfigExchange(providerID, msg, outboundIP); err != nil {
+	var stops []func()
+	stop := func() {
+		for _, s := range stops {
+			s()
+		}
+	}
+	if m.directPort != 0 {
+		stopDirect, err := m.listenDirect(providerID, serviceType, channelHandlers)
+		if err != nil {
+			return nil, err
+		}
+		stops = append(stops, stopDirect)
+	}
+
+	exchangeSub, err := m.brokerConn.Subscribe(configExchangeSubject(providerID, serviceType), func(msg *nats_lib.Msg) {
+		reply, err := m.providerStartConfigExchange(providerID, msg.Data, outboundIP, false)
+		if err != nil {
 			log.Err(err).Msg("Could not handle initial exchange")
 			return
 		}
+		if err := m.brokerConn.Publish(msg.Reply, reply); err != nil {
+			log.Err(err).Msg("Could not publish message via broker")
+		}
 	})
+	if err != nil {
+		stop()
+		return nil, fmt.Errorf("could not subscribe to config exchange: %w", err)
+	}
+	stops = append(stops, unsubscribeFunc(exchangeSub))
 
-	_, err = m.brokerConn.Subscribe(configExchangeACKSubject(providerID, serviceType), func(msg *nats_lib.Msg) {
-		config, err := m.providerAckConfigExchange(msg)
+	ackSub, err := m.brokerConn.Subscribe(configExchangeACKSubject(providerID, serviceType), func(msg *nats_lib.Msg) {
+		config, err := m.providerAckConfigExchange(msg.Data)
 		if err != nil {
 			log.Err(err).Msg("Could not handle exchange ack")
 			return
@@ -142,39 +209,11 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 			}
 		}(msg.Reply)
 
-		var conn1, conn2 *net.UDPConn
-		if len(config.peerPorts) == requiredConnCount {
-			log.Debug().Msg("Skipping consumer ping")
-			conn1, err = net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[0]}, &net.UDPAddr{IP: net.ParseIP(config.peerIP()), Port: config.peerPorts[0]})
-			if err != nil {
-				log.Err(err).Msg("Could not create UDP conn for p2p channel")
-				return
-			}
-			conn2, err = net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[1]}, &net.UDPAddr{IP: net.ParseIP(config.peerIP()), Port: config.peerPorts[1]})
-			if err != nil {
-				log.Err(err).Msg("Could not create UDP conn for service")
-				return
-			}
-		} else {
-			log.Debug().Msgf("Pinging consumer with IP %s using ports %v:%v initial ttl: %v",
-				config.peerIP(), config.localPorts, config.peerPorts, providerInitialTTL)
-			conns, err := m.providerPinger.PingConsumerPeer(context.Background(), config.peerIP(), config.localPorts, config.peerPorts, providerInitialTTL, requiredConnCount)
-			if err != nil {
-				log.Err(err).Msg("Could not ping peer")
-				return
-			}
-			conn1 = conns[0]
-			conn2 = conns[1]
-		}
-		channel, err := newChannel(conn1, config.privateKey, config.peerPubKey)
+		channel, err := m.providerOpenChannel(config, channelHandlers)
 		if err != nil {
-			log.Err(err).Msg("Could not create channel")
+			log.Err(err).Msg("Could not open p2p channel")
 			return
 		}
-		channel.setServiceConn(conn2)
-		channel.setUpnpPortsRelease(config.upnpPortsRelease)
-
-		channelHandlers(channel)
 
 		// Send handlers ready to consumer.
 		if err := m.providerChannelHandlersReady(providerID, serviceType); err != nil {
@@ -183,43 +222,148 @@ func (m *listener) Listen(providerID identity.Identity, serviceType string, chan
 			return
 		}
 	})
+	if err != nil {
+		stop()
+		return nil, fmt.Errorf("could not subscribe to config exchange ack: %w", err)
+	}
+	stops = append(stops, unsubscribeFunc(ackSub))
+
+	return stop, nil
+}
 
-	return err
+func unsubscribeFunc(sub *nats_lib.Subscription) func() {
+	return func() {
+		if err := sub.Unsubscribe(); err != nil {
+			log.Debug().Err(err).Msgf("Could not unsubscribe from %s", sub.Subject)
+		}
+	}
+}
+
+// Close stops serving direct config exchange.
+func (m *listener) Close() error {
+	m.directMu.Lock()
+	defer m.directMu.Unlock()
+
+	m.closed = true
+	if m.direct == nil {
+		return nil
+	}
+	return m.direct.close()
 }
 
-func (m *listener) providerStartConfigExchange(signerID identity.Identity, msg *nats_lib.Msg, outboundIP string) error {
+// listenDirect serves config exchange sent straight to the provider. Provider is publicly reachable
+// in this case, so the exchange is completed without pinging and handlers ready message is sent
+// as the reply to the exchange ack.
+func (m *listener) listenDirect(providerID identity.Identity, serviceType string, channelHandlers func(ch Channel)) (func(), error) {
+	m.directMu.Lock()
+	defer m.directMu.Unlock()
+
+	if m.closed {
+		return nil, errors.New("listener is closed")
+	}
+	if m.direct == nil {
+		direct, err := newDirectServer(m.directPort)
+		if err != nil {
+			return nil, err
+		}
+		m.direct = direct
+	}
+
+	exchangeTopic := configExchangeSubject(providerID, serviceType)
+	ackTopic := configExchangeACKSubject(providerID, serviceType)
+	m.direct.handle(exchangeTopic, func(data []byte) ([]byte, error) {
+		return m.providerStartConfigExchange(providerID, data, "", true)
+	})
+	m.direct.handle(ackTopic, func(data []byte) ([]byte, error) {
+		config, err := m.providerAckConfigExchange(data)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := m.providerOpenChannel(config, channelHandlers); err != nil {
+			return nil, err
+		}
+		return proto.Marshal(&pb.P2PChannelHandlersReady{Value: channelHandlersReadyValue})
+	})
+	direct := m.direct
+	return func() { direct.unhandle(exchangeTopic, ackTopic) }, nil
+}
+
+// providerOpenChannel connects to the consumer, pinging it if needed, and passes the channel to handlers.
+func (m *listener) providerOpenChannel(config *p2pConnectConfig, channelHandlers func(ch Channel)) (*channel, error) {
+	var conn1, conn2 *net.UDPConn
+	var err error
+	if len(config.peerPorts) == requiredConnCount {
+		log.Debug().Msg("Skipping consumer ping")
+		conn1, err = net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[0]}, &net.UDPAddr{IP: net.ParseIP(config.peerIP()), Port: config.peerPorts[0]})
+		if err != nil {
+			return nil, fmt.Errorf("could not create UDP conn for p2p channel: %w", err)
+		}
+		conn2, err = net.DialUDP("udp4", &net.UDPAddr{Port: config.localPorts[1]}, &net.UDPAddr{IP: net.ParseIP(config.peerIP()), Port: config.peerPorts[1]})
+		if err != nil {
+			return nil, fmt.Errorf("could not create UDP conn for service: %w", err)
+		}
+	} else {
+		log.Debug().Msgf("Pinging consumer with IP %s using ports %v:%v initial ttl: %v",
+			config.peerIP(), config.localPorts, config.peerPorts, providerInitialTTL)
+		conns, err := m.providerPinger.PingConsumerPeer(context.Background(), config.peerIP(), config.localPorts, config.peerPorts, providerInitialTTL, requiredConnCount)
+		if err != nil {
+			return nil, fmt.Errorf("could not ping peer: %w", err)
+		}
+		conn1 = conns[0]
+		conn2 = conns[1]
+	}
+	channel, err := newChannel(conn1, config.privateKey, config.peerPubKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not create channel: %w", err)
+	}
+	channel.setServiceConn(conn2)
+	channel.setUpnpPortsRelease(config.upnpPortsRelease)
+
+	channelHandlers(channel)
+	return channel, nil
+}
+
+// providerStartConfigExchange handles initial exchange and returns the reply. Directly reachable provider
+// needs only the ports for connections, without port mapping or extra ports for pinging.
+func (m *listener) providerStartConfigExchange(signerID identity.Identity, data []byte, outboundIP string, direct bool) ([]byte, error) {
 	pubKey, privateKey, err := GenerateKey()
 	if err != nil {
-		return fmt.Errorf("could not generate provider p2p keys: %w", err)
+		return nil, fmt.Errorf("could not generate provider p2p keys: %w", err)
 	}
 
 	// Get initial peer exchange with it's public key.
-	signedMsg, err := unpackSignedMsg(m.verifier, msg.Data)
+	signedMsg, err := unpackSignedMsg(m.verifier, data)
 	if err != nil {
-		return fmt.Errorf("could not unpack signed msg: %w", err)
+		return nil, fmt.Errorf("could not unpack signed msg: %w", err)
 	}
 	var peerExchangeMsg pb.P2PConfigExchangeMsg
 	if err := proto.Unmarshal(signedMsg.Data, &peerExchangeMsg); err != nil {
-		return err
+		return nil, err
 	}
 	peerPubKey, err := DecodePublicKey(peerExchangeMsg.PublicKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	log.Debug().Msgf("Received consumer public key %s", peerPubKey.Hex())
 
 	// Send reply with encrypted exchange config.
 	publicIP, err := m.ipResolver.GetPublicIP()
 	if err != nil {
-		return fmt.Errorf("could not get public IP: %v", err)
+		return nil, fmt.Errorf("could not get public IP: %v", err)
 	}
 
-	localPorts, portsRelease, err := m.prepareLocalPorts(publicIP, outboundIP)
+	var localPorts []int
+	var portsRelease []func()
+	if direct {
+		localPorts, err = acquireLocalPorts(m.portPool, requiredConnCount)
+	} else {
+		localPorts, portsRelease, err = m.prepareLocalPorts(publicIP, outboundIP)
+	}
 	if err != nil {
-		return fmt.Errorf("could not prepare ports: %w", err)
+		return nil, fmt.Errorf("could not prepare ports: %w", err)
 	}
 
-	m.setPendingConfig(p2pConnectConfig{
+	err = m.setPendingConfig(p2pConnectConfig{
 		publicIP:         publicIP,
 		localPorts:       localPorts,
 		privateKey:       privateKey,
@@ -228,6 +372,9 @@ func (m *listener) providerStartConfigExchange(signerID identity.Identity, msg *
 		peerPublicIP:     "",
 		peerPorts:        nil,
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	config := pb.P2PConnectConfig{
 		PublicIP: publicIP,
@@ -235,7 +382,7 @@ func (m *listener) providerStartConfigExchange(signerID identity.Identity, msg *
 	}
 	configCiphertext, err := encryptConnConfigMsg(&config, privateKey, peerPubKey)
 	if err != nil {
-		return fmt.Errorf("could not encrypt config msg: %v", err)
+		return nil, fmt.Errorf("could not encrypt config msg: %v", err)
 	}
 	exchangeMsg := pb.P2PConfigExchangeMsg{
 		PublicKey:        pubKey.Hex(),
@@ -244,13 +391,9 @@ func (m *listener) providerStartConfigExchange(signerID identity.Identity, msg *
 	log.Debug().Msgf("Sending reply with public key %s and encrypted config to consumer", exchangeMsg.PublicKey)
 	packedMsg, err := packSignedMsg(m.signer, signerID, &exchangeMsg)
 	if err != nil {
-		return fmt.Errorf("could not pack signed message: %v", err)
+		return nil, fmt.Errorf("could not pack signed message: %v", err)
 	}
-	err = m.brokerConn.Publish(msg.Reply, packedMsg)
-	if err != nil {
-		return fmt.Errorf("could not publish message via broker: %v", err)
-	}
-	return nil
+	return packedMsg, nil
 }
 
 // prepareLocalPorts acquires ports for p2p connections. It tries to acquire only
@@ -300,8 +443,8 @@ func (m *listener) prepareLocalPorts(publicIP, outboundIP string) ([]int, []func
 	return localPorts, nil, nil
 }
 
-func (m *listener) providerAckConfigExchange(msg *nats_lib.Msg) (*p2pConnectConfig, error) {
-	signedMsg, err := unpackSignedMsg(m.verifier, msg.Data)
+func (m *listener) providerAckConfigExchange(data []byte) (*p2pConnectConfig, error) {
+	signedMsg, err := unpackSignedMsg(m.verifier, data)
 	if err != nil {
 		return nil, fmt.Errorf("could not unpack signed msg: %w", err)
 	}
@@ -339,7 +482,7 @@ func (m *listener) providerAckConfigExchange(msg *nats_lib.Msg) (*p2pConnectConf
 }
 
 func (m *listener) providerChannelHandlersReady(providerID identity.Identity, serviceType string) error {
-	handlersReadyMsg := pb.P2PChannelHandlersReady{Value: "HANDLERS READY"}
+	handlersReadyMsg := pb.P2PChannelHandlersReady{Value: channelHandlersReadyValue}
 
 	message, err := proto.Marshal(&handlersReadyMsg)
 	if err != nil {
@@ -357,10 +500,35 @@ func (m *listener) pendingConfig(peerPubKey PublicKey) (p2pConnectConfig, bool)
 	return config, ok
 }
 
-func (m *listener) setPendingConfig(config p2pConnectConfig) {
+// setPendingConfig stores config until the exchange ack arrives. Ports are released
+// if the ack doesn't arrive in time.
+func (m *listener) setPendingConfig(config p2pConnectConfig) error {
 	m.pendingConfigsMu.Lock()
 	defer m.pendingConfigsMu.Unlock()
+
+	if len(m.pendingConfigs) >= maxPendingConfigs {
+		config.releasePorts()
+		return errTooManyPendingConfigs
+	}
+	config.created = time.Now()
 	m.pendingConfigs[config.peerPubKey] = config
+	time.AfterFunc(pendingConfigTTL, func() {
+		m.expirePendingConfig(config.peerPubKey, config.created)
+	})
+	return nil
+}
+
+func (m *listener) expirePendingConfig(peerPubKey PublicKey, created time.Time) {
+	m.pendingConfigsMu.Lock()
+	defer m.pendingConfigsMu.Unlock()
+
+	config, ok := m.pendingConfigs[peerPubKey]
+	if !ok || !config.created.Equal(created) {
+		return
+	}
+	log.Debug().Msgf("Exchange ack from %s not received, releasing ports", peerPubKey.Hex())
+	config.releasePorts()
+	delete(m.pendingConfigs, peerPubKey)
 }
 
 func (m *listener) deletePendingConfig(peerPubKey PublicKey) {
@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"testing"
+
+	"github.com/mysteriumnetwork/node/communication/nats"
+	"github.com/mysteriumnetwork/node/core/ip"
+	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListener_PendingConfigs(t *testing.T) {
+	m := &listener{pendingConfigs: map[PublicKey]p2pConnectConfig{}}
+
+	var released int
+	for i := 0; i < maxPendingConfigs; i++ {
+		pubKey, _, err := GenerateKey()
+		assert.NoError(t, err)
+		err = m.setPendingConfig(p2pConnectConfig{peerPubKey: pubKey, upnpPortsRelease: []func(){func() { released++ }}})
+		assert.NoError(t, err)
+	}
+
+	pubKey, _, err := GenerateKey()
+	assert.NoError(t, err)
+	err = m.setPendingConfig(p2pConnectConfig{peerPubKey: pubKey, upnpPortsRelease: []func(){func() { released++ }}})
+	assert.Equal(t, errTooManyPendingConfigs, err)
+	assert.Equal(t, 1, released)
+
+	// Ack didn't arrive in time.
+	for key, config := range m.pendingConfigs {
+		m.expirePendingConfig(key, config.created)
+	}
+	assert.Len(t, m.pendingConfigs, 0)
+	assert.Equal(t, maxPendingConfigs+1, released)
+}
+
+func TestListener_StopListening(t *testing.T) {
+	_, providerID, ks, cleanup := createTestIdentities(t)
+	defer cleanup()
+
+	signerFactory := func(id identity.Identity) identity.Signer {
+		return identity.NewSigner(ks, identity.FromAddress(id.Address))
+	}
+	brokerConn := nats.StartConnectionMock()
+	defer brokerConn.Close()
+	ports, err := acquirePorts(1)
+	assert.NoError(t, err)
+
+	l := NewListener(brokerConn, signerFactory, identity.NewVerifierSigned(), ip.NewResolverMock("127.0.0.1"), &mockProviderNATPinger{}, port.NewPool(), &mockPortMapper{}, ports[0]).(*listener)
+	defer l.Close()
+
+	stop, err := l.Listen(providerID, "wireguard", func(ch Channel) {})
+	assert.NoError(t, err)
+	assert.Len(t, l.direct.handlers, 2)
+
+	stop()
+	assert.Len(t, l.direct.handlers, 0)
+
+	assert.NoError(t, l.Close())
+	_, err = l.Listen(providerID, "wireguard", func(ch Channel) {})
+	assert.Error(t, err)
+}
@@ -91,7 +91,7 @@ func NewClientConfigFromSession(vpnConfig VPNConfig, configDir string, runtimeDi
 	}
 
 	clientFileConfig := newClientConfig(runtimeDir, configDir)
-	dnsIPs, err := options.DNS.ResolveIPs(vpnConfig.DNSIPs)
+	dnsIPs, err := options.ResolveDNS(vpnConfig.DNSIPs)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"net"
+	"strings"
+
+	"github.com/mysteriumnetwork/go-openvpn/openvpn/management"
+	"github.com/mysteriumnetwork/go-openvpn/openvpn/middlewares/server"
+)
+
+// clientAddresses follows OpenVPN client events and reports VPN addresses assigned to consumer sessions.
+type clientAddresses struct {
+	onEstablished  func(sessionID string, ip net.IP)
+	onDisconnected func(sessionID string)
+
+	event server.ClientEvent
+}
+
+func newClientAddresses(onEstablished func(sessionID string, ip net.IP), onDisconnected func(sessionID string)) *clientAddresses {
+	return &clientAddresses{
+		onEstablished:  onEstablished,
+		onDisconnected: onDisconnected,
+		event:          newClientEvent(),
+	}
+}
+
+func newClientEvent() server.ClientEvent {
+	return server.ClientEvent{ClientID: server.Undefined, ClientKey: server.Undefined, Env: map[string]string{}}
+}
+
+func (ca *clientAddresses) Start(management.CommandWriter) error {
+	return nil
+}
+
+func (ca *clientAddresses) Stop(management.CommandWriter) error {
+	return nil
+}
+
+// ConsumeLine collects client events, they are handled by auth middleware as well.
+func (ca *clientAddresses) ConsumeLine(line string) (bool, error) {
+	if !strings.HasPrefix(line, ">CLIENT:") {
+		return false, nil
+	}
+
+	eventType, eventData, err := server.ParseClientEvent(strings.TrimPrefix(line, ">CLIENT:"))
+	if err != nil {
+		return false, err
+	}
+
+	switch eventType {
+	case server.Connect, server.Reauth, server.Established, server.Disconnect:
+		ca.event = newClientEvent()
+		ca.event.EventType = eventType
+	case server.Env:
+		if strings.ToLower(eventData) == "end" {
+			ca.handleEvent(ca.event)
+			ca.event = newClientEvent()
+			return false, nil
+		}
+		key, val, err := server.ParseEnvVar(eventData)
+		if err != nil {
+			return false, err
+		}
+		ca.event.Env[key] = val
+	}
+	return false, nil
+}
+
+func (ca *clientAddresses) handleEvent(event server.ClientEvent) {
+	// Username holds the session ID, see session.SignatureCredentialsProvider.
+	sessionID := event.Env["username"]
+	if sessionID == "" {
+		return
+	}
+
+	switch event.EventType {
+	case server.Established:
+		if ip := net.ParseIP(event.Env["ifconfig_pool_remote_ip"]); ip != nil {
+			ca.onEstablished(sessionID, ip)
+		}
+	case server.Disconnect:
+		ca.onDisconnected(sessionID)
+	}
+}
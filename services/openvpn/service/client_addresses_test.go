@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientAddresses_ReportsSessionAddresses(t *testing.T) {
+	established := map[string]string{}
+	var disconnected []string
+	ca := newClientAddresses(
+		func(sessionID string, ip net.IP) { established[sessionID] = ip.String() },
+		func(sessionID string) { disconnected = append(disconnected, sessionID) },
+	)
+
+	for _, line := range []string{
+		">CLIENT:CONNECT,1,2",
+		">CLIENT:ENV,username=session1",
+		">CLIENT:ENV,END",
+		">CLIENT:ESTABLISHED,1",
+		">CLIENT:ENV,username=session1",
+		">CLIENT:ENV,ifconfig_pool_remote_ip=10.8.0.6",
+		">CLIENT:ENV,END",
+		">BYTECOUNT_CLI:1,100,200",
+		">CLIENT:DISCONNECT,1",
+		">CLIENT:ENV,username=session1",
+		">CLIENT:ENV,ifconfig_pool_remote_ip=10.8.0.6",
+		">CLIENT:ENV,END",
+	} {
+		_, err := ca.ConsumeLine(line)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, map[string]string{"session1": "10.8.0.6"}, established)
+	assert.Equal(t, []string{"session1"}, disconnected)
+}
@@ -24,6 +24,7 @@ import (
 	"net"
 
 	"github.com/mysteriumnetwork/go-openvpn/openvpn"
+	"github.com/mysteriumnetwork/go-openvpn/openvpn/management"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/tls"
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/ip"
@@ -70,6 +71,7 @@ type Manager struct {
 	natPinger       natPinger
 	natEventGetter  NATEventGetter
 	dnsProxy        *dns.Proxy
+	dnsBlocklist    *dns.BlocklistHandler
 	eventListener   eventListener
 	portMapper      mapping.PortMapper
 	trafficFirewall firewall.IncomingTrafficFirewall
@@ -96,7 +98,7 @@ func (m *Manager) Serve(instance *service.Instance) (err error) {
 	}
 
 	var dnsPort = 11153
-	dnsHandler, err := dns.ResolveViaSystem()
+	dnsHandler, err := dns.ResolveVia(stringutil.Split(config.GetString(config.FlagDNSUpstreams), ','))
 	if err == nil {
 		if instance.Policies().HasDNSRules() {
 			dnsHandler = dns.WhitelistAnswers(dnsHandler, m.trafficFirewall, instance.Policies())
@@ -110,6 +112,22 @@ func (m *Manager) Serve(instance *service.Instance) (err error) {
 				}
 			}()
 		}
+		if len(m.serviceOptions.DNSBlocklist.Sources) > 0 {
+			m.dnsBlocklist = dns.BlockAnswers(dnsHandler, m.serviceOptions.DNSBlocklist, m.trafficFirewall)
+			m.dnsBlocklist.Start()
+			dnsHandler = m.dnsBlocklist
+			if m.serviceOptions.DNSBlocklist.BlockIPs {
+				removeBlocklist, err := m.trafficFirewall.ApplyBlocklist(m.vpnNetwork)
+				if err != nil {
+					return fmt.Errorf("failed to enable blocklist: %w", err)
+				}
+				defer func() {
+					if err := removeBlocklist(); err != nil {
+						log.Warn().Err(err).Msg("failed to disable blocklist")
+					}
+				}()
+			}
+		}
 
 		m.dnsProxy = dns.NewProxy("", dnsPort, dnsHandler)
 		if err := m.dnsProxy.Run(); err != nil {
@@ -168,11 +186,24 @@ func (m *Manager) Serve(instance *service.Instance) (err error) {
 		m.serviceOptions.Protocol,
 	)
 
+	var middlewares []management.Middleware
+	if m.dnsBlocklist != nil {
+		// Blocked DNS queries are attributed to sessions by their VPN addresses.
+		blocklist := m.dnsBlocklist
+		middlewares = append(middlewares, newClientAddresses(
+			func(sessionID string, ip net.IP) {
+				blocklist.TrackSession(sessionID, net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)})
+			},
+			blocklist.UntrackSession,
+		))
+	}
+
 	m.openvpnProcess = m.processLauncher.launch(launchOpts{
 		config:       vpnServerConfig,
 		filterAllow:  openvpnFilterAllow,
 		filterBlock:  protectedNetworks,
 		stateChannel: stateChannel,
+		middlewares:  middlewares,
 	})
 
 	// register service port to which NATProxy will forward connects attempts to
@@ -234,11 +265,16 @@ func (m *Manager) Stop() error {
 		}
 	}
 
+	if m.dnsBlocklist != nil {
+		m.dnsBlocklist.Stop()
+		log.Info().Msgf("DNS queries of untracked clients blocked: %d", m.dnsBlocklist.Hits()[""])
+	}
+
 	return nil
 }
 
 // ProvideConfig takes session creation config from end consumer and provides the service configuration to the end consumer
-func (m *Manager) ProvideConfig(_ string, sessionConfig json.RawMessage, conn *net.UDPConn) (*session.ConfigParams, error) {
+func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage, conn *net.UDPConn) (*session.ConfigParams, error) {
 	if m.vpnServerPort == 0 {
 		return nil, errors.New("service port not initialized")
 	}
@@ -264,7 +300,7 @@ func (m *Manager) ProvideConfig(_ string, sessionConfig json.RawMessage, conn *n
 
 	if conn == nil { // TODO this backward compatibility block needs to be removed once we will fully migrate to the p2p communication.
 		if _, noop := m.natPinger.(*traversal.NoopPinger); noop {
-			return &session.ConfigParams{SessionServiceConfig: vpnConfig}, nil
+			return &session.ConfigParams{SessionServiceConfig: vpnConfig, SessionDestroyCallback: m.sessionDestroyCallback(sessionID)}, nil
 		}
 
 		var consumerConfig openvpn_service.ConsumerConfig
@@ -299,7 +335,18 @@ func (m *Manager) ProvideConfig(_ string, sessionConfig json.RawMessage, conn *n
 			return nil, fmt.Errorf("could not proxy connection to OpenVPN server: %w", err)
 		}
 	}
-	return &session.ConfigParams{SessionServiceConfig: vpnConfig, TraversalParams: traversalParams}, nil
+	return &session.ConfigParams{SessionServiceConfig: vpnConfig, TraversalParams: traversalParams, SessionDestroyCallback: m.sessionDestroyCallback(sessionID)}, nil
+}
+
+// sessionDestroyCallback reports DNS queries blocked during the session.
+func (m *Manager) sessionDestroyCallback(sessionID string) session.DestroyCallback {
+	if m.dnsBlocklist == nil {
+		return nil
+	}
+	blocklist := m.dnsBlocklist
+	return func() {
+		log.Info().Msgf("Session %s: %d DNS queries blocked", sessionID, blocklist.ReleaseSession(sessionID))
+	}
 }
 
 func (m *Manager) startServer(stateChannel chan openvpn.State) error {
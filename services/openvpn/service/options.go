@@ -22,6 +22,8 @@ import (
 
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/dns"
+	"github.com/mysteriumnetwork/node/services"
 	"github.com/rs/zerolog/log"
 )
 
@@ -31,6 +33,8 @@ type Options struct {
 	Port     int    `json:"port"`
 	Subnet   string `json:"subnet"`
 	Netmask  string `json:"netmask"`
+
+	DNSBlocklist dns.BlocklistOptions `json:"dnsBlocklist"`
 }
 
 // GetOptions returns effective OpenVPN service options from application configuration.
@@ -40,6 +44,8 @@ func GetOptions() Options {
 		Port:     config.GetInt(config.FlagOpenvpnPort),
 		Subnet:   config.GetString(config.FlagOpenvpnSubnet),
 		Netmask:  config.GetString(config.FlagOpenvpnNetmask),
+
+		DNSBlocklist: services.DNSBlocklistConfiguredOptions(),
 	}
 }
 
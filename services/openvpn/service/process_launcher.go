@@ -19,6 +19,7 @@ package service
 
 import (
 	"github.com/mysteriumnetwork/go-openvpn/openvpn"
+	"github.com/mysteriumnetwork/go-openvpn/openvpn/management"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/middlewares/server/auth"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/middlewares/server/bytecount"
 	"github.com/mysteriumnetwork/go-openvpn/openvpn/middlewares/server/filter"
@@ -46,6 +47,7 @@ type launchOpts struct {
 	config                   *openvpn_service.ServerConfig
 	filterAllow, filterBlock []string
 	stateChannel             chan openvpn.State
+	middlewares              []management.Middleware
 }
 
 func (p *processLauncher) launch(opts launchOpts) openvpn.Process {
@@ -57,12 +59,16 @@ func (p *processLauncher) launch(opts launchOpts) openvpn.Process {
 		}
 	}
 
-	return openvpn.CreateNewProcess(
-		p.opts.Openvpn.BinaryPath(),
-		opts.config.GenericConfig,
+	middlewares := append([]management.Middleware{
 		filter.NewMiddleware(opts.filterAllow, opts.filterBlock),
 		auth.NewMiddleware(p.sessionValidator.Validate),
 		state.NewMiddleware(stateCallback),
 		bytecount.NewMiddleware(p.statsCallback, statisticsReportingIntervalInSeconds),
+	}, opts.middlewares...)
+
+	return openvpn.CreateNewProcess(
+		p.opts.Openvpn.BinaryPath(),
+		opts.config.GenericConfig,
+		middlewares...,
 	)
 }
@@ -21,6 +21,8 @@ import (
 	"strings"
 
 	"github.com/mysteriumnetwork/node/config"
+	"github.com/mysteriumnetwork/node/dns"
+	"github.com/mysteriumnetwork/node/utils/stringutil"
 )
 
 // SharedConfiguredOptions returns effective shared service options
@@ -40,3 +42,12 @@ func SharedConfiguredOptions() config.ServicesOptions {
 		ShaperEnabled:             config.GetBool(config.FlagShaperEnabled),
 	}
 }
+
+// DNSBlocklistConfiguredOptions returns effective DNS blocklist options shared by the services
+func DNSBlocklistConfiguredOptions() dns.BlocklistOptions {
+	return dns.BlocklistOptions{
+		Sources:  stringutil.Split(config.GetString(config.FlagDNSBlocklistSources), ','),
+		Refresh:  config.GetDuration(config.FlagDNSBlocklistRefresh),
+		BlockIPs: config.GetBool(config.FlagDNSBlocklistBlockIPs),
+	}
+}
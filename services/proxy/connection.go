@@ -0,0 +1,179 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/xtaci/kcp-go/v5"
+	"github.com/xtaci/smux"
+)
+
+// NewConnection creates a new proxy connection which exposes the provider's proxy on localAddress.
+func NewConnection(localAddress string) (connection.Connection, error) {
+	return &Connection{
+		localAddress: localAddress,
+		stateCh:      make(chan connection.State, 100),
+		done:         make(chan struct{}),
+	}, nil
+}
+
+// Connection forwards local SOCKS5 and HTTP CONNECT clients to the provider's proxy service.
+type Connection struct {
+	stopOnce sync.Once
+	done     chan struct{}
+	stateCh  chan connection.State
+
+	localAddress string
+	key          []byte
+	conn         *net.UDPConn
+	mux          *smux.Session
+	listener     net.Listener
+
+	sent     uint64
+	received uint64
+}
+
+var _ connection.Connection = &Connection{}
+
+// State returns connection state channel.
+func (c *Connection) State() <-chan connection.State {
+	return c.stateCh
+}
+
+// Statistics returns bytes transferred through the local proxy.
+func (c *Connection) Statistics() (connection.Statistics, error) {
+	return connection.Statistics{
+		At:            time.Now(),
+		BytesSent:     atomic.LoadUint64(&c.sent),
+		BytesReceived: atomic.LoadUint64(&c.received),
+	}, nil
+}
+
+// Start connects to the provider over the p2p service conn and starts the local proxy listener.
+func (c *Connection) Start(ctx context.Context, options connection.ConnectOptions) (err error) {
+	if options.ProviderNATConn == nil {
+		return ErrP2PRequired
+	}
+
+	defer func() {
+		if err != nil {
+			c.Stop()
+		}
+	}()
+
+	c.stateCh <- connection.Connecting
+
+	block, err := newBlockCrypt(c.key)
+	if err != nil {
+		return err
+	}
+
+	conn, peerAddr, err := reopenConn(options.ProviderNATConn)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+
+	sess, err := kcp.NewConn3(kcpConv, peerAddr, block, 10, 3, conn)
+	if err != nil {
+		return errors.Wrap(err, "could not create proxy session")
+	}
+	configureSession(sess)
+
+	c.mux, err = smux.Client(sess, smuxConfig())
+	if err != nil {
+		sess.Close()
+		return errors.Wrap(err, "could not create proxy stream multiplexer")
+	}
+
+	c.listener, err = net.Listen("tcp", c.localAddress)
+	if err != nil {
+		return errors.Wrapf(err, "could not listen on %s", c.localAddress)
+	}
+	go c.serve()
+
+	log.Info().Msgf("Proxy is available on %s", c.listener.Addr())
+	c.stateCh <- connection.Connected
+	return nil
+}
+
+func (c *Connection) serve() {
+	for {
+		local, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			stream, err := c.mux.OpenStream()
+			if err != nil {
+				log.Error().Err(err).Msg("Could not open proxy stream")
+				local.Close()
+				return
+			}
+			pipe(local, stream, &c.received, &c.sent)
+		}()
+	}
+}
+
+// Wait blocks until proxy connection is stopped.
+func (c *Connection) Wait() error {
+	<-c.done
+	return nil
+}
+
+// GetConfig returns the consumer configuration for session creation
+func (c *Connection) GetConfig() (connection.ConsumerConfig, error) {
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	c.key = key
+
+	return ConsumerConfig{Key: key}, nil
+}
+
+// Stop closes the local proxy listener and the connection to the provider.
+func (c *Connection) Stop() {
+	c.stopOnce.Do(func() {
+		log.Info().Msg("Stopping proxy connection")
+		c.stateCh <- connection.Disconnecting
+
+		if c.listener != nil {
+			c.listener.Close()
+		}
+		if c.mux != nil {
+			c.mux.Close()
+		}
+		if c.conn != nil {
+			c.conn.Close()
+		}
+
+		c.stateCh <- connection.NotConnected
+
+		close(c.stateCh)
+		close(c.done)
+	})
+}
@@ -0,0 +1,400 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	socks5Version          = 0x05
+	socks5NoAuth           = 0x00
+	socks5UserPassAuth     = 0x02
+	socks5NoAcceptable     = 0xff
+	socks5CmdConnect       = 0x01
+	socks5AddrIPv4         = 0x01
+	socks5AddrDomain       = 0x03
+	socks5AddrIPv6         = 0x04
+	socks5Succeeded        = 0x00
+	socks5NotAllowed       = 0x02
+	socks5HostUnreachable  = 0x04
+	socks5CmdNotSupported  = 0x07
+	socks5AddrNotSupported = 0x08
+	// socks5UserPassVersion is the version of username/password authentication, RFC 1929.
+	socks5UserPassVersion = 0x01
+	socks5AuthSucceeded   = 0x00
+	socks5AuthFailed      = 0x01
+
+	dialTimeout = 15 * time.Second
+)
+
+var errNotAllowed = errors.New("destination is not allowed")
+
+// hostPolicy decides which hostnames consumers may reach through the proxy.
+type hostPolicy interface {
+	IsHostAllowed(host string) bool
+}
+
+// Credentials are the username and password proxy clients have to authenticate with.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+func (c Credentials) match(username, password string) bool {
+	// Both are compared to not reveal which one is wrong through timing.
+	usernameOK := subtle.ConstantTimeCompare([]byte(username), []byte(c.Username)) == 1
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(c.Password)) == 1
+	return usernameOK && passwordOK
+}
+
+// handler serves SOCKS5 and HTTP CONNECT requests arriving on proxied streams.
+type handler struct {
+	// credentials are required from clients when set.
+	credentials *Credentials
+	policy      hostPolicy
+	allowIP     func(ip net.IP) bool
+	resolve     func(ctx context.Context, host string) ([]net.IPAddr, error)
+	dial        func(network, address string) (net.Conn, error)
+}
+
+func newHandler(policy hostPolicy) *handler {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	return &handler{
+		policy:  policy,
+		allowIP: isPublicIP,
+		resolve: net.DefaultResolver.LookupIPAddr,
+		dial:    dialer.Dial,
+	}
+}
+
+// serve handles a single stream. Streams starting with the SOCKS version byte are served as SOCKS5,
+// anything else is expected to be an HTTP CONNECT request.
+// Bytes written to the stream are added to sent, bytes written to the destination to received.
+func (h *handler) serve(stream io.ReadWriteCloser, sent, received *uint64) {
+	defer stream.Close()
+
+	reader := bufio.NewReader(stream)
+	first, err := reader.Peek(1)
+	if err != nil {
+		return
+	}
+
+	var target net.Conn
+	if first[0] == socks5Version {
+		target, err = h.socks5(reader, stream)
+	} else {
+		target, err = h.httpConnect(reader, stream)
+	}
+	if err != nil {
+		log.Debug().Err(err).Msg("Proxy request failed")
+		return
+	}
+
+	pipe(bufferedStream{reader: reader, ReadWriteCloser: stream}, target, sent, received)
+}
+
+func (h *handler) socks5(r *bufio.Reader, w io.Writer) (net.Conn, error) {
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(r, greeting); err != nil {
+		return nil, errors.Wrap(err, "could not read SOCKS5 greeting")
+	}
+	methods := make([]byte, greeting[1])
+	if _, err := io.ReadFull(r, methods); err != nil {
+		return nil, errors.Wrap(err, "could not read SOCKS5 methods")
+	}
+	if h.credentials != nil {
+		if err := h.socks5Authenticate(r, w, methods); err != nil {
+			return nil, err
+		}
+	} else {
+		if bytes.IndexByte(methods, socks5NoAuth) < 0 {
+			w.Write([]byte{socks5Version, socks5NoAcceptable})
+			return nil, errors.New("SOCKS5 client does not support connecting without authentication")
+		}
+		if _, err := w.Write([]byte{socks5Version, socks5NoAuth}); err != nil {
+			return nil, errors.Wrap(err, "could not write SOCKS5 method selection")
+		}
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(r, request); err != nil {
+		return nil, errors.Wrap(err, "could not read SOCKS5 request")
+	}
+	if request[0] != socks5Version {
+		return nil, errors.Errorf("unsupported SOCKS version %d", request[0])
+	}
+	host, err := readSocks5Host(r, request[3])
+	if err != nil {
+		socks5Reply(w, socks5AddrNotSupported)
+		return nil, err
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return nil, errors.Wrap(err, "could not read SOCKS5 port")
+	}
+	if request[1] != socks5CmdConnect {
+		socks5Reply(w, socks5CmdNotSupported)
+		return nil, errors.Errorf("unsupported SOCKS5 command %d", request[1])
+	}
+
+	target, err := h.connect(host, fmt.Sprint(int(port[0])<<8|int(port[1])))
+	if err == errNotAllowed {
+		socks5Reply(w, socks5NotAllowed)
+		return nil, err
+	}
+	if err != nil {
+		socks5Reply(w, socks5HostUnreachable)
+		return nil, err
+	}
+	if err := socks5Reply(w, socks5Succeeded); err != nil {
+		target.Close()
+		return nil, errors.Wrap(err, "could not write SOCKS5 reply")
+	}
+	return target, nil
+}
+
+// socks5Authenticate checks username and password of the client, see RFC 1929.
+func (h *handler) socks5Authenticate(r *bufio.Reader, w io.Writer, methods []byte) error {
+	if bytes.IndexByte(methods, socks5UserPassAuth) < 0 {
+		w.Write([]byte{socks5Version, socks5NoAcceptable})
+		return errors.New("SOCKS5 client does not support username/password authentication")
+	}
+	if _, err := w.Write([]byte{socks5Version, socks5UserPassAuth}); err != nil {
+		return errors.Wrap(err, "could not write SOCKS5 method selection")
+	}
+
+	version, err := r.ReadByte()
+	if err != nil {
+		return errors.Wrap(err, "could not read SOCKS5 authentication")
+	}
+	if version != socks5UserPassVersion {
+		return errors.Errorf("unsupported SOCKS5 authentication version %d", version)
+	}
+	username, err := readSocks5String(r)
+	if err != nil {
+		return errors.Wrap(err, "could not read SOCKS5 username")
+	}
+	password, err := readSocks5String(r)
+	if err != nil {
+		return errors.Wrap(err, "could not read SOCKS5 password")
+	}
+	if !h.credentials.match(username, password) {
+		w.Write([]byte{socks5UserPassVersion, socks5AuthFailed})
+		return errors.New("SOCKS5 client authentication failed")
+	}
+	if _, err := w.Write([]byte{socks5UserPassVersion, socks5AuthSucceeded}); err != nil {
+		return errors.Wrap(err, "could not write SOCKS5 authentication reply")
+	}
+	return nil
+}
+
+// readSocks5String reads a string prefixed by its length byte.
+func readSocks5String(r io.Reader) (string, error) {
+	length := make([]byte, 1)
+	if _, err := io.ReadFull(r, length); err != nil {
+		return "", err
+	}
+	value := make([]byte, length[0])
+	if _, err := io.ReadFull(r, value); err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+func readSocks5Host(r io.Reader, addrType byte) (string, error) {
+	switch addrType {
+	case socks5AddrIPv4, socks5AddrIPv6:
+		ip := make(net.IP, net.IPv4len)
+		if addrType == socks5AddrIPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", errors.Wrap(err, "could not read SOCKS5 address")
+		}
+		return ip.String(), nil
+	case socks5AddrDomain:
+		domain, err := readSocks5String(r)
+		if err != nil {
+			return "", errors.Wrap(err, "could not read SOCKS5 domain")
+		}
+		return domain, nil
+	default:
+		return "", errors.Errorf("unsupported SOCKS5 address type %d", addrType)
+	}
+}
+
+func socks5Reply(w io.Writer, code byte) error {
+	_, err := w.Write([]byte{socks5Version, code, 0x00, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
+
+func (h *handler) httpConnect(r *bufio.Reader, w io.Writer) (net.Conn, error) {
+	req, err := http.ReadRequest(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read HTTP proxy request")
+	}
+	if req.Method != http.MethodConnect {
+		httpReply(w, http.StatusMethodNotAllowed)
+		return nil, errors.Errorf("unsupported HTTP proxy method %s", req.Method)
+	}
+	if h.credentials != nil && !h.httpAuthenticated(req) {
+		_, err := fmt.Fprintf(w, "HTTP/1.1 %d %s\r\nProxy-Authenticate: Basic realm=\"proxy\"\r\n\r\n",
+			http.StatusProxyAuthRequired, http.StatusText(http.StatusProxyAuthRequired))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not write HTTP CONNECT reply")
+		}
+		return nil, errors.New("HTTP proxy client authentication failed")
+	}
+	host, port, err := net.SplitHostPort(req.Host)
+	if err != nil {
+		httpReply(w, http.StatusBadRequest)
+		return nil, errors.Wrap(err, "invalid HTTP CONNECT destination")
+	}
+
+	target, err := h.connect(host, port)
+	if err == errNotAllowed {
+		httpReply(w, http.StatusForbidden)
+		return nil, err
+	}
+	if err != nil {
+		httpReply(w, http.StatusBadGateway)
+		return nil, err
+	}
+	if err := httpReply(w, http.StatusOK); err != nil {
+		target.Close()
+		return nil, errors.Wrap(err, "could not write HTTP CONNECT reply")
+	}
+	return target, nil
+}
+
+// httpAuthenticated checks basic credentials of the Proxy-Authorization header.
+func (h *handler) httpAuthenticated(req *http.Request) bool {
+	const prefix = "Basic "
+	auth := req.Header.Get("Proxy-Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, prefix))
+	if err != nil {
+		return false
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	return ok && h.credentials.match(username, password)
+}
+
+func httpReply(w io.Writer, status int) error {
+	_, err := fmt.Fprintf(w, "HTTP/1.1 %d %s\r\n\r\n", status, http.StatusText(status))
+	return err
+}
+
+// connect checks the destination against access policies and dials it.
+// Hostnames are resolved here so that restricted addresses are never reached through DNS.
+func (h *handler) connect(host, port string) (net.Conn, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if h.policy != nil && !h.policy.IsHostAllowed(host) {
+		return nil, errNotAllowed
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = append(ips, ip)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+		defer cancel()
+		addrs, err := h.resolve(ctx, host)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not resolve %s", host)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	err := errNotAllowed
+	for _, ip := range ips {
+		if !h.allowIP(ip) {
+			continue
+		}
+		var conn net.Conn
+		conn, err = h.dial("tcp", net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+var privateNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+)
+
+// isPublicIP reports whether the proxy may connect to ip. Loopback, link-local,
+// multicast and private addresses are refused so that consumers can not reach the provider's own network.
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsLinkLocalMulticast() {
+		return false
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// bufferedStream reads through the buffer used for the handshake, so that data
+// sent by the client right after its request is not lost.
+type bufferedStream struct {
+	reader *bufio.Reader
+	io.ReadWriteCloser
+}
+
+func (s bufferedStream) Read(p []byte) (int, error) {
+	return s.reader.Read(p)
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/policy"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEchoServer(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+	return listener
+}
+
+func newTestHandler(policies hostPolicy) *handler {
+	h := newHandler(policies)
+	h.allowIP = func(net.IP) bool { return true }
+	h.resolve = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+	}
+	return h
+}
+
+func serveTestStream(h *handler) (net.Conn, *uint64, *uint64) {
+	client, stream := net.Pipe()
+	var sent, received uint64
+	go h.serve(stream, &sent, &received)
+	return client, &sent, &received
+}
+
+func Test_Handler_SOCKS5Connect(t *testing.T) {
+	echo := newEchoServer(t)
+	defer echo.Close()
+	port := echo.Addr().(*net.TCPAddr).Port
+
+	client, _, _ := serveTestStream(newTestHandler(nil))
+	defer client.Close()
+
+	_, err := client.Write([]byte{socks5Version, 1, socks5NoAuth})
+	require.NoError(t, err)
+	reply := make([]byte, 2)
+	_, err = io.ReadFull(client, reply)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{socks5Version, socks5NoAuth}, reply)
+
+	request := []byte{socks5Version, socks5CmdConnect, 0, socks5AddrDomain, 9}
+	request = append(request, "localhost"...)
+	request = append(request, byte(port>>8), byte(port))
+	_, err = client.Write(request)
+	require.NoError(t, err)
+	reply = make([]byte, 10)
+	_, err = io.ReadFull(client, reply)
+	require.NoError(t, err)
+	assert.Equal(t, byte(socks5Succeeded), reply[1])
+
+	_, err = client.Write([]byte("ping"))
+	require.NoError(t, err)
+	echoed := make([]byte, 4)
+	_, err = io.ReadFull(client, echoed)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(echoed))
+}
+
+func Test_Handler_SOCKS5RefusesHostNotAllowedByPolicy(t *testing.T) {
+	policies := policy.NewRepository()
+	policies.SetPolicyRules(
+		market.AccessPolicy{ID: "1"},
+		market.AccessPolicyRuleSet{ID: "1", Allow: []market.AccessRule{{Type: market.AccessPolicyTypeDNSHostname, Value: "ipinfo.io"}}},
+	)
+
+	client, _, _ := serveTestStream(newTestHandler(policies))
+	defer client.Close()
+
+	_, err := client.Write([]byte{socks5Version, 1, socks5NoAuth})
+	require.NoError(t, err)
+	reply := make([]byte, 2)
+	_, err = io.ReadFull(client, reply)
+	require.NoError(t, err)
+
+	request := []byte{socks5Version, socks5CmdConnect, 0, socks5AddrIPv4, 1, 1, 1, 1, 0, 80}
+	_, err = client.Write(request)
+	require.NoError(t, err)
+	reply = make([]byte, 10)
+	_, err = io.ReadFull(client, reply)
+	require.NoError(t, err)
+	assert.Equal(t, byte(socks5NotAllowed), reply[1])
+}
+
+func Test_Handler_HTTPConnect(t *testing.T) {
+	echo := newEchoServer(t)
+	defer echo.Close()
+
+	client, sent, received := serveTestStream(newTestHandler(nil))
+	defer client.Close()
+
+	_, err := client.Write([]byte("CONNECT " + echo.Addr().String() + " HTTP/1.1\r\nHost: " + echo.Addr().String() + "\r\n\r\nping"))
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(client)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	echoed := make([]byte, 4)
+	_, err = io.ReadFull(reader, echoed)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(echoed))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadUint64(sent) == 4 && atomic.LoadUint64(received) == 4
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_Handler_HTTPRejectsPlainRequests(t *testing.T) {
+	client, _, _ := serveTestStream(newTestHandler(nil))
+	defer client.Close()
+
+	go client.Write([]byte("GET http://example.com/ HTTP/1.1\r\nHost: example.com\r\n\r\n"))
+
+	resp, err := http.ReadResponse(bufio.NewReader(client), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func Test_Handler_RefusesRestrictedAddresses(t *testing.T) {
+	h := newHandler(nil)
+
+	_, err := h.connect("127.0.0.1", "4050")
+	assert.Equal(t, errNotAllowed, err)
+
+	_, err = h.connect("192.168.1.1", "80")
+	assert.Equal(t, errNotAllowed, err)
+}
+
+func Test_IsPublicIP(t *testing.T) {
+	for ip, expected := range map[string]bool{
+		"1.1.1.1":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.5.4":      false,
+		"100.64.0.1":      false,
+		"169.254.1.1":     false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"0.0.0.0":         false,
+	} {
+		assert.Equal(t, expected, isPublicIP(net.ParseIP(ip)), ip)
+	}
+}
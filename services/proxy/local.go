@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"context"
+	"net"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ContextDialer connects to the address on the named network.
+type ContextDialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+// ServeLocal serves SOCKS5 and HTTP CONNECT clients accepted by the listener until it is closed.
+// Clients have to authenticate with the credentials, SOCKS5 clients with username/password
+// and HTTP clients with basic Proxy-Authorization.
+// Destinations are reached by dial only and hostnames are resolved by resolver only, so that
+// nothing leaks outside of the tunnel they are bound to. Only IPv4 destinations are used.
+func ServeLocal(listener net.Listener, credentials Credentials, resolver *net.Resolver, dial ContextDialer) {
+	h := &handler{
+		credentials: &credentials,
+		allowIP: func(ip net.IP) bool {
+			return ip.To4() != nil
+		},
+		resolve: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			ips, err := resolver.LookupIP(ctx, "ip4", host)
+			if err != nil {
+				return nil, err
+			}
+			addrs := make([]net.IPAddr, 0, len(ips))
+			for _, ip := range ips {
+				addrs = append(addrs, net.IPAddr{IP: ip})
+			}
+			return addrs, nil
+		},
+		dial: func(network, address string) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+			defer cancel()
+			return dial(ctx, network, address)
+		},
+	}
+
+	var sent, received uint64
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Debug().Err(err).Msgf("Stopped serving local proxy on %s", listener.Addr())
+			return
+		}
+		go h.serve(conn, &sent, &received)
+	}
+}
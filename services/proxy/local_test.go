@@ -0,0 +1,155 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	netproxy "golang.org/x/net/proxy"
+)
+
+var testCredentials = Credentials{Username: "myst", Password: "secret"}
+
+func Test_ServeLocal_ConnectsThroughDialer(t *testing.T) {
+	echo := newEchoServer(t)
+	defer echo.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	dialed := make(chan string, 1)
+	go ServeLocal(listener, testCredentials, net.DefaultResolver, func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed <- address
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, echo.Addr().String())
+	})
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Basic base64 of "myst:secret".
+	_, err = fmt.Fprint(client, "CONNECT 203.0.113.1:443 HTTP/1.1\r\nHost: 203.0.113.1:443\r\nProxy-Authorization: Basic bXlzdDpzZWNyZXQ=\r\n\r\n")
+	require.NoError(t, err)
+	reader := bufio.NewReader(client)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "203.0.113.1:443", <-dialed)
+
+	_, err = client.Write([]byte("ping"))
+	require.NoError(t, err)
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(reader, reply)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(reply))
+}
+
+func Test_ServeLocal_RefusesIPv6Destinations(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go ServeLocal(listener, testCredentials, net.DefaultResolver, func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, fmt.Errorf("unexpected dial to %s", address)
+	})
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = fmt.Fprint(client, "CONNECT [2001:db8::1]:443 HTTP/1.1\r\nHost: [2001:db8::1]:443\r\nProxy-Authorization: Basic bXlzdDpzZWNyZXQ=\r\n\r\n")
+	require.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(client), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func Test_ServeLocal_RequiresHTTPProxyAuthorization(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go ServeLocal(listener, testCredentials, net.DefaultResolver, func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, fmt.Errorf("unexpected dial to %s", address)
+	})
+
+	for name, header := range map[string]string{
+		"missing":        "",
+		"wrong password": "Proxy-Authorization: Basic bXlzdDp3cm9uZw==\r\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			client, err := net.Dial("tcp", listener.Addr().String())
+			require.NoError(t, err)
+			defer client.Close()
+
+			_, err = fmt.Fprintf(client, "CONNECT 203.0.113.1:443 HTTP/1.1\r\nHost: 203.0.113.1:443\r\n%s\r\n", header)
+			require.NoError(t, err)
+			resp, err := http.ReadResponse(bufio.NewReader(client), nil)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusProxyAuthRequired, resp.StatusCode)
+			assert.Equal(t, `Basic realm="proxy"`, resp.Header.Get("Proxy-Authenticate"))
+		})
+	}
+}
+
+func Test_ServeLocal_AuthenticatesSOCKS5Clients(t *testing.T) {
+	echo := newEchoServer(t)
+	defer echo.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go ServeLocal(listener, testCredentials, net.DefaultResolver, func(ctx context.Context, network, address string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, echo.Addr().String())
+	})
+
+	wrong, err := netproxy.SOCKS5("tcp", listener.Addr().String(), &netproxy.Auth{User: "myst", Password: "wrong"}, netproxy.Direct)
+	require.NoError(t, err)
+	_, err = wrong.Dial("tcp", "203.0.113.1:443")
+	assert.Error(t, err)
+
+	anonymous, err := netproxy.SOCKS5("tcp", listener.Addr().String(), nil, netproxy.Direct)
+	require.NoError(t, err)
+	_, err = anonymous.Dial("tcp", "203.0.113.1:443")
+	assert.Error(t, err)
+
+	authenticated, err := netproxy.SOCKS5("tcp", listener.Addr().String(), &netproxy.Auth{User: "myst", Password: "secret"}, netproxy.Direct)
+	require.NoError(t, err)
+	conn, err := authenticated.Dial("tcp", "203.0.113.1:443")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(reply))
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/session/event"
+	"github.com/rs/zerolog/log"
+	"github.com/xtaci/kcp-go/v5"
+	"github.com/xtaci/smux"
+)
+
+const statsPublishInterval = 5 * time.Second
+
+// server accepts proxy streams of a single session on the reopened service conn.
+type server struct {
+	conn     *net.UDPConn
+	listener *kcp.Listener
+	peerAddr *net.UDPAddr
+	handler  *handler
+
+	sent     uint64
+	received uint64
+
+	mu       sync.Mutex
+	sessions []*smux.Session
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newServer(conn *net.UDPConn, peerAddr *net.UDPAddr, block kcp.BlockCrypt, handler *handler) (*server, error) {
+	listener, err := kcp.ServeConn(block, 10, 3, conn)
+	if err != nil {
+		return nil, err
+	}
+	return &server{
+		conn:     conn,
+		listener: listener,
+		peerAddr: peerAddr,
+		handler:  handler,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+func (s *server) serve() {
+	for {
+		sess, err := s.listener.AcceptKCP()
+		if err != nil {
+			return
+		}
+		if addr, ok := sess.RemoteAddr().(*net.UDPAddr); !ok || !addr.IP.Equal(s.peerAddr.IP) || addr.Port != s.peerAddr.Port {
+			log.Warn().Msgf("Rejected proxy connection from unexpected address %s", sess.RemoteAddr())
+			sess.Close()
+			continue
+		}
+		configureSession(sess)
+
+		mux, err := smux.Server(sess, smuxConfig())
+		if err != nil {
+			log.Error().Err(err).Msg("Could not create proxy stream multiplexer")
+			sess.Close()
+			continue
+		}
+		s.mu.Lock()
+		s.sessions = append(s.sessions, mux)
+		s.mu.Unlock()
+
+		go s.serveStreams(mux)
+	}
+}
+
+func (s *server) serveStreams(mux *smux.Session) {
+	for {
+		stream, err := mux.AcceptStream()
+		if err != nil {
+			return
+		}
+		go s.handler.serve(stream, &s.sent, &s.received)
+	}
+}
+
+// publishStats reports transferred bytes of the session, which are used for invoicing.
+func (s *server) publishStats(sessionID string, bus eventbus.Publisher) {
+	ticker := time.NewTicker(statsPublishInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sent, received := s.stats()
+			bus.Publish(event.AppTopicDataTransferred, event.AppEventDataTransferred{
+				ID:   sessionID,
+				Up:   sent,
+				Down: received,
+			})
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *server) stats() (sent, received uint64) {
+	return atomic.LoadUint64(&s.sent), atomic.LoadUint64(&s.received)
+}
+
+func (s *server) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.listener.Close()
+
+		s.mu.Lock()
+		for _, mux := range s.sessions {
+			mux.Close()
+		}
+		s.mu.Unlock()
+
+		s.conn.Close()
+	})
+}
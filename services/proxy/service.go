@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+
+	"github.com/mysteriumnetwork/node/core/location"
+	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrP2PRequired is returned when a session is requested without the p2p service conn.
+var ErrP2PRequired = errors.New("proxy service requires p2p connection")
+
+// NewManager creates new instance of proxy service.
+func NewManager(publisher eventbus.Publisher) *Manager {
+	return &Manager{
+		publisher: publisher,
+		servers:   make(map[string]*server),
+		done:      make(chan struct{}),
+	}
+}
+
+// Manager represents an instance of proxy service which serves SOCKS5 and HTTP CONNECT
+// requests over the p2p service conn without any privileged network setup.
+type Manager struct {
+	publisher eventbus.Publisher
+
+	mu       sync.Mutex
+	policies hostPolicy
+	servers  map[string]*server
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// ProvideConfig starts serving proxy requests of the session on the given service conn.
+func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage, remoteConn *net.UDPConn) (*session.ConfigParams, error) {
+	if remoteConn == nil {
+		return nil, ErrP2PRequired
+	}
+
+	var consumerConfig ConsumerConfig
+	if err := json.Unmarshal(sessionConfig, &consumerConfig); err != nil {
+		return nil, errors.Wrap(err, "could not parse consumer config")
+	}
+	block, err := newBlockCrypt(consumerConfig.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, peerAddr, err := reopenConn(remoteConn)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	policies := m.policies
+	m.mu.Unlock()
+
+	srv, err := newServer(conn, peerAddr, block, newHandler(policies))
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not start proxy listener")
+	}
+
+	m.mu.Lock()
+	m.servers[sessionID] = srv
+	m.mu.Unlock()
+
+	go srv.serve()
+	go srv.publishStats(sessionID, m.publisher)
+	log.Info().Msgf("Serving proxy session %s on port %d", sessionID, conn.LocalAddr().(*net.UDPAddr).Port)
+
+	return &session.ConfigParams{
+		SessionDestroyCallback: func() {
+			m.stopSession(sessionID)
+		},
+	}, nil
+}
+
+func (m *Manager) stopSession(sessionID string) {
+	m.mu.Lock()
+	srv, ok := m.servers[sessionID]
+	delete(m.servers, sessionID)
+	m.mu.Unlock()
+
+	if ok {
+		srv.stop()
+		log.Info().Msgf("Stopped proxy session %s", sessionID)
+	}
+}
+
+// Serve starts service - does block
+func (m *Manager) Serve(instance *service.Instance) error {
+	if policies := instance.Policies(); policies != nil {
+		m.mu.Lock()
+		m.policies = policies
+		m.mu.Unlock()
+	}
+
+	log.Info().Msg("Proxy service started successfully")
+	<-m.done
+	return nil
+}
+
+// Stop stops service and all of its sessions.
+func (m *Manager) Stop() error {
+	m.stopOnce.Do(func() {
+		m.mu.Lock()
+		servers := m.servers
+		m.servers = make(map[string]*server)
+		m.mu.Unlock()
+
+		for _, srv := range servers {
+			srv.stop()
+		}
+		close(m.done)
+		log.Info().Msg("Proxy service stopped")
+	})
+	return nil
+}
+
+// GetProposal returns the proposal for proxy service
+func GetProposal(location location.Location) market.ServiceProposal {
+	return market.ServiceProposal{
+		ServiceType: ServiceType,
+		ServiceDefinition: ServiceDefinition{
+			Location: market.Location{
+				Continent: location.Continent,
+				Country:   location.Country,
+				City:      location.City,
+
+				ASN:      location.ASN,
+				ISP:      location.ISP,
+				NodeType: location.NodeType,
+			},
+		},
+	}
+}
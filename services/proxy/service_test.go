@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/connection"
+	"github.com/mysteriumnetwork/node/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServiceConns(t *testing.T) (provider, consumer *net.UDPConn) {
+	freePort := func() int {
+		conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+		require.NoError(t, err)
+		defer conn.Close()
+		return conn.LocalAddr().(*net.UDPAddr).Port
+	}
+	providerAddr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: freePort()}
+	consumerAddr := &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: freePort()}
+
+	provider, err := net.DialUDP("udp4", providerAddr, consumerAddr)
+	require.NoError(t, err)
+	consumer, err = net.DialUDP("udp4", consumerAddr, providerAddr)
+	require.NoError(t, err)
+	return provider, consumer
+}
+
+func socks5Connect(t *testing.T, proxyAddr string, target *net.TCPAddr) (net.Conn, byte) {
+	conn, err := net.Dial("tcp", proxyAddr)
+	require.NoError(t, err)
+
+	_, err = conn.Write([]byte{socks5Version, 1, socks5NoAuth})
+	require.NoError(t, err)
+	reply := make([]byte, 2)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+
+	request := append([]byte{socks5Version, socks5CmdConnect, 0, socks5AddrIPv4}, target.IP.To4()...)
+	request = append(request, byte(target.Port>>8), byte(target.Port))
+	_, err = conn.Write(request)
+	require.NoError(t, err)
+	reply = make([]byte, 10)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	return conn, reply[1]
+}
+
+func Test_ProxyOverServiceConn(t *testing.T) {
+	echo := newEchoServer(t)
+	defer echo.Close()
+	providerConn, consumerConn := newServiceConns(t)
+
+	consumer, err := NewConnection("127.0.0.1:0")
+	require.NoError(t, err)
+	consumerConfig, err := consumer.GetConfig()
+	require.NoError(t, err)
+	rawConfig, err := json.Marshal(consumerConfig)
+	require.NoError(t, err)
+
+	manager := NewManager(mocks.NewEventBus())
+	defer manager.Stop()
+	params, err := manager.ProvideConfig("session-1", rawConfig, providerConn)
+	require.NoError(t, err)
+	defer params.SessionDestroyCallback()
+
+	err = consumer.Start(context.Background(), connection.ConnectOptions{ProviderNATConn: consumerConn})
+	require.NoError(t, err)
+	defer consumer.Stop()
+	proxyAddr := consumer.(*Connection).listener.Addr().String()
+
+	// Provider refuses to proxy to its own local network.
+	conn, status := socks5Connect(t, proxyAddr, echo.Addr().(*net.TCPAddr))
+	conn.Close()
+	assert.Equal(t, byte(socks5NotAllowed), status)
+
+	manager.servers["session-1"].handler.allowIP = func(net.IP) bool { return true }
+	conn, status = socks5Connect(t, proxyAddr, echo.Addr().(*net.TCPAddr))
+	defer conn.Close()
+	require.Equal(t, byte(socks5Succeeded), status)
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	echoed := make([]byte, 4)
+	_, err = io.ReadFull(conn, echoed)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(echoed))
+
+	stats, err := consumer.Statistics()
+	require.NoError(t, err)
+	assert.NotZero(t, stats.BytesSent)
+	assert.NotZero(t, stats.BytesReceived)
+}
+
+func Test_ProvideConfigRequiresP2P(t *testing.T) {
+	manager := NewManager(mocks.NewEventBus())
+
+	_, err := manager.ProvideConfig("session-1", json.RawMessage(`{}`), nil)
+	assert.Equal(t, ErrP2PRequired, err)
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proxy
+
+import (
+	"crypto/rand"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xtaci/kcp-go/v5"
+	"github.com/xtaci/smux"
+)
+
+const (
+	keySize = 32
+	kcpConv = 1
+	kcpMTU  = 1280
+)
+
+// ConsumerConfig is sent by the consumer over the p2p channel when creating a session.
+type ConsumerConfig struct {
+	// Key is a random secret used to encrypt proxy traffic on the service conn.
+	Key []byte `json:"key"`
+}
+
+func newKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "could not generate proxy key")
+	}
+	return key, nil
+}
+
+func newBlockCrypt(key []byte) (kcp.BlockCrypt, error) {
+	if len(key) != keySize {
+		return nil, errors.Errorf("invalid proxy key size %d", len(key))
+	}
+	return kcp.NewSalsa20BlockCrypt(key)
+}
+
+// reopenConn replaces the punched, pre-connected service conn with an unconnected one
+// bound to the same local port, since KCP writes with WriteTo.
+func reopenConn(conn *net.UDPConn) (*net.UDPConn, *net.UDPAddr, error) {
+	peerAddr, ok := conn.RemoteAddr().(*net.UDPAddr)
+	if !ok {
+		return nil, nil, errors.New("service conn is not connected to the peer")
+	}
+	localAddr := conn.LocalAddr().(*net.UDPAddr)
+	conn.Close()
+
+	reopened, err := net.ListenUDP("udp4", localAddr)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not reopen service conn")
+	}
+	return reopened, peerAddr, nil
+}
+
+func configureSession(sess *kcp.UDPSession) {
+	sess.SetMtu(kcpMTU)
+	sess.SetStreamMode(true)
+	sess.SetWindowSize(1024, 1024)
+	sess.SetNoDelay(1, 20, 2, 1)
+}
+
+func smuxConfig() *smux.Config {
+	cfg := smux.DefaultConfig()
+	cfg.KeepAliveInterval = 5 * time.Second
+	cfg.KeepAliveTimeout = 30 * time.Second
+	return cfg
+}
+
+// countingWriter counts bytes successfully written to the underlying writer.
+type countingWriter struct {
+	io.Writer
+	n *uint64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	atomic.AddUint64(w.n, uint64(n))
+	return n, err
+}
+
+// pipe copies data in both directions until either side is closed.
+// Bytes written to a are added to toA, bytes written to b are added to toB.
+func pipe(a, b io.ReadWriteCloser, toA, toB *uint64) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(countingWriter{Writer: b, n: toB}, a)
+		b.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(countingWriter{Writer: a, n: toA}, b)
+		a.Close()
+	}()
+	wg.Wait()
+}
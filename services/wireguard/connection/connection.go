@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/json"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 
@@ -30,7 +31,9 @@ import (
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/firewall"
 	"github.com/mysteriumnetwork/node/nat/traversal"
+	"github.com/mysteriumnetwork/node/services/proxy"
 	wg "github.com/mysteriumnetwork/node/services/wireguard"
+	"github.com/mysteriumnetwork/node/services/wireguard/endpoint/netstack"
 	"github.com/mysteriumnetwork/node/services/wireguard/key"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -82,9 +85,16 @@ type Connection struct {
 	connEndpointFactory wg.EndpointFactory
 	dnsManager          DNSManager
 	handshakeWaiter     HandshakeWaiter
+	netstackEndpoint    *netstack.ConsumerEndpoint
+	proxyListener       net.Listener
 }
 
-var _ connection.Connection = &Connection{}
+var _ connection.LocalProxyConnection = &Connection{}
+
+// SupportsLocalProxy reports that the tunnel can be exposed on a local proxy port instead of a system-wide tunnel.
+func (c *Connection) SupportsLocalProxy() bool {
+	return true
+}
 
 // State returns connection state channel.
 func (c *Connection) State() <-chan connection.State {
@@ -111,11 +121,14 @@ func (c *Connection) Start(ctx context.Context, options connection.ConnectOption
 		return errors.Wrap(err, "failed to unmarshal connection config")
 	}
 
-	removeAllowedIPRule, err := firewall.AllowIPAccess(config.Provider.Endpoint.IP.String())
-	if err != nil {
-		return errors.Wrap(err, "failed to add firewall exception for wireguard remote IP")
+	localProxy := options.LocalProxy != nil
+	if !localProxy {
+		removeAllowedIPRule, err := firewall.AllowIPAccess(config.Provider.Endpoint.IP.String())
+		if err != nil {
+			return errors.Wrap(err, "failed to add firewall exception for wireguard remote IP")
+		}
+		c.removeAllowedIPRule = removeAllowedIPRule
 	}
-	c.removeAllowedIPRule = removeAllowedIPRule
 
 	defer func() {
 		if err != nil {
@@ -141,7 +154,7 @@ func (c *Connection) Start(ctx context.Context, options connection.ConnectOption
 	}
 
 	log.Info().Msg("Starting new connection")
-	conn, err := c.startConn(wg.ConsumerModeConfig{
+	conn, err := c.startConn(localProxy, wg.ConsumerModeConfig{
 		PrivateKey: c.privateKey,
 		IPAddress:  config.Consumer.IPAddress,
 		ListenPort: config.LocalPort,
@@ -167,12 +180,16 @@ func (c *Connection) Start(ctx context.Context, options connection.ConnectOption
 		return errors.Wrap(err, "failed while waiting for a peer handshake")
 	}
 
-	dnsIPs, err := options.DNS.ResolveIPs(config.Consumer.DNSIPs)
+	dnsIPs, err := options.ResolveDNS(config.Consumer.DNSIPs)
 	if err != nil {
 		return errors.Wrap(err, "could not resolve DNS IPs")
 	}
 	config.Consumer.DNSIPs = dnsIPs[0]
-	if err := c.dnsManager.Set(c.opts.DNSConfigDir, conn.InterfaceName(), config.Consumer.DNSIPs); err != nil {
+	if localProxy {
+		if err := c.startLocalProxy(*options.LocalProxy, config.Consumer.DNSIPs); err != nil {
+			return errors.Wrap(err, "failed to start local proxy")
+		}
+	} else if err := c.dnsManager.Set(c.opts.DNSConfigDir, conn.InterfaceName(), config.Consumer.DNSIPs); err != nil {
 		return errors.Wrap(err, "failed to configure DNS")
 	}
 
@@ -180,10 +197,39 @@ func (c *Connection) Start(ctx context.Context, options connection.ConnectOption
 	return nil
 }
 
-func (c *Connection) startConn(conf wg.ConsumerModeConfig) (wg.ConnectionEndpoint, error) {
-	conn, err := c.connEndpointFactory()
+// startLocalProxy serves the tunnel to local SOCKS5 and HTTP CONNECT clients. Proxy clients reach
+// destinations and the DNS server through the tunnel only, so they lose connectivity once it is down.
+// Clients have to authenticate with the local proxy credentials, so that other users of the host can't use the tunnel.
+func (c *Connection) startLocalProxy(localProxy connection.LocalProxy, dnsIP string) error {
+	dnsServer := net.ParseIP(dnsIP)
+	if dnsServer == nil {
+		return errors.Errorf("invalid DNS server %q", dnsIP)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(localProxy.Port)))
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create new connection endpoint")
+		return err
+	}
+	c.proxyListener = listener
+	tunnel := c.netstackEndpoint.Tunnel()
+	credentials := proxy.Credentials{Username: localProxy.Username, Password: localProxy.Password}
+	go proxy.ServeLocal(listener, credentials, tunnel.Resolver(dnsServer), tunnel.DialContext)
+
+	log.Info().Msgf("Tunnel is available on local proxy %s, using DNS %s", listener.Addr(), dnsServer)
+	return nil
+}
+
+func (c *Connection) startConn(localProxy bool, conf wg.ConsumerModeConfig) (wg.ConnectionEndpoint, error) {
+	var conn wg.ConnectionEndpoint
+	if localProxy {
+		c.netstackEndpoint = netstack.NewConsumerEndpoint()
+		conn = c.netstackEndpoint
+	} else {
+		var err error
+		conn, err = c.connEndpointFactory()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create new connection endpoint")
+		}
 	}
 
 	log.Info().Msg("Starting connection endpoint")
@@ -255,9 +301,14 @@ func (c *Connection) Stop() {
 		log.Info().Msg("Stopping WireGuard connection")
 		c.stateCh <- connection.Disconnecting
 
+		if c.proxyListener != nil {
+			c.proxyListener.Close()
+		}
 		if c.connectionEndpoint != nil {
-			if err := c.dnsManager.Clean(c.opts.DNSConfigDir, c.connectionEndpoint.InterfaceName()); err != nil {
-				log.Error().Err(err).Msg("Failed to clear DNS")
+			if c.netstackEndpoint == nil {
+				if err := c.dnsManager.Clean(c.opts.DNSConfigDir, c.connectionEndpoint.InterfaceName()); err != nil {
+					log.Error().Err(err).Msg("Failed to clear DNS")
+				}
 			}
 			if err := c.connectionEndpoint.Stop(); err != nil {
 				log.Error().Err(err).Msg("Failed to close wireguard connection")
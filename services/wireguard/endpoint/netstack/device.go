@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netstack
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.zx2c4.com/wireguard/tun"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/icmp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+)
+
+const (
+	deviceName = "netstack"
+	nicID      = 1
+	// queueSize limits packets written by the stack and not yet read by wireguard.
+	queueSize = 1024
+)
+
+// Device is a wireguard tunnel device backed by a userspace network stack instead of the system one.
+// Tunnelled connections are made through the device itself, so no routes or privileges are needed.
+type Device struct {
+	endpoint *channel.Endpoint
+	stack    *stack.Stack
+	mtu      int
+	events   chan tun.Event
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+// NewDevice creates a userspace network stack with the given local IPv4 addresses and returns
+// the wireguard tunnel device attached to it.
+func NewDevice(localAddresses []net.IP, mtu int) (*Device, error) {
+	s := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{tcp.NewProtocol, udp.NewProtocol, icmp.NewProtocol4},
+		// Traffic to own addresses is not looped back, otherwise forwarded packets would be dropped
+		// as having local source addresses in promiscuous mode.
+		HandleLocal: false,
+	})
+	sack := tcpip.TCPSACKEnabled(true)
+	if err := s.SetTransportProtocolOption(tcp.ProtocolNumber, &sack); err != nil {
+		s.Close()
+		return nil, errors.Errorf("could not enable TCP SACK: %v", err)
+	}
+
+	endpoint := channel.New(queueSize, uint32(mtu), "")
+	if err := s.CreateNIC(nicID, endpoint); err != nil {
+		s.Close()
+		return nil, errors.Errorf("could not create network interface: %v", err)
+	}
+	for _, ip := range localAddresses {
+		ip4 := ip.To4()
+		if ip4 == nil {
+			s.Close()
+			return nil, errors.Errorf("only IPv4 addresses are supported: %s", ip)
+		}
+		address := tcpip.ProtocolAddress{
+			Protocol:          ipv4.ProtocolNumber,
+			AddressWithPrefix: tcpip.AddrFromSlice(ip4).WithPrefix(),
+		}
+		if err := s.AddProtocolAddress(nicID, address, stack.AddressProperties{}); err != nil {
+			s.Close()
+			return nil, errors.Errorf("could not add address %s: %v", ip, err)
+		}
+	}
+	s.AddRoute(tcpip.Route{Destination: header.IPv4EmptySubnet, NIC: nicID})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Device{
+		endpoint: endpoint,
+		stack:    s,
+		mtu:      mtu,
+		events:   make(chan tun.Event, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	d.events <- tun.EventUp
+	return d, nil
+}
+
+// File returns nil, the device has no file descriptor.
+func (d *Device) File() *os.File {
+	return nil
+}
+
+// Read reads a packet sent by the network stack into the tunnel.
+func (d *Device) Read(buf []byte, offset int) (int, error) {
+	pkt := d.endpoint.ReadContext(d.ctx)
+	if pkt == nil {
+		return 0, os.ErrClosed
+	}
+	view := pkt.ToView()
+	pkt.DecRef()
+	defer view.Release()
+
+	return view.Read(buf[offset:])
+}
+
+// Write delivers a packet received from the tunnel to the network stack.
+func (d *Device) Write(buf []byte, offset int) (int, error) {
+	packet := buf[offset:]
+	if len(packet) == 0 {
+		return 0, nil
+	}
+	if packet[0]>>4 != 4 {
+		return 0, syscall.EAFNOSUPPORT
+	}
+
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(packet)})
+	d.endpoint.InjectInbound(header.IPv4ProtocolNumber, pkt)
+	pkt.DecRef()
+	return len(buf), nil
+}
+
+// Flush does nothing, packets are delivered on write.
+func (d *Device) Flush() error {
+	return nil
+}
+
+// MTU returns the MTU of the device.
+func (d *Device) MTU() (int, error) {
+	return d.mtu, nil
+}
+
+// Name returns the name of the device.
+func (d *Device) Name() (string, error) {
+	return deviceName, nil
+}
+
+// Events returns the channel of device events.
+func (d *Device) Events() chan tun.Event {
+	return d.events
+}
+
+// Close stops the network stack, closing all connections made through it.
+func (d *Device) Close() error {
+	d.closeOnce.Do(func() {
+		d.cancel()
+		d.stack.RemoveNIC(nicID)
+		d.stack.Close()
+		d.endpoint.Close()
+		close(d.events)
+	})
+	return nil
+}
+
+// DialContext connects to the IPv4 address through the tunnel. Supported networks are "tcp", "tcp4", "udp" and "udp4".
+func (d *Device) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	addr, err := fullAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	switch network {
+	case "tcp", "tcp4":
+		return gonet.DialContextTCP(ctx, d.stack, addr, ipv4.ProtocolNumber)
+	case "udp", "udp4":
+		return gonet.DialUDP(d.stack, nil, &addr, ipv4.ProtocolNumber)
+	default:
+		return nil, errors.Errorf("unsupported network %q", network)
+	}
+}
+
+// Resolver returns a resolver which queries the DNS server through the tunnel.
+func (d *Device) Resolver(dnsServer net.IP) *net.Resolver {
+	server := net.JoinHostPort(dnsServer.String(), "53")
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+func fullAddress(address string) (tcpip.FullAddress, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return tcpip.FullAddress{}, err
+	}
+	ip := net.ParseIP(host).To4()
+	if ip == nil {
+		return tcpip.FullAddress{}, errors.Errorf("not an IPv4 address: %s", host)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return tcpip.FullAddress{}, errors.Wrapf(err, "invalid port %s", portStr)
+	}
+	return tcpip.FullAddress{NIC: nicID, Addr: tcpip.AddrFromSlice(ip), Port: uint16(port)}, nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netstack
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+
+	wg "github.com/mysteriumnetwork/node/services/wireguard"
+	"github.com/mysteriumnetwork/node/services/wireguard/key"
+	"github.com/pkg/errors"
+	"golang.zx2c4.com/wireguard/device"
+)
+
+// consumerMTU is the default wireguard MTU.
+const consumerMTU = 1420
+
+// NewConsumerEndpoint returns connection endpoint which runs the consumer tunnel in a userspace network stack.
+// No network interfaces, routes or DNS settings of the host are changed, connections are made through Tunnel only.
+func NewConsumerEndpoint() *ConsumerEndpoint {
+	return &ConsumerEndpoint{}
+}
+
+// ConsumerEndpoint is a wireguard consumer endpoint backed by a userspace network stack.
+type ConsumerEndpoint struct {
+	mu         sync.Mutex
+	privateKey string
+	ipAddr     net.IPNet
+	tunnel     *Device
+	device     *device.Device
+}
+
+var _ wg.ConnectionEndpoint = &ConsumerEndpoint{}
+
+// StartConsumerMode starts userspace wireguard device on top of the userspace network stack.
+func (ce *ConsumerEndpoint) StartConsumerMode(config wg.ConsumerModeConfig) error {
+	tunnel, err := NewDevice([]net.IP{config.IPAddress.IP}, consumerMTU)
+	if err != nil {
+		return errors.Wrap(err, "could not create tunnel device")
+	}
+	dev := device.NewDevice(tunnel, device.NewLogger(device.LogLevelError, "[netstack-wg]"))
+
+	ce.mu.Lock()
+	ce.privateKey = config.PrivateKey
+	ce.ipAddr = config.IPAddress
+	ce.tunnel = tunnel
+	ce.device = dev
+	ce.mu.Unlock()
+
+	deviceConfig := wg.DeviceConfig{
+		PrivateKey: config.PrivateKey,
+		ListenPort: config.ListenPort,
+	}
+	if err := dev.IpcSetOperation(bufio.NewReader(strings.NewReader(deviceConfig.Encode()))); err != nil {
+		return errors.Wrap(err, "could not configure device")
+	}
+	dev.Up()
+	return nil
+}
+
+// StartProviderMode is not supported, provider traffic has to be forwarded by the host.
+func (ce *ConsumerEndpoint) StartProviderMode(_ wg.ProviderModeConfig) error {
+	return errors.New("provider mode is not supported by netstack consumer endpoint")
+}
+
+// AddPeer adds provider peer to the device.
+func (ce *ConsumerEndpoint) AddPeer(_ string, peer wg.Peer) error {
+	dev, err := ce.startedDevice()
+	if err != nil {
+		return err
+	}
+	if err := dev.IpcSetOperation(bufio.NewReader(strings.NewReader(peer.Encode()))); err != nil {
+		return errors.Wrap(err, "could not add device peer")
+	}
+	return nil
+}
+
+// PeerStats returns stats of the provider peer.
+func (ce *ConsumerEndpoint) PeerStats() (*wg.Stats, error) {
+	dev, err := ce.startedDevice()
+	if err != nil {
+		return nil, err
+	}
+	deviceState, err := wg.ParseUserspaceDevice(dev.IpcGetOperation)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse userspace wg device state")
+	}
+	return wg.ParseDevicePeerStats(deviceState)
+}
+
+// ConfigureRoutes does nothing, tunnel traffic never leaves the userspace network stack.
+func (ce *ConsumerEndpoint) ConfigureRoutes(_ net.IP) error {
+	return nil
+}
+
+// Config returns the consumer address of the tunnel.
+func (ce *ConsumerEndpoint) Config() (wg.ServiceConfig, error) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	publicKey, err := key.PrivateKeyToPublicKey(ce.privateKey)
+	if err != nil {
+		return wg.ServiceConfig{}, err
+	}
+
+	var config wg.ServiceConfig
+	config.Provider.PublicKey = publicKey
+	config.Consumer.IPAddress = ce.ipAddr
+	return config, nil
+}
+
+// InterfaceName returns the name of the tunnel device, it is not a network interface of the host.
+func (ce *ConsumerEndpoint) InterfaceName() string {
+	return deviceName
+}
+
+// Tunnel returns the device connections through the tunnel are made with.
+func (ce *ConsumerEndpoint) Tunnel() *Device {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	return ce.tunnel
+}
+
+// Stop closes wireguard device together with the network stack and connections made through it.
+func (ce *ConsumerEndpoint) Stop() error {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if ce.device != nil {
+		ce.device.Close()
+		ce.device = nil
+	}
+	return nil
+}
+
+func (ce *ConsumerEndpoint) startedDevice() (*device.Device, error) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if ce.device == nil {
+		return nil, errors.New("device is not started")
+	}
+	return ce.device, nil
+}
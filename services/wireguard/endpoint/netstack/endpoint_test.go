@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netstack
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	wg "github.com/mysteriumnetwork/node/services/wireguard"
+	"github.com/mysteriumnetwork/node/services/wireguard/key"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.zx2c4.com/wireguard/device"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+)
+
+// serverAddress is the address of the echo server in the provider network stack.
+const serverAddress = "10.0.0.1:80"
+
+func TestConsumerEndpoint_ConnectsThroughTunnel(t *testing.T) {
+	consumerKey, err := key.GeneratePrivateKey()
+	require.NoError(t, err)
+	consumerPublicKey, err := key.PrivateKeyToPublicKey(consumerKey)
+	require.NoError(t, err)
+	providerPublicKey, providerPort := newTestProvider(t, consumerPublicKey)
+
+	endpoint := NewConsumerEndpoint()
+	err = endpoint.StartConsumerMode(wg.ConsumerModeConfig{
+		PrivateKey: consumerKey,
+		IPAddress:  net.IPNet{IP: net.ParseIP("10.0.0.2"), Mask: net.CIDRMask(24, 32)},
+	})
+	require.NoError(t, err)
+	defer endpoint.Stop()
+	err = endpoint.AddPeer(endpoint.InterfaceName(), wg.Peer{
+		PublicKey:  providerPublicKey,
+		Endpoint:   &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: providerPort},
+		AllowedIPs: []string{"0.0.0.0/0"},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := endpoint.Tunnel().DialContext(ctx, "tcp", serverAddress)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(reply))
+
+	stats, err := endpoint.PeerStats()
+	require.NoError(t, err)
+	assert.NotZero(t, stats.BytesSent)
+	assert.NotZero(t, stats.BytesReceived)
+
+	require.NoError(t, endpoint.Stop())
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = endpoint.Tunnel().DialContext(ctx, "tcp", serverAddress)
+	assert.Error(t, err)
+}
+
+// newTestProvider starts wireguard provider running echo server in its network stack.
+func newTestProvider(t *testing.T, consumerPublicKey string) (publicKey string, port int) {
+	udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	port = udpConn.LocalAddr().(*net.UDPAddr).Port
+	require.NoError(t, udpConn.Close())
+
+	tunnel, err := NewDevice([]net.IP{net.ParseIP("10.0.0.1")}, consumerMTU)
+	require.NoError(t, err)
+	addr, err := fullAddress(serverAddress)
+	require.NoError(t, err)
+	listener, err := gonet.ListenTCP(tunnel.stack, addr, ipv4.ProtocolNumber)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+	dev := device.NewDevice(tunnel, device.NewLogger(device.LogLevelError, "[netstack-wg-provider]"))
+	t.Cleanup(dev.Close)
+
+	privateKey, err := key.GeneratePrivateKey()
+	require.NoError(t, err)
+	publicKey, err = key.PrivateKeyToPublicKey(privateKey)
+	require.NoError(t, err)
+	deviceConfig := wg.DeviceConfig{PrivateKey: privateKey, ListenPort: port}
+	peer := wg.Peer{PublicKey: consumerPublicKey, AllowedIPs: []string{"10.0.0.2/32"}}
+	for _, config := range []string{deviceConfig.Encode(), peer.Encode()} {
+		if err := dev.IpcSetOperation(bufio.NewReader(strings.NewReader(config))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dev.Up()
+	return publicKey, port
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netstack
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	// maxInFlightConnections limits TCP connections waiting for the outgoing connection to be established.
+	maxInFlightConnections = 512
+	forwardDialTimeout     = 15 * time.Second
+	// udpIdleTimeout closes forwarded UDP flows without traffic in either direction.
+	udpIdleTimeout = 2 * time.Minute
+)
+
+// Dialer connects to the destination outside of the tunnel.
+type Dialer func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Forward makes the device accept tunnelled TCP connections and UDP flows to any destination
+// and forwards them through the dialer, so that the host acts as NAT for the tunnel peer.
+// Destinations for which allowed returns false are refused. Other protocols are dropped.
+func (d *Device) Forward(dial Dialer, allowed func(ip net.IP) bool) error {
+	if err := d.stack.SetPromiscuousMode(nicID, true); err != nil {
+		return errors.Errorf("could not enable promiscuous mode: %v", err)
+	}
+	if err := d.stack.SetSpoofing(nicID, true); err != nil {
+		return errors.Errorf("could not enable spoofing: %v", err)
+	}
+
+	f := &forwarder{device: d, dial: dial, allowed: allowed}
+	tcpForwarder := tcp.NewForwarder(d.stack, 0, maxInFlightConnections, f.forwardTCP)
+	d.stack.SetTransportProtocolHandler(tcp.ProtocolNumber, tcpForwarder.HandlePacket)
+	udpForwarder := udp.NewForwarder(d.stack, f.forwardUDP)
+	d.stack.SetTransportProtocolHandler(udp.ProtocolNumber, udpForwarder.HandlePacket)
+	return nil
+}
+
+type forwarder struct {
+	device  *Device
+	dial    Dialer
+	allowed func(ip net.IP) bool
+}
+
+func (f *forwarder) destination(id stack.TransportEndpointID) (string, bool) {
+	ip := net.IP(id.LocalAddress.AsSlice())
+	if !f.allowed(ip) {
+		return "", false
+	}
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(id.LocalPort))), true
+}
+
+func (f *forwarder) forwardTCP(r *tcp.ForwarderRequest) {
+	destination, ok := f.destination(r.ID())
+	if !ok {
+		r.Complete(true)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(f.device.ctx, forwardDialTimeout)
+	defer cancel()
+	outgoing, err := f.dial(ctx, "tcp", destination)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Could not forward TCP connection to %s", destination)
+		r.Complete(true)
+		return
+	}
+
+	var wq waiter.Queue
+	ep, tcpErr := r.CreateEndpoint(&wq)
+	r.Complete(false)
+	if tcpErr != nil {
+		log.Debug().Msgf("Could not accept tunnelled TCP connection to %s: %v", destination, tcpErr)
+		outgoing.Close()
+		return
+	}
+	ep.SocketOptions().SetKeepAlive(true)
+
+	go pipe(gonet.NewTCPConn(&wq, ep), outgoing)
+}
+
+func (f *forwarder) forwardUDP(r *udp.ForwarderRequest) {
+	destination, ok := f.destination(r.ID())
+	if !ok {
+		return
+	}
+
+	var wq waiter.Queue
+	ep, udpErr := r.CreateEndpoint(&wq)
+	if udpErr != nil {
+		log.Debug().Msgf("Could not accept tunnelled UDP flow to %s: %v", destination, udpErr)
+		return
+	}
+	tunnelled := gonet.NewUDPConn(&wq, ep)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(f.device.ctx, forwardDialTimeout)
+		defer cancel()
+		outgoing, err := f.dial(ctx, "udp", destination)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Could not forward UDP flow to %s", destination)
+			tunnelled.Close()
+			return
+		}
+		pipe(&idleConn{Conn: tunnelled}, &idleConn{Conn: outgoing})
+	}()
+}
+
+// pipe copies data in both directions until either side is closed.
+func pipe(a, b net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(b, a)
+		b.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(a, b)
+		a.Close()
+	}()
+	wg.Wait()
+}
+
+// idleConn fails reads after the UDP flow has been idle for too long, since datagrams have no end of stream.
+type idleConn struct {
+	net.Conn
+}
+
+func (c *idleConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(udpIdleTimeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
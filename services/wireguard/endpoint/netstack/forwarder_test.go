@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package netstack
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connect passes packets between two devices as if they were the ends of a tunnel.
+func connect(a, b *Device) {
+	transfer := func(from, to *Device) {
+		buf := make([]byte, 2000)
+		for {
+			n, err := from.Read(buf, 0)
+			if err != nil {
+				return
+			}
+			to.Write(buf[:n], 0)
+		}
+	}
+	go transfer(a, b)
+	go transfer(b, a)
+}
+
+// remoteAddress is the destination consumer connects to, provider forwards it to the test server.
+const remoteAddress = "203.0.113.1:80"
+
+func newDevicePair(t *testing.T, allowed func(ip net.IP) bool, server string) (consumer *Device, dialed chan string) {
+	consumer, err := NewDevice([]net.IP{net.ParseIP("10.0.0.2")}, 1280)
+	require.NoError(t, err)
+	provider, err := NewDevice([]net.IP{net.ParseIP("10.0.0.1")}, 1280)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		consumer.Close()
+		provider.Close()
+	})
+
+	dialed = make(chan string, 10)
+	var dialer net.Dialer
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed <- network + " " + address
+		return dialer.DialContext(ctx, network, server)
+	}
+	require.NoError(t, provider.Forward(dial, allowed))
+	connect(consumer, provider)
+	return consumer, dialed
+}
+
+func allowAll(net.IP) bool { return true }
+
+func TestForward_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+	consumer, dialed := newDevicePair(t, allowAll, listener.Addr().String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := consumer.DialContext(ctx, "tcp", remoteAddress)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(reply))
+	assert.Equal(t, "tcp "+remoteAddress, <-dialed)
+}
+
+func TestForward_UDP(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+	go func() {
+		buf := make([]byte, 100)
+		for {
+			n, addr, err := server.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			server.WriteTo(buf[:n], addr)
+		}
+	}()
+	consumer, dialed := newDevicePair(t, allowAll, server.LocalAddr().String())
+
+	conn, err := consumer.DialContext(context.Background(), "udp", remoteAddress)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	reply := make([]byte, 100)
+	n, err := conn.Read(reply)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(reply[:n]))
+	assert.Equal(t, "udp "+remoteAddress, <-dialed)
+}
+
+func TestForward_RefusesNotAllowedDestination(t *testing.T) {
+	consumer, dialed := newDevicePair(t, func(net.IP) bool { return false }, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := consumer.DialContext(ctx, "tcp", remoteAddress)
+	assert.Error(t, err)
+	assert.Empty(t, dialed)
+}
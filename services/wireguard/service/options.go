@@ -24,6 +24,8 @@ import (
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/service"
+	"github.com/mysteriumnetwork/node/dns"
+	"github.com/mysteriumnetwork/node/services"
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
 	"github.com/rs/zerolog/log"
 )
@@ -33,6 +35,7 @@ type Options struct {
 	ConnectDelay int
 	Ports        *port.Range
 	Subnet       net.IPNet
+	DNSBlocklist dns.BlocklistOptions
 }
 
 // DefaultOptions is a wireguard service configuration that will be used if no options provided.
@@ -67,6 +70,7 @@ func GetOptions() Options {
 		ConnectDelay: config.GetInt(config.FlagWireguardConnectDelay),
 		Ports:        portRange,
 		Subnet:       *ipnet,
+		DNSBlocklist: services.DNSBlocklistConfiguredOptions(),
 	}
 }
 
@@ -85,22 +89,25 @@ func ParseJSONOptions(request *json.RawMessage) (service.Options, error) {
 // MarshalJSON implements json.Marshaler interface to provide human readable configuration.
 func (o Options) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
-		ConnectDelay int    `json:"connectDelay"`
-		Ports        string `json:"ports"`
-		Subnet       string `json:"subnet"`
+		ConnectDelay int                  `json:"connectDelay"`
+		Ports        string               `json:"ports"`
+		Subnet       string               `json:"subnet"`
+		DNSBlocklist dns.BlocklistOptions `json:"dnsBlocklist"`
 	}{
 		ConnectDelay: o.ConnectDelay,
 		Ports:        o.Ports.String(),
 		Subnet:       o.Subnet.String(),
+		DNSBlocklist: o.DNSBlocklist,
 	})
 }
 
 // UnmarshalJSON implements json.Unmarshaler interface to receive human readable configuration.
 func (o *Options) UnmarshalJSON(data []byte) error {
 	var options struct {
-		ConnectDelay int    `json:"connectDelay"`
-		Ports        string `json:"ports"`
-		Subnet       string `json:"subnet"`
+		ConnectDelay int                   `json:"connectDelay"`
+		Ports        string                `json:"ports"`
+		Subnet       string                `json:"subnet"`
+		DNSBlocklist *dns.BlocklistOptions `json:"dnsBlocklist"`
 	}
 
 	if err := json.Unmarshal(data, &options); err != nil {
@@ -124,6 +131,9 @@ func (o *Options) UnmarshalJSON(data []byte) error {
 		}
 		o.Subnet = *ipnet
 	}
+	if options.DNSBlocklist != nil {
+		o.DNSBlocklist = *options.DNSBlocklist
+	}
 
 	return nil
 }
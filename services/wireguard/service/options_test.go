@@ -22,9 +22,11 @@ import (
 	"flag"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/port"
+	"github.com/mysteriumnetwork/node/dns"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v2"
 )
@@ -62,6 +64,20 @@ func Test_ParseJSONOptions_ValidRequest(t *testing.T) {
 	}, options)
 }
 
+func Test_ParseJSONOptions_DNSBlocklist(t *testing.T) {
+	configureDefaults()
+	request := json.RawMessage(`{"dnsBlocklist": {"sources": ["https://example.com/hosts"], "refresh": "12h", "blockIPs": true}}`)
+	options, err := ParseJSONOptions(&request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, dns.BlocklistOptions{
+		Sources:  []string{"https://example.com/hosts"},
+		Refresh:  12 * time.Hour,
+		BlockIPs: true,
+	}, options.(Options).DNSBlocklist)
+	assert.Equal(t, DefaultOptions.Subnet, options.(Options).Subnet)
+}
+
 func configureDefaults() {
 	ctx := emptyContext()
 	config.ParseFlagsServiceWireguard(ctx)
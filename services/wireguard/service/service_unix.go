@@ -26,6 +26,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/core/ip"
 	"github.com/mysteriumnetwork/node/core/port"
 	"github.com/mysteriumnetwork/node/core/service"
@@ -42,6 +43,7 @@ import (
 	"github.com/mysteriumnetwork/node/services/wireguard/resources"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/utils/netutil"
+	"github.com/mysteriumnetwork/node/utils/stringutil"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
@@ -88,6 +90,7 @@ func NewManager(
 		},
 		country:        country,
 		connectDelayMS: options.ConnectDelay,
+		dnsBlocklist:   options.DNSBlocklist,
 		sessionCleanup: map[string]func(){},
 	}
 }
@@ -107,9 +110,11 @@ type Manager struct {
 	portMapper      mapping.PortMapper
 	trafficFirewall firewall.IncomingTrafficFirewall
 
-	dnsOK    bool
-	dnsPort  int
-	dnsProxy *dns.Proxy
+	dnsOK        bool
+	dnsPort      int
+	dnsProxy     *dns.Proxy
+	dnsBlocklist dns.BlocklistOptions
+	dnsBlocker   *dns.BlocklistHandler
 
 	connEndpointFactory func() (wg.ConnectionEndpoint, error)
 
@@ -124,6 +129,11 @@ type Manager struct {
 	outboundIP     string
 }
 
+// SetConnectionEndpointFactory overrides how connection endpoints are created for new sessions.
+func (m *Manager) SetConnectionEndpointFactory(factory func() (wg.ConnectionEndpoint, error)) {
+	m.connEndpointFactory = factory
+}
+
 // ProvideConfig provides the config for consumer and handles new WireGuard connection.
 func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage, remoteConn *net.UDPConn) (*session.ConfigParams, error) {
 	log.Info().Msg("Accepting new WireGuard connection")
@@ -166,6 +176,9 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get public IP")
 	}
+	if m.dnsOK {
+		providerConfig.DNSPort = m.dnsPort
+	}
 
 	conn, err := m.startNewConnection(providerConfig)
 	if err != nil {
@@ -194,7 +207,7 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 	}
 
 	var dnsIP net.IP
-	var releaseTrafficFirewall firewall.IncomingRuleRemove
+	var releaseTrafficFirewall, releaseBlocklist firewall.IncomingRuleRemove
 	if m.dnsOK {
 		if m.serviceInstance.Policies().HasDNSRules() {
 			releaseTrafficFirewall, err = m.trafficFirewall.BlockIncomingTraffic(providerConfig.Network)
@@ -205,6 +218,16 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 
 		dnsIP = netutil.FirstIP(config.Consumer.IPAddress)
 		config.Consumer.DNSIPs = dnsIP.String()
+
+		if m.dnsBlocker != nil {
+			m.dnsBlocker.TrackSession(sessionID, config.Consumer.IPAddress)
+			if m.dnsBlocklist.BlockIPs {
+				releaseBlocklist, err = m.trafficFirewall.ApplyBlocklist(providerConfig.Network)
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to enable blocklist")
+				}
+			}
+		}
 	}
 
 	natRules, err := m.natService.Setup(nat.Options{
@@ -249,6 +272,16 @@ func (m *Manager) ProvideConfig(sessionID string, sessionConfig json.RawMessage,
 			}
 		}
 
+		if releaseBlocklist != nil {
+			if err := releaseBlocklist(); err != nil {
+				log.Warn().Err(err).Msg("failed to disable blocklist")
+			}
+		}
+
+		if m.dnsBlocker != nil {
+			log.Info().Msgf("Session %s: %d DNS queries blocked", sessionID, m.dnsBlocker.ReleaseSession(sessionID))
+		}
+
 		log.Trace().Msg("Deleting nat rules")
 		if err := m.natService.Del(natRules); err != nil {
 			log.Error().Err(err).Msg("Failed to delete NAT rules")
@@ -367,11 +400,16 @@ func (m *Manager) Serve(instance *service.Instance) error {
 	// Start DNS proxy.
 	m.dnsPort = 11253
 	m.dnsOK = false
-	dnsHandler, err := dns.ResolveViaSystem()
+	dnsHandler, err := dns.ResolveVia(stringutil.Split(config.GetString(config.FlagDNSUpstreams), ','))
 	if err == nil {
 		if m.serviceInstance.Policies().HasDNSRules() {
 			dnsHandler = dns.WhitelistAnswers(dnsHandler, m.trafficFirewall, instance.Policies())
 		}
+		if len(m.dnsBlocklist.Sources) > 0 {
+			m.dnsBlocker = dns.BlockAnswers(dnsHandler, m.dnsBlocklist, m.trafficFirewall)
+			m.dnsBlocker.Start()
+			dnsHandler = m.dnsBlocker
+		}
 
 		m.dnsProxy = dns.NewProxy("", m.dnsPort, dnsHandler)
 		if err := m.dnsProxy.Run(); err != nil {
@@ -412,6 +450,9 @@ func (m *Manager) Stop() error {
 			log.Error().Err(err).Msg("Failed to stop DNS server")
 		}
 	}
+	if m.dnsBlocker != nil {
+		m.dnsBlocker.Stop()
+	}
 
 	close(m.done)
 	log.Info().Msg("Wireguard: stopped")
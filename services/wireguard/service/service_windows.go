@@ -29,6 +29,7 @@ import (
 	"github.com/mysteriumnetwork/node/nat"
 	natevent "github.com/mysteriumnetwork/node/nat/event"
 	"github.com/mysteriumnetwork/node/nat/mapping"
+	wg "github.com/mysteriumnetwork/node/services/wireguard"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/pkg/errors"
 )
@@ -62,6 +63,10 @@ func NewManager(
 // Manager represents an instance of Wireguard service
 type Manager struct{}
 
+// SetConnectionEndpointFactory overrides how connection endpoints are created for new sessions.
+func (manager *Manager) SetConnectionEndpointFactory(_ func() (wg.ConnectionEndpoint, error)) {
+}
+
 // ProvideConfig provides the config for consumer
 func (manager *Manager) ProvideConfig(_ string, _ json.RawMessage, _ *net.UDPConn) (*session.ConfigParams, error) {
 	return nil, errors.New("not implemented")
@@ -75,6 +75,8 @@ type ProviderModeConfig struct {
 	Network    net.IPNet
 	ListenPort int
 	PublicIP   string
+	// DNSPort is the local port of provider DNS proxy, zero when it is not available.
+	DNSPort int
 }
 
 // ConsumerConfig is used for sending the public key and IP from consumer to provider
@@ -1,5 +1,5 @@
 /*
- * Copyright (C) 2019 The "MysteriumNetwork/node" Authors.
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
  *
  * This program is free software: you can redistribute it and/or modify
  * it under the terms of the GNU General Public License as published by
@@ -19,20 +19,36 @@ package connectivity
 
 import (
 	"sort"
-	"sync"
 	"time"
 
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/rs/zerolog/log"
 )
 
-// maxEntriesKeepPeriod describes how long entries are kept in memory storage.
-// Older than this duration entries are removed on insert.
-const maxEntriesKeepDuration = time.Hour * 24 * 30
+const statusStorageBucketName = "session-connectivity-status"
+
+// AppTopicConnectivityStatus is published when the consumer reports a connectivity status to the provider.
+const AppTopicConnectivityStatus = "connectivity-status"
+
+// RetentionPolicy returns the policy dropping status entries older than maxAge.
+func RetentionPolicy(maxAge time.Duration) storage.RetentionPolicy {
+	return storage.RetentionPolicy{Bucket: statusStorageBucketName, Kind: &statusRecord{}, Field: "CreatedAtUTC", MaxAge: maxAge}
+}
+
+// StorageKinds returns the records kept by the status storage.
+func StorageKinds() []storage.Kind {
+	return []storage.Kind{{Bucket: statusStorageBucketName, Record: &statusRecord{}}}
+}
 
 // StatusStorage is responsible for status storage operations.
 type StatusStorage interface {
 	GetAllStatusEntries() []StatusEntry
 	AddStatusEntry(msg StatusEntry)
+	FindStatusEntries(filter StatusFilter) ([]StatusEntry, error)
+	GetStatusStats(filter StatusFilter) ([]StatusStats, error)
+	GetUnreliableProviders(filter StatusFilter, minFailures int) ([]UnreliableProvider, error)
 }
 
 // StatusEntry describes status entry.
@@ -42,46 +58,193 @@ type StatusEntry struct {
 	StatusCode   StatusCode
 	Message      string
 	CreatedAtUTC time.Time
+	// Outgoing is true for statuses this node sent as a consumer about the provider,
+	// false for statuses received from consumers.
+	Outgoing bool
+}
+
+// StatusFilter narrows status entries, zero fields match everything.
+type StatusFilter struct {
+	PeerID     identity.Identity
+	StatusCode StatusCode
+	From       time.Time
+	To         time.Time
+	Limit      int
+}
+
+// StatusStats is the number of entries of a peer with the same status code.
+type StatusStats struct {
+	PeerID     identity.Identity
+	StatusCode StatusCode
+	Count      int
+}
+
+// UnreliableProvider is a provider this node repeatedly failed to get a working connection to.
+type UnreliableProvider struct {
+	PeerID         identity.Identity
+	Failures       int
+	Successes      int
+	LastFailureUTC time.Time
+}
+
+// IsFailure tells whether the status means the consumer didn't get a working connection.
+func (c StatusCode) IsFailure() bool {
+	return c == StatusSessionEstablishmentFailed || c == StatusSessionIPNotChanged || c == StatusConnectionFailed
+}
+
+type persistentStorage interface {
+	Store(bucket string, data interface{}) error
+	Find(bucket string, query storage.Query, to interface{}) error
+}
+
+type statusRecord struct {
+	ID           int64 `storm:"id,increment"`
+	PeerAddress  string
+	SessionID    string
+	StatusCode   StatusCode
+	Message      string
+	CreatedAtUTC time.Time
+	Outgoing     bool
 }
 
 // NewStatusStorage returns new StatusStorage instance.
-func NewStatusStorage() StatusStorage {
-	return &statusStorage{}
+func NewStatusStorage(db persistentStorage) *statusStorage {
+	return &statusStorage{db: db}
 }
 
 type statusStorage struct {
-	entries    []StatusEntry
-	entriesMux sync.RWMutex
+	db persistentStorage
+}
+
+// Subscribe records the statuses this node sends as a consumer.
+func (s *statusStorage) Subscribe(bus eventbus.Subscriber) error {
+	return bus.Subscribe(AppTopicConnectivityStatus, s.AddStatusEntry)
 }
 
+// GetAllStatusEntries returns all kept entries, newest first.
 func (s *statusStorage) GetAllStatusEntries() []StatusEntry {
-	s.entriesMux.RLock()
-	defer s.entriesMux.RUnlock()
+	entries, err := s.FindStatusEntries(StatusFilter{})
+	if err != nil {
+		log.Error().Err(err).Msg("Could not load connectivity status entries")
+	}
+	return entries
+}
 
-	res := make([]StatusEntry, len(s.entries))
-	copy(res, s.entries)
+// AddStatusEntry persists the entry.
+func (s *statusStorage) AddStatusEntry(msg StatusEntry) {
+	record := &statusRecord{
+		PeerAddress:  msg.PeerID.Address,
+		SessionID:    msg.SessionID,
+		StatusCode:   msg.StatusCode,
+		Message:      msg.Message,
+		CreatedAtUTC: msg.CreatedAtUTC,
+		Outgoing:     msg.Outgoing,
+	}
+	if err := s.db.Store(statusStorageBucketName, record); err != nil {
+		log.Error().Err(err).Msg("Could not store connectivity status entry")
+	}
+}
 
-	// Sort by CreatedAtUTC descending to show newest entries first.
-	sort.Slice(res, func(i, j int) bool {
-		return res[i].CreatedAtUTC.After(res[j].CreatedAtUTC)
+// FindStatusEntries returns entries matching the filter, newest first.
+func (s *statusStorage) FindStatusEntries(filter StatusFilter) ([]StatusEntry, error) {
+	query := storage.Query{OrderBy: "CreatedAtUTC", Reverse: true, Limit: filter.Limit}.Between("CreatedAtUTC", filter.From, filter.To)
+	if filter.PeerID.Address != "" {
+		query = query.Where("PeerAddress", storage.OpEq, filter.PeerID.Address)
+	}
+	if filter.StatusCode != 0 {
+		query = query.Where("StatusCode", storage.OpEq, filter.StatusCode)
+	}
+
+	var records []statusRecord
+	if err := s.db.Find(statusStorageBucketName, query, &records); err != nil {
+		return nil, err
+	}
+
+	entries := make([]StatusEntry, len(records))
+	for i, record := range records {
+		entries[i] = StatusEntry{
+			PeerID:       identity.FromAddress(record.PeerAddress),
+			SessionID:    record.SessionID,
+			StatusCode:   record.StatusCode,
+			Message:      record.Message,
+			CreatedAtUTC: record.CreatedAtUTC,
+			Outgoing:     record.Outgoing,
+		}
+	}
+	return entries, nil
+}
+
+// GetStatusStats counts entries matching the filter per peer and status code.
+func (s *statusStorage) GetStatusStats(filter StatusFilter) ([]StatusStats, error) {
+	filter.Limit = 0
+	entries, err := s.FindStatusEntries(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	type statsKey struct {
+		peer string
+		code StatusCode
+	}
+	counts := make(map[statsKey]int)
+	for _, entry := range entries {
+		counts[statsKey{peer: entry.PeerID.Address, code: entry.StatusCode}]++
+	}
+
+	stats := make([]StatusStats, 0, len(counts))
+	for key, count := range counts {
+		stats = append(stats, StatusStats{PeerID: identity.FromAddress(key.peer), StatusCode: key.code, Count: count})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].PeerID.Address != stats[j].PeerID.Address {
+			return stats[i].PeerID.Address < stats[j].PeerID.Address
+		}
+		return stats[i].StatusCode < stats[j].StatusCode
 	})
-	return res
+	return stats, nil
 }
 
-func (s *statusStorage) AddStatusEntry(msg StatusEntry) {
-	s.entriesMux.Lock()
-	defer s.entriesMux.Unlock()
-
-	// Remove old entries which are older that maxEntriesKeepDuration.
-	var res []StatusEntry
-	minValidEntryTime := time.Now().UTC().Add(-maxEntriesKeepDuration)
-	for _, entry := range s.entries {
-		if entry.CreatedAtUTC.After(minValidEntryTime) {
-			res = append(res, entry)
+// GetUnreliableProviders returns providers with at least minFailures failed connections
+// reported by this node within the filter, the most failing first.
+func (s *statusStorage) GetUnreliableProviders(filter StatusFilter, minFailures int) ([]UnreliableProvider, error) {
+	filter.StatusCode = 0
+	filter.Limit = 0
+	entries, err := s.FindStatusEntries(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make(map[string]*UnreliableProvider)
+	for _, entry := range entries {
+		if !entry.Outgoing {
+			continue
+		}
+		provider, ok := providers[entry.PeerID.Address]
+		if !ok {
+			provider = &UnreliableProvider{PeerID: entry.PeerID}
+			providers[entry.PeerID.Address] = provider
+		}
+		if !entry.StatusCode.IsFailure() {
+			provider.Successes++
+			continue
+		}
+		provider.Failures++
+		if entry.CreatedAtUTC.After(provider.LastFailureUTC) {
+			provider.LastFailureUTC = entry.CreatedAtUTC
 		}
 	}
-	s.entries = res
 
-	// Add new entry.
-	s.entries = append(s.entries, msg)
+	var res []UnreliableProvider
+	for _, provider := range providers {
+		if provider.Failures > 0 && provider.Failures >= minFailures {
+			res = append(res, *provider)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Failures != res[j].Failures {
+			return res[i].Failures > res[j].Failures
+		}
+		return res[i].PeerID.Address < res[j].PeerID.Address
+	})
+	return res, nil
 }
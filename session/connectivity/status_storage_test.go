@@ -14,32 +14,59 @@
  * You should have received a copy of the GNU General Public License
  * along with this program.  If not, see <http://www.gnu.org/licenses/>.
  */
-
-package connectivity
+package connectivity_test
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/connectivity"
 	"github.com/stretchr/testify/assert"
 )
 
+var (
+	peer1 = identity.FromAddress("0x1")
+	peer2 = identity.FromAddress("0x2")
+	now   = time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+)
+
+func newTestDB(t *testing.T) (*boltdb.Bolt, func()) {
+	dir, err := ioutil.TempDir("", "statusStorageTest")
+	assert.NoError(t, err)
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+
+	return bolt, func() {
+		bolt.Close()
+		os.RemoveAll(dir)
+	}
+}
+
 func TestStatusStorage_AddStatusEntry(t *testing.T) {
-	storage := NewStatusStorage()
-	e1 := StatusEntry{
-		PeerID:       identity.Identity{},
+	bolt, cleanup := newTestDB(t)
+	defer cleanup()
+	storage := connectivity.NewStatusStorage(bolt)
+
+	e1 := connectivity.StatusEntry{
+		PeerID:       peer1,
 		SessionID:    "1",
-		StatusCode:   StatusConnectionOk,
+		StatusCode:   connectivity.StatusConnectionOk,
 		Message:      "Ok",
-		CreatedAtUTC: time.Now().UTC(),
+		CreatedAtUTC: now,
 	}
-	e2 := StatusEntry{
-		PeerID:       identity.Identity{},
+	e2 := connectivity.StatusEntry{
+		PeerID:       peer2,
 		SessionID:    "",
-		StatusCode:   StatusConnectionFailed,
+		StatusCode:   connectivity.StatusConnectionFailed,
 		Message:      "Failed",
-		CreatedAtUTC: time.Now().UTC().Add(-1 * time.Second),
+		CreatedAtUTC: now.Add(-1 * time.Second),
+		Outgoing:     true,
 	}
 
 	storage.AddStatusEntry(e1)
@@ -51,63 +78,121 @@ func TestStatusStorage_AddStatusEntry(t *testing.T) {
 	assert.Equal(t, e2, entries[1])
 }
 
-func TestStatusStorage_AddStatusEntry_RemovesOldEntries(t *testing.T) {
-	storage := NewStatusStorage()
-	e1 := StatusEntry{
-		SessionID:    "s1",
-		CreatedAtUTC: time.Now().UTC().Add(-maxEntriesKeepDuration * 2),
-	}
-	storage.AddStatusEntry(e1)
+func TestStatusStorage_GetAllStatusEntries_Returns_Sorted_Data(t *testing.T) {
+	bolt, cleanup := newTestDB(t)
+	defer cleanup()
+	storage := connectivity.NewStatusStorage(bolt)
 
-	e2 := StatusEntry{
-		SessionID:    "s2",
-		CreatedAtUTC: time.Now().UTC(),
-	}
+	e1 := connectivity.StatusEntry{SessionID: "1", CreatedAtUTC: now}
+	e2 := connectivity.StatusEntry{SessionID: "2", CreatedAtUTC: now.Add(-10 * time.Minute)}
+	e3 := connectivity.StatusEntry{SessionID: "3", CreatedAtUTC: now.Add(15 * time.Minute)}
+	storage.AddStatusEntry(e1)
 	storage.AddStatusEntry(e2)
+	storage.AddStatusEntry(e3)
 
 	entries := storage.GetAllStatusEntries()
-	assert.Len(t, entries, 1)
-	assert.Equal(t, e2, entries[0])
+
+	assert.Equal(t, []connectivity.StatusEntry{e3, e1, e2}, entries)
 }
 
-func TestStatusStorage_GetAllStatusEntries_Returns_Immutable_Data(t *testing.T) {
-	storage := NewStatusStorage()
-	e1 := StatusEntry{
-		PeerID:       identity.Identity{},
-		SessionID:    "1",
-		StatusCode:   StatusConnectionOk,
-		Message:      "Ok",
-		CreatedAtUTC: time.Time{},
+func TestStatusStorage_FindStatusEntries(t *testing.T) {
+	bolt, cleanup := newTestDB(t)
+	defer cleanup()
+	storage := connectivity.NewStatusStorage(bolt)
+
+	e1 := connectivity.StatusEntry{PeerID: peer1, SessionID: "1", StatusCode: connectivity.StatusConnectionOk, CreatedAtUTC: now.Add(-2 * time.Hour)}
+	e2 := connectivity.StatusEntry{PeerID: peer1, SessionID: "2", StatusCode: connectivity.StatusSessionIPNotChanged, CreatedAtUTC: now.Add(-time.Hour)}
+	e3 := connectivity.StatusEntry{PeerID: peer2, SessionID: "3", StatusCode: connectivity.StatusSessionIPNotChanged, CreatedAtUTC: now}
+	for _, e := range []connectivity.StatusEntry{e1, e2, e3} {
+		storage.AddStatusEntry(e)
 	}
-	storage.AddStatusEntry(e1)
 
-	entries := storage.GetAllStatusEntries()
+	entries, err := storage.FindStatusEntries(connectivity.StatusFilter{PeerID: peer1})
+	assert.NoError(t, err)
+	assert.Equal(t, []connectivity.StatusEntry{e2, e1}, entries)
+
+	entries, err = storage.FindStatusEntries(connectivity.StatusFilter{StatusCode: connectivity.StatusSessionIPNotChanged})
+	assert.NoError(t, err)
+	assert.Equal(t, []connectivity.StatusEntry{e3, e2}, entries)
+
+	entries, err = storage.FindStatusEntries(connectivity.StatusFilter{From: now.Add(-time.Hour), To: now})
+	assert.NoError(t, err)
+	assert.Equal(t, []connectivity.StatusEntry{e2}, entries)
 
-	entries[0].SessionID = "2"
-	assert.NotEqual(t, entries[0].SessionID, storage.(*statusStorage).entries[0].SessionID)
+	entries, err = storage.FindStatusEntries(connectivity.StatusFilter{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []connectivity.StatusEntry{e3}, entries)
 }
 
-func TestStatusStorage_GetAllStatusEntries_Returns_Sorted_Data(t *testing.T) {
-	storage := NewStatusStorage()
-	e1 := StatusEntry{
-		SessionID:    "1",
-		CreatedAtUTC: time.Now(),
-	}
-	e2 := StatusEntry{
-		SessionID:    "2",
-		CreatedAtUTC: time.Now().Add(-10 * time.Minute),
+func TestStatusStorage_GetStatusStats(t *testing.T) {
+	bolt, cleanup := newTestDB(t)
+	defer cleanup()
+	storage := connectivity.NewStatusStorage(bolt)
+
+	storage.AddStatusEntry(connectivity.StatusEntry{PeerID: peer2, StatusCode: connectivity.StatusConnectionOk, CreatedAtUTC: now})
+	storage.AddStatusEntry(connectivity.StatusEntry{PeerID: peer1, StatusCode: connectivity.StatusConnectionFailed, CreatedAtUTC: now})
+	storage.AddStatusEntry(connectivity.StatusEntry{PeerID: peer1, StatusCode: connectivity.StatusConnectionOk, CreatedAtUTC: now})
+	storage.AddStatusEntry(connectivity.StatusEntry{PeerID: peer1, StatusCode: connectivity.StatusConnectionFailed, CreatedAtUTC: now})
+
+	stats, err := storage.GetStatusStats(connectivity.StatusFilter{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []connectivity.StatusStats{
+		{PeerID: peer1, StatusCode: connectivity.StatusConnectionOk, Count: 1},
+		{PeerID: peer1, StatusCode: connectivity.StatusConnectionFailed, Count: 2},
+		{PeerID: peer2, StatusCode: connectivity.StatusConnectionOk, Count: 1},
+	}, stats)
+}
+
+func TestStatusStorage_GetUnreliableProviders(t *testing.T) {
+	bolt, cleanup := newTestDB(t)
+	defer cleanup()
+	storage := connectivity.NewStatusStorage(bolt)
+
+	for i := 0; i < 3; i++ {
+		storage.AddStatusEntry(connectivity.StatusEntry{PeerID: peer1, StatusCode: connectivity.StatusSessionIPNotChanged, CreatedAtUTC: now.Add(time.Duration(i) * time.Minute), Outgoing: true})
 	}
-	e3 := StatusEntry{
-		SessionID:    "3",
-		CreatedAtUTC: time.Now().Add(15 * time.Minute),
+	storage.AddStatusEntry(connectivity.StatusEntry{PeerID: peer1, StatusCode: connectivity.StatusConnectionOk, CreatedAtUTC: now, Outgoing: true})
+	storage.AddStatusEntry(connectivity.StatusEntry{PeerID: peer2, StatusCode: connectivity.StatusConnectionFailed, CreatedAtUTC: now, Outgoing: true})
+	// Statuses received from consumers are about this node, not the peer.
+	for i := 0; i < 3; i++ {
+		storage.AddStatusEntry(connectivity.StatusEntry{PeerID: peer2, StatusCode: connectivity.StatusConnectionFailed, CreatedAtUTC: now})
 	}
-	storage.AddStatusEntry(e1)
-	storage.AddStatusEntry(e2)
-	storage.AddStatusEntry(e3)
 
-	entries := storage.GetAllStatusEntries()
+	providers, err := storage.GetUnreliableProviders(connectivity.StatusFilter{}, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []connectivity.UnreliableProvider{
+		{PeerID: peer1, Failures: 3, Successes: 1, LastFailureUTC: now.Add(2 * time.Minute)},
+	}, providers)
+}
 
-	assert.Equal(t, e3, entries[0])
-	assert.Equal(t, e1, entries[1])
-	assert.Equal(t, e2, entries[2])
+func TestStatusStorage_Subscribe(t *testing.T) {
+	bolt, cleanup := newTestDB(t)
+	defer cleanup()
+	storage := connectivity.NewStatusStorage(bolt)
+	bus := eventbus.New()
+	assert.NoError(t, storage.Subscribe(bus))
+
+	entry := connectivity.StatusEntry{PeerID: peer1, StatusCode: connectivity.StatusConnectionOk, CreatedAtUTC: now, Outgoing: true}
+	bus.Publish(connectivity.AppTopicConnectivityStatus, entry)
+
+	assert.Equal(t, []connectivity.StatusEntry{entry}, storage.GetAllStatusEntries())
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	bolt, cleanup := newTestDB(t)
+	defer cleanup()
+	statuses := connectivity.NewStatusStorage(bolt)
+
+	statuses.AddStatusEntry(connectivity.StatusEntry{SessionID: "old", CreatedAtUTC: now.Add(-48 * time.Hour)})
+	statuses.AddStatusEntry(connectivity.StatusEntry{SessionID: "new", CreatedAtUTC: now})
+
+	dropped, err := storage.ApplyRetention(bolt, []storage.RetentionPolicy{connectivity.RetentionPolicy(24 * time.Hour)}, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+	entries := statuses.GetAllStatusEntries()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "new", entries[0].SessionID)
 }
@@ -89,3 +89,15 @@ func (m *mockStatusStorage) GetAllStatusEntries() []StatusEntry {
 func (m *mockStatusStorage) AddStatusEntry(msg StatusEntry) {
 	m.addedEntry = msg
 }
+
+func (m *mockStatusStorage) FindStatusEntries(filter StatusFilter) ([]StatusEntry, error) {
+	return []StatusEntry{}, nil
+}
+
+func (m *mockStatusStorage) GetStatusStats(filter StatusFilter) ([]StatusStats, error) {
+	return []StatusStats{}, nil
+}
+
+func (m *mockStatusStorage) GetUnreliableProviders(filter StatusFilter, minFailures int) ([]UnreliableProvider, error) {
+	return []UnreliableProvider{}, nil
+}
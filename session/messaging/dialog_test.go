@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package messaging
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/communication"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/mysteriumnetwork/payments/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialogMessaging_Payments(t *testing.T) {
+	consumerDialog, providerDialog := newDialogPair()
+	consumer := NewDialogConsumer(consumerDialog, connectivity.NewStatusSender())
+	provider := NewDialogProvider(providerDialog)
+
+	invoices := make(chan crypto.Invoice, 1)
+	assert.NoError(t, consumer.ReceiveInvoices(invoices))
+	exchangeMessages := make(chan crypto.ExchangeMessage, 1)
+	assert.NoError(t, provider.ReceiveExchangeMessages(exchangeMessages))
+
+	invoice := crypto.Invoice{AgreementID: 1, AgreementTotal: 100, TransactorFee: 2, Hashlock: "0xabc", Provider: "0x3"}
+	assert.NoError(t, provider.SendInvoice(invoice))
+	assert.Equal(t, invoice, <-invoices)
+
+	em := crypto.ExchangeMessage{
+		Promise:        crypto.Promise{ChannelID: []byte{1}, Amount: 100, Hashlock: []byte{2}, R: []byte{3}, Signature: []byte{4}},
+		AgreementID:    1,
+		AgreementTotal: 100,
+		Provider:       "0x3",
+		Signature:      "0xdef",
+	}
+	assert.NoError(t, consumer.SendExchangeMessage(em))
+	assert.Equal(t, em, <-exchangeMessages)
+
+	assert.Equal(t, []communication.MessageEndpoint{"session-invoice"}, providerDialog.sent)
+	assert.Equal(t, []communication.MessageEndpoint{"session-exchange"}, consumerDialog.sent)
+}
+
+// fakeDialog passes the sent messages, serialized as on the wire, to the consumers of its peer.
+type fakeDialog struct {
+	peer      *fakeDialog
+	consumers map[communication.MessageEndpoint]communication.MessageConsumer
+	sent      []communication.MessageEndpoint
+}
+
+func newDialogPair() (*fakeDialog, *fakeDialog) {
+	a := &fakeDialog{consumers: map[communication.MessageEndpoint]communication.MessageConsumer{}}
+	b := &fakeDialog{consumers: map[communication.MessageEndpoint]communication.MessageConsumer{}, peer: a}
+	a.peer = b
+	return a, b
+}
+
+func (d *fakeDialog) PeerID() identity.Identity {
+	return identity.Identity{}
+}
+
+func (d *fakeDialog) Send(producer communication.MessageProducer) error {
+	d.sent = append(d.sent, producer.GetMessageEndpoint())
+
+	data, err := json.Marshal(producer.Produce())
+	if err != nil {
+		return err
+	}
+	consumer := d.peer.consumers[producer.GetMessageEndpoint()]
+	message := consumer.NewMessage()
+	if err := json.Unmarshal(data, message); err != nil {
+		return err
+	}
+	return consumer.Consume(message)
+}
+
+func (d *fakeDialog) Request(producer communication.RequestProducer) (responsePtr interface{}, err error) {
+	return nil, nil
+}
+
+func (d *fakeDialog) Receive(consumer communication.MessageConsumer) error {
+	d.consumers[consumer.GetMessageEndpoint()] = consumer
+	return nil
+}
+
+func (d *fakeDialog) ReceiveUnsubscribe(endpoint communication.MessageEndpoint) {}
+
+func (d *fakeDialog) Respond(consumer communication.RequestConsumer) error {
+	return nil
+}
+
+func (d *fakeDialog) Unsubscribe() {}
+
+func (d *fakeDialog) Close() error {
+	return nil
+}
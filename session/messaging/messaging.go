@@ -0,0 +1,52 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package messaging carries the session messages between consumer and provider,
+// hiding whether they travel over a p2p channel or a NATS dialog.
+package messaging
+
+import (
+	"context"
+
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/mysteriumnetwork/payments/crypto"
+)
+
+// Consumer sends the session and payment messages of the consumer to the provider.
+type Consumer interface {
+	// CreateSession requests the provider to create a new session.
+	CreateSession(ctx context.Context, request session.CreateRequest) (session.CreateResponse, error)
+	// AcknowledgeSession lets the provider know that the connection is established.
+	AcknowledgeSession(ctx context.Context, sessionID session.ID) error
+	// DestroySession requests the provider to destroy the session.
+	DestroySession(ctx context.Context, sessionID session.ID) error
+	// SendSessionStatus reports the session connectivity status to the provider.
+	SendSessionStatus(ctx context.Context, sessionID session.ID, code connectivity.StatusCode, message string) error
+	// SendExchangeMessage sends the exchange message paying for the invoice.
+	SendExchangeMessage(em crypto.ExchangeMessage) error
+	// ReceiveInvoices forwards the invoices of the provider to the given channel.
+	ReceiveInvoices(invoices chan crypto.Invoice) error
+}
+
+// Provider sends the payment messages of the provider to the consumer.
+type Provider interface {
+	// SendInvoice sends the invoice to the consumer.
+	SendInvoice(invoice crypto.Invoice) error
+	// ReceiveExchangeMessages forwards the exchange messages of the consumer to the given channel.
+	ReceiveExchangeMessages(messages chan crypto.ExchangeMessage) error
+}
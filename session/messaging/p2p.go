@@ -0,0 +1,204 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/p2p"
+	"github.com/mysteriumnetwork/node/pb"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/mysteriumnetwork/payments/crypto"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+)
+
+// NewP2PConsumer returns consumer messaging over the given p2p channel.
+func NewP2PConsumer(ch p2p.Channel, consumerID identity.Identity) Consumer {
+	return &p2pConsumer{ch: ch, consumerID: consumerID}
+}
+
+type p2pConsumer struct {
+	ch         p2p.Channel
+	consumerID identity.Identity
+}
+
+func (c *p2pConsumer) CreateSession(ctx context.Context, request session.CreateRequest) (session.CreateResponse, error) {
+	consumer := &pb.ConsumerInfo{Id: c.consumerID.Address}
+	if request.ConsumerInfo != nil {
+		consumer.AccountantID = request.ConsumerInfo.AccountantID.Address
+		consumer.PaymentVersion = string(request.ConsumerInfo.PaymentVersion)
+	}
+	sessionRequest := &pb.SessionRequest{
+		Consumer:   consumer,
+		ProposalID: int64(request.ProposalID),
+		Config:     request.Config,
+	}
+
+	res, err := send(ctx, c.ch, 20*time.Second, p2p.TopicSessionCreate, sessionRequest)
+	if err != nil {
+		return session.CreateResponse{}, fmt.Errorf("could not send p2p session create request: %w", err)
+	}
+
+	var sessionResponse pb.SessionResponse
+	if err := res.UnmarshalProto(&sessionResponse); err != nil {
+		return session.CreateResponse{}, fmt.Errorf("could not unmarshal session reply to proto: %w", err)
+	}
+
+	return session.CreateResponse{
+		Success: true,
+		Session: session.SessionDto{
+			ID:     session.ID(sessionResponse.GetID()),
+			Config: json.RawMessage(sessionResponse.GetConfig()),
+		},
+		PaymentInfo: session.PaymentInfo{Supports: sessionResponse.GetPaymentInfo()},
+	}, nil
+}
+
+func (c *p2pConsumer) AcknowledgeSession(ctx context.Context, sessionID session.ID) error {
+	_, err := send(ctx, c.ch, 20*time.Second, p2p.TopicSessionAcknowledge, c.sessionInfo(sessionID))
+	return err
+}
+
+func (c *p2pConsumer) DestroySession(ctx context.Context, sessionID session.ID) error {
+	if _, err := send(ctx, c.ch, 10*time.Second, p2p.TopicSessionDestroy, c.sessionInfo(sessionID)); err != nil {
+		return fmt.Errorf("could not send session destroy request: %w", err)
+	}
+	return nil
+}
+
+func (c *p2pConsumer) SendSessionStatus(ctx context.Context, sessionID session.ID, code connectivity.StatusCode, message string) error {
+	sessionStatus := &pb.SessionStatus{
+		ConsumerID: c.consumerID.Address,
+		SessionID:  string(sessionID),
+		Code:       uint32(code),
+		Message:    message,
+	}
+	if _, err := send(ctx, c.ch, 20*time.Second, p2p.TopicSessionStatus, sessionStatus); err != nil {
+		return fmt.Errorf("could not send p2p session status message: %w", err)
+	}
+	return nil
+}
+
+func (c *p2pConsumer) SendExchangeMessage(em crypto.ExchangeMessage) error {
+	pMessage := &pb.ExchangeMessage{
+		Promise: &pb.Promise{
+			ChannelID: em.Promise.ChannelID,
+			Amount:    em.Promise.Amount,
+			Fee:       em.Promise.Fee,
+			Hashlock:  em.Promise.Hashlock,
+			R:         em.Promise.R,
+			Signature: em.Promise.Signature,
+		},
+		AgreementID:    em.AgreementID,
+		AgreementTotal: em.AgreementTotal,
+		Provider:       em.Provider,
+		Signature:      em.Signature,
+	}
+	_, err := send(context.Background(), c.ch, 20*time.Second, p2p.TopicPaymentMessage, pMessage)
+	return err
+}
+
+func (c *p2pConsumer) ReceiveInvoices(invoices chan crypto.Invoice) error {
+	c.ch.Handle(p2p.TopicPaymentInvoice, func(ctx p2p.Context) error {
+		var msg pb.Invoice
+		if err := ctx.Request().UnmarshalProto(&msg); err != nil {
+			return err
+		}
+		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicPaymentInvoice, msg.String())
+
+		invoices <- crypto.Invoice{
+			AgreementID:    msg.GetAgreementID(),
+			AgreementTotal: msg.GetAgreementTotal(),
+			TransactorFee:  msg.GetTransactorFee(),
+			Hashlock:       msg.GetHashlock(),
+			Provider:       msg.GetProvider(),
+		}
+		return nil
+	})
+	return nil
+}
+
+func (c *p2pConsumer) sessionInfo(sessionID session.ID) *pb.SessionInfo {
+	return &pb.SessionInfo{
+		ConsumerID: c.consumerID.Address,
+		SessionID:  string(sessionID),
+	}
+}
+
+// NewP2PProvider returns provider messaging over the given p2p channel.
+func NewP2PProvider(ch p2p.Channel) Provider {
+	return &p2pProvider{ch: ch}
+}
+
+type p2pProvider struct {
+	ch p2p.Channel
+}
+
+func (p *p2pProvider) SendInvoice(invoice crypto.Invoice) error {
+	pInvoice := &pb.Invoice{
+		AgreementID:    invoice.AgreementID,
+		AgreementTotal: invoice.AgreementTotal,
+		TransactorFee:  invoice.TransactorFee,
+		Hashlock:       invoice.Hashlock,
+		Provider:       invoice.Provider,
+	}
+	_, err := send(context.Background(), p.ch, 10*time.Second, p2p.TopicPaymentInvoice, pInvoice)
+	return err
+}
+
+func (p *p2pProvider) ReceiveExchangeMessages(messages chan crypto.ExchangeMessage) error {
+	p.ch.Handle(p2p.TopicPaymentMessage, func(ctx p2p.Context) error {
+		var msg pb.ExchangeMessage
+		if err := ctx.Request().UnmarshalProto(&msg); err != nil {
+			return err
+		}
+		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicPaymentMessage, msg.String())
+
+		messages <- crypto.ExchangeMessage{
+			Promise: crypto.Promise{
+				ChannelID: msg.GetPromise().GetChannelID(),
+				Amount:    msg.GetPromise().GetAmount(),
+				Fee:       msg.GetPromise().GetFee(),
+				Hashlock:  msg.GetPromise().GetHashlock(),
+				R:         msg.GetPromise().GetR(),
+				Signature: msg.GetPromise().GetSignature(),
+			},
+			AgreementID:    msg.GetAgreementID(),
+			AgreementTotal: msg.GetAgreementTotal(),
+			Provider:       msg.GetProvider(),
+			Signature:      msg.GetSignature(),
+		}
+		return nil
+	})
+	return nil
+}
+
+// send sends the message over the channel, waiting for the reply no longer than the timeout.
+func send(ctx context.Context, ch p2p.ChannelSender, timeout time.Duration, topic string, msg proto.Message) (*p2p.Message, error) {
+	log.Debug().Msgf("Sending P2P message to %q: %s", topic, msg)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return ch.Send(ctx, topic, p2p.ProtoMessage(msg))
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package messaging
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/p2p"
+	"github.com/mysteriumnetwork/node/pb"
+	"github.com/mysteriumnetwork/node/session"
+	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/mysteriumnetwork/payments/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+var consumerID = identity.FromAddress("0x1")
+
+func TestP2PConsumer_CreateSession(t *testing.T) {
+	consumerCh, providerCh := newChannelPair()
+	var received pb.SessionRequest
+	providerCh.Handle(p2p.TopicSessionCreate, func(c p2p.Context) error {
+		if err := c.Request().UnmarshalProto(&received); err != nil {
+			return err
+		}
+		return c.OkWithReply(p2p.ProtoMessage(&pb.SessionResponse{
+			ID:          "session-1",
+			PaymentInfo: string(session.PaymentVersionV3),
+			Config:      []byte(`{"key":"value"}`),
+		}))
+	})
+
+	res, err := NewP2PConsumer(consumerCh, consumerID).CreateSession(context.Background(), session.CreateRequest{
+		ProposalID: 7,
+		Config:     []byte(`{}`),
+		ConsumerInfo: &session.ConsumerInfo{
+			IssuerID:       consumerID,
+			AccountantID:   identity.FromAddress("0x2"),
+			PaymentVersion: session.PaymentVersionV3,
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID("session-1"), res.Session.ID)
+	assert.JSONEq(t, `{"key":"value"}`, string(res.Session.Config))
+	assert.Equal(t, string(session.PaymentVersionV3), res.PaymentInfo.Supports)
+	assert.Equal(t, consumerID.Address, received.GetConsumer().GetId())
+	assert.Equal(t, "0x2", received.GetConsumer().GetAccountantID())
+	assert.Equal(t, int64(7), received.GetProposalID())
+}
+
+func TestP2PConsumer_CreateSessionReturnsProviderError(t *testing.T) {
+	consumerCh, providerCh := newChannelPair()
+	providerCh.Handle(p2p.TopicSessionCreate, func(c p2p.Context) error {
+		return c.Error(session.ErrorInvalidProposal)
+	})
+
+	_, err := NewP2PConsumer(consumerCh, consumerID).CreateSession(context.Background(), session.CreateRequest{})
+
+	assert.EqualError(t, err, "could not send p2p session create request: "+session.ErrorInvalidProposal.Error())
+}
+
+func TestP2PConsumer_SessionMessages(t *testing.T) {
+	consumerCh, providerCh := newChannelPair()
+	var topics []string
+	var status pb.SessionStatus
+	for _, topic := range []string{p2p.TopicSessionAcknowledge, p2p.TopicSessionDestroy} {
+		providerCh.Handle(topic, func(c p2p.Context) error {
+			var info pb.SessionInfo
+			if err := c.Request().UnmarshalProto(&info); err != nil {
+				return err
+			}
+			topics = append(topics, info.GetConsumerID()+"/"+info.GetSessionID())
+			return c.OK()
+		})
+	}
+	providerCh.Handle(p2p.TopicSessionStatus, func(c p2p.Context) error {
+		return c.Request().UnmarshalProto(&status)
+	})
+	messenger := NewP2PConsumer(consumerCh, consumerID)
+
+	assert.NoError(t, messenger.AcknowledgeSession(context.Background(), "session-1"))
+	assert.NoError(t, messenger.SendSessionStatus(context.Background(), "session-1", connectivity.StatusConnectionOk, "ok"))
+	assert.NoError(t, messenger.DestroySession(context.Background(), "session-1"))
+
+	assert.Equal(t, []string{"0x1/session-1", "0x1/session-1"}, topics)
+	assert.Equal(t, consumerID.Address, status.GetConsumerID())
+	assert.Equal(t, uint32(connectivity.StatusConnectionOk), status.GetCode())
+	assert.Equal(t, "ok", status.GetMessage())
+}
+
+func TestP2PMessaging_Payments(t *testing.T) {
+	consumerCh, providerCh := newChannelPair()
+	consumer := NewP2PConsumer(consumerCh, consumerID)
+	provider := NewP2PProvider(providerCh)
+
+	invoices := make(chan crypto.Invoice, 1)
+	assert.NoError(t, consumer.ReceiveInvoices(invoices))
+	exchangeMessages := make(chan crypto.ExchangeMessage, 1)
+	assert.NoError(t, provider.ReceiveExchangeMessages(exchangeMessages))
+
+	invoice := crypto.Invoice{AgreementID: 1, AgreementTotal: 100, TransactorFee: 2, Hashlock: "0xabc", Provider: "0x3"}
+	assert.NoError(t, provider.SendInvoice(invoice))
+	assert.Equal(t, invoice, <-invoices)
+
+	em := crypto.ExchangeMessage{
+		Promise: crypto.Promise{
+			ChannelID: []byte{1},
+			Amount:    100,
+			Fee:       2,
+			Hashlock:  []byte{2},
+			R:         []byte{3},
+			Signature: []byte{4},
+		},
+		AgreementID:    1,
+		AgreementTotal: 100,
+		Provider:       "0x3",
+		Signature:      "0xdef",
+	}
+	assert.NoError(t, consumer.SendExchangeMessage(em))
+	assert.Equal(t, em, <-exchangeMessages)
+}
+
+// fakeChannel passes the sent messages straight to the handlers of its peer.
+type fakeChannel struct {
+	peer     *fakeChannel
+	handlers map[string]p2p.HandlerFunc
+}
+
+func newChannelPair() (*fakeChannel, *fakeChannel) {
+	a := &fakeChannel{handlers: map[string]p2p.HandlerFunc{}}
+	b := &fakeChannel{handlers: map[string]p2p.HandlerFunc{}, peer: a}
+	a.peer = b
+	return a, b
+}
+
+func (c *fakeChannel) Send(_ context.Context, topic string, msg *p2p.Message) (*p2p.Message, error) {
+	handler, ok := c.peer.handlers[topic]
+	if !ok {
+		return nil, p2p.ErrHandlerNotFound
+	}
+
+	ctx := &fakeContext{req: msg}
+	if err := handler(ctx); err != nil {
+		return nil, errors.New("internal peer error")
+	}
+	if ctx.publicError != nil {
+		return nil, ctx.publicError
+	}
+	return ctx.res, nil
+}
+
+func (c *fakeChannel) Handle(topic string, handler p2p.HandlerFunc) {
+	c.handlers[topic] = handler
+}
+
+func (c *fakeChannel) ServiceConn() *net.UDPConn { return nil }
+
+func (c *fakeChannel) Conn() *net.UDPConn { return nil }
+
+func (c *fakeChannel) Close() error { return nil }
+
+type fakeContext struct {
+	req         *p2p.Message
+	res         *p2p.Message
+	publicError error
+}
+
+func (c *fakeContext) Request() *p2p.Message { return c.req }
+
+func (c *fakeContext) Error(err error) error {
+	c.publicError = err
+	return nil
+}
+
+func (c *fakeContext) OkWithReply(msg *p2p.Message) error {
+	c.res = msg
+	return nil
+}
+
+func (c *fakeContext) OK() error { return nil }
@@ -85,6 +85,19 @@ func NewAccountantPromiseHandler(deps AccountantPromiseHandlerDeps) *AccountantP
 	}
 }
 
+// AccountantPromiseHandlers holds a promise handler for every accountant the provider accepts payments through.
+type AccountantPromiseHandlers map[common.Address]*AccountantPromiseHandler
+
+// Subscribe subscribes every accountant promise handler to relevant events.
+func (handlers AccountantPromiseHandlers) Subscribe(bus eventbus.Subscriber) error {
+	for accountantID, handler := range handlers {
+		if err := handler.Subscribe(bus); err != nil {
+			return fmt.Errorf("could not subscribe promise handler of accountant %v: %w", accountantID.Hex(), err)
+		}
+	}
+	return nil
+}
+
 type enqueuedRequest struct {
 	errChan    chan error
 	r          []byte
@@ -35,6 +35,7 @@ import (
 	"github.com/mysteriumnetwork/payments/client"
 	"github.com/mysteriumnetwork/payments/crypto"
 	"github.com/pkg/errors"
+	"github.com/robfig/cron"
 	"github.com/rs/zerolog/log"
 )
 
@@ -60,15 +61,24 @@ type promiseStorage interface {
 	Get(id identity.Identity, accountantID common.Address) (AccountantPromise, error)
 }
 
+type settlementHistory interface {
+	Store(attempt *SettlementAttempt) error
+	Update(attempt *SettlementAttempt) error
+	List(filter SettlementHistoryFilter) ([]SettlementAttempt, error)
+}
+
 type receivedPromise struct {
 	provider identity.Identity
 	promise  crypto.Promise
+	attempt  SettlementAttempt
 }
 
 // AccountantPromiseSettler is responsible for settling the accountant promises.
 type AccountantPromiseSettler interface {
 	GetEarnings(id identity.Identity) event.Earnings
+	GetEarningsByAccountant(id identity.Identity) map[common.Address]event.Earnings
 	ForceSettle(providerID identity.Identity, accountantID common.Address) error
+	SettlementHistory(filter SettlementHistoryFilter) ([]SettlementAttempt, error)
 	Subscribe() error
 }
 
@@ -82,11 +92,15 @@ type accountantPromiseSettler struct {
 	ks                         ks
 	transactor                 transactor
 	promiseStorage             promiseStorage
+	history                    settlementHistory
+	schedule                   cron.Schedule
 
 	currentState map[identity.Identity]settlementState
-	settleQueue  chan receivedPromise
-	stop         chan struct{}
-	once         sync.Once
+	// settlePending marks providers with an automatic settlement enqueued, but not processed yet.
+	settlePending map[identity.Identity]bool
+	settleQueue   chan receivedPromise
+	stop          chan struct{}
+	once          sync.Once
 }
 
 // AccountantPromiseSettlerConfig configures the accountant promise settler accordingly.
@@ -94,10 +108,16 @@ type AccountantPromiseSettlerConfig struct {
 	AccountantAddress    common.Address
 	Threshold            float64
 	MaxWaitForSettlement time.Duration
+	// Schedule is a cron expression of additional settlement runs, empty disables scheduled settlement.
+	Schedule string
+	// FeeMultiplier skips automatic settlements unless unsettled earnings exceed the settlement fee multiplied by it, zero disables the check.
+	FeeMultiplier float64
+	// MaxSettlementsPerDay limits automatic settlements submitted during the last 24 hours, zero means no limit.
+	MaxSettlementsPerDay int
 }
 
 // NewAccountantPromiseSettler creates a new instance of accountant promise settler.
-func NewAccountantPromiseSettler(eventBus eventbus.EventBus, transactor transactor, promiseStorage promiseStorage, providerChannelStatusProvider providerChannelStatusProvider, registrationStatusProvider registrationStatusProvider, ks ks, config AccountantPromiseSettlerConfig) *accountantPromiseSettler {
+func NewAccountantPromiseSettler(eventBus eventbus.EventBus, transactor transactor, promiseStorage promiseStorage, history settlementHistory, providerChannelStatusProvider providerChannelStatusProvider, registrationStatusProvider registrationStatusProvider, ks ks, config AccountantPromiseSettlerConfig) *accountantPromiseSettler {
 	return &accountantPromiseSettler{
 		eventBus:                   eventBus,
 		bc:                         providerChannelStatusProvider,
@@ -105,7 +125,9 @@ func NewAccountantPromiseSettler(eventBus eventbus.EventBus, transactor transact
 		registrationStatusProvider: registrationStatusProvider,
 		config:                     config,
 		currentState:               make(map[identity.Identity]settlementState),
+		settlePending:              make(map[identity.Identity]bool),
 		promiseStorage:             promiseStorage,
+		history:                    history,
 
 		// defaulting to a queue of 5, in case we have a few active identities.
 		settleQueue: make(chan receivedPromise, 5),
@@ -162,14 +184,23 @@ func (aps *accountantPromiseSettler) resyncState(id identity.Identity) error {
 
 func (aps *accountantPromiseSettler) publishChangeEvent(id identity.Identity, before, after settlementState) {
 	aps.eventBus.Publish(event.AppTopicEarningsChanged, event.AppEventEarningsChanged{
-		Identity: id,
-		Previous: before.Earnings(),
-		Current:  after.Earnings(),
+		Identity:     id,
+		AccountantID: aps.config.AccountantAddress,
+		Previous:     before.Earnings(),
+		Current:      after.Earnings(),
 	})
 }
 
 // Subscribe subscribes the accountant promise settler to the appropriate events
 func (aps *accountantPromiseSettler) Subscribe() error {
+	if aps.config.Schedule != "" {
+		schedule, err := cron.ParseStandard(aps.config.Schedule)
+		if err != nil {
+			return errors.Wrap(err, "could not parse settlement schedule")
+		}
+		aps.schedule = schedule
+	}
+
 	err := aps.eventBus.SubscribeAsync(nodevent.AppTopicNode, aps.handleNodeEvent)
 	if err != nil {
 		return errors.Wrap(err, "could not subscribe to node status event")
@@ -243,6 +274,10 @@ func (aps *accountantPromiseSettler) handleRegistrationEvent(payload registry.Ap
 }
 
 func (aps *accountantPromiseSettler) handleAccountantPromiseReceived(apep event.AppEventAccountantPromise) {
+	if apep.AccountantID != aps.config.AccountantAddress {
+		return
+	}
+
 	id := apep.ProviderID
 	log.Info().Msgf("Received accountant promise for %q", id)
 	aps.lock.Lock()
@@ -263,14 +298,176 @@ func (aps *accountantPromiseSettler) handleAccountantPromiseReceived(apep event.
 	aps.currentState[apep.ProviderID] = s
 	log.Info().Msgf("Accountant promise state updated for provider %q", id)
 
-	if s.needsSettling(aps.config.Threshold) {
+	if s.needsSettling(aps.config.Threshold) && !aps.settlePending[id] {
+		aps.settlePending[id] = true
+		go aps.enqueue(apep.ProviderID, SettlementTriggerThreshold)
+	}
+}
+
+// enqueue records a settlement attempt and puts it to the settlement queue, unless the settlement policies forbid it.
+// The provider has to be marked as pending settlement, the mark is cleared once the attempt is processed or skipped.
+func (aps *accountantPromiseSettler) enqueue(provider identity.Identity, trigger SettlementTrigger) {
+	attempt := aps.newAttempt(provider, aps.config.AccountantAddress, trigger)
+	err := aps.checkPolicies(&attempt)
+	if err != nil {
+		aps.clearPending(provider)
+		log.Info().Err(err).Msgf("Skipping %s settlement for provider %q", trigger, provider)
+		// threshold is re-evaluated on every received promise, recording each skip would flood the history
+		if trigger == SettlementTriggerThreshold {
+			return
+		}
+		attempt.Status = SettlementStatusSkipped
+		attempt.Error = err.Error()
+		aps.storeAttempt(&attempt)
+		return
+	}
+
+	aps.storeAttempt(&attempt)
+	aps.settleQueue <- receivedPromise{
+		provider: provider,
+		attempt:  attempt,
+	}
+}
+
+// checkPolicies checks whether the automatic settlement is allowed by the configured policies.
+func (aps *accountantPromiseSettler) checkPolicies(attempt *SettlementAttempt) error {
+	if aps.config.MaxSettlementsPerDay > 0 {
+		since := time.Now().UTC().Add(-24 * time.Hour)
+		attempts, err := aps.history.List(SettlementHistoryFilter{ProviderID: &attempt.ProviderID, Since: &since})
+		if err != nil {
+			return errors.Wrap(err, "could not count settlements")
+		}
+
+		submitted := 0
+		for _, a := range attempts {
+			if a.submitted() {
+				submitted++
+			}
+		}
+		if submitted >= aps.config.MaxSettlementsPerDay {
+			return errors.Errorf("daily settlement limit of %d reached", aps.config.MaxSettlementsPerDay)
+		}
+	}
+
+	if aps.config.FeeMultiplier > 0 {
+		fees, err := aps.transactor.FetchSettleFees()
+		if err != nil {
+			return errors.Wrap(err, "could not fetch settlement fees")
+		}
+
+		attempt.Fee = fees.Fee
+		if float64(attempt.Amount) <= aps.config.FeeMultiplier*float64(fees.Fee) {
+			return errors.Errorf("unsettled earnings %v do not exceed %v times the settlement fee %v", attempt.Amount, aps.config.FeeMultiplier, fees.Fee)
+		}
+	}
+
+	return nil
+}
+
+func (aps *accountantPromiseSettler) newAttempt(provider identity.Identity, accountantID common.Address, trigger SettlementTrigger) SettlementAttempt {
+	aps.lock.RLock()
+	defer aps.lock.RUnlock()
+
+	now := time.Now().UTC()
+	return SettlementAttempt{
+		ProviderID:   provider,
+		AccountantID: accountantID,
+		Trigger:      trigger,
+		Status:       SettlementStatusQueued,
+		Amount:       aps.currentState[provider].unsettledBalance(),
+		Created:      now,
+		Updated:      now,
+	}
+}
+
+func (aps *accountantPromiseSettler) storeAttempt(attempt *SettlementAttempt) {
+	if err := aps.history.Store(attempt); err != nil {
+		log.Error().Err(err).Msgf("Could not store settlement attempt for provider %q", attempt.ProviderID)
+	}
+}
+
+func (aps *accountantPromiseSettler) updateAttempt(attempt *SettlementAttempt) {
+	attempt.Updated = time.Now().UTC()
+	if err := aps.history.Update(attempt); err != nil {
+		log.Error().Err(err).Msgf("Could not update settlement attempt %v", attempt.ID)
+	}
+}
+
+// resumePending puts the attempts left unfinished by the previous run back to the settlement queue.
+func (aps *accountantPromiseSettler) resumePending(provider identity.Identity) {
+	attempts, err := aps.history.List(SettlementHistoryFilter{ProviderID: &provider})
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not load pending settlements for provider %q", provider)
+		return
+	}
+
+	// attempts are listed newest first, resume them in the original order
+	for i := len(attempts) - 1; i >= 0; i-- {
+		attempt := attempts[i]
+		if !attempt.pending() || attempt.AccountantID != aps.config.AccountantAddress {
+			continue
+		}
+
+		log.Info().Msgf("Resuming settlement %v for provider %q", attempt.ID, provider)
+		attempt.Status = SettlementStatusQueued
+		aps.updateAttempt(&attempt)
 		aps.settleQueue <- receivedPromise{
-			provider: apep.ProviderID,
-			promise:  apep.Promise,
+			provider: provider,
+			attempt:  attempt,
 		}
 	}
 }
 
+// settleOnSchedule enqueues settlements for all providers with unsettled earnings whenever the schedule fires.
+func (aps *accountantPromiseSettler) settleOnSchedule() {
+	for {
+		next := aps.schedule.Next(time.Now())
+		select {
+		case <-aps.stop:
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		for _, provider := range aps.providersWithEarnings() {
+			if aps.markPending(provider) {
+				go aps.enqueue(provider, SettlementTriggerSchedule)
+			}
+		}
+	}
+}
+
+func (aps *accountantPromiseSettler) providersWithEarnings() []identity.Identity {
+	aps.lock.RLock()
+	defer aps.lock.RUnlock()
+
+	var providers []identity.Identity
+	for id, s := range aps.currentState {
+		if s.registered && !s.settleInProgress && s.unsettledBalance() > 0 {
+			providers = append(providers, id)
+		}
+	}
+	return providers
+}
+
+// markPending marks the provider as pending settlement, unless it is already marked.
+func (aps *accountantPromiseSettler) markPending(provider identity.Identity) bool {
+	aps.lock.Lock()
+	defer aps.lock.Unlock()
+
+	if aps.settlePending[provider] {
+		return false
+	}
+	aps.settlePending[provider] = true
+	return true
+}
+
+func (aps *accountantPromiseSettler) clearPending(provider identity.Identity) {
+	aps.lock.Lock()
+	defer aps.lock.Unlock()
+
+	delete(aps.settlePending, provider)
+}
+
 func (aps *accountantPromiseSettler) listenForSettlementRequests() {
 	log.Info().Msg("Listening for settlement events")
 	defer func() {
@@ -282,7 +479,7 @@ func (aps *accountantPromiseSettler) listenForSettlementRequests() {
 		case <-aps.stop:
 			return
 		case p := <-aps.settleQueue:
-			go aps.settle(p)
+			go aps.processQueued(p)
 		}
 	}
 }
@@ -295,37 +492,114 @@ func (aps *accountantPromiseSettler) GetEarnings(id identity.Identity) event.Ear
 	return aps.currentState[id].Earnings()
 }
 
+// GetEarningsByAccountant returns current settlement status for given identity keyed by accountant
+func (aps *accountantPromiseSettler) GetEarningsByAccountant(id identity.Identity) map[common.Address]event.Earnings {
+	return map[common.Address]event.Earnings{aps.config.AccountantAddress: aps.GetEarnings(id)}
+}
+
 // ErrNothingToSettle indicates that there is nothing to settle.
 var ErrNothingToSettle = errors.New("nothing to settle for the given provider")
 
-// ForceSettle forces the settlement for a provider
+// ForceSettle forces the settlement for a provider, bypassing the settlement policies
 func (aps *accountantPromiseSettler) ForceSettle(providerID identity.Identity, accountantID common.Address) error {
+	promise, err := aps.latestPromise(providerID, accountantID)
+	if err != nil {
+		return err
+	}
+
+	attempt := aps.newAttempt(providerID, accountantID, SettlementTriggerManual)
+	aps.storeAttempt(&attempt)
+	return aps.settleAttempt(&attempt, promise)
+}
+
+// SettlementHistory returns settlement attempts matching the filter, newest first
+func (aps *accountantPromiseSettler) SettlementHistory(filter SettlementHistoryFilter) ([]SettlementAttempt, error) {
+	return aps.history.List(filter)
+}
+
+func (aps *accountantPromiseSettler) latestPromise(providerID identity.Identity, accountantID common.Address) (crypto.Promise, error) {
 	promise, err := aps.promiseStorage.Get(providerID, accountantID)
 	if err == ErrNotFound {
-		return ErrNothingToSettle
+		return crypto.Promise{}, ErrNothingToSettle
 	}
 	if err != nil {
-		return errors.Wrap(err, "could not get promise from storage")
+		return crypto.Promise{}, errors.Wrap(err, "could not get promise from storage")
 	}
 
 	hexR, err := hex.DecodeString(promise.R)
 	if err != nil {
-		return errors.Wrap(err, "could not decode R")
+		return crypto.Promise{}, errors.Wrap(err, "could not decode R")
 	}
 
 	promise.Promise.R = hexR
-	return aps.settle(receivedPromise{
-		promise:  promise.Promise,
-		provider: providerID,
+	return promise.Promise, nil
+}
+
+func (aps *accountantPromiseSettler) processQueued(p receivedPromise) {
+	defer aps.clearPending(p.provider)
+
+	aps.lock.RLock()
+	registered := aps.currentState[p.provider].registered
+	aps.lock.RUnlock()
+
+	attempt := p.attempt
+	if !registered {
+		attempt.Status = SettlementStatusSkipped
+		attempt.Error = "provider is not registered"
+		aps.updateAttempt(&attempt)
+		return
+	}
+
+	promise, err := aps.latestPromise(p.provider, aps.config.AccountantAddress)
+	if err != nil {
+		attempt.Status = SettlementStatusFailed
+		attempt.Error = err.Error()
+		aps.updateAttempt(&attempt)
+		log.Error().Err(err).Msgf("Could not settle promise for %v", p.provider.Address)
+		return
+	}
+
+	aps.settleAttempt(&attempt, promise)
+}
+
+// settleAttempt settles the given promise, keeping the attempt status up to date.
+func (aps *accountantPromiseSettler) settleAttempt(attempt *SettlementAttempt, promise crypto.Promise) error {
+	attempt.Status = SettlementStatusSettling
+	aps.updateAttempt(attempt)
+
+	err := aps.settle(receivedPromise{
+		provider: attempt.ProviderID,
+		promise:  promise,
+		attempt:  *attempt,
 	})
+	switch err {
+	case nil:
+		attempt.Status = SettlementStatusSettled
+	case errSettlerStopped:
+		// leave the attempt as settling, so it's resumed on the next start
+		return err
+	case errSettlementInProgress:
+		attempt.Status = SettlementStatusSkipped
+		attempt.Error = err.Error()
+	default:
+		attempt.Status = SettlementStatusFailed
+		attempt.Error = err.Error()
+	}
+	aps.updateAttempt(attempt)
+	return err
 }
 
 // ErrSettleTimeout indicates that the settlement has timed out
 var ErrSettleTimeout = errors.New("settle timeout")
 
+var (
+	errSettlementInProgress = errors.New("provider already has settlement in progress")
+	errSettlerStopped       = errors.New("settler stopped before settlement completed")
+)
+
 func (aps *accountantPromiseSettler) settle(p receivedPromise) error {
 	if aps.isSettling(p.provider) {
-		return errors.New("provider already has settlement in progress")
+		return errSettlementInProgress
 	}
 
 	aps.setSettling(p.provider, true)
@@ -337,20 +611,25 @@ func (aps *accountantPromiseSettler) settle(p receivedPromise) error {
 		return err
 	}
 
-	errCh := make(chan error)
+	errCh := make(chan error, 1)
+	requestFailed := make(chan struct{})
 	go func() {
 		defer cancel()
 		defer aps.setSettling(p.provider, false)
 		defer close(errCh)
 		select {
 		case <-aps.stop:
+			errCh <- errSettlerStopped
 			return
-		case _, more := <-sink:
+		case <-requestFailed:
+			return
+		case info, more := <-sink:
 			if !more {
 				break
 			}
 
 			log.Info().Msgf("Settling complete for provider %v", p.provider)
+			aps.publishSettlement(p, info, nil)
 
 			err := aps.resyncState(p.provider)
 			if err != nil {
@@ -365,6 +644,7 @@ func (aps *accountantPromiseSettler) settle(p receivedPromise) error {
 			log.Info().Msgf("Settle timeout for %v", p.provider)
 
 			// send a signal to waiter that the settlement has timed out
+			aps.publishSettlement(p, nil, ErrSettleTimeout)
 			errCh <- ErrSettleTimeout
 			return
 		}
@@ -372,14 +652,34 @@ func (aps *accountantPromiseSettler) settle(p receivedPromise) error {
 
 	err = aps.transactor.SettleAndRebalance(aps.config.AccountantAddress.Hex(), p.promise)
 	if err != nil {
-		cancel()
+		close(requestFailed)
 		log.Error().Err(err).Msgf("Could not settle promise for %v", p.provider.Address)
+		aps.publishSettlement(p, nil, err)
 		return err
 	}
 
 	return <-errCh
 }
 
+func (aps *accountantPromiseSettler) publishSettlement(p receivedPromise, info *bindings.AccountantImplementationPromiseSettled, err error) {
+	ev := event.AppEventSettlement{
+		ProviderID:   p.provider,
+		AccountantID: aps.config.AccountantAddress,
+		Fee:          p.promise.Fee,
+		PromiseHash:  hex.EncodeToString(p.promise.GetHash()),
+	}
+	if info != nil {
+		ev.TxHash = info.Raw.TxHash.Hex()
+		if info.Amount != nil {
+			ev.Amount = info.Amount.Uint64()
+		}
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	go aps.eventBus.Publish(event.AppTopicSettlement, ev)
+}
+
 func (aps *accountantPromiseSettler) isSettling(id identity.Identity) bool {
 	aps.lock.RLock()
 	defer aps.lock.RUnlock()
@@ -401,6 +701,9 @@ func (aps *accountantPromiseSettler) setSettling(id identity.Identity, settling
 
 func (aps *accountantPromiseSettler) handleNodeStart() {
 	go aps.listenForSettlementRequests()
+	if aps.schedule != nil {
+		go aps.settleOnSchedule()
+	}
 
 	for _, v := range aps.ks.Accounts() {
 		addr := identity.FromAddress(v.Address.Hex())
@@ -413,7 +716,9 @@ func (aps *accountantPromiseSettler) handleNodeStart() {
 				// On restart, a rebalance then should follow almost immediately.
 				// But we'll get punished for that, won't we?
 				log.Error().Err(err).Msgf("could not load initial state for %v", addr)
+				return
 			}
+			aps.resumePending(address)
 		}(addr)
 	}
 }
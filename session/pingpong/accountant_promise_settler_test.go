@@ -17,6 +17,7 @@
 package pingpong
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -49,7 +50,7 @@ func TestPromiseSettler_resyncState_returns_errors(t *testing.T) {
 
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistory{}, channelStatusProvider, mrsp, ks, cfg)
 	err = settler.resyncState(mockID)
 	assert.Equal(t, fmt.Sprintf("could not get provider channel for %v: %v", mockID, errMock.Error()), err.Error())
 
@@ -74,7 +75,7 @@ func TestPromiseSettler_resyncState_handles_no_promise(t *testing.T) {
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
 	id := identity.FromAddress("test")
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistory{}, channelStatusProvider, mrsp, ks, cfg)
 	err = settler.resyncState(id)
 	assert.NoError(t, err)
 
@@ -103,7 +104,7 @@ func TestPromiseSettler_resyncState_takes_promise_into_account(t *testing.T) {
 
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistory{}, channelStatusProvider, mrsp, ks, cfg)
 	err = settler.resyncState(mockID)
 	assert.NoError(t, err)
 
@@ -132,7 +133,7 @@ func TestPromiseSettler_loadInitialState(t *testing.T) {
 
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistory{}, channelStatusProvider, mrsp, ks, cfg)
 
 	settler.currentState[mockID] = settlementState{}
 
@@ -202,7 +203,7 @@ func TestPromiseSettler_handleServiceEvent(t *testing.T) {
 
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistory{}, channelStatusProvider, mrsp, ks, cfg)
 
 	statusesWithNoChangeExpected := []string{string(servicestate.Starting), string(servicestate.NotRunning)}
 
@@ -244,7 +245,7 @@ func TestPromiseSettler_handleRegistrationEvent(t *testing.T) {
 
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistory{}, channelStatusProvider, mrsp, ks, cfg)
 
 	statusesWithNoChangeExpected := []registry.RegistrationStatus{registry.RegisteredConsumer, registry.Unregistered, registry.InProgress, registry.Promoting, registry.RegistrationError}
 	for _, v := range statusesWithNoChangeExpected {
@@ -286,7 +287,7 @@ func TestPromiseSettler_handleAccountantPromiseReceived(t *testing.T) {
 	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
 
 	// no receive on unknown provider
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistory{}, channelStatusProvider, mrsp, ks, cfg)
 	settler.handleAccountantPromiseReceived(event.AppEventAccountantPromise{
 		AccountantID: cfg.AccountantAddress,
 		ProviderID:   mockID,
@@ -309,6 +310,15 @@ func TestPromiseSettler_handleAccountantPromiseReceived(t *testing.T) {
 		lastPromise: crypto.Promise{Amount: 8900},
 		registered:  true,
 	}
+	// promises of other accountants should be ignored
+	settler.handleAccountantPromiseReceived(event.AppEventAccountantPromise{
+		AccountantID: common.HexToAddress("0x1"),
+		ProviderID:   mockID,
+		Promise:      crypto.Promise{Amount: 9000},
+	})
+	assertNoReceive(t, settler.settleQueue)
+	assert.Equal(t, uint64(8900), settler.currentState[mockID].lastPromise.Amount)
+
 	settler.handleAccountantPromiseReceived(event.AppEventAccountantPromise{
 		AccountantID: cfg.AccountantAddress,
 		ProviderID:   mockID,
@@ -337,6 +347,32 @@ func TestPromiseSettler_handleAccountantPromiseReceived(t *testing.T) {
 	assertNoReceive(t, settler.settleQueue)
 }
 
+func TestPromiseSettler_handleAccountantPromiseReceived_enqueuesOncePerProvider(t *testing.T) {
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, &mockSettlementHistory{}, &mockProviderChannelStatusProvider{}, &mockRegistrationStatusProvider{}, nil, cfg)
+	settler.currentState[mockID] = settlementState{
+		channel:    client.ProviderChannel{Balance: big.NewInt(10000)},
+		registered: true,
+	}
+
+	for _, amount := range []uint64{9000, 9100} {
+		settler.handleAccountantPromiseReceived(event.AppEventAccountantPromise{
+			AccountantID: cfg.AccountantAddress,
+			ProviderID:   mockID,
+			Promise:      crypto.Promise{Amount: amount},
+		})
+	}
+	assert.False(t, settler.markPending(mockID), "schedule should not enqueue a pending provider either")
+
+	p := <-settler.settleQueue
+	assert.Equal(t, mockID, p.provider)
+	assertNoReceive(t, settler.settleQueue)
+
+	// provider deregistration skips the attempt, without reaching the blockchain
+	settler.currentState[mockID] = settlementState{}
+	settler.processQueued(p)
+	assert.True(t, settler.markPending(mockID), "provider should be settled again once the attempt is processed")
+}
+
 func assertNoReceive(t *testing.T, ch chan receivedPromise) {
 	// at this point, we should not receive an event on settled queue as we have no info on provider, let's check for that
 	select {
@@ -375,7 +411,7 @@ func TestPromiseSettler_handleNodeStart(t *testing.T) {
 		},
 	}
 
-	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, channelStatusProvider, mrsp, ks, cfg)
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, &mockSettlementHistory{}, channelStatusProvider, mrsp, ks, cfg)
 
 	settler.handleNodeStart()
 
@@ -447,6 +483,117 @@ func TestPromiseSettlerState_balance(t *testing.T) {
 	assert.Equal(t, uint64(6), s.unsettledBalance())
 }
 
+func TestPromiseSettler_Subscribe_rejectsInvalidSchedule(t *testing.T) {
+	config := cfg
+	config.Schedule = "every now and then"
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, &mockSettlementHistory{}, &mockProviderChannelStatusProvider{}, &mockRegistrationStatusProvider{}, nil, config)
+
+	assert.Error(t, settler.Subscribe())
+}
+
+func TestPromiseSettler_enqueue_respectsFeeMultiplier(t *testing.T) {
+	config := cfg
+	config.FeeMultiplier = 2
+	history := &mockSettlementHistory{}
+	transactor := &mockTransactor{feesToReturn: registry.FeesResponse{Fee: 100}}
+	settler := NewAccountantPromiseSettler(eventbus.New(), transactor, &mockAccountantPromiseGetter{}, history, &mockProviderChannelStatusProvider{}, &mockRegistrationStatusProvider{}, nil, config)
+
+	settler.currentState[mockID] = settlementState{
+		channel:     client.ProviderChannel{Balance: big.NewInt(1000)},
+		lastPromise: crypto.Promise{Amount: 150},
+		registered:  true,
+	}
+	settler.enqueue(mockID, SettlementTriggerSchedule)
+	assertNoReceive(t, settler.settleQueue)
+	assert.Len(t, history.attempts, 1)
+	assert.Equal(t, SettlementStatusSkipped, history.attempts[0].Status)
+	assert.Equal(t, uint64(150), history.attempts[0].Amount)
+	assert.Equal(t, uint64(100), history.attempts[0].Fee)
+
+	// skipped threshold settlements are not recorded
+	settler.enqueue(mockID, SettlementTriggerThreshold)
+	assertNoReceive(t, settler.settleQueue)
+	assert.Len(t, history.attempts, 1)
+
+	settler.currentState[mockID] = settlementState{
+		channel:     client.ProviderChannel{Balance: big.NewInt(1000)},
+		lastPromise: crypto.Promise{Amount: 250},
+		registered:  true,
+	}
+	settler.enqueue(mockID, SettlementTriggerSchedule)
+	p := <-settler.settleQueue
+	assert.Equal(t, mockID, p.provider)
+	assert.Equal(t, SettlementStatusQueued, p.attempt.Status)
+	assert.Equal(t, SettlementTriggerSchedule, p.attempt.Trigger)
+	assert.Len(t, history.attempts, 2)
+}
+
+func TestPromiseSettler_enqueue_respectsDailyLimit(t *testing.T) {
+	config := cfg
+	config.MaxSettlementsPerDay = 2
+	now := time.Now().UTC()
+	history := &mockSettlementHistory{
+		attempts: []SettlementAttempt{
+			{ID: 1, ProviderID: mockID, Status: SettlementStatusSettled, Created: now.Add(-30 * time.Hour)},
+			{ID: 2, ProviderID: mockID, Status: SettlementStatusSettled, Created: now.Add(-time.Hour)},
+			{ID: 3, ProviderID: mockID, Status: SettlementStatusSkipped, Created: now.Add(-time.Minute)},
+		},
+	}
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, history, &mockProviderChannelStatusProvider{}, &mockRegistrationStatusProvider{}, nil, config)
+	settler.currentState[mockID] = settlementState{
+		lastPromise: crypto.Promise{Amount: 150},
+		registered:  true,
+	}
+
+	settler.enqueue(mockID, SettlementTriggerSchedule)
+	p := <-settler.settleQueue
+	assert.Equal(t, mockID, p.provider)
+
+	history.attempts[len(history.attempts)-1].Status = SettlementStatusFailed
+	settler.enqueue(mockID, SettlementTriggerSchedule)
+	assertNoReceive(t, settler.settleQueue)
+	assert.Equal(t, SettlementStatusSkipped, history.attempts[len(history.attempts)-1].Status)
+	assert.Equal(t, "daily settlement limit of 2 reached", history.attempts[len(history.attempts)-1].Error)
+}
+
+func TestPromiseSettler_ForceSettle_recordsAttempt(t *testing.T) {
+	sink := make(chan *bindings.AccountantImplementationPromiseSettled, 1)
+	sink <- &bindings.AccountantImplementationPromiseSettled{}
+	channelStatusProvider := &mockProviderChannelStatusProvider{
+		channelToReturn: mockProviderChannel,
+		sinkToReturn:    sink,
+		subCancel:       func() {},
+	}
+	mapg := &mockAccountantPromiseGetter{
+		promise: AccountantPromise{Promise: crypto.Promise{Amount: 100}, R: "abcdef"},
+	}
+	history := &mockSettlementHistory{}
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, mapg, history, channelStatusProvider, &mockRegistrationStatusProvider{}, nil, cfg)
+
+	err := settler.ForceSettle(mockID, cfg.AccountantAddress)
+	assert.NoError(t, err)
+	assert.Len(t, history.attempts, 1)
+	assert.Equal(t, SettlementTriggerManual, history.attempts[0].Trigger)
+	assert.Equal(t, SettlementStatusSettled, history.attempts[0].Status)
+}
+
+func TestPromiseSettler_resumePending(t *testing.T) {
+	history := &mockSettlementHistory{
+		attempts: []SettlementAttempt{
+			{ID: 1, ProviderID: mockID, AccountantID: cfg.AccountantAddress, Status: SettlementStatusSettled},
+			{ID: 2, ProviderID: mockID, AccountantID: cfg.AccountantAddress, Status: SettlementStatusSettling},
+		},
+	}
+	settler := NewAccountantPromiseSettler(eventbus.New(), &mockTransactor{}, &mockAccountantPromiseGetter{}, history, &mockProviderChannelStatusProvider{}, &mockRegistrationStatusProvider{}, nil, cfg)
+
+	settler.resumePending(mockID)
+
+	p := <-settler.settleQueue
+	assert.Equal(t, int64(2), p.attempt.ID)
+	assert.Equal(t, SettlementStatusQueued, p.attempt.Status)
+	assertNoReceive(t, settler.settleQueue)
+}
+
 // mocks start here
 type mockProviderChannelStatusProvider struct {
 	channelToReturn    client.ProviderChannel
@@ -518,3 +665,58 @@ func (mt *mockTransactor) FetchSettleFees() (registry.FeesResponse, error) {
 func (mt *mockTransactor) SettleAndRebalance(id string, promise crypto.Promise) error {
 	return nil
 }
+
+type mockSettlementHistory struct {
+	attempts []SettlementAttempt
+}
+
+func (msh *mockSettlementHistory) Store(attempt *SettlementAttempt) error {
+	attempt.ID = int64(len(msh.attempts) + 1)
+	msh.attempts = append(msh.attempts, *attempt)
+	return nil
+}
+
+func (msh *mockSettlementHistory) Update(attempt *SettlementAttempt) error {
+	for i := range msh.attempts {
+		if msh.attempts[i].ID == attempt.ID {
+			msh.attempts[i] = *attempt
+		}
+	}
+	return nil
+}
+
+func (msh *mockSettlementHistory) List(filter SettlementHistoryFilter) ([]SettlementAttempt, error) {
+	var result []SettlementAttempt
+	for i := len(msh.attempts) - 1; i >= 0; i-- {
+		attempt := msh.attempts[i]
+		if filter.ProviderID != nil && attempt.ProviderID != *filter.ProviderID {
+			continue
+		}
+		if filter.Since != nil && attempt.Created.Before(*filter.Since) {
+			continue
+		}
+		result = append(result, attempt)
+	}
+	return result, nil
+}
+
+func TestPromiseSettler_publishSettlement_reportsPromiseHash(t *testing.T) {
+	bus := eventbus.New()
+	settled := make(chan event.AppEventSettlement, 1)
+	err := bus.Subscribe(event.AppTopicSettlement, func(ev event.AppEventSettlement) {
+		settled <- ev
+	})
+	assert.NoError(t, err)
+
+	promise := crypto.Promise{ChannelID: []byte{1}, Amount: 100, Fee: 10, Hashlock: []byte{2, 3}}
+	settler := &accountantPromiseSettler{eventBus: bus}
+	settler.publishSettlement(receivedPromise{provider: mockID, promise: promise}, nil, nil)
+
+	select {
+	case ev := <-settled:
+		assert.Equal(t, hex.EncodeToString(promise.GetHash()), ev.PromiseHash)
+		assert.Equal(t, uint64(10), ev.Fee)
+	case <-time.After(2 * time.Second):
+		t.Fatal("settlement was not published")
+	}
+}
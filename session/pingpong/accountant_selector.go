@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrAccountantNotAccepted indicates that the accountant is not accepted by one of the session parties.
+var ErrAccountantNotAccepted = errors.New("accountant not accepted")
+
+type accountantFeeProvider interface {
+	GetAccountantFee(accountantAddress common.Address) (uint16, error)
+}
+
+// AccountantSelector chooses the accountant a consumer pays the provider through.
+type AccountantSelector struct {
+	feeProvider accountantFeeProvider
+	accountants []common.Address
+}
+
+// NewAccountantSelector returns a new instance of accountant selector.
+// The first of the given accountants is the default one.
+func NewAccountantSelector(feeProvider accountantFeeProvider, accountants []common.Address) *AccountantSelector {
+	return &AccountantSelector{
+		feeProvider: feeProvider,
+		accountants: accountants,
+	}
+}
+
+// Select returns the requested accountant if the provider accepts it.
+// If no accountant is requested, the accountant with the lowest fee accepted by both the consumer and the provider is returned.
+func (as *AccountantSelector) Select(requested common.Address, proposal market.ServiceProposal) (common.Address, error) {
+	if requested != (common.Address{}) {
+		if !proposal.AcceptsAccountant(requested.Hex()) {
+			return common.Address{}, errors.Wrapf(ErrAccountantNotAccepted, "provider does not accept accountant %v", requested.Hex())
+		}
+		return requested, nil
+	}
+
+	var candidates []common.Address
+	for _, accountant := range as.accountants {
+		if proposal.AcceptsAccountant(accountant.Hex()) {
+			candidates = append(candidates, accountant)
+		}
+	}
+	if len(candidates) == 0 {
+		return common.Address{}, errors.Wrap(ErrAccountantNotAccepted, "provider accepts none of the configured accountants")
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	selected := candidates[0]
+	var lowestFee *uint16
+	for _, accountant := range candidates {
+		fee, err := as.feeProvider.GetAccountantFee(accountant)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Could not get fee of accountant %v, skipping", accountant.Hex())
+			continue
+		}
+		if lowestFee == nil || fee < *lowestFee {
+			selected, lowestFee = accountant, &fee
+		}
+	}
+	log.Info().Msgf("Selected accountant %v for provider %v", selected.Hex(), proposal.ProviderID)
+	return selected, nil
+}
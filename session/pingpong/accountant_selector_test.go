@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAccountantFees map[common.Address]uint16
+
+func (maf mockAccountantFees) GetAccountantFee(accountantAddress common.Address) (uint16, error) {
+	fee, ok := maf[accountantAddress]
+	if !ok {
+		return 0, errors.New("fee unknown")
+	}
+	return fee, nil
+}
+
+func TestAccountantSelector_Select(t *testing.T) {
+	defaultAccountant := common.HexToAddress("0x1")
+	cheapAccountant := common.HexToAddress("0x2")
+	unknownAccountant := common.HexToAddress("0x3")
+	fees := mockAccountantFees{defaultAccountant: 200, cheapAccountant: 100}
+
+	tests := []struct {
+		name        string
+		accountants []common.Address
+		requested   common.Address
+		accepted    []string
+		want        common.Address
+		wantErr     error
+	}{
+		{
+			name:        "returns requested accountant when provider does not list accountants",
+			accountants: []common.Address{defaultAccountant},
+			requested:   unknownAccountant,
+			want:        unknownAccountant,
+		},
+		{
+			name:        "rejects requested accountant not accepted by provider",
+			accountants: []common.Address{defaultAccountant},
+			requested:   unknownAccountant,
+			accepted:    []string{defaultAccountant.Hex()},
+			wantErr:     ErrAccountantNotAccepted,
+		},
+		{
+			name:        "selects default accountant when provider does not list accountants",
+			accountants: []common.Address{defaultAccountant},
+			want:        defaultAccountant,
+		},
+		{
+			name:        "selects cheapest accountant accepted by provider",
+			accountants: []common.Address{defaultAccountant, cheapAccountant},
+			accepted:    []string{defaultAccountant.Hex(), cheapAccountant.Hex()},
+			want:        cheapAccountant,
+		},
+		{
+			name:        "skips accountants with unknown fees",
+			accountants: []common.Address{unknownAccountant, defaultAccountant},
+			accepted:    []string{unknownAccountant.Hex(), defaultAccountant.Hex()},
+			want:        defaultAccountant,
+		},
+		{
+			name:        "fails when provider accepts none of the accountants",
+			accountants: []common.Address{defaultAccountant, cheapAccountant},
+			accepted:    []string{unknownAccountant.Hex()},
+			wantErr:     ErrAccountantNotAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector := NewAccountantSelector(fees, tt.accountants)
+
+			got, err := selector.Select(tt.requested, market.ServiceProposal{AccountantIDs: tt.accepted})
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, errors.Cause(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -32,6 +32,8 @@ const (
 	AppTopicEarningsChanged = "earnings_change"
 	// AppTopicInvoicePaid is a topic for publish events exchange message send to provider as a consumer.
 	AppTopicInvoicePaid = "invoice_paid"
+	// AppTopicSettlement represents a topic to which we send settlement outcomes.
+	AppTopicSettlement = "settlement_complete"
 )
 
 // AppEventAccountantPromise represents the payload that is sent on the AppTopicAccountantPromise.
@@ -41,6 +43,17 @@ type AppEventAccountantPromise struct {
 	ProviderID   identity.Identity
 }
 
+// AppEventSettlement represents the payload that is sent on the AppTopicSettlement once the settlement completes or fails.
+type AppEventSettlement struct {
+	ProviderID   identity.Identity
+	AccountantID common.Address
+	Amount       uint64
+	Fee          uint64
+	PromiseHash  string
+	TxHash       string
+	Error        string
+}
+
 // AppEventBalanceChanged represents a balance change event
 type AppEventBalanceChanged struct {
 	Identity identity.Identity
@@ -50,9 +63,10 @@ type AppEventBalanceChanged struct {
 
 // AppEventEarningsChanged represents a balance change event
 type AppEventEarningsChanged struct {
-	Identity identity.Identity
-	Previous Earnings
-	Current  Earnings
+	Identity     identity.Identity
+	AccountantID common.Address
+	Previous     Earnings
+	Current      Earnings
 }
 
 // Earnings represents current identity earnings
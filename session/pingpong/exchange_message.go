@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/payments/crypto"
+	"github.com/pkg/errors"
+)
+
+// createExchangeMessage creates the exchange message with its promise, both signed by the given signer.
+// It produces the same message as crypto.CreateExchangeMessage, which can only sign with a local keystore.
+func createExchangeMessage(invoice crypto.Invoice, promiseAmount uint64, channelID string, signer identity.Signer) (*crypto.ExchangeMessage, error) {
+	chID, err := decodeHex(channelID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode channel ID")
+	}
+	hashlock, err := decodeHex(invoice.Hashlock)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode hashlock")
+	}
+
+	promise := crypto.Promise{
+		ChannelID: chID,
+		Amount:    promiseAmount,
+		Fee:       invoice.TransactorFee,
+		Hashlock:  hashlock,
+	}
+	promise.Signature, err = signForBC(signer, promise.GetMessage())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not sign promise")
+	}
+
+	message := crypto.ExchangeMessage{
+		Promise:        promise,
+		AgreementID:    invoice.AgreementID,
+		AgreementTotal: invoice.AgreementTotal,
+		Provider:       invoice.Provider,
+	}
+	signature, err := signForBC(signer, message.GetMessage())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not sign exchange message")
+	}
+	message.Signature = hex.EncodeToString(signature)
+
+	return &message, nil
+}
+
+// signForBC signs the message and formats the signature the way the payment contracts expect it.
+func signForBC(signer identity.Signer, message []byte) ([]byte, error) {
+	signature, err := signer.Sign(message)
+	if err != nil {
+		return nil, err
+	}
+
+	signatureBytes := make([]byte, len(signature.Bytes()))
+	copy(signatureBytes, signature.Bytes())
+	if err := crypto.ReformatSignatureVForBC(signatureBytes); err != nil {
+		return nil, err
+	}
+	return signatureBytes, nil
+}
+
+func decodeHex(value string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(value, "0x"))
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/payments/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_createExchangeMessage_MatchesKeystoreSignedMessage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exchange_message_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
+	acc, err := ks.NewAccount("")
+	assert.Nil(t, err)
+	assert.Nil(t, ks.Unlock(acc, ""))
+
+	invoice := crypto.Invoice{
+		AgreementID:    3,
+		AgreementTotal: 15,
+		TransactorFee:  2,
+		Hashlock:       "0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C",
+		Provider:       "0x0000000000000000000000000000000000000001",
+	}
+	channelID := "0x0000000000000000000000000000000000000002"
+
+	want, err := crypto.CreateExchangeMessage(invoice, 17, channelID, ks, acc.Address)
+	assert.Nil(t, err)
+
+	got, err := createExchangeMessage(invoice, 17, channelID, identity.NewSigner(ks, identity.FromAddress(acc.Address.Hex())))
+	assert.Nil(t, err)
+	assert.Equal(t, want, got)
+}
+
+func Test_createExchangeMessage_RejectsInvalidHashlock(t *testing.T) {
+	_, err := createExchangeMessage(crypto.Invoice{Hashlock: "0xnothex"}, 1, "0x02", &identity.SignerFake{})
+	assert.Error(t, err)
+}
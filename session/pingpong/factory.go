@@ -21,17 +21,15 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/mysteriumnetwork/node/communication"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/datasize"
 	"github.com/mysteriumnetwork/node/eventbus"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/market"
 	"github.com/mysteriumnetwork/node/money"
-	"github.com/mysteriumnetwork/node/p2p"
-	"github.com/mysteriumnetwork/node/pb"
 	"github.com/mysteriumnetwork/node/session"
 	"github.com/mysteriumnetwork/node/session/mbtime"
+	"github.com/mysteriumnetwork/node/session/messaging"
 	"github.com/mysteriumnetwork/payments/crypto"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
@@ -99,8 +97,7 @@ func (pm PaymentMethod) GetRate() market.PaymentRate {
 
 // InvoiceFactoryCreator returns a payment engine factory.
 func InvoiceFactoryCreator(
-	dialog communication.Dialog,
-	channel p2p.Channel,
+	messenger messaging.Provider,
 	balanceSendPeriod, promiseTimeout time.Duration,
 	invoiceStorage providerInvoiceStorage,
 	registryAddress string,
@@ -110,18 +107,23 @@ func InvoiceFactoryCreator(
 	blockchainHelper bcHelper,
 	eventBus eventbus.EventBus,
 	proposal market.ServiceProposal,
-	promiseHandler promiseHandler,
+	promiseHandlers AccountantPromiseHandlers,
+	evidence paymentEvidenceRecorder,
 ) func(identity.Identity, identity.Identity, common.Address, string) (session.PaymentEngine, error) {
 	return func(providerID, consumerID identity.Identity, accountantID common.Address, sessionID string) (session.PaymentEngine, error) {
-		exchangeChan, err := exchangeMessageReceiver(dialog, channel)
-		if err != nil {
+		promiseHandler, ok := promiseHandlers[accountantID]
+		if !ok {
+			return nil, errors.Wrapf(ErrAccountantNotAccepted, "accountant %v", accountantID.Hex())
+		}
+		exchangeChan := make(chan crypto.ExchangeMessage, 1)
+		if err := messenger.ReceiveExchangeMessages(exchangeChan); err != nil {
 			return nil, err
 		}
 		timeTracker := session.NewTracker(mbtime.Now)
 		deps := InvoiceTrackerDeps{
 			Proposal:                   proposal,
 			Peer:                       consumerID,
-			PeerInvoiceSender:          NewInvoiceSender(dialog, channel),
+			PeerInvoiceSender:          NewInvoiceSender(messenger),
 			InvoiceStorage:             invoiceStorage,
 			TimeTracker:                &timeTracker,
 			ChargePeriod:               balanceSendPeriod,
@@ -140,65 +142,26 @@ func InvoiceFactoryCreator(
 			SessionID:                  sessionID,
 			PromiseHandler:             promiseHandler,
 			ChannelAddressCalculator:   NewChannelAddressCalculator(accountantID.Hex(), channelImplementationAddress, registryAddress),
+			EvidenceRecorder:           evidence,
 		}
 		paymentEngine := NewInvoiceTracker(deps)
 		return paymentEngine, nil
 	}
 }
 
-func exchangeMessageReceiver(dialog communication.Dialog, channel p2p.ChannelHandler) (chan crypto.ExchangeMessage, error) {
-	exchangeChan := make(chan crypto.ExchangeMessage, 1)
-
-	if channel == nil { // TODO this block should go away once p2p communication will replace communication dialog.
-		listener := NewExchangeListener(exchangeChan)
-		if err := dialog.Receive(listener.GetConsumer()); err != nil {
-			return nil, err
-		}
-
-		return exchangeChan, nil
-	}
-
-	channel.Handle(p2p.TopicPaymentMessage, func(c p2p.Context) error {
-		var msg pb.ExchangeMessage
-		if err := c.Request().UnmarshalProto(&msg); err != nil {
-			return err
-		}
-		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicPaymentMessage, msg.String())
-
-		exchangeChan <- crypto.ExchangeMessage{
-			Promise: crypto.Promise{
-				ChannelID: msg.GetPromise().GetChannelID(),
-				Amount:    msg.GetPromise().GetAmount(),
-				Fee:       msg.GetPromise().GetFee(),
-				Hashlock:  msg.GetPromise().GetHashlock(),
-				R:         msg.GetPromise().GetR(),
-				Signature: msg.GetPromise().GetSignature(),
-			},
-			AgreementID:    msg.GetAgreementID(),
-			AgreementTotal: msg.GetAgreementTotal(),
-			Provider:       msg.GetProvider(),
-			Signature:      msg.GetSignature(),
-		}
-
-		return nil
-	})
-
-	return exchangeChan, nil
-}
-
 // ExchangeFactoryFunc returns a backwards compatible version of the exchange factory.
 func ExchangeFactoryFunc(
-	keystore *identity.Keystore,
 	signer identity.SignerFactory,
 	totalStorage consumerTotalsStorage,
 	channelImplementation string,
 	registryAddress string,
 	eventBus eventbus.EventBus,
-	dataLeewayMegabytes uint64) func(paymentInfo session.PaymentInfo,
-	dialog communication.Dialog, channel p2p.Channel,
+	dataLeewayMegabytes uint64,
+	evidence paymentEvidenceRecorder) func(paymentInfo session.PaymentInfo,
+	messenger messaging.Consumer,
 	consumer, provider identity.Identity, accountant common.Address, proposal market.ServiceProposal, sessionID string) (connection.PaymentIssuer, error) {
 	return func(paymentInfo session.PaymentInfo,
-		dialog communication.Dialog, channel p2p.Channel,
+		messenger messaging.Consumer,
 		consumer, provider identity.Identity, accountant common.Address, proposal market.ServiceProposal, sessionID string) (connection.PaymentIssuer, error) {
 
 		if paymentInfo.Supports != string(session.PaymentVersionV3) {
@@ -207,17 +170,17 @@ func ExchangeFactoryFunc(
 		}
 
 		log.Info().Msg("Using new payments")
-		invoices, err := invoiceReceiver(dialog, channel)
-		if err != nil {
+		invoices := make(chan crypto.Invoice)
+		if err := messenger.ReceiveInvoices(invoices); err != nil {
 			return nil, err
 		}
 		timeTracker := session.NewTracker(mbtime.Now)
 		deps := InvoicePayerDeps{
 			InvoiceChan:               invoices,
-			PeerExchangeMessageSender: NewExchangeSender(dialog, channel),
+			PeerExchangeMessageSender: NewExchangeSender(messenger),
 			ConsumerTotalsStorage:     totalStorage,
 			TimeTracker:               &timeTracker,
-			Ks:                        keystore,
+			Signer:                    signer(consumer),
 			Identity:                  consumer,
 			Peer:                      provider,
 			Proposal:                  proposal,
@@ -226,39 +189,8 @@ func ExchangeFactoryFunc(
 			AccountantAddress:         accountant,
 			SessionID:                 sessionID,
 			DataLeeway:                datasize.MiB * datasize.BitSize(dataLeewayMegabytes),
+			EvidenceRecorder:          evidence,
 		}
 		return NewInvoicePayer(deps), nil
 	}
 }
-
-func invoiceReceiver(dialog communication.Dialog, channel p2p.ChannelHandler) (chan crypto.Invoice, error) {
-	invoices := make(chan crypto.Invoice)
-	if channel == nil { // TODO this block should go away once p2p communication will replace communication dialog.
-		listener := NewInvoiceListener(invoices)
-		err := dialog.Receive(listener.GetConsumer())
-		if err != nil {
-			return nil, err
-		}
-		return invoices, nil
-	}
-
-	channel.Handle(p2p.TopicPaymentInvoice, func(c p2p.Context) error {
-		var msg pb.Invoice
-		if err := c.Request().UnmarshalProto(&msg); err != nil {
-			return err
-		}
-		log.Debug().Msgf("Received P2P message for %q: %s", p2p.TopicPaymentInvoice, msg.String())
-
-		invoices <- crypto.Invoice{
-			AgreementID:    msg.GetAgreementID(),
-			AgreementTotal: msg.GetAgreementTotal(),
-			TransactorFee:  msg.GetTransactorFee(),
-			Hashlock:       msg.GetHashlock(),
-			Provider:       msg.GetProvider(),
-		}
-
-		return nil
-	})
-
-	return invoices, nil
-}
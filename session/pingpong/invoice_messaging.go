@@ -18,117 +18,21 @@
 package pingpong
 
 import (
-	"context"
-	"time"
-
-	"github.com/mysteriumnetwork/node/communication"
-	"github.com/mysteriumnetwork/node/p2p"
-	"github.com/mysteriumnetwork/node/pb"
+	"github.com/mysteriumnetwork/node/session/messaging"
 	"github.com/mysteriumnetwork/payments/crypto"
-	"github.com/rs/zerolog/log"
 )
 
-// InvoiceRequest structure represents the invoice message that the provider sends to the consumer.
-type InvoiceRequest struct {
-	Invoice crypto.Invoice `json:"invoice"`
-}
-
-const invoiceEndpoint = "session-invoice"
-const invoiceMessageEndpoint = communication.MessageEndpoint(invoiceEndpoint)
-
 // InvoiceSender is responsible for sending the invoice messages.
 type InvoiceSender struct {
-	ch     p2p.ChannelSender
-	sender communication.Sender
+	messenger messaging.Provider
 }
 
 // NewInvoiceSender returns a new instance of the invoice sender.
-func NewInvoiceSender(sender communication.Sender, ch p2p.ChannelSender) *InvoiceSender {
-	return &InvoiceSender{
-		ch:     ch,
-		sender: sender,
-	}
+func NewInvoiceSender(messenger messaging.Provider) *InvoiceSender {
+	return &InvoiceSender{messenger: messenger}
 }
 
 // Send sends the given invoice.
 func (is *InvoiceSender) Send(invoice crypto.Invoice) error {
-	if is.ch == nil { // TODO this block should go away once p2p communication will replace communication dialog.
-		return is.sender.Send(&invoiceMessageProducer{Invoice: invoice})
-	}
-
-	pInvoice := &pb.Invoice{
-		AgreementID:    invoice.AgreementID,
-		AgreementTotal: invoice.AgreementTotal,
-		TransactorFee:  invoice.TransactorFee,
-		Hashlock:       invoice.Hashlock,
-		Provider:       invoice.Provider,
-	}
-	log.Debug().Msgf("Sending P2P message to %q: %s", p2p.TopicPaymentInvoice, pInvoice.String())
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	_, err := is.ch.Send(ctx, p2p.TopicPaymentInvoice, p2p.ProtoMessage(pInvoice))
-	return err
-}
-
-// InvoiceListener listens for invoices.
-type InvoiceListener struct {
-	invoiceMessageConsumer *invoiceMessageConsumer
-}
-
-// NewInvoiceListener returns a new instance of the invoice listener.
-func NewInvoiceListener(messageChan chan crypto.Invoice) *InvoiceListener {
-	return &InvoiceListener{
-		invoiceMessageConsumer: &invoiceMessageConsumer{
-			queue: messageChan,
-		},
-	}
-}
-
-// GetConsumer returns the underlying invoice message consumer. Mostly here for the communication to work.
-func (il *InvoiceListener) GetConsumer() *invoiceMessageConsumer {
-	return il.invoiceMessageConsumer
-}
-
-// Consume handles requests from endpoint and replies with response.
-func (imc *invoiceMessageConsumer) Consume(requestPtr interface{}) (err error) {
-	request := requestPtr.(*InvoiceRequest)
-	imc.queue <- request.Invoice
-	return nil
-}
-
-// Dialog boilerplate below, please ignore.
-type invoiceMessageConsumer struct {
-	queue chan crypto.Invoice
-}
-
-// GetMessageEndpoint returns endpoint where to receive messages.
-func (imc *invoiceMessageConsumer) GetMessageEndpoint() communication.MessageEndpoint {
-	return invoiceMessageEndpoint
-}
-
-// NewRequest creates struct where request from endpoint will be serialized.
-func (imc *invoiceMessageConsumer) NewMessage() (requestPtr interface{}) {
-	return &InvoiceRequest{}
-}
-
-// invoiceMessageProducer.
-type invoiceMessageProducer struct {
-	Invoice crypto.Invoice
-}
-
-// GetMessageEndpoint returns endpoint where to receive messages.
-func (imp *invoiceMessageProducer) GetMessageEndpoint() communication.MessageEndpoint {
-	return invoiceMessageEndpoint
-}
-
-// Produce produces a request message.
-func (imp *invoiceMessageProducer) Produce() (requestPtr interface{}) {
-	return &InvoiceRequest{
-		Invoice: imp.Invoice,
-	}
-}
-
-// NewResponse returns a new response object.
-func (imp *invoiceMessageProducer) NewResponse() (responsePtr interface{}) {
-	return nil
+	return is.messenger.SendInvoice(invoice)
 }
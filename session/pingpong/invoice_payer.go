@@ -93,7 +93,7 @@ type InvoicePayerDeps struct {
 	PeerExchangeMessageSender PeerExchangeMessageSender
 	ConsumerTotalsStorage     consumerTotalsStorage
 	TimeTracker               timeTracker
-	Ks                        *identity.Keystore
+	Signer                    identity.Signer
 	Identity, Peer            identity.Identity
 	Proposal                  market.ServiceProposal
 	SessionID                 string
@@ -101,6 +101,7 @@ type InvoicePayerDeps struct {
 	EventBus                  eventbus.EventBus
 	AccountantAddress         common.Address
 	DataLeeway                datasize.BitSize
+	EvidenceRecorder          paymentEvidenceRecorder
 }
 
 // NewInvoicePayer returns a new instance of exchange message tracker.
@@ -117,6 +118,15 @@ var ErrInvoiceMissmatch = errors.New("invoice mismatch")
 
 // Start starts the message exchange tracker. Blocks.
 func (ip *InvoicePayer) Start() error {
+	session := newEvidenceSession(EvidenceRoleConsumer, ip.deps.SessionID, ip.deps.Proposal, ip.deps.Identity, ip.deps.Peer, ip.deps.AccountantAddress)
+	session.DataLeewayBytes = ip.deps.DataLeeway.Bytes()
+	startEvidence(ip.deps.EvidenceRecorder, session)
+	err := ip.start()
+	endEvidence(ip.deps.EvidenceRecorder, ip.deps.SessionID, err)
+	return err
+}
+
+func (ip *InvoicePayer) start() error {
 	log.Debug().Msg("Starting...")
 	addr, err := ip.deps.ChannelAddressCalculator.GetChannelAddress(ip.deps.Identity)
 	if err != nil {
@@ -137,6 +147,7 @@ func (ip *InvoicePayer) Start() error {
 			return nil
 		case invoice := <-ip.deps.InvoiceChan:
 			log.Debug().Msgf("Invoice received: %v", invoice)
+			ip.recordEvidence(EvidenceRecord{Kind: EvidenceKindInvoice, Invoice: &invoice})
 			err := ip.isInvoiceOK(invoice)
 			if err != nil {
 				return errors.Wrap(err, "invoice not valid")
@@ -152,6 +163,17 @@ func (ip *InvoicePayer) Start() error {
 	}
 }
 
+func (ip *InvoicePayer) recordEvidence(record EvidenceRecord) {
+	if ip.deps.EvidenceRecorder == nil {
+		return
+	}
+
+	record.SessionID = ip.deps.SessionID
+	record.DataTransferred = ip.getDataTransferred()
+	record.Elapsed = ip.deps.TimeTracker.Elapsed()
+	recordEvidence(ip.deps.EvidenceRecorder, record)
+}
+
 func (ip *InvoicePayer) incrementGrandTotalPromised(amount uint64) error {
 	res, err := ip.deps.ConsumerTotalsStorage.Get(ip.deps.Identity, ip.deps.AccountantAddress)
 	if err != nil {
@@ -238,7 +260,7 @@ func (ip *InvoicePayer) issueExchangeMessage(invoice crypto.Invoice) error {
 		return errors.Wrap(err, "could not calculate amount to promise")
 	}
 
-	msg, err := crypto.CreateExchangeMessage(invoice, amountToPromise, ip.channelAddress.Address, ip.deps.Ks, common.HexToAddress(ip.deps.Identity.Address))
+	msg, err := createExchangeMessage(invoice, amountToPromise, ip.channelAddress.Address, ip.deps.Signer)
 	if err != nil {
 		return errors.Wrap(err, "could not create exchange message")
 	}
@@ -247,6 +269,7 @@ func (ip *InvoicePayer) issueExchangeMessage(invoice crypto.Invoice) error {
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to send exchange message")
 	}
+	ip.recordEvidence(EvidenceRecord{Kind: EvidenceKindExchangeMessage, ExchangeMessage: msg})
 
 	ip.deps.EventBus.Publish(event.AppTopicInvoicePaid, event.AppEventInvoicePaid{
 		ConsumerID: ip.deps.Identity,
@@ -19,6 +19,7 @@ package pingpong
 
 import (
 	"io/ioutil"
+	"net/http/httptest"
 	"os"
 	"sync"
 	"testing"
@@ -76,7 +77,7 @@ func Test_InvoicePayer_Start_Stop(t *testing.T) {
 		PeerExchangeMessageSender: mockSender,
 		ConsumerTotalsStorage:     totalsStorage,
 		TimeTracker:               &tracker,
-		Ks:                        ks,
+		Signer:                    identity.NewSigner(ks, identity.FromAddress(acc.Address.Hex())),
 		ChannelAddressCalculator:  NewChannelAddressCalculator(acc.Address.Hex(), acc.Address.Hex(), acc.Address.Hex()),
 		Identity:                  identity.FromAddress(acc.Address.Hex()),
 		Peer:                      identity.FromAddress("0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C"),
@@ -129,7 +130,7 @@ func Test_InvoicePayer_SendsMessage(t *testing.T) {
 		ConsumerTotalsStorage:     totalsStorage,
 		TimeTracker:               &tracker,
 		EventBus:                  mocks.NewEventBus(),
-		Ks:                        ks,
+		Signer:                    identity.NewSigner(ks, identity.FromAddress(acc.Address.Hex())),
 		ChannelAddressCalculator:  NewChannelAddressCalculator(acc.Address.Hex(), acc.Address.Hex(), acc.Address.Hex()),
 		Identity:                  identity.FromAddress(acc.Address.Hex()),
 		Peer:                      identity.FromAddress("0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C"),
@@ -172,6 +173,78 @@ func Test_InvoicePayer_SendsMessage(t *testing.T) {
 	<-testDone
 }
 
+func Test_InvoicePayer_SendsMessageSignedByExternalSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exchange_message_tracker_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// the node holds no keystore, the stand-in signer is the only one with the key
+	signerServer, err := identity.NewMockExternalSignerServer(&identity.MockExternalSigner{
+		PkHex: "6f88637b68ee88816e73f663aef709d7009836c98ae91ef31e3dfac7be3a1657",
+	})
+	assert.Nil(t, err)
+	server := httptest.NewServer(signerServer)
+	defer server.Close()
+	signerFactory, err := identity.NewExternalSignerFactory(server.URL, time.Second)
+	assert.Nil(t, err)
+	consumer := identity.FromAddress("0x53a835143c0ef3bbcbfa796d7eb738ca7dd28f68")
+
+	mockSender := &MockPeerExchangeMessageSender{
+		chanToWriteTo: make(chan crypto.ExchangeMessage, 10),
+	}
+
+	invoiceChan := make(chan crypto.Invoice)
+	bolt, err := boltdb.NewStorage(dir)
+	assert.Nil(t, err)
+	defer bolt.Close()
+
+	tracker := session.NewTracker(mbtime.Now)
+	totalsStorage := NewConsumerTotalsStorage(bolt, eventbus.New())
+	totalsStorage.Store(consumer, common.Address{}, 10)
+	deps := InvoicePayerDeps{
+		InvoiceChan:               invoiceChan,
+		PeerExchangeMessageSender: mockSender,
+		ConsumerTotalsStorage:     totalsStorage,
+		TimeTracker:               &tracker,
+		EventBus:                  mocks.NewEventBus(),
+		Signer:                    signerFactory(consumer),
+		ChannelAddressCalculator:  NewChannelAddressCalculator(consumer.Address, consumer.Address, consumer.Address),
+		Identity:                  consumer,
+		Peer:                      identity.FromAddress("0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C"),
+		Proposal: market.ServiceProposal{
+			PaymentMethod: &mockPaymentMethod{
+				price: money.NewMoney(10, money.CurrencyMyst),
+				rate:  market.PaymentRate{PerTime: time.Minute},
+			},
+		},
+	}
+	InvoicePayer := NewInvoicePayer(deps)
+
+	testDone := make(chan struct{})
+	defer InvoicePayer.Stop()
+	go func() {
+		err := InvoicePayer.Start()
+		assert.Nil(t, err)
+		testDone <- struct{}{}
+	}()
+
+	invoiceChan <- crypto.Invoice{
+		AgreementID:    1,
+		AgreementTotal: 0,
+		Hashlock:       "0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C",
+		Provider:       deps.Peer.Address,
+	}
+
+	exchangeMessage := <-mockSender.chanToWriteTo
+	InvoicePayer.Stop()
+
+	assert.True(t, exchangeMessage.IsMessageValid(consumer.ToCommonAddress()))
+	assert.True(t, exchangeMessage.Promise.IsPromiseValid(consumer.ToCommonAddress()))
+	assert.Equal(t, uint64(10), exchangeMessage.Promise.Amount)
+
+	<-testDone
+}
+
 func Test_InvoicePayer_SendsMessage_OnFreeService(t *testing.T) {
 	dir, err := ioutil.TempDir("", "exchange_message_tracker_test")
 	assert.Nil(t, err)
@@ -202,7 +275,7 @@ func Test_InvoicePayer_SendsMessage_OnFreeService(t *testing.T) {
 		ConsumerTotalsStorage:     totalsStorage,
 		TimeTracker:               &tracker,
 		EventBus:                  mocks.NewEventBus(),
-		Ks:                        ks,
+		Signer:                    identity.NewSigner(ks, identity.FromAddress(acc.Address.Hex())),
 		ChannelAddressCalculator:  NewChannelAddressCalculator(acc.Address.Hex(), acc.Address.Hex(), acc.Address.Hex()),
 		Identity:                  identity.FromAddress(acc.Address.Hex()),
 		Peer:                      identity.FromAddress("0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C"),
@@ -264,7 +337,7 @@ func Test_InvoicePayer_BubblesErrors(t *testing.T) {
 		PeerExchangeMessageSender: mockSender,
 		ConsumerTotalsStorage:     totalsStorage,
 		TimeTracker:               &tracker,
-		Ks:                        ks,
+		Signer:                    identity.NewSigner(ks, identity.FromAddress(acc.Address.Hex())),
 		ChannelAddressCalculator:  NewChannelAddressCalculator(acc.Address.Hex(), acc.Address.Hex(), acc.Address.Hex()),
 		Identity:                  identity.FromAddress(acc.Address.Hex()),
 		Peer:                      identity.FromAddress("0x441Da57A51e42DAB7Daf55909Af93A9b00eEF23C"),
@@ -550,7 +623,7 @@ func TestInvoicePayer_issueExchangeMessage_publishesEvents(t *testing.T) {
 			ConsumerTotalsStorage: &mockConsumerTotalsStorage{
 				bus: mp,
 			},
-			Ks:        ks,
+			Signer:    identity.NewSigner(ks, identity.FromAddress(acc.Address.Hex())),
 			EventBus:  mp,
 			Identity:  identity.FromAddress(acc.Address.Hex()),
 			Peer:      peerID,
@@ -601,7 +674,6 @@ func TestInvoicePayer_issueExchangeMessage(t *testing.T) {
 
 	type fields struct {
 		peerExchangeMessageSender *MockPeerExchangeMessageSender
-		keystore                  *identity.Keystore
 		identity                  identity.Identity
 		peer                      identity.Identity
 		lastInvoice               crypto.Invoice
@@ -622,7 +694,6 @@ func TestInvoicePayer_issueExchangeMessage(t *testing.T) {
 			fields: fields{
 				identity: identity.FromAddress(""),
 				peer:     peerID,
-				keystore: ks,
 				peerExchangeMessageSender: &MockPeerExchangeMessageSender{
 					chanToWriteTo: make(chan crypto.ExchangeMessage, 10),
 				},
@@ -637,7 +708,6 @@ func TestInvoicePayer_issueExchangeMessage(t *testing.T) {
 			fields: fields{
 				identity: identity.FromAddress(acc.Address.Hex()),
 				peer:     peerID,
-				keystore: ks,
 				peerExchangeMessageSender: &MockPeerExchangeMessageSender{
 					chanToWriteTo: make(chan crypto.ExchangeMessage, 10),
 					mockError:     errors.New("explosions everywhere"),
@@ -653,7 +723,6 @@ func TestInvoicePayer_issueExchangeMessage(t *testing.T) {
 			fields: fields{
 				identity: identity.FromAddress(acc.Address.Hex()),
 				peer:     peerID,
-				keystore: ks,
 				peerExchangeMessageSender: &MockPeerExchangeMessageSender{
 					chanToWriteTo: make(chan crypto.ExchangeMessage, 10),
 				},
@@ -677,7 +746,7 @@ func TestInvoicePayer_issueExchangeMessage(t *testing.T) {
 					PeerExchangeMessageSender: tt.fields.peerExchangeMessageSender,
 					ConsumerTotalsStorage:     tt.fields.consumerTotalsStorage,
 					Peer:                      tt.fields.peer,
-					Ks:                        tt.fields.keystore,
+					Signer:                    identity.NewSigner(ks, tt.fields.identity),
 					Identity:                  tt.fields.identity,
 					EventBus:                  mocks.NewEventBus(),
 				},
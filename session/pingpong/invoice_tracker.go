@@ -149,6 +149,7 @@ type InvoiceTrackerDeps struct {
 	ChannelAddressCalculator   channelAddressCalculator
 	SessionID                  string
 	PromiseHandler             promiseHandler
+	EvidenceRecorder           paymentEvidenceRecorder
 }
 
 // NewInvoiceTracker creates a new instance of invoice tracker.
@@ -208,6 +209,20 @@ func (it *InvoiceTracker) listenForExchangeMessages() error {
 	}
 }
 
+func (it *InvoiceTracker) recordExchangeMessage(em crypto.ExchangeMessage) {
+	if it.deps.EvidenceRecorder == nil {
+		return
+	}
+
+	recordEvidence(it.deps.EvidenceRecorder, EvidenceRecord{
+		SessionID:       it.deps.SessionID,
+		Kind:            EvidenceKindExchangeMessage,
+		ExchangeMessage: &em,
+		DataTransferred: it.getDataTransferred(),
+		Elapsed:         it.deps.TimeTracker.Elapsed(),
+	})
+}
+
 func (it *InvoiceTracker) generateAgreementID() {
 	it.rnd.Seed(time.Now().UnixNano())
 	it.agreementID = it.rnd.Uint64()
@@ -220,6 +235,8 @@ func (it *InvoiceTracker) handleExchangeMessage(em crypto.ExchangeMessage) error
 		return ErrInvoiceExpired
 	}
 
+	it.recordExchangeMessage(em)
+
 	err := it.validateExchangeMessage(em)
 	if err != nil {
 		return err
@@ -247,6 +264,13 @@ func (it *InvoiceTracker) handleExchangeMessage(em crypto.ExchangeMessage) error
 
 // Start stars the invoice tracker
 func (it *InvoiceTracker) Start() error {
+	startEvidence(it.deps.EvidenceRecorder, newEvidenceSession(EvidenceRoleProvider, it.deps.SessionID, it.deps.Proposal, it.deps.Peer, it.deps.ProviderID, it.deps.AccountantID))
+	err := it.start()
+	endEvidence(it.deps.EvidenceRecorder, it.deps.SessionID, err)
+	return err
+}
+
+func (it *InvoiceTracker) start() error {
 	log.Debug().Msg("Starting...")
 	it.deps.TimeTracker.StartTracking()
 
@@ -364,7 +388,9 @@ func (it *InvoiceTracker) sendInvoice() error {
 		return ErrExchangeWaitTimeout
 	}
 
-	shouldBe := CalculatePaymentAmount(it.deps.TimeTracker.Elapsed(), it.getDataTransferred(), it.deps.Proposal.PaymentMethod)
+	elapsed := it.deps.TimeTracker.Elapsed()
+	transferred := it.getDataTransferred()
+	shouldBe := CalculatePaymentAmount(elapsed, transferred, it.deps.Proposal.PaymentMethod)
 
 	// In case we're sending a first invoice, there might be a big missmatch percentage wise on the consumer side.
 	// This is due to the fact that both payment providers start at different times.
@@ -392,6 +418,13 @@ func (it *InvoiceTracker) sendInvoice() error {
 		invoice: invoice,
 		r:       r,
 	})
+	recordEvidence(it.deps.EvidenceRecorder, EvidenceRecord{
+		SessionID:       it.deps.SessionID,
+		Kind:            EvidenceKindInvoice,
+		Invoice:         &invoice,
+		DataTransferred: transferred,
+		Elapsed:         elapsed,
+	})
 
 	hlock, err := hex.DecodeString(invoice.Hashlock)
 	if err != nil {
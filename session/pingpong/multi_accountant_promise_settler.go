@@ -0,0 +1,89 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/pingpong/event"
+	"github.com/pkg/errors"
+)
+
+// multiAccountantPromiseSettler settles the promises of every accountant the provider works with.
+type multiAccountantPromiseSettler struct {
+	defaultAccountant common.Address
+	settlers          map[common.Address]AccountantPromiseSettler
+}
+
+// NewMultiAccountantPromiseSettler creates a promise settler which delegates to the settler of a particular accountant.
+// Settlement history is expected to be shared by all the settlers, it is read from the settler of the default accountant.
+func NewMultiAccountantPromiseSettler(defaultAccountant common.Address, settlers map[common.Address]AccountantPromiseSettler) *multiAccountantPromiseSettler {
+	return &multiAccountantPromiseSettler{
+		defaultAccountant: defaultAccountant,
+		settlers:          settlers,
+	}
+}
+
+// Subscribe subscribes settlers of all the accountants to the appropriate events
+func (maps *multiAccountantPromiseSettler) Subscribe() error {
+	for accountantID, settler := range maps.settlers {
+		if err := settler.Subscribe(); err != nil {
+			return errors.Wrapf(err, "could not subscribe settler of accountant %v", accountantID.Hex())
+		}
+	}
+	return nil
+}
+
+// GetEarnings returns current settlement status for given identity summed across all the accountants
+func (maps *multiAccountantPromiseSettler) GetEarnings(id identity.Identity) event.Earnings {
+	var total event.Earnings
+	for _, earnings := range maps.GetEarningsByAccountant(id) {
+		total.LifetimeBalance += earnings.LifetimeBalance
+		total.UnsettledBalance += earnings.UnsettledBalance
+	}
+	return total
+}
+
+// GetEarningsByAccountant returns current settlement status for given identity keyed by accountant
+func (maps *multiAccountantPromiseSettler) GetEarningsByAccountant(id identity.Identity) map[common.Address]event.Earnings {
+	result := make(map[common.Address]event.Earnings, len(maps.settlers))
+	for _, settler := range maps.settlers {
+		for accountantID, earnings := range settler.GetEarningsByAccountant(id) {
+			result[accountantID] = earnings
+		}
+	}
+	return result
+}
+
+// ForceSettle forces the settlement for a provider with the given accountant
+func (maps *multiAccountantPromiseSettler) ForceSettle(providerID identity.Identity, accountantID common.Address) error {
+	settler, ok := maps.settlers[accountantID]
+	if !ok {
+		return errors.Wrapf(ErrAccountantNotAccepted, "accountant %v", accountantID.Hex())
+	}
+	return settler.ForceSettle(providerID, accountantID)
+}
+
+// SettlementHistory returns settlement attempts matching the filter, newest first
+func (maps *multiAccountantPromiseSettler) SettlementHistory(filter SettlementHistoryFilter) ([]SettlementAttempt, error) {
+	settler, ok := maps.settlers[maps.defaultAccountant]
+	if !ok {
+		return nil, errors.Wrapf(ErrAccountantNotAccepted, "accountant %v", maps.defaultAccountant.Hex())
+	}
+	return settler.SettlementHistory(filter)
+}
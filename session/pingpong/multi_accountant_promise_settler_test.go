@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/pingpong/event"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSingleAccountantSettler struct {
+	accountantID common.Address
+	earnings     event.Earnings
+	settled      []identity.Identity
+}
+
+func (mss *mockSingleAccountantSettler) GetEarnings(_ identity.Identity) event.Earnings {
+	return mss.earnings
+}
+
+func (mss *mockSingleAccountantSettler) GetEarningsByAccountant(id identity.Identity) map[common.Address]event.Earnings {
+	return map[common.Address]event.Earnings{mss.accountantID: mss.GetEarnings(id)}
+}
+
+func (mss *mockSingleAccountantSettler) ForceSettle(providerID identity.Identity, _ common.Address) error {
+	mss.settled = append(mss.settled, providerID)
+	return nil
+}
+
+func (mss *mockSingleAccountantSettler) SettlementHistory(_ SettlementHistoryFilter) ([]SettlementAttempt, error) {
+	return []SettlementAttempt{{AccountantID: mss.accountantID}}, nil
+}
+
+func (mss *mockSingleAccountantSettler) Subscribe() error {
+	return nil
+}
+
+func TestMultiAccountantPromiseSettler(t *testing.T) {
+	provider := identity.FromAddress("0x1")
+	first := &mockSingleAccountantSettler{accountantID: common.HexToAddress("0x2"), earnings: event.Earnings{LifetimeBalance: 10, UnsettledBalance: 5}}
+	second := &mockSingleAccountantSettler{accountantID: common.HexToAddress("0x3"), earnings: event.Earnings{LifetimeBalance: 20, UnsettledBalance: 1}}
+	settler := NewMultiAccountantPromiseSettler(first.accountantID, map[common.Address]AccountantPromiseSettler{
+		first.accountantID:  first,
+		second.accountantID: second,
+	})
+
+	assert.NoError(t, settler.Subscribe())
+	assert.Equal(t, event.Earnings{LifetimeBalance: 30, UnsettledBalance: 6}, settler.GetEarnings(provider))
+	assert.Equal(t, map[common.Address]event.Earnings{
+		first.accountantID:  first.earnings,
+		second.accountantID: second.earnings,
+	}, settler.GetEarningsByAccountant(provider))
+
+	assert.NoError(t, settler.ForceSettle(provider, second.accountantID))
+	assert.Empty(t, first.settled)
+	assert.Equal(t, []identity.Identity{provider}, second.settled)
+
+	err := settler.ForceSettle(provider, common.HexToAddress("0x4"))
+	assert.Equal(t, ErrAccountantNotAccepted, errors.Cause(err))
+
+	history, err := settler.SettlementHistory(SettlementHistoryFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []SettlementAttempt{{AccountantID: first.accountantID}}, history)
+}
@@ -20,6 +20,7 @@ package noop
 import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/pingpong"
 	"github.com/mysteriumnetwork/node/session/pingpong/event"
 )
 
@@ -37,7 +38,17 @@ func (n *NoopAccountantPromiseSettler) GetEarnings(_ identity.Identity) event.Ea
 	return event.Earnings{}
 }
 
+// GetEarningsByAccountant returns an empty state.
+func (n *NoopAccountantPromiseSettler) GetEarningsByAccountant(_ identity.Identity) map[common.Address]event.Earnings {
+	return nil
+}
+
 // ForceSettle does nothing.
 func (n *NoopAccountantPromiseSettler) ForceSettle(_ identity.Identity, _ common.Address) error {
 	return nil
 }
+
+// SettlementHistory returns an empty history.
+func (n *NoopAccountantPromiseSettler) SettlementHistory(_ pingpong.SettlementHistoryFilter) ([]pingpong.SettlementAttempt, error) {
+	return nil, nil
+}
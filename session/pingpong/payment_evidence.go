@@ -0,0 +1,404 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/payments/crypto"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	evidenceSessionBucketName = "payment-evidence-sessions"
+	evidenceRecordBucketName  = "payment-evidence-records"
+)
+
+// EvidenceRole describes which side of the session recorded the evidence.
+type EvidenceRole string
+
+const (
+	// EvidenceRoleProvider is used for evidence recorded by the provider.
+	EvidenceRoleProvider EvidenceRole = "provider"
+	// EvidenceRoleConsumer is used for evidence recorded by the consumer.
+	EvidenceRoleConsumer EvidenceRole = "consumer"
+)
+
+// EvidenceKind describes the payment message recorded.
+type EvidenceKind string
+
+const (
+	// EvidenceKindInvoice is used for invoices sent by the provider.
+	EvidenceKindInvoice EvidenceKind = "invoice"
+	// EvidenceKindExchangeMessage is used for exchange messages sent by the consumer.
+	EvidenceKindExchangeMessage EvidenceKind = "exchange_message"
+)
+
+// PaymentEvidenceSession holds the payment terms agreed for the session.
+type PaymentEvidenceSession struct {
+	SessionID         string             `storm:"id" json:"session_id"`
+	Role              EvidenceRole       `json:"role"`
+	ConsumerID        string             `json:"consumer_id"`
+	ProviderID        string             `json:"provider_id"`
+	AccountantID      string             `json:"accountant_id"`
+	PaymentMethodType string             `json:"payment_method_type"`
+	Price             money.Money        `json:"price"`
+	Rate              market.PaymentRate `json:"rate"`
+	// DataLeewayBytes is the amount of data the consumer allows the provider to charge for on top of the measured traffic.
+	DataLeewayBytes uint64    `json:"data_leeway_bytes,omitempty"`
+	Started         time.Time `json:"started"`
+	Ended           time.Time `json:"ended"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// EvidenceRecord is a payment message exchanged during the session together with the usage measured at that moment.
+type EvidenceRecord struct {
+	ID              int64                   `storm:"id,increment" json:"id"`
+	SessionID       string                  `json:"session_id"`
+	Kind            EvidenceKind            `json:"kind"`
+	Invoice         *crypto.Invoice         `json:"invoice,omitempty"`
+	ExchangeMessage *crypto.ExchangeMessage `json:"exchange_message,omitempty"`
+	DataTransferred DataTransferred         `json:"data_transferred"`
+	Elapsed         time.Duration           `json:"elapsed"`
+	Created         time.Time               `json:"created"`
+}
+
+type paymentEvidenceRecorder interface {
+	StartSession(session PaymentEvidenceSession) error
+	Record(record EvidenceRecord) error
+	EndSession(sessionID string, cause error) error
+}
+
+// paymentEvidenceStorer allows us to store and read the payment evidence.
+type paymentEvidenceStorer interface {
+	Store(bucket string, object interface{}) error
+	Update(bucket string, object interface{}) error
+	GetAllFrom(bucket string, array interface{}) error
+	GetOneByField(bucket string, fieldName string, key interface{}, to interface{}) error
+}
+
+// PaymentEvidenceStorage persists the payment messages of sessions, so that billing disputes could be resolved later.
+type PaymentEvidenceStorage struct {
+	bolt paymentEvidenceStorer
+	lock sync.Mutex
+}
+
+// NewPaymentEvidenceStorage creates a new instance of payment evidence storage.
+func NewPaymentEvidenceStorage(bolt paymentEvidenceStorer) *PaymentEvidenceStorage {
+	return &PaymentEvidenceStorage{
+		bolt: bolt,
+	}
+}
+
+// PaymentEvidenceRetentionPolicies return the policies dropping payment evidence older than maxAge.
+func PaymentEvidenceRetentionPolicies(maxAge time.Duration) []storage.RetentionPolicy {
+	return []storage.RetentionPolicy{
+		{Bucket: evidenceSessionBucketName, Kind: &PaymentEvidenceSession{}, Field: "Started", MaxAge: maxAge},
+		{Bucket: evidenceRecordBucketName, Kind: &EvidenceRecord{}, Field: "Created", MaxAge: maxAge},
+	}
+}
+
+// StartSession saves the payment terms of a new session.
+func (pes *PaymentEvidenceStorage) StartSession(session PaymentEvidenceSession) error {
+	pes.lock.Lock()
+	defer pes.lock.Unlock()
+
+	session.Started = session.Started.UTC()
+	return errors.Wrap(pes.bolt.Store(evidenceSessionBucketName, &session), "could not store evidence session")
+}
+
+// Record saves a payment message of the session.
+func (pes *PaymentEvidenceStorage) Record(record EvidenceRecord) error {
+	pes.lock.Lock()
+	defer pes.lock.Unlock()
+
+	record.Created = record.Created.UTC()
+	return errors.Wrap(pes.bolt.Store(evidenceRecordBucketName, &record), "could not store evidence record")
+}
+
+// EndSession marks the session as ended, keeping the error the session was ended with.
+func (pes *PaymentEvidenceStorage) EndSession(sessionID string, cause error) error {
+	pes.lock.Lock()
+	defer pes.lock.Unlock()
+
+	session, err := pes.getSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	session.Ended = time.Now().UTC()
+	if cause != nil {
+		session.Error = cause.Error()
+	}
+	return errors.Wrap(pes.bolt.Update(evidenceSessionBucketName, &session), "could not update evidence session")
+}
+
+// Sessions returns the sessions evidence is recorded for, newest first.
+func (pes *PaymentEvidenceStorage) Sessions() ([]PaymentEvidenceSession, error) {
+	pes.lock.Lock()
+	defer pes.lock.Unlock()
+
+	var sessions []PaymentEvidenceSession
+	err := pes.bolt.GetAllFrom(evidenceSessionBucketName, &sessions)
+	if err != nil && err != storage.ErrNotFound {
+		return nil, errors.Wrap(err, "could not list evidence sessions")
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Started.After(sessions[j].Started)
+	})
+	return sessions, nil
+}
+
+// Bundle collects the evidence recorded for the session into an unsigned bundle.
+func (pes *PaymentEvidenceStorage) Bundle(sessionID string) (EvidenceBundle, error) {
+	pes.lock.Lock()
+	defer pes.lock.Unlock()
+
+	session, err := pes.getSession(sessionID)
+	if err != nil {
+		return EvidenceBundle{}, err
+	}
+
+	var all []EvidenceRecord
+	err = pes.bolt.GetAllFrom(evidenceRecordBucketName, &all)
+	if err != nil && err != storage.ErrNotFound {
+		return EvidenceBundle{}, errors.Wrap(err, "could not list evidence records")
+	}
+
+	records := make([]EvidenceRecord, 0)
+	for _, record := range all {
+		if record.SessionID == sessionID {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+	return EvidenceBundle{Session: session, Records: records}, nil
+}
+
+func (pes *PaymentEvidenceStorage) getSession(sessionID string) (PaymentEvidenceSession, error) {
+	var session PaymentEvidenceSession
+	err := pes.bolt.GetOneByField(evidenceSessionBucketName, "SessionID", sessionID, &session)
+	if err == storage.ErrNotFound {
+		return session, ErrNotFound
+	}
+	return session, errors.Wrap(err, "could not get evidence session")
+}
+
+// EvidenceBundle is an export of the payment evidence of a session, signed by the identity which recorded it.
+type EvidenceBundle struct {
+	Session   PaymentEvidenceSession `json:"session"`
+	Records   []EvidenceRecord       `json:"records"`
+	Signer    string                 `json:"signer"`
+	Signature string                 `json:"signature"`
+}
+
+func (eb EvidenceBundle) payload() ([]byte, error) {
+	return json.Marshal(struct {
+		Session PaymentEvidenceSession `json:"session"`
+		Records []EvidenceRecord       `json:"records"`
+	}{eb.Session, eb.Records})
+}
+
+// recorder returns the identity which recorded the evidence.
+func (eb EvidenceBundle) recorder() identity.Identity {
+	if eb.Session.Role == EvidenceRoleConsumer {
+		return identity.FromAddress(eb.Session.ConsumerID)
+	}
+	return identity.FromAddress(eb.Session.ProviderID)
+}
+
+// Sign signs the bundle with the identity which recorded the evidence.
+func (eb *EvidenceBundle) Sign(signerFactory identity.SignerFactory) error {
+	payload, err := eb.payload()
+	if err != nil {
+		return errors.Wrap(err, "could not marshal evidence")
+	}
+
+	signer := eb.recorder()
+	signature, err := signerFactory(signer).Sign(payload)
+	if err != nil {
+		return errors.Wrap(err, "could not sign evidence")
+	}
+
+	eb.Signer = signer.Address
+	eb.Signature = signature.Base64()
+	return nil
+}
+
+// EvidenceFinding describes a deviation from the agreed payment method found in the evidence.
+type EvidenceFinding struct {
+	RecordID int64        `json:"record_id,omitempty"`
+	Party    EvidenceRole `json:"party,omitempty"`
+	Message  string       `json:"message"`
+}
+
+// EvidenceVerdict is the outcome of replaying the evidence bundle.
+type EvidenceVerdict struct {
+	SignatureValid bool              `json:"signature_valid"`
+	Deviator       EvidenceRole      `json:"deviator,omitempty"`
+	Findings       []EvidenceFinding `json:"findings"`
+}
+
+func (ev *EvidenceVerdict) add(recordID int64, party EvidenceRole, format string, args ...interface{}) {
+	if ev.Deviator == "" {
+		ev.Deviator = party
+	}
+	ev.Findings = append(ev.Findings, EvidenceFinding{RecordID: recordID, Party: party, Message: fmt.Sprintf(format, args...)})
+}
+
+// evidencePaymentMethod restores the payment method agreed for the session.
+type evidencePaymentMethod struct {
+	session PaymentEvidenceSession
+}
+
+func (epm evidencePaymentMethod) GetPrice() money.Money {
+	return epm.session.Price
+}
+
+func (epm evidencePaymentMethod) GetType() string {
+	return epm.session.PaymentMethodType
+}
+
+func (epm evidencePaymentMethod) GetRate() market.PaymentRate {
+	return epm.session.Rate
+}
+
+// Replay verifies the bundle signature and replays the recorded payment messages against the agreed payment method,
+// applying the same rules the provider and the consumer apply during the session.
+func (eb EvidenceBundle) Replay() EvidenceVerdict {
+	verdict := EvidenceVerdict{Findings: make([]EvidenceFinding, 0)}
+
+	payload, err := eb.payload()
+	if err == nil && eb.Signer != "" && eb.recorder().Address == identity.FromAddress(eb.Signer).Address {
+		verdict.SignatureValid = identity.NewVerifierIdentity(eb.recorder()).Verify(payload, identity.SignatureBase64(eb.Signature))
+	}
+	if !verdict.SignatureValid {
+		verdict.Findings = append(verdict.Findings, EvidenceFinding{Message: "bundle signature does not match the identity which recorded the evidence"})
+	}
+
+	method := evidencePaymentMethod{session: eb.Session}
+	consumer := common.HexToAddress(eb.Session.ConsumerID)
+	invoices := make(map[string]crypto.Invoice)
+	var lastPromised uint64
+	for _, record := range eb.Records {
+		switch {
+		case record.Kind == EvidenceKindInvoice && record.Invoice != nil:
+			invoices[record.Invoice.Hashlock] = *record.Invoice
+			eb.replayInvoice(&verdict, record, method)
+		case record.Kind == EvidenceKindExchangeMessage && record.ExchangeMessage != nil:
+			em := record.ExchangeMessage
+			if !em.IsMessageValid(consumer) {
+				verdict.add(record.ID, EvidenceRoleConsumer, "exchange message is not signed by consumer %v", eb.Session.ConsumerID)
+				continue
+			}
+			if em.Promise.Amount < lastPromised {
+				verdict.add(record.ID, EvidenceRoleConsumer, "promised amount decreased from %v to %v", lastPromised, em.Promise.Amount)
+			}
+			lastPromised = em.Promise.Amount
+
+			invoice, ok := invoices[hex.EncodeToString(em.Promise.Hashlock)]
+			if !ok {
+				continue
+			}
+			if em.AgreementTotal < invoice.AgreementTotal {
+				verdict.add(record.ID, EvidenceRoleConsumer, "paid agreement total %v for invoice of %v", em.AgreementTotal, invoice.AgreementTotal)
+			}
+		}
+	}
+	return verdict
+}
+
+func (eb EvidenceBundle) replayInvoice(verdict *EvidenceVerdict, record EvidenceRecord, method market.PaymentMethod) {
+	charged := record.Invoice.AgreementTotal
+	if eb.Session.Role == EvidenceRoleProvider {
+		due := CalculatePaymentAmount(record.Elapsed, record.DataTransferred, method)
+		if charged > due {
+			verdict.add(record.ID, EvidenceRoleProvider, "charged %v while %v was due for the measured usage", charged, due)
+		}
+		return
+	}
+
+	transferred := record.DataTransferred
+	transferred.Up += eb.Session.DataLeewayBytes
+	due := CalculatePaymentAmount(record.Elapsed, transferred, method)
+	upperBound := uint64(math.Trunc(float64(due) * estimateInvoiceTolerance(record.Elapsed, transferred)))
+	if charged > upperBound {
+		verdict.add(record.ID, EvidenceRoleProvider, "charged %v while at most %v was due for the measured usage", charged, upperBound)
+	}
+}
+
+func newEvidenceSession(role EvidenceRole, sessionID string, proposal market.ServiceProposal, consumer, provider identity.Identity, accountant common.Address) PaymentEvidenceSession {
+	session := PaymentEvidenceSession{
+		SessionID:         sessionID,
+		Role:              role,
+		ConsumerID:        consumer.Address,
+		ProviderID:        provider.Address,
+		AccountantID:      accountant.Hex(),
+		PaymentMethodType: proposal.PaymentMethodType,
+		Started:           time.Now(),
+	}
+	if proposal.PaymentMethod != nil {
+		session.Price = proposal.PaymentMethod.GetPrice()
+		session.Rate = proposal.PaymentMethod.GetRate()
+	}
+	return session
+}
+
+// startEvidence, recordEvidence and endEvidence only log failures, the evidence must never break the payments.
+func startEvidence(recorder paymentEvidenceRecorder, session PaymentEvidenceSession) {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.StartSession(session); err != nil {
+		log.Warn().Err(err).Msgf("Could not start payment evidence of session %v", session.SessionID)
+	}
+}
+
+func recordEvidence(recorder paymentEvidenceRecorder, record EvidenceRecord) {
+	if recorder == nil {
+		return
+	}
+	record.Created = time.Now()
+	if err := recorder.Record(record); err != nil {
+		log.Warn().Err(err).Msgf("Could not record payment evidence of session %v", record.SessionID)
+	}
+}
+
+func endEvidence(recorder paymentEvidenceRecorder, sessionID string, cause error) {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.EndSession(sessionID, cause); err != nil {
+		log.Warn().Err(err).Msgf("Could not end payment evidence of session %v", sessionID)
+	}
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/market"
+	"github.com/mysteriumnetwork/node/money"
+	"github.com/mysteriumnetwork/payments/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentEvidenceStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paymentEvidenceStorageTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	defer bolt.Close()
+
+	storage := NewPaymentEvidenceStorage(bolt)
+
+	sessions, err := storage.Sessions()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 0)
+
+	_, err = storage.Bundle("unknown")
+	assert.Equal(t, ErrNotFound, err)
+
+	now := time.Now()
+	assert.NoError(t, storage.StartSession(PaymentEvidenceSession{SessionID: "old", Role: EvidenceRoleProvider, Started: now.Add(-time.Hour)}))
+	assert.NoError(t, storage.StartSession(PaymentEvidenceSession{SessionID: "new", Role: EvidenceRoleConsumer, Started: now}))
+
+	invoice := crypto.CreateInvoice(1, 100, 0, nil)
+	assert.NoError(t, storage.Record(EvidenceRecord{SessionID: "new", Kind: EvidenceKindInvoice, Invoice: &invoice, Elapsed: time.Minute}))
+	assert.NoError(t, storage.Record(EvidenceRecord{SessionID: "old", Kind: EvidenceKindInvoice, Invoice: &invoice}))
+	assert.NoError(t, storage.Record(EvidenceRecord{SessionID: "new", Kind: EvidenceKindExchangeMessage, ExchangeMessage: &crypto.ExchangeMessage{AgreementTotal: 100}}))
+	assert.NoError(t, storage.EndSession("new", ErrProviderOvercharge))
+
+	sessions, err = storage.Sessions()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	assert.Equal(t, "new", sessions[0].SessionID)
+	assert.Equal(t, "old", sessions[1].SessionID)
+
+	bundle, err := storage.Bundle("new")
+	assert.NoError(t, err)
+	assert.Equal(t, EvidenceRoleConsumer, bundle.Session.Role)
+	assert.Equal(t, ErrProviderOvercharge.Error(), bundle.Session.Error)
+	assert.False(t, bundle.Session.Ended.IsZero())
+	assert.Len(t, bundle.Records, 2)
+	assert.Equal(t, EvidenceKindInvoice, bundle.Records[0].Kind)
+	assert.Equal(t, invoice.Hashlock, bundle.Records[0].Invoice.Hashlock)
+	assert.Equal(t, time.Minute, bundle.Records[0].Elapsed)
+	assert.Equal(t, EvidenceKindExchangeMessage, bundle.Records[1].Kind)
+
+	assert.Equal(t, ErrNotFound, storage.EndSession("unknown", nil))
+}
+
+func TestEvidenceBundle_Replay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "evidenceBundleReplayTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ks := identity.NewKeystoreFilesystem(dir, identity.NewMockKeystore(identity.MockKeys), identity.MockDecryptFunc)
+	consumer := identity.FromAddress("0x53a835143c0ef3bbcbfa796d7eb738ca7dd28f68")
+	assert.NoError(t, identity.NewIdentityManager(ks, eventbus.New()).Unlock(consumer.Address, ""))
+	signerFactory := func(id identity.Identity) identity.Signer {
+		return identity.NewSigner(ks, id)
+	}
+
+	method := &mockPaymentMethod{
+		price: money.NewMoney(10, money.CurrencyMyst),
+		rate:  market.PaymentRate{PerTime: time.Minute},
+	}
+	session := newEvidenceSession(EvidenceRoleConsumer, "session", market.ServiceProposal{PaymentMethodType: "BYTES_TRANSFERRED_WITH_TIME", PaymentMethod: method}, consumer, identity.FromAddress("0x2"), identity.FromAddress("0x3").ToCommonAddress())
+	session.Started = session.Started.UTC()
+
+	elapsed := 30 * time.Minute
+	due := CalculatePaymentAmount(elapsed, DataTransferred{}, method)
+	exchange := func(invoice crypto.Invoice, total, promised uint64) *crypto.ExchangeMessage {
+		invoice.AgreementTotal = total
+		em, err := crypto.CreateExchangeMessage(invoice, promised, "0x0000000000000000000000000000000000000004", ks, consumer.ToCommonAddress())
+		assert.NoError(t, err)
+		return em
+	}
+
+	tests := []struct {
+		name     string
+		records  func() []EvidenceRecord
+		deviator EvidenceRole
+	}{
+		{
+			name: "both parties follow the payment method",
+			records: func() []EvidenceRecord {
+				invoice := crypto.CreateInvoice(1, due, 0, nil)
+				return []EvidenceRecord{
+					{ID: 1, Kind: EvidenceKindInvoice, Invoice: &invoice, Elapsed: elapsed},
+					{ID: 2, Kind: EvidenceKindExchangeMessage, ExchangeMessage: exchange(invoice, due, due), Elapsed: elapsed},
+				}
+			},
+		},
+		{
+			name: "provider overcharges",
+			records: func() []EvidenceRecord {
+				invoice := crypto.CreateInvoice(1, due*10, 0, nil)
+				return []EvidenceRecord{
+					{ID: 1, Kind: EvidenceKindInvoice, Invoice: &invoice, Elapsed: elapsed},
+				}
+			},
+			deviator: EvidenceRoleProvider,
+		},
+		{
+			name: "consumer pays less than invoiced",
+			records: func() []EvidenceRecord {
+				invoice := crypto.CreateInvoice(1, due, 0, nil)
+				return []EvidenceRecord{
+					{ID: 1, Kind: EvidenceKindInvoice, Invoice: &invoice, Elapsed: elapsed},
+					{ID: 2, Kind: EvidenceKindExchangeMessage, ExchangeMessage: exchange(invoice, due-1, due-1), Elapsed: elapsed},
+				}
+			},
+			deviator: EvidenceRoleConsumer,
+		},
+		{
+			name: "consumer decreases promised amount",
+			records: func() []EvidenceRecord {
+				first := crypto.CreateInvoice(1, due/2, 0, nil)
+				second := crypto.CreateInvoice(1, due, 0, nil)
+				return []EvidenceRecord{
+					{ID: 1, Kind: EvidenceKindInvoice, Invoice: &first, Elapsed: elapsed / 2},
+					{ID: 2, Kind: EvidenceKindExchangeMessage, ExchangeMessage: exchange(first, due/2, 1000), Elapsed: elapsed / 2},
+					{ID: 3, Kind: EvidenceKindInvoice, Invoice: &second, Elapsed: elapsed},
+					{ID: 4, Kind: EvidenceKindExchangeMessage, ExchangeMessage: exchange(second, due, 10), Elapsed: elapsed},
+				}
+			},
+			deviator: EvidenceRoleConsumer,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bundle := EvidenceBundle{Session: session, Records: test.records()}
+			assert.NoError(t, bundle.Sign(signerFactory))
+			assert.Equal(t, consumer.Address, bundle.Signer)
+
+			// the bundle is verified offline, after it went through its JSON export
+			exported, err := json.Marshal(bundle)
+			assert.NoError(t, err)
+			var imported EvidenceBundle
+			assert.NoError(t, json.Unmarshal(exported, &imported))
+
+			verdict := imported.Replay()
+			assert.True(t, verdict.SignatureValid)
+			assert.Equal(t, test.deviator, verdict.Deviator)
+			assert.Equal(t, test.deviator == "", len(verdict.Findings) == 0, verdict.Findings)
+		})
+	}
+
+	t.Run("tampered bundle", func(t *testing.T) {
+		invoice := crypto.CreateInvoice(1, due, 0, nil)
+		bundle := EvidenceBundle{Session: session, Records: []EvidenceRecord{{ID: 1, Kind: EvidenceKindInvoice, Invoice: &invoice, Elapsed: elapsed}}}
+		assert.NoError(t, bundle.Sign(signerFactory))
+
+		bundle.Records[0].Elapsed = time.Hour
+		verdict := bundle.Replay()
+		assert.False(t, verdict.SignatureValid)
+		assert.Len(t, verdict.Findings, 1)
+	})
+}
+
+func TestPaymentEvidence_NilRecorder(t *testing.T) {
+	startEvidence(nil, PaymentEvidenceSession{})
+	recordEvidence(nil, EvidenceRecord{})
+	endEvidence(nil, "session", errors.New("boom"))
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/pkg/errors"
+)
+
+const settlementHistoryBucketName = "settlement-history"
+
+// SettlementTrigger describes what caused the settlement attempt.
+type SettlementTrigger string
+
+const (
+	// SettlementTriggerThreshold is used when unsettled earnings reached the settlement threshold.
+	SettlementTriggerThreshold SettlementTrigger = "threshold"
+	// SettlementTriggerSchedule is used when the settlement schedule fired.
+	SettlementTriggerSchedule SettlementTrigger = "schedule"
+	// SettlementTriggerManual is used when the settlement was requested by the user.
+	SettlementTriggerManual SettlementTrigger = "manual"
+)
+
+// SettlementStatus describes the state of the settlement attempt.
+type SettlementStatus string
+
+const (
+	// SettlementStatusQueued marks attempts waiting in the settlement queue.
+	SettlementStatusQueued SettlementStatus = "queued"
+	// SettlementStatusSettling marks attempts submitted to the transactor.
+	SettlementStatusSettling SettlementStatus = "settling"
+	// SettlementStatusSettled marks attempts which completed on the blockchain.
+	SettlementStatusSettled SettlementStatus = "settled"
+	// SettlementStatusFailed marks attempts which failed or timed out.
+	SettlementStatusFailed SettlementStatus = "failed"
+	// SettlementStatusSkipped marks attempts rejected by the settlement policies.
+	SettlementStatusSkipped SettlementStatus = "skipped"
+)
+
+// SettlementAttempt represents a single settlement attempt.
+type SettlementAttempt struct {
+	ID           int64 `storm:"id,increment"`
+	ProviderID   identity.Identity
+	AccountantID common.Address
+	Trigger      SettlementTrigger
+	Status       SettlementStatus
+	Amount       uint64
+	Fee          uint64
+	Error        string
+	Created      time.Time
+	Updated      time.Time
+}
+
+// submitted returns true if the attempt has reached the transactor.
+func (sa SettlementAttempt) submitted() bool {
+	return sa.Status == SettlementStatusSettling || sa.Status == SettlementStatusSettled || sa.Status == SettlementStatusFailed
+}
+
+// pending returns true if the attempt has not completed yet.
+func (sa SettlementAttempt) pending() bool {
+	return sa.Status == SettlementStatusQueued || sa.Status == SettlementStatusSettling
+}
+
+// SettlementHistoryFilter narrows down the listed settlement attempts.
+type SettlementHistoryFilter struct {
+	ProviderID *identity.Identity
+	Since      *time.Time
+}
+
+// settlementHistoryStorer allows us to store and list settlement attempts.
+type settlementHistoryStorer interface {
+	Store(bucket string, object interface{}) error
+	Update(bucket string, object interface{}) error
+	GetAllFrom(bucket string, array interface{}) error
+}
+
+// SettlementHistoryStorage persists the settlement attempts.
+type SettlementHistoryStorage struct {
+	bolt settlementHistoryStorer
+	lock sync.Mutex
+}
+
+// SettlementHistoryRetentionPolicy returns the policy dropping settlement attempts older than maxAge.
+func SettlementHistoryRetentionPolicy(maxAge time.Duration) storage.RetentionPolicy {
+	return storage.RetentionPolicy{Bucket: settlementHistoryBucketName, Kind: &SettlementAttempt{}, Field: "Created", MaxAge: maxAge}
+}
+
+// StorageKinds returns the records kept by the payment storages.
+func StorageKinds() []storage.Kind {
+	return []storage.Kind{
+		{Bucket: settlementHistoryBucketName, Record: &SettlementAttempt{}},
+		{Bucket: transactionLedgerBucketName, Record: &LedgerEntry{}},
+		{Bucket: evidenceSessionBucketName, Record: &PaymentEvidenceSession{}},
+		{Bucket: evidenceRecordBucketName, Record: &EvidenceRecord{}},
+	}
+}
+
+// NewSettlementHistoryStorage creates a new instance of settlement history storage.
+func NewSettlementHistoryStorage(bolt settlementHistoryStorer) *SettlementHistoryStorage {
+	return &SettlementHistoryStorage{
+		bolt: bolt,
+	}
+}
+
+// Store saves a new settlement attempt, assigning it an ID.
+func (shs *SettlementHistoryStorage) Store(attempt *SettlementAttempt) error {
+	shs.lock.Lock()
+	defer shs.lock.Unlock()
+
+	return errors.Wrap(shs.bolt.Store(settlementHistoryBucketName, attempt), "could not store settlement attempt")
+}
+
+// Update saves the changes of the existing settlement attempt.
+func (shs *SettlementHistoryStorage) Update(attempt *SettlementAttempt) error {
+	shs.lock.Lock()
+	defer shs.lock.Unlock()
+
+	return errors.Wrap(shs.bolt.Update(settlementHistoryBucketName, attempt), "could not update settlement attempt")
+}
+
+// List returns settlement attempts matching the filter, newest first.
+func (shs *SettlementHistoryStorage) List(filter SettlementHistoryFilter) ([]SettlementAttempt, error) {
+	shs.lock.Lock()
+	defer shs.lock.Unlock()
+
+	var all []SettlementAttempt
+	err := shs.bolt.GetAllFrom(settlementHistoryBucketName, &all)
+	if err != nil && err != storage.ErrNotFound {
+		return nil, errors.Wrap(err, "could not list settlement attempts")
+	}
+
+	result := make([]SettlementAttempt, 0, len(all))
+	for _, attempt := range all {
+		if filter.ProviderID != nil && attempt.ProviderID != *filter.ProviderID {
+			continue
+		}
+		if filter.Since != nil && attempt.Created.Before(*filter.Since) {
+			continue
+		}
+		result = append(result, attempt)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+	return result, nil
+}
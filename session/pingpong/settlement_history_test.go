@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettlementHistoryStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "settlementHistoryStorageTest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+	defer bolt.Close()
+
+	storage := NewSettlementHistoryStorage(bolt)
+
+	// empty history is not an error
+	attempts, err := storage.List(SettlementHistoryFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 0)
+
+	provider := identity.FromAddress("0x1")
+	otherProvider := identity.FromAddress("0x2")
+	now := time.Now().UTC()
+
+	first := SettlementAttempt{ProviderID: provider, Status: SettlementStatusQueued, Created: now.Add(-48 * time.Hour)}
+	assert.NoError(t, storage.Store(&first))
+	second := SettlementAttempt{ProviderID: otherProvider, Status: SettlementStatusQueued, Created: now}
+	assert.NoError(t, storage.Store(&second))
+	third := SettlementAttempt{ProviderID: provider, Status: SettlementStatusQueued, Created: now}
+	assert.NoError(t, storage.Store(&third))
+	assert.NotEqual(t, first.ID, third.ID)
+
+	third.Status = SettlementStatusSettled
+	assert.NoError(t, storage.Update(&third))
+
+	attempts, err = storage.List(SettlementHistoryFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 3)
+	assert.Equal(t, third.ID, attempts[0].ID)
+	assert.Equal(t, SettlementStatusSettled, attempts[0].Status)
+
+	attempts, err = storage.List(SettlementHistoryFilter{ProviderID: &provider})
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 2)
+
+	since := now.Add(-24 * time.Hour)
+	attempts, err = storage.List(SettlementHistoryFilter{ProviderID: &provider, Since: &since})
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 1)
+	assert.Equal(t, third.ID, attempts[0].ID)
+}
@@ -0,0 +1,276 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/core/storage"
+	"github.com/mysteriumnetwork/node/eventbus"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/session/pingpong/event"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const transactionLedgerBucketName = "transaction-ledger"
+
+// LedgerEntryType describes the kind of the transactor interaction.
+type LedgerEntryType string
+
+const (
+	// LedgerEntryRegistration marks identity registrations.
+	LedgerEntryRegistration LedgerEntryType = "registration"
+	// LedgerEntryTopUp marks channel top ups.
+	LedgerEntryTopUp LedgerEntryType = "topup"
+	// LedgerEntrySettlement marks promise settlements.
+	LedgerEntrySettlement LedgerEntryType = "settlement"
+)
+
+// LedgerEntryStatus describes the state of the transactor interaction.
+type LedgerEntryStatus string
+
+const (
+	// LedgerEntryPending marks requests sent to transactor, awaiting the response.
+	LedgerEntryPending LedgerEntryStatus = "pending"
+	// LedgerEntrySubmitted marks requests accepted by transactor, awaiting the blockchain confirmation.
+	LedgerEntrySubmitted LedgerEntryStatus = "submitted"
+	// LedgerEntryConfirmed marks transactions confirmed on the blockchain.
+	LedgerEntryConfirmed LedgerEntryStatus = "confirmed"
+	// LedgerEntryFailed marks requests rejected by transactor or failed transactions.
+	LedgerEntryFailed LedgerEntryStatus = "failed"
+)
+
+// LedgerEntry represents a single transactor interaction.
+type LedgerEntry struct {
+	ID           int64 `storm:"id,increment"`
+	Type         LedgerEntryType
+	Identity     identity.Identity
+	AccountantID common.Address
+	Amount       uint64
+	Fee          uint64
+	PromiseHash  string
+	TxHash       string
+	Status       LedgerEntryStatus
+	Error        string
+	Created      time.Time
+	Updated      time.Time
+}
+
+// LedgerFilter narrows down the listed ledger entries.
+type LedgerFilter struct {
+	Identity *identity.Identity
+	Type     *LedgerEntryType
+}
+
+type transactionLedgerStorer interface {
+	Store(bucket string, object interface{}) error
+	Update(bucket string, object interface{}) error
+	GetAllFrom(bucket string, array interface{}) error
+	Find(bucket string, query storage.Query, to interface{}) error
+}
+
+// TransactionLedger keeps an audit trail of all transactor interactions.
+type TransactionLedger struct {
+	bolt transactionLedgerStorer
+	lock sync.Mutex
+}
+
+// NewTransactionLedger creates a new instance of transaction ledger.
+func NewTransactionLedger(bolt transactionLedgerStorer) *TransactionLedger {
+	return &TransactionLedger{
+		bolt: bolt,
+	}
+}
+
+// Subscribe subscribes the ledger to transactor, registration and settlement events.
+func (tl *TransactionLedger) Subscribe(bus eventbus.Subscriber) error {
+	if err := bus.Subscribe(registry.AppTopicTransactorRegistration, tl.handleRegistrationRequest); err != nil {
+		return errors.Wrap(err, "could not subscribe to registration request event")
+	}
+	if err := bus.Subscribe(registry.AppTopicTransactorTopUp, tl.handleTopUpRequest); err != nil {
+		return errors.Wrap(err, "could not subscribe to top up request event")
+	}
+	if err := bus.Subscribe(registry.AppTopicTransactorResult, tl.handleTransactorResult); err != nil {
+		return errors.Wrap(err, "could not subscribe to transactor result event")
+	}
+	if err := bus.SubscribeAsync(registry.AppTopicIdentityRegistration, tl.handleRegistrationStatus); err != nil {
+		return errors.Wrap(err, "could not subscribe to registration status event")
+	}
+	return errors.Wrap(bus.SubscribeAsync(event.AppTopicSettlement, tl.handleSettlement), "could not subscribe to settlement event")
+}
+
+// List returns ledger entries matching the filter, newest first.
+func (tl *TransactionLedger) List(filter LedgerFilter) ([]LedgerEntry, error) {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+
+	return tl.list(filter)
+}
+
+func (tl *TransactionLedger) list(filter LedgerFilter) ([]LedgerEntry, error) {
+	var all []LedgerEntry
+	err := tl.bolt.GetAllFrom(transactionLedgerBucketName, &all)
+	if err != nil && err != storage.ErrNotFound {
+		return nil, errors.Wrap(err, "could not list ledger entries")
+	}
+
+	result := make([]LedgerEntry, 0, len(all))
+	for _, entry := range all {
+		if filter.Identity != nil && entry.Identity != *filter.Identity {
+			continue
+		}
+		if filter.Type != nil && entry.Type != *filter.Type {
+			continue
+		}
+		result = append(result, entry)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+	return result, nil
+}
+
+func (tl *TransactionLedger) store(entry LedgerEntry) {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+
+	now := time.Now().UTC()
+	entry.Created = now
+	entry.Updated = now
+	if err := tl.bolt.Store(transactionLedgerBucketName, &entry); err != nil {
+		log.Error().Err(err).Msgf("Could not store %s ledger entry for %q", entry.Type, entry.Identity.Address)
+	}
+}
+
+// updateLatest updates the newest entry of the given type and identity which is still in one of the given statuses.
+func (tl *TransactionLedger) updateLatest(entryType LedgerEntryType, id identity.Identity, statuses []LedgerEntryStatus, update func(entry *LedgerEntry)) {
+	tl.lock.Lock()
+	defer tl.lock.Unlock()
+
+	entry, ok, err := tl.latest(entryType, id, statuses)
+	if err != nil {
+		log.Error().Err(err).Msgf("Could not load %s ledger entries for %q", entryType, id.Address)
+		return
+	}
+	if ok {
+		update(&entry)
+		entry.Updated = time.Now().UTC()
+		if err := tl.bolt.Update(transactionLedgerBucketName, &entry); err != nil {
+			log.Error().Err(err).Msgf("Could not update ledger entry %v", entry.ID)
+		}
+		return
+	}
+
+	log.Debug().Msgf("No open %s ledger entry found for %q", entryType, id.Address)
+}
+
+// latest looks up the newest entry per status instead of loading the whole bucket, the ledger only grows over time.
+func (tl *TransactionLedger) latest(entryType LedgerEntryType, id identity.Identity, statuses []LedgerEntryStatus) (LedgerEntry, bool, error) {
+	var latest LedgerEntry
+	found := false
+	for _, status := range statuses {
+		query := storage.Query{OrderBy: "ID", Reverse: true, Limit: 1}.
+			Where("Identity", storage.OpEq, id).
+			Where("Type", storage.OpEq, entryType).
+			Where("Status", storage.OpEq, status)
+
+		var entries []LedgerEntry
+		if err := tl.bolt.Find(transactionLedgerBucketName, query, &entries); err != nil {
+			return LedgerEntry{}, false, err
+		}
+		if len(entries) > 0 && (!found || entries[0].ID > latest.ID) {
+			latest = entries[0]
+			found = true
+		}
+	}
+	return latest, found, nil
+}
+
+func (tl *TransactionLedger) handleRegistrationRequest(req registry.IdentityRegistrationRequest) {
+	tl.store(LedgerEntry{
+		Type:         LedgerEntryRegistration,
+		Identity:     identity.FromAddress(req.Identity),
+		AccountantID: common.HexToAddress(req.AccountantID),
+		Amount:       req.Stake,
+		Fee:          req.Fee,
+		Status:       LedgerEntryPending,
+	})
+}
+
+func (tl *TransactionLedger) handleTopUpRequest(id string) {
+	tl.store(LedgerEntry{
+		Type:     LedgerEntryTopUp,
+		Identity: identity.FromAddress(id),
+		Status:   LedgerEntryPending,
+	})
+}
+
+func (tl *TransactionLedger) handleTransactorResult(result registry.AppEventTransactorResult) {
+	entryType := LedgerEntryRegistration
+	if result.Type == registry.TransactorRequestTopUp {
+		entryType = LedgerEntryTopUp
+	}
+
+	tl.updateLatest(entryType, identity.FromAddress(result.Identity), []LedgerEntryStatus{LedgerEntryPending}, func(entry *LedgerEntry) {
+		entry.Status = LedgerEntrySubmitted
+		if result.Error != "" {
+			entry.Status = LedgerEntryFailed
+			entry.Error = result.Error
+		}
+	})
+}
+
+func (tl *TransactionLedger) handleRegistrationStatus(ev registry.AppEventIdentityRegistration) {
+	open := []LedgerEntryStatus{LedgerEntryPending, LedgerEntrySubmitted}
+	switch ev.Status {
+	case registry.RegisteredConsumer, registry.RegisteredProvider:
+		tl.updateLatest(LedgerEntryRegistration, ev.ID, open, func(entry *LedgerEntry) {
+			entry.Status = LedgerEntryConfirmed
+			entry.TxHash = ev.TxHash
+		})
+	case registry.RegistrationError:
+		tl.updateLatest(LedgerEntryRegistration, ev.ID, open, func(entry *LedgerEntry) {
+			entry.Status = LedgerEntryFailed
+			entry.Error = "registration failed on blockchain"
+		})
+	}
+}
+
+func (tl *TransactionLedger) handleSettlement(ev event.AppEventSettlement) {
+	entry := LedgerEntry{
+		Type:         LedgerEntrySettlement,
+		Identity:     ev.ProviderID,
+		AccountantID: ev.AccountantID,
+		Amount:       ev.Amount,
+		Fee:          ev.Fee,
+		PromiseHash:  ev.PromiseHash,
+		TxHash:       ev.TxHash,
+		Status:       LedgerEntryConfirmed,
+	}
+	if ev.Error != "" {
+		entry.Status = LedgerEntryFailed
+		entry.Error = ev.Error
+	}
+	tl.store(entry)
+}
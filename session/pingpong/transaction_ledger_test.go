@@ -0,0 +1,145 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pingpong
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/session/pingpong/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTransactionLedger(t *testing.T) (*TransactionLedger, func()) {
+	dir, err := ioutil.TempDir("", "transactionLedgerTest")
+	assert.NoError(t, err)
+
+	bolt, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+
+	return NewTransactionLedger(bolt), func() {
+		bolt.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestTransactionLedger_TracksRegistration(t *testing.T) {
+	ledger, cleanup := newTestTransactionLedger(t)
+	defer cleanup()
+
+	id := identity.FromAddress("0x0000000000000000000000000000000000000001")
+	ledger.handleRegistrationRequest(registry.IdentityRegistrationRequest{
+		Identity:     id.Address,
+		AccountantID: "0x0000000000000000000000000000000000000002",
+		Stake:        100,
+		Fee:          10,
+	})
+
+	entries, err := ledger.List(LedgerFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, LedgerEntryRegistration, entries[0].Type)
+	assert.Equal(t, LedgerEntryPending, entries[0].Status)
+	assert.Equal(t, common.HexToAddress("0x0000000000000000000000000000000000000002"), entries[0].AccountantID)
+	assert.Equal(t, uint64(100), entries[0].Amount)
+	assert.Equal(t, uint64(10), entries[0].Fee)
+
+	ledger.handleTransactorResult(registry.AppEventTransactorResult{Type: registry.TransactorRequestRegistration, Identity: id.Address})
+	entries, err = ledger.List(LedgerFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, LedgerEntrySubmitted, entries[0].Status)
+
+	ledger.handleRegistrationStatus(registry.AppEventIdentityRegistration{ID: id, Status: registry.RegisteredProvider, TxHash: "0xabc"})
+	entries, err = ledger.List(LedgerFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, LedgerEntryConfirmed, entries[0].Status)
+	assert.Equal(t, "0xabc", entries[0].TxHash)
+}
+
+func TestTransactionLedger_TracksFailedTopUp(t *testing.T) {
+	ledger, cleanup := newTestTransactionLedger(t)
+	defer cleanup()
+
+	id := "0x0000000000000000000000000000000000000001"
+	ledger.handleTopUpRequest(id)
+	ledger.handleTransactorResult(registry.AppEventTransactorResult{Type: registry.TransactorRequestTopUp, Identity: id, Error: "server response invalid: 500 Internal Server Error"})
+
+	entries, err := ledger.List(LedgerFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, LedgerEntryTopUp, entries[0].Type)
+	assert.Equal(t, LedgerEntryFailed, entries[0].Status)
+	assert.Equal(t, "server response invalid: 500 Internal Server Error", entries[0].Error)
+}
+
+func TestTransactionLedger_UpdatesNewestOpenEntryOfIdentity(t *testing.T) {
+	ledger, cleanup := newTestTransactionLedger(t)
+	defer cleanup()
+
+	id := identity.FromAddress("0x0000000000000000000000000000000000000001")
+	other := identity.FromAddress("0x0000000000000000000000000000000000000003")
+	ledger.handleRegistrationRequest(registry.IdentityRegistrationRequest{Identity: id.Address})
+	ledger.handleTransactorResult(registry.AppEventTransactorResult{Type: registry.TransactorRequestRegistration, Identity: id.Address})
+	ledger.handleRegistrationRequest(registry.IdentityRegistrationRequest{Identity: id.Address})
+	ledger.handleRegistrationRequest(registry.IdentityRegistrationRequest{Identity: other.Address})
+
+	ledger.handleRegistrationStatus(registry.AppEventIdentityRegistration{ID: id, Status: registry.RegisteredConsumer, TxHash: "0xabc"})
+
+	entries, err := ledger.List(LedgerFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, other, entries[0].Identity)
+	assert.Equal(t, LedgerEntryPending, entries[0].Status)
+	assert.Equal(t, LedgerEntryConfirmed, entries[1].Status)
+	assert.Equal(t, "0xabc", entries[1].TxHash)
+	assert.Equal(t, LedgerEntrySubmitted, entries[2].Status)
+}
+
+func TestTransactionLedger_RecordsSettlementsAndFilters(t *testing.T) {
+	ledger, cleanup := newTestTransactionLedger(t)
+	defer cleanup()
+
+	provider := identity.FromAddress("0x0000000000000000000000000000000000000001")
+	other := identity.FromAddress("0x0000000000000000000000000000000000000003")
+	ledger.handleSettlement(event.AppEventSettlement{ProviderID: provider, Amount: 500, Fee: 20, PromiseHash: "aa", TxHash: "0x01"})
+	ledger.handleSettlement(event.AppEventSettlement{ProviderID: provider, Fee: 20, PromiseHash: "bb", Error: "settle timeout"})
+	ledger.handleTopUpRequest(other.Address)
+
+	entries, err := ledger.List(LedgerFilter{Identity: &provider})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, LedgerEntryFailed, entries[0].Status)
+	assert.Equal(t, "settle timeout", entries[0].Error)
+	assert.Equal(t, LedgerEntryConfirmed, entries[1].Status)
+	assert.Equal(t, uint64(500), entries[1].Amount)
+	assert.Equal(t, "0x01", entries[1].TxHash)
+
+	settlement := LedgerEntrySettlement
+	entries, err = ledger.List(LedgerFilter{Type: &settlement})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	entries, err = ledger.List(LedgerFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
@@ -18,10 +18,18 @@
 package client
 
 import (
+	"bufio"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
@@ -39,6 +47,45 @@ func NewClient(ip string, port int) *Client {
 	}
 }
 
+// Options describes how Client connects and authenticates to Tequilapi
+type Options struct {
+	// Token is JWT or API token used to authenticate requests
+	Token string
+	// TLS enables HTTPS with the given configuration
+	TLS *tls.Config
+	// SocketPath makes client connect over unix domain socket instead of TCP
+	SocketPath string
+}
+
+// NewClientWithOptions returns a new instance of Client using the given transport and authentication options
+func NewClientWithOptions(ip string, port int, options Options) *Client {
+	scheme := "http"
+	if options.TLS != nil {
+		scheme = "https"
+	}
+	host := fmt.Sprintf("%s:%d", ip, port)
+	if options.SocketPath != "" {
+		host = "unix"
+	}
+
+	transport := &http.Transport{TLSClientConfig: options.TLS}
+	if options.SocketPath != "" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", options.SocketPath)
+		}
+	}
+
+	return &Client{
+		http: &httpClient{
+			http:      &http.Client{Transport: transport, Timeout: 100 * time.Second},
+			baseURL:   fmt.Sprintf("%s://%s", scheme, host),
+			ua:        "goclient-v0.1",
+			authToken: options.Token,
+		},
+	}
+}
+
 // Client is able perform remote requests to Tequilapi server
 type Client struct {
 	http httpClientInterface
@@ -209,6 +256,19 @@ func (client *Client) ConnectionStatistics() (contract.ConnectionStatisticsDTO,
 	return statistics, err
 }
 
+// ConnectionDNS returns statistics of the local DNS proxy
+func (client *Client) ConnectionDNS() (contract.ConnectionDNSDTO, error) {
+	response, err := client.http.Get("connection/dns", url.Values{})
+	if err != nil {
+		return contract.ConnectionDNSDTO{}, err
+	}
+	defer response.Body.Close()
+
+	var stats contract.ConnectionDNSDTO
+	err = parseResponseJSON(response, &stats)
+	return stats, err
+}
+
 // ConnectionStatus returns connection status
 func (client *Client) ConnectionStatus() (contract.ConnectionStatusDTO, error) {
 	response, err := client.http.Get("connection", url.Values{})
@@ -338,6 +398,45 @@ func (client *Client) Payout(identity, ethAddress string) error {
 	return nil
 }
 
+// PayoutInfo returns payout info registered for identity
+func (client *Client) PayoutInfo(identity string) (PayoutInfoDTO, error) {
+	info := PayoutInfoDTO{}
+	response, err := client.http.Get(fmt.Sprintf("identities/%s/payout", identity), nil)
+	if err != nil {
+		return info, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &info)
+	return info, err
+}
+
+// UserConfig returns the user configuration of the node
+func (client *Client) UserConfig() (ConfigDTO, error) {
+	cfg := ConfigDTO{}
+	response, err := client.http.Get("config/user", nil)
+	if err != nil {
+		return cfg, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &cfg)
+	return cfg, err
+}
+
+// SetUserConfig sets the given user configuration keys, keys with nil values are removed
+func (client *Client) SetUserConfig(data map[string]interface{}) (ConfigDTO, error) {
+	cfg := ConfigDTO{}
+	response, err := client.http.Post("config/user", ConfigDTO{Data: data})
+	if err != nil {
+		return cfg, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &cfg)
+	return cfg, err
+}
+
 // Stop kills mysterium client
 func (client *Client) Stop() error {
 	emptyPayload := struct{}{}
@@ -521,3 +620,171 @@ func (client *Client) Settle(providerID, accountantID identity.Identity, waitFor
 	}
 	return nil
 }
+
+// AuthLogin checks user credentials and returns a JWT token
+func (client *Client) AuthLogin(username, password string) (LoginResponseDTO, error) {
+	login := LoginResponseDTO{}
+	response, err := client.http.Post("auth/login", LoginRequestDTO{Username: username, Password: password})
+	if err != nil {
+		return login, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &login)
+	return login, err
+}
+
+// AuthUsers returns Tequilapi users
+func (client *Client) AuthUsers() (UserListDTO, error) {
+	users := UserListDTO{}
+	response, err := client.http.Get("auth/users", url.Values{})
+	if err != nil {
+		return users, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &users)
+	return users, err
+}
+
+// AuthCreateUser creates a new Tequilapi user with the given scopes
+func (client *Client) AuthCreateUser(username, password string, scopes []string) (UserDTO, error) {
+	user := UserDTO{}
+	response, err := client.http.Post("auth/users", CreateUserRequestDTO{Username: username, Password: password, Scopes: scopes})
+	if err != nil {
+		return user, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &user)
+	return user, err
+}
+
+// AuthDeleteUser deletes Tequilapi user
+func (client *Client) AuthDeleteUser(username string) error {
+	response, err := client.http.Delete("auth/users/"+username, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// AuthTokens returns long-lived API tokens
+func (client *Client) AuthTokens() (APITokenListDTO, error) {
+	tokens := APITokenListDTO{}
+	response, err := client.http.Get("auth/tokens", url.Values{})
+	if err != nil {
+		return tokens, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &tokens)
+	return tokens, err
+}
+
+// AuthCreateToken creates a new long-lived API token with the given scopes
+func (client *Client) AuthCreateToken(name string, scopes []string) (APITokenDTO, error) {
+	token := APITokenDTO{}
+	response, err := client.http.Post("auth/tokens", CreateAPITokenRequestDTO{Name: name, Scopes: scopes})
+	if err != nil {
+		return token, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &token)
+	return token, err
+}
+
+// AuthRevokeToken revokes long-lived API token
+func (client *Client) AuthRevokeToken(id string) error {
+	response, err := client.http.Delete("auth/tokens/"+id, nil)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return nil
+}
+
+// PaymentEvidenceSessions returns sessions with recorded payment evidence
+func (client *Client) PaymentEvidenceSessions() (EvidenceSessionListDTO, error) {
+	sessions := EvidenceSessionListDTO{}
+	response, err := client.http.Get("payments/evidence", url.Values{})
+	if err != nil {
+		return sessions, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &sessions)
+	return sessions, err
+}
+
+// PaymentEvidence returns signed payment evidence bundle of the session as it was exported by the node
+func (client *Client) PaymentEvidence(sessionID string) (json.RawMessage, error) {
+	var bundle json.RawMessage
+	response, err := client.http.Get("payments/evidence/"+sessionID, url.Values{})
+	if err != nil {
+		return bundle, err
+	}
+	defer response.Body.Close()
+
+	err = parseResponseJSON(response, &bundle)
+	return bundle, err
+}
+
+// FeedbackBundle writes the diagnostics bundle of the node to the given writer
+func (client *Client) FeedbackBundle(w io.Writer, mask bool) (int64, error) {
+	response, err := client.http.Get("feedback/bundle", url.Values{"mask": []string{strconv.FormatBool(mask)}})
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	return io.Copy(w, response.Body)
+}
+
+// Events streams node events to the handler until the context is done or the handler fails
+func (client *Client) Events(ctx context.Context, handler func(EventDTO) error) error {
+	response, err := client.http.Stream(ctx, "events/state")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var event EventDTO
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			return errors.Wrap(err, "could not parse event")
+		}
+		if err := handler(event); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errors.New("event stream closed by the node")
+}
+
+// StorageBackup writes a consistent snapshot of the node database to the given writer
+func (client *Client) StorageBackup(w io.Writer) (int64, error) {
+	response, err := client.http.Get("storage/backup", url.Values{})
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	return io.Copy(w, response.Body)
+}
@@ -18,6 +18,8 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -89,6 +91,36 @@ func TestConnectionErrorIsReturnedByClientInsteadOfDoubleParsing(t *testing.T) {
 	assert.True(t, responseBody.Closed)
 }
 
+func Test_Events_StreamsEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/events/state", r.URL.Path)
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"type\":\"state-change\",\"payload\":{\"natStatus\":{}}}\n\n"))
+		w.Write([]byte("data: {\"type\":\"nat\",\"payload\":null}\n\n"))
+	}))
+	defer server.Close()
+	client := Client{http: newHTTPClient(server.URL, "")}
+
+	var events []EventDTO
+	err := client.Events(context.Background(), func(event EventDTO) error {
+		events = append(events, event)
+		return nil
+	})
+
+	assert.EqualError(t, err, "event stream closed by the node")
+	assert.Len(t, events, 2)
+	assert.Equal(t, "state-change", events[0].Type)
+	assert.JSONEq(t, `{"natStatus":{}}`, string(events[0].Payload))
+	assert.Equal(t, "nat", events[1].Type)
+
+	stop := errors.New("stop")
+	err = client.Events(context.Background(), func(event EventDTO) error {
+		return stop
+	})
+	assert.Equal(t, stop, err)
+}
+
 func mockHTTPClient(t *testing.T, method, url string, statusCode int, response string) httpClientInterface {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, method, r.Method)
@@ -111,6 +111,18 @@ type AccessPoliciesRequest struct {
 	IDs []string `json:"ids"`
 }
 
+// PayoutInfoDTO holds payout info registered for identity
+type PayoutInfoDTO struct {
+	EthAddress   string `json:"eth_address"`
+	ReferralCode string `json:"referral_code"`
+	Email        string `json:"email"`
+}
+
+// ConfigDTO holds node user configuration
+type ConfigDTO struct {
+	Data map[string]interface{} `json:"data"`
+}
+
 // NATStatusDTO gives information about NAT traversal success or failure
 type NATStatusDTO struct {
 	Status string `json:"status"`
@@ -122,3 +134,77 @@ type SettleRequest struct {
 	AccountantID string `json:"accountant_id"`
 	ProviderID   string `json:"provider_id"`
 }
+
+// LoginRequestDTO holds user credentials
+type LoginRequestDTO struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// LoginResponseDTO holds JWT token issued on login
+type LoginResponseDTO struct {
+	Token     string   `json:"token"`
+	ExpiresAt string   `json:"expires_at"`
+	Scopes    []string `json:"scopes"`
+}
+
+// UserDTO describes Tequilapi user
+type UserDTO struct {
+	Username  string   `json:"username"`
+	Scopes    []string `json:"scopes"`
+	CreatedAt string   `json:"created_at,omitempty"`
+}
+
+// UserListDTO holds list of Tequilapi users
+type UserListDTO struct {
+	Users []UserDTO `json:"users"`
+}
+
+// CreateUserRequestDTO holds new Tequilapi user data
+type CreateUserRequestDTO struct {
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Scopes   []string `json:"scopes"`
+}
+
+// APITokenDTO describes long-lived API token
+type APITokenDTO struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	CreatedAt string   `json:"created_at"`
+	Token     string   `json:"token,omitempty"`
+}
+
+// APITokenListDTO holds list of API tokens
+type APITokenListDTO struct {
+	Tokens []APITokenDTO `json:"tokens"`
+}
+
+// CreateAPITokenRequestDTO holds new API token data
+type CreateAPITokenRequestDTO struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// EvidenceSessionListDTO holds sessions with recorded payment evidence
+type EvidenceSessionListDTO struct {
+	Sessions []EvidenceSessionDTO `json:"sessions"`
+}
+
+// EvidenceSessionDTO holds a session with recorded payment evidence
+type EvidenceSessionDTO struct {
+	SessionID  string `json:"session_id"`
+	Role       string `json:"role"`
+	ConsumerID string `json:"consumer_id"`
+	ProviderID string `json:"provider_id"`
+	Started    string `json:"started"`
+	Ended      string `json:"ended,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// EventDTO holds a node event streamed by the events endpoint
+type EventDTO struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
@@ -19,6 +19,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -36,6 +37,7 @@ type httpClientInterface interface {
 	Post(path string, payload interface{}) (*http.Response, error)
 	Put(path string, payload interface{}) (*http.Response, error)
 	Delete(path string, payload interface{}) (*http.Response, error)
+	Stream(ctx context.Context, path string) (*http.Response, error)
 }
 
 type httpRequestInterface interface {
@@ -51,9 +53,10 @@ func newHTTPClient(baseURL string, ua string) *httpClient {
 }
 
 type httpClient struct {
-	http    httpRequestInterface
-	baseURL string
-	ua      string
+	http      httpRequestInterface
+	baseURL   string
+	ua        string
+	authToken string
 }
 
 func (client *httpClient) Get(path string, values url.Values) (*http.Response, error) {
@@ -81,6 +84,37 @@ func (client *httpClient) Delete(path string, payload interface{}) (*http.Respon
 	return client.doPayloadRequest("DELETE", path, payload)
 }
 
+// Stream requests a long living response, which is read until the context is done.
+func (client *httpClient) Stream(ctx context.Context, path string) (*http.Response, error) {
+	request, err := http.NewRequest("GET", client.baseURL+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("User-Agent", client.ua)
+	request.Header.Set("Accept", "text/event-stream")
+	if client.authToken != "" {
+		request.Header.Set("Authorization", "Bearer "+client.authToken)
+	}
+
+	doer := client.http
+	if httpClient, ok := doer.(*http.Client); ok {
+		streamClient := *httpClient
+		streamClient.Timeout = 0
+		doer = &streamClient
+	}
+
+	response, err := doer.Do(request)
+	if err != nil {
+		return response, err
+	}
+	if err := parseResponseError(response); err != nil {
+		response.Body.Close()
+		return response, err
+	}
+	return response, nil
+}
+
 func (client httpClient) doPayloadRequest(method, path string, payload interface{}) (*http.Response, error) {
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
@@ -100,6 +134,9 @@ func (client *httpClient) executeRequest(method, fullPath string, payloadJSON []
 	request.Header.Set("User-Agent", client.ua)
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Accept", "application/json")
+	if client.authToken != "" {
+		request.Header.Set("Authorization", "Bearer "+client.authToken)
+	}
 
 	response, err := client.http.Do(request)
 
@@ -22,6 +22,7 @@ import (
 	"github.com/mysteriumnetwork/node/consumer/bandwidth"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/dns"
 	"github.com/mysteriumnetwork/node/tequilapi/validation"
 	"github.com/mysteriumnetwork/payments/crypto"
 )
@@ -43,6 +44,13 @@ func NewConnectionStatusDTO(session connection.Status) ConnectionStatusDTO {
 		proposalRes := NewProposalDTO(session.Proposal)
 		response.Proposal = &proposalRes
 	}
+	if session.LocalProxy != nil {
+		response.Proxy = &LocalProxyDTO{
+			Port:     session.LocalProxy.Port,
+			Username: session.LocalProxy.Username,
+			Password: session.LocalProxy.Password,
+		}
+	}
 	return response
 }
 
@@ -63,6 +71,22 @@ type ConnectionStatusDTO struct {
 
 	// example: 4cfb0324-daf6-4ad8-448b-e61fe0a1f918
 	SessionID string `json:"session_id,omitempty"`
+
+	// local proxy the tunnel is exposed on, set for connections started with proxy_port only
+	Proxy *LocalProxyDTO `json:"proxy,omitempty"`
+}
+
+// LocalProxyDTO holds the address and credentials of the local SOCKS5/HTTP proxy.
+// swagger:model LocalProxyDTO
+type LocalProxyDTO struct {
+	// example: 1080
+	Port int `json:"port"`
+
+	// example: myst
+	Username string `json:"username"`
+
+	// example: 2b7e151628aed2a6abf7158809cf4f3c
+	Password string `json:"password"`
 }
 
 // NewConnectionDTO maps to API connection.
@@ -121,6 +145,60 @@ type ConnectionStatisticsDTO struct {
 	TokensSpent uint64 `json:"tokens_spent"`
 }
 
+// NewConnectionDNSDTO maps to API local DNS proxy stats.
+func NewConnectionDNSDTO(stats dns.ConsumerStats, enabled bool) ConnectionDNSDTO {
+	return ConnectionDNSDTO{
+		Enabled:         enabled,
+		Active:          stats.Active,
+		SessionID:       stats.SessionID,
+		Servers:         stats.Servers,
+		Queries:         stats.Queries,
+		Refused:         stats.Refused,
+		CacheEntries:    stats.Cache.Entries,
+		CacheHits:       stats.Cache.Hits,
+		CacheMisses:     stats.Cache.Misses,
+		CachePrefetches: stats.Cache.Prefetches,
+	}
+}
+
+// ConnectionDNSDTO holds statistics of the consumer local DNS proxy caching answers of the tunnel DNS.
+// swagger:model ConnectionDNSDTO
+type ConnectionDNSDTO struct {
+	// local DNS proxy is enabled
+	// example: true
+	Enabled bool `json:"enabled"`
+
+	// queries are forwarded to the tunnel DNS servers
+	// example: true
+	Active bool `json:"active"`
+
+	// example: 4cfb0324-daf6-4ad8-448b-e61fe0a1f918
+	SessionID string `json:"session_id,omitempty"`
+
+	// tunnel DNS servers the queries are forwarded to
+	// example: ["10.182.0.1"]
+	Servers []string `json:"servers,omitempty"`
+
+	// example: 120
+	Queries uint64 `json:"queries"`
+
+	// queries refused while the tunnel was down
+	// example: 2
+	Refused uint64 `json:"refused"`
+
+	// example: 64
+	CacheEntries int `json:"cache_entries"`
+
+	// example: 80
+	CacheHits uint64 `json:"cache_hits"`
+
+	// example: 38
+	CacheMisses uint64 `json:"cache_misses"`
+
+	// example: 5
+	CachePrefetches uint64 `json:"cache_prefetches"`
+}
+
 // ConnectionCreateRequest request used to start a connection.
 // swagger:model ConnectionCreateRequestDTO
 type ConnectionCreateRequest struct {
@@ -134,12 +212,12 @@ type ConnectionCreateRequest struct {
 	// example: 0x0000000000000000000000000000000000000002
 	ProviderID string `json:"provider_id"`
 
-	// accountant identity
-	// required: true
+	// accountant identity, the cheapest accountant accepted by the provider is used if empty
+	// required: false
 	// example: 0x0000000000000000000000000000000000000003
 	AccountantID string `json:"accountant_id"`
 
-	// service type. Possible values are "openvpn", "wireguard" and "noop"
+	// service type. Possible values are "openvpn", "wireguard", "proxy" and "noop"
 	// required: false
 	// default: openvpn
 	// example: openvpn
@@ -159,9 +237,6 @@ func (cr ConnectionCreateRequest) Validate() *validation.FieldErrorMap {
 	if len(cr.ProviderID) == 0 {
 		errs.ForField("provider_id").AddError("required", "Field is required")
 	}
-	if len(cr.AccountantID) == 0 {
-		errs.ForField("accountant_id").AddError("required", "Field is required")
-	}
 	return errs
 }
 
@@ -175,6 +250,11 @@ type ConnectOptions struct {
 	// DNS to use
 	// required: false
 	// default: auto
-	// example: auto, provider, system, "1.1.1.1,8.8.8.8"
+	// example: auto, provider, system, "1.1.1.1,8.8.8.8", "doh:https://cloudflare-dns.com/dns-query,dot:dns.quad9.net"
 	DNS connection.DNSOption `json:"dns"`
+	// local port to expose the tunnel on as a SOCKS5/HTTP proxy instead of a system-wide tunnel,
+	// proxy credentials are returned in the connection status
+	// required: false
+	// example: 1080
+	ProxyPort int `json:"proxy_port,omitempty"`
 }
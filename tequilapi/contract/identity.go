@@ -18,7 +18,9 @@
 package contract
 
 import (
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/mysteriumnetwork/node/identity"
+	pingpong_event "github.com/mysteriumnetwork/node/session/pingpong/event"
 	"github.com/mysteriumnetwork/node/tequilapi/validation"
 )
 
@@ -43,6 +45,27 @@ type IdentityDTO struct {
 	Balance            uint64 `json:"balance"`
 	Earnings           uint64 `json:"earnings"`
 	EarningsTotal      uint64 `json:"earnings_total"`
+	// earnings with every accountant keyed by accountant address
+	EarningsPerAccountant map[string]EarningsDTO `json:"earnings_per_accountant,omitempty"`
+}
+
+// EarningsDTO holds identity earnings with a single accountant.
+// swagger:model EarningsDTO
+type EarningsDTO struct {
+	Earnings      uint64 `json:"earnings"`
+	EarningsTotal uint64 `json:"earnings_total"`
+}
+
+// NewEarningsPerAccountantDTO maps to API earnings keyed by accountant address.
+func NewEarningsPerAccountantDTO(earnings map[common.Address]pingpong_event.Earnings) map[string]EarningsDTO {
+	if len(earnings) == 0 {
+		return nil
+	}
+	res := make(map[string]EarningsDTO, len(earnings))
+	for accountantID, e := range earnings {
+		res[accountantID.Hex()] = EarningsDTO{Earnings: e.UnsettledBalance, EarningsTotal: e.LifetimeBalance}
+	}
+	return res
 }
 
 // NewIdentityDTO maps to API identity.
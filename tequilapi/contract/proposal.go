@@ -44,6 +44,7 @@ func NewProposalDTO(p market.ServiceProposal) ProposalDTO {
 		},
 		AccessPolicies: p.AccessPolicies,
 		PaymentMethod:  NewPaymentMethodDTO(p.PaymentMethod),
+		AccountantIDs:  p.AccountantIDs,
 	}
 }
 
@@ -85,6 +86,10 @@ type ProposalDTO struct {
 
 	// PaymentMethod
 	PaymentMethod PaymentMethodDTO `json:"payment_method"`
+
+	// accountants the provider accepts payments through
+	// example: ["0x0000000000000000000000000000000000000001"]
+	AccountantIDs []string `json:"accountant_ids,omitempty"`
 }
 
 func (p ProposalDTO) String() string {
@@ -20,6 +20,7 @@ package endpoints
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -33,12 +34,19 @@ type authenticationAPI struct {
 }
 
 type jwtAuthenticator interface {
-	CreateToken(username string) (auth.JWT, error)
+	CreateToken(username string, scopes auth.Scopes) (auth.JWT, error)
 }
 
 type authenticator interface {
 	CheckCredentials(username, password string) error
 	ChangePassword(username, oldPassword, newPassword string) error
+	UserScopes(username string) (auth.Scopes, error)
+	Users() ([]auth.User, error)
+	CreateUser(username, password string, scopes auth.Scopes) (auth.User, error)
+	DeleteUser(username string) error
+	APITokens() ([]auth.APIToken, error)
+	CreateAPIToken(name string, scopes auth.Scopes) (string, auth.APIToken, error)
+	RevokeAPIToken(id string) error
 }
 
 // swagger:model LoginRequest
@@ -47,6 +55,13 @@ type loginRequest struct {
 	Password string `json:"password"`
 }
 
+// swagger:model LoginResponse
+type loginResponse struct {
+	Token     string      `json:"token"`
+	ExpiresAt string      `json:"expires_at"`
+	Scopes    auth.Scopes `json:"scopes"`
+}
+
 // swagger:model ChangePasswordRequest
 type changePasswordRequest struct {
 	Username    string `json:"username"`
@@ -66,6 +81,8 @@ type changePasswordRequest struct {
 // responses:
 //   200:
 //     description: Logged in successfully
+//     schema:
+//       "$ref": "#/definitions/LoginResponse"
 //   400:
 //     description: Body parsing error
 //     schema:
@@ -89,7 +106,13 @@ func (api *authenticationAPI) Login(httpRes http.ResponseWriter, httpReq *http.R
 		return
 	}
 
-	jwtToken, err := api.jwtAuthenticator.CreateToken(req.Username)
+	scopes, err := api.authenticator.UserScopes(req.Username)
+	if err != nil {
+		utils.SendError(httpRes, err, http.StatusUnauthorized)
+		return
+	}
+
+	jwtToken, err := api.jwtAuthenticator.CreateToken(req.Username, scopes)
 	if err != nil {
 		utils.SendError(httpRes, err, http.StatusBadRequest)
 		return
@@ -103,6 +126,11 @@ func (api *authenticationAPI) Login(httpRes http.ResponseWriter, httpReq *http.R
 		Secure:   false,
 		Path:     "/",
 	})
+	utils.WriteAsJSON(loginResponse{
+		Token:     jwtToken.Token,
+		ExpiresAt: jwtToken.ExpirationTime.Format(time.RFC3339),
+		Scopes:    scopes,
+	}, httpRes)
 }
 
 // swagger:operation PUT /auth/password Authentication changePassword
@@ -140,6 +168,263 @@ func (api *authenticationAPI) ChangePassword(httpRes http.ResponseWriter, httpRe
 	}
 }
 
+// swagger:model UserDTO
+type userDTO struct {
+	Username  string      `json:"username"`
+	Scopes    auth.Scopes `json:"scopes"`
+	CreatedAt string      `json:"created_at,omitempty"`
+}
+
+// swagger:model UserListDTO
+type userListDTO struct {
+	Users []userDTO `json:"users"`
+}
+
+// swagger:model CreateUserRequest
+type createUserRequest struct {
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Scopes   []string `json:"scopes"`
+}
+
+// swagger:operation GET /auth/users Authentication listUsers
+// ---
+// summary: List users
+// description: Returns Tequilapi users and their scopes
+// responses:
+//   200:
+//     description: List of users
+//     schema:
+//       "$ref": "#/definitions/UserListDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (api *authenticationAPI) ListUsers(httpRes http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	users, err := api.authenticator.Users()
+	if err != nil {
+		utils.SendError(httpRes, err, http.StatusInternalServerError)
+		return
+	}
+
+	result := userListDTO{Users: make([]userDTO, len(users))}
+	for i, user := range users {
+		result.Users[i] = toUserDTO(user)
+	}
+	utils.WriteAsJSON(result, httpRes)
+}
+
+// swagger:operation POST /auth/users Authentication createUser
+// ---
+// summary: Create user
+// description: Creates a new Tequilapi user with the given scopes
+// parameters:
+//   - in: body
+//     name: body
+//     schema:
+//       $ref: "#/definitions/CreateUserRequest"
+// responses:
+//   201:
+//     description: User created
+//     schema:
+//       "$ref": "#/definitions/UserDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   409:
+//     description: User already exists
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (api *authenticationAPI) CreateUser(httpRes http.ResponseWriter, httpReq *http.Request, _ httprouter.Params) {
+	var req createUserRequest
+	if err := json.NewDecoder(httpReq.Body).Decode(&req); err != nil {
+		utils.SendError(httpRes, err, http.StatusBadRequest)
+		return
+	}
+	scopes, err := auth.ParseScopes(req.Scopes)
+	if err != nil {
+		utils.SendError(httpRes, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := api.authenticator.CreateUser(req.Username, req.Password, scopes)
+	if err == auth.ErrUserExists {
+		utils.SendError(httpRes, err, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		utils.SendError(httpRes, err, http.StatusBadRequest)
+		return
+	}
+
+	httpRes.WriteHeader(http.StatusCreated)
+	utils.WriteAsJSON(toUserDTO(user), httpRes)
+}
+
+// swagger:operation DELETE /auth/users/{username} Authentication deleteUser
+// ---
+// summary: Delete user
+// description: Deletes Tequilapi user
+// parameters:
+//   - in: path
+//     name: username
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: User deleted
+//   404:
+//     description: User not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (api *authenticationAPI) DeleteUser(httpRes http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	err := api.authenticator.DeleteUser(params.ByName("username"))
+	if err == auth.ErrUserNotFound {
+		utils.SendError(httpRes, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.SendError(httpRes, err, http.StatusBadRequest)
+		return
+	}
+	httpRes.WriteHeader(http.StatusAccepted)
+}
+
+// swagger:model APITokenDTO
+type apiTokenDTO struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	Scopes    auth.Scopes `json:"scopes"`
+	CreatedAt string      `json:"created_at"`
+	// Token value is returned only once, when the token is created
+	Token string `json:"token,omitempty"`
+}
+
+// swagger:model APITokenListDTO
+type apiTokenListDTO struct {
+	Tokens []apiTokenDTO `json:"tokens"`
+}
+
+// swagger:model CreateAPITokenRequest
+type createAPITokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// swagger:operation GET /auth/tokens Authentication listAPITokens
+// ---
+// summary: List API tokens
+// description: Returns long-lived API tokens without their values
+// responses:
+//   200:
+//     description: List of API tokens
+//     schema:
+//       "$ref": "#/definitions/APITokenListDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (api *authenticationAPI) ListAPITokens(httpRes http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	tokens, err := api.authenticator.APITokens()
+	if err != nil {
+		utils.SendError(httpRes, err, http.StatusInternalServerError)
+		return
+	}
+
+	result := apiTokenListDTO{Tokens: make([]apiTokenDTO, len(tokens))}
+	for i, token := range tokens {
+		result.Tokens[i] = toAPITokenDTO(token)
+	}
+	utils.WriteAsJSON(result, httpRes)
+}
+
+// swagger:operation POST /auth/tokens Authentication createAPIToken
+// ---
+// summary: Create API token
+// description: Creates a long-lived API token with the given scopes. Token value is returned only once.
+// parameters:
+//   - in: body
+//     name: body
+//     schema:
+//       $ref: "#/definitions/CreateAPITokenRequest"
+// responses:
+//   201:
+//     description: API token created
+//     schema:
+//       "$ref": "#/definitions/APITokenDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (api *authenticationAPI) CreateAPIToken(httpRes http.ResponseWriter, httpReq *http.Request, _ httprouter.Params) {
+	var req createAPITokenRequest
+	if err := json.NewDecoder(httpReq.Body).Decode(&req); err != nil {
+		utils.SendError(httpRes, err, http.StatusBadRequest)
+		return
+	}
+	scopes, err := auth.ParseScopes(req.Scopes)
+	if err != nil {
+		utils.SendError(httpRes, err, http.StatusBadRequest)
+		return
+	}
+
+	value, token, err := api.authenticator.CreateAPIToken(req.Name, scopes)
+	if err != nil {
+		utils.SendError(httpRes, err, http.StatusBadRequest)
+		return
+	}
+
+	dto := toAPITokenDTO(token)
+	dto.Token = value
+	httpRes.WriteHeader(http.StatusCreated)
+	utils.WriteAsJSON(dto, httpRes)
+}
+
+// swagger:operation DELETE /auth/tokens/{id} Authentication revokeAPIToken
+// ---
+// summary: Revoke API token
+// description: Revokes long-lived API token
+// parameters:
+//   - in: path
+//     name: id
+//     type: string
+//     required: true
+// responses:
+//   202:
+//     description: API token revoked
+//   404:
+//     description: API token not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (api *authenticationAPI) RevokeAPIToken(httpRes http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	if err := api.authenticator.RevokeAPIToken(params.ByName("id")); err != nil {
+		utils.SendError(httpRes, err, http.StatusNotFound)
+		return
+	}
+	httpRes.WriteHeader(http.StatusAccepted)
+}
+
+func toUserDTO(user auth.User) userDTO {
+	dto := userDTO{
+		Username: user.Username,
+		Scopes:   user.Scopes,
+	}
+	if !user.CreatedAt.IsZero() {
+		dto.CreatedAt = user.CreatedAt.Format(time.RFC3339)
+	}
+	return dto
+}
+
+func toAPITokenDTO(token auth.APIToken) apiTokenDTO {
+	return apiTokenDTO{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt.Format(time.RFC3339),
+	}
+}
+
 func toLoginRequest(req *http.Request) (*loginRequest, error) {
 	var loginReq = loginRequest{}
 	if err := json.NewDecoder(req.Body).Decode(&loginReq); err != nil {
@@ -171,4 +456,10 @@ func AddRoutesForAuthentication(
 	}
 	router.PUT("/auth/password", api.ChangePassword)
 	router.POST(TequilapiLoginEndpointPath, api.Login)
+	router.GET("/auth/users", api.ListUsers)
+	router.POST("/auth/users", api.CreateUser)
+	router.DELETE("/auth/users/:username", api.DeleteUser)
+	router.GET("/auth/tokens", api.ListAPITokens)
+	router.POST("/auth/tokens", api.CreateAPIToken)
+	router.DELETE("/auth/tokens/:id", api.RevokeAPIToken)
 }
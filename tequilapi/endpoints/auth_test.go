@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/core/auth"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb"
+	"github.com/mysteriumnetwork/node/core/storage/boltdb/boltdbtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAuthTestRouter(t *testing.T) (*httprouter.Router, func()) {
+	dir := boltdbtest.CreateTempDir(t)
+	db, err := boltdb.NewStorage(dir)
+	assert.NoError(t, err)
+
+	router := httprouter.New()
+	AddRoutesForAuthentication(router, auth.NewAuthenticator(db), auth.NewJWTAuthenticator([]byte("secret")))
+	return router, func() {
+		db.Close()
+		boltdbtest.RemoveTempDir(t, dir)
+	}
+}
+
+func TestAuthEndpoints_CreateUserAndLogin(t *testing.T) {
+	router, cleanup := newAuthTestRouter(t)
+	defer cleanup()
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/auth/users", strings.NewReader(`{"username": "dashboard", "password": "pass", "scopes": ["read"]}`))
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+
+	resp = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/auth/users", strings.NewReader(`{"username": "other", "password": "pass", "scopes": ["root"]}`))
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.JSONEq(t, `{"message": "unknown scope \"root\""}`, resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username": "dashboard", "password": "pass"}`))
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var login loginResponse
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &login))
+	assert.NotEmpty(t, login.Token)
+	assert.Equal(t, auth.Scopes{auth.ScopeRead}, login.Scopes)
+}
+
+func TestAuthEndpoints_APITokens(t *testing.T) {
+	router, cleanup := newAuthTestRouter(t)
+	defer cleanup()
+
+	resp := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/auth/tokens", strings.NewReader(`{"name": "grafana", "scopes": ["read"]}`))
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+
+	var created apiTokenDTO
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+	assert.True(t, strings.HasPrefix(created.Token, auth.APITokenPrefix))
+
+	resp = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/auth/tokens", nil)
+	router.ServeHTTP(resp, req)
+
+	var list apiTokenListDTO
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	assert.Len(t, list.Tokens, 1)
+	assert.Equal(t, "grafana", list.Tokens[0].Name)
+	assert.Empty(t, list.Tokens[0].Token)
+
+	resp = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/auth/tokens/"+created.ID, nil)
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+}
@@ -20,19 +20,17 @@ package endpoints
 import (
 	"encoding/json"
 	"net/http"
-	"reflect"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/config"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
-	"github.com/rs/zerolog/log"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
 )
 
 type configProvider interface {
 	GetUserConfig() map[string]interface{}
-	SetUser(key string, value interface{})
-	RemoveUser(key string)
-	SaveUserConfig() error
+	UpdateUser(changes map[string]interface{}) error
+	History() ([]config.Change, error)
 }
 
 // swagger:model configPayload
@@ -41,6 +39,11 @@ type configPayload struct {
 	Data map[string]interface{} `json:"data"`
 }
 
+// swagger:model configHistory
+type configHistory struct {
+	Changes []config.Change `json:"changes"`
+}
+
 type configAPI struct {
 	config configProvider
 }
@@ -72,7 +75,7 @@ func (api *configAPI) GetUserConfig(writer http.ResponseWriter, httpReq *http.Re
 // swagger:operation POST /user/config Configuration serUserConfig
 // ---
 // summary: Sets and returns user configuration
-// description: For keys present in the payload, it will set or remove the user config values (if the key is null). Changes are persisted to the config file.
+// description: For keys present in the payload, it will set or remove the user config values (if the key is null). Values are validated against known flags and either all changes are applied or none. Changes are persisted to the config file.
 // parameters:
 //   - in: body
 //     name: body
@@ -84,6 +87,10 @@ func (api *configAPI) GetUserConfig(writer http.ResponseWriter, httpReq *http.Re
 //     description: User configuration
 //     schema:
 //       "$ref": "#/definitions/configPayload"
+//   422:
+//     description: Parameters validation error
+//     schema:
+//       "$ref": "#/definitions/ValidationErrorDTO"
 //   500:
 //     description: Internal server error
 //     schema:
@@ -95,16 +102,15 @@ func (api *configAPI) SetUserConfig(writer http.ResponseWriter, httpReq *http.Re
 		utils.SendError(writer, err, http.StatusBadRequest)
 		return
 	}
-	for k, v := range req.Data {
-		if isNil(v) {
-			log.Debug().Msgf("Clearing user config value: %q", v)
-			api.config.RemoveUser(k)
-		} else {
-			log.Debug().Msgf("Setting user config value: %q = %q", k, v)
-			api.config.SetUser(k, v)
+	err = api.config.UpdateUser(req.Data)
+	if invalid, ok := err.(config.ValidationError); ok {
+		errorMap := validation.NewErrorMap()
+		for key, keyErr := range invalid {
+			errorMap.ForField(key).AddError("invalid", keyErr.Error())
 		}
+		utils.SendValidationErrorMessage(writer, errorMap)
+		return
 	}
-	err = api.config.SaveUserConfig()
 	if err != nil {
 		utils.SendError(writer, err, http.StatusInternalServerError)
 		return
@@ -112,15 +118,27 @@ func (api *configAPI) SetUserConfig(writer http.ResponseWriter, httpReq *http.Re
 	api.GetUserConfig(writer, nil, nil)
 }
 
-func isNil(val interface{}) bool {
-	if val == nil {
-		return true
-	}
-	v := reflect.ValueOf(val)
-	if v.Kind() == reflect.Ptr && v.IsNil() {
-		return true
+// GetHistory returns the audit log of user configuration changes
+// swagger:operation GET /config/history Configuration getConfigHistory
+// ---
+// summary: Returns user configuration change history
+// description: Returns applied and rejected user configuration changes made via API or by editing the config file, the oldest first
+// responses:
+//   200:
+//     description: Configuration changes
+//     schema:
+//       "$ref": "#/definitions/configHistory"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (api *configAPI) GetHistory(writer http.ResponseWriter, httpReq *http.Request, params httprouter.Params) {
+	changes, err := api.config.History()
+	if err != nil {
+		utils.SendError(writer, err, http.StatusInternalServerError)
+		return
 	}
-	return false
+	utils.WriteAsJSON(configHistory{Changes: changes}, writer)
 }
 
 // AddRoutesForConfig registers /config endpoints in Tequilapi
@@ -130,4 +148,5 @@ func AddRoutesForConfig(
 	api := newConfigAPI(config.Current)
 	router.GET("/config/user", api.GetUserConfig)
 	router.POST("/config/user", api.SetUserConfig)
+	router.GET("/config/history", api.GetHistory)
 }
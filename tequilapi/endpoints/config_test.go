@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockConfigProvider struct {
+	user      map[string]interface{}
+	updateErr error
+	history   []config.Change
+}
+
+func (m *mockConfigProvider) GetUserConfig() map[string]interface{} {
+	return m.user
+}
+
+func (m *mockConfigProvider) UpdateUser(changes map[string]interface{}) error {
+	if m.updateErr != nil {
+		return m.updateErr
+	}
+	for key, value := range changes {
+		m.user[key] = value
+	}
+	return nil
+}
+
+func (m *mockConfigProvider) History() ([]config.Change, error) {
+	return m.history, nil
+}
+
+func newConfigRouter(provider configProvider) *httprouter.Router {
+	api := newConfigAPI(provider)
+	router := httprouter.New()
+	router.POST("/config/user", api.SetUserConfig)
+	router.GET("/config/history", api.GetHistory)
+	return router
+}
+
+func TestConfigAPI_SetUserConfig(t *testing.T) {
+	router := newConfigRouter(&mockConfigProvider{user: map[string]interface{}{}})
+
+	req := httptest.NewRequest(http.MethodPost, "/config/user", strings.NewReader(`{"data":{"openvpn.port":1195}}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"data":{"openvpn.port":1195}}`, resp.Body.String())
+}
+
+func TestConfigAPI_SetUserConfigReturnsValidationErrors(t *testing.T) {
+	router := newConfigRouter(&mockConfigProvider{
+		user:      map[string]interface{}{},
+		updateErr: config.ValidationError{"openvpn.proto": errors.New("must be one of: udp, tcp")},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/config/user", strings.NewReader(`{"data":{"openvpn.proto":"icmp"}}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(t, `{
+		"message": "validation_error",
+		"errors": {"openvpn.proto": [{"code": "invalid", "message": "must be one of: udp, tcp"}]}
+	}`, resp.Body.String())
+}
+
+func TestConfigAPI_GetHistory(t *testing.T) {
+	router := newConfigRouter(&mockConfigProvider{history: []config.Change{
+		{Time: time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC), Source: config.ChangeSourceFile, Key: "openvpn.port", OldValue: 1194, NewValue: 1195, Applied: true},
+	}})
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/config/history", nil))
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"changes": [{
+		"time": "2020-04-01T10:00:00Z",
+		"source": "file",
+		"key": "openvpn.port",
+		"old_value": 1194,
+		"new_value": 1195,
+		"applied": true
+	}]}`, resp.Body.String())
+}
@@ -56,6 +56,10 @@ type identityRegistry interface {
 	GetRegistrationStatus(identity.Identity) (registry.RegistrationStatus, error)
 }
 
+type accountantSelector interface {
+	Select(requested common.Address, proposal market.ServiceProposal) (common.Address, error)
+}
+
 // ConnectionEndpoint struct represents /connection resource and it's subresources
 type ConnectionEndpoint struct {
 	manager       connection.Manager
@@ -63,15 +67,17 @@ type ConnectionEndpoint struct {
 	//TODO connection should use concrete proposal from connection params and avoid going to marketplace
 	proposalRepository proposal.Repository
 	identityRegistry   identityRegistry
+	accountantSelector accountantSelector
 }
 
 // NewConnectionEndpoint creates and returns connection endpoint
-func NewConnectionEndpoint(manager connection.Manager, stateProvider stateProvider, proposalRepository proposal.Repository, identityRegistry identityRegistry) *ConnectionEndpoint {
+func NewConnectionEndpoint(manager connection.Manager, stateProvider stateProvider, proposalRepository proposal.Repository, identityRegistry identityRegistry, accountantSelector accountantSelector) *ConnectionEndpoint {
 	return &ConnectionEndpoint{
 		manager:            manager,
 		stateProvider:      stateProvider,
 		proposalRepository: proposalRepository,
 		identityRegistry:   identityRegistry,
+		accountantSelector: accountantSelector,
 	}
 }
 
@@ -173,7 +179,13 @@ func (ce *ConnectionEndpoint) Create(resp http.ResponseWriter, req *http.Request
 		return
 	}
 
-	err = ce.manager.Connect(consumerID, common.HexToAddress(cr.AccountantID), *proposal, getConnectOptions(cr))
+	accountantID, err := ce.accountantSelector.Select(common.HexToAddress(cr.AccountantID), *proposal)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	err = ce.manager.Connect(consumerID, accountantID, *proposal, getConnectOptions(cr))
 
 	if err != nil {
 		switch err {
@@ -242,14 +254,30 @@ func (ce *ConnectionEndpoint) GetStatistics(writer http.ResponseWriter, request
 	utils.WriteAsJSON(response, writer)
 }
 
+// GetDNS returns statistics of the local DNS proxy
+// swagger:operation GET /connection/dns Connection connectionDNS
+// ---
+// summary: Returns local DNS proxy statistics
+// description: Returns cache statistics of the local DNS proxy the tunnel DNS is pointed to
+// responses:
+//   200:
+//     description: Local DNS proxy statistics
+//     schema:
+//       "$ref": "#/definitions/ConnectionDNSDTO"
+func (ce *ConnectionEndpoint) GetDNS(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+	stats, enabled := ce.manager.DNSStats()
+	utils.WriteAsJSON(contract.NewConnectionDNSDTO(stats, enabled), writer)
+}
+
 // AddRoutesForConnection adds connections routes to given router
 func AddRoutesForConnection(router *httprouter.Router, manager connection.Manager,
-	stateProvider stateProvider, proposalRepository proposal.Repository, identityRegistry identityRegistry) {
-	connectionEndpoint := NewConnectionEndpoint(manager, stateProvider, proposalRepository, identityRegistry)
+	stateProvider stateProvider, proposalRepository proposal.Repository, identityRegistry identityRegistry, accountantSelector accountantSelector) {
+	connectionEndpoint := NewConnectionEndpoint(manager, stateProvider, proposalRepository, identityRegistry, accountantSelector)
 	router.GET("/connection", connectionEndpoint.Status)
 	router.PUT("/connection", connectionEndpoint.Create)
 	router.DELETE("/connection", connectionEndpoint.Kill)
 	router.GET("/connection/statistics", connectionEndpoint.GetStatistics)
+	router.GET("/connection/dns", connectionEndpoint.GetDNS)
 }
 
 func toConnectionRequest(req *http.Request) (*contract.ConnectionCreateRequest, error) {
@@ -278,5 +306,6 @@ func getConnectOptions(cr *contract.ConnectionCreateRequest) connection.ConnectP
 	return connection.ConnectParams{
 		DisableKillSwitch: cr.ConnectOptions.DisableKillSwitch,
 		DNS:               dns,
+		ProxyPort:         cr.ConnectOptions.ProxyPort,
 	}
 }
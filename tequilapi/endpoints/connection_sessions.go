@@ -24,6 +24,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/consumer/session"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/pkg/errors"
 )
 
 // connectionSessionsList defines session list representable as json
@@ -74,7 +75,7 @@ type connectionSession struct {
 }
 
 type connectionSessionStorage interface {
-	GetAll() ([]session.History, error)
+	List(filter session.Filter) ([]session.History, error)
 }
 
 type connectionSessionsEndpoint struct {
@@ -91,18 +92,56 @@ func NewConnectionSessionsEndpoint(sessionStorage connectionSessionStorage) *con
 // swagger:operation GET /connection-sessions Connection connectionSessions
 // ---
 // summary: Returns sessions history
-// description: Returns list of sessions history
+// description: Returns list of sessions history ordered by start time
+// parameters:
+// - in: query
+//   name: date_from
+//   description: Lists sessions started at or after the date, given as RFC3339 time or YYYY-MM-DD
+//   type: string
+// - in: query
+//   name: date_to
+//   description: Lists sessions started before the date, given as RFC3339 time or YYYY-MM-DD
+//   type: string
+// - in: query
+//   name: status
+//   description: Session status to filter by, New or Completed
+//   type: string
+// - in: query
+//   name: service_type
+//   description: Service type to filter by
+//   type: string
 // responses:
 //   200:
 //     description: List of sessions
 //     schema:
 //       "$ref": "#/definitions/ConnectionSessionListDTO"
+//   400:
+//     description: Invalid date filter
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
 //   500:
 //     description: Internal server error
 //     schema:
 //       "$ref": "#/definitions/ErrorMessageDTO"
 func (endpoint *connectionSessionsEndpoint) List(resp http.ResponseWriter, request *http.Request, params httprouter.Params) {
-	sessions, err := endpoint.sessionStorage.GetAll()
+	query := request.URL.Query()
+	from, err := parseDateFilter(query.Get("date_from"))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	to, err := parseDateFilter(query.Get("date_to"))
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	sessions, err := endpoint.sessionStorage.List(session.Filter{
+		From:        from,
+		To:          to,
+		Status:      query.Get("status"),
+		ServiceType: query.Get("service_type"),
+	})
 	if err != nil {
 		utils.SendError(resp, err, http.StatusInternalServerError)
 		return
@@ -118,6 +157,20 @@ func AddRoutesForConnectionSessions(router *httprouter.Router, sessionStorage co
 	router.GET("/connection-sessions", sessionsEndpoint.List)
 }
 
+func parseDateFilter(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid date %q, expected RFC3339 time or YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
 func connectionSessionToDto(se session.History) connectionSession {
 	return connectionSession{
 		SessionID:       string(se.SessionID),
@@ -91,6 +91,27 @@ func Test_ConnectionSessionsEndpoint_List(t *testing.T) {
 	assert.EqualValues(t, connectionSessionToDto(connectionSessionMock), parsedResponse.Sessions[0])
 }
 
+func Test_ConnectionSessionsEndpoint_ListFilters(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/connection-sessions?date_from=2020-04-01&date_to=2020-04-02T10:00:00Z&status=Completed&service_type=wireguard", nil)
+	ssm := &connectionSessionStorageMock{}
+
+	resp := httptest.NewRecorder()
+	NewConnectionSessionsEndpoint(ssm).List(resp, req, nil)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, session.Filter{
+		From:        time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2020, 4, 2, 10, 0, 0, 0, time.UTC),
+		Status:      "Completed",
+		ServiceType: "wireguard",
+	}, ssm.filter)
+
+	req = httptest.NewRequest(http.MethodGet, "/connection-sessions?date_from=yesterday", nil)
+	resp = httptest.NewRecorder()
+	NewConnectionSessionsEndpoint(ssm).List(resp, req, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
 func Test_ConnectionSessionsEndpoint_ListBubblesError(t *testing.T) {
 	req, err := http.NewRequest(
 		http.MethodGet,
@@ -119,8 +140,10 @@ func Test_ConnectionSessionsEndpoint_ListBubblesError(t *testing.T) {
 type connectionSessionStorageMock struct {
 	sessionsToReturn []session.History
 	errToReturn      error
+	filter           session.Filter
 }
 
-func (ssm *connectionSessionStorageMock) GetAll() ([]session.History, error) {
+func (ssm *connectionSessionStorageMock) List(filter session.Filter) ([]session.History, error) {
+	ssm.filter = filter
 	return ssm.sessionsToReturn, ssm.errToReturn
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/mysteriumnetwork/node/consumer/bandwidth"
 	"github.com/mysteriumnetwork/node/core/connection"
 	"github.com/mysteriumnetwork/node/datasize"
+	"github.com/mysteriumnetwork/node/dns"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
 	"github.com/mysteriumnetwork/node/market"
@@ -46,6 +47,7 @@ type mockConnectionManager struct {
 	requestedProvider     identity.Identity
 	requestedAccountantID common.Address
 	requestedServiceType  string
+	dnsStats              *dns.ConsumerStats
 }
 
 func (cm *mockConnectionManager) Connect(consumerID identity.Identity, accountantID common.Address, proposal market.ServiceProposal, options connection.ConnectParams) error {
@@ -66,10 +68,32 @@ func (cm *mockConnectionManager) Disconnect() error {
 	return cm.onDisconnectReturn
 }
 
+func (cm *mockConnectionManager) DNSStats() (dns.ConsumerStats, bool) {
+	if cm.dnsStats == nil {
+		return dns.ConsumerStats{}, false
+	}
+	return *cm.dnsStats, true
+}
+
 func (cm *mockConnectionManager) Wait() error {
 	return nil
 }
 
+type mockAccountantSelector struct {
+	accountantToReturn common.Address
+	errToReturn        error
+}
+
+func (mas *mockAccountantSelector) Select(requested common.Address, _ market.ServiceProposal) (common.Address, error) {
+	if mas.errToReturn != nil {
+		return common.Address{}, mas.errToReturn
+	}
+	if requested != (common.Address{}) {
+		return requested, nil
+	}
+	return mas.accountantToReturn, nil
+}
+
 func mockRepositoryWithProposal(providerID, serviceType string) *mockProposalRepository {
 	sampleProposal := market.ServiceProposal{
 		ID:                1,
@@ -94,7 +118,7 @@ func TestAddRoutesForConnectionAddsRoutes(t *testing.T) {
 	fakeState.stateToReturn.Connection.Statistics = connection.Statistics{BytesSent: 1, BytesReceived: 2}
 
 	mockedProposalProvider := mockRepositoryWithProposal("node1", "noop")
-	AddRoutesForConnection(router, fakeManager, fakeState, mockedProposalProvider, mockIdentityRegistryInstance)
+	AddRoutesForConnection(router, fakeManager, fakeState, mockedProposalProvider, mockIdentityRegistryInstance, &mockAccountantSelector{})
 
 	tests := []struct {
 		method         string
@@ -153,7 +177,7 @@ func TestStateIsReturnedFromStore(t *testing.T) {
 		},
 	}
 
-	connEndpoint := NewConnectionEndpoint(manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, &mockAccountantSelector{})
 	req := httptest.NewRequest(http.MethodGet, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -173,7 +197,7 @@ func TestStateIsReturnedFromStore(t *testing.T) {
 func TestPutReturns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, &mockAccountantSelector{})
 	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("a"))
 	resp := httptest.NewRecorder()
 
@@ -192,7 +216,7 @@ func TestPutReturns400ErrorIfRequestBodyIsNotJSON(t *testing.T) {
 func TestPutReturns422ErrorIfRequestBodyIsMissingFieldValues(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, &mockAccountantSelector{})
 	req := httptest.NewRequest(http.MethodPut, "/irrelevant", strings.NewReader("{}"))
 	resp := httptest.NewRecorder()
 
@@ -205,7 +229,6 @@ func TestPutReturns422ErrorIfRequestBodyIsMissingFieldValues(t *testing.T) {
 		`{
 			"message" : "validation_error",
 			"errors" : {
-				"accountant_id" : [ {"code" : "required" , "message" : "Field is required" } ],
 				"consumer_id" : [ { "code" : "required" , "message" : "Field is required" } ],
 				"provider_id" : [ {"code" : "required" , "message" : "Field is required" } ]
 			}
@@ -222,7 +245,7 @@ func TestPutWithValidBodyCreatesConnection(t *testing.T) {
 	fakeState.stateToReturn.Connection.Session = state
 
 	proposalProvider := mockRepositoryWithProposal("required-node", "openvpn")
-	connEndpoint := NewConnectionEndpoint(&fakeManager, fakeState, proposalProvider, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, fakeState, proposalProvider, mockIdentityRegistryInstance, &mockAccountantSelector{})
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -252,6 +275,52 @@ func TestPutWithValidBodyCreatesConnection(t *testing.T) {
 	)
 }
 
+func TestPutWithoutAccountantUsesSelectedAccountant(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+	selector := &mockAccountantSelector{accountantToReturn: common.HexToAddress("0x0000000000000000000000000000000000000003")}
+
+	proposalProvider := mockRepositoryWithProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, proposalProvider, mockIdentityRegistryInstance, selector)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node"
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, common.HexToAddress("0x0000000000000000000000000000000000000003"), fakeManager.requestedAccountantID)
+}
+
+func TestPutWithNotAcceptedAccountantReturnsError(t *testing.T) {
+	fakeManager := mockConnectionManager{}
+	selector := &mockAccountantSelector{errToReturn: errors.New("accountant not accepted")}
+
+	proposalProvider := mockRepositoryWithProposal("required-node", "openvpn")
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, proposalProvider, mockIdentityRegistryInstance, selector)
+	req := httptest.NewRequest(
+		http.MethodPut,
+		"/irrelevant",
+		strings.NewReader(
+			`{
+				"consumer_id" : "my-identity",
+				"provider_id" : "required-node",
+				"accountant_id" : "0x0000000000000000000000000000000000000004"
+			}`))
+	resp := httptest.NewRecorder()
+
+	connEndpoint.Create(resp, req, httprouter.Params{})
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.JSONEq(t, `{"message":"accountant not accepted"}`, resp.Body.String())
+	assert.Equal(t, identity.Identity{}, fakeManager.requestedConsumerID)
+}
+
 func TestPutUnregisteredIdentityReturnsError(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
@@ -259,7 +328,7 @@ func TestPutUnregisteredIdentityReturnsError(t *testing.T) {
 	mir := *mockIdentityRegistryInstance
 	mir.RegistrationStatus = registry.Unregistered
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, proposalProvider, &mir)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, proposalProvider, &mir, &mockAccountantSelector{})
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -288,7 +357,7 @@ func TestPutFailedRegistrationCheckReturnsError(t *testing.T) {
 	mir := *mockIdentityRegistryInstance
 	mir.RegistrationCheckError = errors.New("explosions everywhere")
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, proposalProvider, &mir)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, proposalProvider, &mir, &mockAccountantSelector{})
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -314,7 +383,7 @@ func TestPutWithServiceTypeOverridesDefault(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
 	mystAPI := mockRepositoryWithProposal("required-node", "noop")
-	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, &mockStateProvider{}, mystAPI, mockIdentityRegistryInstance, &mockAccountantSelector{})
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -340,7 +409,7 @@ func TestPutWithServiceTypeOverridesDefault(t *testing.T) {
 func TestDeleteCallsDisconnect(t *testing.T) {
 	fakeManager := mockConnectionManager{}
 
-	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&fakeManager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, &mockAccountantSelector{})
 	req := httptest.NewRequest(http.MethodDelete, "/irrelevant", nil)
 	resp := httptest.NewRecorder()
 
@@ -358,7 +427,7 @@ func TestGetStatisticsEndpointReturnsStatistics(t *testing.T) {
 	fakeState.stateToReturn.Connection.Invoice = crypto.Invoice{AgreementTotal: 10001}
 
 	manager := mockConnectionManager{}
-	connEndpoint := NewConnectionEndpoint(&manager, fakeState, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connEndpoint := NewConnectionEndpoint(&manager, fakeState, &mockProposalRepository{}, mockIdentityRegistryInstance, &mockAccountantSelector{})
 
 	resp := httptest.NewRecorder()
 	connEndpoint.GetStatistics(resp, nil, nil)
@@ -376,12 +445,61 @@ func TestGetStatisticsEndpointReturnsStatistics(t *testing.T) {
 	)
 }
 
+func TestGetDNSEndpointReturnsStats(t *testing.T) {
+	manager := mockConnectionManager{}
+	connEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, &mockAccountantSelector{})
+
+	resp := httptest.NewRecorder()
+	connEndpoint.GetDNS(resp, nil, nil)
+	assert.JSONEq(
+		t,
+		`{
+			"enabled": false,
+			"active": false,
+			"queries": 0,
+			"refused": 0,
+			"cache_entries": 0,
+			"cache_hits": 0,
+			"cache_misses": 0,
+			"cache_prefetches": 0
+		}`,
+		resp.Body.String(),
+	)
+
+	manager.dnsStats = &dns.ConsumerStats{
+		SessionID: "session1",
+		Active:    true,
+		Servers:   []string{"10.182.0.1"},
+		Queries:   10,
+		Refused:   1,
+		Cache:     dns.CacheStats{Entries: 3, Hits: 6, Misses: 3, Prefetches: 1},
+	}
+	resp = httptest.NewRecorder()
+	connEndpoint.GetDNS(resp, nil, nil)
+	assert.JSONEq(
+		t,
+		`{
+			"enabled": true,
+			"active": true,
+			"session_id": "session1",
+			"servers": ["10.182.0.1"],
+			"queries": 10,
+			"refused": 1,
+			"cache_entries": 3,
+			"cache_hits": 6,
+			"cache_misses": 3,
+			"cache_prefetches": 1
+		}`,
+		resp.Body.String(),
+	)
+}
+
 func TestEndpointReturnsConflictStatusIfConnectionAlreadyExists(t *testing.T) {
 	manager := mockConnectionManager{}
 	manager.onConnectReturn = connection.ErrAlreadyExists
 
 	mystAPI := mockRepositoryWithProposal("required-node", "openvpn")
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, mystAPI, mockIdentityRegistryInstance)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, mystAPI, mockIdentityRegistryInstance, &mockAccountantSelector{})
 
 	req := httptest.NewRequest(
 		http.MethodPut,
@@ -410,7 +528,7 @@ func TestDisconnectReturnsConflictStatusIfConnectionDoesNotExist(t *testing.T) {
 	manager := mockConnectionManager{}
 	manager.onDisconnectReturn = connection.ErrNoConnection
 
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, &mockAccountantSelector{})
 
 	req := httptest.NewRequest(
 		http.MethodDelete,
@@ -436,7 +554,7 @@ func TestConnectReturnsConnectCancelledStatusWhenErrConnectionCancelledIsEncount
 	manager.onConnectReturn = connection.ErrConnectionCancelled
 
 	mockProposalProvider := mockRepositoryWithProposal("required-node", "openvpn")
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, mockProposalProvider, mockIdentityRegistryInstance)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, mockProposalProvider, mockIdentityRegistryInstance, &mockAccountantSelector{})
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -464,7 +582,7 @@ func TestConnectReturnsErrorIfNoProposals(t *testing.T) {
 	manager := mockConnectionManager{}
 	manager.onConnectReturn = connection.ErrConnectionCancelled
 
-	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance)
+	connectionEndpoint := NewConnectionEndpoint(&manager, nil, &mockProposalRepository{}, mockIdentityRegistryInstance, &mockAccountantSelector{})
 	req := httptest.NewRequest(
 		http.MethodPut,
 		"/irrelevant",
@@ -19,7 +19,9 @@ package endpoints
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/feedback"
@@ -28,12 +30,17 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+type diagnosticsBundler interface {
+	Write(w io.Writer, opts feedback.BundleOptions) error
+}
+
 type feedbackAPI struct {
 	reporter *feedback.Reporter
+	bundler  diagnosticsBundler
 }
 
-func newFeedbackAPI(reporter *feedback.Reporter) *feedbackAPI {
-	return &feedbackAPI{reporter: reporter}
+func newFeedbackAPI(reporter *feedback.Reporter, bundler diagnosticsBundler) *feedbackAPI {
+	return &feedbackAPI{reporter: reporter, bundler: bundler}
 }
 
 // ReportIssueRequest params for issue report
@@ -109,11 +116,51 @@ func (api *feedbackAPI) ReportIssue(httpRes http.ResponseWriter, httpReq *http.R
 	utils.WriteAsJSON(result.Response, httpRes)
 }
 
+// DiagnosticsBundle downloads local diagnostics bundle
+// swagger:operation GET /feedback/bundle Feedback diagnosticsBundle
+// ---
+// summary: Downloads diagnostics bundle
+// description: Collects logs, redacted configuration, node state, NAT and connectivity status, firewall rules and build info into a ZIP archive. Nothing is sent to the feedback service.
+// parameters:
+//   - in: query
+//     name: mask
+//     description: Mask IP addresses and secrets in the bundle
+//     type: boolean
+// produces:
+// - application/zip
+// responses:
+//   200:
+//     description: Diagnostics bundle
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (api *feedbackAPI) DiagnosticsBundle(httpRes http.ResponseWriter, httpReq *http.Request, _ httprouter.Params) {
+	opts := feedback.BundleOptions{}
+	if mask := httpReq.URL.Query().Get("mask"); mask != "" {
+		value, err := strconv.ParseBool(mask)
+		if err != nil {
+			utils.SendError(httpRes, errors.Wrap(err, "invalid mask parameter"), http.StatusBadRequest)
+			return
+		}
+		opts.Mask = value
+	}
+
+	httpRes.Header().Set("Content-Type", "application/zip")
+	httpRes.Header().Set("Content-Disposition", `attachment; filename="myst-diagnostics.zip"`)
+	// the status is already sent once the bundle starts streaming, so failures can only be logged
+	if err := api.bundler.Write(httpRes, opts); err != nil {
+		log.Error().Err(err).Msg("Failed to stream diagnostics bundle")
+	}
+}
+
 // AddRoutesForFeedback registers feedback routes
 func AddRoutesForFeedback(
 	router *httprouter.Router,
 	reporter *feedback.Reporter,
+	bundler diagnosticsBundler,
 ) {
-	api := newFeedbackAPI(reporter)
+	api := newFeedbackAPI(reporter, bundler)
 	router.POST("/feedback/issue", api.ReportIssue)
+	router.GET("/feedback/bundle", api.DiagnosticsBundle)
 }
@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/feedback"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDiagnosticsBundler struct {
+	opts feedback.BundleOptions
+}
+
+func (mdb *mockDiagnosticsBundler) Write(w io.Writer, opts feedback.BundleOptions) error {
+	mdb.opts = opts
+	_, err := io.WriteString(w, "bundle")
+	return err
+}
+
+func Test_Feedback_DiagnosticsBundle(t *testing.T) {
+	bundler := &mockDiagnosticsBundler{}
+	router := httprouter.New()
+	AddRoutesForFeedback(router, nil, bundler)
+
+	req := httptest.NewRequest(http.MethodGet, "/feedback/bundle?mask=true", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/zip", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "bundle", resp.Body.String())
+	assert.True(t, bundler.opts.Mask)
+}
+
+func Test_Feedback_DiagnosticsBundle_InvalidMask(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForFeedback(router, nil, &mockDiagnosticsBundler{})
+
+	req := httptest.NewRequest(http.MethodGet, "/feedback/bundle?mask=maybe", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/julienschmidt/httprouter"
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
@@ -39,6 +40,7 @@ type balanceProvider interface {
 
 type earningsProvider interface {
 	GetEarnings(id identity.Identity) pingpong_event.Earnings
+	GetEarningsByAccountant(id identity.Identity) map[common.Address]pingpong_event.Earnings
 }
 
 type identitiesAPI struct {
@@ -274,12 +276,13 @@ func (endpoint *identitiesAPI) Get(resp http.ResponseWriter, _ *http.Request, pa
 	balance := endpoint.balanceProvider.ForceBalanceUpdate(id)
 	settlement := endpoint.earningsProvider.GetEarnings(id)
 	status := contract.IdentityDTO{
-		Address:            address,
-		RegistrationStatus: regStatus.String(),
-		ChannelAddress:     channelAddress.Hex(),
-		Balance:            balance,
-		Earnings:           settlement.UnsettledBalance,
-		EarningsTotal:      settlement.LifetimeBalance,
+		Address:               address,
+		RegistrationStatus:    regStatus.String(),
+		ChannelAddress:        channelAddress.Hex(),
+		Balance:               balance,
+		Earnings:              settlement.UnsettledBalance,
+		EarningsTotal:         settlement.LifetimeBalance,
+		EarningsPerAccountant: contract.NewEarningsPerAccountantDTO(endpoint.earningsProvider.GetEarningsByAccountant(id)),
 	}
 	utils.WriteAsJSON(status, resp)
 }
@@ -0,0 +1,243 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/pkg/errors"
+)
+
+// ledgerEntryList defines ledger entry list representable as json
+// swagger:model LedgerEntryListDTO
+type ledgerEntryList struct {
+	Entries []ledgerEntry `json:"entries"`
+}
+
+// ledgerEntry represents a single transactor interaction
+// swagger:model LedgerEntryDTO
+type ledgerEntry struct {
+	// example: 1
+	ID int64 `json:"id"`
+
+	// example: settlement
+	Type string `json:"type"`
+
+	// example: 0x0000000000000000000000000000000000000001
+	Identity string `json:"identity"`
+
+	// example: 0x0000000000000000000000000000000000000001
+	AccountantID string `json:"accountant_id"`
+
+	// example: 500000
+	Amount uint64 `json:"amount"`
+
+	// example: 1000
+	Fee uint64 `json:"fee"`
+
+	// keccak hash of the settled promise
+	// example: 9c22ff5f21f0b81b113e63f7db6da94fedef11b2119b4088b89664fb9a3cb658
+	PromiseHash string `json:"promise_hash,omitempty"`
+	TxHash      string `json:"tx_hash,omitempty"`
+
+	// example: confirmed
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+
+	// example: 2020-04-01T10:00:00Z
+	Created string `json:"created"`
+
+	// example: 2020-04-01T10:00:00Z
+	Updated string `json:"updated"`
+}
+
+var ledgerCSVHeader = []string{"id", "type", "identity", "accountant_id", "amount", "fee", "promise_hash", "tx_hash", "status", "error", "created", "updated"}
+
+type transactionLedger interface {
+	List(filter pingpong.LedgerFilter) ([]pingpong.LedgerEntry, error)
+}
+
+type ledgerEndpoint struct {
+	ledger transactionLedger
+}
+
+// NewLedgerEndpoint creates and returns transaction ledger endpoint
+func NewLedgerEndpoint(ledger transactionLedger) *ledgerEndpoint {
+	return &ledgerEndpoint{
+		ledger: ledger,
+	}
+}
+
+// swagger:operation GET /transactor/ledger Transactor ledgerList
+// ---
+// summary: Returns transaction ledger
+// description: Returns registrations, top ups and settlements sent to Transactor, newest first
+// parameters:
+// - in: query
+//   name: identity
+//   description: Identity to filter the entries by
+//   type: string
+// - in: query
+//   name: type
+//   description: Entry type to filter by, one of registration, topup or settlement
+//   type: string
+// responses:
+//   200:
+//     description: List of ledger entries
+//     schema:
+//       "$ref": "#/definitions/LedgerEntryListDTO"
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *ledgerEndpoint) List(resp http.ResponseWriter, request *http.Request, _ httprouter.Params) {
+	entries, status, err := endpoint.entries(request)
+	if err != nil {
+		utils.SendError(resp, err, status)
+		return
+	}
+
+	result := ledgerEntryList{Entries: make([]ledgerEntry, len(entries))}
+	for i, entry := range entries {
+		result.Entries[i] = ledgerEntryToDto(entry)
+	}
+	utils.WriteAsJSON(result, resp)
+}
+
+// swagger:operation GET /transactor/ledger/csv Transactor ledgerCSV
+// ---
+// summary: Exports transaction ledger
+// description: Returns the transaction ledger as CSV, accepts the same filters as the JSON listing
+// produces:
+// - text/csv
+// parameters:
+// - in: query
+//   name: identity
+//   description: Identity to filter the entries by
+//   type: string
+// - in: query
+//   name: type
+//   description: Entry type to filter by, one of registration, topup or settlement
+//   type: string
+// responses:
+//   200:
+//     description: Ledger entries as CSV
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *ledgerEndpoint) ExportCSV(resp http.ResponseWriter, request *http.Request, _ httprouter.Params) {
+	entries, status, err := endpoint.entries(request)
+	if err != nil {
+		utils.SendError(resp, err, status)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "text/csv")
+	resp.Header().Set("Content-Disposition", `attachment; filename="ledger.csv"`)
+	writer := csv.NewWriter(resp)
+	writer.Write(ledgerCSVHeader)
+	for _, entry := range entries {
+		dto := ledgerEntryToDto(entry)
+		writer.Write([]string{
+			strconv.FormatInt(dto.ID, 10),
+			dto.Type,
+			dto.Identity,
+			dto.AccountantID,
+			strconv.FormatUint(dto.Amount, 10),
+			strconv.FormatUint(dto.Fee, 10),
+			dto.PromiseHash,
+			dto.TxHash,
+			dto.Status,
+			dto.Error,
+			dto.Created,
+			dto.Updated,
+		})
+	}
+	writer.Flush()
+}
+
+func (endpoint *ledgerEndpoint) entries(request *http.Request) ([]pingpong.LedgerEntry, int, error) {
+	filter := pingpong.LedgerFilter{}
+	query := request.URL.Query()
+	if address := query.Get("identity"); address != "" {
+		id := identity.FromAddress(address)
+		filter.Identity = &id
+	}
+	if entryType := query.Get("type"); entryType != "" {
+		t := pingpong.LedgerEntryType(entryType)
+		switch t {
+		case pingpong.LedgerEntryRegistration, pingpong.LedgerEntryTopUp, pingpong.LedgerEntrySettlement:
+			filter.Type = &t
+		default:
+			return nil, http.StatusBadRequest, fmt.Errorf("unknown ledger entry type %q", entryType)
+		}
+	}
+
+	entries, err := endpoint.ledger.List(filter)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.Wrap(err, "could not list ledger entries")
+	}
+	return entries, http.StatusOK, nil
+}
+
+func ledgerEntryToDto(entry pingpong.LedgerEntry) ledgerEntry {
+	accountantID := ""
+	if entry.AccountantID != (common.Address{}) {
+		accountantID = entry.AccountantID.Hex()
+	}
+
+	return ledgerEntry{
+		ID:           entry.ID,
+		Type:         string(entry.Type),
+		Identity:     entry.Identity.Address,
+		AccountantID: accountantID,
+		Amount:       entry.Amount,
+		Fee:          entry.Fee,
+		PromiseHash:  entry.PromiseHash,
+		TxHash:       entry.TxHash,
+		Status:       string(entry.Status),
+		Error:        entry.Error,
+		Created:      entry.Created.Format(time.RFC3339),
+		Updated:      entry.Updated.Format(time.RFC3339),
+	}
+}
+
+// AddRoutesForLedger attaches transaction ledger endpoints to router
+func AddRoutesForLedger(router *httprouter.Router, ledger transactionLedger) {
+	endpoint := NewLedgerEndpoint(ledger)
+	router.GET("/transactor/ledger", endpoint.List)
+	router.GET("/transactor/ledger/csv", endpoint.ExportCSV)
+}
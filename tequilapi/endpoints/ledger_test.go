@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTransactionLedger struct {
+	entries []pingpong.LedgerEntry
+	filter  pingpong.LedgerFilter
+}
+
+func (mtl *mockTransactionLedger) List(filter pingpong.LedgerFilter) ([]pingpong.LedgerEntry, error) {
+	mtl.filter = filter
+	return mtl.entries, nil
+}
+
+var ledgerEntriesMock = []pingpong.LedgerEntry{
+	{
+		ID:          1,
+		Type:        pingpong.LedgerEntrySettlement,
+		Identity:    identity.FromAddress("0x0000000000000000000000000000000000000001"),
+		Amount:      500,
+		Fee:         20,
+		PromiseHash: "aa",
+		TxHash:      "0x01",
+		Status:      pingpong.LedgerEntryConfirmed,
+		Created:     time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC),
+		Updated:     time.Date(2020, 4, 1, 10, 5, 0, 0, time.UTC),
+	},
+}
+
+func Test_Ledger_List(t *testing.T) {
+	ledger := &mockTransactionLedger{entries: ledgerEntriesMock}
+	router := httprouter.New()
+	AddRoutesForLedger(router, ledger)
+
+	req := httptest.NewRequest(http.MethodGet, "/transactor/ledger?identity=0x0000000000000000000000000000000000000001&type=settlement", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "0x0000000000000000000000000000000000000001", ledger.filter.Identity.Address)
+	assert.Equal(t, pingpong.LedgerEntrySettlement, *ledger.filter.Type)
+	assert.JSONEq(t, `{
+		"entries": [{
+			"id": 1,
+			"type": "settlement",
+			"identity": "0x0000000000000000000000000000000000000001",
+			"accountant_id": "",
+			"amount": 500,
+			"fee": 20,
+			"promise_hash": "aa",
+			"tx_hash": "0x01",
+			"status": "confirmed",
+			"created": "2020-04-01T10:00:00Z",
+			"updated": "2020-04-01T10:05:00Z"
+		}]
+	}`, resp.Body.String())
+}
+
+func Test_Ledger_RejectsUnknownType(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForLedger(router, &mockTransactionLedger{})
+
+	req := httptest.NewRequest(http.MethodGet, "/transactor/ledger?type=refund", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.JSONEq(t, `{"message":"unknown ledger entry type \"refund\""}`, resp.Body.String())
+}
+
+func Test_Ledger_ExportCSV(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForLedger(router, &mockTransactionLedger{entries: ledgerEntriesMock})
+
+	req := httptest.NewRequest(http.MethodGet, "/transactor/ledger/csv", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "text/csv", resp.Header().Get("Content-Type"))
+	assert.Equal(t,
+		"id,type,identity,accountant_id,amount,fee,promise_hash,tx_hash,status,error,created,updated\n"+
+			"1,settlement,0x0000000000000000000000000000000000000001,,500,20,aa,0x01,confirmed,,2020-04-01T10:00:00Z,2020-04-01T10:05:00Z\n",
+		resp.Body.String(),
+	)
+}
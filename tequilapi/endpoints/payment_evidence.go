@@ -0,0 +1,189 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/pkg/errors"
+)
+
+// evidenceSessionList defines sessions with recorded payment evidence representable as json
+// swagger:model EvidenceSessionListDTO
+type evidenceSessionList struct {
+	Sessions []evidenceSession `json:"sessions"`
+}
+
+// evidenceSession represents a session with recorded payment evidence
+// swagger:model EvidenceSessionDTO
+type evidenceSession struct {
+	// example: 4cfb0324-daf6-4ad8-448b-e61fe0a1f918
+	SessionID string `json:"session_id"`
+
+	// example: consumer
+	Role string `json:"role"`
+
+	// example: 0x0000000000000000000000000000000000000001
+	ConsumerID string `json:"consumer_id"`
+
+	// example: 0x0000000000000000000000000000000000000002
+	ProviderID string `json:"provider_id"`
+
+	// example: 2020-04-01T10:00:00Z
+	Started string `json:"started"`
+
+	// example: 2020-04-01T10:30:00Z
+	Ended string `json:"ended,omitempty"`
+
+	// example: provider is trying to overcharge
+	Error string `json:"error,omitempty"`
+}
+
+type paymentEvidenceStorage interface {
+	Sessions() ([]pingpong.PaymentEvidenceSession, error)
+	Bundle(sessionID string) (pingpong.EvidenceBundle, error)
+}
+
+type paymentEvidenceEndpoint struct {
+	storage       paymentEvidenceStorage
+	signerFactory identity.SignerFactory
+}
+
+// NewPaymentEvidenceEndpoint creates and returns payment evidence endpoint
+func NewPaymentEvidenceEndpoint(storage paymentEvidenceStorage, signerFactory identity.SignerFactory) *paymentEvidenceEndpoint {
+	return &paymentEvidenceEndpoint{
+		storage:       storage,
+		signerFactory: signerFactory,
+	}
+}
+
+// swagger:operation GET /payments/evidence Payments evidenceSessions
+// ---
+// summary: Lists sessions with payment evidence
+// description: Returns sessions for which invoices and exchange messages were recorded, newest first
+// responses:
+//   200:
+//     description: List of sessions
+//     schema:
+//       "$ref": "#/definitions/EvidenceSessionListDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *paymentEvidenceEndpoint) Sessions(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	sessions, err := endpoint.storage.Sessions()
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	result := evidenceSessionList{Sessions: make([]evidenceSession, len(sessions))}
+	for i, session := range sessions {
+		result.Sessions[i] = evidenceSessionToDto(session)
+	}
+	utils.WriteAsJSON(result, resp)
+}
+
+// swagger:operation GET /payments/evidence/{id} Payments evidenceExport
+// ---
+// summary: Exports payment evidence of the session
+// description: Returns the invoices and exchange messages of the session together with the measured usage, signed by the identity which recorded them
+// parameters:
+// - name: id
+//   in: path
+//   description: Session id
+//   type: string
+//   required: true
+// responses:
+//   200:
+//     description: Signed evidence bundle
+//   404:
+//     description: Session not found
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *paymentEvidenceEndpoint) Export(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	bundle, err := endpoint.storage.Bundle(params.ByName("id"))
+	if err == pingpong.ErrNotFound {
+		utils.SendError(resp, errors.New("no payment evidence for the session"), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := bundle.Sign(endpoint.signerFactory); err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+	utils.WriteAsJSON(bundle, resp)
+}
+
+// swagger:operation POST /payments/evidence/verify Payments evidenceVerify
+// ---
+// summary: Verifies payment evidence
+// description: Checks the bundle signature and replays the payment messages against the agreed payment method to find who deviated from it
+// responses:
+//   200:
+//     description: Verification verdict
+//   400:
+//     description: Bad request
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *paymentEvidenceEndpoint) Verify(resp http.ResponseWriter, request *http.Request, _ httprouter.Params) {
+	var bundle pingpong.EvidenceBundle
+	if err := json.NewDecoder(request.Body).Decode(&bundle); err != nil {
+		utils.SendError(resp, errors.Wrap(err, "could not parse evidence bundle"), http.StatusBadRequest)
+		return
+	}
+
+	utils.WriteAsJSON(bundle.Replay(), resp)
+}
+
+func evidenceSessionToDto(session pingpong.PaymentEvidenceSession) evidenceSession {
+	dto := evidenceSession{
+		SessionID:  session.SessionID,
+		Role:       string(session.Role),
+		ConsumerID: session.ConsumerID,
+		ProviderID: session.ProviderID,
+		Started:    session.Started.Format(time.RFC3339),
+		Error:      session.Error,
+	}
+	if !session.Ended.IsZero() {
+		dto.Ended = session.Ended.Format(time.RFC3339)
+	}
+	return dto
+}
+
+// AddRoutesForPaymentEvidence attaches payment evidence endpoints to router
+func AddRoutesForPaymentEvidence(router *httprouter.Router, storage paymentEvidenceStorage, signerFactory identity.SignerFactory) {
+	endpoint := NewPaymentEvidenceEndpoint(storage, signerFactory)
+	router.GET("/payments/evidence", endpoint.Sessions)
+	router.GET("/payments/evidence/:id", endpoint.Export)
+	router.POST("/payments/evidence/verify", endpoint.Verify)
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPaymentEvidenceStorage struct {
+	sessions []pingpong.PaymentEvidenceSession
+}
+
+func (mpes *mockPaymentEvidenceStorage) Sessions() ([]pingpong.PaymentEvidenceSession, error) {
+	return mpes.sessions, nil
+}
+
+func (mpes *mockPaymentEvidenceStorage) Bundle(sessionID string) (pingpong.EvidenceBundle, error) {
+	for _, session := range mpes.sessions {
+		if session.SessionID == sessionID {
+			return pingpong.EvidenceBundle{Session: session, Records: []pingpong.EvidenceRecord{}}, nil
+		}
+	}
+	return pingpong.EvidenceBundle{}, pingpong.ErrNotFound
+}
+
+var evidenceSessionMock = pingpong.PaymentEvidenceSession{
+	SessionID:  "session1",
+	Role:       pingpong.EvidenceRoleConsumer,
+	ConsumerID: "0x0000000000000000000000000000000000000001",
+	ProviderID: "0x0000000000000000000000000000000000000002",
+	Started:    time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC),
+	Error:      "provider is trying to overcharge",
+}
+
+func mockEvidenceSignerFactory(_ identity.Identity) identity.Signer {
+	return &identity.SignerFake{}
+}
+
+func Test_PaymentEvidence_Sessions(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForPaymentEvidence(router, &mockPaymentEvidenceStorage{sessions: []pingpong.PaymentEvidenceSession{evidenceSessionMock}}, mockEvidenceSignerFactory)
+
+	req := httptest.NewRequest(http.MethodGet, "/payments/evidence", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{
+		"sessions": [{
+			"session_id": "session1",
+			"role": "consumer",
+			"consumer_id": "0x0000000000000000000000000000000000000001",
+			"provider_id": "0x0000000000000000000000000000000000000002",
+			"started": "2020-04-01T10:00:00Z",
+			"error": "provider is trying to overcharge"
+		}]
+	}`, resp.Body.String())
+}
+
+func Test_PaymentEvidence_Export(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForPaymentEvidence(router, &mockPaymentEvidenceStorage{sessions: []pingpong.PaymentEvidenceSession{evidenceSessionMock}}, mockEvidenceSignerFactory)
+
+	req := httptest.NewRequest(http.MethodGet, "/payments/evidence/session1", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"signer":"0x0000000000000000000000000000000000000001"`)
+
+	req = httptest.NewRequest(http.MethodGet, "/payments/evidence/unknown", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func Test_PaymentEvidence_Verify(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForPaymentEvidence(router, &mockPaymentEvidenceStorage{}, mockEvidenceSignerFactory)
+
+	req := httptest.NewRequest(http.MethodPost, "/payments/evidence/verify", strings.NewReader(`{"session": {"session_id": "session1", "role": "consumer"}, "records": []}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"signature_valid":false`)
+
+	req = httptest.NewRequest(http.MethodPost, "/payments/evidence/verify", strings.NewReader(`{`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
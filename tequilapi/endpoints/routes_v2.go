@@ -0,0 +1,147 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	stateEvent "github.com/mysteriumnetwork/node/core/state/event"
+	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/session/pingpong"
+	"github.com/mysteriumnetwork/node/tequilapi/contract"
+	v2 "github.com/mysteriumnetwork/node/tequilapi/v2"
+)
+
+var proposalFilterParams = []v2.Param{
+	{Name: "provider_id", In: "query", Type: "string", Description: "Provider identity to filter the proposals by"},
+	{Name: "service_type", In: "query", Type: "string", Description: "Service type to filter the proposals by"},
+	{Name: "access_policy_id", In: "query", Type: "string", Description: "Access policy id to filter the proposals by"},
+	{Name: "access_policy_source", In: "query", Type: "string", Description: "Access policy source to filter the proposals by"},
+	{Name: "upper_time_price_bound", In: "query", Type: "integer"},
+	{Name: "lower_time_price_bound", In: "query", Type: "integer"},
+	{Name: "upper_gb_price_bound", In: "query", Type: "integer"},
+	{Name: "lower_gb_price_bound", In: "query", Type: "integer"},
+	{Name: "monitoring_failed", In: "query", Type: "boolean", Description: "Include proposals which failed quality monitoring"},
+	{Name: "fetch_metrics", In: "query", Type: "boolean", Description: "Fetch connection success metrics of proposals"},
+}
+
+var connectionSessionFilterParams = []v2.Param{
+	{Name: "date_from", In: "query", Type: "string", Description: "Lists sessions started at or after the date, given as RFC3339 time or YYYY-MM-DD"},
+	{Name: "date_to", In: "query", Type: "string", Description: "Lists sessions started before the date, given as RFC3339 time or YYYY-MM-DD"},
+	{Name: "status", In: "query", Type: "string", Description: "Session status to filter by, New or Completed"},
+	{Name: "service_type", In: "query", Type: "string", Description: "Service type to filter by"},
+}
+
+var connectivityStatusFilterParams = []v2.Param{
+	{Name: "peer_address", In: "query", Type: "string", Description: "Peer identity to filter the statuses by"},
+	{Name: "status_code", In: "query", Type: "integer", Description: "Status code to filter the statuses by"},
+	{Name: "date_from", In: "query", Type: "string", Description: "Lists statuses created at or after the date, given as RFC3339 time or YYYY-MM-DD"},
+	{Name: "date_to", In: "query", Type: "string", Description: "Lists statuses created before the date, given as RFC3339 time or YYYY-MM-DD"},
+}
+
+var ledgerFilterParams = []v2.Param{
+	{Name: "identity", In: "query", Type: "string", Description: "Identity to filter the entries by"},
+	{Name: "type", In: "query", Type: "string", Description: "Entry type to filter by, one of registration, topup or settlement"},
+}
+
+// RoutesV2 lists Tequilapi v2 routes. Every route is served by v1 handler of the same path.
+var RoutesV2 = []v2.Route{
+	{Method: http.MethodGet, Path: "/healthcheck", Tag: "Client", Summary: "Returns health check information", Response: healthCheckData{}},
+
+	{Method: http.MethodPost, Path: TequilapiLoginEndpointPath, Tag: "Authentication", Summary: "Issues a token for the user", Request: loginRequest{}, Response: loginResponse{}},
+	{Method: http.MethodPut, Path: "/auth/password", Tag: "Authentication", Summary: "Changes password of the user", Request: changePasswordRequest{}},
+	{Method: http.MethodGet, Path: "/auth/users", Tag: "Authentication", Summary: "Lists Tequilapi users", Response: userListDTO{}},
+	{Method: http.MethodPost, Path: "/auth/users", Tag: "Authentication", Summary: "Creates Tequilapi user", Request: createUserRequest{}, Response: userDTO{}},
+	{Method: http.MethodDelete, Path: "/auth/users/:username", Tag: "Authentication", Summary: "Deletes Tequilapi user"},
+	{Method: http.MethodGet, Path: "/auth/tokens", Tag: "Authentication", Summary: "Lists API tokens", Response: apiTokenListDTO{}},
+	{Method: http.MethodPost, Path: "/auth/tokens", Tag: "Authentication", Summary: "Creates API token", Request: createAPITokenRequest{}, Response: apiTokenDTO{}},
+	{Method: http.MethodDelete, Path: "/auth/tokens/:id", Tag: "Authentication", Summary: "Revokes API token"},
+
+	{Method: http.MethodGet, Path: "/identities", Tag: "Identity", Summary: "Lists identities", Response: contract.ListIdentitiesResponse{},
+		Paging: &v2.Paging{Field: "identities", KeyFields: []string{"id"}}},
+	{Method: http.MethodPost, Path: "/identities", Tag: "Identity", Summary: "Creates identity", Request: contract.IdentityCreateRequest{}, Response: contract.IdentityRefDTO{}},
+	{Method: http.MethodPut, Path: "/identities/:id", Tag: "Identity", Summary: "Returns current identity when id is \"current\"", Request: contract.IdentityCurrentRequest{}, Response: contract.IdentityRefDTO{}},
+	{Method: http.MethodGet, Path: "/identities/:id", Tag: "Identity", Summary: "Returns identity", Response: contract.IdentityDTO{}},
+	{Method: http.MethodGet, Path: "/identities/:id/status", Tag: "Identity", Summary: "Returns identity status", Response: contract.IdentityDTO{}},
+	{Method: http.MethodPut, Path: "/identities/:id/unlock", Tag: "Identity", Summary: "Unlocks identity", Request: contract.IdentityUnlockRequest{}},
+	{Method: http.MethodGet, Path: "/identities/:id/registration", Tag: "Identity", Summary: "Returns identity registration status", Response: contract.IdentityRegistrationResponse{}},
+	{Method: http.MethodPost, Path: "/identities/:id/register", Tag: "Identity", Summary: "Registers identity", Request: registry.IdentityRegistrationRequestDTO{}},
+	{Method: http.MethodGet, Path: "/identities/:id/payout", Tag: "Identity", Summary: "Returns payout info", Response: payoutInfoResponse{}},
+	{Method: http.MethodPut, Path: "/identities/:id/payout", Tag: "Identity", Summary: "Updates payout info", Request: payoutInfo{}},
+	{Method: http.MethodPut, Path: "/identities/:id/referral", Tag: "Identity", Summary: "Updates referral info", Request: referralInfo{}},
+	{Method: http.MethodPut, Path: "/identities/:id/email", Tag: "Identity", Summary: "Updates email", Request: emailInfo{}},
+
+	{Method: http.MethodGet, Path: "/connection", Tag: "Connection", Summary: "Returns connection status", Response: contract.ConnectionStatusDTO{}},
+	{Method: http.MethodPut, Path: "/connection", Tag: "Connection", Summary: "Starts new connection", Request: contract.ConnectionCreateRequest{}, Response: contract.ConnectionStatusDTO{}},
+	{Method: http.MethodDelete, Path: "/connection", Tag: "Connection", Summary: "Stops current connection"},
+	{Method: http.MethodGet, Path: "/connection/statistics", Tag: "Connection", Summary: "Returns statistics of current connection", Response: contract.ConnectionStatisticsDTO{}},
+	{Method: http.MethodGet, Path: "/connection/dns", Tag: "Connection", Summary: "Returns statistics of the local DNS proxy", Response: contract.ConnectionDNSDTO{}},
+	{Method: http.MethodGet, Path: "/connection/ip", Tag: "Connection", Summary: "Returns current IP address", Response: ipResponse{}},
+	{Method: http.MethodGet, Path: "/connection/location", Tag: "Connection", Summary: "Returns location of current connection", Response: locationResponse{}},
+	{Method: http.MethodGet, Path: "/location", Tag: "Location", Summary: "Returns original location", Response: locationResponse{}},
+	{Method: http.MethodGet, Path: "/connection-sessions", Tag: "Connection", Summary: "Lists connection sessions", Params: connectionSessionFilterParams, Response: connectionSessionsList{},
+		Paging: &v2.Paging{Field: "sessions", KeyFields: []string{"session_id"}}},
+
+	{Method: http.MethodGet, Path: "/proposals", Tag: "Proposal", Summary: "Lists proposals", Params: proposalFilterParams, Response: contract.ListProposalsResponse{},
+		Paging: &v2.Paging{Field: "proposals", KeyFields: []string{"provider_id", "service_type"}}},
+	{Method: http.MethodGet, Path: "/proposals/quality", Tag: "Proposal", Summary: "Returns quality metrics of proposals", Response: contract.ProposalsQualityMetricsResponse{}},
+	{Method: http.MethodGet, Path: "/access-policies", Tag: "Proposal", Summary: "Lists access policies", Response: accessPolicyCollection{}},
+
+	{Method: http.MethodGet, Path: "/services", Tag: "Service", Summary: "Lists running services", Response: serviceList{}},
+	{Method: http.MethodPost, Path: "/services", Tag: "Service", Summary: "Starts service", Request: serviceRequest{}, Response: serviceInfo{}},
+	{Method: http.MethodGet, Path: "/services/:id", Tag: "Service", Summary: "Returns service", Response: serviceInfo{}},
+	{Method: http.MethodDelete, Path: "/services/:id", Tag: "Service", Summary: "Stops service"},
+	{Method: http.MethodGet, Path: "/service-sessions", Tag: "Service", Summary: "Lists service sessions", Response: serviceSessionsList{},
+		Paging: &v2.Paging{Field: "sessions", KeyFields: []string{"id"}}},
+	{Method: http.MethodGet, Path: "/nat/status", Tag: "NAT", Summary: "Returns NAT status", Response: stateEvent.NATStatus{}},
+	{Method: http.MethodGet, Path: "/sessions-connectivity-status", Tag: "Service", Summary: "Lists connectivity status of sessions", Response: sessionConnectivityStatusCollection{},
+		Params: append(connectivityStatusFilterParams, v2.Param{Name: "limit", In: "query", Type: "integer", Description: "Maximum number of statuses to return"})},
+	{Method: http.MethodGet, Path: "/sessions-connectivity-status/stats", Tag: "Service", Summary: "Counts connectivity statuses per peer and status code", Response: sessionConnectivityStatsCollection{},
+		Params: connectivityStatusFilterParams},
+	{Method: http.MethodGet, Path: "/sessions-connectivity-status/unreliable-providers", Tag: "Connection", Summary: "Lists providers repeatedly failing to connect", Response: unreliableProviderCollection{},
+		Params: append(connectivityStatusFilterParams[2:], v2.Param{Name: "min_failures", In: "query", Type: "integer", Description: "Minimum number of failed connections, 3 by default"})},
+
+	{Method: http.MethodGet, Path: "/transactor/fees", Tag: "Transactor", Summary: "Returns transactor fees", Response: Fees{}},
+	{Method: http.MethodPost, Path: "/transactor/topup", Tag: "Transactor", Summary: "Tops up identity channel", Request: registry.TopUpRequest{}},
+	{Method: http.MethodPost, Path: "/transactor/settle/sync", Tag: "Transactor", Summary: "Settles promises and waits for the result", Request: SettleRequest{}},
+	{Method: http.MethodPost, Path: "/transactor/settle/async", Tag: "Transactor", Summary: "Starts settling promises", Request: SettleRequest{}},
+	{Method: http.MethodGet, Path: "/transactor/settle/history", Tag: "Transactor", Summary: "Returns settlement history", Response: SettlementHistoryDTO{},
+		Params: []v2.Param{{Name: "provider_id", In: "query", Type: "string", Description: "Provider identity to filter the attempts by"}}},
+	{Method: http.MethodGet, Path: "/transactor/ledger", Tag: "Transactor", Summary: "Returns transaction ledger", Response: ledgerEntryList{}, Params: ledgerFilterParams},
+	{Method: http.MethodGet, Path: "/transactor/ledger/csv", Tag: "Transactor", Summary: "Exports transaction ledger as CSV", Params: ledgerFilterParams},
+	{Method: http.MethodGet, Path: "/payments/evidence", Tag: "Payments", Summary: "Lists sessions with payment evidence", Response: evidenceSessionList{}},
+	{Method: http.MethodGet, Path: "/payments/evidence/:id", Tag: "Payments", Summary: "Exports signed payment evidence of the session", Response: pingpong.EvidenceBundle{}},
+	{Method: http.MethodPost, Path: "/payments/evidence/verify", Tag: "Payments", Summary: "Replays payment evidence to find who deviated from the payment method", Request: pingpong.EvidenceBundle{}, Response: pingpong.EvidenceVerdict{}},
+
+	{Method: http.MethodGet, Path: "/storage/backup", Tag: "Storage", Summary: "Streams a consistent snapshot of the node database"},
+
+	{Method: http.MethodGet, Path: "/config/user", Tag: "Configuration", Summary: "Returns user configuration", Response: configPayload{}},
+	{Method: http.MethodPost, Path: "/config/user", Tag: "Configuration", Summary: "Updates user configuration", Request: configPayload{}, Response: configPayload{}},
+	{Method: http.MethodGet, Path: "/config/history", Tag: "Configuration", Summary: "Returns user configuration change history", Response: configHistory{}},
+	{Method: http.MethodPost, Path: "/feedback/issue", Tag: "Feedback", Summary: "Reports an issue", Request: ReportIssueRequest{}, Response: ReportIssueSuccess{}},
+	{Method: http.MethodGet, Path: "/feedback/bundle", Tag: "Feedback", Summary: "Downloads diagnostics bundle", ContentType: "application/zip",
+		Params: []v2.Param{{Name: "mask", In: "query", Type: "boolean", Description: "Mask IP addresses and secrets in the bundle"}}},
+	{Method: http.MethodGet, Path: "/events/state", Tag: "Client", Summary: "Streams node state changes", Stream: true},
+	{Method: http.MethodPost, Path: "/stop", Tag: "Client", Summary: "Stops the node"},
+}
+
+// AddRoutesForV2 mounts Tequilapi v2 routes and OpenAPI document on top of v1 routes registered in the router
+func AddRoutesForV2(router *httprouter.Router, version string) error {
+	return v2.Mount(router, router, v2.Info{Title: "Tequilapi", Version: version}, RoutesV2)
+}
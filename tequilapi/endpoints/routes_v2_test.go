@@ -0,0 +1,49 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	v2 "github.com/mysteriumnetwork/node/tequilapi/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddRoutesForV2_DocumentsAllRoutes(t *testing.T) {
+	router := httprouter.New()
+	assert.NoError(t, AddRoutesForV2(router, "0.0.0-test"))
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, v2.OpenAPIPath, nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var doc v2.Document
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &doc))
+	operations := 0
+	for _, path := range doc.Paths {
+		operations += len(path)
+	}
+	assert.Equal(t, len(RoutesV2), operations)
+	assert.Contains(t, doc.Paths, "/v2/identities/{id}/payout")
+	assert.Contains(t, doc.Components.Schemas, "ProposalDTO")
+	assert.Contains(t, doc.Paths["/v2/feedback/bundle"]["get"].Responses["200"].Content, "application/zip")
+}
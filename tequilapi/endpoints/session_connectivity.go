@@ -19,13 +19,19 @@ package endpoints
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/session/connectivity"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	"github.com/pkg/errors"
 )
 
+// defaultMinFailures is how many failed connections make a provider unreliable when not given.
+const defaultMinFailures = 3
+
 // swagger:model ConnectivityStatus
 type sessionConnectivityStatusCollection struct {
 	Entries []*sessionConnectivityStatus `json:"entries"`
@@ -37,6 +43,31 @@ type sessionConnectivityStatus struct {
 	Code         uint32    `json:"code"`
 	Message      string    `json:"message"`
 	CreatedAtUTC time.Time `json:"created_at_utc"`
+	// Outgoing is true for statuses this node reported as a consumer about the provider
+	Outgoing bool `json:"outgoing"`
+}
+
+// swagger:model ConnectivityStatusStats
+type sessionConnectivityStatsCollection struct {
+	Stats []sessionConnectivityStats `json:"stats"`
+}
+
+type sessionConnectivityStats struct {
+	PeerAddress string `json:"peer_address"`
+	Code        uint32 `json:"code"`
+	Count       int    `json:"count"`
+}
+
+// swagger:model UnreliableProviders
+type unreliableProviderCollection struct {
+	Providers []unreliableProvider `json:"providers"`
+}
+
+type unreliableProvider struct {
+	ProviderID     string    `json:"provider_id"`
+	Failures       int       `json:"failures"`
+	Successes      int       `json:"successes"`
+	LastFailureUTC time.Time `json:"last_failure_utc"`
 }
 
 type sessionConnectivityEndpoint struct {
@@ -46,24 +77,185 @@ type sessionConnectivityEndpoint struct {
 // swagger:operation GET /sessions-connectivity-status ConnectivityStatus
 // ---
 // summary: Returns session connectivity status
-// description: Returns list of session connectivity status
+// description: Returns list of session connectivity status, newest first
+// parameters:
+// - in: query
+//   name: peer_address
+//   description: Peer identity to filter the statuses by
+//   type: string
+// - in: query
+//   name: status_code
+//   description: Status code to filter the statuses by
+//   type: integer
+// - in: query
+//   name: date_from
+//   description: Lists statuses created at or after the date, given as RFC3339 time or YYYY-MM-DD
+//   type: string
+// - in: query
+//   name: date_to
+//   description: Lists statuses created before the date, given as RFC3339 time or YYYY-MM-DD
+//   type: string
+// - in: query
+//   name: limit
+//   description: Maximum number of statuses to return
+//   type: integer
 // responses:
 //   200:
 //     description: List of connectivity statuses
 //     schema:
 //       "$ref": "#/definitions/ConnectivityStatus"
+//   400:
+//     description: Invalid filter
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
 func (e *sessionConnectivityEndpoint) List(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	filter, err := parseStatusFilter(req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	entries, err := e.statusStorage.FindStatusEntries(filter)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
 	r := sessionConnectivityStatusCollection{
 		Entries: []*sessionConnectivityStatus{},
 	}
-
-	for _, entry := range e.statusStorage.GetAllStatusEntries() {
+	for _, entry := range entries {
 		r.Entries = append(r.Entries, &sessionConnectivityStatus{
 			PeerAddress:  entry.PeerID.Address,
 			SessionID:    entry.SessionID,
 			Code:         uint32(entry.StatusCode),
 			Message:      entry.Message,
 			CreatedAtUTC: entry.CreatedAtUTC,
+			Outgoing:     entry.Outgoing,
+		})
+	}
+
+	utils.WriteAsJSON(r, resp)
+}
+
+// swagger:operation GET /sessions-connectivity-status/stats ConnectivityStatus
+// ---
+// summary: Returns session connectivity status counts
+// description: Returns number of connectivity statuses per peer and status code
+// parameters:
+// - in: query
+//   name: peer_address
+//   description: Peer identity to filter the statuses by
+//   type: string
+// - in: query
+//   name: status_code
+//   description: Status code to filter the statuses by
+//   type: integer
+// - in: query
+//   name: date_from
+//   description: Counts statuses created at or after the date, given as RFC3339 time or YYYY-MM-DD
+//   type: string
+// - in: query
+//   name: date_to
+//   description: Counts statuses created before the date, given as RFC3339 time or YYYY-MM-DD
+//   type: string
+// responses:
+//   200:
+//     description: Connectivity status counts
+//     schema:
+//       "$ref": "#/definitions/ConnectivityStatusStats"
+//   400:
+//     description: Invalid filter
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (e *sessionConnectivityEndpoint) Stats(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	filter, err := parseStatusFilter(req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	stats, err := e.statusStorage.GetStatusStats(filter)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	r := sessionConnectivityStatsCollection{Stats: []sessionConnectivityStats{}}
+	for _, s := range stats {
+		r.Stats = append(r.Stats, sessionConnectivityStats{
+			PeerAddress: s.PeerID.Address,
+			Code:        uint32(s.StatusCode),
+			Count:       s.Count,
+		})
+	}
+
+	utils.WriteAsJSON(r, resp)
+}
+
+// swagger:operation GET /sessions-connectivity-status/unreliable-providers ConnectivityStatus
+// ---
+// summary: Returns unreliable providers
+// description: Returns providers this node repeatedly failed to get a working connection to, e.g. to exclude them from discovery
+// parameters:
+// - in: query
+//   name: min_failures
+//   description: Minimum number of failed connections, 3 by default
+//   type: integer
+// - in: query
+//   name: date_from
+//   description: Counts statuses created at or after the date, given as RFC3339 time or YYYY-MM-DD
+//   type: string
+// - in: query
+//   name: date_to
+//   description: Counts statuses created before the date, given as RFC3339 time or YYYY-MM-DD
+//   type: string
+// responses:
+//   200:
+//     description: Unreliable providers, the most failing first
+//     schema:
+//       "$ref": "#/definitions/UnreliableProviders"
+//   400:
+//     description: Invalid filter
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (e *sessionConnectivityEndpoint) UnreliableProviders(resp http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	filter, err := parseStatusFilter(req)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+	minFailures, err := parsePositiveInt(req.URL.Query().Get("min_failures"), defaultMinFailures)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusBadRequest)
+		return
+	}
+
+	providers, err := e.statusStorage.GetUnreliableProviders(filter, minFailures)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	r := unreliableProviderCollection{Providers: []unreliableProvider{}}
+	for _, p := range providers {
+		r.Providers = append(r.Providers, unreliableProvider{
+			ProviderID:     p.PeerID.Address,
+			Failures:       p.Failures,
+			Successes:      p.Successes,
+			LastFailureUTC: p.LastFailureUTC,
 		})
 	}
 
@@ -76,4 +268,44 @@ func AddRoutesForConnectivityStatus(router *httprouter.Router, statusStorage con
 		statusStorage: statusStorage,
 	}
 	router.GET("/sessions-connectivity-status", e.List)
+	router.GET("/sessions-connectivity-status/stats", e.Stats)
+	router.GET("/sessions-connectivity-status/unreliable-providers", e.UnreliableProviders)
+}
+
+func parseStatusFilter(req *http.Request) (connectivity.StatusFilter, error) {
+	query := req.URL.Query()
+
+	var filter connectivity.StatusFilter
+	var err error
+	if filter.From, err = parseDateFilter(query.Get("date_from")); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseDateFilter(query.Get("date_to")); err != nil {
+		return filter, err
+	}
+	if filter.Limit, err = parsePositiveInt(query.Get("limit"), 0); err != nil {
+		return filter, err
+	}
+	if value := query.Get("status_code"); value != "" {
+		code, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return filter, errors.Errorf("invalid status code %q", value)
+		}
+		filter.StatusCode = connectivity.StatusCode(code)
+	}
+	if value := query.Get("peer_address"); value != "" {
+		filter.PeerID = identity.FromAddress(value)
+	}
+	return filter, nil
+}
+
+func parsePositiveInt(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 1 {
+		return 0, errors.Errorf("invalid number %q, expected a positive integer", value)
+	}
+	return number, nil
 }
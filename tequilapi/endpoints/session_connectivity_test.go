@@ -0,0 +1,145 @@
+/*
+ * Copyright (C) 2018 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/identity"
+	"github.com/mysteriumnetwork/node/session/connectivity"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SessionConnectivityEndpoint_ListFilters(t *testing.T) {
+	storage := &mockConnectivityStatusStorage{
+		entries: []connectivity.StatusEntry{{
+			PeerID:       identity.FromAddress("0x1"),
+			SessionID:    "s1",
+			StatusCode:   connectivity.StatusSessionIPNotChanged,
+			CreatedAtUTC: time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC),
+			Outgoing:     true,
+		}},
+	}
+	router := httprouter.New()
+	AddRoutesForConnectivityStatus(router, storage)
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions-connectivity-status?peer_address=0x1&status_code=2002&date_from=2020-04-01&limit=10", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"entries":[{"peer_address":"0x1","session_id":"s1","code":2002,"message":"","created_at_utc":"2020-04-01T10:00:00Z","outgoing":true}]}`, resp.Body.String())
+	assert.Equal(t, connectivity.StatusFilter{
+		PeerID:     identity.FromAddress("0x1"),
+		StatusCode: connectivity.StatusSessionIPNotChanged,
+		From:       time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+		Limit:      10,
+	}, storage.filter)
+}
+
+func Test_SessionConnectivityEndpoint_ListRejectsInvalidFilter(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForConnectivityStatus(router, &mockConnectivityStatusStorage{})
+
+	for _, query := range []string{"status_code=ok", "limit=-1", "date_to=yesterday"} {
+		req := httptest.NewRequest(http.MethodGet, "/sessions-connectivity-status?"+query, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code, query)
+	}
+}
+
+func Test_SessionConnectivityEndpoint_Stats(t *testing.T) {
+	storage := &mockConnectivityStatusStorage{
+		stats: []connectivity.StatusStats{{PeerID: identity.FromAddress("0x1"), StatusCode: connectivity.StatusConnectionOk, Count: 4}},
+	}
+	router := httprouter.New()
+	AddRoutesForConnectivityStatus(router, storage)
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions-connectivity-status/stats", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"stats":[{"peer_address":"0x1","code":1000,"count":4}]}`, resp.Body.String())
+}
+
+func Test_SessionConnectivityEndpoint_UnreliableProviders(t *testing.T) {
+	storage := &mockConnectivityStatusStorage{
+		providers: []connectivity.UnreliableProvider{{
+			PeerID:         identity.FromAddress("0x1"),
+			Failures:       5,
+			Successes:      1,
+			LastFailureUTC: time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC),
+		}},
+	}
+	router := httprouter.New()
+	AddRoutesForConnectivityStatus(router, storage)
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions-connectivity-status/unreliable-providers", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"providers":[{"provider_id":"0x1","failures":5,"successes":1,"last_failure_utc":"2020-04-01T10:00:00Z"}]}`, resp.Body.String())
+	assert.Equal(t, defaultMinFailures, storage.minFailures)
+
+	req = httptest.NewRequest(http.MethodGet, "/sessions-connectivity-status/unreliable-providers?min_failures=10", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 10, storage.minFailures)
+}
+
+type mockConnectivityStatusStorage struct {
+	entries   []connectivity.StatusEntry
+	stats     []connectivity.StatusStats
+	providers []connectivity.UnreliableProvider
+
+	filter      connectivity.StatusFilter
+	minFailures int
+}
+
+func (m *mockConnectivityStatusStorage) GetAllStatusEntries() []connectivity.StatusEntry {
+	return m.entries
+}
+
+func (m *mockConnectivityStatusStorage) AddStatusEntry(msg connectivity.StatusEntry) {
+	m.entries = append(m.entries, msg)
+}
+
+func (m *mockConnectivityStatusStorage) FindStatusEntries(filter connectivity.StatusFilter) ([]connectivity.StatusEntry, error) {
+	m.filter = filter
+	return m.entries, nil
+}
+
+func (m *mockConnectivityStatusStorage) GetStatusStats(filter connectivity.StatusFilter) ([]connectivity.StatusStats, error) {
+	m.filter = filter
+	return m.stats, nil
+}
+
+func (m *mockConnectivityStatusStorage) GetUnreliableProviders(filter connectivity.StatusFilter, minFailures int) ([]connectivity.UnreliableProvider, error) {
+	m.filter = filter
+	m.minFailures = minFailures
+	return m.providers, nil
+}
@@ -220,12 +220,13 @@ func mapState(event stateEvent.State) stateRes {
 	identitiesRes := make([]contract.IdentityDTO, len(event.Identities))
 	for idx, identity := range event.Identities {
 		identitiesRes[idx] = contract.IdentityDTO{
-			Address:            identity.Address,
-			RegistrationStatus: identity.RegistrationStatus.String(),
-			ChannelAddress:     identity.ChannelAddress.Hex(),
-			Balance:            identity.Balance,
-			Earnings:           identity.Earnings,
-			EarningsTotal:      identity.EarningsTotal,
+			Address:               identity.Address,
+			RegistrationStatus:    identity.RegistrationStatus.String(),
+			ChannelAddress:        identity.ChannelAddress.Hex(),
+			Balance:               identity.Balance,
+			Earnings:              identity.Earnings,
+			EarningsTotal:         identity.EarningsTotal,
+			EarningsPerAccountant: contract.NewEarningsPerAccountantDTO(identity.EarningsPerAccountant),
 		}
 	}
 
@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rs/zerolog/log"
+)
+
+type storageBackuper interface {
+	Backup(w io.Writer) (int64, error)
+}
+
+type storageEndpoint struct {
+	storage storageBackuper
+}
+
+// NewStorageEndpoint creates and returns storage endpoint
+func NewStorageEndpoint(storage storageBackuper) *storageEndpoint {
+	return &storageEndpoint{
+		storage: storage,
+	}
+}
+
+// swagger:operation GET /storage/backup Storage storageBackup
+// ---
+// summary: Backs up node database
+// description: Streams a consistent snapshot of the node database, it can be restored with "myst db restore" while the node is stopped
+// produces:
+// - application/octet-stream
+// responses:
+//   200:
+//     description: Database snapshot
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (endpoint *storageEndpoint) Backup(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	resp.Header().Set("Content-Type", "application/octet-stream")
+	resp.Header().Set("Content-Disposition", `attachment; filename="myst.db"`)
+	// the status is already sent once the snapshot starts streaming, so failures can only be logged
+	if _, err := endpoint.storage.Backup(resp); err != nil {
+		log.Error().Err(err).Msg("Failed to stream database backup")
+	}
+}
+
+// AddRoutesForStorage attaches storage maintenance endpoints to router
+func AddRoutesForStorage(router *httprouter.Router, storage storageBackuper) {
+	endpoint := NewStorageEndpoint(storage)
+	router.GET("/storage/backup", endpoint.Backup)
+}
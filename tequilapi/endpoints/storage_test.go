@@ -0,0 +1,50 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package endpoints
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStorageBackuper struct {
+	snapshot string
+}
+
+func (msb *mockStorageBackuper) Backup(w io.Writer) (int64, error) {
+	written, err := io.WriteString(w, msb.snapshot)
+	return int64(written), err
+}
+
+func Test_Storage_Backup(t *testing.T) {
+	router := httprouter.New()
+	AddRoutesForStorage(router, &mockStorageBackuper{snapshot: "snapshot"})
+
+	req := httptest.NewRequest(http.MethodGet, "/storage/backup", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/octet-stream", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "snapshot", resp.Body.String())
+}
@@ -20,6 +20,7 @@ package endpoints
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/julienschmidt/httprouter"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/session/pingpong"
 	"github.com/mysteriumnetwork/node/tequilapi/utils"
 )
 
@@ -42,6 +44,7 @@ type Transactor interface {
 // promiseSettler settles the given promises
 type promiseSettler interface {
 	ForceSettle(providerID identity.Identity, accountantID common.Address) error
+	SettlementHistory(filter pingpong.SettlementHistoryFilter) ([]pingpong.SettlementAttempt, error)
 }
 
 type transactorEndpoint struct {
@@ -177,6 +180,77 @@ func (te *transactorEndpoint) settle(request *http.Request, settler func(identit
 	return errors.Wrap(settler(identity.FromAddress(req.ProviderID), common.HexToAddress(req.AccountantID)), "settling failed")
 }
 
+// SettlementAttemptDTO represents a single settlement attempt
+// swagger:model SettlementAttemptDTO
+type SettlementAttemptDTO struct {
+	ID           int64     `json:"id"`
+	ProviderID   string    `json:"provider_id"`
+	AccountantID string    `json:"accountant_id"`
+	Trigger      string    `json:"trigger"`
+	Status       string    `json:"status"`
+	Amount       uint64    `json:"amount"`
+	Fee          uint64    `json:"fee"`
+	Error        string    `json:"error,omitempty"`
+	Created      time.Time `json:"created"`
+	Updated      time.Time `json:"updated"`
+}
+
+// SettlementHistoryDTO represents the list of settlement attempts
+// swagger:model SettlementHistoryDTO
+type SettlementHistoryDTO struct {
+	Attempts []SettlementAttemptDTO `json:"attempts"`
+}
+
+// swagger:operation GET /transactor/settle/history SettlementHistory
+// ---
+// summary: Returns settlement history
+// description: Returns queued, completed and skipped settlement attempts, newest first
+// parameters:
+// - in: query
+//   name: provider_id
+//   description: Provider identity to filter the attempts by
+//   type: string
+// responses:
+//   200:
+//     description: Settlement history
+//     schema:
+//       "$ref": "#/definitions/SettlementHistoryDTO"
+//   500:
+//     description: Internal server error
+//     schema:
+//       "$ref": "#/definitions/ErrorMessageDTO"
+func (te *transactorEndpoint) SettlementHistory(resp http.ResponseWriter, request *http.Request, _ httprouter.Params) {
+	filter := pingpong.SettlementHistoryFilter{}
+	if providerID := request.URL.Query().Get("provider_id"); providerID != "" {
+		id := identity.FromAddress(providerID)
+		filter.ProviderID = &id
+	}
+
+	attempts, err := te.promiseSettler.SettlementHistory(filter)
+	if err != nil {
+		utils.SendError(resp, err, http.StatusInternalServerError)
+		return
+	}
+
+	history := SettlementHistoryDTO{Attempts: []SettlementAttemptDTO{}}
+	for _, attempt := range attempts {
+		history.Attempts = append(history.Attempts, SettlementAttemptDTO{
+			ID:           attempt.ID,
+			ProviderID:   attempt.ProviderID.Address,
+			AccountantID: attempt.AccountantID.Hex(),
+			Trigger:      string(attempt.Trigger),
+			Status:       string(attempt.Status),
+			Amount:       attempt.Amount,
+			Fee:          attempt.Fee,
+			Error:        attempt.Error,
+			Created:      attempt.Created,
+			Updated:      attempt.Updated,
+		})
+	}
+
+	utils.WriteAsJSON(history, resp)
+}
+
 // swagger:operation POST /transactor/topup
 // ---
 // summary: tops up myst to the given identity
@@ -271,4 +345,5 @@ func AddRoutesForTransactor(router *httprouter.Router, transactor Transactor, pr
 	router.POST("/transactor/topup", te.TopUp)
 	router.POST("/transactor/settle/sync", te.SettleSync)
 	router.POST("/transactor/settle/async", te.SettleAsync)
+	router.GET("/transactor/settle/history", te.SettlementHistory)
 }
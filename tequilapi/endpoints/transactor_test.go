@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/julienschmidt/httprouter"
@@ -33,6 +34,7 @@ import (
 
 	"github.com/mysteriumnetwork/node/identity"
 	"github.com/mysteriumnetwork/node/identity/registry"
+	"github.com/mysteriumnetwork/node/session/pingpong"
 )
 
 var identityRegData = `{
@@ -236,6 +238,49 @@ func Test_SettleSync_ReturnsError(t *testing.T) {
 	assert.JSONEq(t, `{"message":"settling failed: explosions everywhere"}`, resp.Body.String())
 }
 
+func Test_SettlementHistory(t *testing.T) {
+	provider := identity.FromAddress("0xbe180c8CA53F280C7BE8669596fF7939d933AA10")
+	settler := &mockSettler{
+		historyToReturn: []pingpong.SettlementAttempt{
+			{
+				ID:           2,
+				ProviderID:   provider,
+				AccountantID: common.HexToAddress("0x0000000000000000000000000000000000000001"),
+				Trigger:      pingpong.SettlementTriggerSchedule,
+				Status:       pingpong.SettlementStatusSkipped,
+				Amount:       100,
+				Fee:          50,
+				Error:        "unsettled earnings 100 do not exceed 3 times the settlement fee 50",
+				Created:      time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC),
+				Updated:      time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	router := httprouter.New()
+	AddRoutesForTransactor(router, nil, settler)
+
+	req := httptest.NewRequest(http.MethodGet, "/transactor/settle/history?provider_id="+provider.Address, nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, &provider, settler.filter.ProviderID)
+	assert.JSONEq(t, `{
+		"attempts": [{
+			"id": 2,
+			"provider_id": "0xbe180c8ca53f280c7be8669596ff7939d933aa10",
+			"accountant_id": "0x0000000000000000000000000000000000000001",
+			"trigger": "schedule",
+			"status": "skipped",
+			"amount": 100,
+			"fee": 50,
+			"error": "unsettled earnings 100 do not exceed 3 times the settlement fee 50",
+			"created": "2020-04-01T10:00:00Z",
+			"updated": "2020-04-01T10:00:00Z"
+		}]
+	}`, resp.Body.String())
+}
+
 func newTestTransactorServer(mockStatus int, mockResponse string) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(mockStatus)
@@ -266,9 +311,16 @@ func (fs *fakeSigner) Sign(message []byte) (identity.Signature, error) {
 }
 
 type mockSettler struct {
-	errToReturn error
+	errToReturn     error
+	historyToReturn []pingpong.SettlementAttempt
+	filter          pingpong.SettlementHistoryFilter
 }
 
 func (ms *mockSettler) ForceSettle(_ identity.Identity, _ common.Address) error {
 	return ms.errToReturn
 }
+
+func (ms *mockSettler) SettlementHistory(filter pingpong.SettlementHistoryFilter) ([]pingpong.SettlementAttempt, error) {
+	ms.filter = filter
+	return ms.historyToReturn, ms.errToReturn
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package tequilapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/core/auth"
+	"github.com/mysteriumnetwork/node/tequilapi/utils"
+	v2 "github.com/mysteriumnetwork/node/tequilapi/v2"
+)
+
+// scopePublic marks routes which are accessible without authentication
+const scopePublic auth.Scope = ""
+
+// RouteScope defines a scope required to access routes matching the given method and path pattern.
+// Path segments starting with ':' match any single segment, trailing '*' matches the rest of the path.
+// Empty method matches any method.
+type RouteScope struct {
+	Method string
+	Path   string
+	Scope  auth.Scope
+}
+
+// DefaultRouteScopes lists Tequilapi route scopes, the first matching rule wins.
+// Routes not matched by any rule require admin scope.
+var DefaultRouteScopes = []RouteScope{
+	{Method: http.MethodGet, Path: "/healthcheck", Scope: scopePublic},
+	{Method: http.MethodPost, Path: "/auth/login", Scope: scopePublic},
+	{Method: http.MethodPut, Path: "/auth/password", Scope: auth.ScopeRead},
+	{Path: "/auth/*", Scope: auth.ScopeAdmin},
+	{Path: "/debug/*", Scope: auth.ScopeAdmin},
+	{Path: "/storage/*", Scope: auth.ScopeAdmin},
+	{Method: http.MethodGet, Path: "/feedback/bundle", Scope: auth.ScopeAdmin},
+	{Method: http.MethodPut, Path: "/connection", Scope: auth.ScopeConnect},
+	{Method: http.MethodDelete, Path: "/connection", Scope: auth.ScopeConnect},
+	{Method: http.MethodPost, Path: "/services", Scope: auth.ScopeServices},
+	{Method: http.MethodDelete, Path: "/services/:id", Scope: auth.ScopeServices},
+	{Method: http.MethodPost, Path: "/identities/:id/register", Scope: auth.ScopePayments},
+	{Method: http.MethodPut, Path: "/identities/:id/payout", Scope: auth.ScopePayments},
+	{Method: http.MethodPost, Path: "/transactor/*", Scope: auth.ScopePayments},
+	{Method: http.MethodPost, Path: "/payments/evidence/verify", Scope: auth.ScopeRead},
+	{Method: http.MethodGet, Path: "/*", Scope: auth.ScopeRead},
+}
+
+type tokenAuthenticator interface {
+	Authenticate(token string) (auth.Principal, error)
+}
+
+type authorizationHandler struct {
+	originalHandler http.Handler
+	authenticator   tokenAuthenticator
+	routes          []RouteScope
+}
+
+// ApplyAuthorization wraps original handler by requiring a JWT or an API token with a scope allowing the requested route.
+// Tokens are accepted either as "Authorization: Bearer <token>" header or in the JWT cookie.
+func ApplyAuthorization(original http.Handler, authenticator tokenAuthenticator, routes []RouteScope) http.Handler {
+	return &authorizationHandler{
+		originalHandler: original,
+		authenticator:   authenticator,
+		routes:          routes,
+	}
+}
+
+func (ah *authorizationHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	required := requiredScope(ah.routes, req.Method, v1Path(req.URL.Path))
+	if required == scopePublic || req.Method == http.MethodOptions {
+		ah.originalHandler.ServeHTTP(resp, req)
+		return
+	}
+
+	token := requestToken(req)
+	if token == "" {
+		sendAuthorizationError(resp, req, "missing authentication token", http.StatusUnauthorized)
+		return
+	}
+
+	principal, err := ah.authenticator.Authenticate(token)
+	if err != nil {
+		sendAuthorizationError(resp, req, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if !principal.Scopes.Allows(required) {
+		sendAuthorizationError(resp, req, "scope '"+string(required)+"' is required", http.StatusForbidden)
+		return
+	}
+
+	ah.originalHandler.ServeHTTP(resp, req)
+}
+
+// v1Path maps v2 route path to the v1 path, so both versions share the same scopes
+func v1Path(path string) string {
+	if strings.HasPrefix(path, v2.Prefix+"/") {
+		return strings.TrimPrefix(path, v2.Prefix)
+	}
+	return path
+}
+
+func sendAuthorizationError(resp http.ResponseWriter, req *http.Request, message string, status int) {
+	if strings.HasPrefix(req.URL.Path, v2.Prefix+"/") {
+		code := v2.CodeUnauthorized
+		if status == http.StatusForbidden {
+			code = v2.CodeForbidden
+		}
+		v2.SendError(resp, status, code, message, nil)
+		return
+	}
+	utils.SendErrorMessage(resp, message, status)
+}
+
+func requestToken(req *http.Request) string {
+	header := req.Header.Get("Authorization")
+	if strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+
+	if cookie, err := req.Cookie(auth.JWTCookieName); err == nil {
+		return cookie.Value
+	}
+
+	return ""
+}
+
+func requiredScope(routes []RouteScope, method, path string) auth.Scope {
+	for _, route := range routes {
+		if route.Method != "" && route.Method != method {
+			continue
+		}
+		if matchPath(route.Path, path) {
+			return route.Scope
+		}
+	}
+	return auth.ScopeAdmin
+}
+
+func matchPath(pattern, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, part := range patternParts {
+		if part == "*" {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if !strings.HasPrefix(part, ":") && part != pathParts[i] {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package tequilapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mysteriumnetwork/node/core/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTokenAuthenticator map[string]auth.Scopes
+
+func (m mockTokenAuthenticator) Authenticate(token string) (auth.Principal, error) {
+	scopes, ok := m[token]
+	if !ok {
+		return auth.Principal{}, auth.ErrUnauthorized
+	}
+	return auth.Principal{Username: token, Scopes: scopes}, nil
+}
+
+func TestAuthorization_RequiredScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		scope  auth.Scope
+	}{
+		{http.MethodGet, "/healthcheck", scopePublic},
+		{http.MethodPost, "/auth/login", scopePublic},
+		{http.MethodGet, "/auth/tokens", auth.ScopeAdmin},
+		{http.MethodGet, "/storage/backup", auth.ScopeAdmin},
+		{http.MethodGet, "/feedback/bundle", auth.ScopeAdmin},
+		{http.MethodGet, "/identities/0x1/payout", auth.ScopeRead},
+		{http.MethodPut, "/identities/0x1/payout", auth.ScopePayments},
+		{http.MethodPost, "/transactor/settle/sync", auth.ScopePayments},
+		{http.MethodPut, "/connection", auth.ScopeConnect},
+		{http.MethodDelete, "/services/123", auth.ScopeServices},
+		{http.MethodPost, "/stop", auth.ScopeAdmin},
+		{http.MethodPost, "/config/user", auth.ScopeAdmin},
+		{http.MethodPost, "/payments/evidence/verify", auth.ScopeRead},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.scope, requiredScope(DefaultRouteScopes, test.method, test.path), test.method+" "+test.path)
+	}
+}
+
+func TestAuthorization_EnforcesScopes(t *testing.T) {
+	authenticator := mockTokenAuthenticator{
+		"dashboard": {auth.ScopeRead},
+		"admin":     {auth.ScopeAdmin},
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		header string
+		cookie string
+		status int
+	}{
+		{http.MethodGet, "/healthcheck", "", "", http.StatusOK},
+		{http.MethodGet, "/sessions", "", "", http.StatusUnauthorized},
+		{http.MethodGet, "/sessions", "Bearer unknown", "", http.StatusUnauthorized},
+		{http.MethodGet, "/sessions", "Bearer dashboard", "", http.StatusOK},
+		{http.MethodGet, "/sessions", "", "dashboard", http.StatusOK},
+		{http.MethodPost, "/transactor/settle/sync", "Bearer dashboard", "", http.StatusForbidden},
+		{http.MethodPost, "/transactor/settle/sync", "Bearer admin", "", http.StatusOK},
+		{http.MethodPost, "/v2/auth/login", "", "", http.StatusOK},
+		{http.MethodGet, "/v2/openapi.json", "Bearer dashboard", "", http.StatusOK},
+		{http.MethodPost, "/v2/transactor/settle/sync", "Bearer dashboard", "", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		if test.header != "" {
+			req.Header.Set("Authorization", test.header)
+		}
+		if test.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: auth.JWTCookieName, Value: test.cookie})
+		}
+		resp := httptest.NewRecorder()
+		mock := &mockedHTTPHandler{}
+
+		ApplyAuthorization(mock, authenticator, DefaultRouteScopes).ServeHTTP(resp, req)
+
+		assert.Equal(t, test.status, resp.Code, test.method+" "+test.path)
+		assert.Equal(t, test.status == http.StatusOK, mock.wasCalled)
+	}
+}
+
+func TestAuthorization_UsesV2ErrorEnvelope(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v2/identities", nil)
+	resp := httptest.NewRecorder()
+
+	ApplyAuthorization(&mockedHTTPHandler{}, mockTokenAuthenticator{}, DefaultRouteScopes).ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.JSONEq(t, `{"error":{"code":"unauthorized","message":"missing authentication token"}}`, resp.Body.String())
+}
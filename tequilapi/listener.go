@@ -17,7 +17,17 @@
 
 package tequilapi
 
-import "net"
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
 
 // NewListener returns tequilapi listener.
 func NewListener(network, address string) (net.Listener, error) {
@@ -43,3 +53,146 @@ func (n noopListener) Close() error {
 func (n noopListener) Addr() net.Addr {
 	return nil
 }
+
+// NewTLSListener wraps the given listener to serve TLS connections.
+func NewTLSListener(listener net.Listener, config *tls.Config) net.Listener {
+	return tls.NewListener(listener, config)
+}
+
+// NewUnixListener returns tequilapi listener on unix domain socket with the given file permissions.
+// The socket is created in a private directory and moved into place once its permissions are set,
+// so that it is never reachable with the default permissions.
+func NewUnixListener(path string, mode os.FileMode) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "failed to remove stale Tequilapi socket")
+	}
+
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".tequilapi")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Tequilapi socket directory")
+	}
+	defer os.RemoveAll(dir)
+
+	privatePath := filepath.Join(dir, "sock")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: privatePath, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(privatePath, mode); err != nil {
+		listener.Close()
+		return nil, errors.Wrap(err, "failed to set Tequilapi socket permissions")
+	}
+	if err := os.Rename(privatePath, path); err != nil {
+		listener.Close()
+		return nil, errors.Wrap(err, "failed to move Tequilapi socket into place")
+	}
+	return &unixListener{UnixListener: listener, path: path}, nil
+}
+
+// unixListener removes the socket file on close, as it was created under a different name.
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (ul *unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: ul.path, Net: "unix"}
+}
+
+func (ul *unixListener) Close() error {
+	err := ul.UnixListener.Close()
+	if removeErr := os.Remove(ul.path); removeErr != nil && !os.IsNotExist(removeErr) && err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// NewMultiListener returns a listener accepting connections from all the given listeners.
+// Address of the first listener is reported as listener address.
+// Accept fails only once all the listeners have failed, a failed listener doesn't stop the rest of them.
+func NewMultiListener(listeners ...net.Listener) net.Listener {
+	ml := &multiListener{
+		listeners: listeners,
+		active:    len(listeners),
+		conns:     make(chan acceptResult),
+		closed:    make(chan struct{}),
+	}
+	for _, listener := range listeners {
+		go ml.acceptFrom(listener)
+	}
+	return ml
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+type multiListener struct {
+	listeners []net.Listener
+	lock      sync.Mutex
+	active    int
+	conns     chan acceptResult
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (ml *multiListener) acceptFrom(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		ne, ok := err.(net.Error)
+		failed := err != nil && !(ok && ne.Temporary())
+		if failed && !ml.lastActive() {
+			log.Error().Err(err).Msgf("Tequilapi listener %s failed", listener.Addr())
+			return
+		}
+
+		select {
+		case ml.conns <- acceptResult{conn: conn, err: err}:
+		case <-ml.closed:
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+		if failed {
+			return
+		}
+	}
+}
+
+// lastActive marks one of the listeners as failed and reports whether it was the last active one.
+func (ml *multiListener) lastActive() bool {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+
+	ml.active--
+	return ml.active == 0
+}
+
+func (ml *multiListener) Accept() (net.Conn, error) {
+	select {
+	case result := <-ml.conns:
+		return result.conn, result.err
+	case <-ml.closed:
+		return nil, errors.New("listener closed")
+	}
+}
+
+func (ml *multiListener) Close() error {
+	var err error
+	ml.closeOnce.Do(func() {
+		close(ml.closed)
+		for _, listener := range ml.listeners {
+			if closeErr := listener.Close(); closeErr != nil {
+				err = closeErr
+			}
+		}
+	})
+	return err
+}
+
+func (ml *multiListener) Addr() net.Addr {
+	return ml.listeners[0].Addr()
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package tequilapi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mysteriumnetwork/node/tequilapi/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveHealthcheck(t *testing.T, listener net.Listener) APIServer {
+	server := NewServer(listener, NewAPIRouter(), NewMysteriumCorsPolicy())
+	server.StartServing()
+	return server
+}
+
+func TestListener_ServesOverTLSAndUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tequilapi")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := filepath.Join(dir, "cert", "tequilapi.crt"), filepath.Join(dir, "cert", "tequilapi.key")
+	assert.NoError(t, EnsureSelfSignedCert(certFile, keyFile, []string{"localhost", "127.0.0.1"}))
+	serverTLS, err := NewTLSConfig(certFile, keyFile, "")
+	assert.NoError(t, err)
+
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	socketPath := filepath.Join(dir, "tequilapi.sock")
+	socketListener, err := NewUnixListener(socketPath, 0600)
+	assert.NoError(t, err)
+
+	info, err := os.Stat(socketPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	server := serveHealthcheck(t, NewMultiListener(NewTLSListener(tcpListener, serverTLS), socketListener))
+	defer server.Stop()
+	port := tcpListener.Addr().(*net.TCPAddr).Port
+
+	clientTLS, err := NewLocalClientTLSConfig(certFile, keyFile)
+	assert.NoError(t, err)
+	_, err = client.NewClientWithOptions("127.0.0.1", port, client.Options{TLS: clientTLS}).Healthcheck()
+	assert.NoError(t, err)
+
+	_, err = client.NewClientWithOptions("", 0, client.Options{SocketPath: socketPath}).Healthcheck()
+	assert.NoError(t, err)
+
+	resp, err := http.Get("http://" + tcpListener.Addr().String() + "/healthcheck")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestListener_RequiresClientCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tequilapi")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	assert.NoError(t, EnsureSelfSignedCert(certFile, keyFile, []string{"127.0.0.1"}))
+	caFile, caKeyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	assert.NoError(t, EnsureSelfSignedCert(caFile, caKeyFile, []string{"management"}))
+
+	serverTLS, err := NewTLSConfig(certFile, keyFile, caFile)
+	assert.NoError(t, err)
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := serveHealthcheck(t, NewTLSListener(tcpListener, serverTLS))
+	defer server.Stop()
+	port := tcpListener.Addr().(*net.TCPAddr).Port
+
+	localTLS, err := NewLocalClientTLSConfig(certFile, keyFile)
+	assert.NoError(t, err)
+	_, err = client.NewClientWithOptions("127.0.0.1", port, client.Options{TLS: localTLS}).Healthcheck()
+	assert.NoError(t, err)
+
+	managementTLS, err := NewLocalClientTLSConfig(certFile, keyFile)
+	assert.NoError(t, err)
+	managementCert, err := tls.LoadX509KeyPair(caFile, caKeyFile)
+	assert.NoError(t, err)
+	managementTLS.Certificates = []tls.Certificate{managementCert}
+	_, err = client.NewClientWithOptions("127.0.0.1", port, client.Options{TLS: managementTLS}).Healthcheck()
+	assert.NoError(t, err)
+
+	anonymousTLS, err := NewLocalClientTLSConfig(certFile, keyFile)
+	assert.NoError(t, err)
+	anonymousTLS.Certificates = nil
+	_, err = client.NewClientWithOptions("127.0.0.1", port, client.Options{TLS: anonymousTLS}).Healthcheck()
+	assert.Error(t, err)
+}
+
+func TestListener_RejectsClientCertificatesIssuedWithNodeKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tequilapi")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	assert.NoError(t, EnsureSelfSignedCert(certFile, keyFile, []string{"127.0.0.1"}))
+	caFile, caKeyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	assert.NoError(t, EnsureSelfSignedCert(caFile, caKeyFile, []string{"management"}))
+
+	serverTLS, err := NewTLSConfig(certFile, keyFile, caFile)
+	assert.NoError(t, err)
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := serveHealthcheck(t, NewTLSListener(tcpListener, serverTLS))
+	defer server.Stop()
+	port := tcpListener.Addr().(*net.TCPAddr).Port
+
+	nodeCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	assert.NoError(t, err)
+	nodeCert.Leaf, err = x509.ParseCertificate(nodeCert.Certificate[0])
+	assert.NoError(t, err)
+	assert.False(t, nodeCert.Leaf.IsCA)
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "issued"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	issued, err := x509.CreateCertificate(rand.Reader, &template, nodeCert.Leaf, &clientKey.PublicKey, nodeCert.PrivateKey)
+	assert.NoError(t, err)
+
+	issuedTLS, err := NewLocalClientTLSConfig(certFile, keyFile)
+	assert.NoError(t, err)
+	issuedTLS.Certificates = []tls.Certificate{{Certificate: [][]byte{issued}, PrivateKey: clientKey}}
+	_, err = client.NewClientWithOptions("127.0.0.1", port, client.Options{TLS: issuedTLS}).Healthcheck()
+	assert.Error(t, err)
+}
+
+func TestMultiListener_KeepsAcceptingAfterOneListenerFails(t *testing.T) {
+	failing, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	working, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	listener := NewMultiListener(failing, working)
+	defer listener.Close()
+
+	failing.Close()
+	conn, err := net.Dial("tcp", working.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	accepted, err := listener.Accept()
+	assert.NoError(t, err)
+	accepted.Close()
+
+	working.Close()
+	_, err = listener.Accept()
+	assert.Error(t, err)
+}
+
+func TestUnixListener_CreatesSocketWithPermissions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tequilapi")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "tequilapi.sock")
+	listener, err := NewUnixListener(socketPath, 0660)
+	assert.NoError(t, err)
+	assert.Equal(t, socketPath, listener.Addr().String())
+
+	info, err := os.Stat(socketPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0660), info.Mode().Perm())
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1, "private socket directory should be removed")
+
+	assert.NoError(t, listener.Close())
+	_, err = os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err))
+}
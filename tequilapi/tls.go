@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package tequilapi
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const selfSignedCertValidity = 5 * 365 * 24 * time.Hour
+
+// NewTLSConfig creates server TLS configuration from the given certificate and key.
+// If clientCAFile is given, clients are required to present a certificate signed by one of its CAs.
+// The server certificate itself is always accepted as a client certificate, so that local tools
+// (built-in UI, CLI) holding the node certificate can talk to Tequilapi.
+func NewTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load Tequilapi TLS certificate")
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile == "" {
+		return config, nil
+	}
+
+	pool := x509.NewCertPool()
+	for _, file := range []string{clientCAFile, certFile} {
+		pemBytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read client CA %s", file)
+		}
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, errors.Errorf("no certificates found in %s", file)
+		}
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return config, nil
+}
+
+// NewLocalClientTLSConfig creates client TLS configuration for local tools talking to Tequilapi of the same node.
+// The node certificate is pinned instead of verifying the host name, since local tools connect to the bind address,
+// and it is also presented as a client certificate.
+func NewLocalClientTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load Tequilapi TLS certificate")
+	}
+
+	pinned := cert.Certificate[0]
+	return &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], pinned) {
+				return errors.New("Tequilapi presented unexpected certificate")
+			}
+			return nil
+		},
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// EnsureSelfSignedCert generates a self-signed certificate for the given hosts unless the files already exist.
+// The certificate is a leaf, it can't sign other certificates, so trusting it as a client certificate
+// doesn't trust certificates issued with the node key.
+func EnsureSelfSignedCert(certFile, keyFile string, hosts []string) error {
+	_, certErr := os.Stat(certFile)
+	_, keyErr := os.Stat(keyFile)
+	if certErr == nil && keyErr == nil {
+		if !isCACert(certFile) {
+			return nil
+		}
+		log.Warn().Msgf("Replacing self-signed Tequilapi CA certificate with a leaf certificate: %s", certFile)
+	}
+
+	log.Info().Msgf("Generating self-signed Tequilapi certificate: %s", certFile)
+	if err := os.MkdirAll(filepath.Dir(certFile), 0700); err != nil {
+		return errors.Wrap(err, "failed to create certificate directory")
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate key")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return errors.Wrap(err, "failed to generate serial number")
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Mysterium Node"}, CommonName: "tequilapi"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(selfSignedCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  false,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return errors.Wrap(err, "failed to create certificate")
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal key")
+	}
+
+	if err := writePEM(keyFile, "EC PRIVATE KEY", keyDer, 0600); err != nil {
+		return err
+	}
+	return writePEM(certFile, "CERTIFICATE", der, 0644)
+}
+
+// isCACert reports whether the certificate file holds a CA certificate, as generated by earlier versions.
+func isCACert(certFile string) bool {
+	pemBytes, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return false
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	return err == nil && cert.IsCA
+}
+
+func writePEM(file, blockType string, bytes []byte, mode os.FileMode) error {
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: bytes})
+	return errors.Wrapf(ioutil.WriteFile(file, pemBytes, mode), "failed to write %s", file)
+}
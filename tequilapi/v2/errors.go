@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+)
+
+// Error codes of Tequilapi v2 error envelope
+const (
+	CodeBadRequest       = "bad_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeValidationError  = "validation_error"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "service_unavailable"
+)
+
+// ErrorResponse is the error envelope returned by all Tequilapi v2 routes
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Error describes an error of Tequilapi v2 request
+type Error struct {
+	Code    string                             `json:"code"`
+	Message string                             `json:"message"`
+	Fields  map[string][]validation.FieldError `json:"fields,omitempty"`
+}
+
+// SendError writes error envelope to the response
+func SendError(resp http.ResponseWriter, status int, code, message string, fields map[string][]validation.FieldError) {
+	resp.Header().Set("Content-type", "application/json; charset=utf-8")
+	resp.WriteHeader(status)
+	json.NewEncoder(resp).Encode(ErrorResponse{Error: Error{Code: code, Message: message, Fields: fields}})
+}
+
+// v1Error covers error bodies produced by v1 handlers, including validation errors
+type v1Error struct {
+	Message string                             `json:"message"`
+	Errors  map[string][]validation.FieldError `json:"errors"`
+}
+
+func sendV1Error(resp http.ResponseWriter, status int, body []byte) {
+	var parsed v1Error
+	if err := json.Unmarshal(body, &parsed); err != nil || parsed.Message == "" {
+		parsed.Message = strings.TrimSpace(string(body))
+	}
+	if parsed.Message == "" {
+		parsed.Message = http.StatusText(status)
+	}
+
+	code := codeForStatus(status)
+	if parsed.Message == "validation_error" {
+		code = CodeValidationError
+		parsed.Message = "request validation failed"
+	}
+	SendError(resp, status, code, parsed.Message, parsed.Errors)
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusUnprocessableEntity:
+		return CodeValidationError
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package v2
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// Page describes the returned page of the list
+type Page struct {
+	Limit int `json:"limit"`
+	// NextCursor should be passed as cursor parameter to fetch the next page, it is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type pageRequest struct {
+	limit  int
+	cursor string
+}
+
+var pagingParams = []Param{
+	{Name: "limit", In: "query", Type: "integer", Description: fmt.Sprintf("Maximum number of items to return (default %d, max %d)", defaultPageLimit, maxPageLimit)},
+	{Name: "cursor", In: "query", Type: "string", Description: "Cursor returned as next_cursor by the previous page"},
+}
+
+func parsePageRequest(req *http.Request) (pageRequest, map[string][]validation.FieldError) {
+	page := pageRequest{limit: defaultPageLimit, cursor: req.URL.Query().Get("cursor")}
+	fields := map[string][]validation.FieldError{}
+
+	if value := req.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			fields["limit"] = []validation.FieldError{{Code: "invalid", Message: fmt.Sprintf("limit must be between 1 and %d", maxPageLimit)}}
+		}
+		page.limit = limit
+	}
+	return page, fields
+}
+
+func paginate(body []byte, paging Paging, page pageRequest) ([]byte, error) {
+	var after string
+	if page.cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(page.cursor)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		after = string(decoded)
+	}
+
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, errors.Wrap(err, "failed to parse list response")
+	}
+	var rawItems []json.RawMessage
+	if raw, ok := response[paging.Field]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &rawItems); err != nil {
+			return nil, errors.Wrap(err, "failed to parse list items")
+		}
+	}
+
+	type keyedItem struct {
+		key  string
+		item json.RawMessage
+	}
+	items := make([]keyedItem, 0, len(rawItems))
+	for _, raw := range rawItems {
+		var fields map[string]interface{}
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, errors.Wrap(err, "failed to parse list item")
+		}
+		keyParts := make([]string, len(paging.KeyFields))
+		for i, field := range paging.KeyFields {
+			keyParts[i] = fmt.Sprint(fields[field])
+		}
+		items = append(items, keyedItem{key: strings.Join(keyParts, "/"), item: raw})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].key < items[j].key
+	})
+
+	pageItems := make([]json.RawMessage, 0, page.limit)
+	result := Page{Limit: page.limit}
+	var lastKey string
+	for _, item := range items {
+		if after != "" && item.key <= after {
+			continue
+		}
+		if len(pageItems) == page.limit {
+			result.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+			break
+		}
+		pageItems = append(pageItems, item.item)
+		lastKey = item.key
+	}
+
+	var err error
+	if response[paging.Field], err = json.Marshal(pageItems); err != nil {
+		return nil, err
+	}
+	if response["paging"], err = json.Marshal(result); err != nil {
+		return nil, err
+	}
+	return json.Marshal(response)
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package v2 implements Tequilapi v2: versioned routes backed by v1 handlers,
+// uniform error envelope, cursor pagination of lists and generated OpenAPI 3 document.
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mysteriumnetwork/node/tequilapi/validation"
+	"github.com/pkg/errors"
+)
+
+// Prefix is the path prefix of Tequilapi v2 routes
+const Prefix = "/v2"
+
+// OpenAPIPath is the path of generated OpenAPI document
+const OpenAPIPath = Prefix + "/openapi.json"
+
+// Param describes a path or query parameter of the route
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	// Type is a JSON schema type: string, integer, number or boolean
+	Type     string
+	Required bool
+}
+
+// Paging enables cursor pagination of the list returned by the route
+type Paging struct {
+	// Field is the JSON field holding the list in the response
+	Field string
+	// KeyFields identify list items, items are ordered by them and cursors point to them
+	KeyFields []string
+}
+
+// Route describes Tequilapi v2 route, which is served by v1 handler of the same path
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	Tag     string
+	Params  []Param
+	// Request and Response are zero values of request and response body types, used for documentation
+	Request  interface{}
+	Response interface{}
+	// ContentType documents non JSON response body, e.g. file downloads
+	ContentType string
+	Paging      *Paging
+	// Stream routes are passed through without buffering, e.g. server sent events
+	Stream bool
+}
+
+// Mount registers v2 routes and OpenAPI document in the router. Routes are served by v1Handler with v2 prefix removed.
+func Mount(router *httprouter.Router, v1Handler http.Handler, info Info, routes []Route) error {
+	document, err := json.Marshal(NewDocument(info, routes))
+	if err != nil {
+		return errors.Wrap(err, "failed to generate OpenAPI document")
+	}
+
+	router.GET(OpenAPIPath, func(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		resp.Header().Set("Content-type", "application/json; charset=utf-8")
+		resp.Write(document)
+	})
+	for _, route := range routes {
+		router.Handle(route.Method, Prefix+route.Path, newHandler(v1Handler, route))
+	}
+
+	router.NotFound = envelopeFallback(router.NotFound, http.StatusNotFound)
+	router.MethodNotAllowed = envelopeFallback(router.MethodNotAllowed, http.StatusMethodNotAllowed)
+	return nil
+}
+
+func newHandler(v1Handler http.Handler, route Route) httprouter.Handle {
+	return func(resp http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		v1Req := req.Clone(req.Context())
+		v1Req.URL.Path = strings.TrimPrefix(req.URL.Path, Prefix)
+		v1Req.URL.RawPath = ""
+
+		if route.Stream {
+			v1Handler.ServeHTTP(resp, v1Req)
+			return
+		}
+
+		var page pageRequest
+		if route.Paging != nil {
+			var fields map[string][]validation.FieldError
+			page, fields = parsePageRequest(req)
+			if len(fields) > 0 {
+				SendError(resp, http.StatusUnprocessableEntity, CodeValidationError, "request validation failed", fields)
+				return
+			}
+		}
+
+		recorder := newResponseRecorder()
+		v1Handler.ServeHTTP(recorder, v1Req)
+		if recorder.status >= http.StatusBadRequest {
+			sendV1Error(resp, recorder.status, recorder.body.Bytes())
+			return
+		}
+
+		body := recorder.body.Bytes()
+		if route.Paging != nil && recorder.status == http.StatusOK {
+			paged, err := paginate(body, *route.Paging, page)
+			if err == errInvalidCursor {
+				SendError(resp, http.StatusUnprocessableEntity, CodeValidationError, "request validation failed", map[string][]validation.FieldError{
+					"cursor": {{Code: "invalid", Message: "invalid cursor"}},
+				})
+				return
+			}
+			if err != nil {
+				SendError(resp, http.StatusInternalServerError, CodeInternal, err.Error(), nil)
+				return
+			}
+			body = paged
+		}
+
+		for key, values := range recorder.header {
+			if key != "Content-Length" {
+				resp.Header()[key] = values
+			}
+		}
+		resp.WriteHeader(recorder.status)
+		resp.Write(body)
+	}
+}
+
+func envelopeFallback(original http.Handler, status int) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, Prefix+"/") {
+			SendError(resp, status, codeForStatus(status), http.StatusText(status), nil)
+			return
+		}
+		if original != nil {
+			original.ServeHTTP(resp, req)
+			return
+		}
+		http.Error(resp, http.StatusText(status), status)
+	})
+}
+
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: http.Header{}, status: http.StatusOK}
+}
+
+func (rr *responseRecorder) Header() http.Header {
+	return rr.header
+}
+
+func (rr *responseRecorder) Write(data []byte) (int, error) {
+	return rr.body.Write(data)
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+}
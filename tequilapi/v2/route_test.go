@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2020 The "MysteriumNetwork/node" Authors.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+type testItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type testList struct {
+	Items []testItem `json:"items"`
+}
+
+type testValidationRequest struct {
+	Value *int `json:"value"`
+}
+
+func newTestRouter(t *testing.T) *httprouter.Router {
+	router := httprouter.New()
+	router.GET("/items", func(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		json.NewEncoder(resp).Encode(testList{Items: []testItem{{ID: "c"}, {ID: "a"}, {ID: "b"}}})
+	})
+	router.GET("/items/:id", func(resp http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+		if params.ByName("id") != "a" {
+			resp.WriteHeader(http.StatusNotFound)
+			resp.Write([]byte(`{"message":"item not found"}`))
+			return
+		}
+		json.NewEncoder(resp).Encode(testItem{ID: "a", Name: "first"})
+	})
+	router.POST("/items", func(resp http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		resp.WriteHeader(http.StatusUnprocessableEntity)
+		resp.Write([]byte(`{"message":"validation_error","errors":{"value":[{"code":"required","message":"'value' is required"}]}}`))
+	})
+
+	err := Mount(router, router, Info{Title: "Test", Version: "1.0"}, []Route{
+		{Method: http.MethodGet, Path: "/items", Response: testList{}, Paging: &Paging{Field: "items", KeyFields: []string{"id"}}},
+		{Method: http.MethodGet, Path: "/items/:id", Response: testItem{}},
+		{Method: http.MethodPost, Path: "/items", Request: testValidationRequest{}, Response: testItem{}},
+	})
+	assert.NoError(t, err)
+	return router
+}
+
+func get(router http.Handler, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestMount_ServesV1Handler(t *testing.T) {
+	router := newTestRouter(t)
+
+	resp := get(router, http.MethodGet, "/v2/items/a")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"id":"a","name":"first"}`, resp.Body.String())
+
+	resp = get(router, http.MethodGet, "/items/a")
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestMount_WrapsErrorsInEnvelope(t *testing.T) {
+	router := newTestRouter(t)
+
+	resp := get(router, http.MethodGet, "/v2/items/z")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.JSONEq(t, `{"error":{"code":"not_found","message":"item not found"}}`, resp.Body.String())
+
+	resp = get(router, http.MethodPost, "/v2/items")
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.JSONEq(t, `{"error":{
+		"code":"validation_error",
+		"message":"request validation failed",
+		"fields":{"value":[{"code":"required","message":"'value' is required"}]}
+	}}`, resp.Body.String())
+
+	resp = get(router, http.MethodGet, "/v2/unknown")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.JSONEq(t, `{"error":{"code":"not_found","message":"Not Found"}}`, resp.Body.String())
+
+	resp = get(router, http.MethodGet, "/items/z")
+	assert.JSONEq(t, `{"message":"item not found"}`, resp.Body.String())
+}
+
+func TestMount_PaginatesLists(t *testing.T) {
+	router := newTestRouter(t)
+
+	resp := get(router, http.MethodGet, "/v2/items?limit=2")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var page struct {
+		Items  []testItem `json:"items"`
+		Paging Page       `json:"paging"`
+	}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &page))
+	assert.Equal(t, []testItem{{ID: "a"}, {ID: "b"}}, page.Items)
+	assert.Equal(t, 2, page.Paging.Limit)
+	assert.NotEmpty(t, page.Paging.NextCursor)
+
+	resp = get(router, http.MethodGet, "/v2/items?limit=2&cursor="+page.Paging.NextCursor)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"items":[{"id":"c","name":""}],"paging":{"limit":2}}`, resp.Body.String())
+
+	resp = get(router, http.MethodGet, "/v2/items?limit=0")
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"limit"`)
+
+	resp = get(router, http.MethodGet, "/v2/items?cursor=%25%25")
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"cursor"`)
+}
+
+func TestMount_ServesOpenAPIDocument(t *testing.T) {
+	router := newTestRouter(t)
+
+	resp := get(router, http.MethodGet, OpenAPIPath)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var doc Document
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, Info{Title: "Test", Version: "1.0"}, doc.Info)
+
+	list := doc.Paths["/v2/items"]["get"]
+	assert.Equal(t, "getItems", list.OperationID)
+	assert.Len(t, list.Parameters, 2)
+	assert.Len(t, list.Responses["200"].Content["application/json"].Schema.AllOf, 2)
+	assert.Equal(t, "#/components/schemas/ErrorResponse", list.Responses["default"].Content["application/json"].Schema.Ref)
+
+	item := doc.Paths["/v2/items/{id}"]["get"]
+	assert.Equal(t, []Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}}, item.Parameters)
+	assert.Equal(t, "#/components/schemas/TestItem", item.Responses["200"].Content["application/json"].Schema.Ref)
+
+	assert.Equal(t, &Schema{Type: "object", Properties: map[string]*Schema{
+		"id":   {Type: "string"},
+		"name": {Type: "string"},
+	}}, doc.Components.Schemas["TestItem"])
+	assert.Equal(t, &Schema{Type: "object", Properties: map[string]*Schema{
+		"value": {Type: "integer", Format: "int32", Nullable: true},
+	}}, doc.Components.Schemas["TestValidationRequest"])
+}
@@ -18,6 +18,7 @@
 package ui
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"net/http/httputil"
@@ -31,8 +32,9 @@ import (
 	"github.com/mysteriumnetwork/node/tequilapi/endpoints"
 )
 
-func buildTransport() *http.Transport {
+func buildTransport(tlsConfig *tls.Config) *http.Transport {
 	return &http.Transport{
+		TLSClientConfig: tlsConfig,
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
 			Timeout:   20 * time.Second,
@@ -44,9 +46,13 @@ func buildTransport() *http.Transport {
 }
 
 func buildReverseProxy(bindAddress string, transport *http.Transport, tequilapiPort int) *httputil.ReverseProxy {
+	scheme := "http"
+	if transport.TLSClientConfig != nil {
+		scheme = "https"
+	}
 	proxy := &httputil.ReverseProxy{
 		Director: func(req *http.Request) {
-			req.URL.Scheme = "http"
+			req.URL.Scheme = scheme
 			req.URL.Host = bindAddress + ":" + strconv.Itoa(tequilapiPort)
 			req.URL.Path = strings.Replace(req.URL.Path, tequilapiUrlPrefix, "", 1)
 			req.URL.Path = strings.TrimRight(req.URL.Path, "/")
@@ -67,9 +73,9 @@ func buildReverseProxy(bindAddress string, transport *http.Transport, tequilapiP
 	return proxy
 }
 
-// ReverseTequilapiProxy proxies UIServer requests to the TequilAPI server
-func ReverseTequilapiProxy(bindAddress string, tequilapiPort int, authenticator jwtAuthenticator) gin.HandlerFunc {
-	proxy := buildReverseProxy(bindAddress, buildTransport(), tequilapiPort)
+// ReverseTequilapiProxy proxies UIServer requests to the TequilAPI server, using HTTPS if tlsConfig is given
+func ReverseTequilapiProxy(bindAddress string, tequilapiPort int, tlsConfig *tls.Config, authenticator jwtAuthenticator) gin.HandlerFunc {
+	proxy := buildReverseProxy(bindAddress, buildTransport(tlsConfig), tequilapiPort)
 
 	return func(c *gin.Context) {
 		// skip non Tequilapi routes